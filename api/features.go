@@ -143,6 +143,87 @@ const (
 	// Note: The instruction list is too long to enumerate in godoc.
 	// See https://github.com/WebAssembly/spec/blob/wg-2.0.draft1/proposals/simd/SIMD.md
 	CoreFeatureSIMD
+
+	// CoreFeatureExtendedConst allows `i32.add`, `i32.sub`, `i32.mul`,
+	// `i64.add`, `i64.sub` and `i64.mul` in constant expressions
+	// ("extended-const"), such as global initializers and data or element
+	// segment offsets. This is not included in CoreFeaturesV2.
+	//
+	// See https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+	CoreFeatureExtendedConst
+
+	// CoreFeatureMemory64 allows a memory to be indexed with i64 values
+	// ("memory64"). This is not included in CoreFeaturesV2.
+	//
+	// Here are the notable effects:
+	//   - Memory limits can have the 64-bit index flag.
+	//   - Load and store instructions, `memory.size`, `memory.grow`,
+	//     `memory.fill`, `memory.copy`, `memory.init` and data segment
+	//     offsets use i64 instead of i32 for addresses and sizes.
+	//   - The offset immediate of load and store instructions is a u64.
+	//
+	// Note: This is only supported by the interpreter, and memory is still
+	// limited to 65536 pages (4GiB). Hence, Memory keeps its 32-bit offsets,
+	// which address every byte of a 64-bit memory, too.
+	//
+	// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+	CoreFeatureMemory64
+
+	// CoreFeatureRelaxedSIMD enables vector instructions whose results may
+	// differ by platform ("relaxed-simd"). This requires CoreFeatureSIMD and
+	// is not included in CoreFeaturesV2.
+	//
+	// Here are the instructions, which are encoded after the vector prefix
+	// (0xfd) with sub-opcodes 0x100 to 0x113:
+	//   - `i8x16.relaxed_swizzle`
+	//   - `i32x4.relaxed_trunc_f32x4_s`, `i32x4.relaxed_trunc_f32x4_u`,
+	//     `i32x4.relaxed_trunc_f64x2_s_zero` and `i32x4.relaxed_trunc_f64x2_u_zero`
+	//   - `f32x4.relaxed_madd`, `f32x4.relaxed_nmadd`, `f64x2.relaxed_madd` and
+	//     `f64x2.relaxed_nmadd`
+	//   - `i8x16.relaxed_laneselect`, `i16x8.relaxed_laneselect`,
+	//     `i32x4.relaxed_laneselect` and `i64x2.relaxed_laneselect`
+	//   - `f32x4.relaxed_min`, `f32x4.relaxed_max`, `f64x2.relaxed_min` and
+	//     `f64x2.relaxed_max`
+	//   - `i16x8.relaxed_q15mulr_s`, `i16x8.relaxed_dot_i8x16_i7x16_s` and
+	//     `i32x4.relaxed_dot_i8x16_i7x16_add_s`
+	//
+	// Note: This is only supported by the interpreter. The proposal allows
+	// each of these to return one of a few results. wazero always picks the
+	// same one, which matches the non-relaxed instruction where there is one,
+	// except the madd and nmadd instructions, which are fused (single rounding)
+	// unless wazero.RuntimeConfig WithDeterministicFloats is set.
+	//
+	// See https://github.com/WebAssembly/relaxed-simd/blob/main/proposals/relaxed-simd/Overview.md
+	CoreFeatureRelaxedSIMD
+
+	// CoreFeatureExceptionHandling enables tags and instructions to throw and
+	// catch exceptions ("exception-handling"). This is not included in
+	// CoreFeaturesV2.
+	//
+	// Here are the notable effects:
+	//   - A module can define tags in the tag section, whose type has no
+	//     results. Tags can't be imported or exported, yet.
+	//   - `try`, `catch`, `catch_all`, `throw` and `rethrow` instructions are
+	//     valid. `delegate` is not supported.
+	//
+	// Note: This is only supported by the interpreter, which only catches an
+	// exception thrown in the same function. An exception which isn't caught
+	// in the function which threw it traps, and `rethrow` fails compilation.
+	//
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/legacy/Exceptions.md
+	CoreFeatureExceptionHandling
+
+	// CoreFeatureThreads allows a memory to be shared ("threads"). This is
+	// not included in CoreFeaturesV2.
+	//
+	// The only effect of enabling is that memory limits can have the shared
+	// flag, which requires a max. api.MemoryDefinition IsShared reports it.
+	//
+	// Note: Atomic instructions and `memory.atomic.wait` or `notify` are not
+	// supported, yet, so wazero doesn't share memory between threads.
+	//
+	// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+	CoreFeatureThreads
 )
 
 // SetEnabled enables or disables the feature or group of features.
@@ -207,6 +288,21 @@ func featureName(f CoreFeatures) string {
 	case CoreFeatureSIMD:
 		// match https://github.com/WebAssembly/spec/blob/wg-2.0.draft1/proposals/simd/SIMD.md
 		return "simd"
+	case CoreFeatureExtendedConst:
+		// match https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+		return "extended-const"
+	case CoreFeatureMemory64:
+		// match https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+		return "memory64"
+	case CoreFeatureRelaxedSIMD:
+		// match https://github.com/WebAssembly/relaxed-simd/blob/main/proposals/relaxed-simd/Overview.md
+		return "relaxed-simd"
+	case CoreFeatureExceptionHandling:
+		// match https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/legacy/Exceptions.md
+		return "exception-handling"
+	case CoreFeatureThreads:
+		// match https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+		return "threads"
 	}
 	return ""
 }
@@ -0,0 +1,70 @@
+// Package leb128 encodes and decodes integers in the LEB128 format used by the
+// WebAssembly binary format, for example in instruction immediates.
+//
+// Decoding is strict: it errs on values which overflow the integer type, and
+// on encodings longer than the maximum for it, e.g. more than 5 bytes for a
+// 32-bit integer.
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#integers%E2%91%A4
+package leb128
+
+import (
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// EncodeUint32 returns the unsigned LEB128 encoding of the value.
+func EncodeUint32(value uint32) []byte {
+	return EncodeUint64(uint64(value))
+}
+
+// EncodeUint64 returns the unsigned LEB128 encoding of the value.
+func EncodeUint64(value uint64) []byte {
+	// Copy as small values are encoded into a shared buffer.
+	return append([]byte(nil), leb128.EncodeUint64(value)...)
+}
+
+// EncodeInt32 returns the signed LEB128 encoding of the value.
+func EncodeInt32(value int32) []byte {
+	return leb128.EncodeInt32(value)
+}
+
+// EncodeInt64 returns the signed LEB128 encoding of the value.
+func EncodeInt64(value int64) []byte {
+	return leb128.EncodeInt64(value)
+}
+
+// DecodeUint32 decodes an unsigned LEB128 value at the start of buf,
+// returning it and the count of bytes read.
+//
+// This errs if buf is truncated, or the value overflows 32 bits.
+func DecodeUint32(buf []byte) (value uint32, bytesRead int, err error) {
+	v, n, err := leb128.LoadUint32(buf)
+	return v, int(n), err
+}
+
+// DecodeUint64 decodes an unsigned LEB128 value at the start of buf,
+// returning it and the count of bytes read.
+//
+// This errs if buf is truncated, or the value overflows 64 bits.
+func DecodeUint64(buf []byte) (value uint64, bytesRead int, err error) {
+	v, n, err := leb128.LoadUint64(buf)
+	return v, int(n), err
+}
+
+// DecodeInt32 decodes a signed LEB128 value at the start of buf, returning
+// it and the count of bytes read.
+//
+// This errs if buf is truncated, or the value overflows 32 bits.
+func DecodeInt32(buf []byte) (value int32, bytesRead int, err error) {
+	v, n, err := leb128.LoadInt32(buf)
+	return v, int(n), err
+}
+
+// DecodeInt64 decodes a signed LEB128 value at the start of buf, returning
+// it and the count of bytes read.
+//
+// This errs if buf is truncated, or the value overflows 64 bits.
+func DecodeInt64(buf []byte) (value int64, bytesRead int, err error) {
+	v, n, err := leb128.LoadInt64(buf)
+	return v, int(n), err
+}
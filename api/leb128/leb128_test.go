@@ -0,0 +1,110 @@
+package leb128
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestUint32(t *testing.T) {
+	for _, v := range []uint32{0, 1, 0x7f, 0x80, 0x3fff, 0x4000, math.MaxUint32} {
+		buf := EncodeUint32(v)
+		decoded, n, err := DecodeUint32(append(buf, 0xff)) // trailing bytes aren't read
+		require.NoError(t, err)
+		require.Equal(t, v, decoded)
+		require.Equal(t, len(buf), n)
+	}
+	require.Equal(t, []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, EncodeUint32(math.MaxUint32))
+}
+
+func TestUint64(t *testing.T) {
+	for _, v := range []uint64{0, 1, 0x7f, 0x80, math.MaxUint32, math.MaxUint32 + 1, math.MaxUint64} {
+		buf := EncodeUint64(v)
+		decoded, n, err := DecodeUint64(buf)
+		require.NoError(t, err)
+		require.Equal(t, v, decoded)
+		require.Equal(t, len(buf), n)
+	}
+	require.Equal(t, 10, len(EncodeUint64(math.MaxUint64)))
+}
+
+func TestInt32(t *testing.T) {
+	for _, v := range []int32{0, 1, -1, 63, 64, -64, -65, math.MaxInt32, math.MinInt32} {
+		buf := EncodeInt32(v)
+		decoded, n, err := DecodeInt32(buf)
+		require.NoError(t, err)
+		require.Equal(t, v, decoded)
+		require.Equal(t, len(buf), n)
+	}
+	require.Equal(t, []byte{0x7f}, EncodeInt32(-1))
+	require.Equal(t, []byte{0x80, 0x80, 0x80, 0x80, 0x78}, EncodeInt32(math.MinInt32))
+}
+
+func TestInt64(t *testing.T) {
+	for _, v := range []int64{0, 1, -1, math.MaxInt32 + 1, math.MinInt32 - 1, math.MaxInt64, math.MinInt64} {
+		buf := EncodeInt64(v)
+		decoded, n, err := DecodeInt64(buf)
+		require.NoError(t, err)
+		require.Equal(t, v, decoded)
+		require.Equal(t, len(buf), n)
+	}
+	require.Equal(t, 10, len(EncodeInt64(math.MinInt64)))
+}
+
+// TestEncode_NotShared ensures callers can't corrupt other encodings.
+func TestEncode_NotShared(t *testing.T) {
+	buf := EncodeUint32(1)
+	buf[0] = 2
+	require.Equal(t, []byte{1}, EncodeUint32(1))
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func([]byte) error
+		input  []byte
+	}{
+		{name: "uint32 empty", decode: decodeUint32, input: nil},
+		{name: "uint32 truncated", decode: decodeUint32, input: []byte{0x80}},
+		{name: "uint32 too long", decode: decodeUint32, input: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x00}},
+		{name: "uint32 overflow", decode: decodeUint32, input: []byte{0xff, 0xff, 0xff, 0xff, 0x1f}},
+		{name: "uint64 truncated", decode: decodeUint64, input: []byte{0xff}},
+		{name: "uint64 too long", decode: decodeUint64, input: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}},
+		{name: "uint64 overflow", decode: decodeUint64, input: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02}},
+		{name: "int32 empty", decode: decodeInt32, input: nil},
+		{name: "int32 truncated", decode: decodeInt32, input: []byte{0x80}},
+		{name: "int32 too long", decode: decodeInt32, input: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x00}},
+		{name: "int32 overflow", decode: decodeInt32, input: []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+		{name: "int64 truncated", decode: decodeInt64, input: []byte{0xff}},
+		{name: "int64 too long", decode: decodeInt64, input: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}},
+		{name: "int64 overflow", decode: decodeInt64, input: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			require.Error(t, tc.decode(tc.input))
+		})
+	}
+}
+
+func decodeUint32(buf []byte) (err error) {
+	_, _, err = DecodeUint32(buf)
+	return
+}
+
+func decodeUint64(buf []byte) (err error) {
+	_, _, err = DecodeUint64(buf)
+	return
+}
+
+func decodeInt32(buf []byte) (err error) {
+	_, _, err = DecodeInt32(buf)
+	return
+}
+
+func decodeInt64(buf []byte) (err error) {
+	_, _, err = DecodeInt64(buf)
+	return
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CallVoid is a Function which has no parameters or results. See BindTyped
+type CallVoid func(ctx context.Context) error
+
+// CallI32 is a Function with the signature (i32) -> (i32). See BindTyped
+type CallI32 func(ctx context.Context, a int32) (int32, error)
+
+// CallI32I32 is a Function with the signature (i32, i32) -> (i32). See BindTyped
+type CallI32I32 func(ctx context.Context, a, b int32) (int32, error)
+
+// CallI64 is a Function with the signature (i64) -> (i64). See BindTyped
+type CallI64 func(ctx context.Context, a int64) (int64, error)
+
+// CallI64I64 is a Function with the signature (i64, i64) -> (i64). See BindTyped
+type CallI64I64 func(ctx context.Context, a, b int64) (int64, error)
+
+// CallF32 is a Function with the signature (f32) -> (f32). See BindTyped
+type CallF32 func(ctx context.Context, a float32) (float32, error)
+
+// CallF32F32 is a Function with the signature (f32, f32) -> (f32). See BindTyped
+type CallF32F32 func(ctx context.Context, a, b float32) (float32, error)
+
+// CallF64 is a Function with the signature (f64) -> (f64). See BindTyped
+type CallF64 func(ctx context.Context, a float64) (float64, error)
+
+// CallF64F64 is a Function with the signature (f64, f64) -> (f64). See BindTyped
+type CallF64F64 func(ctx context.Context, a, b float64) (float64, error)
+
+// BindTyped returns a closure which calls the function with Go types instead
+// of encoded parameters and results, or an error if the signature in its
+// Definition isn't supported.
+//
+// The result is one of CallVoid, CallI32, CallI32I32, CallI64, CallI64I64,
+// CallF32, CallF32F32, CallF64 or CallF64F64. Here's an example:
+//
+//	bound, err := api.BindTyped(mod.ExportedFunction("add"))
+//	if err != nil {
+//		return err
+//	}
+//	add, ok := bound.(api.CallF64F64)
+//	if !ok {
+//		return errors.New("unexpected signature")
+//	}
+//	sum, err := add(ctx, 1.5, 2.5)
+//
+// # Notes
+//
+//   - The closure has the same concurrency constraints as Function.Call.
+//   - Parameters and results are encoded with EncodeI32, EncodeF64, etc.
+func BindTyped(fn Function) (interface{}, error) {
+	def := fn.Definition()
+	params, results := def.ParamTypes(), def.ResultTypes()
+	switch signature(params, results) {
+	case "_":
+		return CallVoid(func(ctx context.Context) error {
+			_, err := fn.Call(ctx)
+			return err
+		}), nil
+	case "i32_i32":
+		return CallI32(func(ctx context.Context, a int32) (int32, error) {
+			ret, err := fn.Call(ctx, EncodeI32(a))
+			if err != nil {
+				return 0, err
+			}
+			return int32(ret[0]), nil
+		}), nil
+	case "i32i32_i32":
+		return CallI32I32(func(ctx context.Context, a, b int32) (int32, error) {
+			ret, err := fn.Call(ctx, EncodeI32(a), EncodeI32(b))
+			if err != nil {
+				return 0, err
+			}
+			return int32(ret[0]), nil
+		}), nil
+	case "i64_i64":
+		return CallI64(func(ctx context.Context, a int64) (int64, error) {
+			ret, err := fn.Call(ctx, EncodeI64(a))
+			if err != nil {
+				return 0, err
+			}
+			return int64(ret[0]), nil
+		}), nil
+	case "i64i64_i64":
+		return CallI64I64(func(ctx context.Context, a, b int64) (int64, error) {
+			ret, err := fn.Call(ctx, EncodeI64(a), EncodeI64(b))
+			if err != nil {
+				return 0, err
+			}
+			return int64(ret[0]), nil
+		}), nil
+	case "f32_f32":
+		return CallF32(func(ctx context.Context, a float32) (float32, error) {
+			ret, err := fn.Call(ctx, EncodeF32(a))
+			if err != nil {
+				return 0, err
+			}
+			return DecodeF32(ret[0]), nil
+		}), nil
+	case "f32f32_f32":
+		return CallF32F32(func(ctx context.Context, a, b float32) (float32, error) {
+			ret, err := fn.Call(ctx, EncodeF32(a), EncodeF32(b))
+			if err != nil {
+				return 0, err
+			}
+			return DecodeF32(ret[0]), nil
+		}), nil
+	case "f64_f64":
+		return CallF64(func(ctx context.Context, a float64) (float64, error) {
+			ret, err := fn.Call(ctx, EncodeF64(a))
+			if err != nil {
+				return 0, err
+			}
+			return DecodeF64(ret[0]), nil
+		}), nil
+	case "f64f64_f64":
+		return CallF64F64(func(ctx context.Context, a, b float64) (float64, error) {
+			ret, err := fn.Call(ctx, EncodeF64(a), EncodeF64(b))
+			if err != nil {
+				return 0, err
+			}
+			return DecodeF64(ret[0]), nil
+		}), nil
+	}
+	return nil, fmt.Errorf("unsupported signature for %s: %s", def.DebugName(), signatureString(params, results))
+}
+
+// signature returns a key for the switch in BindTyped, e.g. "i32i32_i32".
+func signature(params, results []ValueType) string {
+	var ret strings.Builder
+	for _, p := range params {
+		ret.WriteString(ValueTypeName(p))
+	}
+	ret.WriteByte('_')
+	for _, r := range results {
+		ret.WriteString(ValueTypeName(r))
+	}
+	return ret.String()
+}
+
+// signatureString returns a human-readable signature for errors, e.g. "(i32,i32) -> (i32)".
+func signatureString(params, results []ValueType) string {
+	return fmt.Sprintf("(%s) -> (%s)", valueTypeNames(params), valueTypeNames(results))
+}
+
+func valueTypeNames(types []ValueType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = ValueTypeName(t)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+func TestBindTyped(t *testing.T) {
+	t.Run("void", func(t *testing.T) {
+		var called bool
+		bound, err := BindTyped(&testFunction{call: func(params []uint64) ([]uint64, error) {
+			called = true
+			require.Zero(t, len(params))
+			return nil, nil
+		}})
+		require.NoError(t, err)
+
+		require.NoError(t, bound.(CallVoid)(testCtx))
+		require.True(t, called)
+	})
+
+	t.Run("i32i32_i32", func(t *testing.T) {
+		bound, err := BindTyped(&testFunction{
+			params:  []ValueType{ValueTypeI32, ValueTypeI32},
+			results: []ValueType{ValueTypeI32},
+			call: func(params []uint64) ([]uint64, error) {
+				return []uint64{EncodeI32(int32(params[0]) + int32(params[1]))}, nil
+			},
+		})
+		require.NoError(t, err)
+
+		ret, err := bound.(CallI32I32)(testCtx, -3, 1)
+		require.NoError(t, err)
+		require.Equal(t, int32(-2), ret)
+	})
+
+	t.Run("i64_i64", func(t *testing.T) {
+		bound, err := BindTyped(&testFunction{
+			params:  []ValueType{ValueTypeI64},
+			results: []ValueType{ValueTypeI64},
+			call: func(params []uint64) ([]uint64, error) {
+				return []uint64{EncodeI64(-int64(params[0]))}, nil
+			},
+		})
+		require.NoError(t, err)
+
+		ret, err := bound.(CallI64)(testCtx, 1<<40)
+		require.NoError(t, err)
+		require.Equal(t, int64(-1<<40), ret)
+	})
+
+	t.Run("f32f32_f32", func(t *testing.T) {
+		bound, err := BindTyped(&testFunction{
+			params:  []ValueType{ValueTypeF32, ValueTypeF32},
+			results: []ValueType{ValueTypeF32},
+			call: func(params []uint64) ([]uint64, error) {
+				return []uint64{EncodeF32(DecodeF32(params[0]) * DecodeF32(params[1]))}, nil
+			},
+		})
+		require.NoError(t, err)
+
+		ret, err := bound.(CallF32F32)(testCtx, 1.5, -2)
+		require.NoError(t, err)
+		require.Equal(t, float32(-3), ret)
+	})
+
+	t.Run("f64_f64", func(t *testing.T) {
+		bound, err := BindTyped(&testFunction{
+			params:  []ValueType{ValueTypeF64},
+			results: []ValueType{ValueTypeF64},
+			call: func(params []uint64) ([]uint64, error) {
+				return []uint64{EncodeF64(DecodeF64(params[0]) / 2)}, nil
+			},
+		})
+		require.NoError(t, err)
+
+		ret, err := bound.(CallF64)(testCtx, 3.5)
+		require.NoError(t, err)
+		require.Equal(t, 1.75, ret)
+	})
+
+	t.Run("call error", func(t *testing.T) {
+		expectedErr := errors.New("ice cream")
+		bound, err := BindTyped(&testFunction{
+			params:  []ValueType{ValueTypeF64, ValueTypeF64},
+			results: []ValueType{ValueTypeF64},
+			call: func([]uint64) ([]uint64, error) {
+				return nil, expectedErr
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = bound.(CallF64F64)(testCtx, 1, 2)
+		require.Equal(t, expectedErr, err)
+	})
+
+	t.Run("unsupported signature", func(t *testing.T) {
+		_, err := BindTyped(&testFunction{
+			params:  []ValueType{ValueTypeI32, ValueTypeF64},
+			results: []ValueType{ValueTypeI32, ValueTypeI32},
+		})
+		require.EqualError(t, err, "unsupported signature for test.fn: (i32,f64) -> (i32,i32)")
+	})
+}
+
+// testFunction implements Function for TestBindTyped.
+type testFunction struct {
+	params, results []ValueType
+	call            func(params []uint64) ([]uint64, error)
+}
+
+// Definition implements the same method as documented on Function.
+func (f *testFunction) Definition() FunctionDefinition {
+	return &testFunctionDefinition{f}
+}
+
+// Call implements the same method as documented on Function.
+func (f *testFunction) Call(_ context.Context, params ...uint64) ([]uint64, error) {
+	return f.call(params)
+}
+
+// CallInPlace implements the same method as documented on Function.
+func (f *testFunction) CallInPlace(_ context.Context, params []uint64) ([]uint64, error) {
+	return f.call(params)
+}
+
+// testFunctionDefinition implements FunctionDefinition for TestBindTyped.
+type testFunctionDefinition struct {
+	*testFunction
+}
+
+func (d *testFunctionDefinition) ModuleName() string             { return "test" }
+func (d *testFunctionDefinition) Index() uint32                  { return 0 }
+func (d *testFunctionDefinition) Import() (string, string, bool) { return "", "", false }
+func (d *testFunctionDefinition) ExportNames() []string          { return []string{"fn"} }
+func (d *testFunctionDefinition) Name() string                   { return "fn" }
+func (d *testFunctionDefinition) DebugName() string              { return "test.fn" }
+func (d *testFunctionDefinition) GoFunction() interface{}        { return nil }
+func (d *testFunctionDefinition) ParamTypes() []ValueType        { return d.params }
+func (d *testFunctionDefinition) ParamNames() []string           { return nil }
+func (d *testFunctionDefinition) ResultTypes() []ValueType       { return d.results }
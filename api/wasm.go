@@ -2,8 +2,10 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"math"
 )
 
@@ -144,10 +146,45 @@ type Module interface {
 	// Memory returns a memory defined in this module or nil if there are none wasn't.
 	Memory() Memory
 
+	// MemoryIndex returns the memory at the given index in this module's
+	// memory index namespace, or nil if the index is out of range. Index zero
+	// is the same as Memory.
+	//
+	// Note: Until "multi-memory" is implemented, a module has at most one
+	// memory, so any index other than zero returns nil.
+	MemoryIndex(index uint32) Memory
+
+	// MemoryStream returns a stream over Memory, positioned at offset zero,
+	// or nil if there is no memory. This allows standard I/O patterns, such
+	// as io.Copy, against guest memory.
+	//
+	// # Notes
+	//
+	//   - Read returns io.EOF at the end of memory. Write returns
+	//     io.ErrShortWrite if it would pass the end, after writing the bytes
+	//     which fit.
+	//   - The stream always accesses the current memory, so remains valid
+	//     after it grows. However, io.SeekEnd is relative to the size at the
+	//     time of the seek, so is invalidated by a later grow.
+	//   - The stream isn't safe for concurrent use, as it has a position.
+	MemoryStream() io.ReadWriteSeeker
+
 	// ExportedFunction returns a function exported from this module or nil if it wasn't.
 	ExportedFunction(name string) Function
 
-	// TODO: Table
+	// ExportedFunctionByIndex returns the exported function at funcIndex in
+	// the function index space of this module, where imported functions come
+	// first, or nil if it is out of range or not exported.
+	//
+	// This is useful when driving a module by a precomputed table of indices,
+	// or when export names are empty and so ambiguous to look up.
+	//
+	// Note: If the function is exported under several names, the first in
+	// the export section is used, e.g. to choose its timeout.
+	ExportedFunctionByIndex(funcIndex uint32) Function
+
+	// ExportedTable returns a table exported from this module or nil if it wasn't.
+	ExportedTable(name string) Table
 
 	// ExportedMemory returns a memory exported from this module or nil if it wasn't.
 	//
@@ -160,6 +197,43 @@ type Module interface {
 	// ExportedGlobal a global exported from this module or nil if it wasn't.
 	ExportedGlobal(name string) Global
 
+	// StackPointer returns the mutable global exported as "__stack_pointer",
+	// or false if it wasn't. Compilers such as clang and rustc use this for
+	// the top of the stack in linear memory, so hosts can read and restore
+	// it, e.g. to unwind and rewind with asyncify.
+	//
+	// Note: Compilers only export this global when asked, e.g. with the
+	// linker flag "--export=__stack_pointer".
+	StackPointer() (MutableGlobal, bool)
+
+	// Initialize calls the "_initialize" function exported by a WASI reactor,
+	// or does nothing if this module doesn't export it. An error is returned
+	// if initialization traps.
+	//
+	// Unlike a command's "_start", which wazero.ModuleConfig calls by default,
+	// this lets callers prepare a module without knowing which kind it is.
+	//
+	// See https://github.com/WebAssembly/WASI/blob/snapshot-01/design/application-abi.md#current-unstable-abi
+	Initialize(ctx context.Context) error
+
+	// InstructionBudget returns the count of instructions this module may
+	// still execute, or false if wazero.ModuleConfig WithInstructionBudget
+	// wasn't set.
+	//
+	// Note: Instruction budgets are interpreter-only, so this is always false
+	// when the runtime uses the compiler. While a call is in progress, this
+	// doesn't include instructions it executed since it last called a
+	// function.
+	InstructionBudget() (remaining uint64, ok bool)
+
+	// HostState returns the value set by wazero.HostModuleBuilder WithState,
+	// or nil if there is none.
+	//
+	// When passed to a host function, this is the state of the module that
+	// defined the function, even though Memory is that of the caller. This
+	// allows host functions to reach their own state without globals.
+	HostState() interface{}
+
 	// CloseWithExitCode releases resources allocated for this Module. Use a non-zero exitCode parameter to indicate a
 	// failure to ExportedFunction callers.
 	//
@@ -219,12 +293,77 @@ type ExportDefinition interface {
 type MemoryDefinition interface {
 	ExportDefinition
 
+	// Name is the module-defined name of the memory, from the "name" custom
+	// section, or empty if there is none. This is not necessarily the same
+	// as its export name.
+	Name() string
+
 	// Min returns the possibly zero initial count of 64KB pages.
 	Min() uint32
 
 	// Max returns the possibly zero max count of 64KB pages, or false if
 	// unbounded.
 	Max() (uint32, bool)
+
+	// IsShared returns true if the memory has the shared flag of the threads
+	// proposal, so host code can decide whether concurrent access is safe.
+	// This is always false unless api.CoreFeatureThreads is enabled.
+	//
+	// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+	IsShared() bool
+
+	// Is64 returns true if the memory is indexed with i64 values, per the
+	// memory64 proposal (api.CoreFeatureMemory64).
+	//
+	// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+	Is64() bool
+}
+
+// RefType is the type of the elements of a table.
+//
+// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/syntax/types.html#reference-types
+type RefType = byte
+
+const (
+	// RefTypeFuncref is the type of tables of functions, such as those used
+	// by call_indirect.
+	RefTypeFuncref RefType = 0x70
+	// RefTypeExternref is the type of tables of opaque host references.
+	RefTypeExternref RefType = 0x6f
+)
+
+// RefTypeName returns the type name of the given RefType as a string.
+func RefTypeName(t RefType) string {
+	switch t {
+	case RefTypeFuncref:
+		return "funcref"
+	case RefTypeExternref:
+		return "externref"
+	}
+	return "unknown"
+}
+
+// TableDefinition is a WebAssembly table defined or imported in a module
+// (wazero.CompiledModule). Units are in elements.
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#tables%E2%91%A0
+type TableDefinition interface {
+	ExportDefinition
+
+	// Name is the module-defined name of the table, from the "name" custom
+	// section, or empty if there is none. This is not necessarily the same
+	// as its export name.
+	Name() string
+
+	// ElemType returns the type of the elements of the table.
+	ElemType() RefType
+
+	// Min returns the possibly zero initial count of elements.
+	Min() uint32
+
+	// Max returns the possibly zero max count of elements, or false if
+	// unbounded.
+	Max() (uint32, bool)
 }
 
 // FunctionDefinition is a WebAssembly function exported in a module
@@ -280,6 +419,85 @@ type FunctionDefinition interface {
 	ResultTypes() []ValueType
 }
 
+// Producer is a language or tool that produced a module, as recorded in its
+// "producers" custom section (wazero.CompiledModule).
+//
+// See https://github.com/WebAssembly/tool-conventions/blob/main/ProducersSection.md
+type Producer struct {
+	// Field is the kind of producer: "language", "processed-by" or "sdk".
+	Field string
+
+	// Name is the name of the language or tool, e.g. "Rust" or "wasm-opt".
+	Name string
+
+	// Version is the possibly empty version of the language or tool.
+	Version string
+}
+
+// DylinkInfo is the memory and table layout a dynamically linked module
+// (e.g. an Emscripten SIDE_MODULE) requires, as recorded in its "dylink.0"
+// custom section (wazero.CompiledModule).
+//
+// See https://github.com/WebAssembly/tool-conventions/blob/main/DynamicLinking.md
+type DylinkInfo struct {
+	// MemorySize is the size in bytes of the memory region the module's data
+	// segments require.
+	MemorySize uint32
+
+	// MemoryAlignment is the required alignment of the memory region, as a
+	// power of two, e.g. 3 means 8-byte aligned.
+	MemoryAlignment uint32
+
+	// TableSize is the count of table elements the module requires.
+	TableSize uint32
+
+	// TableAlignment is the required alignment of the table region, as a
+	// power of two.
+	TableAlignment uint32
+
+	// Needed are the names of the shared libraries this module depends on,
+	// in the order they should be loaded, or nil if there are none.
+	Needed []string
+}
+
+// Export is an entry in the export section of a module
+// (wazero.CompiledModule), which can be of any ExternType.
+//
+// Note: This isn't named ExportDefinition, as that is the interface embedded
+// by FunctionDefinition, MemoryDefinition and TableDefinition. Unlike those,
+// this describes one export by name, so a definition exported twice is two
+// entries.
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#exports%E2%91%A0
+type Export struct {
+	// Name is what the host refers to the exported definition as.
+	Name string
+
+	// Type is the kind of definition exported, e.g. ExternTypeFunc.
+	Type ExternType
+
+	// Index is the position in the index namespace of Type, imports first.
+	// e.g. If ExternTypeFunc, this is a position in the function index
+	// namespace.
+	Index uint32
+}
+
+// FunctionSignature is a WebAssembly function type, as decoded from the type
+// section of a module (wazero.CompiledModule).
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#function-types%E2%91%A0
+type FunctionSignature interface {
+	// Params are the possibly empty sequence of parameter types.
+	//
+	// See ValueType documentation for encoding rules.
+	Params() []ValueType
+
+	// Results are the possibly empty sequence of result types.
+	//
+	// See ValueType documentation for encoding rules.
+	Results() []ValueType
+}
+
 // Function is a WebAssembly function exported from an instantiated module
 // (wazero.Runtime InstantiateModule).
 //
@@ -300,7 +518,42 @@ type Function interface {
 	// Call is not goroutine-safe, therefore it is recommended to create
 	// another Function if you want to invoke the same function concurrently.
 	// On the other hand, sequential invocations of Call is allowed.
+	//
+	// # Notes
+	//
+	//   - params are neither modified nor retained after Call returns, so the
+	//     caller can reuse them.
+	//   - results are freshly allocated on each call, so they don't alias
+	//     params or the results of any other call. Use CallInPlace to avoid
+	//     this allocation.
+	//   - Host functions may call back into wasm, e.g. via the Module passed
+	//     to them, while at most 100 calls are in progress. Beyond that, Call
+	//     returns a "stack overflow" error instead of overflowing the Go stack.
 	Call(ctx context.Context, params ...uint64) ([]uint64, error)
+
+	// CallInPlace is like Call, except results are written into the backing
+	// array of params when its capacity is at least the count of
+	// FunctionDefinition.ResultTypes. Otherwise, results are allocated as in
+	// Call.
+	//
+	// For example, this reuses the same slice for the param and results of a
+	// function with one of each:
+	//
+	//	stack := []uint64{0}
+	//	for i := uint64(0); i < 10; i++ {
+	//		stack[0] = i
+	//		results, err := fn.CallInPlace(ctx, stack)
+	//		// results[0] aliases stack[0]
+	//	}
+	//
+	// # Notes
+	//
+	//   - results alias params, so they are overwritten by subsequent writes
+	//     to params, including the next CallInPlace with the same slice. Copy
+	//     results to retain them.
+	//   - params are not retained after CallInPlace returns, and are only
+	//     overwritten when the call returns without an error.
+	CallInPlace(ctx context.Context, params []uint64) ([]uint64, error)
 }
 
 // GoModuleFunction is a Function implemented in Go instead of a wasm binary.
@@ -378,6 +631,38 @@ func (f GoFunc) Call(ctx context.Context, stack []uint64) {
 	f(ctx, stack)
 }
 
+// Table is a WebAssembly table exported from an instantiated module
+// (wazero.Runtime InstantiateModule), such as the function pointer table of
+// a guest compiled from C.
+//
+// For example, to check the entries of a table, such as a vtable:
+//
+//	entries, _ := module.ExportedTable("__indirect_function_table").Entries(ctx)
+//	if entries[1] == 0 {
+//		// Entry 1 is null!
+//	}
+//
+// Note: This is an interface for decoupling, not third-party implementations.
+// All implementations are in wazero.
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#tables%E2%91%A0
+type Table interface {
+	// Size returns the current count of entries in the table.
+	Size(ctx context.Context) uint32
+
+	// Entries returns a copy of the current entries of the table, or false if
+	// it has none. Later changes to the table, e.g. by table.set, are not
+	// visible in the result, nor do writes to the result change the table.
+	Entries(ctx context.Context) ([]Reference, bool)
+}
+
+// Reference is an entry of a Table, which is zero when null.
+//
+// For a table of externref, this is encoded as with EncodeExternref. For a
+// table of funcref, this is opaque, except that entries are equal when they
+// refer to the same function.
+type Reference uint64
+
 // Global is a WebAssembly 1.0 (20191205) global exported from an instantiated module (wazero.Runtime InstantiateModule).
 //
 // For example, if the value is not mutable, you can read it once:
@@ -413,6 +698,9 @@ type MutableGlobal interface {
 
 	// Set updates the value of this global.
 	//
+	// Modules importing this global share it, so their next global.get sees
+	// v. Engines don't cache global values, even in loops.
+	//
 	// See Global.Type for how to decode this value to a Go type.
 	Set(ctx context.Context, v uint64)
 }
@@ -435,6 +723,33 @@ type Memory interface {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#-hrefsyntax-instr-memorymathsfmemorysize%E2%91%A0
 	Size(context.Context) uint32
 
+	// MaxSize returns the size in bytes the memory can grow to, or false if
+	// the memory has no max. e.g. If MemoryDefinition.Max is 2 pages: 131072
+	//
+	// # Notes
+	//
+	//   - When unbounded, this returns the limit of the runtime, defined by
+	//     wazero.RuntimeConfig WithMemoryLimitPages.
+	//   - The result is capped at math.MaxUint32, as the size of 65536
+	//     pages does not fit in uint32.
+	MaxSize(context.Context) (uint32, bool)
+
+	// MinPages returns the initial count of 64KB pages of this memory
+	// instance. This is a shortcut for host code, which would otherwise use
+	// Definition.
+	//
+	// Unlike Definition, this reflects the instance, not the declaration of
+	// the module. For example, if this memory was imported, the result is the
+	// min of the module that defined it, not the one that imported it.
+	MinPages(context.Context) uint32
+
+	// MaxPages returns the count of 64KB pages this memory can grow to, or
+	// false if it has no max. Like MinPages, this reflects the instance.
+	//
+	// Note: When unbounded, this returns the limit of the runtime, defined by
+	// wazero.RuntimeConfig WithMemoryLimitPages.
+	MaxPages(context.Context) (uint32, bool)
+
 	// Grow increases memory by the delta in pages (65536 bytes per page).
 	// The return val is the previous memory size in pages, or false if the
 	// delta was ignored as it exceeds MemoryDefinition.Max.
@@ -474,6 +789,14 @@ type Memory interface {
 	// See math.Float64bits
 	ReadFloat64Le(ctx context.Context, offset uint32) (float64, bool)
 
+	// ReadPtr reads a pointer of the guest in little-endian encoding from the
+	// underlying buffer at the offset or returns false if out of range. This
+	// avoids hard-coding the pointer size of the guest in host functions.
+	//
+	// The size of a pointer is 4 bytes, unless MemoryDefinition.Is64, which
+	// is the case for wasm64 guests, where it is 8 bytes.
+	ReadPtr(ctx context.Context, offset uint32) (uint64, bool)
+
 	// Read reads byteCount bytes from the underlying buffer at the offset or
 	// returns false if out of range.
 	//
@@ -494,7 +817,7 @@ type Memory interface {
 	//	buf, _ = memory.Read(ctx, offset, byteCount)
 	//	buf[1] = 'a' // writes through to memory, meaning Wasm code see 'a'.
 	//
-	// If you don't intend-write through, make a copy of the returned slice.
+	// If you don't intend-write through, use ReadCopy instead.
 	//
 	// When to refresh Read
 	//
@@ -507,6 +830,79 @@ type Memory interface {
 	// allocated.
 	Read(ctx context.Context, offset, byteCount uint32) ([]byte, bool)
 
+	// ReadCopy is like Read, except it returns a newly allocated copy of the
+	// byteCount bytes starting at the offset, or returns false if out of
+	// range.
+	//
+	// The result is safe to retain and mutate, as it is never shared with
+	// Wasm: writes to it aren't visible to Wasm, and it is unaffected by
+	// later writes from Wasm or memory growth.
+	//
+	// For example:
+	//	buf, _ := memory.ReadCopy(ctx, offset, byteCount)
+	//	buf[1] = 'a' // only changes buf, not memory.
+	ReadCopy(ctx context.Context, offset, byteCount uint32) ([]byte, bool)
+
+	// Reader returns a reader over the byteCount bytes starting at the
+	// offset, or returns false if out of range.
+	//
+	// This is like Read, except the view is read-only, so can be passed to
+	// standard library functions without a copy, e.g. json.NewDecoder.
+	//
+	// For example:
+	//	r, _ := memory.Reader(ctx, offset, byteCount)
+	//	err := json.NewDecoder(r).Decode(&v)
+	//
+	// Note: The reader has the same invalidation rules as Read. Notably, if
+	// Wasm grows its memory, the reader no longer sees updates.
+	Reader(ctx context.Context, offset, byteCount uint32) (*bytes.Reader, bool)
+
+	// WriteTo copies the byteCount bytes starting at the offset to w, in
+	// chunks, returning the count of bytes written. Unlike ReadCopy, this
+	// doesn't allocate, so is suited to streaming large guest output, such as
+	// a buffer the guest returned as (ptr, len).
+	//
+	// For example:
+	//	n, err := memory.WriteTo(ctx, ptr, len, os.Stdout)
+	//
+	// # Notes
+	//
+	//   - If the range is out of memory, the bytes in range are written, then
+	//     an error is returned with the short count.
+	//   - Like Read, w receives views of memory, so must not retain them.
+	WriteTo(ctx context.Context, offset, byteCount uint32, w io.Writer) (int64, error)
+
+	// MustRead is like Read, except it panics with a descriptive message
+	// instead of returning false when out of range.
+	//
+	// Use this in host functions that treat out-of-range access as a bug,
+	// to avoid checking ok at each call site. For example:
+	//	buf := memory.MustRead(ctx, offset, byteCount)
+	//
+	// Note: When called from a host function, the panic is reported to the
+	// caller of the exported function as an error, like any other trap.
+	MustRead(ctx context.Context, offset, byteCount uint32) []byte
+
+	// MustReadByte is like ReadByte, except it panics if out of range.
+	//
+	// See MustRead
+	MustReadByte(ctx context.Context, offset uint32) byte
+
+	// MustReadUint16Le is like ReadUint16Le, except it panics if out of range.
+	//
+	// See MustRead
+	MustReadUint16Le(ctx context.Context, offset uint32) uint16
+
+	// MustReadUint32Le is like ReadUint32Le, except it panics if out of range.
+	//
+	// See MustRead
+	MustReadUint32Le(ctx context.Context, offset uint32) uint32
+
+	// MustReadUint64Le is like ReadUint64Le, except it panics if out of range.
+	//
+	// See MustRead
+	MustReadUint64Le(ctx context.Context, offset uint32) uint64
+
 	// WriteByte writes a single byte to the underlying buffer at the offset in or returns false if out of range.
 	WriteByte(ctx context.Context, offset uint32, v byte) bool
 
@@ -528,6 +924,14 @@ type Memory interface {
 	// false if out of range.
 	WriteUint64Le(ctx context.Context, offset uint32, v uint64) bool
 
+	// WritePtr writes a pointer of the guest in little-endian encoding to the
+	// underlying buffer at the offset or returns false if out of range. The
+	// size of a pointer is the same as ReadPtr.
+	//
+	// Note: When the pointer size is 4 bytes, this returns false if v doesn't
+	// fit in uint32.
+	WritePtr(ctx context.Context, offset uint32, v uint64) bool
+
 	// WriteFloat64Le writes the value in 64 IEEE 754 little-endian encoded bits to the underlying buffer at the offset
 	// or returns false if out of range.
 	//
@@ -539,6 +943,14 @@ type Memory interface {
 
 	// WriteString writes the string to the underlying buffer at the offset or returns false if out of range.
 	WriteString(ctx context.Context, offset uint32, v string) bool
+
+	// WriteStringN writes the string to the underlying buffer at the offset,
+	// returning len(v) and true on success. If out of range, nothing is
+	// written, and this returns the count of bytes that would have fit
+	// (possibly zero) and false.
+	//
+	// This is useful for host code that writes large strings in chunks.
+	WriteStringN(ctx context.Context, offset uint32, v string) (written uint32, ok bool)
 }
 
 // EncodeExternref encodes the input as a ValueTypeExternref.
@@ -54,6 +54,26 @@ func TestValueTypeName(t *testing.T) {
 	}
 }
 
+func TestRefTypeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    RefType
+		expected string
+	}{
+		{"funcref", RefTypeFuncref, "funcref"},
+		{"externref", RefTypeExternref, "externref"},
+		{"unknown", 100, "unknown"},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, RefTypeName(tc.input))
+		})
+	}
+}
+
 func TestEncodeDecodeExternRef(t *testing.T) {
 	for _, v := range []uintptr{
 		0, uintptr(unsafe.Pointer(t)),
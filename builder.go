@@ -107,11 +107,38 @@ type HostFunctionBuilder interface {
 	//		fn = m.ExportedFunction("__read")
 	//		results, err := fn(ctx, offset, byteCount)
 	//	--snip--
+	//
+	// # Struct parameters
+	//
+	// Functions with many parameters can instead take a pointer to a struct,
+	// after the optional context.Context and api.Module. Fields tagged
+	// `wasm:"param"` are set from the parameters, and fields tagged
+	// `wasm:"result"` are the results, each in field order. Use `wasm:"-"` to
+	// ignore a field. Such a function can return an error, which fails the
+	// call.
+	//
+	//	type addArgs struct {
+	//		X   uint32 `wasm:"param"`
+	//		Y   uint32 `wasm:"param"`
+	//		Sum uint32 `wasm:"result"`
+	//	}
+	//
+	//	builder.WithFunc(func(ctx context.Context, args *addArgs) error {
+	//		args.Sum = args.X + args.Y
+	//		return nil
+	//	})
+	//
+	// Compile errs if any field isn't tagged, or has a type that doesn't map
+	// to a WebAssembly numeric value type.
 	WithFunc(interface{}) HostFunctionBuilder
 
 	// WithName defines the optional module-local name of this function, e.g.
 	// "random_get"
 	//
+	// This name is used in stack traces, prefixed by the module name passed to
+	// NewHostModuleBuilder. For example, a function named "myHostFn" in the
+	// module "env" appears as "env.myHostFn(i32) i32".
+	//
 	// Note: This is not required to match the Export name.
 	WithName(name string) HostFunctionBuilder
 
@@ -172,6 +199,66 @@ type HostModuleBuilder interface {
 	// NewFunctionBuilder begins the definition of a host function.
 	NewFunctionBuilder() HostFunctionBuilder
 
+	// WithState sets opaque state returned by api.Module HostState to any
+	// function in this module. For example, a logger or connection pool.
+	//
+	// Here's an example:
+	//
+	//	logString := func(ctx context.Context, m api.Module, offset, byteCount uint32) {
+	//		logger := m.HostState().(*log.Logger)
+	//		buf, _ := m.Memory().Read(ctx, offset, byteCount)
+	//		logger.Println(string(buf))
+	//	}
+	//	_, err := r.NewHostModuleBuilder("env").
+	//		WithState(logger).
+	//		NewFunctionBuilder().WithFunc(logString).Export("log").
+	//		Instantiate(ctx, r)
+	//
+	// Note: The state is shared by all instances of the compiled module, and
+	// is not closed with them.
+	WithState(state interface{}) HostModuleBuilder
+
+	// ExportFunctions exports each Go func in the map under its key, as if
+	// each were defined with HostFunctionBuilder.WithFunc and Export.
+	//
+	// Here's an example:
+	//
+	//	_, err := r.NewHostModuleBuilder("env").
+	//		ExportFunctions(map[string]interface{}{
+	//			"add": func(x, y uint32) uint32 { return x + y },
+	//			"log": logString,
+	//		}).
+	//		Instantiate(ctx, r)
+	//
+	// Note: Errors are deferred until Compile, which reports every invalid
+	// function signature, not just the first.
+	ExportFunctions(nameToGoFunc map[string]interface{}) HostModuleBuilder
+
+	// ExportTable exports a table of function references (funcref) under the
+	// given name, such as an indirect function table imported by a guest. The
+	// table has min entries, and can grow to max unless it is nil.
+	//
+	// Entries are initialized in order, from index zero, by elements. Each is
+	// one of:
+	//   - nil: a null reference.
+	//   - string: the export name of a function defined by this builder.
+	//   - api.Function: a function of an instantiated module, e.g. a guest.
+	//
+	// Here's an example:
+	//
+	//	_, err := r.NewHostModuleBuilder("env").
+	//		NewFunctionBuilder().WithFunc(abort).Export("abort").
+	//		ExportTable("__indirect_function_table", 2, nil, nil, "abort").
+	//		Instantiate(ctx, r)
+	//
+	// # Notes
+	//
+	//   - Errors are deferred until Compile, e.g. when min is greater than max,
+	//     there are more elements than min, or an element is of another type.
+	//   - An api.Function is only valid in the Namespace it was instantiated in,
+	//     and must not be closed while the table is in use.
+	ExportTable(name string, min uint32, max *uint32, elements ...interface{}) HostModuleBuilder
+
 	// Compile returns a CompiledModule that can instantiated in any namespace (Namespace).
 	//
 	// Note: Closing the Namespace has the same effect as closing the result.
@@ -207,6 +294,8 @@ type hostModuleBuilder struct {
 	moduleName   string
 	nameToGoFunc map[string]interface{}
 	funcToNames  map[string][]string
+	nameToTable  map[string]*wasm.HostTable
+	state        interface{}
 }
 
 // NewHostModuleBuilder implements Runtime.NewHostModuleBuilder
@@ -216,6 +305,7 @@ func (r *runtime) NewHostModuleBuilder(moduleName string) HostModuleBuilder {
 		moduleName:   moduleName,
 		nameToGoFunc: map[string]interface{}{},
 		funcToNames:  map[string][]string{},
+		nameToTable:  map[string]*wasm.HostTable{},
 	}
 }
 
@@ -278,7 +368,7 @@ func (h *hostFunctionBuilder) Export(exportName string) HostModuleBuilder {
 		fn.ExportNames = []string{exportName}
 	}
 	h.b.nameToGoFunc[exportName] = h.fn
-	if len(h.paramNames) > 0 {
+	if len(h.paramNames) > 0 || h.name != exportName {
 		h.b.funcToNames[exportName] = append([]string{h.name}, h.paramNames...)
 	}
 	return h.b
@@ -299,14 +389,39 @@ func (b *hostModuleBuilder) NewFunctionBuilder() HostFunctionBuilder {
 	return &hostFunctionBuilder{b: b}
 }
 
+// WithState implements HostModuleBuilder.WithState
+func (b *hostModuleBuilder) WithState(state interface{}) HostModuleBuilder {
+	b.state = state
+	return b
+}
+
+// ExportFunctions implements HostModuleBuilder.ExportFunctions
+func (b *hostModuleBuilder) ExportFunctions(nameToGoFunc map[string]interface{}) HostModuleBuilder {
+	for k, v := range nameToGoFunc {
+		b.nameToGoFunc[k] = v
+	}
+	return b
+}
+
+// ExportTable implements HostModuleBuilder.ExportTable
+func (b *hostModuleBuilder) ExportTable(name string, min uint32, max *uint32, elements ...interface{}) HostModuleBuilder {
+	b.nameToTable[name] = &wasm.HostTable{Min: min, Max: max, Elements: elements}
+	return b
+}
+
 // Compile implements HostModuleBuilder.Compile
 func (b *hostModuleBuilder) Compile(ctx context.Context) (CompiledModule, error) {
-	module, err := wasm.NewHostModule(b.moduleName, b.nameToGoFunc, b.funcToNames, b.r.enabledFeatures)
+	module, err := wasm.NewHostModule(b.moduleName, b.nameToGoFunc, b.funcToNames, b.nameToTable, b.r.enabledFeatures)
 	if err != nil {
 		return nil, err
 	} else if err = module.Validate(b.r.enabledFeatures); err != nil {
 		return nil, err
 	}
+	module.HostState = b.state
+
+	if b.r.strictHostResults {
+		module.EnableStrictHostResults()
+	}
 
 	c := &compiledModule{module: module, compiledEngine: b.r.store.Engine}
 	if c.listeners, err = buildListeners(ctx, b.r, module); err != nil {
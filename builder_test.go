@@ -2,11 +2,13 @@ package wazero
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
 )
 
 // TestNewHostModuleBuilder_Compile only covers a few scenarios to avoid duplicating tests in internal/wasm/host_test.go
@@ -27,6 +29,8 @@ func TestNewHostModuleBuilder_Compile(t *testing.T) {
 		stack[0] = 0
 	})
 
+	zero, three := wasm.Index(0), uint32(3)
+
 	tests := []struct {
 		name     string
 		input    func(Runtime) HostModuleBuilder
@@ -134,6 +138,30 @@ func TestNewHostModuleBuilder_Compile(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ExportFunctions",
+			input: func(r Runtime) HostModuleBuilder {
+				return r.NewHostModuleBuilder("").ExportFunctions(map[string]interface{}{
+					"2": uint64_uint32,
+					"1": uint32_uint32,
+				})
+			},
+			expected: &wasm.Module{
+				TypeSection: []*wasm.FunctionType{
+					{Params: []api.ValueType{i32}, Results: []api.ValueType{i32}},
+					{Params: []api.ValueType{i64}, Results: []api.ValueType{i32}},
+				},
+				FunctionSection: []wasm.Index{0, 1},
+				CodeSection:     []*wasm.Code{wasm.MustParseGoReflectFuncCode(uint32_uint32), wasm.MustParseGoReflectFuncCode(uint64_uint32)},
+				ExportSection: []*wasm.Export{
+					{Name: "1", Type: wasm.ExternTypeFunc, Index: 0},
+					{Name: "2", Type: wasm.ExternTypeFunc, Index: 1},
+				},
+				NameSection: &wasm.NameSection{
+					FunctionNames: wasm.NameMap{{Index: 0, Name: "1"}, {Index: 1, Name: "2"}},
+				},
+			},
+		},
 		{
 			name: "WithGoFunction",
 			input: func(r Runtime) HostModuleBuilder {
@@ -241,6 +269,41 @@ func TestNewHostModuleBuilder_Compile(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ExportTable",
+			input: func(r Runtime) HostModuleBuilder {
+				return r.NewHostModuleBuilder("").
+					NewFunctionBuilder().
+					WithGoFunction(gofunc1, []api.ValueType{i32}, []api.ValueType{i32}).
+					Export("1").
+					ExportTable("table", 2, &three, nil, "1")
+			},
+			expected: &wasm.Module{
+				TypeSection: []*wasm.FunctionType{
+					{Params: []api.ValueType{i32}, Results: []api.ValueType{i32}},
+				},
+				FunctionSection: []wasm.Index{0},
+				CodeSection: []*wasm.Code{
+					{IsHostFunction: true, GoFunc: gofunc1},
+				},
+				TableSection: []*wasm.Table{{Min: 2, Max: &three, Type: wasm.RefTypeFuncref}},
+				ExportSection: []*wasm.Export{
+					{Name: "1", Type: wasm.ExternTypeFunc, Index: 0},
+					{Name: "table", Type: wasm.ExternTypeTable, Index: 0},
+				},
+				ElementSection: []*wasm.ElementSegment{
+					{
+						OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+						Init:       []*wasm.Index{nil, &zero},
+						Type:       wasm.RefTypeFuncref,
+						Mode:       wasm.ElementModeActive,
+					},
+				},
+				NameSection: &wasm.NameSection{
+					FunctionNames: wasm.NameMap{{Index: 0, Name: "1"}},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -289,6 +352,55 @@ func TestNewHostModuleBuilder_Compile_Errors(t *testing.T) {
 	have ()
 	want (i32)`,
 		},
+		{
+			name: "ExportFunctions reports all bad signatures",
+			input: func(rt Runtime) HostModuleBuilder {
+				return rt.NewHostModuleBuilder("env").ExportFunctions(map[string]interface{}{
+					"ok":  func(uint32) {},
+					"bad": func(string) {},
+					"str": "hello",
+				})
+			},
+			expectedErr: `func[env.bad] param[0] is unsupported: string
+func[env.str] kind != func: string`,
+		},
+		{
+			name: "struct param field without a wasm tag",
+			input: func(rt Runtime) HostModuleBuilder {
+				return rt.NewHostModuleBuilder("env").NewFunctionBuilder().
+					WithFunc(func(*struct{ X uint32 }) {}).Export("fn")
+			},
+			expectedErr: `func[env.fn] field X has no wasm tag`,
+		},
+		{
+			name: "ExportTable min over max",
+			input: func(rt Runtime) HostModuleBuilder {
+				max := uint32(1)
+				return rt.NewHostModuleBuilder("env").ExportTable("table", 2, &max)
+			},
+			expectedErr: `table[table] min 2 > max 1`,
+		},
+		{
+			name: "ExportTable more elements than min",
+			input: func(rt Runtime) HostModuleBuilder {
+				return rt.NewHostModuleBuilder("env").ExportTable("table", 1, nil, nil, nil)
+			},
+			expectedErr: `table[table] has 2 elements, but min 1`,
+		},
+		{
+			name: "ExportTable unknown function",
+			input: func(rt Runtime) HostModuleBuilder {
+				return rt.NewHostModuleBuilder("env").ExportTable("table", 1, nil, "fn")
+			},
+			expectedErr: `table[table] element[0]: unknown function "fn"`,
+		},
+		{
+			name: "ExportTable invalid element",
+			input: func(rt Runtime) HostModuleBuilder {
+				return rt.NewHostModuleBuilder("env").ExportTable("table", 1, nil, 1)
+			},
+			expectedErr: `table[table] element[0]: invalid type int`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -301,6 +413,100 @@ func TestNewHostModuleBuilder_Compile_Errors(t *testing.T) {
 	}
 }
 
+// TestNewHostModuleBuilder_StructParams ensures a func with a struct param can be called.
+func TestNewHostModuleBuilder_StructParams(t *testing.T) {
+	type addArgs struct {
+		X   uint32 `wasm:"param"`
+		Y   uint32 `wasm:"param"`
+		Sum uint32 `wasm:"result"`
+	}
+
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	m, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, args *addArgs) error {
+			if args.X == 0 {
+				return errors.New("x is zero")
+			}
+			args.Sum = args.X + args.Y
+			return nil
+		}).Export("add").
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	i32 := api.ValueTypeI32
+	add := m.ExportedFunction("add")
+	require.Equal(t, []api.ValueType{i32, i32}, add.Definition().ParamTypes())
+	require.Equal(t, []api.ValueType{i32}, add.Definition().ResultTypes())
+
+	results, err := add.Call(testCtx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3}, results)
+
+	_, err = add.Call(testCtx, 0, 2)
+	require.Contains(t, err.Error(), "x is zero")
+}
+
+// TestNewHostModuleBuilder_ExportTable ensures a guest can call host and guest
+// functions via a table it imports from a host module.
+func TestNewHostModuleBuilder_ExportTable(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	// Instantiate a guest which exports a function for the table.
+	answer, err := r.InstantiateModuleFromBinary(testCtx, binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeI32Const, 42, wasm.OpcodeEnd}}},
+		ExportSection:   []*wasm.Export{{Name: "answer", Type: wasm.ExternTypeFunc, Index: 0}},
+		NameSection:     &wasm.NameSection{ModuleName: "answer"},
+	}))
+	require.NoError(t, err)
+
+	env, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func() uint32 { return 7 }).Export("seven").
+		ExportTable("table", 3, nil, "seven", answer.ExportedFunction("answer")).
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), env.ExportedTable("table").Size(testCtx))
+
+	// Instantiate a guest which calls the function at the given table index.
+	main, err := r.InstantiateModuleFromBinary(testCtx, binaryformat.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Results: []wasm.ValueType{wasm.ValueTypeI32}},
+			{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}},
+		},
+		ImportSection: []*wasm.Import{{
+			Type: wasm.ExternTypeTable, Module: "env", Name: "table",
+			DescTable: &wasm.Table{Min: 3, Type: wasm.RefTypeFuncref},
+		}},
+		FunctionSection: []wasm.Index{1},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeLocalGet, 0,
+			wasm.OpcodeCallIndirect, 0, 0, // type 0, table 0
+			wasm.OpcodeEnd,
+		}}},
+		ExportSection: []*wasm.Export{{Name: "call", Type: wasm.ExternTypeFunc, Index: 0}},
+		NameSection:   &wasm.NameSection{ModuleName: "main"},
+	}))
+	require.NoError(t, err)
+
+	call := main.ExportedFunction("call")
+	results, err := call.Call(testCtx, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{7}, results)
+
+	results, err = call.Call(testCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+
+	// The last entry is null.
+	_, err = call.Call(testCtx, 2)
+	require.Error(t, err)
+}
+
 // TestNewHostModuleBuilder_Instantiate ensures Runtime.InstantiateModule is called on success.
 func TestNewHostModuleBuilder_Instantiate(t *testing.T) {
 	r := NewRuntime(testCtx)
@@ -315,6 +521,62 @@ func TestNewHostModuleBuilder_Instantiate(t *testing.T) {
 	require.Zero(t, r.(*runtime).store.Engine.CompiledModuleCount())
 }
 
+// TestNewHostModuleBuilder_WithName_Trace ensures the name of a host function
+// in a stack trace is WithName, not the export name.
+func TestNewHostModuleBuilder_WithName_Trace(t *testing.T) {
+	divBy := func(d uint32) uint32 {
+		return 10 / d
+	}
+
+	tests := []struct {
+		name          string
+		fn            func(HostFunctionBuilder) HostFunctionBuilder
+		expectedTrace string
+	}{
+		{
+			name: "WithFunc",
+			fn: func(b HostFunctionBuilder) HostFunctionBuilder {
+				return b.WithFunc(divBy)
+			},
+			expectedTrace: "env.myHostFn(i32) i32",
+		},
+		{
+			name: "WithFunc WithParameterNames",
+			fn: func(b HostFunctionBuilder) HostFunctionBuilder {
+				return b.WithFunc(divBy).WithParameterNames("d")
+			},
+			expectedTrace: "env.myHostFn(i32) i32",
+		},
+		{
+			name: "WithGoFunction",
+			fn: func(b HostFunctionBuilder) HostFunctionBuilder {
+				return b.WithGoFunction(api.GoFunc(func(ctx context.Context, stack []uint64) {
+					stack[0] = uint64(divBy(uint32(stack[0])))
+				}), []api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32})
+			},
+			expectedTrace: "env.myHostFn(i32) i32",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntime(testCtx)
+			defer r.Close(testCtx)
+
+			mod, err := tc.fn(r.NewHostModuleBuilder("env").NewFunctionBuilder()).
+				WithName("myHostFn").Export("div_by").Instantiate(testCtx, r)
+			require.NoError(t, err)
+
+			_, err = mod.ExportedFunction("div_by").Call(testCtx, 0)
+			require.EqualError(t, err, `runtime error: integer divide by zero (recovered by wazero)
+wasm stack trace:
+	`+tc.expectedTrace)
+		})
+	}
+}
+
 // TestNewHostModuleBuilder_Instantiate_Errors ensures errors propagate from Runtime.InstantiateModule
 func TestNewHostModuleBuilder_Instantiate_Errors(t *testing.T) {
 	r := NewRuntime(testCtx)
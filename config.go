@@ -7,6 +7,8 @@ import (
 	"io"
 	"io/fs"
 	"math"
+	"net"
+	"reflect"
 	"time"
 
 	"github.com/tetratelabs/wazero/api"
@@ -67,6 +69,296 @@ type RuntimeConfig interface {
 	//
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#grow-mem
 	WithMemoryCapacityFromMax(memoryCapacityFromMax bool) RuntimeConfig
+
+	// WithInterpreterStackSize preallocates the value stack of each call in
+	// the interpreter to hold the given count of 64-bit values. The default is
+	// zero, which means the stack grows on demand.
+	//
+	// This example avoids re-allocations for calls with deep expression or
+	// recursion stacks:
+	//	rConfig = wazero.NewRuntimeConfigInterpreter().WithInterpreterStackSize(4096)
+	//
+	// # Notes
+	//
+	//   - The stack still grows transparently beyond the initial size.
+	//   - This is ignored by the compiler, which manages its own stack.
+	//   - Setting a negative value will panic.
+	WithInterpreterStackSize(initial int) RuntimeConfig
+
+	// WithMaxInstances limits the count of modules instantiated and not yet
+	// closed in the runtime, across all namespaces. The default is zero, which
+	// means unlimited.
+	//
+	// This example allows at most 100 live modules, such as for a multi-tenant
+	// server:
+	//	rConfig = wazero.NewRuntimeConfig().WithMaxInstances(100)
+	//
+	// # Notes
+	//
+	//   - Instantiating a module errs if it would exceed the limit, until
+	//     another module is closed.
+	//   - Host modules, such as from HostModuleBuilder, count towards the limit.
+	//   - Setting a negative value will panic.
+	WithMaxInstances(n int) RuntimeConfig
+
+	// WithMemoryGrowDeniedHook sets a function invoked when a module's memory
+	// could not grow, because it would exceed the maximum pages. The default
+	// is nil, which means denials are not observable except via the result.
+	//
+	// This example logs guests hitting their memory limit:
+	//	rConfig = wazero.NewRuntimeConfig().WithMemoryGrowDeniedHook(
+	//		func(mod api.Module, requestedPages, currentPages, maxPages uint32) {
+	//			log.Printf("%s: grow by %d denied at %d/%d pages", mod.Name(), requestedPages, currentPages, maxPages)
+	//		})
+	//
+	// # Notes
+	//
+	//   - The hook fires for both the `memory.grow` instruction and
+	//     api.Memory Grow. It doesn't change their result.
+	//   - mod is the module that defines the memory, even if the denied grow
+	//     was from a module that imports it.
+	//   - requestedPages is the delta, not the total pages requested.
+	//   - The hook is called synchronously on the goroutine that grows memory.
+	WithMemoryGrowDeniedHook(hook func(mod api.Module, requestedPages, currentPages, maxPages uint32)) RuntimeConfig
+
+	// WithStripNames drops function and local names decoded from the "name"
+	// custom section, to reduce the memory held by each compiled module. This
+	// is useful for long-lived hosts with many modules.
+	//
+	// This example saves memory in production:
+	//	rConfig = wazero.NewRuntimeConfig().WithStripNames()
+	//
+	// # Notes
+	//
+	//   - This trades debuggability for footprint: stack traces and
+	//     api.FunctionDefinition DebugName use the function index, e.g.
+	//     "env.$1", instead of the name, and Name returns "".
+	//   - Export names are unaffected, as are module names, which are still
+	//     used as the default name of instantiated modules.
+	WithStripNames() RuntimeConfig
+
+	// WithFloatsDisabled rejects modules which use floating point, for
+	// deterministic hosts such as smart-contract platforms.
+	//
+	// Runtime.CompileModule errs, naming the offending function, if a module
+	// declares an f32 or f64 param, result, local or global, or if its code
+	// uses any f32 or f64 instruction. Vector instructions are also rejected,
+	// as many of them operate on floats.
+	//
+	// This example rejects modules using floats:
+	//	rConfig = wazero.NewRuntimeConfig().WithFloatsDisabled()
+	WithFloatsDisabled() RuntimeConfig
+
+	// WithCopyOnWriteMemory maps the memory of each instance copy-on-write
+	// from an image of the module's active data segments, instead of copying
+	// them. This reduces instantiation time and resident memory of modules
+	// with large data segments that are instantiated many times, as pages are
+	// only copied on first write.
+	//
+	// This example instantiates modules copy-on-write:
+	//	rConfig = wazero.NewRuntimeConfig().WithCopyOnWriteMemory()
+	//
+	// # Notes
+	//
+	//   - This is transparent to the guest: memory contents and growth are
+	//     the same as without this option.
+	//   - This is only supported on darwin, linux and freebsd. Elsewhere, or
+	//     when a module's memory is imported, 64-bit, or initialized from
+	//     segments with non-constant offsets, data segments are copied as usual.
+	//   - The image is built on first instantiation and released when the
+	//     CompiledModule is closed. It is backed by an unlinked temporary file.
+	//   - Memory is unmapped once its module, and any module importing from
+	//     it, is closed and no call is in progress. Slices returned by
+	//     api.Memory Read must not be retained beyond that: use api.Memory
+	//     ReadCopy for data that must outlive the module.
+	WithCopyOnWriteMemory() RuntimeConfig
+
+	// WithDeterministicFloats makes float instructions whose results may
+	// differ by platform return the same result everywhere. The default is
+	// false, which allows the fastest choice.
+	//
+	// This example computes relaxed madd instructions without fusing:
+	//	rConfig = wazero.NewRuntimeConfigInterpreter().
+	//		WithCoreFeatures(api.CoreFeaturesV2 | api.CoreFeatureRelaxedSIMD).
+	//		WithDeterministicFloats()
+	//
+	// Note: This currently only affects the madd and nmadd instructions of
+	// api.CoreFeatureRelaxedSIMD, which round the product before the sum
+	// instead of being fused.
+	WithDeterministicFloats() RuntimeConfig
+
+	// WithRejectNondeterminism rejects modules which use instructions whose
+	// results may differ by platform, for consensus hosts where every node
+	// must compute the same result.
+	//
+	// Runtime.CompileModule errs, naming the offending function and
+	// instruction, if a module uses a relaxed vector instruction or a float
+	// instruction which may produce a NaN, such as f32.add, as NaN bits aren't
+	// canonicalized. Float instructions which only move or compare bits, such
+	// as f64.load or f32.eq, are allowed.
+	//
+	// This example rejects nondeterministic modules:
+	//	rConfig = wazero.NewRuntimeConfig().WithRejectNondeterminism()
+	//
+	// # Notes
+	//
+	//   - This is stricter than WithDeterministicFloats, which makes some
+	//     instructions deterministic instead of rejecting them.
+	//   - Atomic instructions, such as memory.atomic.wait, aren't supported, so
+	//     modules using them already fail to compile.
+	//   - Use WithFloatsDisabled to reject any use of floats.
+	WithRejectNondeterminism() RuntimeConfig
+
+	// WithDecodeBufferPool reduces allocations of Runtime.CompileModule, for
+	// hosts which compile many modules. The default is to allocate each
+	// decoded module independently.
+	//
+	// This example reuses decode buffers across compilations:
+	//	rConfig = wazero.NewRuntimeConfig().WithDecodeBufferPool()
+	//
+	// # Notes
+	//
+	//   - This is experimental, as the effect depends on the modules.
+	//   - Scratch buffers are reused across calls from a pool, so concurrent
+	//     calls to CompileModule are safe.
+	//   - The function bodies of a module are allocated together, so are only
+	//     garbage collected when none are referenced.
+	WithDecodeBufferPool() RuntimeConfig
+
+	// WithWrappingDivision makes signed integer division of the minimum
+	// value by -1, e.g. i32.div_s of math.MinInt32 and -1, result in the
+	// minimum value instead of trapping. The default is false, which traps
+	// with an integer overflow as the WebAssembly specification requires.
+	//
+	// This example wraps on division overflow:
+	//	rConfig = wazero.NewRuntimeConfig().WithWrappingDivision()
+	//
+	// # Notes
+	//
+	//   - This is experimental and deviates from the WebAssembly
+	//     specification. Only use it to run guests that expect wrapping.
+	//   - Other instructions which trap on overflow, such as
+	//     i32.trunc_f32_s, are not affected.
+	WithWrappingDivision() RuntimeConfig
+
+	// WithVerboseTraces adds the current values of params and locals to each
+	// frame of the stack trace in errors returned by api.Function Call. The
+	// default is false, as this retains more state when formatting errors.
+	//
+	// For example, a trap in a function with a custom name section reads:
+	//	wasm error: integer divide by zero
+	//	wasm stack trace:
+	//		.div(i32,i32) i32
+	//			x=1, y=0, result=0
+	//
+	// # Notes
+	//
+	//   - This is only supported by the interpreter, and ignored by the
+	//     compiler. See NewRuntimeConfigInterpreter.
+	//   - Locals without a name are named by their index, e.g. "$2".
+	WithVerboseTraces() RuntimeConfig
+
+	// WithStrictHostResults makes a call fail when a host function returns a
+	// value that doesn't fit its result type, such as an i32 result above
+	// math.MaxUint32. The default is false, which truncates the value to the
+	// size of the type.
+	//
+	// This example fails calls on lossy host function results:
+	//	rConfig = wazero.NewRuntimeConfig().WithStrictHostResults()
+	//
+	// # Notes
+	//
+	//   - An i32 result may be zero or sign-extended to 64 bits, as both
+	//     are lossless, e.g. uint64(int32(-1)).
+	//   - Go functions defined with HostFunctionBuilder.WithFunc can't
+	//     overflow, as their result types match the WebAssembly type. This
+	//     only affects api.GoFunction and api.GoModuleFunction.
+	WithStrictHostResults() RuntimeConfig
+
+	// WithMaxCompiledCodeBytes limits the size of machine code each module
+	// compiles to. Runtime.CompileModule fails when a module would exceed it.
+	// Zero, the default, means unlimited.
+	//
+	// This protects multi-tenant hosts from modules which expand to an
+	// enormous amount of machine code, e.g. 1MiB:
+	//	rConfig = wazero.NewRuntimeConfig().WithMaxCompiledCodeBytes(1 << 20)
+	//
+	// # Notes
+	//
+	//   - This is only supported by the compiler, and ignored by the
+	//     interpreter. See NewRuntimeConfigCompiler.
+	//   - Code is only reused from the compilation cache if it was compiled
+	//     with the same limit, so cached code is always within it.
+	//   - The limit is checked after each function is compiled, so memory
+	//     used compiling the function which exceeds it isn't bounded.
+	WithMaxCompiledCodeBytes(n uint64) RuntimeConfig
+
+	// WithStrictFeatures makes Runtime.CompileModule fail when WithCoreFeatures
+	// enabled a feature the engine doesn't implement, such as
+	// api.CoreFeatureMemory64 in the compiler. The error names each
+	// unimplemented feature. The default is false, which only fails when a
+	// module uses such a feature, or in some cases, at the first instruction
+	// which does.
+	//
+	// This example fails early, as the compiler doesn't implement memory64:
+	//	rConfig = wazero.NewRuntimeConfigCompiler().
+	//		WithCoreFeatures(api.CoreFeaturesV2 | api.CoreFeatureMemory64).
+	//		WithStrictFeatures()
+	WithStrictFeatures() RuntimeConfig
+
+	// WithValidationLimits makes Runtime.CompileModule fail when a module
+	// exceeds any of the limits, such as the count of locals in a function.
+	// The error names the limit and what exceeded it. The default is to only
+	// enforce limits of the WebAssembly specification and wazero.
+	//
+	// This protects multi-tenant hosts from modules which are expensive to
+	// validate and compile, e.g. to limit each function to 1000 locals:
+	//	rConfig = wazero.NewRuntimeConfig().
+	//		WithValidationLimits(wazero.ValidationLimits{MaxFunctionLocals: 1000})
+	//
+	// Note: Zero fields default to the implementation limits of the
+	// WebAssembly JavaScript Interface, so ValidationLimits{} enforces the
+	// same limits as most browsers.
+	// See https://webassembly.github.io/spec/js-api/#limits
+	WithValidationLimits(ValidationLimits) RuntimeConfig
+
+	// WithUnresolvedImportStub allows modules to instantiate when a function
+	// import can't be resolved, e.g. its module isn't instantiated. Instead,
+	// the import is satisfied by a stub which traps when called. The default
+	// is false, which fails instantiation.
+	//
+	// This example lets modules with optional imports run unless they use one:
+	//	rConfig = wazero.NewRuntimeConfig().WithUnresolvedImportStub()
+	//
+	// For example, calling a stub of the import "env.abort" fails with:
+	//	wasm error: unimplemented import: env.abort
+	//	wasm stack trace:
+	//		env.abort()
+	//		--snip--
+	//
+	// Note: Only function imports are stubbed. Instantiation still fails when
+	// a memory, table or global import can't be resolved, or an import exists
+	// with a different signature.
+	WithUnresolvedImportStub() RuntimeConfig
+}
+
+// ValidationLimits are limits RuntimeConfig.WithValidationLimits enforces in
+// addition to the WebAssembly specification. Zero fields use the default.
+type ValidationLimits struct {
+	// MaxTypes is the maximum count of function types. Defaults to 1000000.
+	MaxTypes uint32
+
+	// MaxTableSize is the maximum min size of a table a module defines.
+	// Defaults to 10000000.
+	MaxTableSize uint32
+
+	// MaxFunctionLocals is the maximum count of locals in a function,
+	// including its parameters. Defaults to 50000.
+	MaxFunctionLocals uint32
+
+	// MaxFunctionBodySize is the maximum size of the code of a function in
+	// bytes, excluding the declaration of its locals. Defaults to 7654321.
+	MaxFunctionBodySize uint32
 }
 
 // NewRuntimeConfig returns a RuntimeConfig using the compiler if it is supported in this environment,
@@ -79,8 +371,26 @@ type runtimeConfig struct {
 	enabledFeatures       api.CoreFeatures
 	memoryLimitPages      uint32
 	memoryCapacityFromMax bool
+	interpreterStackSize  int
+	maxInstances          int
+	memoryGrowDeniedHook  func(mod api.Module, requestedPages, currentPages, maxPages uint32)
+	stripNames            bool
+	floatsDisabled        bool
+	copyOnWriteMemory     bool
+	deterministicFloats   bool
+	rejectNondeterminism  bool
+	decodeBufferPool      bool
+	wrappingDivision      bool
+	verboseTraces         bool
+	strictHostResults     bool
+	maxCompiledCodeBytes  uint64
+	strictFeatures        bool
+	validationLimits      *wasm.ValidationLimits
+	unresolvedImportStub  bool
 	isInterpreter         bool
 	newEngine             func(context.Context, api.CoreFeatures) wasm.Engine
+	// implementedFeatures are the features newEngine can execute.
+	implementedFeatures api.CoreFeatures
 }
 
 // engineLessConfig helps avoid copy/pasting the wrong defaults.
@@ -107,6 +417,7 @@ var engineLessConfig = &runtimeConfig{
 func NewRuntimeConfigCompiler() RuntimeConfig {
 	ret := engineLessConfig.clone()
 	ret.newEngine = compiler.NewEngine
+	ret.implementedFeatures = compiler.ImplementedFeatures
 	return ret
 }
 
@@ -115,9 +426,39 @@ func NewRuntimeConfigInterpreter() RuntimeConfig {
 	ret := engineLessConfig.clone()
 	ret.isInterpreter = true
 	ret.newEngine = interpreter.NewEngine
+	ret.implementedFeatures = interpreter.ImplementedFeatures
 	return ret
 }
 
+// EngineFeatures returns the api.CoreFeatures the engine of the config
+// implements on the current runtime.GOOS and runtime.GOARCH. This allows
+// detecting support for a feature, e.g. api.CoreFeatureRelaxedSIMD, before
+// enabling it with RuntimeConfig.WithCoreFeatures.
+//
+// For example, this enables relaxed SIMD only when the engine implements it:
+//
+//	rConfig := wazero.NewRuntimeConfig()
+//	features := api.CoreFeaturesV2
+//	if wazero.EngineFeatures(rConfig).IsEnabled(api.CoreFeatureRelaxedSIMD) {
+//		features |= api.CoreFeatureRelaxedSIMD
+//	}
+//	rConfig = rConfig.WithCoreFeatures(features)
+//
+// # Notes
+//
+//   - This is zero for NewRuntimeConfigCompiler when the compiler isn't
+//     supported on this platform.
+//   - This is independent of the features enabled by WithCoreFeatures. See
+//     RuntimeConfig.WithStrictFeatures to reject enabled features which
+//     aren't implemented.
+func EngineFeatures(config RuntimeConfig) api.CoreFeatures {
+	c := config.(*runtimeConfig)
+	if !c.isInterpreter && !platform.CompilerSupported() {
+		return 0
+	}
+	return c.implementedFeatures
+}
+
 // clone makes a deep copy of this runtime config.
 func (c *runtimeConfig) clone() *runtimeConfig {
 	ret := *c // copy except maps which share a ref
@@ -149,6 +490,139 @@ func (c *runtimeConfig) WithMemoryCapacityFromMax(memoryCapacityFromMax bool) Ru
 	return ret
 }
 
+// WithInterpreterStackSize implements RuntimeConfig.WithInterpreterStackSize
+func (c *runtimeConfig) WithInterpreterStackSize(initial int) RuntimeConfig {
+	ret := c.clone()
+	// This panics instead of returning an error as it is unlikely.
+	if initial < 0 {
+		panic(fmt.Errorf("interpreterStackSize invalid: %d < 0", initial))
+	}
+	ret.interpreterStackSize = initial
+	return ret
+}
+
+// WithMaxInstances implements RuntimeConfig.WithMaxInstances
+func (c *runtimeConfig) WithMaxInstances(n int) RuntimeConfig {
+	ret := c.clone()
+	// This panics instead of returning an error as it is unlikely.
+	if n < 0 {
+		panic(fmt.Errorf("maxInstances invalid: %d < 0", n))
+	}
+	ret.maxInstances = n
+	return ret
+}
+
+// WithMemoryGrowDeniedHook implements RuntimeConfig.WithMemoryGrowDeniedHook
+func (c *runtimeConfig) WithMemoryGrowDeniedHook(hook func(mod api.Module, requestedPages, currentPages, maxPages uint32)) RuntimeConfig {
+	ret := c.clone()
+	ret.memoryGrowDeniedHook = hook
+	return ret
+}
+
+// WithStripNames implements RuntimeConfig.WithStripNames
+func (c *runtimeConfig) WithStripNames() RuntimeConfig {
+	ret := c.clone()
+	ret.stripNames = true
+	return ret
+}
+
+// WithFloatsDisabled implements RuntimeConfig.WithFloatsDisabled
+func (c *runtimeConfig) WithFloatsDisabled() RuntimeConfig {
+	ret := c.clone()
+	ret.floatsDisabled = true
+	return ret
+}
+
+// WithCopyOnWriteMemory implements RuntimeConfig.WithCopyOnWriteMemory
+func (c *runtimeConfig) WithCopyOnWriteMemory() RuntimeConfig {
+	ret := c.clone()
+	ret.copyOnWriteMemory = true
+	return ret
+}
+
+// WithDeterministicFloats implements RuntimeConfig.WithDeterministicFloats
+func (c *runtimeConfig) WithDeterministicFloats() RuntimeConfig {
+	ret := c.clone()
+	ret.deterministicFloats = true
+	return ret
+}
+
+// WithRejectNondeterminism implements RuntimeConfig.WithRejectNondeterminism
+func (c *runtimeConfig) WithRejectNondeterminism() RuntimeConfig {
+	ret := c.clone()
+	ret.rejectNondeterminism = true
+	return ret
+}
+
+// WithDecodeBufferPool implements RuntimeConfig.WithDecodeBufferPool
+func (c *runtimeConfig) WithDecodeBufferPool() RuntimeConfig {
+	ret := c.clone()
+	ret.decodeBufferPool = true
+	return ret
+}
+
+// WithWrappingDivision implements RuntimeConfig.WithWrappingDivision
+func (c *runtimeConfig) WithWrappingDivision() RuntimeConfig {
+	ret := c.clone()
+	ret.wrappingDivision = true
+	return ret
+}
+
+// WithVerboseTraces implements RuntimeConfig.WithVerboseTraces
+func (c *runtimeConfig) WithVerboseTraces() RuntimeConfig {
+	ret := c.clone()
+	ret.verboseTraces = true
+	return ret
+}
+
+// WithStrictHostResults implements RuntimeConfig.WithStrictHostResults
+func (c *runtimeConfig) WithStrictHostResults() RuntimeConfig {
+	ret := c.clone()
+	ret.strictHostResults = true
+	return ret
+}
+
+// WithMaxCompiledCodeBytes implements RuntimeConfig.WithMaxCompiledCodeBytes
+func (c *runtimeConfig) WithMaxCompiledCodeBytes(n uint64) RuntimeConfig {
+	ret := c.clone()
+	ret.maxCompiledCodeBytes = n
+	return ret
+}
+
+// WithStrictFeatures implements RuntimeConfig.WithStrictFeatures
+func (c *runtimeConfig) WithStrictFeatures() RuntimeConfig {
+	ret := c.clone()
+	ret.strictFeatures = true
+	return ret
+}
+
+// WithValidationLimits implements RuntimeConfig.WithValidationLimits
+func (c *runtimeConfig) WithValidationLimits(limits ValidationLimits) RuntimeConfig {
+	ret := c.clone()
+	l := wasm.DefaultValidationLimits
+	if limits.MaxTypes != 0 {
+		l.MaxTypes = limits.MaxTypes
+	}
+	if limits.MaxTableSize != 0 {
+		l.MaxTableSize = limits.MaxTableSize
+	}
+	if limits.MaxFunctionLocals != 0 {
+		l.MaxFunctionLocals = limits.MaxFunctionLocals
+	}
+	if limits.MaxFunctionBodySize != 0 {
+		l.MaxFunctionBodySize = limits.MaxFunctionBodySize
+	}
+	ret.validationLimits = &l
+	return ret
+}
+
+// WithUnresolvedImportStub implements RuntimeConfig.WithUnresolvedImportStub
+func (c *runtimeConfig) WithUnresolvedImportStub() RuntimeConfig {
+	ret := c.clone()
+	ret.unresolvedImportStub = true
+	return ret
+}
+
 // CompiledModule is a WebAssembly module ready to be instantiated (Runtime.InstantiateModule) as an api.Module.
 //
 // In WebAssembly terminology, this is a decoded, validated, and possibly also compiled module. wazero avoids using
@@ -187,6 +661,74 @@ type CompiledModule interface {
 	// memory.
 	ExportedMemories() map[string]api.MemoryDefinition
 
+	// Exports returns all exports (api.Export) of any type, in the order of
+	// the export section, or nil if there are none.
+	//
+	// Note: Use this to enumerate globals, which unlike functions, memories
+	// and tables, have no definition to describe them.
+	Exports() []api.Export
+
+	// TableDefinitions returns all tables, imported or defined, in index
+	// order, or nil if there are none. This describes the shape of tables
+	// before instantiation, e.g. to set up dynamic linking.
+	TableDefinitions() []api.TableDefinition
+
+	// DataCount returns the count of data segments declared in the data count
+	// section, or false if the module has no such section. This is known
+	// before the data section is decoded, e.g. to size passive segments.
+	//
+	// Note: Validation ensures the count equals the count of data segments.
+	DataCount() (count uint32, ok bool)
+
+	// Types returns all function types (api.FunctionSignature) in the type
+	// section of this module, in index order, or nil if there are none.
+	//
+	// Note: This includes types not used by any import or export.
+	Types() []api.FunctionSignature
+
+	// Producers returns the languages, tools and SDKs recorded in the
+	// "producers" custom section, or nil if there are none.
+	//
+	// Note: A malformed "producers" section is ignored, so it also results
+	// in nil.
+	Producers() []api.Producer
+
+	// DylinkInfo returns the memory and table layout recorded in the
+	// "dylink.0" custom section of a dynamically linked module, such as an
+	// Emscripten SIDE_MODULE, or nil if there is none.
+	//
+	// Note: Unlike "producers", a malformed "dylink.0" section fails
+	// compilation, as it is required to link the module correctly.
+	DylinkInfo() *api.DylinkInfo
+
+	// ContentHash returns the SHA-256 of the WebAssembly binary this module
+	// was compiled from. This is stable across processes for the same binary,
+	// so can be used to dedupe modules or key metrics by module identity.
+	//
+	// # Notes
+	//
+	//   - This is the same key used by the compilation cache, e.g.
+	//     experimental.WithCompilationCacheDirName.
+	//   - The hash is of the binary, not its semantics: binaries that differ
+	//     only in custom sections have different hashes.
+	//   - Modules from HostModuleBuilder aren't compiled from a binary, so
+	//     their hash is only stable within the process.
+	ContentHash() [32]byte
+
+	// MaxStackDepth returns the worst-case count of values on the operand
+	// stack of any function in this module, as computed by validation. This
+	// can size the value stack of the interpreter, e.g. with
+	// RuntimeConfig.WithInterpreterStackSize, or reject modules with
+	// pathologically nested expressions before instantiating them.
+	//
+	// # Notes
+	//
+	//   - Each value counts as one, including v128 values, which the
+	//     interpreter stores in two stack slots.
+	//   - This excludes params and locals, and values of callers, so a call
+	//     stack needs the sum of the depth and locals of each of its frames.
+	MaxStackDepth() uint32
+
 	// Close releases all the allocated resources for this CompiledModule.
 	//
 	// Note: It is safe to call Close while having outstanding calls from an
@@ -218,6 +760,7 @@ func (c *compiledModule) Name() (moduleName string) {
 // Close implements CompiledModule.Close
 func (c *compiledModule) Close(context.Context) error {
 	c.compiledEngine.DeleteCompiledModule(c.module)
+	c.module.ReleaseMemoryImage()
 	// It is possible the underlying may need to return an error later, but in any case this matches api.Module.Close.
 	return nil
 }
@@ -232,6 +775,11 @@ func (c *compiledModule) ExportedFunctions() map[string]api.FunctionDefinition {
 	return c.module.ExportedFunctions()
 }
 
+// TableDefinitions implements CompiledModule.TableDefinitions
+func (c *compiledModule) TableDefinitions() []api.TableDefinition {
+	return c.module.TableDefinitions()
+}
+
 // ImportedMemories implements CompiledModule.ImportedMemories
 func (c *compiledModule) ImportedMemories() []api.MemoryDefinition {
 	return c.module.ImportedMemories()
@@ -242,6 +790,72 @@ func (c *compiledModule) ExportedMemories() map[string]api.MemoryDefinition {
 	return c.module.ExportedMemories()
 }
 
+// Types implements CompiledModule.Types
+func (c *compiledModule) Types() []api.FunctionSignature {
+	return c.module.Types()
+}
+
+// Exports implements CompiledModule.Exports
+func (c *compiledModule) Exports() (ret []api.Export) {
+	for _, e := range c.module.ExportSection {
+		ret = append(ret, api.Export{Name: e.Name, Type: e.Type, Index: e.Index})
+	}
+	return
+}
+
+// DataCount implements CompiledModule.DataCount
+func (c *compiledModule) DataCount() (uint32, bool) {
+	if dc := c.module.DataCountSection; dc != nil {
+		return *dc, true
+	}
+	return 0, false
+}
+
+// Producers implements CompiledModule.Producers
+func (c *compiledModule) Producers() []api.Producer {
+	return c.module.ProducersSection
+}
+
+// DylinkInfo implements CompiledModule.DylinkInfo
+func (c *compiledModule) DylinkInfo() *api.DylinkInfo {
+	return c.module.DylinkSection
+}
+
+// ContentHash implements CompiledModule.ContentHash
+func (c *compiledModule) ContentHash() [32]byte {
+	return c.module.ID
+}
+
+// MaxStackDepth implements CompiledModule.MaxStackDepth
+func (c *compiledModule) MaxStackDepth() uint32 {
+	return c.module.MaxStackDepth()
+}
+
+// ExportedGlobalType is exposed for experimental.DiffExports.
+func (c *compiledModule) ExportedGlobalType(name string) (valType api.ValueType, mutable, ok bool) {
+	for _, e := range c.module.ExportSection {
+		if e.Type == api.ExternTypeGlobal && e.Name == name {
+			_, globals, _, _, _ := c.module.AllDeclarations()
+			g := globals[e.Index]
+			return g.ValType, g.Mutable, true
+		}
+	}
+	return
+}
+
+// ReachableFunctions is exposed for experimental.ReachableFunctions.
+func (c *compiledModule) ReachableFunctions(roots []string) []uint32 {
+	return c.module.ReachableFunctions(roots)
+}
+
+// CalledImports is exposed for experimental.CalledImports.
+func (c *compiledModule) CalledImports() (ret []api.FunctionDefinition) {
+	for _, idx := range c.module.CalledImports() {
+		ret = append(ret, c.module.FunctionDefinitionSection[idx])
+	}
+	return
+}
+
 // ModuleConfig configures resources needed by functions that have low-level interactions with the host operating
 // system. Using this, resources such as STDIN can be isolated, so that the same module can be safely instantiated
 // multiple times.
@@ -274,6 +888,28 @@ type ModuleConfig interface {
 	// See https://linux.die.net/man/3/argv and https://en.wikipedia.org/wiki/Null-terminated_string
 	WithArgs(...string) ModuleConfig
 
+	// WithContextValue adds a value to the context.Context of each call to a
+	// function of the module, so host functions it calls can read it with
+	// ctx.Value(key). This scopes values to the instance, such as a tenant
+	// ID, without the caller adding them to every call. Defaults to none.
+	//
+	// For example, a host function can read the tenant of its caller:
+	//	config := wazero.NewModuleConfig().WithContextValue(tenantKey{}, "acme")
+	//	...
+	//	builder.WithFunc(func(ctx context.Context) {
+	//		tenant := ctx.Value(tenantKey{}).(string)
+	//	})
+	//
+	// # Notes
+	//
+	//   - The key must be non-nil and comparable, as with context.WithValue,
+	//     or Runtime.InstantiateModule errs.
+	//   - Setting the same key again replaces its value.
+	//   - A value in the context passed to api.Function Call takes precedence
+	//     over the value for the same key set here.
+	//   - This also applies to the start functions called on instantiation.
+	WithContextValue(key, value interface{}) ModuleConfig
+
 	// WithEnv sets an environment variable visible to a Module that imports functions. Defaults to none.
 	// Runtime.InstantiateModule errs if the key is empty or contains a NULL(0) or equals("") character.
 	//
@@ -290,6 +926,57 @@ type ModuleConfig interface {
 	// See https://linux.die.net/man/3/environ and https://en.wikipedia.org/wiki/Null-terminated_string
 	WithEnv(key, value string) ModuleConfig
 
+	// WithExitHandler sets a function consulted when the guest calls the
+	// WASI function proc_exit. The default is nil, which closes the module
+	// with the exit code and fails the call with a sys.ExitError.
+	//
+	// When the handler returns nil, proc_exit returns to the guest and the
+	// module is not closed. Otherwise, the module is closed with the exit code
+	// and the call fails with the error returned.
+	//
+	// This example keeps a reactor usable after a non-zero exit:
+	//	config := wazero.NewModuleConfig().WithExitHandler(func(exitCode uint32) error {
+	//		if exitCode == 0 {
+	//			return sys.NewExitError("app", exitCode)
+	//		}
+	//		log.Printf("guest exited with %d", exitCode)
+	//		return nil
+	//	})
+	//
+	// # Notes
+	//
+	//   - Compilers often emit an unreachable instruction after a call to
+	//     exit, so the guest may trap after proc_exit returns. The module is
+	//     still usable for subsequent calls.
+	//   - Return sys.NewExitError to preserve the default error.
+	WithExitHandler(handler func(exitCode uint32) error) ModuleConfig
+
+	// WithYieldHandler sets a function called when the guest calls the WASI
+	// function sched_yield, e.g. to run the host's scheduler or check for
+	// cancellation. The default is nil, which returns success to the guest
+	// without doing anything.
+	//
+	// A nil error returns success to the guest. Otherwise, the error is
+	// returned to the guest as an errno: context.Canceled and
+	// context.DeadlineExceeded become ECANCELED, syscall.EINTR becomes EINTR,
+	// and other errors become EIO.
+	//
+	// This example lets other goroutines run while the guest spins:
+	//	config := wazero.NewModuleConfig().WithYieldHandler(func(ctx context.Context) error {
+	//		runtime.Gosched()
+	//		return ctx.Err()
+	//	})
+	WithYieldHandler(handler func(ctx context.Context) error) ModuleConfig
+
+	// WithStrictUTF8 makes WASI functions which take a path, such as
+	// path_open, return ErrnoIlseq if the path isn't valid UTF-8, instead of
+	// passing its bytes to the file system. This catches encoding bugs in the
+	// guest early. The default is false, for compatibility.
+	//
+	// This example rejects paths which aren't valid UTF-8:
+	//	config := wazero.NewModuleConfig().WithStrictUTF8()
+	WithStrictUTF8() ModuleConfig
+
 	// WithFS assigns the file system to use for any paths beginning at "/".
 	// Defaults return fs.ErrNotExist.
 	//
@@ -321,9 +1008,82 @@ type ModuleConfig interface {
 	// otherwise, is compiler-specific. See /RATIONALE.md for notes.
 	WithFS(fs.FS) ModuleConfig
 
+	// WithFunctionTimeout limits how long a call to the exported function of
+	// the given name may run. When the call exceeds the duration, it traps
+	// with an error that wraps context.DeadlineExceeded. Zero, the default,
+	// means unlimited.
+	//
+	// This is like calling the function with context.WithTimeout, except it
+	// is scoped to one entry point: other exported functions remain
+	// unbounded, and the timeout doesn't apply when the function is called
+	// from within the module, for example via call or call_indirect.
+	//
+	// # Notes
+	//
+	//   - Setting a negative value will panic.
+	//   - The timeout is checked at the same safe-points as context deadlines,
+	//     such as loop headers. A host function that blocks isn't interrupted.
+	WithFunctionTimeout(name string, d time.Duration) ModuleConfig
+
+	// WithInstructionBudget limits the total count of instructions executed by
+	// functions of the module over its lifetime, across all calls. Zero, the
+	// default, means unlimited.
+	//
+	// When the budget is exhausted, the call traps, as do any subsequent calls
+	// into the module. Use api.Module InstructionBudget to read the remaining
+	// budget.
+	//
+	// # Notes
+	//
+	//   - This is interpreter-only for now! Runtime.InstantiateModule errs when
+	//     this is set and the runtime uses the compiler.
+	//   - Instructions are counted in wazero's intermediate representation,
+	//     which is close to, but not exactly, one per WebAssembly instruction.
+	//   - Only functions defined in this module are charged, not those it
+	//     imports. The start section, if any, is not charged.
+	//   - Each call is limited by the budget remaining when it started or last
+	//     called a function, so concurrent calls may together exceed it.
+	WithInstructionBudget(n uint64) ModuleConfig
+
+	// WithListener pre-opens the listener as a socket, which WASI functions
+	// such as sock_accept can use. Each call adds a listener, assigned the
+	// next file descriptor after any pre-opened directory.
+	//
+	// This example allows the guest to accept connections on file descriptor
+	// 3, when there's no file system configured:
+	//
+	//	ln, _ := net.Listen("tcp", "127.0.0.1:8080")
+	//	config := wazero.NewModuleConfig().WithListener(ln)
+	//
+	// # Notes
+	//
+	//   - This is experimental, and only supports the functions sock_accept,
+	//     sock_recv and sock_send. fd_read and fd_write also work on
+	//     accepted connections.
+	//   - Any net.Listener can be used, not just those from net.Listen. For
+	//     example, one that accepts in-memory connections from net.Pipe.
+	//   - The listener is closed when the module is closed.
+	WithListener(net.Listener) ModuleConfig
+
 	// WithName configures the module name. Defaults to what was decoded from the name section.
 	WithName(string) ModuleConfig
 
+	// WithPrefaultMemory writes to each page of the module's memory at
+	// instantiation, so that the operating system commits it up front. This
+	// trades instantiation time for predictable call latency, as the first
+	// guest write to a page doesn't incur a page fault.
+	//
+	// # Notes
+	//
+	//   - This is a hint: whether it helps depends on how the memory was
+	//     allocated, e.g. lazily zeroed pages from the Go heap or a
+	//     copy-on-write mapping per RuntimeConfig.WithCopyOnWriteMemory.
+	//   - Only pages committed at instantiation are touched, not those added
+	//     later by memory.grow.
+	//   - This has no effect when the module has no memory, or imports it, as
+	//     other modules may be using an imported memory concurrently.
+	WithPrefaultMemory() ModuleConfig
+
 	// WithStartFunctions configures the functions to call after the module is
 	// instantiated. Defaults to "_start".
 	//
@@ -374,6 +1134,24 @@ type ModuleConfig interface {
 	// See https://linux.die.net/man/3/stdout
 	WithStdout(io.Writer) ModuleConfig
 
+	// WithStdoutLineHandler buffers standard output (file descriptor 1),
+	// calling the handler with each newline-terminated line, excluding the
+	// newline. This is more convenient than WithStdout for log forwarding.
+	//
+	// Here's an example that forwards lines to a logger:
+	//	config := wazero.NewModuleConfig().WithStdoutLineHandler(func(line string) {
+	//		log.Printf("guest: %s", line)
+	//	})
+	//
+	// # Notes
+	//
+	//   - This replaces any writer set by WithStdout, and vice versa.
+	//   - Each module instance has its own buffer. Any partial line is passed
+	//     to the handler when the module is closed.
+	//   - The handler is called synchronously on the goroutine writing, e.g.
+	//     the one calling "fd_write" in "wasi_snapshot_preview1".
+	WithStdoutLineHandler(handler func(line string)) ModuleConfig
+
 	// WithWalltime configures the wall clock, sometimes referred to as the
 	// real time clock. Defaults to a fake result that increases by 1ms on
 	// each reading.
@@ -472,6 +1250,26 @@ type moduleConfig struct {
 	environKeys map[string]int
 	// fs is the file system to open files with
 	fs fs.FS
+	// functionTimeouts are keyed by export name.
+	functionTimeouts map[string]time.Duration
+	// instructionBudget is zero when unlimited.
+	instructionBudget uint64
+	// prefaultMemory writes to each page of memory at instantiation.
+	prefaultMemory bool
+	// listeners are pre-opened as sockets.
+	listeners []net.Listener
+	// exitHandler is consulted by proc_exit, when non-nil.
+	exitHandler func(exitCode uint32) error
+	// yieldHandler is called by sched_yield, when non-nil.
+	yieldHandler func(ctx context.Context) error
+	// strictUTF8 rejects paths which aren't valid UTF-8 in WASI functions.
+	strictUTF8 bool
+	// stdoutLineHandler replaces stdout with an internalsys.LineWriter, when
+	// non-nil.
+	stdoutLineHandler func(line string)
+	// contextValues are pair-indexed keys and values to add to the context
+	// of calls, in the order they were set.
+	contextValues []interface{}
 }
 
 // NewModuleConfig returns a ModuleConfig that can be used for configuring module instantiation.
@@ -489,6 +1287,12 @@ func (c *moduleConfig) clone() *moduleConfig {
 	for key, value := range c.environKeys {
 		ret.environKeys[key] = value
 	}
+	if c.functionTimeouts != nil {
+		ret.functionTimeouts = make(map[string]time.Duration, len(c.functionTimeouts))
+		for name, d := range c.functionTimeouts {
+			ret.functionTimeouts[name] = d
+		}
+	}
 	return &ret
 }
 
@@ -499,6 +1303,14 @@ func (c *moduleConfig) WithArgs(args ...string) ModuleConfig {
 	return ret
 }
 
+// WithContextValue implements ModuleConfig.WithContextValue
+func (c *moduleConfig) WithContextValue(key, value interface{}) ModuleConfig {
+	ret := c.clone()
+	// Copy to avoid appending to the slice of another config.
+	ret.contextValues = append(append(make([]interface{}, 0, len(c.contextValues)+2), c.contextValues...), key, value)
+	return ret
+}
+
 // WithEnv implements ModuleConfig.WithEnv
 func (c *moduleConfig) WithEnv(key, value string) ModuleConfig {
 	ret := c.clone()
@@ -512,6 +1324,27 @@ func (c *moduleConfig) WithEnv(key, value string) ModuleConfig {
 	return ret
 }
 
+// WithExitHandler implements ModuleConfig.WithExitHandler
+func (c *moduleConfig) WithExitHandler(handler func(exitCode uint32) error) ModuleConfig {
+	ret := c.clone()
+	ret.exitHandler = handler
+	return ret
+}
+
+// WithYieldHandler implements ModuleConfig.WithYieldHandler
+func (c *moduleConfig) WithYieldHandler(handler func(ctx context.Context) error) ModuleConfig {
+	ret := c.clone()
+	ret.yieldHandler = handler
+	return ret
+}
+
+// WithStrictUTF8 implements ModuleConfig.WithStrictUTF8
+func (c *moduleConfig) WithStrictUTF8() ModuleConfig {
+	ret := c.clone()
+	ret.strictUTF8 = true
+	return ret
+}
+
 // WithFS implements ModuleConfig.WithFS
 func (c *moduleConfig) WithFS(fs fs.FS) ModuleConfig {
 	ret := c.clone()
@@ -519,6 +1352,13 @@ func (c *moduleConfig) WithFS(fs fs.FS) ModuleConfig {
 	return ret
 }
 
+// WithListener implements ModuleConfig.WithListener
+func (c *moduleConfig) WithListener(l net.Listener) ModuleConfig {
+	ret := c.clone()
+	ret.listeners = append(append([]net.Listener{}, c.listeners...), l)
+	return ret
+}
+
 // WithName implements ModuleConfig.WithName
 func (c *moduleConfig) WithName(name string) ModuleConfig {
 	ret := c.clone()
@@ -526,6 +1366,38 @@ func (c *moduleConfig) WithName(name string) ModuleConfig {
 	return ret
 }
 
+// WithFunctionTimeout implements ModuleConfig.WithFunctionTimeout
+func (c *moduleConfig) WithFunctionTimeout(name string, d time.Duration) ModuleConfig {
+	// This panics instead of returning an error as it is unlikely.
+	if d < 0 {
+		panic(fmt.Errorf("function timeout invalid: %s < 0", d))
+	}
+	ret := c.clone()
+	if d == 0 {
+		delete(ret.functionTimeouts, name)
+		return ret
+	}
+	if ret.functionTimeouts == nil {
+		ret.functionTimeouts = map[string]time.Duration{}
+	}
+	ret.functionTimeouts[name] = d
+	return ret
+}
+
+// WithInstructionBudget implements ModuleConfig.WithInstructionBudget
+func (c *moduleConfig) WithInstructionBudget(n uint64) ModuleConfig {
+	ret := c.clone()
+	ret.instructionBudget = n
+	return ret
+}
+
+// WithPrefaultMemory implements ModuleConfig.WithPrefaultMemory
+func (c *moduleConfig) WithPrefaultMemory() ModuleConfig {
+	ret := c.clone()
+	ret.prefaultMemory = true
+	return ret
+}
+
 // WithStartFunctions implements ModuleConfig.WithStartFunctions
 func (c *moduleConfig) WithStartFunctions(startFunctions ...string) ModuleConfig {
 	ret := c.clone()
@@ -551,6 +1423,15 @@ func (c *moduleConfig) WithStdin(stdin io.Reader) ModuleConfig {
 func (c *moduleConfig) WithStdout(stdout io.Writer) ModuleConfig {
 	ret := c.clone()
 	ret.stdout = stdout
+	ret.stdoutLineHandler = nil
+	return ret
+}
+
+// WithStdoutLineHandler implements ModuleConfig.WithStdoutLineHandler
+func (c *moduleConfig) WithStdoutLineHandler(handler func(line string)) ModuleConfig {
+	ret := c.clone()
+	ret.stdout = nil
+	ret.stdoutLineHandler = handler
 	return ret
 }
 
@@ -622,17 +1503,40 @@ func (c *moduleConfig) toSysContext() (sysCtx *internalsys.Context, err error) {
 		environ = append(environ, key+"="+value)
 	}
 
+	// Same validation as context.WithValue, which would otherwise panic on
+	// each call.
+	for i := 0; i < len(c.contextValues); i += 2 {
+		if key := c.contextValues[i]; key == nil {
+			err = errors.New("context value invalid: nil key")
+			return
+		} else if !reflect.TypeOf(key).Comparable() {
+			err = fmt.Errorf("context value invalid: key of type %T is not comparable", key)
+			return
+		}
+	}
+
+	stdout := c.stdout
+	if c.stdoutLineHandler != nil { // Buffer per module, not per config.
+		stdout = internalsys.NewLineWriter(c.stdoutLineHandler)
+	}
+
 	return internalsys.NewContext(
 		math.MaxUint32,
 		c.args,
 		environ,
 		c.stdin,
-		c.stdout,
+		stdout,
 		c.stderr,
 		c.randSource,
 		c.walltime, c.walltimeResolution,
 		c.nanotime, c.nanotimeResolution,
 		c.nanosleep,
 		c.fs,
+		c.listeners,
+		c.exitHandler,
+		c.yieldHandler,
+		c.strictUTF8,
+		c.contextValues,
+		c.prefaultMemory,
 	)
 }
@@ -1,19 +1,24 @@
 package wazero
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"io"
 	"io/fs"
 	"math"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/platform"
 	internalsys "github.com/tetratelabs/wazero/internal/sys"
 	testfs "github.com/tetratelabs/wazero/internal/testing/fs"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -50,6 +55,119 @@ func TestRuntimeConfig(t *testing.T) {
 				memoryCapacityFromMax: true,
 			},
 		},
+		{
+			name: "interpreterStackSize",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithInterpreterStackSize(1024)
+			},
+			expected: &runtimeConfig{
+				interpreterStackSize: 1024,
+			},
+		},
+		{
+			name: "maxInstances",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithMaxInstances(10)
+			},
+			expected: &runtimeConfig{
+				maxInstances: 10,
+			},
+		},
+		{
+			name: "stripNames",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithStripNames()
+			},
+			expected: &runtimeConfig{
+				stripNames: true,
+			},
+		},
+		{
+			name: "floatsDisabled",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFloatsDisabled()
+			},
+			expected: &runtimeConfig{
+				floatsDisabled: true,
+			},
+		},
+		{
+			name: "copyOnWriteMemory",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithCopyOnWriteMemory()
+			},
+			expected: &runtimeConfig{
+				copyOnWriteMemory: true,
+			},
+		},
+		{
+			name: "deterministicFloats",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithDeterministicFloats()
+			},
+			expected: &runtimeConfig{
+				deterministicFloats: true,
+			},
+		},
+		{
+			name: "rejectNondeterminism",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithRejectNondeterminism()
+			},
+			expected: &runtimeConfig{
+				rejectNondeterminism: true,
+			},
+		},
+		{
+			name: "decodeBufferPool",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithDecodeBufferPool()
+			},
+			expected: &runtimeConfig{
+				decodeBufferPool: true,
+			},
+		},
+		{
+			name: "wrappingDivision",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithWrappingDivision()
+			},
+			expected: &runtimeConfig{
+				wrappingDivision: true,
+			},
+		},
+		{
+			name: "verboseTraces",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithVerboseTraces()
+			},
+			expected: &runtimeConfig{
+				verboseTraces: true,
+			},
+		},
+		{
+			name: "strictHostResults",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithStrictHostResults()
+			},
+			expected: &runtimeConfig{
+				strictHostResults: true,
+			},
+		},
+		{
+			name: "validationLimits defaults zero fields",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithValidationLimits(ValidationLimits{MaxFunctionLocals: 10})
+			},
+			expected: &runtimeConfig{
+				validationLimits: &wasm.ValidationLimits{
+					MaxTypes:            wasm.DefaultValidationLimits.MaxTypes,
+					MaxTableSize:        wasm.DefaultValidationLimits.MaxTableSize,
+					MaxFunctionLocals:   10,
+					MaxFunctionBodySize: wasm.DefaultValidationLimits.MaxFunctionBodySize,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -71,6 +189,38 @@ func TestRuntimeConfig(t *testing.T) {
 		})
 		require.EqualError(t, err, "memoryLimitPages invalid: 65537 > 65536")
 	})
+
+	t.Run("interpreterStackSize invalid panics", func(t *testing.T) {
+		err := require.CapturePanic(func() {
+			input := &runtimeConfig{}
+			input.WithInterpreterStackSize(-1)
+		})
+		require.EqualError(t, err, "interpreterStackSize invalid: -1 < 0")
+	})
+
+	t.Run("maxInstances invalid panics", func(t *testing.T) {
+		err := require.CapturePanic(func() {
+			input := &runtimeConfig{}
+			input.WithMaxInstances(-1)
+		})
+		require.EqualError(t, err, "maxInstances invalid: -1 < 0")
+	})
+}
+
+func TestEngineFeatures(t *testing.T) {
+	interpreterFeatures := api.CoreFeaturesV2 | api.CoreFeatureExtendedConst |
+		api.CoreFeatureMemory64 | api.CoreFeatureRelaxedSIMD | api.CoreFeatureExceptionHandling |
+		api.CoreFeatureThreads
+	require.Equal(t, interpreterFeatures, EngineFeatures(NewRuntimeConfigInterpreter()))
+
+	// Enabled features don't change what the engine implements.
+	require.Equal(t, interpreterFeatures, EngineFeatures(NewRuntimeConfigInterpreter().WithCoreFeatures(api.CoreFeaturesV1)))
+
+	var compilerFeatures api.CoreFeatures
+	if platform.CompilerSupported() {
+		compilerFeatures = api.CoreFeaturesV2 | api.CoreFeatureExtendedConst | api.CoreFeatureThreads
+	}
+	require.Equal(t, compilerFeatures, EngineFeatures(NewRuntimeConfigCompiler()))
 }
 
 func TestModuleConfig(t *testing.T) {
@@ -510,6 +660,40 @@ func TestModuleConfig_toSysContext_WithNanosleep(t *testing.T) {
 	sysCtx.Nanosleep(testCtx, 2)
 }
 
+// TestModuleConfig_toSysContext_WithStdoutLineHandler has to test differently
+// because we can't compare function pointers.
+func TestModuleConfig_toSysContext_WithStdoutLineHandler(t *testing.T) {
+	var lines []string
+	config := NewModuleConfig().WithStdout(io.Discard).WithStdoutLineHandler(func(line string) {
+		lines = append(lines, line)
+	}).(*moduleConfig)
+
+	// Each module has its own buffer.
+	sysCtx1, err := config.toSysContext()
+	require.NoError(t, err)
+	sysCtx2, err := config.toSysContext()
+	require.NoError(t, err)
+	_, _ = sysCtx1.Stdout().Write([]byte("a"))
+	_, _ = sysCtx2.Stdout().Write([]byte("b\n"))
+	_, _ = sysCtx1.Stdout().Write([]byte("c\n"))
+	require.Equal(t, []string{"b", "ac"}, lines)
+
+	// WithStdout replaces the handler.
+	sysCtx, err := config.WithStdout(io.Discard).(*moduleConfig).toSysContext()
+	require.NoError(t, err)
+	require.Equal(t, io.Discard, sysCtx.Stdout())
+}
+
+func TestModuleConfig_toSysContext_WithPrefaultMemory(t *testing.T) {
+	sysCtx, err := NewModuleConfig().(*moduleConfig).toSysContext()
+	require.NoError(t, err)
+	require.False(t, sysCtx.PrefaultMemory())
+
+	sysCtx, err = NewModuleConfig().WithPrefaultMemory().(*moduleConfig).toSysContext()
+	require.NoError(t, err)
+	require.True(t, sysCtx.PrefaultMemory())
+}
+
 func TestModuleConfig_toSysContext_Errors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -541,6 +725,16 @@ func TestModuleConfig_toSysContext_Errors(t *testing.T) {
 			input:       NewModuleConfig().WithEnv("", "a"),
 			expectedErr: "environ invalid: empty key",
 		},
+		{
+			name:        "WithContextValue nil key",
+			input:       NewModuleConfig().WithContextValue(nil, "a"),
+			expectedErr: "context value invalid: nil key",
+		},
+		{
+			name:        "WithContextValue key not comparable",
+			input:       NewModuleConfig().WithContextValue([]byte("a"), "a"),
+			expectedErr: "context value invalid: key of type []uint8 is not comparable",
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -568,6 +762,20 @@ func TestModuleConfig_clone(t *testing.T) {
 
 	// Ensure the fs is not shared
 	require.Nil(t, cloned.fs)
+
+	// Ensure the function timeouts are not shared
+	withTimeout := mc.WithFunctionTimeout("a", time.Second).(*moduleConfig)
+	withTimeout.WithFunctionTimeout("b", time.Second)
+	withTimeout.WithFunctionTimeout("a", 0)
+	require.Nil(t, mc.functionTimeouts)
+	require.Equal(t, map[string]time.Duration{"a": time.Second}, withTimeout.functionTimeouts)
+}
+
+func TestModuleConfig_WithFunctionTimeout_Negative(t *testing.T) {
+	err := require.CapturePanic(func() {
+		NewModuleConfig().WithFunctionTimeout("a", -1)
+	})
+	require.EqualError(t, err, "function timeout invalid: -1ns < 0")
 }
 
 func Test_compiledModule_Name(t *testing.T) {
@@ -600,6 +808,172 @@ func Test_compiledModule_Name(t *testing.T) {
 	}
 }
 
+func Test_compiledModule_Exports(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	t.Run("none", func(t *testing.T) {
+		compiled, err := r.CompileModule(testCtx, binaryNamedZero)
+		require.NoError(t, err)
+		require.Nil(t, compiled.Exports())
+	})
+
+	t.Run("one of each type", func(t *testing.T) {
+		compiled, err := r.CompileModule(testCtx, binaryformat.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{}},
+			ImportSection:   []*wasm.Import{{Module: "env", Name: "f", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+			FunctionSection: []wasm.Index{0},
+			CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+			TableSection:    []*wasm.Table{{Min: 1, Type: wasm.RefTypeFuncref}},
+			MemorySection:   &wasm.Memory{Min: 1, Cap: 1, Max: 1},
+			GlobalSection: []*wasm.Global{{
+				Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			}},
+			// Not in the order of extern types, to ensure the order is retained.
+			ExportSection: []*wasm.Export{
+				{Name: "global", Type: wasm.ExternTypeGlobal, Index: 0},
+				{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
+				{Name: "func", Type: wasm.ExternTypeFunc, Index: 1},
+				{Name: "table", Type: wasm.ExternTypeTable, Index: 0},
+			},
+		}))
+		require.NoError(t, err)
+
+		require.Equal(t, []api.Export{
+			{Name: "global", Type: api.ExternTypeGlobal, Index: 0},
+			{Name: "memory", Type: api.ExternTypeMemory, Index: 0},
+			{Name: "func", Type: api.ExternTypeFunc, Index: 1},
+			{Name: "table", Type: api.ExternTypeTable, Index: 0},
+		}, compiled.Exports())
+	})
+}
+
+func Test_compiledModule_TableDefinitions(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	t.Run("none", func(t *testing.T) {
+		compiled, err := r.CompileModule(testCtx, binaryNamedZero)
+		require.NoError(t, err)
+		require.Nil(t, compiled.TableDefinitions())
+	})
+
+	t.Run("funcref table", func(t *testing.T) {
+		max := uint32(20)
+		compiled, err := r.CompileModule(testCtx, binaryformat.EncodeModule(&wasm.Module{
+			TableSection:  []*wasm.Table{{Min: 10, Max: &max, Type: wasm.RefTypeFuncref}},
+			ExportSection: []*wasm.Export{{Name: "table", Type: wasm.ExternTypeTable, Index: 0}},
+		}))
+		require.NoError(t, err)
+
+		defs := compiled.TableDefinitions()
+		require.Equal(t, 1, len(defs))
+		def := defs[0]
+		require.Equal(t, uint32(0), def.Index())
+		require.Equal(t, api.RefTypeFuncref, def.ElemType())
+		require.Equal(t, uint32(10), def.Min())
+		maxElems, ok := def.Max()
+		require.True(t, ok)
+		require.Equal(t, uint32(20), maxElems)
+		_, _, isImport := def.Import()
+		require.False(t, isImport)
+		require.Equal(t, []string{"table"}, def.ExportNames())
+	})
+}
+
+func Test_compiledModule_DataCount(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	header := append(append([]byte{}, binaryformat.Magic...), 0x01, 0x00, 0x00, 0x00)
+	memory := []byte{wasm.SectionIDMemory, 0x03, 0x01, 0x00, 0x01}                   // (memory 1)
+	data := []byte{wasm.SectionIDData, 0x07, 0x02, 0x01, 0x01, 'a', 0x01, 0x01, 'b'} // two passive segments
+
+	tests := []struct {
+		name          string
+		binary        []byte
+		expectedCount uint32
+		expectedOk    bool
+	}{
+		{
+			name:   "no data count section",
+			binary: bytes.Join([][]byte{header, memory, data}, nil),
+		},
+		{
+			name:          "data count section",
+			binary:        bytes.Join([][]byte{header, memory, {wasm.SectionIDDataCount, 0x01, 0x02}, data}, nil),
+			expectedCount: 2,
+			expectedOk:    true,
+		},
+		{
+			name:       "zero",
+			binary:     bytes.Join([][]byte{header, {wasm.SectionIDDataCount, 0x01, 0x00}}, nil),
+			expectedOk: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			compiled, err := r.CompileModule(testCtx, tc.binary)
+			require.NoError(t, err)
+
+			count, ok := compiled.DataCount()
+			require.Equal(t, tc.expectedCount, count)
+			require.Equal(t, tc.expectedOk, ok)
+		})
+	}
+}
+
+func Test_compiledModule_ContentHash(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, binaryNamedZero)
+	require.NoError(t, err)
+
+	// The hash is of the binary, so is the same in any process.
+	require.Equal(t, [32]byte(sha256.Sum256(binaryNamedZero)), compiled.ContentHash())
+
+	other, err := r.CompileModule(testCtx, binaryformat.EncodeModule(&wasm.Module{}))
+	require.NoError(t, err)
+	require.NotEqual(t, compiled.ContentHash(), other.ContentHash())
+}
+
+func Test_compiledModule_MaxStackDepth(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	// nested returns (i32.add (i32.const 0) (i32.add (i32.const 1) ...)),
+	// which needs a value on the stack for each level of nesting.
+	nested := func(depth int) []byte {
+		var body []byte
+		for i := 0; i < depth; i++ {
+			body = append(body, wasm.OpcodeI32Const, 0)
+		}
+		body = append(body, wasm.OpcodeI32Const, 0)
+		for i := 0; i < depth; i++ {
+			body = append(body, wasm.OpcodeI32Add)
+		}
+		return append(body, wasm.OpcodeEnd)
+	}
+
+	compiled, err := r.CompileModule(testCtx, binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection:     []*wasm.Code{{Body: nested(2)}, {Body: nested(100)}},
+	}))
+	require.NoError(t, err)
+
+	// The deepest function decides the depth.
+	require.Equal(t, uint32(101), compiled.MaxStackDepth())
+
+	empty, err := r.CompileModule(testCtx, binaryformat.EncodeModule(&wasm.Module{}))
+	require.NoError(t, err)
+	require.Zero(t, empty.MaxStackDepth())
+}
+
 func Test_compiledModule_Close(t *testing.T) {
 	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
 		e := &mockEngine{name: "1", cachedModules: map[*wasm.Module]struct{}{}}
@@ -649,6 +1023,12 @@ func requireSysContext(
 		nanotime, nanotimeResolution,
 		nanosleep,
 		fs,
+		nil,   // listeners
+		nil,   // exitHandler
+		nil,   // yieldHandler
+		false, // strictUTF8
+		nil,   // contextValues
+		false, // prefaultMemory
 	)
 	require.NoError(t, err)
 	return sysCtx
@@ -0,0 +1,52 @@
+package experimental
+
+import (
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// ReinitializeData copies the active data segments of the module into its
+// current memory again, as done on instantiation. This is cheaper than
+// re-instantiating the module, e.g. between fuzzing iterations.
+//
+// # Notes
+//
+//   - Passive data segments are left untouched.
+//   - Memory outside the active data segments is left as-is. Pair this with a
+//     snapshot of memory to fully reset it.
+//   - Memory is never grown. An error is returned without changing memory if
+//     any segment no longer fits, e.g. the memory was imported and replaced.
+//   - This must not be called concurrently with functions of the module.
+func ReinitializeData(mod api.Module) error {
+	if r, ok := mod.(interface{ ReinitializeData() error }); ok {
+		return r.ReinitializeData()
+	}
+	return fmt.Errorf("unsupported module: %v", mod)
+}
+
+// Reset restores the module to its state after instantiation, without
+// compiling or linking it again. This is cheaper than re-instantiating the
+// module, e.g. to isolate fuzzing iterations or requests.
+//
+// Mutable globals are reset to the values they had after instantiation,
+// including those initialized from an imported global. Memory is shrunk to its
+// initial size and zeroed, then the active data segments are copied into it
+// again. Data segments dropped by "data.drop" are restored.
+//
+// # Notes
+//
+//   - The start function isn't called again.
+//   - Imported globals and memory are left as-is, as they belong to another
+//     module. So are tables and element segments.
+//   - Memory this module exports is reset under any module importing it,
+//     which sees it shrink and lose its contents. Reset importers too, or
+//     don't reset such a module while they use the memory.
+//   - This is a no-op on a module without globals, memory or data segments.
+//   - This must not be called concurrently with functions of the module.
+func Reset(mod api.Module) error {
+	if r, ok := mod.(interface{ Reset() error }); ok {
+		return r.Reset()
+	}
+	return fmt.Errorf("unsupported module: %v", mod)
+}
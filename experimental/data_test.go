@@ -0,0 +1,57 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestReinitializeData(t *testing.T) {
+	ctx := context.Background()
+
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	mod, err := r.InstantiateModuleFromBinary(ctx, binary.EncodeModule(&wasm.Module{
+		MemorySection: &wasm.Memory{Min: 1},
+		DataSection: []*wasm.DataSegment{
+			{
+				OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{1}},
+				Init:             []byte("wazero"),
+			},
+		},
+		ExportSection: []*wasm.Export{{Name: "memory", Type: wasm.ExternTypeMemory}},
+	}))
+	require.NoError(t, err)
+
+	mem := mod.ExportedMemory("memory")
+	require.True(t, mem.WriteString(ctx, 0, "overwritten"))
+
+	require.NoError(t, ReinitializeData(mod))
+
+	buf, ok := mem.Read(ctx, 0, 11)
+	require.True(t, ok)
+	require.Equal(t, "owazerotten", string(buf))
+}
+
+func TestReset(t *testing.T) {
+	t.Run("no state", func(t *testing.T) {
+		ctx := context.Background()
+
+		r := wazero.NewRuntime(ctx)
+		defer r.Close(ctx)
+
+		mod, err := r.InstantiateModuleFromBinary(ctx, binary.EncodeModule(&wasm.Module{}))
+		require.NoError(t, err)
+		require.NoError(t, Reset(mod))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		require.EqualError(t, Reset(nil), "unsupported module: <nil>")
+	})
+}
@@ -0,0 +1,155 @@
+package experimental
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasmdebug"
+)
+
+// ExportChangeKind is how an export differs between two modules.
+type ExportChangeKind byte
+
+const (
+	// ExportAdded is an export only in the second module.
+	ExportAdded ExportChangeKind = iota + 1
+	// ExportRemoved is an export only in the first module.
+	ExportRemoved
+	// ExportChanged is an export in both modules, with a different type.
+	ExportChanged
+)
+
+// String returns "added", "removed" or "changed".
+func (k ExportChangeKind) String() string {
+	switch k {
+	case ExportAdded:
+		return "added"
+	case ExportRemoved:
+		return "removed"
+	case ExportChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("unknown(%d)", k)
+}
+
+// ExportChange is an exported function, memory or global that differs
+// between two modules. See DiffExports
+type ExportChange struct {
+	// Kind is how the export changed.
+	Kind ExportChangeKind
+
+	// Type is the kind of definition exported: api.ExternTypeFunc,
+	// api.ExternTypeMemory or api.ExternTypeGlobal.
+	Type api.ExternType
+
+	// Name is the export name.
+	Name string
+
+	// Before and After describe the type of the export in the first and
+	// second module, or are empty when it isn't exported by that module. For
+	// example, "(i32,i32) i32" for a function, "{min: 1, max: 2}" for a
+	// memory and "mut i32" for a global.
+	Before, After string
+}
+
+// String implements fmt.Stringer, e.g. "changed func add: (i32,i32) i32 -> (i64,i64) i64"
+func (c ExportChange) String() string {
+	return fmt.Sprintf("%s %s %s: %s -> %s", c.Kind, api.ExternTypeName(c.Type), c.Name, c.Before, c.After)
+}
+
+// DiffExports returns the exported functions, memories and globals added,
+// removed or changed between the compiled modules a and b, e.g. to detect
+// breaking changes to the public surface of a guest. Both must be a
+// wazero.CompiledModule, otherwise this returns nil.
+//
+// Changes are sorted by Type, then Name, so the result is deterministic.
+// Functions change when their param or result types differ, memories when
+// their limits, sharing or index type differ, and globals when their value
+// type or mutability differ.
+//
+// Note: Exported tables are not compared.
+func DiffExports(a, b interface{}) (changes []ExportChange) {
+	before, ok := exportTypes(a)
+	if !ok {
+		return nil
+	}
+	after, ok := exportTypes(b)
+	if !ok {
+		return nil
+	}
+
+	for k, desc := range before {
+		if afterDesc, ok := after[k]; !ok {
+			changes = append(changes, ExportChange{Kind: ExportRemoved, Type: k.t, Name: k.name, Before: desc})
+		} else if desc != afterDesc {
+			changes = append(changes, ExportChange{Kind: ExportChanged, Type: k.t, Name: k.name, Before: desc, After: afterDesc})
+		}
+	}
+	for k, desc := range after {
+		if _, ok := before[k]; !ok {
+			changes = append(changes, ExportChange{Kind: ExportAdded, Type: k.t, Name: k.name, After: desc})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Type != changes[j].Type {
+			return changes[i].Type < changes[j].Type
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return
+}
+
+type exportKey struct {
+	t    api.ExternType
+	name string
+}
+
+// exportTypes returns a description of the type of each exported function,
+// memory and global, or false if compiled isn't a wazero.CompiledModule.
+func exportTypes(compiled interface{}) (map[exportKey]string, bool) {
+	c, ok := compiled.(interface {
+		ExportedFunctions() map[string]api.FunctionDefinition
+		ExportedMemories() map[string]api.MemoryDefinition
+		Exports() []api.Export
+		ExportedGlobalType(name string) (valType api.ValueType, mutable, ok bool)
+	})
+	if !ok {
+		return nil, false
+	}
+
+	ret := map[exportKey]string{}
+	for name, def := range c.ExportedFunctions() {
+		ret[exportKey{api.ExternTypeFunc, name}] = wasmdebug.Signature("", def.ParamTypes(), def.ResultTypes())
+	}
+	for name, def := range c.ExportedMemories() {
+		var desc strings.Builder
+		fmt.Fprintf(&desc, "{min: %d", def.Min())
+		if max, ok := def.Max(); ok {
+			fmt.Fprintf(&desc, ", max: %d", max)
+		}
+		if def.IsShared() {
+			desc.WriteString(", shared")
+		}
+		if def.Is64() {
+			desc.WriteString(", i64")
+		}
+		desc.WriteByte('}')
+		ret[exportKey{api.ExternTypeMemory, name}] = desc.String()
+	}
+	for _, e := range c.Exports() {
+		if e.Type != api.ExternTypeGlobal {
+			continue
+		}
+		if valType, mutable, ok := c.ExportedGlobalType(e.Name); ok {
+			desc := api.ValueTypeName(valType)
+			if mutable {
+				desc = "mut " + desc
+			}
+			ret[exportKey{api.ExternTypeGlobal, e.Name}] = desc
+		}
+	}
+	return ret, true
+}
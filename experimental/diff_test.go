@@ -0,0 +1,113 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestDiffExports(t *testing.T) {
+	ctx := context.Background()
+
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	i32, i64 := wasm.ValueTypeI32, wasm.ValueTypeI64
+	compile := func(m *wasm.Module) wazero.CompiledModule {
+		compiled, err := r.CompileModule(ctx, binary.EncodeModule(m))
+		require.NoError(t, err)
+		return compiled
+	}
+	i32Const := &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}}
+	i64Const := &wasm.ConstantExpression{Opcode: wasm.OpcodeI64Const, Data: []byte{0}}
+
+	before := compile(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}},
+			{},
+		},
+		FunctionSection: []wasm.Index{0, 1, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+		},
+		MemorySection: &wasm.Memory{Min: 1, Max: 2, IsMaxEncoded: true},
+		GlobalSection: []*wasm.Global{
+			{Type: &wasm.GlobalType{ValType: i32}, Init: i32Const},
+			{Type: &wasm.GlobalType{ValType: i32}, Init: i32Const},
+		},
+		ExportSection: []*wasm.Export{
+			{Type: wasm.ExternTypeFunc, Name: "add", Index: 0},
+			{Type: wasm.ExternTypeFunc, Name: "same", Index: 1},
+			{Type: wasm.ExternTypeFunc, Name: "gone", Index: 2},
+			{Type: wasm.ExternTypeMemory, Name: "memory", Index: 0},
+			{Type: wasm.ExternTypeGlobal, Name: "counter", Index: 0},
+			{Type: wasm.ExternTypeGlobal, Name: "offset", Index: 1},
+		},
+	})
+	after := compile(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i64, i64}, Results: []wasm.ValueType{i64}},
+			{},
+		},
+		FunctionSection: []wasm.Index{0, 1, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+		},
+		MemorySection: &wasm.Memory{Min: 1, Max: 3, IsMaxEncoded: true},
+		GlobalSection: []*wasm.Global{
+			{Type: &wasm.GlobalType{ValType: i32, Mutable: true}, Init: i32Const},
+			{Type: &wasm.GlobalType{ValType: i32}, Init: i32Const},
+			{Type: &wasm.GlobalType{ValType: i64}, Init: i64Const},
+		},
+		ExportSection: []*wasm.Export{
+			{Type: wasm.ExternTypeFunc, Name: "add", Index: 0},
+			{Type: wasm.ExternTypeFunc, Name: "same", Index: 1},
+			{Type: wasm.ExternTypeFunc, Name: "new", Index: 2},
+			{Type: wasm.ExternTypeMemory, Name: "memory", Index: 0},
+			{Type: wasm.ExternTypeGlobal, Name: "counter", Index: 0},
+			{Type: wasm.ExternTypeGlobal, Name: "offset", Index: 1},
+			{Type: wasm.ExternTypeGlobal, Name: "size", Index: 2},
+		},
+	})
+	empty := compile(&wasm.Module{})
+
+	t.Run("changes", func(t *testing.T) {
+		changes := DiffExports(before, after)
+		require.Equal(t, []ExportChange{
+			{Kind: ExportChanged, Type: api.ExternTypeFunc, Name: "add", Before: "(i32,i32) i32", After: "(i64,i64) i64"},
+			{Kind: ExportRemoved, Type: api.ExternTypeFunc, Name: "gone", Before: "()"},
+			{Kind: ExportAdded, Type: api.ExternTypeFunc, Name: "new", After: "()"},
+			{Kind: ExportChanged, Type: api.ExternTypeMemory, Name: "memory", Before: "{min: 1, max: 2}", After: "{min: 1, max: 3}"},
+			{Kind: ExportChanged, Type: api.ExternTypeGlobal, Name: "counter", Before: "i32", After: "mut i32"},
+			{Kind: ExportAdded, Type: api.ExternTypeGlobal, Name: "size", After: "i64"},
+		}, changes)
+		require.Equal(t, "changed func add: (i32,i32) i32 -> (i64,i64) i64", changes[0].String())
+	})
+
+	t.Run("same module", func(t *testing.T) {
+		require.Nil(t, DiffExports(before, before))
+	})
+
+	t.Run("from empty", func(t *testing.T) {
+		changes := DiffExports(empty, before)
+		require.Equal(t, 6, len(changes))
+		for _, c := range changes {
+			require.Equal(t, ExportAdded, c.Kind)
+		}
+	})
+
+	t.Run("not a compiled module", func(t *testing.T) {
+		require.Nil(t, DiffExports(before, "foo"))
+		require.Nil(t, DiffExports(nil, before))
+	})
+}
@@ -0,0 +1,203 @@
+// Package fuzz runs untrusted WebAssembly binaries in a locked-down runtime,
+// classifying how they fail. This standardizes differential fuzzing between
+// the interpreter and the compiler.
+//
+// Note: This is a separate package from experimental, as it uses wazero,
+// which imports experimental.
+package fuzz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+const (
+	// MemoryLimitPages is the maximum size of memory of an input, in pages
+	// of 64KiB, which is 16MiB.
+	MemoryLimitPages = 256
+
+	// InstructionBudget is the count of instructions an input may execute on
+	// the interpreter, including its start function.
+	InstructionBudget = 10_000_000
+
+	// Timeout bounds the time to compile and instantiate an input, and
+	// separately, to call its entry.
+	Timeout = time.Second
+)
+
+// instructionBudget and timeout are InstructionBudget and Timeout, except in
+// tests, which lower the budget so it always trips before the timeout.
+var (
+	instructionBudget uint64 = InstructionBudget
+	timeout                  = Timeout
+)
+
+// Engine is the engine to run an input with.
+type Engine byte
+
+const (
+	// EngineInterpreter runs with wazero.NewRuntimeConfigInterpreter.
+	EngineInterpreter Engine = iota
+	// EngineCompiler runs with wazero.NewRuntimeConfigCompiler.
+	EngineCompiler
+)
+
+// String implements fmt.Stringer
+func (e Engine) String() string {
+	switch e {
+	case EngineInterpreter:
+		return "interpreter"
+	case EngineCompiler:
+		return "compiler"
+	}
+	return fmt.Sprintf("engine(%d)", byte(e))
+}
+
+// ErrorKind classifies an Error returned by OneInput.
+type ErrorKind byte
+
+const (
+	// ErrorKindInvalid means the input couldn't be compiled or instantiated,
+	// e.g. it is malformed, fails validation or has imports.
+	ErrorKindInvalid ErrorKind = iota
+	// ErrorKindTrap means the input trapped, e.g. on unreachable.
+	ErrorKindTrap
+	// ErrorKindGasExhausted means the input executed more than
+	// InstructionBudget instructions.
+	ErrorKindGasExhausted
+	// ErrorKindTimeout means the input ran longer than Timeout.
+	ErrorKindTimeout
+)
+
+// String implements fmt.Stringer
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorKindInvalid:
+		return "invalid"
+	case ErrorKindTrap:
+		return "trap"
+	case ErrorKindGasExhausted:
+		return "gas exhausted"
+	case ErrorKindTimeout:
+		return "timeout"
+	}
+	return fmt.Sprintf("kind(%d)", byte(k))
+}
+
+// Error is the error returned by OneInput when the input didn't return.
+type Error struct {
+	// Kind classifies Err.
+	Kind ErrorKind
+	// Err is the error returned by wazero.
+	Err error
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+}
+
+// Unwrap allows errors.Is and errors.As on Err.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// OneInput compiles the binary with the engine, instantiates it and calls
+// its exported function named entry with params, returning its results or an
+// *Error classifying why it didn't return.
+//
+// The input runs in a new runtime, closed before returning, so no resources
+// leak across inputs. The runtime is locked down: it has no host modules,
+// such as WASI, memory is limited to MemoryLimitPages, and compiling with
+// instantiation, then the call, are each limited to Timeout. Start functions other than the start
+// section, such as "_start", are not called.
+//
+// Usage:
+//
+//	func FuzzEngines(f *testing.F) {
+//		f.Fuzz(func(t *testing.T, bin []byte) {
+//			want, wantErr := fuzz.OneInput(fuzz.EngineInterpreter, bin, "run", nil)
+//			have, haveErr := fuzz.OneInput(fuzz.EngineCompiler, bin, "run", nil)
+//			// compare results and error kinds...
+//		})
+//	}
+//
+// # Notes
+//
+//   - InstructionBudget only applies to the interpreter, as the compiler
+//     doesn't support it. So, an input which loops forever is
+//     ErrorKindGasExhausted on the interpreter, but ErrorKindTimeout on the
+//     compiler. Differential fuzzing should treat these kinds as equal.
+//   - An error is returned, which isn't an *Error, if the engine isn't
+//     supported on this platform.
+func OneInput(engine Engine, binary []byte, entry string, params []uint64) ([]uint64, error) {
+	var rConfig wazero.RuntimeConfig
+	// Calls only honor the context deadline with a function timeout.
+	mConfig := wazero.NewModuleConfig().WithStartFunctions().WithFunctionTimeout(entry, timeout)
+	switch engine {
+	case EngineInterpreter:
+		rConfig = wazero.NewRuntimeConfigInterpreter()
+		mConfig = mConfig.WithInstructionBudget(instructionBudget)
+	case EngineCompiler:
+		if !platform.CompilerSupported() {
+			return nil, fmt.Errorf("%s not supported on this platform", engine)
+		}
+		rConfig = wazero.NewRuntimeConfigCompiler()
+	default:
+		return nil, fmt.Errorf("unknown %s", engine)
+	}
+	rConfig = rConfig.WithMemoryLimitPages(MemoryLimitPages)
+
+	// Compiling and instantiating share this deadline. The call has its own,
+	// from the function timeout, so it isn't shortened by the time they took.
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	r := wazero.NewRuntimeWithConfig(ctx, rConfig)
+	defer r.Close(context.Background()) // This closes everything this Runtime created.
+
+	compiled, err := r.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, &Error{Kind: ErrorKindInvalid, Err: err}
+	}
+
+	mod, err := r.InstantiateModule(ctx, compiled, mConfig)
+	if err != nil { // e.g. the start function trapped or an import is missing.
+		return nil, classify(err, ErrorKindInvalid)
+	}
+
+	fn := mod.ExportedFunction(entry)
+	if fn == nil {
+		return nil, &Error{Kind: ErrorKindInvalid, Err: fmt.Errorf("function[%s] not exported", entry)}
+	}
+	if want := len(fn.Definition().ParamTypes()); want != len(params) {
+		return nil, &Error{Kind: ErrorKindInvalid, Err: fmt.Errorf("function[%s] has %d params, but passed %d", entry, want, len(params))}
+	}
+
+	results, err := fn.Call(context.Background(), params...)
+	if err != nil {
+		return nil, classify(err, ErrorKindTrap)
+	}
+	return results, nil
+}
+
+// classify returns err as an *Error, using kind unless err is a timeout or
+// trap.
+func classify(err error, kind ErrorKind) *Error {
+	var trap *wasmruntime.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = ErrorKindTimeout
+	case errors.Is(err, wasmruntime.ErrRuntimeInstructionBudgetExhausted):
+		kind = ErrorKindGasExhausted
+	case errors.As(err, &trap):
+		kind = ErrorKindTrap
+	}
+	return &Error{Kind: kind, Err: err}
+}
@@ -0,0 +1,122 @@
+package fuzz
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestOneInput(t *testing.T) {
+	// Lower the budget, so the interpreter exhausts it long before the
+	// timeout, regardless of machine speed, -race or coverage.
+	defer func(budget uint64) { instructionBudget = budget }(instructionBudget)
+	instructionBudget = 10_000
+
+	i32 := wasm.ValueTypeI32
+	// module exports "run", which has the given body and returns an i32.
+	module := func(body ...byte) []byte {
+		return binary.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+			FunctionSection: []wasm.Index{0},
+			CodeSection:     []*wasm.Code{{Body: append(body, wasm.OpcodeEnd)}},
+			MemorySection:   &wasm.Memory{Min: 1, Max: MemoryLimitPages},
+			ExportSection:   []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "run", Index: 0}},
+		})
+	}
+	loop := module(wasm.OpcodeLoop, 0x40, wasm.OpcodeBr, 0, wasm.OpcodeEnd, wasm.OpcodeI32Const, 0)
+
+	engines := []Engine{EngineInterpreter}
+	if platform.CompilerSupported() {
+		engines = append(engines, EngineCompiler)
+	}
+
+	tests := []struct {
+		name            string
+		binary          []byte
+		entry           string
+		params          []uint64
+		expectedResults []uint64
+		expectedKind    ErrorKind
+		// expectedKinds overrides expectedKind per engine.
+		expectedKinds map[Engine]ErrorKind
+	}{
+		{
+			name:            "returns",
+			binary:          module(wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add),
+			entry:           "run",
+			params:          []uint64{41},
+			expectedResults: []uint64{42},
+		},
+		{
+			name:         "malformed",
+			binary:       []byte("wasm"),
+			entry:        "run",
+			expectedKind: ErrorKindInvalid,
+		},
+		{
+			name:         "not exported",
+			binary:       module(wasm.OpcodeI32Const, 0),
+			entry:        "nope",
+			params:       []uint64{0},
+			expectedKind: ErrorKindInvalid,
+		},
+		{
+			name:         "wrong params",
+			binary:       module(wasm.OpcodeI32Const, 0),
+			entry:        "run",
+			expectedKind: ErrorKindInvalid,
+		},
+		{
+			name:         "trap",
+			binary:       module(wasm.OpcodeUnreachable),
+			entry:        "run",
+			params:       []uint64{0},
+			expectedKind: ErrorKindTrap,
+		},
+		{
+			name:         "loop",
+			binary:       loop,
+			entry:        "run",
+			params:       []uint64{0},
+			expectedKind: ErrorKindGasExhausted,
+			expectedKinds: map[Engine]ErrorKind{
+				EngineCompiler: ErrorKindTimeout,
+			},
+		},
+	}
+
+	for _, e := range engines {
+		engine := e
+		t.Run(engine.String(), func(t *testing.T) {
+			for _, tt := range tests {
+				tc := tt
+				t.Run(tc.name, func(t *testing.T) {
+					results, err := OneInput(engine, tc.binary, tc.entry, tc.params)
+					if tc.expectedResults != nil {
+						require.NoError(t, err)
+						require.Equal(t, tc.expectedResults, results)
+						return
+					}
+
+					expectedKind := tc.expectedKind
+					if kind, ok := tc.expectedKinds[engine]; ok {
+						expectedKind = kind
+					}
+					var fuzzErr *Error
+					require.True(t, errors.As(err, &fuzzErr), err)
+					require.Equal(t, expectedKind, fuzzErr.Kind, err.Error())
+					require.Nil(t, results)
+				})
+			}
+		})
+	}
+}
+
+func TestOneInput_UnknownEngine(t *testing.T) {
+	_, err := OneInput(Engine(9), nil, "run", nil)
+	require.EqualError(t, err, "unknown engine(9)")
+}
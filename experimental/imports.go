@@ -0,0 +1,43 @@
+package experimental
+
+import "github.com/tetratelabs/wazero/api"
+
+// ImportRequirement is the exact function type an imported function must
+// have for the importing module to link.
+type ImportRequirement struct {
+	// ModuleName and Name are the import's module and name, e.g. "env" and
+	// "abort".
+	ModuleName, Name string
+
+	// ParamTypes are the parameter types the import requires.
+	ParamTypes []api.ValueType
+
+	// ResultTypes are the result types the import requires.
+	ResultTypes []api.ValueType
+}
+
+// ImportRequirements returns the function type of each function imported by
+// the compiled module, so that embedders can build matching host functions
+// programmatically. The compiled module must be a wazero.CompiledModule,
+// otherwise this returns nil.
+//
+// Requirements are in import order, which is the same order as
+// wazero.CompiledModule ImportedFunctions.
+func ImportRequirements(compiled interface{}) (requirements []ImportRequirement) {
+	c, ok := compiled.(interface {
+		ImportedFunctions() []api.FunctionDefinition
+	})
+	if !ok {
+		return nil
+	}
+	for _, def := range c.ImportedFunctions() {
+		moduleName, name, _ := def.Import()
+		requirements = append(requirements, ImportRequirement{
+			ModuleName:  moduleName,
+			Name:        name,
+			ParamTypes:  def.ParamTypes(),
+			ResultTypes: def.ResultTypes(),
+		})
+	}
+	return
+}
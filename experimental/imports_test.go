@@ -0,0 +1,44 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestImportRequirements(t *testing.T) {
+	ctx := context.Background()
+
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	compiled, err := r.CompileModule(ctx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI64}, Results: []wasm.ValueType{wasm.ValueTypeF32}},
+			{},
+		},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "b", Type: wasm.ExternTypeFunc, DescFunc: 0},
+			{Module: "env", Name: "memory", Type: wasm.ExternTypeMemory, DescMem: &wasm.Memory{Min: 1}},
+			{Module: "env", Name: "a", Type: wasm.ExternTypeFunc, DescFunc: 1},
+		},
+	}))
+	require.NoError(t, err)
+
+	require.Equal(t, []ImportRequirement{
+		{
+			ModuleName:  "env",
+			Name:        "b",
+			ParamTypes:  []api.ValueType{api.ValueTypeI32, api.ValueTypeI64},
+			ResultTypes: []api.ValueType{api.ValueTypeF32},
+		},
+		{ModuleName: "env", Name: "a"},
+	}, ImportRequirements(compiled))
+	require.Nil(t, ImportRequirements(nil))
+}
@@ -0,0 +1,55 @@
+package experimental
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// ReadIovecs returns a view of each segment of the WASI iovec (or ciovec)
+// array of iovsLen elements starting at offset iovs. Each element is a pair
+// of little-endian uint32 values: the offset of the segment and its length.
+// This is a building block for host functions implementing scatter-gather
+// syscalls, such as "fd_read" or "fd_write" in "wasi_snapshot_preview1".
+//
+// This returns false if the array or any segment is out of range of the
+// memory.
+//
+// For example, this writes the segments of a ciovec array to a file:
+//
+//	segments, ok := experimental.ReadIovecs(ctx, mod.Memory(), iovs, iovsLen)
+//	if !ok {
+//		return ErrnoFault
+//	}
+//	for _, b := range segments {
+//		if _, err := f.Write(b); err != nil {
+//			return ErrnoIo
+//		}
+//	}
+//
+// Note: The segments are views of the memory, so writes to them are visible
+// to the guest. They become invalid if the memory grows.
+func ReadIovecs(ctx context.Context, mem api.Memory, iovs, iovsLen uint32) ([][]byte, bool) {
+	buf, ok := mem.Read(ctx, 0, mem.Size(ctx))
+	if !ok {
+		return nil, false
+	}
+
+	size := uint64(len(buf))
+	if uint64(iovs)+uint64(iovsLen)*8 > size {
+		return nil, false
+	}
+
+	segments := make([][]byte, iovsLen)
+	for i := range segments {
+		iov := buf[uint64(iovs)+uint64(i)*8:]
+		offset := uint64(binary.LittleEndian.Uint32(iov))
+		length := uint64(binary.LittleEndian.Uint32(iov[4:]))
+		if offset+length > size {
+			return nil, false
+		}
+		segments[i] = buf[offset : offset+length : offset+length]
+	}
+	return segments, true
+}
@@ -0,0 +1,78 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestReadIovecs(t *testing.T) {
+	ctx := context.Background()
+	mem := wasm.NewMemoryInstance(&wasm.Memory{Min: 1, Cap: 1, Max: 1})
+	size := mem.Size(ctx)
+
+	// Scatter-gather layout: the iovec array at offset 1 points to segments
+	// after it, out of order, including an empty one.
+	iovs := uint32(1)
+	require.True(t, mem.Write(ctx, iovs, []byte{
+		30, 0, 0, 0, // iovs[0].offset
+		4, 0, 0, 0, // iovs[0].length
+		25, 0, 0, 0, // iovs[1].offset
+		2, 0, 0, 0, // iovs[1].length
+		0, 0, 0, 0, // iovs[2].offset
+		0, 0, 0, 0, // iovs[2].length
+	}))
+	require.True(t, mem.WriteString(ctx, 25, "ro"))
+	require.True(t, mem.WriteString(ctx, 30, "waze"))
+
+	segments, ok := ReadIovecs(ctx, mem, iovs, 3)
+	require.True(t, ok)
+	require.Equal(t, [][]byte{[]byte("waze"), []byte("ro"), {}}, segments)
+
+	// Segments are views of memory.
+	segments[1][0] = 'R'
+	buf, ok := mem.Read(ctx, 25, 2)
+	require.True(t, ok)
+	require.Equal(t, "Ro", string(buf))
+
+	t.Run("empty", func(t *testing.T) {
+		segments, ok := ReadIovecs(ctx, mem, size, 0)
+		require.True(t, ok)
+		require.Equal(t, 0, len(segments))
+	})
+
+	t.Run("segment ends at memory end", func(t *testing.T) {
+		require.True(t, mem.WriteUint32Le(ctx, 100, size-1))
+		require.True(t, mem.WriteUint32Le(ctx, 104, 1))
+		segments, ok := ReadIovecs(ctx, mem, 100, 1)
+		require.True(t, ok)
+		require.Equal(t, 1, len(segments[0]))
+	})
+
+	tests := []struct {
+		name          string
+		iovs, iovsLen uint32
+		iov           [2]uint32 // offset and length of the segment at offset 200
+	}{
+		{name: "array out of range", iovs: size - 4, iovsLen: 1},
+		{name: "array length overflows", iovs: 0, iovsLen: 0xffffffff},
+		{name: "segment offset out of range", iovs: 200, iovsLen: 1, iov: [2]uint32{size, 1}},
+		{name: "segment length out of range", iovs: 200, iovsLen: 1, iov: [2]uint32{size - 1, 2}},
+		{name: "segment overflows", iovs: 200, iovsLen: 1, iov: [2]uint32{0xffffffff, 0xffffffff}},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			require.True(t, mem.WriteUint32Le(ctx, 200, tc.iov[0]))
+			require.True(t, mem.WriteUint32Le(ctx, 204, tc.iov[1]))
+
+			segments, ok := ReadIovecs(ctx, mem, tc.iovs, tc.iovsLen)
+			require.False(t, ok)
+			require.Nil(t, segments)
+		})
+	}
+}
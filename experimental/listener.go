@@ -2,6 +2,7 @@ package experimental
 
 import (
 	"context"
+	"time"
 
 	"github.com/tetratelabs/wazero/api"
 )
@@ -46,6 +47,22 @@ type FunctionListener interface {
 	After(ctx context.Context, def api.FunctionDefinition, err error, resultValues []uint64)
 }
 
+// TimedFunctionListener is a FunctionListener which also receives the wall
+// time a function took, e.g. to build flame graphs. Implementing this is a
+// capability flag: the engine only measures time for listeners which do, so
+// others have no overhead.
+type TimedFunctionListener interface {
+	FunctionListener
+
+	// AfterWithDuration is invoked after a function is called, instead of
+	// After. Params are the same as After, except elapsed.
+	//
+	// elapsed is the wall time between Before returning and the function
+	// returning. This includes the time of any functions it called, but not
+	// the time spent in Before or After.
+	AfterWithDuration(ctx context.Context, def api.FunctionDefinition, err error, resultValues []uint64, elapsed time.Duration)
+}
+
 // TODO: We need to add tests to enginetest to ensure contexts nest. A good test can use a combination of call and call
 // indirect in terms of depth and breadth. The test could show a tree 3 calls deep where the there are a couple calls at
 // each depth under the root. The main thing this can help prevent is accidentally swapping the context internally.
@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"testing"
+	"time"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
@@ -71,3 +72,56 @@ func TestFunctionListenerFactory(t *testing.T) {
 		"fn2": {},
 	}, factory.m)
 }
+
+// timedListener implements TimedFunctionListener, recording the elapsed time
+// of each function by its debug name.
+type timedListener struct {
+	elapsed map[string]time.Duration
+}
+
+func (l *timedListener) NewListener(api.FunctionDefinition) FunctionListener {
+	return l
+}
+
+func (l *timedListener) Before(ctx context.Context, _ api.FunctionDefinition, _ []uint64) context.Context {
+	return ctx
+}
+
+func (l *timedListener) After(context.Context, api.FunctionDefinition, error, []uint64) {
+	panic("After should not be called on a TimedFunctionListener")
+}
+
+func (l *timedListener) AfterWithDuration(_ context.Context, def api.FunctionDefinition, _ error, _ []uint64, elapsed time.Duration) {
+	l.elapsed[def.DebugName()] = elapsed
+}
+
+func TestTimedFunctionListener(t *testing.T) {
+	listener := &timedListener{map[string]time.Duration{}}
+	ctx := context.WithValue(context.Background(), FunctionListenerFactoryKey{}, listener)
+
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+	defer r.Close(ctx) // This closes everything this Runtime created.
+
+	sleep := 5 * time.Millisecond
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func() { time.Sleep(sleep) }).Export("sleep").
+		Instantiate(ctx, r)
+	require.NoError(t, err)
+
+	mod, err := r.InstantiateModuleFromBinary(ctx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "sleep", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}}},
+		ExportSection:   []*wasm.Export{{Name: "run", Type: wasm.ExternTypeFunc, Index: 1}},
+		NameSection:     &wasm.NameSection{ModuleName: "test"},
+	}))
+	require.NoError(t, err)
+
+	_, err = mod.ExportedFunction("run").Call(ctx)
+	require.NoError(t, err)
+
+	// The elapsed time of the caller includes its callee.
+	require.True(t, listener.elapsed["env.sleep"] >= sleep, listener.elapsed)
+	require.True(t, listener.elapsed["test.$1"] >= listener.elapsed["env.sleep"], listener.elapsed)
+}
@@ -104,7 +104,7 @@ func (l *loggingListener) writeFuncExit(message *strings.Builder, err error, val
 		message.WriteString("error: ")
 		message.WriteString(err.Error())
 		return
-	} else if l.isWasi {
+	} else if l.isWasi && len(vals) > 0 { // proc_exit has no errno
 		message.WriteString(wasi_snapshot_preview1.ErrnoName(uint32(vals[0])))
 		return
 	}
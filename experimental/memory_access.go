@@ -0,0 +1,39 @@
+package experimental
+
+import "context"
+
+// MemoryAccessHookKey is a context.Context Value key. Its associated value
+// should be a MemoryAccessHook. Use WithMemoryAccessHook to set it.
+//
+// Note: This is interpreter-only for now!
+type MemoryAccessHookKey struct{}
+
+// MemoryAccessHook is called before a guest reads or writes length bytes of
+// its memory at offset, e.g. to build a shadow memory or taint tracker.
+//
+// # Notes
+//
+//   - This is called for loads, stores and bulk memory operations, even if
+//     the access subsequently traps as out of bounds.
+//   - Accesses by host functions, such as api.Memory Read, are not reported.
+//   - This is called on the goroutine of the function call, so it must not
+//     block or the guest will stall.
+type MemoryAccessHook func(isWrite bool, offset, length uint32)
+
+// WithMemoryAccessHook returns a context which reports each guest memory
+// access of a function call to the hook. This is strictly opt-in, as it slows
+// down every memory instruction.
+//
+// Usage:
+//
+//	ctx = experimental.WithMemoryAccessHook(ctx, func(isWrite bool, offset, length uint32) {
+//		if isWrite {
+//			shadow.taint(offset, length)
+//		}
+//	})
+//	_, err := mod.ExportedFunction("run").Call(ctx)
+//
+// Note: This is interpreter-only for now! The compiler ignores the hook.
+func WithMemoryAccessHook(ctx context.Context, hook MemoryAccessHook) context.Context {
+	return context.WithValue(ctx, MemoryAccessHookKey{}, hook)
+}
@@ -0,0 +1,58 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestWithMemoryAccessHook(t *testing.T) {
+	type access struct {
+		isWrite        bool
+		offset, length uint32
+	}
+	var accesses []access
+	ctx := WithMemoryAccessHook(context.Background(), func(isWrite bool, offset, length uint32) {
+		accesses = append(accesses, access{isWrite, offset, length})
+	})
+
+	// Define a module which stores a value, then loads it back.
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeI32Const, 8, wasm.OpcodeI32Const, 42,
+			wasm.OpcodeI32Store16, 0x1, 0x2, // alignment=1, offset=2
+			wasm.OpcodeI32Const, 8,
+			wasm.OpcodeI32Load, 0x2, 0x2, // alignment=2, offset=2
+			wasm.OpcodeEnd,
+		}}},
+		MemorySection: &wasm.Memory{Min: 1},
+		ExportSection: []*wasm.Export{{Name: "run", Type: wasm.ExternTypeFunc, Index: 0}},
+	})
+
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+	defer r.Close(ctx)
+
+	mod, err := r.InstantiateModuleFromBinary(ctx, bin)
+	require.NoError(t, err)
+
+	// Without the hook in the context, nothing is reported.
+	results, err := mod.ExportedFunction("run").Call(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+	require.Zero(t, len(accesses))
+
+	results, err = mod.ExportedFunction("run").Call(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+	require.Equal(t, []access{
+		{isWrite: true, offset: 10, length: 2},
+		{isWrite: false, offset: 10, length: 4},
+	}, accesses)
+}
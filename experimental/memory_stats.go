@@ -0,0 +1,42 @@
+package experimental
+
+import "context"
+
+// MemoryStatsKey is a context.Context Value key. Its associated value should
+// be a *MemoryStats. Use WithMemoryStats to set it.
+type MemoryStatsKey struct{}
+
+// MemoryStats is the size of the memory of a module over an api.Function
+// Call, in 64KB pages. This helps right-size wazero.RuntimeConfig
+// WithMemoryLimitPages.
+type MemoryStats struct {
+	// InitialPages is the size of memory when the call began.
+	InitialPages uint32
+
+	// PeakPages is the largest size of memory reached during the call,
+	// including any growth by "memory.grow" or a host function.
+	PeakPages uint32
+}
+
+// WithMemoryStats returns a context which records the memory size of each
+// function call into stats, overwriting the previous call's.
+//
+// Usage:
+//
+//	var stats experimental.MemoryStats
+//	ctx = experimental.WithMemoryStats(ctx, &stats)
+//	_, err := mod.ExportedFunction("run").Call(ctx)
+//	fmt.Printf("memory grew from %d to %d pages\n", stats.InitialPages, stats.PeakPages)
+//
+// # Notes
+//
+//   - Stats are recorded even if the call fails.
+//   - Stats are zero when the module has no memory.
+//   - A host function which calls back into the guest with the same context
+//     overwrites stats, until the outer call returns and records its own.
+//   - Reset shrinks memory to its initial size, so the next call reports
+//     that as InitialPages. Calling Reset during a call, e.g. from a host
+//     function, isn't supported: PeakPages would be the size after it.
+func WithMemoryStats(ctx context.Context, stats *MemoryStats) context.Context {
+	return context.WithValue(ctx, MemoryStatsKey{}, stats)
+}
@@ -0,0 +1,57 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestWithMemoryStats(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	// Define a module which grows its memory by its param.
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0},
+		MemorySection:   &wasm.Memory{Min: 1, Cap: 1, Max: 10, IsMaxEncoded: true},
+		CodeSection: []*wasm.Code{{
+			Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeMemoryGrow, 0, wasm.OpcodeEnd},
+		}},
+		ExportSection: []*wasm.Export{{Name: "grow", Type: wasm.ExternTypeFunc, Index: 0}},
+	})
+
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	mod, err := r.InstantiateModuleFromBinary(ctx, bin)
+	require.NoError(t, err)
+	grow := mod.ExportedFunction("grow")
+
+	var stats MemoryStats
+	ctx = WithMemoryStats(ctx, &stats)
+
+	_, err = grow.Call(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, MemoryStats{InitialPages: 1, PeakPages: 3}, stats)
+
+	// Stats are reset on each call.
+	_, err = grow.Call(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, MemoryStats{InitialPages: 3, PeakPages: 3}, stats)
+
+	// A failed grow doesn't change the peak.
+	_, err = grow.Call(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, MemoryStats{InitialPages: 3, PeakPages: 3}, stats)
+
+	// Reset between calls shrinks memory, which the next call reports.
+	require.NoError(t, Reset(mod))
+	_, err = grow.Call(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, MemoryStats{InitialPages: 1, PeakPages: 1}, stats)
+}
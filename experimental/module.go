@@ -0,0 +1,89 @@
+package experimental
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// InternalModule is an unstable view of the internals of a module instance,
+// for power-user host functions such as a "dlopen" analog, which need to
+// dynamically link functions via tables. See ModuleFunc.
+//
+// # Safety
+//
+// This gives access to state which is normally guarded by validation, so
+// misuse can break the guest in ways the WebAssembly specification doesn't
+// allow. The host function using this is responsible for the following:
+//
+//   - Only use the module within the host function call it was passed to.
+//   - Never call functions of the module concurrently with another call.
+//   - Don't type assert Engine or retain it, as it is an implementation
+//     detail that may change in any release.
+//
+// # Notes
+//
+//   - This is an experimental API and may change or be removed in any release.
+//   - Tables and type IDs are those of the calling module.
+type InternalModule interface {
+	api.Module
+
+	// NumTables returns the count of tables in the module, including imported
+	// ones.
+	NumTables() uint32
+
+	// TableSize returns the count of elements in the table at tableIdx, or
+	// false if there is no such table.
+	TableSize(tableIdx uint32) (size uint32, ok bool)
+
+	// TypeIDs returns the type ID of each type in the module's type section.
+	// Type IDs are unique per runtime, so two functions have the same
+	// signature when their type IDs are equal.
+	TypeIDs() []uint32
+
+	// LookupTableFunction returns the function at the offset in the table at
+	// tableIdx, as call_indirect would. This errs with the same traps as
+	// call_indirect, e.g. when the offset is out of range, the element is
+	// null, or the function's type ID isn't typeID.
+	//
+	// Like emscripten "invoke_" functions, the element is resolved to a
+	// function of this module. Tables should only hold functions defined in
+	// or imported into this module.
+	LookupTableFunction(tableIdx, offset, typeID uint32) (api.Function, error)
+
+	// Engine returns the engine executing the module. Its type is an
+	// implementation detail, exposed only for troubleshooting.
+	Engine() interface{}
+}
+
+// ModuleFunc is an api.GoModuleFunction which receives the calling module as
+// an InternalModule. Use this with HostFunctionBuilder.WithGoModuleFunction.
+//
+// For example, this calls the function at the table offset in parameter
+// zero, if its signature matches the type at index zero:
+//
+//	builder.WithGoModuleFunction(experimental.ModuleFunc(func(ctx context.Context, mod experimental.InternalModule, stack []uint64) {
+//		fn, err := mod.LookupTableFunction(0, uint32(stack[0]), mod.TypeIDs()[0])
+//		if err != nil {
+//			panic(err)
+//		}
+//		if _, err = fn.Call(ctx); err != nil {
+//			panic(err)
+//		}
+//	}), []api.ValueType{api.ValueTypeI32}, []api.ValueType{})
+//
+// Note: This is an experimental API, see the safety obligations on
+// InternalModule.
+type ModuleFunc func(ctx context.Context, mod InternalModule, stack []uint64)
+
+// Call implements api.GoModuleFunction.Call.
+//
+// This panics if mod isn't an InternalModule, e.g. a wrapper of one.
+func (f ModuleFunc) Call(ctx context.Context, mod api.Module, stack []uint64) {
+	im, ok := mod.(InternalModule)
+	if !ok {
+		panic(fmt.Errorf("unsupported module: %v", mod))
+	}
+	f(ctx, im, stack)
+}
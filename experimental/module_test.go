@@ -0,0 +1,111 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestModuleFunc(t *testing.T) {
+	ctx := context.Background()
+
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	// dlcall calls the function at the table offset in parameter zero, if it
+	// has the signature of type one: () -> i32.
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().
+		WithGoModuleFunction(ModuleFunc(func(ctx context.Context, mod InternalModule, stack []uint64) {
+			require.Equal(t, uint32(1), mod.NumTables())
+			size, ok := mod.TableSize(0)
+			require.True(t, ok)
+			require.Equal(t, uint32(3), size)
+			_, ok = mod.TableSize(1)
+			require.False(t, ok)
+			require.NotNil(t, mod.Engine())
+
+			fn, err := mod.LookupTableFunction(0, uint32(stack[0]), mod.TypeIDs()[1])
+			if err != nil {
+				panic(err)
+			}
+			results, err := fn.Call(ctx)
+			if err != nil {
+				panic(err)
+			}
+			stack[0] = results[0]
+		}), []api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}).
+		Export("dlcall").
+		Instantiate(ctx, r)
+	require.NoError(t, err)
+
+	// lib defines a function which is put in the table of mod, to show table
+	// functions are resolved in the module which defines them.
+	_, err = r.InstantiateModuleFromBinary(ctx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeI32Const, 9, wasm.OpcodeEnd}}},
+		ExportSection:   []*wasm.Export{{Name: "nine", Type: wasm.ExternTypeFunc, Index: 0}},
+		NameSection:     &wasm.NameSection{ModuleName: "lib"},
+	}))
+	require.NoError(t, err)
+
+	one, two, three := wasm.Index(1), wasm.Index(2), wasm.Index(3)
+	mod, err := r.InstantiateModuleFromBinary(ctx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}},
+			{Results: []wasm.ValueType{wasm.ValueTypeI32}},
+		},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "dlcall", Type: wasm.ExternTypeFunc, DescFunc: 0},
+			{Module: "lib", Name: "nine", Type: wasm.ExternTypeFunc, DescFunc: 1},
+		},
+		FunctionSection: []wasm.Index{1, 1, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeI32Const, 42, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeI32Const, 7, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+		},
+		TableSection: []*wasm.Table{{Min: 3, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&two, &three, &one},
+			Type:       wasm.RefTypeFuncref,
+			Mode:       wasm.ElementModeActive,
+		}},
+		ExportSection: []*wasm.Export{{Name: "run", Type: wasm.ExternTypeFunc, Index: 4}},
+	}))
+	require.NoError(t, err)
+
+	run := mod.ExportedFunction("run")
+
+	results, err := run.Call(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+
+	results, err = run.Call(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{7}, results)
+
+	results, err = run.Call(ctx, 2) // imported
+	require.NoError(t, err)
+	require.Equal(t, []uint64{9}, results)
+
+	_, err = run.Call(ctx, 3)
+	require.Contains(t, err.Error(), "invalid table access")
+
+	t.Run("unsupported module", func(t *testing.T) {
+		// wrapper is an api.Module which isn't an InternalModule.
+		type wrapper struct{ api.Module }
+
+		f := ModuleFunc(func(context.Context, InternalModule, []uint64) {})
+		err := require.CapturePanic(func() { f.Call(ctx, wrapper{mod}, nil) })
+		require.EqualError(t, err, "unsupported module: Module[]")
+	})
+}
@@ -0,0 +1,47 @@
+package experimental
+
+import "github.com/tetratelabs/wazero/api"
+
+// CompiledFunction describes the native code the compiler generated for a
+// function of a module instance. See CompiledFunctionOf.
+//
+// This is for power users who inspect compiled code, e.g. to disassemble or
+// symbolize it in a native profiler. CodeAddress is NOT callable outside
+// wazero: compiled code is entered with registers pointing to wazero's
+// internal call engine, whose layout and exit statuses aren't exported and
+// change between releases. To call the function, use api.Function.
+//
+// # Notes
+//
+//   - This is an experimental API and may change or be removed in any release.
+//   - Addresses are only valid until the module is closed, and are invalid
+//     if the runtime moved to another engine.
+type CompiledFunction struct {
+	// CodeAddress is the address of the first instruction of the function.
+	// It is only valid to read, not to jump to.
+	CodeAddress uintptr
+
+	// ModuleAddress is the address of the module instance the function
+	// belongs to, which compiled code uses to initialize its memory, globals
+	// and tables on entry.
+	ModuleAddress uintptr
+
+	// FunctionPointer is the opaque value a funcref table element or global
+	// holds for this function.
+	FunctionPointer uint64
+}
+
+// CompiledFunctionOf returns the native code of the function at funcIndex
+// in the module, or false if the module isn't compiled to native code, e.g.
+// it runs in the interpreter, or there is no such function.
+//
+// Note: funcIndex is in the function index space, so imported functions
+// come first.
+func CompiledFunctionOf(mod api.Module, funcIndex uint32) (CompiledFunction, bool) {
+	if c, ok := mod.(interface {
+		CompiledFunction(funcIndex uint32) (CompiledFunction, bool)
+	}); ok {
+		return c.CompiledFunction(funcIndex)
+	}
+	return CompiledFunction{}, false
+}
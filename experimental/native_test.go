@@ -0,0 +1,55 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestCompiledFunctionOf(t *testing.T) {
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+	})
+
+	t.Run("interpreter", func(t *testing.T) {
+		ctx := context.Background()
+		r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+		defer r.Close(ctx)
+
+		mod, err := r.InstantiateModuleFromBinary(ctx, bin)
+		require.NoError(t, err)
+
+		_, ok := CompiledFunctionOf(mod, 0)
+		require.False(t, ok)
+	})
+
+	t.Run("compiler", func(t *testing.T) {
+		if !platform.CompilerSupported() {
+			t.Skip()
+		}
+
+		ctx := context.Background()
+		r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigCompiler())
+		defer r.Close(ctx)
+
+		mod, err := r.InstantiateModuleFromBinary(ctx, bin)
+		require.NoError(t, err)
+
+		fn, ok := CompiledFunctionOf(mod, 0)
+		require.True(t, ok)
+		require.NotEqual(t, uintptr(0), fn.CodeAddress)
+		require.NotEqual(t, uintptr(0), fn.ModuleAddress)
+		require.NotEqual(t, uint64(0), fn.FunctionPointer)
+
+		_, ok = CompiledFunctionOf(mod, 1)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,50 @@
+package experimental
+
+import "github.com/tetratelabs/wazero/api"
+
+// ReachableFunctions returns the indices of functions reachable from the
+// exported functions of the given names, for tree-shaking analysis. The
+// compiled module must be a wazero.CompiledModule, otherwise this returns nil.
+//
+// Indices are in the function index namespace, so include imported functions,
+// and are sorted in ascending order. Any function not in the result can be
+// removed without changing the behavior of calls to the roots.
+//
+// # Notes
+//
+//   - Names that aren't exported functions are ignored.
+//   - The start function, if any, is always reachable.
+//   - The scan is conservative: when any reachable function uses
+//     call_indirect, all functions that may be placed into a table, e.g. via
+//     element segments or ref.func, are considered reachable. If any
+//     function body can't be scanned, all functions are.
+func ReachableFunctions(compiled interface{}, roots []string) (reachable []uint32) {
+	if r, ok := compiled.(interface{ ReachableFunctions([]string) []uint32 }); ok {
+		return r.ReachableFunctions(roots)
+	}
+	return nil
+}
+
+// CalledImports returns the imported functions reachable from any exported
+// function of the compiled module, or its start function, so that hosts can
+// avoid providing stubs for imports that are never called, or warn on them.
+// The compiled module must be a wazero.CompiledModule, otherwise this returns
+// nil.
+//
+// Definitions are in import order, so are a subset of
+// wazero.CompiledModule ImportedFunctions.
+//
+// # Notes
+//
+//   - Reachability is the same as ReachableFunctions, with all exported
+//     functions as roots. So, imports that may be placed into a table are
+//     called when any reachable function uses call_indirect.
+//   - An exported import is always called, as the host may call it.
+func CalledImports(compiled interface{}) []api.FunctionDefinition {
+	if c, ok := compiled.(interface {
+		CalledImports() []api.FunctionDefinition
+	}); ok {
+		return c.CalledImports()
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestReachableFunctions(t *testing.T) {
+	ctx := context.Background()
+
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	compiled, err := r.CompileModule(ctx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0, 0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeCall, 2, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{{Name: "main", Type: wasm.ExternTypeFunc, Index: 0}},
+	}))
+	require.NoError(t, err)
+
+	require.Equal(t, []uint32{0, 2}, ReachableFunctions(compiled, []string{"main"}))
+	require.Nil(t, ReachableFunctions(nil, []string{"main"}))
+}
+
+func TestCalledImports(t *testing.T) {
+	ctx := context.Background()
+
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	compiled, err := r.CompileModule(ctx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{{}},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "used", Type: wasm.ExternTypeFunc, DescFunc: 0},
+			{Module: "env", Name: "unused", Type: wasm.ExternTypeFunc, DescFunc: 0},
+		},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}}},
+		ExportSection:   []*wasm.Export{{Name: "main", Type: wasm.ExternTypeFunc, Index: 2}},
+	}))
+	require.NoError(t, err)
+
+	called := CalledImports(compiled)
+	require.Equal(t, 1, len(called))
+	moduleName, name, _ := called[0].Import()
+	require.Equal(t, "env", moduleName)
+	require.Equal(t, "used", name)
+	require.Nil(t, CalledImports(nil))
+}
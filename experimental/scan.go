@@ -0,0 +1,49 @@
+package experimental
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// ScanMemory calls fn with each window of at most chunkSize bytes of the
+// total bytes of memory starting at offset, in order. This allows streaming
+// parsing of a large payload produced by a guest, without copying it.
+//
+// This returns an error, without calling fn, if the region is out of range of
+// the memory or chunkSize is zero. Otherwise, it stops at and returns the
+// first error returned by fn.
+//
+// For example, this hashes a payload at (ptr, len):
+//
+//	h := sha256.New()
+//	err := experimental.ScanMemory(ctx, mod.Memory(), ptr, len, 4096, func(chunk []byte) error {
+//		_, err := h.Write(chunk)
+//		return err
+//	})
+//
+// Note: Each chunk is a view of the memory, like api.Memory Read, so must not
+// be retained after fn returns. fn must not grow the memory.
+func ScanMemory(ctx context.Context, mem api.Memory, offset, total, chunkSize uint32, fn func(chunk []byte) error) error {
+	if chunkSize == 0 {
+		return errors.New("chunkSize == 0")
+	}
+	buf, ok := mem.Read(ctx, offset, total)
+	if !ok {
+		return fmt.Errorf("out of range scanning %d bytes at offset %d of memory size %d",
+			total, offset, mem.Size(ctx))
+	}
+	for len(buf) > 0 {
+		n := len(buf)
+		if n > int(chunkSize) {
+			n = int(chunkSize)
+		}
+		if err := fn(buf[:n:n]); err != nil {
+			return err
+		}
+		buf = buf[n:]
+	}
+	return nil
+}
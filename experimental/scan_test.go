@@ -0,0 +1,80 @@
+package experimental_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestScanMemory(t *testing.T) {
+	ctx := context.Background()
+
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	mod, err := r.InstantiateModuleFromBinary(ctx, binary.EncodeModule(&wasm.Module{
+		MemorySection: &wasm.Memory{Min: 1},
+		ExportSection: []*wasm.Export{{Name: "memory", Type: wasm.ExternTypeMemory}},
+	}))
+	require.NoError(t, err)
+
+	mem := mod.ExportedMemory("memory")
+	var expectedSum uint64
+	for i := uint32(0); i < 1000; i++ {
+		require.True(t, mem.WriteByte(ctx, 10+i, byte(i)))
+		expectedSum += uint64(byte(i))
+	}
+
+	t.Run("sums bytes across chunks", func(t *testing.T) {
+		var sum uint64
+		var chunks []int
+		err := ScanMemory(ctx, mem, 10, 1000, 300, func(chunk []byte) error {
+			chunks = append(chunks, len(chunk))
+			for _, b := range chunk {
+				sum += uint64(b)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, expectedSum, sum)
+		require.Equal(t, []int{300, 300, 300, 100}, chunks)
+	})
+
+	t.Run("stops on error", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := ScanMemory(ctx, mem, 10, 1000, 300, func([]byte) error {
+			calls++
+			return stop
+		})
+		require.Equal(t, stop, err)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		err := ScanMemory(ctx, mem, mem.Size(ctx), 0, 300, func([]byte) error {
+			t.Fatal("unexpected call")
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		err := ScanMemory(ctx, mem, mem.Size(ctx)-1, 2, 300, func([]byte) error {
+			t.Fatal("unexpected call")
+			return nil
+		})
+		require.EqualError(t, err, "out of range scanning 2 bytes at offset 65535 of memory size 65536")
+	})
+
+	t.Run("zero chunk size", func(t *testing.T) {
+		err := ScanMemory(ctx, mem, 0, 1, 0, func([]byte) error { return nil })
+		require.EqualError(t, err, "chunkSize == 0")
+	})
+}
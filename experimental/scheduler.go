@@ -0,0 +1,148 @@
+package experimental
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Task is a function call driven by a Scheduler.
+type Task struct {
+	// ID is the order the task was spawned in, starting at zero.
+	ID int
+
+	// Function and Params are the call of this task.
+	Function api.Function
+	Params   []uint64
+
+	// Continuation is non-nil while the task is suspended, after it called
+	// Suspend. A Pick function may overwrite the end of its Stack, e.g. to
+	// deliver a message, before the task is resumed.
+	Continuation *Continuation
+
+	// Results and Err are the outcome of the call, once Done.
+	Results []uint64
+	Err     error
+
+	started, done bool
+}
+
+// Done returns true once the call of this task returned or failed.
+func (t *Task) Done() bool {
+	return t.done
+}
+
+// Pick chooses the next task to run from the tasks which aren't done, in ID
+// order. It must return one of them.
+//
+// Pick should only depend on its input and state it owns, so that the same
+// guests, params and host functions result in the same interleaving.
+type Pick func(runnable []*Task) *Task
+
+// Scheduler cooperatively runs function calls which suspend with Suspend,
+// e.g. to simulate several guests deterministically. Each step runs the task
+// chosen by Pick until it suspends or completes.
+//
+// Usage:
+//
+//	s := experimental.NewScheduler(nil) // round-robin
+//	a := s.Spawn(modA.ExportedFunction("run"))
+//	b := s.Spawn(modB.ExportedFunction("run"))
+//	if err := s.Run(ctx); err != nil {
+//		return err
+//	}
+//	// a.Results, a.Err, b.Results, b.Err are now set.
+//
+// # Notes
+//
+//   - This is an experimental API and may change or be removed in any release.
+//   - This is interpreter-only, as Suspend is.
+//   - Tasks run on the goroutine calling Run, one at a time, so are never
+//     concurrent.
+type Scheduler struct {
+	pick  Pick
+	tasks []*Task
+	// last is the ID of the task which ran last, or -1.
+	last int
+}
+
+// NewScheduler returns a Scheduler which picks tasks with pick, or in
+// round-robin order of ID if nil.
+func NewScheduler(pick Pick) *Scheduler {
+	s := &Scheduler{pick: pick, last: -1}
+	if s.pick == nil {
+		s.pick = s.roundRobin
+	}
+	return s
+}
+
+// Spawn adds a task which calls fn with params when first picked.
+func (s *Scheduler) Spawn(fn api.Function, params ...uint64) *Task {
+	t := &Task{ID: len(s.tasks), Function: fn, Params: params}
+	s.tasks = append(s.tasks, t)
+	return t
+}
+
+// Run steps tasks until all are done, including any spawned by Pick or host
+// functions while running. Errors of tasks are recorded in Task.Err, so the
+// error returned is only non-nil if ctx is done or Pick returned a task
+// which isn't runnable.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		var runnable []*Task
+		for _, t := range s.tasks {
+			if !t.done {
+				runnable = append(runnable, t)
+			}
+		}
+		if len(runnable) == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		t := s.pick(runnable)
+		if t == nil {
+			return errors.New("pick returned nil")
+		} else if t.ID < 0 || t.ID >= len(s.tasks) || s.tasks[t.ID] != t || t.done {
+			return fmt.Errorf("pick returned task %d, which isn't runnable", t.ID)
+		}
+		s.step(ctx, t)
+	}
+}
+
+// step runs the task until it suspends or completes.
+func (s *Scheduler) step(ctx context.Context, t *Task) {
+	s.last = t.ID
+
+	var results []uint64
+	var err error
+	if !t.started {
+		t.started = true
+		results, err = t.Function.Call(ctx, t.Params...)
+	} else {
+		results, err = Resume(ctx, t.Continuation)
+	}
+
+	var cont *Continuation
+	if errors.As(err, &cont) {
+		t.Continuation = cont
+		return
+	}
+	t.Continuation = nil
+	t.Results, t.Err, t.done = results, err, true
+}
+
+// roundRobin is the default Pick, which returns the first runnable task
+// after the last one run, wrapping around.
+func (s *Scheduler) roundRobin(runnable []*Task) *Task {
+	for _, t := range runnable {
+		if t.ID > s.last {
+			return t
+		}
+	}
+	return runnable[0]
+}
@@ -0,0 +1,129 @@
+package experimental_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	// newGuests returns two guests, whose run(base) calls yield(base),
+	// yield(base+1) then returns base+2. yield logs its param and suspends.
+	newGuests := func(t *testing.T) (r wazero.Runtime, guests []api.Module, log *[]uint64) {
+		r = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+
+		log = &[]uint64{}
+		_, err := r.NewHostModuleBuilder("env").
+			NewFunctionBuilder().
+			WithGoFunction(api.GoFunc(func(ctx context.Context, stack []uint64) {
+				*log = append(*log, stack[0])
+				Suspend()
+			}), []api.ValueType{api.ValueTypeI32}, []api.ValueType{}).
+			Export("yield").
+			Instantiate(ctx, r)
+		require.NoError(t, err)
+
+		i32 := wasm.ValueTypeI32
+		compiled, err := r.CompileModule(ctx, binary.EncodeModule(&wasm.Module{
+			TypeSection: []*wasm.FunctionType{
+				{Params: []wasm.ValueType{i32}},
+				{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+			},
+			ImportSection:   []*wasm.Import{{Module: "env", Name: "yield", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+			FunctionSection: []wasm.Index{1},
+			CodeSection: []*wasm.Code{{Body: []byte{
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeCall, 0,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 2, wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+			}}},
+			ExportSection: []*wasm.Export{{Name: "run", Type: wasm.ExternTypeFunc, Index: 1}},
+		}))
+		require.NoError(t, err)
+
+		for _, name := range []string{"a", "b"} {
+			mod, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(name))
+			require.NoError(t, err)
+			guests = append(guests, mod)
+		}
+		return
+	}
+
+	tests := []struct {
+		name        string
+		pick        Pick
+		expectedLog []uint64
+	}{
+		{
+			name:        "round-robin",
+			expectedLog: []uint64{10, 20, 11, 21},
+		},
+		{
+			name: "last first",
+			pick: func(runnable []*Task) *Task {
+				return runnable[len(runnable)-1]
+			},
+			expectedLog: []uint64{20, 21, 10, 11},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			// Run twice to show the interleaving is the same each time.
+			for i := 0; i < 2; i++ {
+				r, guests, log := newGuests(t)
+
+				s := NewScheduler(tc.pick)
+				a := s.Spawn(guests[0].ExportedFunction("run"), 10)
+				b := s.Spawn(guests[1].ExportedFunction("run"), 20)
+				require.NoError(t, s.Run(ctx))
+
+				require.Equal(t, tc.expectedLog, *log)
+				require.True(t, a.Done())
+				require.NoError(t, a.Err)
+				require.Equal(t, []uint64{12}, a.Results)
+				require.True(t, b.Done())
+				require.NoError(t, b.Err)
+				require.Equal(t, []uint64{22}, b.Results)
+
+				require.NoError(t, r.Close(ctx))
+			}
+		})
+	}
+
+	t.Run("invalid pick", func(t *testing.T) {
+		r, guests, _ := newGuests(t)
+		defer r.Close(ctx)
+
+		s := NewScheduler(func([]*Task) *Task { return &Task{} })
+		s.Spawn(guests[0].ExportedFunction("run"), 10)
+		require.EqualError(t, s.Run(ctx), "pick returned task 0, which isn't runnable")
+
+		s = NewScheduler(func([]*Task) *Task { return nil })
+		s.Spawn(guests[0].ExportedFunction("run"), 10)
+		require.EqualError(t, s.Run(ctx), "pick returned nil")
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		r, guests, _ := newGuests(t)
+		defer r.Close(ctx)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		s := NewScheduler(nil)
+		a := s.Spawn(guests[0].ExportedFunction("run"), 10)
+		require.True(t, errors.Is(s.Run(canceled), context.Canceled))
+		require.False(t, a.Done())
+	})
+}
@@ -0,0 +1,108 @@
+package experimental
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// StepperKey is a context.Context Value key. Its associated value should be
+// a StepFunc. Use WithStepper to set it.
+//
+// Note: This is interpreter-only!
+type StepperKey struct{}
+
+// SteppingKey is a context.Context Value key. Its associated value should be
+// a bool. Use WithStepping to set it.
+//
+// Note: This is interpreter-only!
+type SteppingKey struct{}
+
+// WithStepping returns a context to pass to wazero.NewRuntime, so that its
+// functions can be stepped with WithStepper. Otherwise, the interpreter
+// doesn't retain the offset of each instruction, and ignores any stepper.
+//
+// Usage:
+//
+//	ctx = experimental.WithStepping(ctx)
+//	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+//
+// Note: This is interpreter-only!
+func WithStepping(ctx context.Context) context.Context {
+	return context.WithValue(ctx, SteppingKey{}, true)
+}
+
+// StepAction is how a StepFunc resumes the function call it paused.
+type StepAction byte
+
+const (
+	// StepNext runs the instruction, then pauses before the next one.
+	StepNext StepAction = iota
+	// StepContinue runs the rest of the function call without pausing.
+	StepContinue
+	// StepAbort fails the function call with ErrStepAborted, without running
+	// the instruction.
+	StepAbort
+)
+
+// ErrStepAborted is the cause of the error returned by api.Function Call
+// when a StepFunc returns StepAbort.
+var ErrStepAborted = errors.New("aborted by stepper")
+
+// Step is the state of a function call, paused before an instruction.
+type Step struct {
+	// Function is the function whose instruction is next.
+	Function api.FunctionDefinition
+
+	// PC is the offset of the instruction in the body of Function, after its
+	// local declarations.
+	PC uint64
+
+	// Opcode is the first byte of the instruction, e.g. 0x6a for i32.add.
+	// For prefixed instructions, such as vector instructions, this is the
+	// prefix.
+	Opcode byte
+
+	// Locals are the params then locals of Function, encoded as with
+	// api.Function Call. A v128 local takes two values: the lower then
+	// higher 64 bits.
+	Locals []uint64
+
+	// Stack is the value stack of Function, with the top value last.
+	Stack []uint64
+}
+
+// StepFunc is called before each instruction of a function call, and
+// returns how to resume it. This is the foundation of a debugger, which can
+// single step a guest, inspecting its state.
+//
+// # Notes
+//
+//   - Step Locals and Stack are views of the function call, so must not be
+//     retained. Writing to them changes the values seen by the instruction.
+//   - Instructions which have no effect at runtime, such as block or nop,
+//     are not paused at.
+//   - Calls to host functions are not stepped into.
+type StepFunc func(ctx context.Context, step *Step) StepAction
+
+// WithStepper returns a context which pauses before each instruction of a
+// function call, to call the stepper. This is strictly opt-in, as it slows
+// down every instruction.
+//
+// Usage:
+//
+//	ctx = experimental.WithStepper(ctx, func(ctx context.Context, step *experimental.Step) experimental.StepAction {
+//		fmt.Printf("%s: %#x %#x\n", step.Function.DebugName(), step.PC, step.Opcode)
+//		return experimental.StepNext
+//	})
+//	_, err := mod.ExportedFunction("run").Call(ctx)
+//
+// # Notes
+//
+//   - This is interpreter-only! The compiler ignores the stepper.
+//   - The stepper is ignored unless the runtime was created with a context
+//     from WithStepping.
+func WithStepper(ctx context.Context, stepper StepFunc) context.Context {
+	return context.WithValue(ctx, StepperKey{}, stepper)
+}
@@ -0,0 +1,135 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestWithStepper(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	// Define a module which adds one to its param, via a local.
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{
+			LocalTypes: []wasm.ValueType{i32},
+			Body: []byte{
+				wasm.OpcodeLocalGet, 0, // 0
+				wasm.OpcodeI32Const, 1, // 2
+				wasm.OpcodeI32Add,      // 4
+				wasm.OpcodeLocalSet, 1, // 5
+				wasm.OpcodeLocalGet, 1, // 7
+				wasm.OpcodeEnd, // 9
+			},
+		}},
+		ExportSection: []*wasm.Export{{Name: "inc", Type: wasm.ExternTypeFunc, Index: 0}},
+	})
+
+	r := wazero.NewRuntimeWithConfig(WithStepping(context.Background()), wazero.NewRuntimeConfigInterpreter())
+	defer r.Close(context.Background())
+
+	mod, err := r.InstantiateModuleFromBinary(context.Background(), bin)
+	require.NoError(t, err)
+	inc := mod.ExportedFunction("inc")
+
+	type step struct {
+		pc            uint64
+		opcode        byte
+		locals, stack []uint64
+	}
+
+	t.Run("StepNext", func(t *testing.T) {
+		var steps []step
+		ctx := WithStepper(context.Background(), func(ctx context.Context, s *Step) StepAction {
+			require.Equal(t, ".$0", s.Function.DebugName())
+			steps = append(steps, step{
+				pc:     s.PC,
+				opcode: s.Opcode,
+				locals: append([]uint64{}, s.Locals...),
+				stack:  append([]uint64{}, s.Stack...),
+			})
+			return StepNext
+		})
+
+		results, err := inc.Call(ctx, 41)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{42}, results)
+		require.Equal(t, []step{
+			{pc: 0, opcode: wasm.OpcodeLocalGet, locals: []uint64{41, 0}, stack: []uint64{}},
+			{pc: 2, opcode: wasm.OpcodeI32Const, locals: []uint64{41, 0}, stack: []uint64{41}},
+			{pc: 4, opcode: wasm.OpcodeI32Add, locals: []uint64{41, 0}, stack: []uint64{41, 1}},
+			{pc: 5, opcode: wasm.OpcodeLocalSet, locals: []uint64{41, 0}, stack: []uint64{42}},
+			{pc: 7, opcode: wasm.OpcodeLocalGet, locals: []uint64{41, 42}, stack: []uint64{}},
+			{pc: 9, opcode: wasm.OpcodeEnd, locals: []uint64{41, 42}, stack: []uint64{42}},
+		}, steps)
+	})
+
+	t.Run("StepContinue", func(t *testing.T) {
+		var pcs []uint64
+		ctx := WithStepper(context.Background(), func(ctx context.Context, s *Step) StepAction {
+			pcs = append(pcs, s.PC)
+			if s.PC == 2 {
+				return StepContinue
+			}
+			return StepNext
+		})
+
+		results, err := inc.Call(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{2}, results)
+		require.Equal(t, []uint64{0, 2}, pcs)
+	})
+
+	t.Run("StepAbort", func(t *testing.T) {
+		ctx := WithStepper(context.Background(), func(ctx context.Context, s *Step) StepAction {
+			if s.Opcode == wasm.OpcodeI32Add {
+				return StepAbort
+			}
+			return StepNext
+		})
+
+		_, err := inc.Call(ctx, 1)
+		require.ErrorIs(t, err, ErrStepAborted)
+	})
+
+	t.Run("modifies locals", func(t *testing.T) {
+		ctx := WithStepper(context.Background(), func(ctx context.Context, s *Step) StepAction {
+			if s.PC == 7 {
+				s.Locals[1] = 100
+			}
+			return StepNext
+		})
+
+		results, err := inc.Call(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{100}, results)
+	})
+
+	t.Run("without stepper", func(t *testing.T) {
+		results, err := inc.Call(context.Background(), 1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{2}, results)
+	})
+
+	t.Run("without stepping", func(t *testing.T) {
+		r := wazero.NewRuntimeWithConfig(context.Background(), wazero.NewRuntimeConfigInterpreter())
+		defer r.Close(context.Background())
+
+		mod, err := r.InstantiateModuleFromBinary(context.Background(), bin)
+		require.NoError(t, err)
+
+		ctx := WithStepper(context.Background(), func(context.Context, *Step) StepAction {
+			t.Fatal("stepper called without stepping")
+			return StepAbort
+		})
+		results, err := mod.ExportedFunction("inc").Call(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{2}, results)
+	})
+}
@@ -0,0 +1,114 @@
+package experimental
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// FieldType is the type of a field in a FieldSpec.
+type FieldType byte
+
+const (
+	// FieldTypeI8 is an 8-bit integer, zero-extended into its result.
+	FieldTypeI8 FieldType = iota + 1
+	// FieldTypeI16 is a 16-bit little-endian integer, zero-extended into its
+	// result.
+	FieldTypeI16
+	// FieldTypeI32 is a 32-bit little-endian integer, zero-extended into its
+	// result.
+	FieldTypeI32
+	// FieldTypeI64 is a 64-bit little-endian integer.
+	FieldTypeI64
+	// FieldTypeF32 is a 32-bit IEEE-754 float. Use api.DecodeF32 to read it.
+	FieldTypeF32
+	// FieldTypeF64 is a 64-bit IEEE-754 float. Use api.DecodeF64 to read it.
+	FieldTypeF64
+)
+
+// size returns the count of bytes in a field of this type, or zero if invalid.
+func (t FieldType) size() uint64 {
+	switch t {
+	case FieldTypeI8:
+		return 1
+	case FieldTypeI16:
+		return 2
+	case FieldTypeI32, FieldTypeF32:
+		return 4
+	case FieldTypeI64, FieldTypeF64:
+		return 8
+	}
+	return 0
+}
+
+// FieldSpec describes a field of a C-struct-like region of memory, for
+// ReadStruct.
+type FieldSpec struct {
+	// Type is the type of the field.
+	Type FieldType
+
+	// Offset is the offset of the field in bytes from the start of the
+	// struct. Padding is implicit in the gaps between fields.
+	Offset uint32
+}
+
+// ReadStruct decodes the fields of a C-struct-like region of memory starting
+// at offset into dst, which is index-correlated with layout. The encoding of
+// each field is the same as api.Function parameters, e.g. floats are their
+// IEEE-754 bits. This is a building block for FFI bridges, as it bounds checks
+// the whole struct once, instead of reading each field separately.
+//
+// This returns false, leaving dst unchanged, if the struct is out of range of
+// the memory, any field has an invalid type, or dst is shorter than layout.
+//
+// For example, this reads `struct { uint8_t tag; double value; }`:
+//
+//	layout := []experimental.FieldSpec{
+//		{Type: experimental.FieldTypeI8, Offset: 0},
+//		{Type: experimental.FieldTypeF64, Offset: 8}, // after 7 bytes of padding
+//	}
+//	var fields [2]uint64
+//	if !experimental.ReadStruct(ctx, mod.Memory(), ptr, layout, fields[:]) {
+//		panic("out of range")
+//	}
+//	tag, value := uint8(fields[0]), api.DecodeF64(fields[1])
+func ReadStruct(ctx context.Context, mem api.Memory, offset uint32, layout []FieldSpec, dst []uint64) bool {
+	if len(dst) < len(layout) {
+		return false
+	}
+
+	var extent uint64
+	for _, f := range layout {
+		size := f.Type.size()
+		if size == 0 {
+			return false
+		}
+		if end := uint64(f.Offset) + size; end > extent {
+			extent = end
+		}
+	}
+	if extent > uint64(^uint32(0)) {
+		return false
+	}
+
+	buf, ok := mem.Read(ctx, offset, uint32(extent))
+	if !ok {
+		return false
+	}
+
+	for i, f := range layout {
+		b := buf[f.Offset:]
+		switch f.Type {
+		case FieldTypeI8:
+			dst[i] = uint64(b[0])
+		case FieldTypeI16:
+			dst[i] = uint64(binary.LittleEndian.Uint16(b))
+		case FieldTypeI32, FieldTypeF32:
+			dst[i] = uint64(binary.LittleEndian.Uint32(b))
+		case FieldTypeI64, FieldTypeF64:
+			dst[i] = binary.LittleEndian.Uint64(b)
+		}
+	}
+	return true
+}
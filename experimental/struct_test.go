@@ -0,0 +1,94 @@
+package experimental_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestReadStruct(t *testing.T) {
+	ctx := context.Background()
+	mem := wasm.NewMemoryInstance(&wasm.Memory{Min: 1, Cap: 1, Max: 1})
+
+	// struct { uint8_t a; int16_t b; int32_t c; int64_t d; float e; double f; }
+	// at offset 8, with natural alignment.
+	layout := []FieldSpec{
+		{Type: FieldTypeI8, Offset: 0},
+		{Type: FieldTypeI16, Offset: 2},
+		{Type: FieldTypeI32, Offset: 4},
+		{Type: FieldTypeI64, Offset: 8},
+		{Type: FieldTypeF32, Offset: 16},
+		{Type: FieldTypeF64, Offset: 24},
+	}
+	require.True(t, mem.WriteByte(ctx, 8, 0xfe))
+	require.True(t, mem.WriteUint16Le(ctx, 10, 0xfffe))
+	require.True(t, mem.WriteUint32Le(ctx, 12, uint32(api.EncodeI32(-3))))
+	require.True(t, mem.WriteUint64Le(ctx, 16, api.EncodeI64(-4)))
+	require.True(t, mem.WriteFloat32Le(ctx, 24, 1.5))
+	require.True(t, mem.WriteFloat64Le(ctx, 32, math.Pi))
+
+	t.Run("ok", func(t *testing.T) {
+		dst := make([]uint64, len(layout))
+		require.True(t, ReadStruct(ctx, mem, 8, layout, dst))
+		require.Equal(t, uint8(0xfe), uint8(dst[0]))
+		require.Equal(t, int16(-2), int16(dst[1]))
+		require.Equal(t, int32(-3), int32(dst[2]))
+		require.Equal(t, int64(-4), int64(dst[3]))
+		require.Equal(t, float32(1.5), api.DecodeF32(dst[4]))
+		require.Equal(t, math.Pi, api.DecodeF64(dst[5]))
+	})
+
+	t.Run("empty layout", func(t *testing.T) {
+		require.True(t, ReadStruct(ctx, mem, 8, nil, nil))
+	})
+
+	t.Run("at the end of memory", func(t *testing.T) {
+		dst := make([]uint64, len(layout))
+		require.True(t, ReadStruct(ctx, mem, mem.Size(ctx)-32, layout, dst))
+	})
+
+	tests := []struct {
+		name   string
+		offset uint32
+		layout []FieldSpec
+		dst    []uint64
+	}{
+		{
+			name:   "out of range",
+			offset: mem.Size(ctx) - 31,
+			layout: layout,
+			dst:    make([]uint64, len(layout)),
+		},
+		{
+			name:   "field offset overflows",
+			offset: 0,
+			layout: []FieldSpec{{Type: FieldTypeI64, Offset: math.MaxUint32}},
+			dst:    make([]uint64, 1),
+		},
+		{
+			name:   "invalid field type",
+			offset: 8,
+			layout: []FieldSpec{{Type: 0, Offset: 0}},
+			dst:    make([]uint64, 1),
+		},
+		{
+			name:   "dst too short",
+			offset: 8,
+			layout: layout,
+			dst:    make([]uint64, len(layout)-1),
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			require.False(t, ReadStruct(ctx, mem, tc.offset, tc.layout, tc.dst))
+			require.Equal(t, make([]uint64, len(tc.dst)), tc.dst) // unchanged
+		})
+	}
+}
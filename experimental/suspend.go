@@ -0,0 +1,102 @@
+package experimental
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Continuation is the state of a function call suspended by Suspend. It is
+// returned as the error of api.Function Call, and continued with Resume.
+//
+// Except Module, fields are plain data, so a continuation can be serialized.
+// After decoding, set Module to a module instantiated the same way, with the
+// same memory contents, to resume the call there.
+type Continuation struct {
+	// Module is the module the suspended call was made from.
+	Module api.Module `json:"-"`
+
+	// Frames are the suspended Wasm functions, from the one initially called
+	// to the one which called the host function that suspended.
+	Frames []ContinuationFrame
+
+	// Stack is the value stack of the call. It ends with the results of the
+	// host function that suspended, which can be overwritten before Resume,
+	// e.g. with the outcome of an asynchronous operation.
+	Stack []uint64
+}
+
+// ContinuationFrame is a suspended Wasm function in a Continuation.
+type ContinuationFrame struct {
+	// ModuleName is the name of the module defining the function.
+	ModuleName string
+
+	// FunctionIndex is the index of the function in the module, including
+	// imported functions.
+	FunctionIndex uint32
+
+	// PC is the position of the suspended call in the engine's code for the
+	// function. This is not an offset in the Wasm function body.
+	PC uint64
+
+	// StackBase is the index in Continuation Stack of the first param of the
+	// function, if tracked by the engine, or zero.
+	StackBase int
+}
+
+// Error implements error, as a suspended call returns the Continuation as
+// its error.
+func (c *Continuation) Error() string {
+	return fmt.Sprintf("call suspended with %d frames", len(c.Frames))
+}
+
+// Suspend ends the current host function call without returning to its
+// caller, suspending the function call which made it. That call returns a
+// *Continuation error, to pass to Resume later. This allows cooperative
+// scheduling of guests, e.g. as green threads.
+//
+// Usage:
+//
+//	builder.WithGoFunction(api.GoFunc(func(ctx context.Context, stack []uint64) {
+//		stack[0] = 0 // the result seen by the guest on Resume
+//		experimental.Suspend()
+//	}), []api.ValueType{}, []api.ValueType{api.ValueTypeI32})
+//
+//	_, err := mod.ExportedFunction("run").Call(ctx)
+//	var cont *experimental.Continuation
+//	for errors.As(err, &cont) {
+//		// run other work, then continue where "run" left off.
+//		_, err = experimental.Resume(ctx, cont)
+//	}
+//
+// # Notes
+//
+//   - This is interpreter-only! With the compiler, the call fails with an
+//     error whose continuation can't be resumed.
+//   - Results of the host function must be written before calling this.
+//   - Suspend only from a host function called directly by a Wasm
+//     function, not, for example, from a FunctionListener.
+//   - FunctionListener After is not called for functions in the
+//     continuation, as they didn't return.
+func Suspend() {
+	panic(&Continuation{})
+}
+
+// Resume continues a function call suspended by Suspend, returning its
+// results once it completes, or another *Continuation error if it suspends
+// again.
+//
+// # Notes
+//
+//   - A continuation must be resumed at most once.
+//   - A timeout set with wazero.ModuleConfig WithFunctionTimeout for the
+//     function initially called applies to each resumed call, too.
+func Resume(ctx context.Context, cont *Continuation) ([]uint64, error) {
+	if r, ok := cont.Module.(interface {
+		Resume(context.Context, *Continuation) ([]uint64, error)
+	}); ok {
+		return r.Resume(ctx, cont)
+	}
+	return nil, fmt.Errorf("unsupported module: %v", cont.Module)
+}
@@ -0,0 +1,129 @@
+package experimental_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestSuspend_Resume(t *testing.T) {
+	ctx := context.Background()
+
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+	defer r.Close(ctx)
+
+	// yield(i) returns i*10, suspending the caller each time.
+	var yields []uint64
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().
+		WithGoFunction(api.GoFunc(func(ctx context.Context, stack []uint64) {
+			yields = append(yields, stack[0])
+			stack[0] *= 10
+			Suspend()
+		}), []api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}).
+		Export("yield").
+		Instantiate(ctx, r)
+	require.NoError(t, err)
+
+	// run(n) sums next(i) for i in [0, n), where next(i) calls yield(i).
+	i32 := wasm.ValueTypeI32
+	mod, err := r.InstantiateModuleFromBinary(ctx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "yield", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+			{
+				LocalTypes: []wasm.ValueType{i32, i32},
+				Body: []byte{
+					wasm.OpcodeBlock, 0x40,
+					wasm.OpcodeLoop, 0x40,
+					wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 0, wasm.OpcodeI32GeS,
+					wasm.OpcodeBrIf, 1,
+					wasm.OpcodeLocalGet, 2, wasm.OpcodeLocalGet, 1, wasm.OpcodeCall, 1, wasm.OpcodeI32Add,
+					wasm.OpcodeLocalSet, 2,
+					wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add,
+					wasm.OpcodeLocalSet, 1,
+					wasm.OpcodeBr, 0,
+					wasm.OpcodeEnd,
+					wasm.OpcodeEnd,
+					wasm.OpcodeLocalGet, 2,
+					wasm.OpcodeEnd,
+				},
+			},
+		},
+		ExportSection: []*wasm.Export{{Name: "run", Type: wasm.ExternTypeFunc, Index: 2}},
+	}))
+	require.NoError(t, err)
+
+	results, err := mod.ExportedFunction("run").Call(ctx, 4)
+	suspensions := 0
+	var cont *Continuation
+	for errors.As(err, &cont) {
+		suspensions++
+		require.Equal(t, 2, len(cont.Frames))
+		require.Equal(t, uint32(2), cont.Frames[0].FunctionIndex)
+		require.Equal(t, uint32(1), cont.Frames[1].FunctionIndex)
+
+		// The result of yield is last on the stack.
+		i := uint64(suspensions - 1)
+		require.Equal(t, i*10, cont.Stack[len(cont.Stack)-1])
+		if i == 3 {
+			cont.Stack[len(cont.Stack)-1] = 100 // Override the result.
+		}
+
+		results, err = Resume(ctx, cont)
+	}
+	require.NoError(t, err)
+	require.Equal(t, 4, suspensions)
+	require.Equal(t, []uint64{0, 1, 2, 3}, yields)
+	require.Equal(t, []uint64{0 + 10 + 20 + 100}, results)
+}
+
+func TestResume_timeout(t *testing.T) {
+	ctx := context.Background()
+
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+	defer r.Close(ctx)
+
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().
+		WithGoFunction(api.GoFunc(func(context.Context, []uint64) { Suspend() }), []api.ValueType{}, []api.ValueType{}).
+		Export("pause").
+		Instantiate(ctx, r)
+	require.NoError(t, err)
+
+	// spin calls pause, then loops forever once resumed.
+	compiled, err := r.CompileModule(ctx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "pause", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeCall, 0,
+			wasm.OpcodeLoop, 0x40, wasm.OpcodeBr, 0, wasm.OpcodeEnd,
+			wasm.OpcodeEnd,
+		}}},
+		ExportSection: []*wasm.Export{{Name: "spin", Type: wasm.ExternTypeFunc, Index: 1}},
+	}))
+	require.NoError(t, err)
+
+	mod, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+		WithFunctionTimeout("spin", 10*time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = mod.ExportedFunction("spin").Call(ctx)
+	var cont *Continuation
+	require.True(t, errors.As(err, &cont))
+
+	// The timeout of spin applies to the resumed call, too.
+	_, err = Resume(ctx, cont)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
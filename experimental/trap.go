@@ -0,0 +1,54 @@
+package experimental
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+// TrapToResults returns a function which calls fn, except when the call
+// traps, such as on "unreachable" or an out of bounds memory access, it
+// returns the given results instead of an error. This allows running
+// untrusted code where any trap maps to a uniform "error" return, or guests
+// with their own exception model.
+//
+// Usage:
+//
+//	// run returns -1 instead of an error when it traps.
+//	run := experimental.TrapToResults(mod.ExportedFunction("run"), api.EncodeI32(-1))
+//	results, err := run.Call(ctx)
+//
+// # Notes
+//
+//   - This deviates from the WebAssembly specification, which requires a
+//     trap to unwind to the embedder. Only use it on functions whose callers
+//     expect the results.
+//   - Side effects before the trap, such as writes to memory or globals,
+//     are not rolled back.
+//   - Errors which are not traps are returned as usual, e.g. sys.ExitError
+//     or a panic in a host function.
+//   - This panics if the count of results doesn't match the function's.
+func TrapToResults(fn api.Function, results ...uint64) api.Function {
+	if expected := len(fn.Definition().ResultTypes()); len(results) != expected {
+		panic(fmt.Errorf("trap results invalid: %d != %d", len(results), expected))
+	}
+	return &trapToResults{Function: fn, results: results}
+}
+
+type trapToResults struct {
+	api.Function
+	results []uint64
+}
+
+// Call implements the same method as documented on api.Function.
+func (f *trapToResults) Call(ctx context.Context, params ...uint64) ([]uint64, error) {
+	ret, err := f.Function.Call(ctx, params...)
+	var trap *wasmruntime.Error
+	if errors.As(err, &trap) {
+		return append([]uint64{}, f.results...), nil
+	}
+	return ret, err
+}
@@ -0,0 +1,86 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	. "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+func TestTrapToResults(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	// Define a module which divides 100 by its param, and exits on -1.
+	bin := binary.EncodeModule(&wasm.Module{
+		ImportSection: []*wasm.Import{{Module: "env", Name: "exit", Type: wasm.ExternTypeFunc, DescFunc: 1}},
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+			{},
+		},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{
+			Body: []byte{
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI32Const, 0x7f, // -1
+				wasm.OpcodeI32Eq,
+				wasm.OpcodeIf, 0x40,
+				wasm.OpcodeCall, 0,
+				wasm.OpcodeEnd,
+				wasm.OpcodeI32Const, 0xe4, 0x00, // 100
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI32DivS,
+				wasm.OpcodeEnd,
+			},
+		}},
+		ExportSection: []*wasm.Export{{Name: "div", Type: wasm.ExternTypeFunc, Index: 1}},
+	})
+
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, mod api.Module) {
+		_ = mod.CloseWithExitCode(ctx, 3)
+	}).Export("exit").
+		Instantiate(ctx, r)
+	require.NoError(t, err)
+
+	mod, err := r.InstantiateModuleFromBinary(ctx, bin)
+	require.NoError(t, err)
+	div := TrapToResults(mod.ExportedFunction("div"), api.EncodeI32(-1))
+
+	t.Run("no trap", func(t *testing.T) {
+		results, err := div.Call(ctx, 5)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{20}, results)
+	})
+
+	t.Run("trap", func(t *testing.T) {
+		results, err := div.Call(ctx, 0)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{api.EncodeI32(-1)}, results)
+
+		// The function is still usable after a trap.
+		results, err = div.Call(ctx, 4)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{25}, results)
+	})
+
+	t.Run("invalid results", func(t *testing.T) {
+		err := require.CapturePanic(func() {
+			TrapToResults(mod.ExportedFunction("div"))
+		})
+		require.EqualError(t, err, "trap results invalid: 0 != 1")
+	})
+
+	t.Run("not a trap", func(t *testing.T) {
+		_, err := div.Call(ctx, api.EncodeI32(-1))
+		require.Equal(t, uint32(3), err.(*sys.ExitError).ExitCode())
+	})
+}
@@ -347,10 +347,10 @@ func callDynamic(ctx context.Context, callCtx *wasm.CallContext, typeName string
 		return nil, wasmruntime.ErrRuntimeIndirectCallTypeMismatch
 	}
 
-	t := m.Tables[0] // Emscripten doesn't use multiple tables
-	idx, err := m.Engine.LookupFunction(t, typeId, tableOffset)
+	// Emscripten doesn't use multiple tables
+	fn, err := callCtx.LookupTableFunction(0, tableOffset, uint32(typeId))
 	if err != nil {
 		return nil, err
 	}
-	return callCtx.Function(idx).Call(ctx, params...)
+	return fn.Call(ctx, params...)
 }
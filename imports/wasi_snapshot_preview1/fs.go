@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"path"
 	"syscall"
+	"unicode/utf8"
 
 	"github.com/tetratelabs/wazero/api"
 	internalsys "github.com/tetratelabs/wazero/internal/sys"
@@ -296,6 +297,8 @@ func fdFilestatGetFunc(ctx context.Context, mod api.Module, fd, resultBuf uint32
 		wasiFileMode = wasiFiletypeRegularFile
 	} else if fileMode&fs.ModeSymlink != 0 {
 		wasiFileMode = wasiFiletypeSymbolicLink
+	} else if fileMode&fs.ModeSocket != 0 {
+		wasiFileMode = wasiFiletypeSocketStream
 	}
 
 	buf, ok := mod.Memory().Read(ctx, resultBuf, 64)
@@ -404,7 +407,7 @@ func fdPrestatGetFn(ctx context.Context, mod api.Module, params []uint64) Errno
 	fd, resultPrestat := uint32(params[0]), uint32(params[1])
 
 	entry, ok := sysCtx.FS(ctx).OpenedFile(ctx, fd)
-	if !ok {
+	if !ok || isSocket(entry) {
 		return ErrnoBadf
 	}
 
@@ -467,7 +470,7 @@ func fdPrestatDirNameFn(ctx context.Context, mod api.Module, params []uint64) Er
 	fd, path, pathLen := uint32(params[0]), uint32(params[1]), uint32(params[2])
 
 	f, ok := sysCtx.FS(ctx).OpenedFile(ctx, fd)
-	if !ok {
+	if !ok || isSocket(f) {
 		return ErrnoBadf
 	}
 
@@ -906,6 +909,8 @@ var pathCreateDirectory = stubFunction(
 //   - ErrnoNametoolong: `path` + `path_len` is out of memory
 //   - ErrnoFault: `resultFilestat` points to an offset out of memory
 //   - ErrnoNoent: could not find the path
+//   - ErrnoIlseq: `path` isn't valid UTF-8, and wazero.ModuleConfig
+//     WithStrictUTF8 was set
 //
 // The rest of this implementation matches that of fdFilestatGet, so is not
 // repeated here.
@@ -944,6 +949,9 @@ func pathFilestatGetFn(ctx context.Context, mod api.Module, params []uint64) Err
 	if !ok {
 		return ErrnoNametoolong
 	}
+	if sysCtx.StrictUTF8() && !utf8.Valid(b) {
+		return ErrnoIlseq
+	}
 	pathName := string(b)
 
 	if dir, ok := fsc.OpenedFile(ctx, fd); !ok {
@@ -1013,6 +1021,8 @@ var pathLink = stubFunction(
 //   - ErrnoExist: `path` exists, while `oFlags` requires that it must not.
 //   - ErrnoNotdir: `path` is not a directory, while `oFlags` requires it.
 //   - ErrnoIo: a file system error
+//   - ErrnoIlseq: `path` isn't valid UTF-8, and wazero.ModuleConfig
+//     WithStrictUTF8 was set
 //
 // For example, this function needs to first read `path` to determine the file
 // to open. If parameters `path` = 1, `pathLen` = 6, and the path is "wazero",
@@ -1073,6 +1083,9 @@ func pathOpenFn(ctx context.Context, mod api.Module, params []uint64) Errno {
 	if !ok {
 		return ErrnoFault
 	}
+	if sysCtx.StrictUTF8() && !utf8.Valid(b) {
+		return ErrnoIlseq
+	}
 
 	newFD, errnoResult := openFile(ctx, fsc, string(b))
 	if errnoResult != ErrnoSuccess {
@@ -1306,6 +1306,41 @@ func Test_fdWrite_discard(t *testing.T) {
 	require.Equal(t, expectedMemory, actual)
 }
 
+// Test_fdWrite_StdoutLineHandler ensures lines written to STDOUT are passed to
+// the handler, including a partial line when the module is closed.
+func Test_fdWrite_StdoutLineHandler(t *testing.T) {
+	var lines []string
+	config := wazero.NewModuleConfig().WithStdoutLineHandler(func(line string) {
+		lines = append(lines, line)
+	})
+	mod, r, _ := requireProxyModule(t, config)
+	defer r.Close(testCtx)
+
+	iovs, iovsCount := uint32(0), uint32(1)
+	resultSize := uint32(8)
+	write := func(text string) {
+		memory := []byte{
+			12, 0, 0, 0, // = iovs[0].offset (where the text begins)
+			byte(len(text)), 0, 0, 0, // = iovs[0].length
+			'?', '?', '?', '?', // result.size is written here
+		}
+		ok := mod.Memory().Write(testCtx, 0, append(memory, text...))
+		require.True(t, ok)
+
+		fd := 1 // stdout
+		requireErrno(t, ErrnoSuccess, mod, functionFdWrite, uint64(fd), uint64(iovs), uint64(iovsCount), uint64(resultSize))
+	}
+
+	write("hello\nwor")
+	require.Equal(t, []string{"hello"}, lines)
+
+	write("ld\ngoodbye\n!")
+	require.Equal(t, []string{"hello", "world", "goodbye"}, lines)
+
+	require.NoError(t, mod.Close(testCtx))
+	require.Equal(t, []string{"hello", "world", "goodbye", "!"}, lines)
+}
+
 func Test_fdWrite_Errors(t *testing.T) {
 	tmpDir := t.TempDir() // open before loop to ensure no locking problems.
 	pathName := "test_path"
@@ -1720,6 +1755,43 @@ func Test_pathOpen(t *testing.T) {
 	require.Equal(t, pathName, f.Path)
 }
 
+func Test_pathOpen_StrictUTF8(t *testing.T) {
+	rootFD := uint64(3) // after 0, 1, and 2, that are stdin/out/err
+	invalid := []byte{'w', 0xff, 'z'}
+	testFS := fstest.MapFS{}
+
+	tests := []struct {
+		name          string
+		config        wazero.ModuleConfig
+		expectedErrno Errno
+	}{
+		{
+			name:          "lenient",
+			config:        wazero.NewModuleConfig().WithFS(testFS),
+			expectedErrno: ErrnoNoent, // the path is passed to the file system.
+		},
+		{
+			name:          "strict",
+			config:        wazero.NewModuleConfig().WithFS(testFS).WithStrictUTF8(),
+			expectedErrno: ErrnoIlseq,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			mod, r, _ := requireProxyModule(t, tc.config)
+			defer r.Close(testCtx)
+
+			require.True(t, mod.Memory().Write(testCtx, 0, invalid))
+			pathLen := uint64(len(invalid))
+
+			requireErrno(t, tc.expectedErrno, mod, functionPathOpen, rootFD, 0, 0, pathLen, 0, 0, 0, 0, 8)
+			requireErrno(t, tc.expectedErrno, mod, functionPathFilestatGet, rootFD, 0, 0, pathLen, 8)
+		})
+	}
+}
+
 func Test_pathOpen_Errors(t *testing.T) {
 	validFD := uint32(3) // arbitrary valid fd after 0, 1, and 2, that are stdin/out/err
 	pathName := "wazero"
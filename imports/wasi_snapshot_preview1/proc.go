@@ -17,6 +17,9 @@ const (
 // execution of the module with an exit code. The only successful exit code is
 // zero.
 //
+// If wazero.ModuleConfig WithExitHandler was set and returns nil, this
+// returns to the guest instead.
+//
 // # Parameters
 //
 //   - exitCode: exit code.
@@ -29,20 +32,27 @@ var procExit = &wasm.HostFunc{
 	ParamNames:  []string{"rval"},
 	Code: &wasm.Code{
 		IsHostFunction: true,
-		GoFunc:         wasiFunc(procExitFn),
+		GoFunc:         api.GoModuleFunc(procExitFn),
 	},
 }
 
-func procExitFn(ctx context.Context, mod api.Module, params []uint64) Errno {
+func procExitFn(ctx context.Context, mod api.Module, params []uint64) {
 	exitCode := uint32(params[0])
 
+	var err error = sys.NewExitError(mod.Name(), exitCode)
+	if handler := mod.(*wasm.CallContext).Sys.ExitHandler(); handler != nil {
+		if err = handler(exitCode); err == nil {
+			return // proc_exit has no result, so there's no errno to write.
+		}
+	}
+
 	// Ensure other callers see the exit code.
 	_ = mod.CloseWithExitCode(ctx, exitCode)
 
 	// Prevent any code from executing after this function. For example, LLVM
 	// inserts unreachable instructions after calls to exit.
 	// See: https://github.com/emscripten-core/emscripten/issues/12322
-	panic(sys.NewExitError(mod.Name(), exitCode))
+	panic(err)
 }
 
 // procRaise is stubbed and will never be supported, as it was removed.
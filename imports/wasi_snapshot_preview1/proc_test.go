@@ -1,6 +1,7 @@
 package wasi_snapshot_preview1
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/tetratelabs/wazero"
@@ -52,6 +53,44 @@ func Test_procExit(t *testing.T) {
 	}
 }
 
+func Test_procExit_ExitHandler(t *testing.T) {
+	var exitCodes []uint32
+	errExit := errors.New("exit 0")
+	config := wazero.NewModuleConfig().WithExitHandler(func(exitCode uint32) error {
+		exitCodes = append(exitCodes, exitCode)
+		if exitCode == 0 {
+			return errExit
+		}
+		return nil
+	})
+
+	mod, r, log := requireProxyModule(t, config)
+	defer r.Close(testCtx)
+
+	// A nil error from the handler returns to the guest, without closing it.
+	for _, exitCode := range []uint64{1, 42} {
+		_, err := mod.ExportedFunction(functionProcExit).Call(testCtx, exitCode)
+		require.NoError(t, err)
+	}
+	require.Equal(t, []uint32{1, 42}, exitCodes)
+	require.Equal(t, `
+--> proxy.proc_exit(rval=1)
+	==> wasi_snapshot_preview1.proc_exit(rval=1)
+	<== ()
+<-- ()
+--> proxy.proc_exit(rval=42)
+	==> wasi_snapshot_preview1.proc_exit(rval=42)
+	<== ()
+<-- ()
+`, "\n"+log.String())
+
+	// Otherwise, the call fails with the handler's error and the module is closed.
+	_, err := mod.ExportedFunction(functionProcExit).Call(testCtx, 0)
+	require.True(t, errors.Is(err, errExit), err)
+	_, err = mod.ExportedFunction(functionProcExit).Call(testCtx, 1)
+	require.Equal(t, sys.NewExitError(mod.Name(), 0), err)
+}
+
 // Test_procRaise only tests it is stubbed for GrainLang per #271
 func Test_procRaise(t *testing.T) {
 	log := requireErrnoNosys(t, functionProcRaise, 0)
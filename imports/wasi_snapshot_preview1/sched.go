@@ -1,9 +1,55 @@
 package wasi_snapshot_preview1
 
+import (
+	"context"
+	"errors"
+	"syscall"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
 const functionSchedYield = "sched_yield"
 
 // schedYield is the WASI function named functionSchedYield which temporarily
 // yields execution of the calling thread.
 //
+// If wazero.ModuleConfig WithYieldHandler was set, this calls it, otherwise
+// this does nothing.
+//
+// Result (Errno)
+//
+// The return value is ErrnoSuccess except the following error conditions:
+//   - ErrnoCanceled: the handler returned context.Canceled or
+//     context.DeadlineExceeded
+//   - ErrnoIntr: the handler returned syscall.EINTR
+//   - ErrnoIo: the handler returned another error
+//
 // See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-sched_yield---errno
-var schedYield = stubFunction(functionSchedYield, nil, nil)
+var schedYield = &wasm.HostFunc{
+	ExportNames: []string{functionSchedYield},
+	Name:        functionSchedYield,
+	ResultTypes: []api.ValueType{i32},
+	Code: &wasm.Code{
+		IsHostFunction: true,
+		GoFunc:         wasiFunc(schedYieldFn),
+	},
+}
+
+func schedYieldFn(ctx context.Context, mod api.Module, _ []uint64) Errno {
+	handler := mod.(*wasm.CallContext).Sys.YieldHandler()
+	if handler == nil {
+		return ErrnoSuccess
+	}
+
+	switch err := handler(ctx); {
+	case err == nil:
+		return ErrnoSuccess
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrnoCanceled
+	case errors.Is(err, syscall.EINTR):
+		return ErrnoIntr
+	default:
+		return ErrnoIo
+	}
+}
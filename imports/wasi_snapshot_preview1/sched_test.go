@@ -1,18 +1,56 @@
 package wasi_snapshot_preview1
 
 import (
+	"context"
+	"errors"
+	"syscall"
 	"testing"
 
+	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
-// Test_schedYield only tests it is stubbed for GrainLang per #271
 func Test_schedYield(t *testing.T) {
-	log := requireErrnoNosys(t, functionSchedYield)
+	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+
+	requireErrno(t, ErrnoSuccess, mod, functionSchedYield)
 	require.Equal(t, `
 --> proxy.sched_yield()
-	--> wasi_snapshot_preview1.sched_yield()
-	<-- ENOSYS
-<-- (52)
-`, log)
+	==> wasi_snapshot_preview1.sched_yield()
+	<== ESUCCESS
+<-- (0)
+`, "\n"+log.String())
+}
+
+func Test_schedYield_YieldHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		expectedErrno Errno
+	}{
+		{name: "success", expectedErrno: ErrnoSuccess},
+		{name: "canceled", err: context.Canceled, expectedErrno: ErrnoCanceled},
+		{name: "deadline", err: context.DeadlineExceeded, expectedErrno: ErrnoCanceled},
+		{name: "interrupted", err: syscall.EINTR, expectedErrno: ErrnoIntr},
+		{name: "other", err: errors.New("scheduler stopped"), expectedErrno: ErrnoIo},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			var yields int
+			config := wazero.NewModuleConfig().WithYieldHandler(func(ctx context.Context) error {
+				yields++
+				return tc.err
+			})
+
+			mod, r, _ := requireProxyModule(t, config)
+			defer r.Close(testCtx)
+
+			requireErrno(t, tc.expectedErrno, mod, functionSchedYield)
+			require.Equal(t, 1, yields)
+		})
+	}
 }
@@ -1,6 +1,15 @@
 package wasi_snapshot_preview1
 
-import "github.com/tetratelabs/wazero/internal/wasm"
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/tetratelabs/wazero/api"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
 
 const (
 	functionSockAccept   = "sock_accept"
@@ -12,40 +21,194 @@ const (
 // sockAccept is the WASI function named functionSockAccept which accepts a new
 // incoming connection.
 //
+// # Parameters
+//
+//   - fd: a listener pre-opened with wazero.ModuleConfig WithListener
+//   - flags: file descriptor flags of the connection, which must be zero
+//   - resultFd: offset to write the file descriptor of the connection
+//
+// Result (Errno)
+//
+// The return value is ErrnoSuccess except the following error conditions:
+//   - ErrnoBadf: `fd` is invalid
+//   - ErrnoNotsock: `fd` is not a listener
+//   - ErrnoNotsup: `flags` is not zero, e.g. non-blocking is not supported
+//   - ErrnoIo: the listener failed to accept a connection
+//   - ErrnoFault: `resultFd` points to an offset out of memory
+//
 // See: https://github.com/WebAssembly/WASI/blob/0ba0c5e2e37625ca5a6d3e4255a998dfaa3efc52/phases/snapshot/docs.md#sock_accept
 // and https://github.com/WebAssembly/WASI/pull/458
-var sockAccept = stubFunction(
-	functionSockAccept,
-	[]wasm.ValueType{i32, i32, i32},
-	[]string{"fd", "flags", "result.fd"},
-)
+var sockAccept = &wasm.HostFunc{
+	ExportNames: []string{functionSockAccept},
+	Name:        functionSockAccept,
+	ParamTypes:  []api.ValueType{i32, i32, i32},
+	ParamNames:  []string{"fd", "flags", "result.fd"},
+	ResultTypes: []api.ValueType{i32},
+	Code: &wasm.Code{
+		IsHostFunction: true,
+		GoFunc:         wasiFunc(sockAcceptFn),
+	},
+}
+
+func sockAcceptFn(ctx context.Context, mod api.Module, params []uint64) Errno {
+	sysCtx := mod.(*wasm.CallContext).Sys
+	fd, flags, resultFd := uint32(params[0]), uint32(params[1]), uint32(params[2])
+
+	if flags != 0 {
+		return ErrnoNotsup
+	}
+
+	newFD, err := sysCtx.FS(ctx).SockAccept(ctx, fd)
+	if errors.Is(err, syscall.EBADF) {
+		return ErrnoBadf
+	} else if errors.Is(err, syscall.ENOTSOCK) {
+		return ErrnoNotsock
+	} else if err != nil {
+		return ErrnoIo
+	}
+
+	if !mod.Memory().WriteUint32Le(ctx, resultFd, newFD) {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
 
 // sockRecv is the WASI function named functionSockRecv which receives a
 // message from a socket.
 //
+// This is the same as fdRead, except `fd` must be a connection accepted with
+// sockAccept, `riFlags` must be zero and zero is written to `resultRoFlags`.
+//
+// Result (Errno)
+//
+// The return value is the same as fdRead, except for the following:
+//   - ErrnoNotsock: `fd` is not a connection
+//   - ErrnoNotsup: `riFlags` is not zero, e.g. peek is not supported
+//   - ErrnoFault: `resultRoFlags` points to an offset out of memory
+//
 // See: https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-sock_recvfd-fd-ri_data-iovec_array-ri_flags-riflags---errno-size-roflags
-var sockRecv = stubFunction(
-	functionSockRecv,
-	[]wasm.ValueType{i32, i32, i32, i32, i32, i32},
-	[]string{"fd", "ri_data", "ri_data_count", "ri_flags", "result.ro_datalen", "result.ro_flags"},
-)
+var sockRecv = &wasm.HostFunc{
+	ExportNames: []string{functionSockRecv},
+	Name:        functionSockRecv,
+	ParamTypes:  []api.ValueType{i32, i32, i32, i32, i32, i32},
+	ParamNames:  []string{"fd", "ri_data", "ri_data_count", "ri_flags", "result.ro_datalen", "result.ro_flags"},
+	ResultTypes: []api.ValueType{i32},
+	Code: &wasm.Code{
+		IsHostFunction: true,
+		GoFunc:         wasiFunc(sockRecvFn),
+	},
+}
+
+func sockRecvFn(ctx context.Context, mod api.Module, params []uint64) Errno {
+	fd, riFlags, resultRoFlags := uint32(params[0]), uint32(params[3]), uint32(params[5])
+
+	if errno := requireConn(ctx, mod, fd); errno != ErrnoSuccess {
+		return errno
+	} else if riFlags != 0 {
+		return ErrnoNotsup
+	}
+
+	// fdRead has the same parameters, except the flags.
+	if errno := fdReadFn(ctx, mod, []uint64{params[0], params[1], params[2], params[4]}); errno != ErrnoSuccess {
+		return errno
+	}
+	if !mod.Memory().WriteUint16Le(ctx, resultRoFlags, 0) {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
 
 // sockSend is the WASI function named functionSockSend which sends a message
 // on a socket.
 //
+// This is the same as fdWrite, except `fd` must be a connection accepted with
+// sockAccept, and `siFlags` must be zero.
+//
+// Result (Errno)
+//
+// The return value is the same as fdWrite, except for the following:
+//   - ErrnoNotsock: `fd` is not a connection
+//   - ErrnoNotsup: `siFlags` is not zero
+//
 // See: https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-sock_sendfd-fd-si_data-ciovec_array-si_flags-siflags---errno-size
-var sockSend = stubFunction(
-	functionSockSend,
-	[]wasm.ValueType{i32, i32, i32, i32, i32},
-	[]string{"fd", "si_data", "si_data_count", "si_flags", "result.so_datalen"},
-)
+var sockSend = &wasm.HostFunc{
+	ExportNames: []string{functionSockSend},
+	Name:        functionSockSend,
+	ParamTypes:  []api.ValueType{i32, i32, i32, i32, i32},
+	ParamNames:  []string{"fd", "si_data", "si_data_count", "si_flags", "result.so_datalen"},
+	ResultTypes: []api.ValueType{i32},
+	Code: &wasm.Code{
+		IsHostFunction: true,
+		GoFunc:         wasiFunc(sockSendFn),
+	},
+}
+
+func sockSendFn(ctx context.Context, mod api.Module, params []uint64) Errno {
+	fd, siFlags := uint32(params[0]), uint32(params[3])
+
+	if errno := requireConn(ctx, mod, fd); errno != ErrnoSuccess {
+		return errno
+	} else if siFlags != 0 {
+		return ErrnoNotsup
+	}
+
+	// fdWrite has the same parameters, except the flags.
+	return fdWriteFn(ctx, mod, []uint64{params[0], params[1], params[2], params[4]})
+}
+
+// isSocket returns true if the file is a listener or a connection, which
+// aren't pre-opened directories.
+func isSocket(entry *internalsys.FileEntry) bool {
+	switch entry.File.(type) {
+	case net.Listener, net.Conn:
+		return true
+	}
+	return false
+}
+
+// requireConn returns ErrnoSuccess if the file descriptor is a connection
+// accepted with sockAccept.
+func requireConn(ctx context.Context, mod api.Module, fd uint32) Errno {
+	sysCtx := mod.(*wasm.CallContext).Sys
+	if f, ok := sysCtx.FS(ctx).OpenedFile(ctx, fd); !ok {
+		return ErrnoBadf
+	} else if _, ok = f.File.(net.Conn); !ok {
+		return ErrnoNotsock
+	}
+	return ErrnoSuccess
+}
 
 // sockShutdown is the WASI function named functionSockShutdown which shuts
 // down socket send and receive channels.
 //
+// # Parameters
+//
+//   - fd: a connection accepted with sockAccept
+//   - how: which channels to shut down
+//
+// Result (Errno)
+//
+// The return value is ErrnoNotsup, as shutting down a channel of a connection
+// isn't supported, except the following error conditions:
+//   - ErrnoBadf: `fd` is invalid
+//   - ErrnoNotsock: `fd` is not a connection
+//
 // See: https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-sock_shutdownfd-fd-how-sdflags---errno
-var sockShutdown = stubFunction(
-	functionSockShutdown,
-	[]wasm.ValueType{i32, i32},
-	[]string{"fd", "how"},
-)
+var sockShutdown = &wasm.HostFunc{
+	ExportNames: []string{functionSockShutdown},
+	Name:        functionSockShutdown,
+	ParamTypes:  []api.ValueType{i32, i32},
+	ParamNames:  []string{"fd", "how"},
+	ResultTypes: []api.ValueType{i32},
+	Code: &wasm.Code{
+		IsHostFunction: true,
+		GoFunc:         wasiFunc(sockShutdownFn),
+	},
+}
+
+func sockShutdownFn(ctx context.Context, mod api.Module, params []uint64) Errno {
+	if errno := requireConn(ctx, mod, uint32(params[0])); errno != ErrnoSuccess {
+		return errno
+	}
+	return ErrnoNotsup
+}
@@ -1,51 +1,216 @@
 package wasi_snapshot_preview1
 
 import (
+	"io"
+	"net"
 	"testing"
+	"testing/fstest"
 
+	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
-// Test_sockAccept only tests it is stubbed for GrainLang per #271
-func Test_sockAccept(t *testing.T) {
-	log := requireErrnoNosys(t, functionSockAccept, 0, 0, 0)
-	require.Equal(t, `
---> proxy.sock_accept(fd=0,flags=0,result.fd=0)
-	--> wasi_snapshot_preview1.sock_accept(fd=0,flags=0,result.fd=0)
-	<-- ENOSYS
-<-- (52)
-`, log)
-}
+// Test_sockAccept_Recv_Send exchanges a few bytes with a loopback connection.
+func Test_sockAccept_Recv_Send(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig().WithListener(ln))
+	defer r.Close(testCtx)
+
+	// The client sends "wazero", then reads the reply.
+	reply := make(chan string, 1)
+	go func() {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			reply <- err.Error()
+			return
+		}
+		defer conn.Close()
+		if _, err = conn.Write([]byte("wazero")); err != nil {
+			reply <- err.Error()
+			return
+		}
+		buf := make([]byte, 2)
+		if _, err = io.ReadFull(conn, buf); err != nil {
+			reply <- err.Error()
+			return
+		}
+		reply <- string(buf)
+	}()
 
-// Test_sockRecv only tests it is stubbed for GrainLang per #271
-func Test_sockRecv(t *testing.T) {
-	log := requireErrnoNosys(t, functionSockRecv, 0, 0, 0, 0, 0, 0)
+	// There's no file system, so the listener is the first pre-opened fd.
+	listenerFd, resultFd := uint32(3), uint32(0)
+	requireErrno(t, ErrnoSuccess, mod, functionSockAccept, uint64(listenerFd), 0, uint64(resultFd))
+	fd, ok := mod.Memory().ReadUint32Le(testCtx, resultFd)
+	require.True(t, ok)
+	require.Equal(t, uint32(4), fd)
 	require.Equal(t, `
---> proxy.sock_recv(fd=0,ri_data=0,ri_data_count=0,ri_flags=0,result.ro_datalen=0,result.ro_flags=0)
-	--> wasi_snapshot_preview1.sock_recv(fd=0,ri_data=0,ri_data_count=0,ri_flags=0,result.ro_datalen=0,result.ro_flags=0)
-	<-- ENOSYS
-<-- (52)
-`, log)
-}
+--> proxy.sock_accept(fd=3,flags=0,result.fd=0)
+	==> wasi_snapshot_preview1.sock_accept(fd=3,flags=0,result.fd=0)
+	<== ESUCCESS
+<-- (0)
+`, "\n"+log.String())
+	log.Reset()
+
+	// Receive "wazero" into memory at offset 16. A read may return fewer
+	// bytes than were sent, so read until all arrived or the end of stream.
+	iovs, resultDatalen, resultFlags := uint32(4), uint32(12), uint32(40)
+	var received []byte
+	for len(received) < len("wazero") {
+		require.True(t, mod.Memory().Write(testCtx, iovs, []byte{
+			16, 0, 0, 0, // = iovs[0].offset
+			16, 0, 0, 0, // = iovs[0].length
+		}))
+		require.True(t, mod.Memory().WriteUint16Le(testCtx, resultFlags, 0xffff))
+		requireErrno(t, ErrnoSuccess, mod, functionSockRecv, uint64(fd), uint64(iovs), 1, 0, uint64(resultDatalen), uint64(resultFlags))
+		datalen, ok := mod.Memory().ReadUint32Le(testCtx, resultDatalen)
+		require.True(t, ok)
+		if datalen == 0 {
+			break // EOF
+		}
+		buf, ok := mod.Memory().Read(testCtx, 16, datalen)
+		require.True(t, ok)
+		received = append(received, buf...)
+		flags, ok := mod.Memory().ReadUint16Le(testCtx, resultFlags)
+		require.True(t, ok)
+		require.Zero(t, flags)
+	}
+	require.Equal(t, "wazero", string(received))
+	log.Reset() // The count of sock_recv calls varies.
+
+	// Send "ok" back from memory at offset 16.
+	require.True(t, mod.Memory().Write(testCtx, 16, []byte("ok")))
+	require.True(t, mod.Memory().WriteUint32Le(testCtx, iovs+4, 2)) // iovs[0].length
+	requireErrno(t, ErrnoSuccess, mod, functionSockSend, uint64(fd), uint64(iovs), 1, 0, uint64(resultDatalen))
+	datalen, ok := mod.Memory().ReadUint32Le(testCtx, resultDatalen)
+	require.True(t, ok)
+	require.Equal(t, uint32(2), datalen)
+	require.Equal(t, "ok", <-reply)
+
+	// Shutting down a channel isn't supported.
+	requireErrno(t, ErrnoNotsup, mod, functionSockShutdown, uint64(fd), 0)
 
-// Test_sockSend only tests it is stubbed for GrainLang per #271
-func Test_sockSend(t *testing.T) {
-	log := requireErrnoNosys(t, functionSockSend, 0, 0, 0, 0, 0)
 	require.Equal(t, `
---> proxy.sock_send(fd=0,si_data=0,si_data_count=0,si_flags=0,result.so_datalen=0)
-	--> wasi_snapshot_preview1.sock_send(fd=0,si_data=0,si_data_count=0,si_flags=0,result.so_datalen=0)
-	<-- ENOSYS
-<-- (52)
-`, log)
+--> proxy.sock_send(fd=4,si_data=4,si_data_count=1,si_flags=0,result.so_datalen=12)
+	==> wasi_snapshot_preview1.sock_send(fd=4,si_data=4,si_data_count=1,si_flags=0,result.so_datalen=12)
+	<== ESUCCESS
+<-- (0)
+--> proxy.sock_shutdown(fd=4,how=0)
+	==> wasi_snapshot_preview1.sock_shutdown(fd=4,how=0)
+	<== ENOTSUP
+<-- (58)
+`, "\n"+log.String())
 }
 
-// Test_sockShutdown only tests it is stubbed for GrainLang per #271
-func Test_sockShutdown(t *testing.T) {
-	log := requireErrnoNosys(t, functionSockShutdown, 0, 0)
-	require.Equal(t, `
---> proxy.sock_shutdown(fd=0,how=0)
-	--> wasi_snapshot_preview1.sock_shutdown(fd=0,how=0)
-	<-- ENOSYS
-<-- (52)
-`, log)
+func Test_sock_Errors(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// The root directory is fd 3, so the listener is fd 4.
+	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig().WithFS(fstest.MapFS{}).WithListener(ln))
+	defer r.Close(testCtx)
+
+	tests := []struct {
+		name          string
+		funcName      string
+		params        []uint64
+		expectedErrno Errno
+		expectedLog   string
+	}{
+		{
+			name:          "sock_accept invalid fd",
+			funcName:      functionSockAccept,
+			params:        []uint64{42, 0, 0},
+			expectedErrno: ErrnoBadf,
+			expectedLog: `
+--> proxy.sock_accept(fd=42,flags=0,result.fd=0)
+	==> wasi_snapshot_preview1.sock_accept(fd=42,flags=0,result.fd=0)
+	<== EBADF
+<-- (8)
+`,
+		},
+		{
+			name:          "sock_accept not a listener",
+			funcName:      functionSockAccept,
+			params:        []uint64{3, 0, 0},
+			expectedErrno: ErrnoNotsock,
+			expectedLog: `
+--> proxy.sock_accept(fd=3,flags=0,result.fd=0)
+	==> wasi_snapshot_preview1.sock_accept(fd=3,flags=0,result.fd=0)
+	<== ENOTSOCK
+<-- (57)
+`,
+		},
+		{
+			name:          "sock_accept non-blocking",
+			funcName:      functionSockAccept,
+			params:        []uint64{4, 4, 0},
+			expectedErrno: ErrnoNotsup,
+			expectedLog: `
+--> proxy.sock_accept(fd=4,flags=4,result.fd=0)
+	==> wasi_snapshot_preview1.sock_accept(fd=4,flags=4,result.fd=0)
+	<== ENOTSUP
+<-- (58)
+`,
+		},
+		{
+			name:          "sock_recv not a connection",
+			funcName:      functionSockRecv,
+			params:        []uint64{4, 0, 0, 0, 0, 0},
+			expectedErrno: ErrnoNotsock,
+			expectedLog: `
+--> proxy.sock_recv(fd=4,ri_data=0,ri_data_count=0,ri_flags=0,result.ro_datalen=0,result.ro_flags=0)
+	==> wasi_snapshot_preview1.sock_recv(fd=4,ri_data=0,ri_data_count=0,ri_flags=0,result.ro_datalen=0,result.ro_flags=0)
+	<== ENOTSOCK
+<-- (57)
+`,
+		},
+		{
+			name:          "sock_send invalid fd",
+			funcName:      functionSockSend,
+			params:        []uint64{42, 0, 0, 0, 0},
+			expectedErrno: ErrnoBadf,
+			expectedLog: `
+--> proxy.sock_send(fd=42,si_data=0,si_data_count=0,si_flags=0,result.so_datalen=0)
+	==> wasi_snapshot_preview1.sock_send(fd=42,si_data=0,si_data_count=0,si_flags=0,result.so_datalen=0)
+	<== EBADF
+<-- (8)
+`,
+		},
+		{
+			name:          "sock_shutdown not a connection",
+			funcName:      functionSockShutdown,
+			params:        []uint64{4, 0},
+			expectedErrno: ErrnoNotsock,
+			expectedLog: `
+--> proxy.sock_shutdown(fd=4,how=0)
+	==> wasi_snapshot_preview1.sock_shutdown(fd=4,how=0)
+	<== ENOTSOCK
+<-- (57)
+`,
+		},
+		{
+			name:          "fd_prestat_get on a listener",
+			funcName:      functionFdPrestatGet,
+			params:        []uint64{4, 0},
+			expectedErrno: ErrnoBadf,
+			expectedLog: `
+--> proxy.fd_prestat_get(fd=4,result.prestat=0)
+	==> wasi_snapshot_preview1.fd_prestat_get(fd=4,result.prestat=0)
+	<== EBADF
+<-- (8)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			defer log.Reset()
+
+			requireErrno(t, tc.expectedErrno, mod, tc.funcName, tc.params...)
+			require.Equal(t, tc.expectedLog, "\n"+log.String())
+		})
+	}
 }
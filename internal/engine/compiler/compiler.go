@@ -23,6 +23,9 @@ type compiler interface {
 	// Return true if the compiler decided to skip the entire label.
 	// See wazeroir.OperationLabel
 	compileLabel(o *wazeroir.OperationLabel) (skipThisLabel bool)
+	// compileCheckInterrupt adds instructions to decrement callEngine.exitContext.interruptCheckCountdown,
+	// and call builtinFunctionIndexCheckInterrupt when it reaches zero. This is emitted at loop headers.
+	compileCheckInterrupt() error
 	// compileUnreachable adds instruction to perform wazeroir.OperationUnreachable.
 	compileUnreachable() error
 	// compileSet adds instruction to perform wazeroir.OperationSet.
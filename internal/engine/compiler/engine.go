@@ -7,9 +7,11 @@ import (
 	"reflect"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/compilationcache"
 	"github.com/tetratelabs/wazero/internal/platform"
 	"github.com/tetratelabs/wazero/internal/version"
@@ -19,6 +21,19 @@ import (
 	"github.com/tetratelabs/wazero/internal/wazeroir"
 )
 
+// ImplementedFeatures are the api.CoreFeatures the compiler can execute.
+// Notably, this excludes api.CoreFeatureMemory64, api.CoreFeatureRelaxedSIMD
+// and api.CoreFeatureExceptionHandling.
+//
+// See wazero.RuntimeConfig WithStrictFeatures
+const ImplementedFeatures = api.CoreFeaturesV2 | api.CoreFeatureExtendedConst | api.CoreFeatureThreads
+
+// MaxCodeBytesKey is a context.Context key holding the uint64 max count of
+// bytes of machine code a module may compile to.
+//
+// See wazero.RuntimeConfig WithMaxCompiledCodeBytes
+type MaxCodeBytesKey struct{}
+
 type (
 	// engine is a Compiler implementation of wasm.Engine
 	engine struct {
@@ -29,6 +44,10 @@ type (
 		// setFinalizer defaults to runtime.SetFinalizer, but overridable for tests.
 		setFinalizer  func(obj interface{}, finalizer interface{})
 		wazeroVersion string
+		// wrappingDivision makes signed integer division overflow wrap instead of trap.
+		wrappingDivision bool
+		// maxCodeBytes is the max size of machine code per module, or zero if unlimited.
+		maxCodeBytes uint64
 	}
 
 	// moduleEngine implements wasm.ModuleEngine
@@ -118,6 +137,11 @@ type (
 
 		// initialFn is the initial function for this call engine.
 		initialFn *function
+
+		// interruptCause is the value builtinFunctionIndexCheckInterrupt panics with, if non-nil.
+		interruptCause error
+		// interruptMu guards interruptCause.
+		interruptMu sync.Mutex
 	}
 
 	// moduleContext holds the per-function call specific module information.
@@ -199,6 +223,14 @@ type (
 		// returnAddress is the return address which the engine jumps into
 		// after executing a builtin function or host function.
 		returnAddress uintptr
+
+		// interruptCheckCountdown is decremented by compiled code at loop headers,
+		// which calls builtinFunctionIndexCheckInterrupt when it reaches zero.
+		//
+		// Note: This isn't only a flag set by Interrupt, as native code cannot be
+		// preempted by the Go scheduler. The goroutine watching for an interrupt
+		// may never run unless we periodically yield, e.g. when GOMAXPROCS=1.
+		interruptCheckCountdown uint64
 	}
 
 	// callFrame holds the information to which the caller function can return.
@@ -289,6 +321,7 @@ const (
 	callEngineExitContextNativeCallStatusCodeOffset     = 120
 	callEngineExitContextBuiltinFunctionCallIndexOffset = 124
 	callEngineExitContextReturnAddressOffset            = 128
+	callEngineExitContextInterruptCheckCountdownOffset  = 136
 
 	// Offsets for function.
 	functionCodeInitialAddressOffset    = 0
@@ -353,13 +386,15 @@ const (
 	nativeCallStatusCodeInvalidFloatToIntConversion
 	// nativeCallStatusCodeMemoryOutOfBounds means an out-of-bounds memory access happened.
 	nativeCallStatusCodeMemoryOutOfBounds
-	// nativeCallStatusCodeInvalidTableAccess means either offset to the table was out of bounds of table, or
-	// the target element in the table was uninitialized during call_indirect instruction.
+	// nativeCallStatusCodeInvalidTableAccess means the offset to the table was out of bounds of table.
 	nativeCallStatusCodeInvalidTableAccess
 	// nativeCallStatusCodeTypeMismatchOnIndirectCall means the type check failed during call_indirect.
 	nativeCallStatusCodeTypeMismatchOnIndirectCall
 	nativeCallStatusIntegerOverflow
 	nativeCallStatusIntegerDivisionByZero
+	// nativeCallStatusCodeUninitializedElement means the target element in the table was uninitialized
+	// during call_indirect instruction.
+	nativeCallStatusCodeUninitializedElement
 )
 
 // causePanic causes a panic with the corresponding error to the nativeCallStatusCode.
@@ -378,6 +413,8 @@ func (s nativeCallStatusCode) causePanic() {
 		err = wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess
 	case nativeCallStatusCodeInvalidTableAccess:
 		err = wasmruntime.ErrRuntimeInvalidTableAccess
+	case nativeCallStatusCodeUninitializedElement:
+		err = wasmruntime.ErrRuntimeUninitializedElement
 	case nativeCallStatusCodeTypeMismatchOnIndirectCall:
 		err = wasmruntime.ErrRuntimeIndirectCallTypeMismatch
 	}
@@ -400,6 +437,8 @@ func (s nativeCallStatusCode) String() (ret string) {
 		ret = "memory out of bounds"
 	case nativeCallStatusCodeInvalidTableAccess:
 		ret = "invalid table access"
+	case nativeCallStatusCodeUninitializedElement:
+		ret = "uninitialized element"
 	case nativeCallStatusCodeTypeMismatchOnIndirectCall:
 		ret = "type mismatch on indirect call"
 	case nativeCallStatusIntegerOverflow:
@@ -432,6 +471,8 @@ func releaseCode(compiledFn *code) {
 
 // CompiledModuleCount implements the same method as documented on wasm.Engine.
 func (e *engine) CompiledModuleCount() uint32 {
+	e.mux.RLock()
+	defer e.mux.RUnlock()
 	return uint32(len(e.codes))
 }
 
@@ -449,12 +490,14 @@ func (e *engine) CompileModule(ctx context.Context, module *wasm.Module) error {
 	}
 
 	funcs := make([]*code, 0, len(module.FunctionSection))
+	var codeBytes uint64
 
 	irs, err := wazeroir.CompileFunctions(ctx, e.enabledFeatures, callFrameDataSizeInUint64, module)
 	if err != nil {
 		return err
 	}
 	for funcIndex, ir := range irs {
+		ir.WrappingDivision = e.wrappingDivision
 		var compiled *code
 		if ir.GoFunc != nil {
 			if compiled, err = compileGoDefinedHostFunction(ir); err != nil {
@@ -469,6 +512,10 @@ func (e *engine) CompileModule(ctx context.Context, module *wasm.Module) error {
 		// As this uses mmap, we need to munmap on the compiled machine code when it's GCed.
 		e.setFinalizer(compiled, releaseCode)
 
+		if codeBytes += uint64(len(compiled.codeSegment)); e.maxCodeBytes > 0 && codeBytes > e.maxCodeBytes {
+			return fmt.Errorf("compiled code exceeds the max of %d bytes", e.maxCodeBytes)
+		}
+
 		compiled.indexInModule = wasm.Index(funcIndex)
 		compiled.sourceModule = module
 
@@ -552,6 +599,22 @@ func (e *moduleEngine) InitializeFuncrefGlobals(globals []*wasm.GlobalInstance)
 	}
 }
 
+// CompiledFunction implements the same method as documented on wasm.NativeModuleEngine.
+func (e *moduleEngine) CompiledFunction(funcIndex wasm.Index) (experimental.CompiledFunction, bool) {
+	if funcIndex >= uint32(len(e.functions)) {
+		return experimental.CompiledFunction{}, false
+	}
+	f := e.functions[funcIndex]
+	if f == nil { // e.g. the module was closed.
+		return experimental.CompiledFunction{}, false
+	}
+	return experimental.CompiledFunction{
+		CodeAddress:     f.codeInitialAddress,
+		ModuleAddress:   f.moduleInstanceAddress,
+		FunctionPointer: uint64(uintptr(unsafe.Pointer(f))),
+	}, true
+}
+
 func (e *moduleEngine) NewCallEngine(callCtx *wasm.CallContext, f *wasm.FunctionInstance) (ce wasm.CallEngine, err error) {
 	// Note: The input parameters are pre-validated, so a compiled function is only absent on close. Updates to
 	// code on close aren't locked, neither is this read.
@@ -571,14 +634,14 @@ func (e *moduleEngine) NewCallEngine(callCtx *wasm.CallContext, f *wasm.Function
 }
 
 // LookupFunction implements the same method as documented on wasm.ModuleEngine.
-func (e *moduleEngine) LookupFunction(t *wasm.TableInstance, typeId wasm.FunctionTypeID, tableOffset wasm.Index) (idx wasm.Index, err error) {
+func (e *moduleEngine) LookupFunction(t *wasm.TableInstance, typeId wasm.FunctionTypeID, tableOffset wasm.Index) (f *wasm.FunctionInstance, err error) {
 	if tableOffset >= uint32(len(t.References)) {
 		err = wasmruntime.ErrRuntimeInvalidTableAccess
 		return
 	}
 	rawPtr := t.References[tableOffset]
 	if rawPtr == 0 {
-		err = wasmruntime.ErrRuntimeInvalidTableAccess
+		err = wasmruntime.ErrRuntimeUninitializedElement
 		return
 	}
 
@@ -587,7 +650,7 @@ func (e *moduleEngine) LookupFunction(t *wasm.TableInstance, typeId wasm.Functio
 		err = wasmruntime.ErrRuntimeIndirectCallTypeMismatch
 		return
 	}
-	idx = tf.source.Idx
+	f = tf.source
 
 	return
 }
@@ -606,11 +669,24 @@ func functionFromUintptr(ptr uintptr) *function {
 
 // Call implements the same method as documented on wasm.ModuleEngine.
 func (ce *callEngine) Call(ctx context.Context, callCtx *wasm.CallContext, params []uint64) (results []uint64, err error) {
+	return ce.call(ctx, callCtx, params, nil)
+}
+
+// CallInPlace implements the same method as documented on wasm.CallEngine.
+func (ce *callEngine) CallInPlace(ctx context.Context, callCtx *wasm.CallContext, params []uint64) (results []uint64, err error) {
+	return ce.call(ctx, callCtx, params, params)
+}
+
+// call invokes the initial function with params, writing results into the
+// backing array of buf if it has enough capacity.
+func (ce *callEngine) call(ctx context.Context, callCtx *wasm.CallContext, params, buf []uint64) (results []uint64, err error) {
 	tp := ce.initialFn.source.Type
 
 	paramCount := len(params)
 	if tp.ParamNumInUint64 != paramCount {
-		return nil, fmt.Errorf("expected %d params, but passed %d", ce.initialFn.source.Type.ParamNumInUint64, paramCount)
+		def := ce.initialFn.source.Definition
+		return nil, fmt.Errorf("%s: expected %d params, but passed %d",
+			wasmdebug.Signature(def.DebugName(), def.ParamTypes(), def.ResultTypes()), tp.ParamNumInUint64, paramCount)
 	}
 
 	// We ensure that this Call method never panics as
@@ -632,13 +708,28 @@ func (ce *callEngine) Call(ctx context.Context, callCtx *wasm.CallContext, param
 	// This returns a safe copy of the results, instead of a slice view. If we
 	// returned a re-slice, the caller could accidentally or purposefully
 	// corrupt the stack of subsequent calls
-	if resultCount := tp.ResultNumInUint64; resultCount > 0 {
+	if resultCount := tp.ResultNumInUint64; cap(buf) >= resultCount {
+		results = buf[:resultCount]
+		copy(results, ce.stack[:resultCount])
+	} else if resultCount > 0 {
 		results = make([]uint64, resultCount)
 		copy(results, ce.stack[:resultCount])
 	}
 	return
 }
 
+// Interrupt implements the same method as documented on wasm.CallEngine.
+func (ce *callEngine) Interrupt(cause error) {
+	ce.interruptMu.Lock()
+	ce.interruptCause = cause
+	ce.interruptMu.Unlock()
+	if cause != nil {
+		// Check on the next loop iteration. This can be lost if compiled code
+		// concurrently decrements the countdown, but only until the next check.
+		atomic.StoreUint64(&ce.exitContext.interruptCheckCountdown, 1)
+	}
+}
+
 // initializeStack initializes callEngine.stack before entering native code.
 //
 // The stack must look like, if len(params) < len(results):
@@ -731,12 +822,25 @@ func newEngine(ctx context.Context, enabledFeatures api.CoreFeatures) *engine {
 	if v := ctx.Value(version.WazeroVersionKey{}); v != nil {
 		wazeroVersion = v.(string)
 	}
+	wrappingDivision, _ := ctx.Value(wazeroir.WrappingDivisionKey{}).(bool)
+	if wrappingDivision {
+		// Code compiled with wrapping division must not be shared via the
+		// cache with code compiled without it, so treat it as stale.
+		wazeroVersion += "+wrapping-division"
+	}
+	maxCodeBytes, _ := ctx.Value(MaxCodeBytesKey{}).(uint64)
+	if maxCodeBytes > 0 {
+		// Likewise, code cached without this limit wasn't checked against it.
+		wazeroVersion += fmt.Sprintf("+max-code-bytes=%d", maxCodeBytes)
+	}
 	return &engine{
-		enabledFeatures: enabledFeatures,
-		codes:           map[wasm.ModuleID][]*code{},
-		setFinalizer:    runtime.SetFinalizer,
-		Cache:           compilationcache.NewFileCache(ctx),
-		wazeroVersion:   wazeroVersion,
+		enabledFeatures:  enabledFeatures,
+		codes:            map[wasm.ModuleID][]*code{},
+		setFinalizer:     runtime.SetFinalizer,
+		Cache:            compilationcache.NewFileCache(ctx),
+		wazeroVersion:    wazeroVersion,
+		wrappingDivision: wrappingDivision,
+		maxCodeBytes:     maxCodeBytes,
 	}
 }
 
@@ -776,6 +880,7 @@ func (e *moduleEngine) newCallEngine(stackSize uint64, fn *function) *callEngine
 		initialFn:     fn,
 		moduleContext: moduleContext{fn: fn},
 	}
+	ce.exitContext.interruptCheckCountdown = interruptCheckInterval
 
 	stackHeader := (*reflect.SliceHeader)(unsafe.Pointer(&ce.stack))
 	ce.stackContext = stackContext{
@@ -804,6 +909,9 @@ const (
 	builtinFunctionIndexMemoryGrow wasm.Index = iota
 	builtinFunctionIndexGrowStack
 	builtinFunctionIndexTableGrow
+	// builtinFunctionIndexCheckInterrupt yields to the Go scheduler, then panics
+	// with the cause passed to callEngine.Interrupt, if any.
+	builtinFunctionIndexCheckInterrupt
 	// builtinFunctionIndexBreakPoint is internal (only for wazero developers). Disabled by default.
 	builtinFunctionIndexBreakPoint
 )
@@ -836,11 +944,16 @@ entry:
 			fn := calleeHostFunction.source.GoFunc
 			switch fn := fn.(type) {
 			case api.GoModuleFunction:
-				fn.Call(ctx, callCtx.WithMemory(ce.memoryInstance), stack)
+				fn.Call(ctx, callCtx.WithMemory(ce.memoryInstance).WithHostModule(calleeHostFunction.source.Module), stack)
 			case api.GoFunction:
 				fn.Call(ctx, stack)
 			}
 
+			// The host function may have grown memory via api.Memory Grow.
+			if mem := ce.memoryInstance; mem != nil {
+				ce.refreshMemory(mem)
+			}
+
 			codeAddr, modAddr = ce.returnAddress, ce.moduleInstanceAddress
 			goto entry
 		case nativeCallStatusCodeCallBuiltInFunction:
@@ -852,6 +965,8 @@ entry:
 				ce.builtinFunctionGrowStack(caller.stackPointerCeil)
 			case builtinFunctionIndexTableGrow:
 				ce.builtinFunctionTableGrow(ctx, caller.source.Module.Tables)
+			case builtinFunctionIndexCheckInterrupt:
+				ce.builtinFunctionCheckInterrupt()
 			}
 			if false {
 				if ce.exitContext.builtinFunctionCallIndex == builtinFunctionIndexBreakPoint {
@@ -901,12 +1016,35 @@ func (ce *callEngine) builtinFunctionMemoryGrow(ctx context.Context, mem *wasm.M
 		ce.pushValue(uint64(res))
 	}
 
-	// Update the moduleContext fields as they become stale after the update ^^.
+	ce.refreshMemory(mem)
+}
+
+// refreshMemory updates the moduleContext fields which become stale when
+// memory grows, e.g. by memory.grow or a host function.
+func (ce *callEngine) refreshMemory(mem *wasm.MemoryInstance) {
 	bufSliceHeader := (*reflect.SliceHeader)(unsafe.Pointer(&mem.Buffer))
 	ce.moduleContext.memorySliceLen = uint64(bufSliceHeader.Len)
 	ce.moduleContext.memoryElement0Address = bufSliceHeader.Data
 }
 
+// interruptCheckInterval is the count of loop iterations between calls to
+// builtinFunctionCheckInterrupt, absent a call to callEngine.Interrupt.
+const interruptCheckInterval = 1 << 16
+
+func (ce *callEngine) builtinFunctionCheckInterrupt() {
+	// Give other goroutines, such as one watching for a context deadline, a
+	// chance to call Interrupt.
+	runtime.Gosched()
+
+	ce.interruptMu.Lock()
+	cause := ce.interruptCause
+	ce.interruptMu.Unlock()
+	if cause != nil {
+		panic(cause)
+	}
+	atomic.StoreUint64(&ce.exitContext.interruptCheckCountdown, interruptCheckInterval)
+}
+
 func (ce *callEngine) builtinFunctionTableGrow(ctx context.Context, tables []*wasm.TableInstance) {
 	tableIndex := uint32(ce.popValue())
 	table := tables[tableIndex] // verified not to be out of range by the func validation at compilation phase.
@@ -962,7 +1100,11 @@ func compileWasmFunction(_ api.CoreFeatures, ir *wazeroir.CompilationResult) (*c
 		var err error
 		switch o := op.(type) {
 		case *wazeroir.OperationLabel:
-			// Label op is already handled ^^.
+			// Label op is already handled ^^, but loop headers need to poll
+			// for interrupts, as otherwise an infinite loop can't be stopped.
+			if o.Label.Kind == wazeroir.LabelKindHeader && ir.LabelCallers[o.Label.String()] > 1 {
+				err = compiler.compileCheckInterrupt()
+			}
 		case *wazeroir.OperationUnreachable:
 			err = compiler.compileUnreachable()
 		case *wazeroir.OperationBr:
@@ -71,12 +71,13 @@ func (e *engine) getCodesFromCache(module *wasm.Module) (codes []*code, hit bool
 	if !hit || err != nil {
 		return
 	}
-	defer cached.Close()
 
 	// Otherwise, we hit the cache on external cache.
 	// We retrieve *code structures from `cached`.
 	var staleCache bool
 	codes, staleCache, err = deserializeCodes(e.wazeroVersion, cached)
+	// Close before deleting a stale entry, as the cache may lock until then.
+	_ = cached.Close()
 	if err != nil {
 		hit = false
 		return
@@ -36,8 +36,8 @@ func (e *engineTester) ListenerFactory() experimental.FunctionListenerFactory {
 }
 
 // NewEngine implements the same method as documented on enginetest.EngineTester.
-func (e *engineTester) NewEngine(enabledFeatures api.CoreFeatures) wasm.Engine {
-	return newEngine(context.Background(), enabledFeatures)
+func (e *engineTester) NewEngine(ctx context.Context, enabledFeatures api.CoreFeatures) wasm.Engine {
+	return newEngine(ctx, enabledFeatures)
 }
 
 // InitTables implements the same method as documented on enginetest.EngineTester.
@@ -81,6 +81,11 @@ func TestCompiler_ModuleEngine_LookupFunction(t *testing.T) {
 	enginetest.RunTestModuleEngine_LookupFunction(t, et)
 }
 
+func TestCompiler_ModuleEngine_TableOps(t *testing.T) {
+	requireSupportedOSArch(t)
+	enginetest.RunTestModuleEngine_TableOps(t, et)
+}
+
 func TestCompiler_ModuleEngine_Call(t *testing.T) {
 	requireSupportedOSArch(t)
 	enginetest.RunTestModuleEngine_Call(t, et)
@@ -96,6 +101,31 @@ func TestCompiler_ModuleEngine_Call_Errors(t *testing.T) {
 	enginetest.RunTestModuleEngine_Call_Errors(t, et)
 }
 
+func TestCompiler_ModuleEngine_RefTypes(t *testing.T) {
+	requireSupportedOSArch(t)
+	enginetest.RunTestModuleEngine_RefTypes(t, et)
+}
+
+func TestCompiler_ModuleEngine_WrappingDivision(t *testing.T) {
+	requireSupportedOSArch(t)
+	enginetest.RunTestModuleEngine_WrappingDivision(t, et)
+}
+
+func TestCompiler_ModuleEngine_NonTrappingFloatToInt(t *testing.T) {
+	requireSupportedOSArch(t)
+	enginetest.RunTestModuleEngine_NonTrappingFloatToInt(t, et)
+}
+
+func TestCompiler_Engine_FloatConsistency(t *testing.T) {
+	requireSupportedOSArch(t)
+	enginetest.RunTestEngine_FloatConsistency(t, et)
+}
+
+func TestCompiler_ModuleEngine_BulkMemory(t *testing.T) {
+	requireSupportedOSArch(t)
+	enginetest.RunTestModuleEngine_BulkMemory(t, et)
+}
+
 func TestCompiler_ModuleEngine_Memory(t *testing.T) {
 	requireSupportedOSArch(t)
 	enginetest.RunTestModuleEngine_Memory(t, et)
@@ -120,7 +150,7 @@ func (f fakeFinalizer) setFinalizer(obj interface{}, finalizer interface{}) {
 
 func TestCompiler_CompileModule(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
-		e := et.NewEngine(api.CoreFeaturesV1).(*engine)
+		e := et.NewEngine(testCtx, api.CoreFeaturesV1).(*engine)
 		ff := fakeFinalizer{}
 		e.setFinalizer = ff.setFinalizer
 
@@ -166,7 +196,7 @@ func TestCompiler_CompileModule(t *testing.T) {
 		}
 		errModule.BuildFunctionDefinitions()
 
-		e := et.NewEngine(api.CoreFeaturesV1).(*engine)
+		e := et.NewEngine(testCtx, api.CoreFeaturesV1).(*engine)
 		err := e.CompileModule(testCtx, errModule)
 		require.EqualError(t, err, "failed to lower func[.$2] to wazeroir: handling instruction: apply stack failed for call: reading immediates: EOF")
 
@@ -214,13 +244,13 @@ func TestCompiler_SliceAllocatedOnHeap(t *testing.T) {
 		// Trigger relocation of goroutine stack because at this point we have the majority of
 		// goroutine stack unused after recursive call.
 		runtime.GC()
-	}}, nil, enabledFeatures)
+	}}, nil, nil, enabledFeatures)
 	require.NoError(t, err)
 
 	err = s.Engine.CompileModule(testCtx, hm)
 	require.NoError(t, err)
 
-	_, err = s.Instantiate(testCtx, ns, hm, hostModuleName, nil, nil)
+	_, err = s.Instantiate(testCtx, ns, hm, hostModuleName, nil, nil, nil)
 	require.NoError(t, err)
 
 	const stackCorruption = "value_stack_corruption"
@@ -276,7 +306,7 @@ func TestCompiler_SliceAllocatedOnHeap(t *testing.T) {
 	err = s.Engine.CompileModule(testCtx, m)
 	require.NoError(t, err)
 
-	mi, err := s.Instantiate(testCtx, ns, m, t.Name(), nil, nil)
+	mi, err := s.Instantiate(testCtx, ns, m, t.Name(), nil, nil, nil)
 	require.NoError(t, err)
 
 	for _, fnName := range []string{stackCorruption, callStackCorruption} {
@@ -669,6 +669,32 @@ func (c *amd64Compiler) compileLabel(o *wazeroir.OperationLabel) (skipLabel bool
 	return
 }
 
+// compileCheckInterrupt implements compiler.compileCheckInterrupt for the amd64 architecture.
+func (c *amd64Compiler) compileCheckInterrupt() error {
+	// Release all the registers up front, so that both branches below end with the same location stack.
+	if err := c.compileReleaseAllRegistersToStack(); err != nil {
+		return err
+	}
+
+	// "ce.exitContext.interruptCheckCountdown--"
+	c.assembler.CompileNoneToMemory(amd64.DECQ,
+		amd64ReservedRegisterForCallEngine, callEngineExitContextInterruptCheckCountdownOffset)
+
+	// Jump if the countdown hasn't reached zero, which is the common case.
+	jmpIfNotZero := c.assembler.CompileJump(amd64.JNE)
+
+	if err := c.compileCallBuiltinFunction(builtinFunctionIndexCheckInterrupt); err != nil {
+		return err
+	}
+
+	// After the function call, we have to initialize the stack base pointer and memory reserved registers.
+	c.compileReservedStackBasePointerInitialization()
+	c.compileReservedMemoryPointerInitialization()
+
+	c.assembler.SetJumpTargetOnNext(jmpIfNotZero)
+	return nil
+}
+
 // compileCall implements compiler.compileCall for the amd64 architecture.
 func (c *amd64Compiler) compileCall(o *wazeroir.OperationCall) error {
 	if err := c.maybeCompileMoveTopConditionalToGeneralPurposeRegister(); err != nil {
@@ -756,8 +782,8 @@ func (c *amd64Compiler) compileCallIndirect(o *wazeroir.OperationCallIndirect) e
 	// Jump if the target is initialized element.
 	jumpIfInitialized := c.assembler.CompileJump(amd64.JNE)
 
-	// If not initialized, we return the function with nativeCallStatusCodeInvalidTableAccess.
-	c.compileExitFromNativeCode(nativeCallStatusCodeInvalidTableAccess)
+	// If not initialized, we return the function with nativeCallStatusCodeUninitializedElement.
+	c.compileExitFromNativeCode(nativeCallStatusCodeUninitializedElement)
 
 	c.assembler.SetJumpTargetOnNext(jumpIfInitialized)
 
@@ -1404,7 +1430,7 @@ func (c *amd64Compiler) performDivisionOnInts(isRem, is32Bit, signed bool) error
 
 	isSignedRem := isRem && signed
 	isSignedDiv := !isRem && signed
-	var signedRemMinusOneDivisorJmp asm.Node
+	var signedRemMinusOneDivisorJmp, signedDivOverflowJmp asm.Node
 	if isSignedRem {
 		// If this is for getting remainder of signed division,
 		// we have to treat the special case where the divisor equals -1.
@@ -1473,7 +1499,13 @@ func (c *amd64Compiler) performDivisionOnInts(isRem, is32Bit, signed bool) error
 		// Otherwise, we are trying to do (math.MaxInt32 / -1) or (math.Math.Int64 / -1),
 		// and that is the overflow in division as the result becomes 2^31 which is larger than
 		// the maximum of signed 32-bit int (2^31-1).
-		c.compileExitFromNativeCode(nativeCallStatusIntegerOverflow)
+		if c.ir.WrappingDivision {
+			// The wrapped result equals the dividend, which is already in the quotient register,
+			// so we skip the div instruction.
+			signedDivOverflowJmp = c.assembler.CompileJump(amd64.JMP)
+		} else {
+			c.compileExitFromNativeCode(nativeCallStatusIntegerOverflow)
+		}
 
 		// Set the normal case's jump target.
 		c.assembler.SetJumpTargetOnNext(nonMinusOneDivisorJmp, jmpOK)
@@ -1505,6 +1537,8 @@ func (c *amd64Compiler) performDivisionOnInts(isRem, is32Bit, signed bool) error
 	// the exit jump from division -1 case towards the next instruction.
 	if signedRemMinusOneDivisorJmp != nil {
 		c.assembler.SetJumpTargetOnNext(signedRemMinusOneDivisorJmp)
+	} else if signedDivOverflowJmp != nil {
+		c.assembler.SetJumpTargetOnNext(signedDivOverflowJmp)
 	}
 
 	// We mark them as unused so that we can push one of them onto the location stack at call sites.
@@ -3173,7 +3207,7 @@ func (c *amd64Compiler) compileLoad(o *wazeroir.OperationLoad) error {
 		vt = runtimeValueTypeF64
 	}
 
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	reg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3206,7 +3240,7 @@ func (c *amd64Compiler) compileLoad(o *wazeroir.OperationLoad) error {
 // compileLoad8 implements compiler.compileLoad8 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad8(o *wazeroir.OperationLoad8) error {
 	const targetSizeInBytes = 1
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	reg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3242,7 +3276,7 @@ func (c *amd64Compiler) compileLoad8(o *wazeroir.OperationLoad8) error {
 // compileLoad16 implements compiler.compileLoad16 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad16(o *wazeroir.OperationLoad16) error {
 	const targetSizeInBytes = 16 / 8
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	reg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3278,7 +3312,7 @@ func (c *amd64Compiler) compileLoad16(o *wazeroir.OperationLoad16) error {
 // compileLoad32 implements compiler.compileLoad32 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad32(o *wazeroir.OperationLoad32) error {
 	const targetSizeInBytes = 32 / 8
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	reg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3360,22 +3394,22 @@ func (c *amd64Compiler) compileStore(o *wazeroir.OperationStore) error {
 		movInst = amd64.MOVQ
 		targetSizeInByte = 64 / 8
 	}
-	return c.compileStoreImpl(o.Arg.Offset, movInst, targetSizeInByte)
+	return c.compileStoreImpl(uint32(o.Arg.Offset), movInst, targetSizeInByte)
 }
 
 // compileStore8 implements compiler.compileStore8 for the amd64 architecture.
 func (c *amd64Compiler) compileStore8(o *wazeroir.OperationStore8) error {
-	return c.compileStoreImpl(o.Arg.Offset, amd64.MOVB, 1)
+	return c.compileStoreImpl(uint32(o.Arg.Offset), amd64.MOVB, 1)
 }
 
 // compileStore32 implements compiler.compileStore32 for the amd64 architecture.
 func (c *amd64Compiler) compileStore16(o *wazeroir.OperationStore16) error {
-	return c.compileStoreImpl(o.Arg.Offset, amd64.MOVW, 16/8)
+	return c.compileStoreImpl(uint32(o.Arg.Offset), amd64.MOVW, 16/8)
 }
 
 // compileStore32 implements compiler.compileStore32 for the amd64 architecture.
 func (c *amd64Compiler) compileStore32(o *wazeroir.OperationStore32) error {
-	return c.compileStoreImpl(o.Arg.Offset, amd64.MOVL, 32/8)
+	return c.compileStoreImpl(uint32(o.Arg.Offset), amd64.MOVL, 32/8)
 }
 
 func (c *amd64Compiler) compileStoreImpl(offsetConst uint32, inst asm.Instruction, targetSizeInBytes int64) error {
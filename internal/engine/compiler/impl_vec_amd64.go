@@ -121,21 +121,21 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.OperationV128Load) error {
 
 	switch o.Type {
 	case wazeroir.V128LoadType128:
-		err = c.compileV128LoadImpl(amd64.MOVDQU, o.Arg.Offset, 16, result)
+		err = c.compileV128LoadImpl(amd64.MOVDQU, uint32(o.Arg.Offset), 16, result)
 	case wazeroir.V128LoadType8x8s:
-		err = c.compileV128LoadImpl(amd64.PMOVSXBW, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVSXBW, uint32(o.Arg.Offset), 8, result)
 	case wazeroir.V128LoadType8x8u:
-		err = c.compileV128LoadImpl(amd64.PMOVZXBW, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVZXBW, uint32(o.Arg.Offset), 8, result)
 	case wazeroir.V128LoadType16x4s:
-		err = c.compileV128LoadImpl(amd64.PMOVSXWD, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVSXWD, uint32(o.Arg.Offset), 8, result)
 	case wazeroir.V128LoadType16x4u:
-		err = c.compileV128LoadImpl(amd64.PMOVZXWD, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVZXWD, uint32(o.Arg.Offset), 8, result)
 	case wazeroir.V128LoadType32x2s:
-		err = c.compileV128LoadImpl(amd64.PMOVSXDQ, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVSXDQ, uint32(o.Arg.Offset), 8, result)
 	case wazeroir.V128LoadType32x2u:
-		err = c.compileV128LoadImpl(amd64.PMOVZXDQ, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.PMOVZXDQ, uint32(o.Arg.Offset), 8, result)
 	case wazeroir.V128LoadType8Splat:
-		reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, 1)
+		reg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), 1)
 		if err != nil {
 			return err
 		}
@@ -153,7 +153,7 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.OperationV128Load) error {
 		c.assembler.CompileRegisterToRegister(amd64.PXOR, tmpVReg, tmpVReg)
 		c.assembler.CompileRegisterToRegister(amd64.PSHUFB, tmpVReg, result)
 	case wazeroir.V128LoadType16Splat:
-		reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, 2)
+		reg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), 2)
 		if err != nil {
 			return err
 		}
@@ -166,7 +166,7 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.OperationV128Load) error {
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PINSRW, reg, result, 1)
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PSHUFD, result, result, 0)
 	case wazeroir.V128LoadType32Splat:
-		reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, 4)
+		reg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), 4)
 		if err != nil {
 			return err
 		}
@@ -177,7 +177,7 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.OperationV128Load) error {
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PINSRD, reg, result, 0)
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PSHUFD, result, result, 0)
 	case wazeroir.V128LoadType64Splat:
-		reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, 8)
+		reg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), 8)
 		if err != nil {
 			return err
 		}
@@ -188,9 +188,9 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.OperationV128Load) error {
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PINSRQ, reg, result, 0)
 		c.assembler.CompileRegisterToRegisterWithArg(amd64.PINSRQ, reg, result, 1)
 	case wazeroir.V128LoadType32zero:
-		err = c.compileV128LoadImpl(amd64.MOVL, o.Arg.Offset, 4, result)
+		err = c.compileV128LoadImpl(amd64.MOVL, uint32(o.Arg.Offset), 4, result)
 	case wazeroir.V128LoadType64zero:
-		err = c.compileV128LoadImpl(amd64.MOVQ, o.Arg.Offset, 8, result)
+		err = c.compileV128LoadImpl(amd64.MOVQ, uint32(o.Arg.Offset), 8, result)
 	}
 
 	if err != nil {
@@ -231,7 +231,7 @@ func (c *amd64Compiler) compileV128LoadLane(o *wazeroir.OperationV128LoadLane) e
 	}
 
 	targetSizeInBytes := int64(o.LaneSize / 8)
-	offsetReg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	offsetReg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -250,7 +250,7 @@ func (c *amd64Compiler) compileV128Store(o *wazeroir.OperationV128Store) error {
 	}
 
 	const targetSizeInBytes = 16
-	offsetReg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	offsetReg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -282,7 +282,7 @@ func (c *amd64Compiler) compileV128StoreLane(o *wazeroir.OperationV128StoreLane)
 	}
 
 	targetSizeInBytes := int64(o.LaneSize / 8)
-	offsetReg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	offsetReg, err := c.compileMemoryAccessCeilSetup(uint32(o.Arg.Offset), targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -138,7 +138,7 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.OperationV128Load) (err erro
 
 	switch o.Type {
 	case wazeroir.V128LoadType128:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 16)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 16)
 		if err != nil {
 			return err
 		}
@@ -146,7 +146,7 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.OperationV128Load) (err erro
 			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementQ,
 		)
 	case wazeroir.V128LoadType8x8s:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 8)
 		if err != nil {
 			return err
 		}
@@ -156,7 +156,7 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.OperationV128Load) (err erro
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.SSHLL, result, result,
 			arm64.VectorArrangement8B, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.V128LoadType8x8u:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 8)
 		if err != nil {
 			return err
 		}
@@ -166,7 +166,7 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.OperationV128Load) (err erro
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.USHLL, result, result,
 			arm64.VectorArrangement8B, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.V128LoadType16x4s:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 8)
 		if err != nil {
 			return err
 		}
@@ -176,7 +176,7 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.OperationV128Load) (err erro
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.SSHLL, result, result,
 			arm64.VectorArrangement4H, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.V128LoadType16x4u:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 8)
 		if err != nil {
 			return err
 		}
@@ -186,7 +186,7 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.OperationV128Load) (err erro
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.USHLL, result, result,
 			arm64.VectorArrangement4H, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.V128LoadType32x2s:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 8)
 		if err != nil {
 			return err
 		}
@@ -196,7 +196,7 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.OperationV128Load) (err erro
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.SSHLL, result, result,
 			arm64.VectorArrangement2S, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.V128LoadType32x2u:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 8)
 		if err != nil {
 			return err
 		}
@@ -206,35 +206,35 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.OperationV128Load) (err erro
 		c.assembler.CompileVectorRegisterToVectorRegister(arm64.USHLL, result, result,
 			arm64.VectorArrangement2S, arm64.VectorIndexNone, arm64.VectorIndexNone)
 	case wazeroir.V128LoadType8Splat:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 1)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 1)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileRegisterToRegister(arm64.ADD, arm64ReservedRegisterForMemory, offset)
 		c.assembler.CompileMemoryToVectorRegister(arm64.LD1R, offset, 0, result, arm64.VectorArrangement16B)
 	case wazeroir.V128LoadType16Splat:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 2)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 2)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileRegisterToRegister(arm64.ADD, arm64ReservedRegisterForMemory, offset)
 		c.assembler.CompileMemoryToVectorRegister(arm64.LD1R, offset, 0, result, arm64.VectorArrangement8H)
 	case wazeroir.V128LoadType32Splat:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 4)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 4)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileRegisterToRegister(arm64.ADD, arm64ReservedRegisterForMemory, offset)
 		c.assembler.CompileMemoryToVectorRegister(arm64.LD1R, offset, 0, result, arm64.VectorArrangement4S)
 	case wazeroir.V128LoadType64Splat:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 8)
 		if err != nil {
 			return err
 		}
 		c.assembler.CompileRegisterToRegister(arm64.ADD, arm64ReservedRegisterForMemory, offset)
 		c.assembler.CompileMemoryToVectorRegister(arm64.LD1R, offset, 0, result, arm64.VectorArrangement2D)
 	case wazeroir.V128LoadType32zero:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 4)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 4)
 		if err != nil {
 			return err
 		}
@@ -242,7 +242,7 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.OperationV128Load) (err erro
 			arm64ReservedRegisterForMemory, offset, result, arm64.VectorArrangementS,
 		)
 	case wazeroir.V128LoadType64zero:
-		offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, 8)
+		offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), 8)
 		if err != nil {
 			return err
 		}
@@ -263,7 +263,7 @@ func (c *arm64Compiler) compileV128LoadLane(o *wazeroir.OperationV128LoadLane) (
 	}
 
 	targetSizeInBytes := int64(o.LaneSize / 8)
-	source, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, targetSizeInBytes)
+	source, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -301,7 +301,7 @@ func (c *arm64Compiler) compileV128Store(o *wazeroir.OperationV128Store) (err er
 	}
 
 	const targetSizeInBytes = 16
-	offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, targetSizeInBytes)
+	offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -338,7 +338,7 @@ func (c *arm64Compiler) compileV128StoreLane(o *wazeroir.OperationV128StoreLane)
 	}
 
 	targetSizeInBytes := int64(o.LaneSize / 8)
-	offset, err := c.compileMemoryAccessOffsetSetup(o.Arg.Offset, targetSizeInBytes)
+	offset, err := c.compileMemoryAccessOffsetSetup(uint32(o.Arg.Offset), targetSizeInBytes)
 	if err != nil {
 		return err
 	}
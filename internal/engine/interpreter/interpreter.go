@@ -8,6 +8,8 @@ import (
 	"math/bits"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero/api"
@@ -22,25 +24,81 @@ import (
 // callStackCeiling is the maximum WebAssembly call frame stack height. This allows wazero to raise
 // wasm.ErrCallStackOverflow instead of overflowing the Go runtime.
 //
+// Each frame is a nested Go call, so the Go stack used at this height is well below its default
+// maximum (1GB on 64-bit platforms). A host function calling back into wasm starts a new call with
+// its own frames, so recursion that way is bounded by how deep calls may nest instead. See
+// callDepthCeiling in the wasm package.
+//
 // The default value should suffice for most use cases. Those wishing to change this can via `go build -ldflags`.
 var callStackCeiling = 2000
 
+// ImplementedFeatures are the api.CoreFeatures the interpreter can execute.
+//
+// See wazero.RuntimeConfig WithStrictFeatures
+const ImplementedFeatures = api.CoreFeaturesV2 | api.CoreFeatureExtendedConst |
+	api.CoreFeatureMemory64 | api.CoreFeatureRelaxedSIMD | api.CoreFeatureExceptionHandling |
+	api.CoreFeatureThreads
+
+// InitialStackSizeKey is a context.Context key holding the count of values
+// to preallocate in the value stack of each callEngine.
+//
+// See wazero.RuntimeConfig WithInterpreterStackSize
+type InitialStackSizeKey struct{}
+
+// DeterministicFloatsKey is a context.Context key which, when set to true,
+// makes float instructions whose results may vary by platform deterministic.
+//
+// See wazero.RuntimeConfig WithDeterministicFloats
+type DeterministicFloatsKey struct{}
+
+// VerboseTracesKey is a context.Context key which, when set to true, adds
+// the current values of params and locals to each frame of the stack trace
+// of an error.
+//
+// See wazero.RuntimeConfig WithVerboseTraces
+type VerboseTracesKey struct{}
+
 // engine is an interpreter implementation of wasm.Engine
 type engine struct {
 	enabledFeatures api.CoreFeatures
 	codes           map[wasm.ModuleID][]*code // guarded by mutex.
 	mux             sync.RWMutex
+	// initialStackSize is the capacity of callEngine.stack on creation.
+	initialStackSize int
+	// deterministicFloats disables fusing in relaxed madd instructions.
+	deterministicFloats bool
+	// wrappingDivision makes signed integer division overflow wrap instead of trap.
+	wrappingDivision bool
+	// verboseTraces adds locals to stack traces.
+	verboseTraces bool
+	// stepping retains the source offsets of ops, needed by a stepper.
+	stepping bool
 }
 
-func NewEngine(_ context.Context, enabledFeatures api.CoreFeatures) wasm.Engine {
+func NewEngine(ctx context.Context, enabledFeatures api.CoreFeatures) wasm.Engine {
+	var initialStackSize int
+	if v := ctx.Value(InitialStackSizeKey{}); v != nil {
+		initialStackSize = v.(int)
+	}
+	deterministicFloats, _ := ctx.Value(DeterministicFloatsKey{}).(bool)
+	wrappingDivision, _ := ctx.Value(wazeroir.WrappingDivisionKey{}).(bool)
+	verboseTraces, _ := ctx.Value(VerboseTracesKey{}).(bool)
+	stepping, _ := ctx.Value(experimental.SteppingKey{}).(bool)
 	return &engine{
-		enabledFeatures: enabledFeatures,
-		codes:           map[wasm.ModuleID][]*code{},
+		enabledFeatures:     enabledFeatures,
+		codes:               map[wasm.ModuleID][]*code{},
+		initialStackSize:    initialStackSize,
+		deterministicFloats: deterministicFloats,
+		wrappingDivision:    wrappingDivision,
+		verboseTraces:       verboseTraces,
+		stepping:            stepping,
 	}
 }
 
 // CompiledModuleCount implements the same method as documented on wasm.Engine.
 func (e *engine) CompiledModuleCount() uint32 {
+	e.mux.RLock()
+	defer e.mux.RUnlock()
 	return uint32(len(e.codes))
 }
 
@@ -96,10 +154,33 @@ type callEngine struct {
 	compiled *function
 	// source is the FunctionInstance from which compiled is created from.
 	source *wasm.FunctionInstance
+
+	// interrupted is non-zero when Interrupt was called with a non-nil cause.
+	// This is read atomically on branches, so it is cheap to poll.
+	interrupted uint32
+	// interruptCause is the value to panic with when interrupted is set.
+	interruptCause error
+	// interruptMu guards interruptCause.
+	interruptMu sync.Mutex
+
+	// memoryAccessHook is set from experimental.MemoryAccessHookKey on each
+	// call, and is nil unless memory accesses should be reported.
+	memoryAccessHook experimental.MemoryAccessHook
+
+	// stepper is set from experimental.StepperKey on each call, and is nil
+	// unless the call should pause before each instruction.
+	stepper experimental.StepFunc
+
+	// verboseTraces adds locals to stack traces.
+	verboseTraces bool
 }
 
 func (e *moduleEngine) newCallEngine(source *wasm.FunctionInstance, compiled *function) *callEngine {
-	return &callEngine{source: source, compiled: compiled}
+	ce := &callEngine{source: source, compiled: compiled, verboseTraces: e.parentEngine.verboseTraces}
+	if size := e.parentEngine.initialStackSize; size > 0 {
+		ce.stack = make([]uint64, 0, size)
+	}
+	return ce
 }
 
 func (ce *callEngine) pushValue(v uint64) {
@@ -167,17 +248,29 @@ type callFrame struct {
 	pc uint64
 	// f is the compiled function used in this function frame.
 	f *function
+	// base is the index in callEngine.stack of the first param of f, only
+	// set when callEngine.verboseTraces or callEngine.stepper is non-nil.
+	base int
 }
 
 type code struct {
-	body   []*interpreterOp
+	body []*interpreterOp
+	// sourceOffsets holds the offset in wasm of the instruction each op of
+	// body begins, or wazeroir.NoSourceOffset if it doesn't begin one.
+	sourceOffsets []uint64
+	// wasm is the function body this was lowered from.
+	//
+	// Note: sourceOffsets and wasm are nil unless experimental.WithStepping.
+	wasm   []byte
 	hostFn interface{}
 }
 
 type function struct {
-	source *wasm.FunctionInstance
-	body   []*interpreterOp
-	hostFn interface{}
+	source        *wasm.FunctionInstance
+	body          []*interpreterOp
+	sourceOffsets []uint64
+	wasm          []byte
+	hostFn        interface{}
 }
 
 // functionFromUintptr resurrects the original *function from the given uintptr
@@ -194,9 +287,11 @@ func functionFromUintptr(ptr uintptr) *function {
 
 func (c *code) instantiate(f *wasm.FunctionInstance) *function {
 	return &function{
-		source: f,
-		body:   c.body,
-		hostFn: c.hostFn,
+		source:        f,
+		body:          c.body,
+		sourceOffsets: c.sourceOffsets,
+		wasm:          c.wasm,
+		hostFn:        c.hostFn,
 	}
 }
 
@@ -226,6 +321,11 @@ func (e *engine) CompileModule(ctx context.Context, module *wasm.Module) error {
 	}
 
 	funcs := make([]*code, 0, len(module.FunctionSection))
+	if e.stepping {
+		// Source offsets allow an experimental.StepFunc to pause before each
+		// Wasm instruction.
+		ctx = context.WithValue(ctx, wazeroir.SourceOffsetsKey{}, true)
+	}
 	irs, err := wazeroir.CompileFunctions(ctx, e.enabledFeatures, callFrameStackSize, module)
 	if err != nil {
 		return err
@@ -241,6 +341,9 @@ func (e *engine) CompileModule(ctx context.Context, module *wasm.Module) error {
 			def := module.FunctionDefinitionSection[uint32(i)+module.ImportFuncCount()]
 			return fmt.Errorf("failed to lower func[%s] to wazeroir: %w", def.DebugName(), err)
 		} else {
+			if e.stepping {
+				compiled.wasm = module.CodeSection[i].Body
+			}
 			funcs = append(funcs, compiled)
 		}
 	}
@@ -294,7 +397,15 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 	ret := &code{}
 	labelAddress := map[string]uint64{}
 	onLabelAddressResolved := map[string][]func(addr uint64){}
-	for _, original := range ops {
+	// sourceOffset is the offset of the instruction the next op begins. This
+	// carries over ops which are eliminated, such as labels.
+	sourceOffset := uint64(wazeroir.NoSourceOffset)
+	for i, original := range ops {
+		if ir.SourceOffsets != nil {
+			if offset := ir.SourceOffsets[i]; i == 0 || offset != ir.SourceOffsets[i-1] {
+				sourceOffset = offset
+			}
+		}
 		op := &interpreterOp{kind: original.Kind()}
 		switch o := original.(type) {
 		case *wazeroir.OperationUnreachable:
@@ -410,41 +521,41 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.b1 = byte(o.Type)
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationLoad8:
 			op.b1 = byte(o.Type)
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationLoad16:
 			op.b1 = byte(o.Type)
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationLoad32:
 			if o.Signed {
 				op.b1 = 1
 			}
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationStore:
 			op.b1 = byte(o.Type)
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationStore8:
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationStore16:
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationStore32:
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationMemorySize:
 		case *wazeroir.OperationMemoryGrow:
 		case *wazeroir.OperationConstI32:
@@ -487,6 +598,7 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.b1 = byte(o.Type)
 		case *wazeroir.OperationDiv:
 			op.b1 = byte(o.Type)
+			op.b3 = e.wrappingDivision
 		case *wazeroir.OperationRem:
 			op.b1 = byte(o.Type)
 		case *wazeroir.OperationAnd:
@@ -597,23 +709,23 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			op.b1 = o.Type
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationV128LoadLane:
 			op.b1 = o.LaneSize
 			op.b2 = o.LaneIndex
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationV128Store:
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationV128StoreLane:
 			op.b1 = o.LaneSize
 			op.b2 = o.LaneIndex
 			op.us = make([]uint64, 2)
 			op.us[0] = uint64(o.Arg.Alignment)
-			op.us[1] = uint64(o.Arg.Offset)
+			op.us[1] = o.Arg.Offset
 		case *wazeroir.OperationV128ExtractLane:
 			op.b1 = o.Shape
 			op.b2 = o.LaneIndex
@@ -712,10 +824,24 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 		case *wazeroir.OperationV128ITruncSatFromF:
 			op.b1 = o.OriginShape
 			op.b3 = o.Signed
+		case *wazeroir.OperationV128RelaxedMadd:
+			op.b1 = o.Shape
+			op.b3 = o.Negate
+			if !e.deterministicFloats {
+				op.b2 = 1 // fused
+			}
+		case *wazeroir.OperationV128RelaxedDot:
+		case *wazeroir.OperationV128RelaxedDotAdd:
+		case *wazeroir.OperationThrow:
+			op.us = []uint64{uint64(o.TagIndex)}
 		default:
 			panic(fmt.Errorf("BUG: unimplemented operation %s", op.kind.String()))
 		}
 		ret.body = append(ret.body, op)
+		if ir.SourceOffsets != nil {
+			ret.sourceOffsets = append(ret.sourceOffsets, sourceOffset)
+			sourceOffset = wazeroir.NoSourceOffset
+		}
 	}
 
 	if len(onLabelAddressResolved) > 0 {
@@ -775,14 +901,14 @@ func (e *moduleEngine) NewCallEngine(callCtx *wasm.CallContext, f *wasm.Function
 }
 
 // LookupFunction implements the same method as documented on wasm.ModuleEngine.
-func (e *moduleEngine) LookupFunction(t *wasm.TableInstance, typeId wasm.FunctionTypeID, tableOffset wasm.Index) (idx wasm.Index, err error) {
+func (e *moduleEngine) LookupFunction(t *wasm.TableInstance, typeId wasm.FunctionTypeID, tableOffset wasm.Index) (f *wasm.FunctionInstance, err error) {
 	if tableOffset >= uint32(len(t.References)) {
 		err = wasmruntime.ErrRuntimeInvalidTableAccess
 		return
 	}
 	rawPtr := t.References[tableOffset]
 	if rawPtr == 0 {
-		err = wasmruntime.ErrRuntimeInvalidTableAccess
+		err = wasmruntime.ErrRuntimeUninitializedElement
 		return
 	}
 
@@ -791,22 +917,57 @@ func (e *moduleEngine) LookupFunction(t *wasm.TableInstance, typeId wasm.Functio
 		err = wasmruntime.ErrRuntimeIndirectCallTypeMismatch
 		return
 	}
-	idx = tf.source.Idx
+	f = tf.source
 
 	return
 }
 
+// Interrupt implements the same method as documented on wasm.CallEngine.
+func (ce *callEngine) Interrupt(cause error) {
+	ce.interruptMu.Lock()
+	ce.interruptCause = cause
+	ce.interruptMu.Unlock()
+	if cause != nil {
+		atomic.StoreUint32(&ce.interrupted, 1)
+	} else {
+		atomic.StoreUint32(&ce.interrupted, 0)
+	}
+}
+
+// checkInterrupt panics with the cause passed to Interrupt, if any. This is
+// called on branches, which are the only way to loop without a call.
+func (ce *callEngine) checkInterrupt() {
+	if atomic.LoadUint32(&ce.interrupted) == 0 {
+		return
+	}
+	ce.interruptMu.Lock()
+	cause := ce.interruptCause
+	ce.interruptMu.Unlock()
+	if cause != nil {
+		panic(cause)
+	}
+}
+
 // Call implements the same method as documented on wasm.CallEngine.
 func (ce *callEngine) Call(ctx context.Context, m *wasm.CallContext, params []uint64) (results []uint64, err error) {
-	return ce.call(ctx, m, ce.compiled, params)
+	return ce.call(ctx, m, ce.compiled, params, nil)
 }
 
-func (ce *callEngine) call(ctx context.Context, m *wasm.CallContext, tf *function, params []uint64) (results []uint64, err error) {
+// CallInPlace implements the same method as documented on wasm.CallEngine.
+func (ce *callEngine) CallInPlace(ctx context.Context, m *wasm.CallContext, params []uint64) (results []uint64, err error) {
+	return ce.call(ctx, m, ce.compiled, params, params)
+}
+
+// call invokes tf with params, writing results into the backing array of buf
+// if it has enough capacity.
+func (ce *callEngine) call(ctx context.Context, m *wasm.CallContext, tf *function, params, buf []uint64) (results []uint64, err error) {
 	ft := tf.source.Type
 	paramSignature := ft.ParamNumInUint64
 	paramCount := len(params)
 	if paramSignature != paramCount {
-		return nil, fmt.Errorf("expected %d params, but passed %d", paramSignature, paramCount)
+		def := tf.source.Definition
+		return nil, fmt.Errorf("%s: expected %d params, but passed %d",
+			wasmdebug.Signature(def.DebugName(), def.ParamTypes(), def.ResultTypes()), paramSignature, paramCount)
 	}
 
 	defer func() {
@@ -817,7 +978,7 @@ func (ce *callEngine) call(ctx context.Context, m *wasm.CallContext, tf *functio
 		// TODO: ^^ Will not fail if the function was imported from a closed module.
 
 		if v := recover(); v != nil {
-			err = ce.recoverOnCall(v)
+			err = ce.recoverOnCall(m, v)
 		}
 	}()
 
@@ -825,25 +986,124 @@ func (ce *callEngine) call(ctx context.Context, m *wasm.CallContext, tf *functio
 		ce.pushValue(param)
 	}
 
+	ce.memoryAccessHook, _ = ctx.Value(experimental.MemoryAccessHookKey{}).(experimental.MemoryAccessHook)
+	ce.stepper, _ = ctx.Value(experimental.StepperKey{}).(experimental.StepFunc)
+
 	ce.callFunction(ctx, m, tf)
 
 	// This returns a safe copy of the results, instead of a slice view. If we
 	// returned a re-slice, the caller could accidentally or purposefully
 	// corrupt the stack of subsequent calls.
-	results = wasm.PopValues(ft.ResultNumInUint64, ce.popValue)
+	if resultCount := ft.ResultNumInUint64; cap(buf) >= resultCount {
+		results = buf[:resultCount]
+		for i := resultCount - 1; i >= 0; i-- {
+			results[i] = ce.popValue()
+		}
+	} else {
+		results = wasm.PopValues(resultCount, ce.popValue)
+	}
 	return
 }
 
+// Resume implements wasm.Resumer.
+func (ce *callEngine) Resume(ctx context.Context, m *wasm.CallContext, functions []*wasm.FunctionInstance, cont *experimental.Continuation) (results []uint64, err error) {
+	frames := make([]*callFrame, len(functions))
+	for i, fi := range functions {
+		me, ok := fi.Module.Engine.(*moduleEngine)
+		if !ok || me.functions[fi.Idx] == nil || me.functions[fi.Idx].hostFn != nil {
+			return nil, fmt.Errorf("%s can't be resumed", fi.Definition.DebugName())
+		}
+		frame := cont.Frames[i]
+		f := me.functions[fi.Idx]
+		if frame.PC >= uint64(len(f.body)) {
+			return nil, fmt.Errorf("%s has no pc %d", fi.Definition.DebugName(), frame.PC)
+		}
+		frames[i] = &callFrame{pc: frame.PC, f: f, base: frame.StackBase}
+	}
+
+	defer func() {
+		if err == nil {
+			err = m.FailIfClosed()
+		}
+		if v := recover(); v != nil {
+			err = ce.recoverOnCall(m, v)
+		}
+	}()
+
+	ce.stack = append(ce.stack[:0], cont.Stack...)
+	ce.frames = append(ce.frames[:0], frames...)
+
+	ce.memoryAccessHook, _ = ctx.Value(experimental.MemoryAccessHookKey{}).(experimental.MemoryAccessHook)
+	ce.stepper, _ = ctx.Value(experimental.StepperKey{}).(experimental.StepFunc)
+
+	// Each frame is suspended at the call to the next, whose results are on
+	// the stack. So, run the innermost frame past its call, then each caller.
+	for len(ce.frames) > 0 {
+		frame := ce.popFrame()
+		frame.pc++
+		ce.runFrame(ctx, m, frame)
+	}
+
+	results = wasm.PopValues(functions[0].Type.ResultNumInUint64, ce.popValue)
+	return
+}
+
+// suspend saves the state of a call suspended by experimental.Suspend into
+// cont, returning false if it wasn't suspended by a host function called
+// from Wasm.
+func (ce *callEngine) suspend(m *wasm.CallContext, cont *experimental.Continuation) bool {
+	frameCount := len(ce.frames)
+	if frameCount < 2 {
+		return false
+	}
+	host := ce.frames[frameCount-1].f
+	if host.hostFn == nil {
+		return false
+	}
+	frames := make([]experimental.ContinuationFrame, frameCount-1)
+	for i, frame := range ce.frames[:frameCount-1] {
+		if frame.f.hostFn != nil {
+			return false
+		}
+		frames[i] = experimental.ContinuationFrame{
+			ModuleName:    frame.f.source.Module.Name,
+			FunctionIndex: frame.f.source.Idx,
+			PC:            frame.pc,
+			StackBase:     frame.base,
+		}
+	}
+
+	// The host function's results are at the start of its stack, which has
+	// room for the larger of its params and results. See callGoFuncWithStack
+	stackLen := len(ce.stack)
+	if shrinkLen := host.source.Type.ParamNumInUint64 - host.source.Type.ResultNumInUint64; shrinkLen > 0 {
+		stackLen -= shrinkLen
+	}
+
+	cont.Module = m
+	cont.Frames = frames
+	cont.Stack = append([]uint64{}, ce.stack[:stackLen]...)
+	return true
+}
+
 // recoverOnCall takes the recovered value `recoverOnCall`, and wraps it
 // with the call frame stack traces. Also, reset the state of callEngine
 // so that it can be used for the subsequent calls.
-func (ce *callEngine) recoverOnCall(v interface{}) (err error) {
+func (ce *callEngine) recoverOnCall(m *wasm.CallContext, v interface{}) (err error) {
+	if cont, ok := v.(*experimental.Continuation); ok && ce.suspend(m, cont) {
+		ce.stack, ce.frames = ce.stack[:0], ce.frames[:0]
+		return cont
+	}
+
 	builder := wasmdebug.NewErrorBuilder()
 	frameCount := len(ce.frames)
 	for i := 0; i < frameCount; i++ {
 		frame := ce.popFrame()
 		def := frame.f.source.Definition
 		builder.AddFrame(def.DebugName(), def.ParamTypes(), def.ResultTypes())
+		if ce.verboseTraces && frame.f.hostFn == nil {
+			ce.addLocals(builder, frame)
+		}
 	}
 	err = builder.FromRecovered(v)
 
@@ -852,6 +1112,37 @@ func (ce *callEngine) recoverOnCall(v interface{}) (err error) {
 	return
 }
 
+// addLocals adds the current values of the params and locals of the frame to
+// the builder, named by the custom name section if present.
+func (ce *callEngine) addLocals(builder wasmdebug.ErrorBuilder, frame *callFrame) {
+	source := frame.f.source
+	types := make([]wasm.ValueType, 0, len(source.Type.Params)+len(source.LocalTypes))
+	types = append(append(types, source.Type.Params...), source.LocalTypes...)
+
+	names := make([]string, len(types))
+	if def, ok := source.Definition.(*wasm.FunctionDefinition); ok {
+		for _, n := range def.LocalNames() {
+			if int(n.Index) < len(names) {
+				names[n.Index] = n.Name
+			}
+		}
+	}
+
+	// Each local is on the stack after the params, unless the function
+	// trapped before pushing it.
+	end := frame.base + source.Type.ParamNumInUint64
+	for _, vt := range source.LocalTypes {
+		end++
+		if vt == wasm.ValueTypeV128 {
+			end++
+		}
+	}
+	if end > len(ce.stack) {
+		end = len(ce.stack)
+	}
+	builder.AddLocals(names, types, ce.stack[frame.base:end])
+}
+
 func (ce *callEngine) callFunction(ctx context.Context, callCtx *wasm.CallContext, f *function) {
 	if f.hostFn != nil {
 		ce.callGoFuncWithStack(ctx, callCtx, f)
@@ -863,9 +1154,11 @@ func (ce *callEngine) callFunction(ctx context.Context, callCtx *wasm.CallContex
 }
 
 func (ce *callEngine) callGoFunc(ctx context.Context, callCtx *wasm.CallContext, f *function, stack []uint64) {
+	var start time.Time
 	if f.source.Listener != nil {
 		params := stack[:f.source.Type.ParamNumInUint64]
 		ctx = f.source.Listener.Before(ctx, f.source.Definition, params)
+		start = listenerStart(f.source.Listener)
 	}
 	frame := &callFrame{f: f}
 	ce.pushFrame(frame)
@@ -873,7 +1166,7 @@ func (ce *callEngine) callGoFunc(ctx context.Context, callCtx *wasm.CallContext,
 	fn := f.source.GoFunc
 	switch fn := fn.(type) {
 	case api.GoModuleFunction:
-		fn.Call(ctx, callCtx.WithMemory(ce.callerMemory()), stack)
+		fn.Call(ctx, callCtx.WithMemory(ce.callerMemory()).WithHostModule(f.source.Module), stack)
 	case api.GoFunction:
 		fn.Call(ctx, stack)
 	}
@@ -882,12 +1175,22 @@ func (ce *callEngine) callGoFunc(ctx context.Context, callCtx *wasm.CallContext,
 	if f.source.Listener != nil {
 		// TODO: This doesn't get the error due to use of panic to propagate them.
 		results := stack[:f.source.Type.ResultNumInUint64]
-		f.source.Listener.After(ctx, f.source.Definition, nil, results)
+		listenerAfter(ctx, f.source.Listener, f.source.Definition, results, start)
 	}
 }
 
 func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallContext, f *function) {
 	frame := &callFrame{f: f}
+	if ce.verboseTraces || ce.stepper != nil {
+		frame.base = len(ce.stack) - f.source.Type.ParamNumInUint64
+	}
+	ce.runFrame(ctx, callCtx, frame)
+}
+
+// runFrame pushes the frame, and runs its function from frame.pc until it
+// returns.
+func (ce *callEngine) runFrame(ctx context.Context, callCtx *wasm.CallContext, frame *callFrame) {
+	f := frame.f
 	moduleInst := f.source.Module
 	functions := moduleInst.Engine.(*moduleEngine).functions
 	var memoryInst *wasm.MemoryInstance
@@ -901,19 +1204,34 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 	typeIDs := f.source.Module.TypeIDs
 	dataInstances := f.source.Module.DataInstances
 	elementInstances := f.source.Module.ElementInstances
+	budget := instructionBudget{shared: moduleInst.InstructionBudget}
+	if budget.shared != nil {
+		budget.sync()
+		defer budget.publish() // also on a trap
+	}
 	ce.pushFrame(frame)
 	bodyLen := uint64(len(frame.f.body))
 	for frame.pc < bodyLen {
 		op := frame.f.body[frame.pc]
+		if budget.shared != nil {
+			budget.charge()
+		}
+		if ce.stepper != nil {
+			ce.step(ctx, frame)
+		}
 		// TODO: add description of each operation/case
 		// on, for example, how many args are used,
 		// how the stack is modified, etc.
 		switch op.kind {
 		case wazeroir.OperationKindUnreachable:
 			panic(wasmruntime.ErrRuntimeUnreachable)
+		case wazeroir.OperationKindThrow:
+			panic(wasmruntime.ErrRuntimeUncaughtException)
 		case wazeroir.OperationKindBr:
+			ce.checkInterrupt()
 			frame.pc = op.us[0]
 		case wazeroir.OperationKindBrIf:
+			ce.checkInterrupt()
 			if ce.popValue() > 0 {
 				ce.drop(op.rs[0])
 				frame.pc = op.us[0]
@@ -922,6 +1240,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 				frame.pc = op.us[1]
 			}
 		case wazeroir.OperationKindBrTable:
+			ce.checkInterrupt()
 			if v := uint64(ce.popValue()); v < uint64(len(op.us)-1) {
 				ce.drop(op.rs[v+1])
 				frame.pc = op.us[v+1]
@@ -931,7 +1250,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 				frame.pc = op.us[0]
 			}
 		case wazeroir.OperationKindCall:
-			ce.callFunction(ctx, callCtx, functions[op.us[0]])
+			budget.callFunction(ctx, ce, callCtx, functions[op.us[0]])
 			frame.pc++
 		case wazeroir.OperationKindCallIndirect:
 			offset := ce.popValue()
@@ -941,7 +1260,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 			rawPtr := table.References[offset]
 			if rawPtr == 0 {
-				panic(wasmruntime.ErrRuntimeInvalidTableAccess)
+				panic(wasmruntime.ErrRuntimeUninitializedElement)
 			}
 
 			tf := functionFromUintptr(rawPtr)
@@ -949,7 +1268,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 				panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
 			}
 
-			ce.callFunction(ctx, callCtx, tf)
+			budget.callFunction(ctx, ce, callCtx, tf)
 			frame.pc++
 		case wazeroir.OperationKindDrop:
 			ce.drop(op.rs[0])
@@ -1007,12 +1326,14 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			offset := ce.popMemoryOffset(op)
 			switch wazeroir.UnsignedType(op.b1) {
 			case wazeroir.UnsignedTypeI32, wazeroir.UnsignedTypeF32:
+				ce.onMemoryAccess(false, offset, 4)
 				if val, ok := memoryInst.ReadUint32Le(ctx, offset); !ok {
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 				} else {
 					ce.pushValue(uint64(val))
 				}
 			case wazeroir.UnsignedTypeI64, wazeroir.UnsignedTypeF64:
+				ce.onMemoryAccess(false, offset, 8)
 				if val, ok := memoryInst.ReadUint64Le(ctx, offset); !ok {
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 				} else {
@@ -1021,7 +1342,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 			frame.pc++
 		case wazeroir.OperationKindLoad8:
-			val, ok := memoryInst.ReadByte(ctx, ce.popMemoryOffset(op))
+			offset := ce.popMemoryOffset(op)
+			ce.onMemoryAccess(false, offset, 1)
+			val, ok := memoryInst.ReadByte(ctx, offset)
 			if !ok {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
@@ -1036,8 +1359,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 			frame.pc++
 		case wazeroir.OperationKindLoad16:
-
-			val, ok := memoryInst.ReadUint16Le(ctx, ce.popMemoryOffset(op))
+			offset := ce.popMemoryOffset(op)
+			ce.onMemoryAccess(false, offset, 2)
+			val, ok := memoryInst.ReadUint16Le(ctx, offset)
 			if !ok {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
@@ -1052,7 +1376,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 			frame.pc++
 		case wazeroir.OperationKindLoad32:
-			val, ok := memoryInst.ReadUint32Le(ctx, ce.popMemoryOffset(op))
+			offset := ce.popMemoryOffset(op)
+			ce.onMemoryAccess(false, offset, 4)
+			val, ok := memoryInst.ReadUint32Le(ctx, offset)
 			if !ok {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
@@ -1068,10 +1394,12 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			offset := ce.popMemoryOffset(op)
 			switch wazeroir.UnsignedType(op.b1) {
 			case wazeroir.UnsignedTypeI32, wazeroir.UnsignedTypeF32:
+				ce.onMemoryAccess(true, offset, 4)
 				if !memoryInst.WriteUint32Le(ctx, offset, uint32(val)) {
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 				}
 			case wazeroir.UnsignedTypeI64, wazeroir.UnsignedTypeF64:
+				ce.onMemoryAccess(true, offset, 8)
 				if !memoryInst.WriteUint64Le(ctx, offset, val) {
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 				}
@@ -1080,6 +1408,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 		case wazeroir.OperationKindStore8:
 			val := byte(ce.popValue())
 			offset := ce.popMemoryOffset(op)
+			ce.onMemoryAccess(true, offset, 1)
 			if !memoryInst.WriteByte(ctx, offset, val) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
@@ -1087,6 +1416,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 		case wazeroir.OperationKindStore16:
 			val := uint16(ce.popValue())
 			offset := ce.popMemoryOffset(op)
+			ce.onMemoryAccess(true, offset, 2)
 			if !memoryInst.WriteUint16Le(ctx, offset, val) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
@@ -1094,6 +1424,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 		case wazeroir.OperationKindStore32:
 			val := uint32(ce.popValue())
 			offset := ce.popMemoryOffset(op)
+			ce.onMemoryAccess(true, offset, 4)
 			if !memoryInst.WriteUint32Le(ctx, offset, val) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
@@ -1103,8 +1434,14 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			frame.pc++
 		case wazeroir.OperationKindMemoryGrow:
 			n := ce.popValue()
-			if res, ok := memoryInst.Grow(ctx, uint32(n)); !ok {
-				ce.pushValue(uint64(0xffffffff)) // = -1 in signed 32-bit integer.
+			if n > math.MaxUint32 { // Only possible in a 64-bit memory, which is limited to 4GiB.
+				ce.pushValue(math.MaxUint64) // = -1 in signed 64-bit integer.
+			} else if res, ok := memoryInst.Grow(ctx, uint32(n)); !ok {
+				if memoryInst.Is64 {
+					ce.pushValue(math.MaxUint64) // = -1 in signed 64-bit integer.
+				} else {
+					ce.pushValue(uint64(0xffffffff)) // = -1 in signed 32-bit integer.
+				}
 			} else {
 				ce.pushValue(uint64(res))
 			}
@@ -1341,14 +1678,14 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			case wazeroir.SignedTypeInt32:
 				d := int32(v2)
 				n := int32(v1)
-				if n == math.MinInt32 && d == -1 {
+				if n == math.MinInt32 && d == -1 && !op.b3 {
 					panic(wasmruntime.ErrRuntimeIntegerOverflow)
 				}
 				ce.pushValue(uint64(uint32(n / d)))
 			case wazeroir.SignedTypeInt64:
 				d := int64(v2)
 				n := int64(v1)
-				if n == math.MinInt64 && d == -1 {
+				if n == math.MinInt64 && d == -1 && !op.b3 {
 					panic(wasmruntime.ErrRuntimeIntegerOverflow)
 				}
 				ce.pushValue(uint64(n / d))
@@ -1871,8 +2208,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			copySize := ce.popValue()
 			inDataOffset := ce.popValue()
 			inMemoryOffset := ce.popValue()
+			ce.onMemoryAccess(true, uint32(inMemoryOffset), uint32(copySize))
 			if inDataOffset+copySize > uint64(len(dataInstance)) ||
-				inMemoryOffset+copySize > uint64(len(memoryInst.Buffer)) {
+				!memoryInst.HasSize64(inMemoryOffset, copySize) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			} else if copySize != 0 {
 				copy(memoryInst.Buffer[inMemoryOffset:inMemoryOffset+copySize], dataInstance[inDataOffset:])
@@ -1882,11 +2220,12 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			dataInstances[op.us[0]] = nil
 			frame.pc++
 		case wazeroir.OperationKindMemoryCopy:
-			memLen := uint64(len(memoryInst.Buffer))
 			copySize := ce.popValue()
 			sourceOffset := ce.popValue()
 			destinationOffset := ce.popValue()
-			if sourceOffset+copySize > memLen || destinationOffset+copySize > memLen {
+			ce.onMemoryAccess(false, uint32(sourceOffset), uint32(copySize))
+			ce.onMemoryAccess(true, uint32(destinationOffset), uint32(copySize))
+			if !memoryInst.HasSize64(sourceOffset, copySize) || !memoryInst.HasSize64(destinationOffset, copySize) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			} else if copySize != 0 {
 				copy(memoryInst.Buffer[destinationOffset:],
@@ -1897,7 +2236,8 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			fillSize := ce.popValue()
 			value := byte(ce.popValue())
 			offset := ce.popValue()
-			if fillSize+offset > uint64(len(memoryInst.Buffer)) {
+			ce.onMemoryAccess(true, uint32(offset), uint32(fillSize))
+			if !memoryInst.HasSize64(offset, fillSize) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			} else if fillSize != 0 {
 				// Uses the copy trick for faster filling buffer.
@@ -2082,6 +2422,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			frame.pc++
 		case wazeroir.OperationKindV128Load:
 			offset := ce.popMemoryOffset(op)
+			ce.onMemoryAccess(false, offset, v128LoadSize(op.b1))
 			switch op.b1 {
 			case wazeroir.V128LoadType128:
 				lo, ok := memoryInst.ReadUint64Le(ctx, offset)
@@ -2205,6 +2546,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 		case wazeroir.OperationKindV128LoadLane:
 			hi, lo := ce.popValue(), ce.popValue()
 			offset := ce.popMemoryOffset(op)
+			ce.onMemoryAccess(false, offset, uint32(op.b1/8))
 			switch op.b1 {
 			case 8:
 				b, ok := memoryInst.ReadByte(ctx, offset)
@@ -2259,6 +2601,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 		case wazeroir.OperationKindV128Store:
 			hi, lo := ce.popValue(), ce.popValue()
 			offset := ce.popMemoryOffset(op)
+			ce.onMemoryAccess(true, offset, 16)
 			if ok := memoryInst.WriteUint64Le(ctx, offset, lo); !ok {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
@@ -2269,6 +2612,7 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 		case wazeroir.OperationKindV128StoreLane:
 			hi, lo := ce.popValue(), ce.popValue()
 			offset := ce.popMemoryOffset(op)
+			ce.onMemoryAccess(true, offset, uint32(op.b1/8))
 			var ok bool
 			switch op.b1 {
 			case 8:
@@ -4080,6 +4424,38 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 					(uint64(uint32(int32(int16(x1Hi>>32))*int32(int16(x2Hi>>32))+int32(int16(x1Hi>>48))*int32(int16(x2Hi>>48)))) << 32),
 			)
 			frame.pc++
+		case wazeroir.OperationKindV128RelaxedMadd:
+			cHi, cLo := ce.popValue(), ce.popValue()
+			bHi, bLo := ce.popValue(), ce.popValue()
+			aHi, aLo := ce.popValue(), ce.popValue()
+			negate, fused := op.b3, op.b2 == 1
+			var retLo, retHi uint64
+			switch op.b1 {
+			case wazeroir.ShapeF32x4:
+				retLo = uint64(relaxedMaddF32(uint32(aLo), uint32(bLo), uint32(cLo), negate, fused)) |
+					uint64(relaxedMaddF32(uint32(aLo>>32), uint32(bLo>>32), uint32(cLo>>32), negate, fused))<<32
+				retHi = uint64(relaxedMaddF32(uint32(aHi), uint32(bHi), uint32(cHi), negate, fused)) |
+					uint64(relaxedMaddF32(uint32(aHi>>32), uint32(bHi>>32), uint32(cHi>>32), negate, fused))<<32
+			case wazeroir.ShapeF64x2:
+				retLo = relaxedMaddF64(aLo, bLo, cLo, negate, fused)
+				retHi = relaxedMaddF64(aHi, bHi, cHi, negate, fused)
+			}
+			ce.pushValue(retLo)
+			ce.pushValue(retHi)
+			frame.pc++
+		case wazeroir.OperationKindV128RelaxedDot:
+			x2Hi, x2Lo := ce.popValue(), ce.popValue()
+			x1Hi, x1Lo := ce.popValue(), ce.popValue()
+			ce.pushValue(relaxedDotI8x16(x1Lo, x2Lo))
+			ce.pushValue(relaxedDotI8x16(x1Hi, x2Hi))
+			frame.pc++
+		case wazeroir.OperationKindV128RelaxedDotAdd:
+			cHi, cLo := ce.popValue(), ce.popValue()
+			x2Hi, x2Lo := ce.popValue(), ce.popValue()
+			x1Hi, x1Lo := ce.popValue(), ce.popValue()
+			ce.pushValue(relaxedDotAddI8x16(x1Lo, x2Lo, cLo))
+			ce.pushValue(relaxedDotAddI8x16(x1Hi, x2Hi, cHi))
+			frame.pc++
 		case wazeroir.OperationKindV128ITruncSatFromF:
 			hi, lo := ce.popValue(), ce.popValue()
 			signed := op.b3
@@ -4364,19 +4740,245 @@ func i32Abs(v uint32) uint32 {
 	}
 }
 
+// relaxedMaddF32 returns the bits of a*b+c, or -(a*b)+c when negate is true.
+// When fused, the product isn't rounded before adding c, otherwise it is.
+func relaxedMaddF32(a, b, c uint32, negate, fused bool) uint32 {
+	x, y, z := math.Float32frombits(a), math.Float32frombits(b), math.Float32frombits(c)
+	if negate {
+		x = -x
+	}
+	if fused {
+		return math.Float32bits(fmaF32(x, y, z))
+	}
+	// The conversion prevents the Go compiler from fusing the operations.
+	return math.Float32bits(float32(x*y) + z)
+}
+
+// fmaF32 returns x*y+z rounded once to float32, like math.FMA for float64.
+//
+// The product is exact as a float64, but the sum isn't, and rounding it to
+// float64 then float32 can differ from rounding it once, when the float64 is
+// halfway between two float32. To avoid that, the sum is rounded to odd: if
+// inexact, its last bit is set. The float32 conversion is then correct, as
+// float64 has more than two extra bits of precision.
+//
+// See Boldo and Melquiond, "Emulation of a FMA and correctly-rounded sums:
+// proved algorithms using rounding to odd"
+func fmaF32(x, y, z float32) float32 {
+	p, c := float64(x)*float64(y), float64(z)
+	sum := p + c
+	if math.IsInf(sum, 0) || math.IsNaN(sum) {
+		return float32(sum)
+	}
+
+	// TwoSum: err is the exact difference between p+c and sum.
+	pp := sum - c
+	err := (p - pp) + (c - (sum - pp))
+
+	if bits := math.Float64bits(sum); err != 0 && bits&1 == 0 {
+		// Move sum by one ulp toward p+c, which makes its last bit one.
+		if (err > 0) == (sum > 0) {
+			bits++
+		} else {
+			bits--
+		}
+		sum = math.Float64frombits(bits)
+	}
+	return float32(sum)
+}
+
+// relaxedMaddF64 is like relaxedMaddF32, except for float64 bits.
+func relaxedMaddF64(a, b, c uint64, negate, fused bool) uint64 {
+	x, y, z := math.Float64frombits(a), math.Float64frombits(b), math.Float64frombits(c)
+	if negate {
+		x = -x
+	}
+	if fused {
+		return math.Float64bits(math.FMA(x, y, z))
+	}
+	// The conversion prevents the Go compiler from fusing the operations.
+	return math.Float64bits(float64(x*y) + z)
+}
+
+// relaxedDotI8x16 returns the four 16-bit lanes of the dot product of the
+// signed 8-bit lanes of x1 and x2, which are the lower or higher 64 bits of
+// i16x8.relaxed_dot_i8x16_i7x16_s operands. Lanes of x2 are treated as
+// signed, and overflow wraps.
+func relaxedDotI8x16(x1, x2 uint64) (ret uint64) {
+	for i := 0; i < 4; i++ {
+		shift := i * 16
+		v := int16(int8(x1>>shift))*int16(int8(x2>>shift)) +
+			int16(int8(x1>>(shift+8)))*int16(int8(x2>>(shift+8)))
+		ret |= uint64(uint16(v)) << shift
+	}
+	return
+}
+
+// relaxedDotAddI8x16 returns the two 32-bit lanes of the sum of adjacent
+// lanes of relaxedDotI8x16, plus the corresponding 32-bit lane of c.
+func relaxedDotAddI8x16(x1, x2, c uint64) (ret uint64) {
+	dot := relaxedDotI8x16(x1, x2)
+	for i := 0; i < 2; i++ {
+		shift := i * 32
+		v := int32(int16(dot>>shift)) + int32(int16(dot>>(shift+16))) + int32(c>>shift)
+		ret |= uint64(uint32(v)) << shift
+	}
+	return
+}
+
 func (ce *callEngine) callNativeFuncWithListener(ctx context.Context, callCtx *wasm.CallContext, f *function, fnl experimental.FunctionListener) context.Context {
-	ctx = fnl.Before(ctx, f.source.Definition, ce.peekValues(len(f.source.Type.Params)))
+	ctx = fnl.Before(ctx, f.source.Definition, ce.peekValues(f.source.Type.ParamNumInUint64))
+	start := listenerStart(fnl)
 	ce.callNativeFunc(ctx, callCtx, f)
 	// TODO: This doesn't get the error due to use of panic to propagate them.
-	fnl.After(ctx, f.source.Definition, nil, ce.peekValues(len(f.source.Type.Results)))
+	listenerAfter(ctx, fnl, f.source.Definition, ce.peekValues(f.source.Type.ResultNumInUint64), start)
 	return ctx
 }
 
+// listenerStart returns the current time if the listener needs the duration
+// of the function call, or the zero time otherwise.
+func listenerStart(fnl experimental.FunctionListener) (start time.Time) {
+	if _, ok := fnl.(experimental.TimedFunctionListener); ok {
+		start = time.Now()
+	}
+	return
+}
+
+// listenerAfter notifies the listener that the function returned, including
+// the time since start if it is an experimental.TimedFunctionListener.
+func listenerAfter(ctx context.Context, fnl experimental.FunctionListener, def api.FunctionDefinition, results []uint64, start time.Time) {
+	if tfnl, ok := fnl.(experimental.TimedFunctionListener); ok {
+		tfnl.AfterWithDuration(ctx, def, nil, results, time.Since(start))
+	} else {
+		fnl.After(ctx, def, nil, results)
+	}
+}
+
+// instructionBudget charges the instructions a frame executes to the budget
+// of its module, which is shared by all calls. To avoid an atomic update per
+// instruction, they are counted locally, and only published when the frame
+// calls a function or returns.
+type instructionBudget struct {
+	// shared is wasm.ModuleInstance InstructionBudget, or nil if unlimited.
+	shared *uint64
+	// left is the value of shared when last synced, and used is the count
+	// of instructions executed since.
+	left, used uint64
+}
+
+// sync reads the shared budget, e.g. after a function call charged it.
+func (b *instructionBudget) sync() {
+	b.left, b.used = atomic.LoadUint64(b.shared), 0
+}
+
+// charge counts an instruction, panicking if the budget is exhausted.
+func (b *instructionBudget) charge() {
+	if b.used == b.left {
+		panic(wasmruntime.ErrRuntimeInstructionBudgetExhausted)
+	}
+	b.used++
+}
+
+// publish subtracts the instructions used since the last sync from the shared
+// budget, saturating at zero as concurrent calls may have charged it.
+func (b *instructionBudget) publish() {
+	if b.used == 0 {
+		return
+	}
+	for {
+		remaining := atomic.LoadUint64(b.shared)
+		next := uint64(0)
+		if remaining > b.used {
+			next = remaining - b.used
+		}
+		if atomic.CompareAndSwapUint64(b.shared, remaining, next) {
+			b.left, b.used = next, 0
+			return
+		}
+	}
+}
+
+// callFunction calls f, publishing instructions used before, so that f sees
+// them, and syncing after, so that this frame sees those f used.
+func (b *instructionBudget) callFunction(ctx context.Context, ce *callEngine, callCtx *wasm.CallContext, f *function) {
+	if b.shared == nil {
+		ce.callFunction(ctx, callCtx, f)
+		return
+	}
+	b.publish()
+	ce.callFunction(ctx, callCtx, f)
+	b.sync()
+}
+
+// onMemoryAccess reports a guest memory access to the hook set by
+// experimental.WithMemoryAccessHook, if any.
+func (ce *callEngine) onMemoryAccess(isWrite bool, offset, length uint32) {
+	if ce.memoryAccessHook != nil {
+		ce.memoryAccessHook(isWrite, offset, length)
+	}
+}
+
+// step calls ce.stepper if the next op of the frame begins a Wasm instruction.
+func (ce *callEngine) step(ctx context.Context, frame *callFrame) {
+	f := frame.f
+	if f.sourceOffsets == nil {
+		return
+	}
+	pc := f.sourceOffsets[frame.pc]
+	if pc == wazeroir.NoSourceOffset {
+		return
+	}
+
+	localsEnd := frame.base + f.source.Type.ParamNumInUint64
+	for _, vt := range f.source.LocalTypes {
+		localsEnd++
+		if vt == wasm.ValueTypeV128 {
+			localsEnd++
+		}
+	}
+	step := &experimental.Step{
+		Function: f.source.Definition,
+		PC:       pc,
+		Opcode:   f.wasm[pc],
+		Locals:   ce.stack[frame.base:localsEnd:localsEnd],
+		Stack:    ce.stack[localsEnd:],
+	}
+	switch ce.stepper(ctx, step) {
+	case experimental.StepContinue:
+		ce.stepper = nil
+	case experimental.StepAbort:
+		panic(experimental.ErrStepAborted)
+	}
+}
+
+// v128LoadSize returns the count of bytes read by the wazeroir.V128LoadType.
+func v128LoadSize(loadType wazeroir.V128LoadType) uint32 {
+	switch loadType {
+	case wazeroir.V128LoadType128:
+		return 16
+	case wazeroir.V128LoadType8Splat:
+		return 1
+	case wazeroir.V128LoadType16Splat:
+		return 2
+	case wazeroir.V128LoadType32Splat, wazeroir.V128LoadType32zero:
+		return 4
+	default: // 8x8s, 8x8u, 16x4s, 16x4u, 32x2s, 32x2u, 64Splat and 64zero
+		return 8
+	}
+}
+
 // popMemoryOffset takes a memory offset off the stack for use in load and store instructions.
 // As the top of stack value is 64-bit, this ensures it is in range before returning it.
 func (ce *callEngine) popMemoryOffset(op *interpreterOp) uint32 {
+	base := ce.popValue()
+	// base and the offset in op.us[1] can only exceed 32-bits in a 64-bit
+	// memory, which is limited to 4GiB. This check prevents the addition
+	// below from overflowing.
+	if base > math.MaxUint32 || op.us[1] > math.MaxUint32 {
+		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	}
 	// TODO: Document what 'us' is and why we expect to look at value 1.
-	offset := op.us[1] + ce.popValue()
+	offset := op.us[1] + base
 	if offset > math.MaxUint32 {
 		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 	}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/big"
+	"math/rand"
 	"strconv"
 	"testing"
 	"unsafe"
@@ -64,6 +66,30 @@ func TestInterpreter_CallEngine_PushFrame_StackOverflow(t *testing.T) {
 	require.EqualError(t, captured, "stack overflow")
 }
 
+func TestInterpreter_NewEngine_InitialStackSize(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		e := NewEngine(testCtx, api.CoreFeaturesV2).(*engine)
+		me := &moduleEngine{parentEngine: e}
+		ce := me.newCallEngine(nil, nil)
+		require.Nil(t, ce.stack)
+	})
+
+	t.Run("preallocated", func(t *testing.T) {
+		ctx := context.WithValue(testCtx, InitialStackSizeKey{}, 128)
+		e := NewEngine(ctx, api.CoreFeaturesV2).(*engine)
+		me := &moduleEngine{parentEngine: e}
+		ce := me.newCallEngine(nil, nil)
+		require.Equal(t, 0, len(ce.stack))
+		require.Equal(t, 128, cap(ce.stack))
+
+		// Growth beyond the initial size is transparent.
+		for i := 0; i < 200; i++ {
+			ce.pushValue(uint64(i))
+		}
+		require.Equal(t, uint64(199), ce.popValue())
+	})
+}
+
 // et is used for tests defined in the enginetest package.
 var (
 	et              = &engineTester{}
@@ -85,8 +111,8 @@ func (e engineTester) ListenerFactory() experimental.FunctionListenerFactory {
 }
 
 // NewEngine implements enginetest.EngineTester NewEngine.
-func (e engineTester) NewEngine(enabledFeatures api.CoreFeatures) wasm.Engine {
-	return NewEngine(context.Background(), enabledFeatures)
+func (e engineTester) NewEngine(ctx context.Context, enabledFeatures api.CoreFeatures) wasm.Engine {
+	return NewEngine(ctx, enabledFeatures)
 }
 
 // InitTables implements enginetest.EngineTester InitTables.
@@ -128,11 +154,20 @@ func TestInterpreter_ModuleEngine_LookupFunction(t *testing.T) {
 	enginetest.RunTestModuleEngine_LookupFunction(t, et)
 }
 
+func TestInterpreter_ModuleEngine_TableOps(t *testing.T) {
+	defer functionLog.Reset()
+	enginetest.RunTestModuleEngine_TableOps(t, et)
+}
+
 func TestInterpreter_ModuleEngine_Call(t *testing.T) {
 	defer functionLog.Reset()
 	enginetest.RunTestModuleEngine_Call(t, et)
 	require.Equal(t, `
 --> .$0(1,2)
+<-- (2,1)
+--> .$0(1,2)
+<-- (2,1)
+--> .$0(2,1)
 <-- (1,2)
 `, "\n"+functionLog.String())
 }
@@ -196,10 +231,106 @@ func TestInterpreter_ModuleEngine_Call_Errors(t *testing.T) {
 `, "\n"+functionLog.String())
 }
 
+func TestInterpreter_ModuleEngine_RefTypes(t *testing.T) {
+	defer functionLog.Reset()
+	enginetest.RunTestModuleEngine_RefTypes(t, et)
+}
+
+func TestInterpreter_ModuleEngine_WrappingDivision(t *testing.T) {
+	defer functionLog.Reset()
+	enginetest.RunTestModuleEngine_WrappingDivision(t, et)
+}
+
+func TestInterpreter_ModuleEngine_NonTrappingFloatToInt(t *testing.T) {
+	defer functionLog.Reset()
+	enginetest.RunTestModuleEngine_NonTrappingFloatToInt(t, et)
+}
+
+func TestInterpreter_Engine_FloatConsistency(t *testing.T) {
+	defer functionLog.Reset()
+	enginetest.RunTestEngine_FloatConsistency(t, et)
+}
+
+func TestInterpreter_ModuleEngine_BulkMemory(t *testing.T) {
+	enginetest.RunTestModuleEngine_BulkMemory(t, et)
+}
+
 func TestInterpreter_ModuleEngine_Memory(t *testing.T) {
 	enginetest.RunTestModuleEngine_Memory(t, et)
 }
 
+func TestInterpreter_ModuleEngine_ExceptionHandling(t *testing.T) {
+	defer functionLog.Reset()
+	enginetest.RunTestModuleEngine_ExceptionHandling(t, et)
+}
+
+func TestInterpreter_ModuleEngine_RelaxedSIMD(t *testing.T) {
+	defer functionLog.Reset()
+	enginetest.RunTestModuleEngine_RelaxedSIMD(t, et)
+}
+
+func Test_relaxedMaddF32(t *testing.T) {
+	a := math.Float32frombits(0x3f800800) // 1 + 2^-12, so a*a is 1 + 2^-11 + 2^-24
+	tests := []struct {
+		name          string
+		z             float32
+		negate, fused bool
+		expected      uint32
+	}{
+		{
+			name:     "product rounded",
+			z:        -(1 + 0x1p-11),
+			expected: 0,
+		},
+		{
+			name:     "product not rounded",
+			z:        -(1 + 0x1p-11),
+			fused:    true,
+			expected: 0x33800000, // 2^-24
+		},
+		{
+			// The sum as a float64 is 1 + 2^-11 + 2^-24, which is halfway
+			// between two float32, so rounding it again rounds to even.
+			name:     "sum rounded once",
+			z:        0x1p-80,
+			fused:    true,
+			expected: 0x3f801001, // 1 + 2^-11 + 2^-23
+		},
+		{
+			name:     "negated sum rounded once",
+			z:        -0x1p-80,
+			negate:   true,
+			fused:    true,
+			expected: 0xbf801001,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			actual := relaxedMaddF32(math.Float32bits(a), math.Float32bits(a), math.Float32bits(tc.z), tc.negate, tc.fused)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("same as rounding the exact result", func(t *testing.T) {
+		r := rand.New(rand.NewSource(0))
+		for i := 0; i < 100000; i++ {
+			x := math.Float32frombits(r.Uint32()&0x807fffff | 0x3f000000)                // [0.5, 1) and negative
+			y := math.Float32frombits(r.Uint32()&0x807fffff | 0x3f000000)                // [0.5, 1) and negative
+			z := math.Float32frombits(r.Uint32()&0x807fffff | uint32(r.Intn(60)+96)<<23) // 2^-31 to 2^28
+
+			exact := new(big.Float).SetPrec(200).SetFloat64(float64(x))
+			exact.Mul(exact, new(big.Float).SetFloat64(float64(y)))
+			exact.Add(exact, new(big.Float).SetFloat64(float64(z)))
+			expected, _ := exact.Float32()
+
+			actual := math.Float32frombits(relaxedMaddF32(math.Float32bits(x), math.Float32bits(y), math.Float32bits(z), false, true))
+			require.Equal(t, expected, actual, "%v*%v+%v", x, y, z)
+		}
+	})
+}
+
 func TestInterpreter_NonTrappingFloatToIntConversion(t *testing.T) {
 	_0x80000000 := uint32(0x80000000)
 	_0xffffffff := uint32(0xffffffff)
@@ -517,7 +648,7 @@ func TestInterpreter_CallEngine_callNativeFunc_signExtend(t *testing.T) {
 
 func TestInterpreter_Compile(t *testing.T) {
 	t.Run("uncompiled", func(t *testing.T) {
-		e := et.NewEngine(api.CoreFeaturesV1).(*engine)
+		e := et.NewEngine(testCtx, api.CoreFeaturesV1).(*engine)
 		_, err := e.NewModuleEngine("foo",
 			&wasm.Module{},
 			nil, // imports
@@ -528,7 +659,7 @@ func TestInterpreter_Compile(t *testing.T) {
 		require.EqualError(t, err, "source module for foo must be compiled before instantiation")
 	})
 	t.Run("fail", func(t *testing.T) {
-		e := et.NewEngine(api.CoreFeaturesV1).(*engine)
+		e := et.NewEngine(testCtx, api.CoreFeaturesV1).(*engine)
 
 		errModule := &wasm.Module{
 			TypeSection:     []*wasm.FunctionType{{}},
@@ -550,7 +681,7 @@ func TestInterpreter_Compile(t *testing.T) {
 		require.False(t, ok)
 	})
 	t.Run("ok", func(t *testing.T) {
-		e := et.NewEngine(api.CoreFeaturesV1).(*engine)
+		e := et.NewEngine(testCtx, api.CoreFeaturesV1).(*engine)
 
 		okModule := &wasm.Module{
 			TypeSection:     []*wasm.FunctionType{{}},
@@ -576,7 +707,7 @@ func TestInterpreter_Compile(t *testing.T) {
 }
 
 func TestEngine_CachedcodesPerModule(t *testing.T) {
-	e := et.NewEngine(api.CoreFeaturesV1).(*engine)
+	e := et.NewEngine(testCtx, api.CoreFeaturesV1).(*engine)
 	exp := []*code{
 		{body: []*interpreterOp{}},
 		{body: []*interpreterOp{}},
@@ -64,6 +64,28 @@ func BenchmarkInitialization(b *testing.B) {
 	}
 }
 
+// BenchmarkInterpreterStackSize shows the allocation reduction of
+// wazero.RuntimeConfig WithInterpreterStackSize on a recursion-heavy call.
+func BenchmarkInterpreterStackSize(b *testing.B) {
+	for _, size := range []int{0, 1024} {
+		b.Run(fmt.Sprintf("initial_%d", size), func(b *testing.B) {
+			config := wazero.NewRuntimeConfigInterpreter().WithInterpreterStackSize(size)
+			m := instantiateHostFunctionModuleWithEngine(b, config)
+			defer m.Close(testCtx)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Look up the function each time, as the value stack is
+				// allocated per api.Function.
+				if _, err := m.ExportedFunction("fibonacci").Call(testCtx, 20); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkCompilation(b *testing.B) {
 	if !platform.CompilerSupported() {
 		b.Skip()
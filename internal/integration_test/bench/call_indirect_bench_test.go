@@ -0,0 +1,85 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// callIndirectWasm exports "dispatch", which loops the given count of times,
+// calling one of four functions in the table per iteration, like a virtual
+// method call in an object-oriented language.
+var callIndirectWasm = func() []byte {
+	i32 := wasm.ValueTypeI32
+	i32_i32 := &wasm.FunctionType{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}
+	indices := []wasm.Index{0, 1, 2, 3}
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{i32_i32},
+		FunctionSection: []wasm.Index{0, 0, 0, 0, 0},
+		TableSection:    []*wasm.Table{{Min: 4, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&indices[0], &indices[1], &indices[2], &indices[3]},
+			Type:       wasm.RefTypeFuncref,
+			Mode:       wasm.ElementModeActive,
+		}},
+		ExportSection: []*wasm.Export{{Name: "dispatch", Type: wasm.ExternTypeFunc, Index: 4}},
+	}
+	// Each function in the table adds a different constant to its param.
+	for i := byte(1); i <= 4; i++ {
+		m.CodeSection = append(m.CodeSection, &wasm.Code{Body: []byte{
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, i, wasm.OpcodeI32Add, wasm.OpcodeEnd,
+		}})
+	}
+	m.CodeSection = append(m.CodeSection, &wasm.Code{
+		LocalTypes: []wasm.ValueType{i32, i32}, // i, acc
+		Body: []byte{
+			wasm.OpcodeBlock, 0x40,
+			wasm.OpcodeLoop, 0x40,
+			// if i >= count { break }
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 0, wasm.OpcodeI32GeU, wasm.OpcodeBrIf, 1,
+			// acc = table[i&3](acc)
+			wasm.OpcodeLocalGet, 2,
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Const, 3, wasm.OpcodeI32And,
+			wasm.OpcodeCallIndirect, 0, 0, // type 0, table 0
+			wasm.OpcodeLocalSet, 2,
+			// i++
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeLocalSet, 1,
+			wasm.OpcodeBr, 0,
+			wasm.OpcodeEnd,
+			wasm.OpcodeEnd,
+			wasm.OpcodeLocalGet, 2,
+			wasm.OpcodeEnd,
+		},
+	})
+	return binary.EncodeModule(m)
+}()
+
+// BenchmarkCallIndirect_Polymorphic measures call_indirect in a loop which
+// dispatches to a different function each iteration.
+func BenchmarkCallIndirect_Polymorphic(b *testing.B) {
+	const count = 1000
+
+	r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfigInterpreter())
+	defer r.Close(testCtx)
+
+	mod, err := r.InstantiateModuleFromBinary(testCtx, callIndirectWasm)
+	if err != nil {
+		b.Fatal(err)
+	}
+	dispatch := mod.ExportedFunction("dispatch")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		results, err := dispatch.Call(testCtx, count)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if results[0] != count/4*(1+2+3+4) {
+			b.Fatalf("unexpected result: %d", results[0])
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package bench
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+)
+
+// BenchmarkCompileModule_DecodeBufferPool shows the effect of
+// wazero.RuntimeConfig WithDecodeBufferPool on compiling the same module
+// repeatedly.
+func BenchmarkCompileModule_DecodeBufferPool(b *testing.B) {
+	for _, pool := range []bool{false, true} {
+		config := wazero.NewRuntimeConfigInterpreter()
+		if pool {
+			config = config.WithDecodeBufferPool()
+		}
+		b.Run(fmt.Sprintf("pool=%v", pool), func(b *testing.B) {
+			r := wazero.NewRuntimeWithConfig(testCtx, config)
+			defer r.Close(testCtx)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				compiled, err := r.CompileModule(testCtx, caseWasm)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err = compiled.Close(testCtx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
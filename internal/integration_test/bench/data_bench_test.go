@@ -0,0 +1,56 @@
+package bench
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// BenchmarkInstantiation_LargeData shows the effect of wazero.RuntimeConfig
+// WithCopyOnWriteMemory on instantiating a module with a 10MB data segment.
+func BenchmarkInstantiation_LargeData(b *testing.B) {
+	data := make([]byte, 10*1024*1024)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	pages := uint32(len(data))/wasm.MemoryPageSize + 1
+	bin := binary.EncodeModule(&wasm.Module{
+		MemorySection: &wasm.Memory{Min: pages, Cap: pages, Max: pages, IsMaxEncoded: true},
+		DataSection: []*wasm.DataSegment{{
+			OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: leb128.EncodeInt32(0)},
+			Init:             data,
+		}},
+	})
+
+	for _, cow := range []bool{false, true} {
+		config := wazero.NewRuntimeConfigInterpreter()
+		if cow {
+			config = config.WithCopyOnWriteMemory()
+		}
+		b.Run(fmt.Sprintf("copyOnWrite=%v", cow), func(b *testing.B) {
+			r := wazero.NewRuntimeWithConfig(testCtx, config)
+			defer r.Close(testCtx)
+
+			compiled, err := r.CompileModule(testCtx, bin)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName(""))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err = mod.Close(testCtx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
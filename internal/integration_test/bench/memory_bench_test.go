@@ -1,9 +1,13 @@
 package bench
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
 )
 
 func BenchmarkMemory(b *testing.B) {
@@ -44,3 +48,70 @@ func BenchmarkMemory(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkMemory_Prefault shows the effect of wazero.ModuleConfig
+// WithPrefaultMemory on the first call to a function that writes to each OS
+// page of a 16MiB memory.
+func BenchmarkMemory_Prefault(b *testing.B) {
+	pages := uint32(256)
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		MemorySection:   &wasm.Memory{Min: pages, Cap: pages, Max: pages, IsMaxEncoded: true},
+		ExportSection:   []*wasm.Export{{Name: "touch", Type: wasm.ExternTypeFunc, Index: 0}},
+		CodeSection: []*wasm.Code{{
+			LocalTypes: []wasm.ValueType{wasm.ValueTypeI32}, // offset
+			Body: append(append(append([]byte{
+				wasm.OpcodeLoop, 0x40,
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Store, 0x2, 0x0, // alignment=2 (natural alignment) staticOffset=0
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI32Const}, leb128.EncodeInt32(4096)...),
+				wasm.OpcodeI32Add,
+				wasm.OpcodeLocalTee, 0,
+				wasm.OpcodeI32Const),
+				append(leb128.EncodeInt32(int32(pages*wasm.MemoryPageSize)),
+					wasm.OpcodeI32LtU,
+					wasm.OpcodeBrIf, 0,
+					wasm.OpcodeEnd,
+					wasm.OpcodeEnd)...),
+		}},
+	})
+
+	for _, prefault := range []bool{false, true} {
+		config := wazero.NewModuleConfig().WithName("")
+		if prefault {
+			config = config.WithPrefaultMemory()
+		}
+		b.Run(fmt.Sprintf("prefault=%v", prefault), func(b *testing.B) {
+			r := wazero.NewRuntime(testCtx)
+			defer r.Close(testCtx)
+
+			compiled, err := r.CompileModule(testCtx, bin)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				mod, err := r.InstantiateModule(testCtx, compiled, config)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+
+				if _, err = mod.ExportedFunction("touch").Call(testCtx); err != nil {
+					b.Fatal(err)
+				}
+
+				b.StopTimer()
+				if err = mod.Close(testCtx); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+		})
+	}
+}
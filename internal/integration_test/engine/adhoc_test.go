@@ -3,17 +3,24 @@ package adhoc
 import (
 	"context"
 	_ "embed"
+	"errors"
+	"fmt"
 	"math"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/platform"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasm/binary"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -29,19 +36,33 @@ var memoryCapacityPages = uint32(2)
 var moduleConfig = wazero.NewModuleConfig()
 
 var tests = map[string]func(t *testing.T, r wazero.Runtime){
-	"huge stack":                                        testHugeStack,
-	"unreachable":                                       testUnreachable,
-	"recursive entry":                                   testRecursiveEntry,
-	"host func memory":                                  testHostFuncMemory,
-	"host function with context parameter":              testHostFunctionContextParameter,
-	"host function with nested context":                 testNestedGoContext,
-	"host function with numeric parameter":              testHostFunctionNumericParameter,
-	"close module with in-flight calls":                 testCloseInFlight,
-	"multiple instantiation from same source":           testMultipleInstantiation,
-	"exported function that grows memory":               testMemOps,
-	"import functions with reference type in signature": testReftypeImports,
-	"overflow integer addition":                         testOverflow,
-	"un-signed extend global":                           testGlobalExtend,
+	"huge stack":      testHugeStack,
+	"unreachable":     testUnreachable,
+	"recursive entry": testRecursiveEntry,
+	"recursive entry beyond the call depth ceiling": testRecursiveEntryDepth,
+	"host func memory":                                      testHostFuncMemory,
+	"host function with context parameter":                  testHostFunctionContextParameter,
+	"host function with nested context":                     testNestedGoContext,
+	"host function with numeric parameter":                  testHostFunctionNumericParameter,
+	"close module with in-flight calls":                     testCloseInFlight,
+	"multiple instantiation from same source":               testMultipleInstantiation,
+	"exported function that grows memory":                   testMemOps,
+	"import functions with reference type in signature":     testReftypeImports,
+	"overflow integer addition":                             testOverflow,
+	"un-signed extend global":                               testGlobalExtend,
+	"call_indirect to uninitialized table element":          testCallIndirectNullElement,
+	"call_indirect through an imported table":               testCallIndirectImportedTable,
+	"call_indirect after the table entry changes":           testCallIndirectTableSet,
+	"exported table entries":                                testExportedTableEntries,
+	"host function that grows memory":                       testHostFuncMemoryGrow,
+	"start function honors the context deadline":            testStartDeadline,
+	"host state of the module which defines a function":     testHostState,
+	"start function imported from a host module":            testStartImported,
+	"imported mutable global set by the host between calls": testImportedMutableGlobal,
+	"function timeout":                                      testFunctionTimeout,
+	"concurrent compile and instantiate":                    testConcurrentCompileAndInstantiate,
+	"call in place":                                         testCallInPlace,
+	"reset":                                                 testReset,
 }
 
 func TestEngineCompiler(t *testing.T) {
@@ -164,6 +185,627 @@ func testGlobalExtend(t *testing.T, r wazero.Runtime) {
 	require.Equal(t, uint64(0xffff_ffff), res[0])
 }
 
+// testCallIndirectNullElement ensures call_indirect traps on a table element
+// which was never initialized, distinctly from one which is out of range.
+func testCallIndirectNullElement(t *testing.T, r wazero.Runtime) {
+	zero := wasm.Index(0)
+	module, err := r.InstantiateModuleFromBinary(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}, {Params: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeEnd}},
+			{Body: []byte{
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeCallIndirect, 0, 0, // type 0, table 0
+				wasm.OpcodeEnd,
+			}},
+		},
+		// Only the first of two table elements is initialized.
+		TableSection: []*wasm.Table{{Min: 2, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&zero},
+			Type:       wasm.RefTypeFuncref,
+			Mode:       wasm.ElementModeActive,
+		}},
+		ExportSection: []*wasm.Export{{Name: "call", Type: wasm.ExternTypeFunc, Index: 1}},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	call := module.ExportedFunction("call")
+
+	_, err = call.Call(testCtx, 0)
+	require.NoError(t, err)
+
+	_, err = call.Call(testCtx, 1) // null
+	require.True(t, errors.Is(err, wasmruntime.ErrRuntimeUninitializedElement), err.Error())
+
+	_, err = call.Call(testCtx, 2) // out of range
+	require.True(t, errors.Is(err, wasmruntime.ErrRuntimeInvalidTableAccess), err.Error())
+}
+
+// testCallIndirectImportedTable ensures a table exported by one module is the
+// same instance when imported by another, as used by dynamic linking.
+func testCallIndirectImportedTable(t *testing.T, r wazero.Runtime) {
+	zero := wasm.Index(0)
+	v_i32 := &wasm.FunctionType{Results: []wasm.ValueType{i32}}
+	i32_i32 := &wasm.FunctionType{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}
+	callIndirect := &wasm.Code{Body: []byte{
+		wasm.OpcodeLocalGet, 0,
+		wasm.OpcodeCallIndirect, 0, 0, // type 0, table 0
+		wasm.OpcodeEnd,
+	}}
+
+	// env defines the table, and places a function returning 42 at offset 0.
+	env, err := r.InstantiateModuleFromBinary(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{v_i32, i32_i32},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeI32Const, 42, wasm.OpcodeEnd}},
+			callIndirect,
+		},
+		TableSection: []*wasm.Table{{Min: 2, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&zero},
+			Type:       wasm.RefTypeFuncref,
+			Mode:       wasm.ElementModeActive,
+		}},
+		ExportSection: []*wasm.Export{
+			{Name: "__indirect_function_table", Type: wasm.ExternTypeTable, Index: 0},
+			{Name: "call", Type: wasm.ExternTypeFunc, Index: 1},
+		},
+		NameSection: &wasm.NameSection{ModuleName: "env"},
+	}))
+	require.NoError(t, err)
+	defer env.Close(testCtx)
+
+	// side imports the table, and places a function returning 7 at offset 1.
+	side, err := r.InstantiateModuleFromBinary(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{v_i32, i32_i32},
+		ImportSection: []*wasm.Import{{
+			Module: "env", Name: "__indirect_function_table",
+			Type: wasm.ExternTypeTable, DescTable: &wasm.Table{Min: 2, Type: wasm.RefTypeFuncref},
+		}},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeI32Const, 7, wasm.OpcodeEnd}},
+			callIndirect,
+		},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{1}},
+			Init:       []*wasm.Index{&zero},
+			Type:       wasm.RefTypeFuncref,
+			Mode:       wasm.ElementModeActive,
+		}},
+		ExportSection: []*wasm.Export{{Name: "call", Type: wasm.ExternTypeFunc, Index: 1}},
+		NameSection:   &wasm.NameSection{ModuleName: "side"},
+	}))
+	require.NoError(t, err)
+	defer side.Close(testCtx)
+
+	// Each module can call the function the other placed in the shared table.
+	for _, mod := range []api.Module{env, side} {
+		for offset, expected := range []uint64{42, 7} {
+			results, err := mod.ExportedFunction("call").Call(testCtx, uint64(offset))
+			require.NoError(t, err)
+			require.Equal(t, []uint64{expected}, results)
+		}
+	}
+
+	// The importing module must not require more elements than the table has.
+	_, err = r.InstantiateModuleFromBinary(testCtx, binary.EncodeModule(&wasm.Module{
+		ImportSection: []*wasm.Import{{
+			Module: "env", Name: "__indirect_function_table",
+			Type: wasm.ExternTypeTable, DescTable: &wasm.Table{Min: 3, Type: wasm.RefTypeFuncref},
+		}},
+		NameSection: &wasm.NameSection{ModuleName: "too_big"},
+	}))
+	require.EqualError(t, err, "import[0] table[env.__indirect_function_table]: minimum size mismatch: 3 > 2")
+}
+
+// testCallIndirectTableSet ensures call_indirect calls the current table
+// entry, and checks its type, when the table changes between or during calls.
+func testCallIndirectTableSet(t *testing.T, r wazero.Runtime) {
+	indices := []wasm.Index{0, 1, 2}
+	v_i32 := &wasm.FunctionType{Results: []wasm.ValueType{i32}}
+	i32_v := &wasm.FunctionType{Params: []wasm.ValueType{i32}}
+	i32_i32 := &wasm.FunctionType{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}
+	module, err := r.InstantiateModuleFromBinary(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{v_i32, i32_v, i32_i32},
+		FunctionSection: []wasm.Index{0, 0, 2, 0, 1, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeI32Const, 2, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}}, // different type
+			// call returns the result of table[0].
+			{Body: []byte{
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeCallIndirect, 0, 0, // type 0, table 0
+				wasm.OpcodeEnd,
+			}},
+			// set sets table[0] to table[index], where table[1:] are functions 0-2.
+			{Body: []byte{
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeTableGet, 0,
+				wasm.OpcodeTableSet, 0,
+				wasm.OpcodeEnd,
+			}},
+			// call_set_call calls table[0], sets it to function 1 and calls
+			// it again, returning the first result * 10 + the second.
+			{Body: []byte{
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeCallIndirect, 0, 0, // type 0, table 0
+				wasm.OpcodeI32Const, 10, wasm.OpcodeI32Mul,
+				wasm.OpcodeI32Const, 0, wasm.OpcodeRefFunc, 1, wasm.OpcodeTableSet, 0,
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeCallIndirect, 0, 0, // type 0, table 0
+				wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+			}},
+		},
+		TableSection: []*wasm.Table{{Min: 4, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&indices[0], &indices[0], &indices[1], &indices[2]},
+			Type:       wasm.RefTypeFuncref,
+			Mode:       wasm.ElementModeActive,
+		}},
+		ExportSection: []*wasm.Export{
+			{Name: "call", Type: wasm.ExternTypeFunc, Index: 3},
+			{Name: "set", Type: wasm.ExternTypeFunc, Index: 4},
+			{Name: "call_set_call", Type: wasm.ExternTypeFunc, Index: 5},
+		},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	call, set := module.ExportedFunction("call"), module.ExportedFunction("set")
+	requireCall := func(expected uint64) {
+		results, err := call.Call(testCtx)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{expected}, results)
+	}
+
+	requireCall(1)
+
+	_, err = set.Call(testCtx, 2)
+	require.NoError(t, err)
+	requireCall(2)
+
+	_, err = set.Call(testCtx, 3)
+	require.NoError(t, err)
+	_, err = call.Call(testCtx)
+	require.True(t, errors.Is(err, wasmruntime.ErrRuntimeIndirectCallTypeMismatch), err.Error())
+
+	_, err = set.Call(testCtx, 1)
+	require.NoError(t, err)
+	requireCall(1)
+
+	// The table changes between call_indirect instructions in the same call.
+	results, err := module.ExportedFunction("call_set_call").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{12}, results)
+	requireCall(2)
+}
+
+// testExportedTableEntries uses the same tables as the multi-table test in
+// enginetest: function 1 at table[0][0] and function 2 at table[1][5].
+func testExportedTableEntries(t *testing.T, r wazero.Runtime) {
+	func1, func2 := wasm.Index(1), wasm.Index(2)
+	v_v := &wasm.FunctionType{}
+	i32_v := &wasm.FunctionType{Params: []wasm.ValueType{i32}}
+	module, err := r.InstantiateModuleFromBinary(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{v_v, i32_v},
+		FunctionSection: []wasm.Index{0, 0, 0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+			// clear sets table[1][index] to null.
+			{Body: []byte{
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeRefNull, wasm.RefTypeFuncref,
+				wasm.OpcodeTableSet, 1,
+				wasm.OpcodeEnd,
+			}},
+		},
+		TableSection: []*wasm.Table{
+			{Min: 2, Type: wasm.RefTypeFuncref},
+			{Min: 10, Type: wasm.RefTypeFuncref},
+		},
+		ElementSection: []*wasm.ElementSegment{
+			{
+				OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+				TableIndex: 0,
+				Init:       []*wasm.Index{&func1},
+				Type:       wasm.RefTypeFuncref,
+				Mode:       wasm.ElementModeActive,
+			},
+			{
+				OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{5}},
+				TableIndex: 1,
+				Init:       []*wasm.Index{&func2, &func1},
+				Type:       wasm.RefTypeFuncref,
+				Mode:       wasm.ElementModeActive,
+			},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "t0", Type: wasm.ExternTypeTable, Index: 0},
+			{Name: "t1", Type: wasm.ExternTypeTable, Index: 1},
+			{Name: "clear", Type: wasm.ExternTypeFunc, Index: 3},
+		},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	require.Nil(t, module.ExportedTable("clear"))
+	t0, t1 := module.ExportedTable("t0"), module.ExportedTable("t1")
+	require.Equal(t, uint32(2), t0.Size(testCtx))
+	require.Equal(t, uint32(10), t1.Size(testCtx))
+
+	entries0, ok := t0.Entries(testCtx)
+	require.True(t, ok)
+	entries1, ok := t1.Entries(testCtx)
+	require.True(t, ok)
+
+	ref1, ref2 := entries0[0], entries1[5]
+	require.NotEqual(t, api.Reference(0), ref1)
+	require.NotEqual(t, api.Reference(0), ref2)
+	require.NotEqual(t, ref1, ref2)
+	require.Equal(t, []api.Reference{ref1, 0}, entries0)
+	require.Equal(t, []api.Reference{0, 0, 0, 0, 0, ref2, ref1, 0, 0, 0}, entries1)
+
+	// Entries are a snapshot, so don't see later changes.
+	_, err = module.ExportedFunction("clear").Call(testCtx, 5)
+	require.NoError(t, err)
+	require.Equal(t, ref2, entries1[5])
+	entries1, _ = t1.Entries(testCtx)
+	require.Equal(t, []api.Reference{0, 0, 0, 0, 0, 0, ref1, 0, 0, 0}, entries1)
+}
+
+// testHostFuncMemoryGrow ensures the guest sees memory grown by a host
+// function it called, e.g. the compiler refreshes its view of memory.
+func testHostFuncMemoryGrow(t *testing.T, r wazero.Runtime) {
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module) {
+			mem := m.Memory()
+			previousPages, ok := mem.Grow(ctx, 1)
+			require.True(t, ok)
+			require.Equal(t, uint32(1), previousPages)
+			require.True(t, mem.WriteUint32Le(ctx, wasm.MemoryPageSize, 42))
+		}).
+		Export("grow").
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	v_v := &wasm.FunctionType{}
+	v_i32 := &wasm.FunctionType{Results: []wasm.ValueType{i32}}
+	module, err := r.InstantiateModuleFromBinary(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:   []*wasm.FunctionType{v_v, v_i32},
+		ImportSection: []*wasm.Import{{Module: "env", Name: "grow", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		// Capacity is the min, so growing reallocates the buffer.
+		MemorySection:   &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true},
+		FunctionSection: []wasm.Index{1},
+		CodeSection: []*wasm.Code{
+			// run calls grow, then reads the first value of the new page.
+			{Body: []byte{
+				wasm.OpcodeCall, 0,
+				wasm.OpcodeI32Const, 0x80, 0x80, 0x04, // 65536
+				wasm.OpcodeI32Load, 0x2, 0x0, // alignment=2 (natural alignment) staticOffset=0
+				wasm.OpcodeEnd,
+			}},
+		},
+		ExportSection: []*wasm.Export{{Name: "run", Type: wasm.ExternTypeFunc, Index: 1}},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	results, err := module.ExportedFunction("run").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+	require.Equal(t, 2*wasm.MemoryPageSize, module.Memory().Size(testCtx))
+}
+
+func testStartDeadline(t *testing.T, r wazero.Runtime) {
+	zero := uint32(0)
+	code, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // (loop (br 0)), which never ends.
+				wasm.OpcodeLoop, 0x40,
+				wasm.OpcodeBr, 0,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}},
+		},
+		MemorySection: &wasm.Memory{Min: 1},
+		StartSection:  &zero,
+	}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(testCtx, 50*time.Millisecond)
+	defer cancel()
+
+	// Instantiate the module, which calls the start function. This hangs unless the deadline is honored.
+	_, err = r.InstantiateModule(ctx, code, wazero.NewModuleConfig().WithName("loop"))
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err.Error())
+
+	// The partially instantiated module should be removed, freeing its name.
+	require.Nil(t, r.Module("loop"))
+	empty, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{}))
+	require.NoError(t, err)
+	_, err = r.InstantiateModule(testCtx, empty, wazero.NewModuleConfig().WithName("loop"))
+	require.NoError(t, err)
+}
+
+// testHostState ensures host functions see the state of the module
+// that defined them, even when called from a guest.
+func testHostState(t *testing.T, r wazero.Runtime) {
+	type counter struct{ count uint32 }
+
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}, ResultNumInUint64: 1}},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "inc", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}}, // Call the imported env.inc.
+		},
+		MemorySection: &wasm.Memory{Min: 1},
+		ExportSection: []*wasm.Export{{Type: api.ExternTypeFunc, Name: "inc", Index: 1}},
+	})
+
+	state := &counter{}
+	env, err := r.NewHostModuleBuilder("env").
+		WithState(state).
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module) uint32 {
+		// The memory is the guest's, but the state is env's.
+		require.Equal(t, uint32(wasm.MemoryPageSize), m.Memory().Size(ctx))
+		c := m.HostState().(*counter)
+		c.count++
+		return c.count
+	}).Export("inc").
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+	require.Equal(t, state, env.HostState())
+
+	mod, err := r.InstantiateModuleFromBinary(testCtx, bin)
+	require.NoError(t, err)
+	require.Nil(t, mod.HostState())
+
+	for i := uint64(1); i <= 2; i++ {
+		results, err := mod.ExportedFunction("inc").Call(testCtx)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{i}, results)
+	}
+	require.Equal(t, uint32(2), state.count)
+}
+
+func testStartImported(t *testing.T, r wazero.Runtime) {
+	zero := uint32(0)
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:   []*wasm.FunctionType{{}},
+		ImportSection: []*wasm.Import{{Type: wasm.ExternTypeFunc, Module: "env", Name: "start", DescFunc: 0}},
+		MemorySection: &wasm.Memory{Min: 1},
+		ExportSection: []*wasm.Export{{Type: wasm.ExternTypeMemory, Name: "memory"}},
+		StartSection:  &zero,
+	})
+
+	var started []string
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module) {
+		// The start function sees the memory of the importing module.
+		started = append(started, m.Name())
+		require.NotNil(t, m.ExportedMemory("memory"))
+	}).Export("start").
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	code, err := r.CompileModule(testCtx, bin)
+	require.NoError(t, err)
+
+	_, err = r.InstantiateModule(testCtx, code, wazero.NewModuleConfig().WithName("main"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"main"}, started)
+}
+
+func testImportedMutableGlobal(t *testing.T, r wazero.Runtime) {
+	i32 := wasm.ValueTypeI32
+	providerBin := binary.EncodeModule(&wasm.Module{
+		GlobalSection: []*wasm.Global{{
+			Type: &wasm.GlobalType{ValType: i32, Mutable: true},
+			Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{1}},
+		}},
+		ExportSection: []*wasm.Export{{Type: wasm.ExternTypeGlobal, Name: "counter"}},
+	})
+	// get returns the imported global from a loop, so the compiler can't
+	// treat the read as loop invariant.
+	guestBin := binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{{Results: []wasm.ValueType{i32}}},
+		ImportSection: []*wasm.Import{{
+			Type: wasm.ExternTypeGlobal, Module: "provider", Name: "counter",
+			DescGlobal: &wasm.GlobalType{ValType: i32, Mutable: true},
+		}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{
+			LocalTypes: []wasm.ValueType{i32, i32},
+			Body: []byte{
+				wasm.OpcodeLoop, 0x40,
+				wasm.OpcodeGlobalGet, 0, wasm.OpcodeLocalSet, 0,
+				wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeLocalTee, 1,
+				wasm.OpcodeI32Const, 10, wasm.OpcodeI32LtU,
+				wasm.OpcodeBrIf, 0,
+				wasm.OpcodeEnd,
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeEnd,
+			},
+		}},
+		ExportSection: []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "get", Index: 0}},
+	})
+
+	code, err := r.CompileModule(testCtx, providerBin)
+	require.NoError(t, err)
+	provider, err := r.InstantiateModule(testCtx, code, wazero.NewModuleConfig().WithName("provider"))
+	require.NoError(t, err)
+	guest, err := r.InstantiateModuleFromBinary(testCtx, guestBin)
+	require.NoError(t, err)
+	get := guest.ExportedFunction("get")
+
+	results, err := get.Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1}, results)
+
+	// The guest sees the value the host set between calls.
+	provider.ExportedGlobal("counter").(api.MutableGlobal).Set(testCtx, 2)
+	results, err = get.Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, results)
+}
+
+func testFunctionTimeout(t *testing.T, r wazero.Runtime) {
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // (loop (br 0)), which never ends.
+				wasm.OpcodeLoop, 0x40,
+				wasm.OpcodeBr, 0,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{wasm.OpcodeNop, wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{
+			{Type: api.ExternTypeFunc, Name: "loop", Index: 0},
+			{Type: api.ExternTypeFunc, Name: "nop", Index: 1},
+		},
+	})
+
+	code, err := r.CompileModule(testCtx, bin)
+	require.NoError(t, err)
+
+	mConfig := wazero.NewModuleConfig().
+		WithFunctionTimeout("loop", 50*time.Millisecond).
+		WithFunctionTimeout("nop", 50*time.Millisecond)
+	mod, err := r.InstantiateModule(testCtx, code, mConfig)
+	require.NoError(t, err)
+
+	// The call hangs unless the timeout is honored.
+	_, err = mod.ExportedFunction("loop").Call(testCtx)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err.Error())
+
+	// Functions that finish in time are unaffected, including after
+	// another call timed out.
+	_, err = mod.ExportedFunction("nop").Call(testCtx)
+	require.NoError(t, err)
+}
+
+// testConcurrentCompileAndInstantiate ensures a Runtime can compile and instantiate
+// modules from many goroutines. Run this with -race.
+func testConcurrentCompileAndInstantiate(t *testing.T, r wazero.Runtime) {
+	const goroutines = 20
+
+	var wg sync.WaitGroup
+	errs := make([]error, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = compileAndCall(r, i)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, goroutines, len(r.Modules()))
+}
+
+// compileAndCall compiles a module distinct to i, which returns i, then
+// instantiates and calls it.
+func compileAndCall(r wazero.Runtime, i int) error {
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: append(
+			append([]byte{wasm.OpcodeI32Const}, leb128.EncodeInt32(int32(i))...), wasm.OpcodeEnd,
+		)}},
+		ExportSection: []*wasm.Export{{Name: "i", Type: wasm.ExternTypeFunc, Index: 0}},
+	})
+
+	compiled, err := r.CompileModule(testCtx, bin)
+	if err != nil {
+		return err
+	}
+	mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName(strconv.Itoa(i)))
+	if err != nil {
+		return err
+	}
+	results, err := mod.ExportedFunction("i").Call(testCtx)
+	if err != nil {
+		return err
+	} else if results[0] != uint64(i) {
+		return fmt.Errorf("expected %d, but was %d", i, results[0])
+	}
+	return nil
+}
+
+func testCallInPlace(t *testing.T, r wazero.Runtime) {
+	// next returns its param and its param plus one.
+	i32 := wasm.ValueTypeI32
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32, i32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeLocalGet, 0,
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add,
+			wasm.OpcodeEnd,
+		}}},
+		ExportSection: []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "next", Index: 0}},
+	})
+
+	mod, err := r.InstantiateModuleFromBinary(testCtx, bin)
+	require.NoError(t, err)
+	next := mod.ExportedFunction("next")
+
+	t.Run("enough capacity", func(t *testing.T) {
+		stack := make([]uint64, 1, 2)
+		stack[0] = 1
+		results, err := next.CallInPlace(testCtx, stack)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1, 2}, results)
+		require.Equal(t, &stack[0], &results[0])
+
+		// Calling again overwrites the previous results.
+		stack[0] = 5
+		again, err := next.CallInPlace(testCtx, stack)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{5, 6}, results)
+		require.Equal(t, &results[0], &again[0])
+	})
+
+	t.Run("not enough capacity", func(t *testing.T) {
+		stack := []uint64{1}
+		results, err := next.CallInPlace(testCtx, stack)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1, 2}, results)
+		require.Equal(t, []uint64{1}, stack)
+	})
+
+	t.Run("Call doesn't alias", func(t *testing.T) {
+		params := make([]uint64, 1, 2)
+		params[0] = 1
+		results, err := next.Call(testCtx, params...)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1, 2}, results)
+		require.Equal(t, []uint64{1, 0}, params[:2])
+	})
+}
+
 func testUnreachable(t *testing.T, r wazero.Runtime) {
 	callUnreachable := func() {
 		panic("panic in host function")
@@ -207,6 +849,53 @@ func testRecursiveEntry(t *testing.T, r wazero.Runtime) {
 	require.NoError(t, err)
 }
 
+// testRecursiveEntryDepth ensures a host function calling back into wasm
+// without bound traps cleanly, instead of overflowing the Go stack, which
+// would crash the process.
+func testRecursiveEntryDepth(t *testing.T, r wazero.Runtime) {
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, mod api.Module, n uint32) {
+			if n == 0 {
+				return
+			}
+			if _, err := mod.ExportedFunction("enter").Call(ctx, uint64(n-1), 1000); err != nil {
+				panic(err)
+			}
+		}).
+		Export("reenter").
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	// enter(n, frames) recurses frames times, then calls reenter(n), so that
+	// each nested call also uses a deep wasm stack.
+	mod, err := r.InstantiateModuleFromBinary(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}}, {Params: []wasm.ValueType{i32, i32}}},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "reenter", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{1},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeIf, 0x40,
+			wasm.OpcodeLocalGet, 0,
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub,
+			wasm.OpcodeCall, 1,
+			wasm.OpcodeElse,
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0,
+			wasm.OpcodeEnd,
+			wasm.OpcodeEnd,
+		}}},
+		ExportSection: []*wasm.Export{{Name: "enter", Type: wasm.ExternTypeFunc, Index: 1}},
+	}))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	_, err = mod.ExportedFunction("enter").Call(testCtx, 1_000_000, 1000)
+	require.True(t, errors.Is(err, wasmruntime.ErrRuntimeStackOverflow), err)
+
+	// Calls which nest less deeply are unaffected.
+	_, err = mod.ExportedFunction("enter").Call(testCtx, 10, 1000)
+	require.NoError(t, err)
+}
+
 // testHostFuncMemory ensures that host functions can see the callers' memory
 func testHostFuncMemory(t *testing.T, r wazero.Runtime) {
 	var memory *wasm.MemoryInstance
@@ -291,7 +980,9 @@ func testHostFunctionContextParameter(t *testing.T, r wazero.Runtime) {
 			return p + 1
 		},
 		"ctx mod": func(ctx context.Context, module api.Module, p uint32) uint32 {
-			require.Equal(t, importing, module)
+			// The module is a copy which also counts the calls in progress.
+			require.Equal(t, importing.Name(), module.Name())
+			require.Equal(t, importing.Memory(), module.Memory())
 			return p + 1
 		},
 	}
@@ -650,3 +1341,114 @@ func testMultipleInstantiation(t *testing.T, r wazero.Runtime) {
 		require.Equal(t, uint64(1000), after)
 	}
 }
+
+// TestEngineInterpreter_CallStackExhausted ensures deep recursion in the
+// interpreter traps cleanly when it exceeds the call frame ceiling, instead of
+// overflowing the Go stack, which would crash the process.
+func TestEngineInterpreter_CallStackExhausted(t *testing.T) {
+	r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfigInterpreter())
+	defer r.Close(testCtx)
+
+	// Both functions recurse while the param is non-zero: directly, or via
+	// call_indirect, which is how function pointers are called.
+	recurse := func(call ...byte) *wasm.Code {
+		body := []byte{
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeIf, 0x40,
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub,
+		}
+		body = append(body, call...)
+		return &wasm.Code{Body: append(body, wasm.OpcodeEnd, wasm.OpcodeEnd)}
+	}
+	one := wasm.Index(1)
+	mod, err := r.InstantiateModuleFromBinary(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			recurse(wasm.OpcodeCall, 0),
+			recurse(wasm.OpcodeI32Const, 0, wasm.OpcodeCallIndirect, 0, 0),
+		},
+		TableSection: []*wasm.Table{{Min: 1, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&one},
+			Type:       wasm.RefTypeFuncref,
+			Mode:       wasm.ElementModeActive,
+		}},
+		ExportSection: []*wasm.Export{
+			{Name: "call", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "call_indirect", Type: wasm.ExternTypeFunc, Index: 1},
+		},
+	}))
+	require.NoError(t, err)
+
+	for _, name := range []string{"call", "call_indirect"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			_, err := mod.ExportedFunction(name).Call(testCtx, 1_000_000)
+			require.True(t, errors.Is(err, wasmruntime.ErrRuntimeStackOverflow), err)
+
+			// The module is still usable after the trap.
+			_, err = mod.ExportedFunction(name).Call(testCtx, 10)
+			require.NoError(t, err)
+		})
+	}
+}
+
+// testReset ensures experimental.Reset restores globals and memory to their
+// state after instantiation.
+func testReset(t *testing.T, r wazero.Runtime) {
+	bin := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			// Overwrite the first byte and grow memory.
+			wasm.OpcodeI32Const, 0, wasm.OpcodeI32Const, 'X',
+			wasm.OpcodeI32Store8, 0, 0,
+			wasm.OpcodeI32Const, 1, wasm.OpcodeMemoryGrow, 0, wasm.OpcodeDrop,
+			// Increment the global and return it.
+			wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add,
+			wasm.OpcodeGlobalSet, 0,
+			wasm.OpcodeGlobalGet, 0,
+			wasm.OpcodeEnd,
+		}}},
+		GlobalSection: []*wasm.Global{{
+			Type: &wasm.GlobalType{ValType: i32, Mutable: true},
+			Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{5}},
+		}},
+		MemorySection: &wasm.Memory{Min: 1, Max: 3, IsMaxEncoded: true},
+		DataSection: []*wasm.DataSegment{
+			{
+				OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{1}},
+				Init:             []byte("wazero"),
+			},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "run", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "memory", Type: wasm.ExternTypeMemory},
+		},
+	})
+
+	mod, err := r.InstantiateModuleFromBinary(testCtx, bin)
+	require.NoError(t, err)
+	run, mem := mod.ExportedFunction("run"), mod.ExportedMemory("memory")
+
+	for _, expected := range []uint64{6, 7} {
+		results, err := run.Call(testCtx)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{expected}, results)
+	}
+	require.Equal(t, uint32(3*65536), mem.Size(testCtx))
+
+	require.NoError(t, experimental.Reset(mod))
+
+	require.Equal(t, uint32(65536), mem.Size(testCtx))
+	buf, ok := mem.Read(testCtx, 0, 8)
+	require.True(t, ok)
+	require.Equal(t, "\x00wazero\x00", string(buf))
+
+	// Running again behaves the same as after instantiation.
+	results, err := run.Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{6}, results)
+	require.Equal(t, uint32(2*65536), mem.Size(testCtx))
+}
@@ -0,0 +1,66 @@
+// This uses syscall.Mmap with MAP_PRIVATE on a file. Go's SDK only supports this on darwin, linux and freebsd.
+//go:build darwin || linux || freebsd
+
+package platform
+
+import (
+	"os"
+	"syscall"
+)
+
+// CopyOnWriteSupported returns true when NewMemoryImage is supported.
+func CopyOnWriteSupported() bool {
+	return true
+}
+
+// MemoryImage is the initial contents of a linear memory, which any number of
+// instances can map copy-on-write via Map.
+//
+// The image is backed by an unlinked, sparse temporary file. This means pages
+// never written by WriteAt take no space, and read back as zero.
+type MemoryImage struct {
+	f    *os.File
+	size int
+}
+
+// NewMemoryImage returns a zero-filled image of the given size in bytes.
+func NewMemoryImage(size int) (*MemoryImage, error) {
+	f, err := os.CreateTemp("", "wazero-memory-*")
+	if err != nil {
+		return nil, err
+	}
+	// Unlink the file, so that it is reclaimed when the last mapping is.
+	if err = os.Remove(f.Name()); err == nil {
+		err = f.Truncate(int64(size))
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &MemoryImage{f: f, size: size}, nil
+}
+
+// WriteAt writes p into the image at the given offset. This must not be
+// called after Map.
+func (i *MemoryImage) WriteAt(p []byte, off int64) (int, error) {
+	return i.f.WriteAt(p, off)
+}
+
+// Map returns a private, writable mapping of the whole image. Writes are
+// visible only to the returned slice, as pages are copied on first write.
+//
+// Release the result with UnmapMemoryImage.
+func (i *MemoryImage) Map() ([]byte, error) {
+	return syscall.Mmap(int(i.f.Fd()), 0, i.size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+}
+
+// Close releases the file backing the image. Existing mappings are still
+// valid after this.
+func (i *MemoryImage) Close() error {
+	return i.f.Close()
+}
+
+// UnmapMemoryImage releases a mapping returned by MemoryImage.Map.
+func UnmapMemoryImage(b []byte) error {
+	return syscall.Munmap(b)
+}
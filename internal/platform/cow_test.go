@@ -0,0 +1,49 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestMemoryImage(t *testing.T) {
+	if !CopyOnWriteSupported() {
+		_, err := NewMemoryImage(1 << 16)
+		require.Error(t, err)
+		t.Skip()
+	}
+
+	image, err := NewMemoryImage(1 << 16)
+	require.NoError(t, err)
+	defer image.Close()
+
+	_, err = image.WriteAt([]byte{1, 2, 3}, 10)
+	require.NoError(t, err)
+
+	m1, err := image.Map()
+	require.NoError(t, err)
+	defer UnmapMemoryImage(m1)
+	m2, err := image.Map()
+	require.NoError(t, err)
+
+	// Both mappings see the image, and unwritten bytes are zero.
+	require.Equal(t, 1<<16, len(m1))
+	require.Equal(t, []byte{0, 1, 2, 3, 0}, m1[9:14])
+	require.Equal(t, []byte{0, 1, 2, 3, 0}, m2[9:14])
+
+	// Writes are private to the mapping.
+	m1[11] = 42
+	m1[1<<15] = 43
+	require.Equal(t, []byte{0, 1, 42, 3, 0}, m1[9:14])
+	require.Equal(t, []byte{0, 1, 2, 3, 0}, m2[9:14])
+	require.Equal(t, byte(0), m2[1<<15])
+
+	// Mappings outlive the image.
+	require.NoError(t, image.Close())
+	m3, err := image.Map()
+	require.Error(t, err)
+	require.Nil(t, m3)
+	require.Equal(t, byte(2), m2[11])
+
+	require.NoError(t, UnmapMemoryImage(m2))
+}
@@ -0,0 +1,43 @@
+//go:build !(darwin || linux || freebsd)
+
+package platform
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var errCopyOnWriteUnsupported = fmt.Errorf("copy-on-write memory unsupported on %s", runtime.GOOS)
+
+// CopyOnWriteSupported returns true when NewMemoryImage is supported.
+func CopyOnWriteSupported() bool {
+	return false
+}
+
+// MemoryImage is unsupported on this platform.
+type MemoryImage struct{}
+
+// NewMemoryImage returns an error as copy-on-write is unsupported on this platform.
+func NewMemoryImage(int) (*MemoryImage, error) {
+	return nil, errCopyOnWriteUnsupported
+}
+
+// WriteAt returns an error as copy-on-write is unsupported on this platform.
+func (i *MemoryImage) WriteAt([]byte, int64) (int, error) {
+	return 0, errCopyOnWriteUnsupported
+}
+
+// Map returns an error as copy-on-write is unsupported on this platform.
+func (i *MemoryImage) Map() ([]byte, error) {
+	return nil, errCopyOnWriteUnsupported
+}
+
+// Close returns an error as copy-on-write is unsupported on this platform.
+func (i *MemoryImage) Close() error {
+	return errCopyOnWriteUnsupported
+}
+
+// UnmapMemoryImage returns an error as copy-on-write is unsupported on this platform.
+func UnmapMemoryImage([]byte) error {
+	return errCopyOnWriteUnsupported
+}
@@ -0,0 +1,57 @@
+package sys
+
+import (
+	"bytes"
+	"sync"
+)
+
+// LineWriter is an io.Writer which buffers writes, calling a handler with each
+// newline-terminated line, excluding the newline.
+//
+// See wazero.ModuleConfig WithStdoutLineHandler
+type LineWriter struct {
+	handler func(line string)
+	mux     sync.Mutex
+	// buf holds the partial line written after the last newline.
+	buf []byte
+}
+
+// NewLineWriter returns a LineWriter which calls the handler with each line.
+func NewLineWriter(handler func(line string)) *LineWriter {
+	return &LineWriter{handler: handler}
+}
+
+// Write implements io.Writer
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			break
+		}
+		if len(w.buf) > 0 {
+			w.handler(string(append(w.buf, p[:i]...)))
+			w.buf = w.buf[:0]
+		} else {
+			w.handler(string(p[:i]))
+		}
+		p = p[i+1:]
+	}
+	w.buf = append(w.buf, p...)
+	return n, nil
+}
+
+// Flush calls the handler with any partial line written, e.g. when the module
+// is closed.
+func (w *LineWriter) Flush() {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if len(w.buf) > 0 {
+		w.handler(string(w.buf))
+		w.buf = w.buf[:0]
+	}
+}
@@ -0,0 +1,30 @@
+package sys
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := NewLineWriter(func(line string) {
+		lines = append(lines, line)
+	})
+
+	n, err := w.Write([]byte("hello\nwor"))
+	require.NoError(t, err)
+	require.Equal(t, 9, n)
+	require.Equal(t, []string{"hello"}, lines)
+
+	_, err = w.Write([]byte("ld\n\n!"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"hello", "world", ""}, lines)
+
+	w.Flush()
+	require.Equal(t, []string{"hello", "world", "", "!"}, lines)
+
+	// Flushing again doesn't repeat the partial line.
+	w.Flush()
+	require.Equal(t, 4, len(lines))
+}
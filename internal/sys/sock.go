@@ -0,0 +1,85 @@
+package sys
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"syscall"
+	"time"
+)
+
+// listenerFile is a fs.File which accepts connections for WASI sock_accept.
+// It embeds the listener, so can be type asserted to net.Listener.
+type listenerFile struct {
+	net.Listener
+}
+
+// Stat implements fs.File
+func (f *listenerFile) Stat() (fs.FileInfo, error) {
+	return &socketInfo{name: f.Addr().String()}, nil
+}
+
+// Read implements fs.File, but always fails as listeners aren't connected.
+func (f *listenerFile) Read([]byte) (int, error) {
+	return 0, syscall.ENOTCONN
+}
+
+// connFile is a fs.File which reads and writes a connection accepted by a
+// listenerFile. It embeds the connection, so can be type asserted to
+// net.Conn.
+type connFile struct {
+	net.Conn
+}
+
+// Stat implements fs.File
+func (f *connFile) Stat() (fs.FileInfo, error) {
+	return &socketInfo{name: f.RemoteAddr().String()}, nil
+}
+
+// socketInfo implements fs.FileInfo for a socket.
+type socketInfo struct {
+	name string
+}
+
+func (i *socketInfo) Name() string       { return i.name }
+func (i *socketInfo) Size() int64        { return 0 }
+func (i *socketInfo) Mode() fs.FileMode  { return fs.ModeSocket }
+func (i *socketInfo) ModTime() time.Time { return time.Time{} }
+func (i *socketInfo) IsDir() bool        { return false }
+func (i *socketInfo) Sys() interface{}   { return nil }
+
+// preopenListener assigns the next file descriptor to the listener.
+func (c *FSContext) preopenListener(l net.Listener) {
+	c.openedFiles[c.nextFD()] = &FileEntry{File: &listenerFile{l}}
+}
+
+// SockAccept is like syscall.Accept and returns the file descriptor of a new
+// connection from the listener at fd, or an error.
+//
+// The returned errors are:
+//   - syscall.EBADF: fd is not open
+//   - syscall.ENOTSOCK: fd is not a listener
+//   - any error from net.Listener Accept
+func (c *FSContext) SockAccept(_ context.Context, fd uint32) (uint32, error) {
+	f, ok := c.openedFiles[fd]
+	if !ok {
+		return 0, syscall.EBADF
+	}
+	l, ok := f.File.(*listenerFile)
+	if !ok {
+		return 0, syscall.ENOTSOCK
+	}
+
+	conn, err := l.Accept()
+	if err != nil {
+		return 0, err
+	}
+
+	newFD := c.nextFD()
+	if newFD == 0 { // TODO: out of file descriptors
+		_ = conn.Close()
+		return 0, syscall.EBADF
+	}
+	c.openedFiles[newFD] = &FileEntry{File: &connFile{conn}}
+	return newFD, nil
+}
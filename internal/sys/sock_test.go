@@ -0,0 +1,57 @@
+package sys
+
+import (
+	"io"
+	"io/fs"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestFSContext_SockAccept(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	sysCtx, err := NewContext(0, nil, nil, nil, nil, nil, nil, nil, 0, nil, 0, nil, nil, []net.Listener{ln}, nil, nil, false, nil, false)
+	require.NoError(t, err)
+	fsc := sysCtx.FS(testCtx)
+	defer fsc.Close(testCtx)
+
+	// The shared context of the empty file system wasn't changed.
+	require.NotEqual(t, emptyFSContext, fsc)
+	require.Zero(t, len(emptyFSContext.openedFiles))
+
+	// There's no file system, so the listener is the first pre-opened fd.
+	f, ok := fsc.OpenedFile(testCtx, 3)
+	require.True(t, ok)
+	st, err := f.File.Stat()
+	require.NoError(t, err)
+	require.Equal(t, fs.ModeSocket, st.Mode())
+
+	go func() {
+		if conn, err := net.Dial("tcp", ln.Addr().String()); err == nil {
+			_, _ = conn.Write([]byte("wazero"))
+			_ = conn.Close()
+		}
+	}()
+
+	fd, err := fsc.SockAccept(testCtx, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint32(4), fd)
+
+	// The connection can be read like any other file.
+	f, ok = fsc.OpenedFile(testCtx, fd)
+	require.True(t, ok)
+	buf := make([]byte, 6)
+	_, err = io.ReadFull(f.File, buf)
+	require.NoError(t, err)
+	require.Equal(t, "wazero", string(buf))
+
+	_, err = fsc.SockAccept(testCtx, 42)
+	require.Equal(t, syscall.EBADF, err)
+
+	_, err = fsc.SockAccept(testCtx, fd)
+	require.Equal(t, syscall.ENOTSOCK, err)
+}
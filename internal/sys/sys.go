@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net"
 	"time"
 
 	"github.com/tetratelabs/wazero/internal/platform"
@@ -29,6 +30,12 @@ type Context struct {
 	nanosleep          *sys.Nanosleep
 	randSource         io.Reader
 	fsc                *FSContext
+	exitHandler        func(exitCode uint32) error
+	yieldHandler       func(ctx context.Context) error
+	strictUTF8         bool
+	// contextValues are pair-indexed keys and values, in the order set.
+	contextValues  []interface{}
+	prefaultMemory bool
 }
 
 // Args is like os.Args and defaults to nil.
@@ -123,6 +130,44 @@ func (c *Context) RandSource() io.Reader {
 	return c.randSource
 }
 
+// ExitHandler is consulted by proc_exit and defaults to nil.
+// See wazero.ModuleConfig WithExitHandler
+func (c *Context) ExitHandler() func(exitCode uint32) error {
+	return c.exitHandler
+}
+
+// YieldHandler is called by sched_yield and defaults to nil.
+// See wazero.ModuleConfig WithYieldHandler
+func (c *Context) YieldHandler() func(ctx context.Context) error {
+	return c.yieldHandler
+}
+
+// StrictUTF8 is true when WASI functions must reject paths which aren't
+// valid UTF-8, and defaults to false.
+// See wazero.ModuleConfig WithStrictUTF8
+func (c *Context) StrictUTF8() bool {
+	return c.strictUTF8
+}
+
+// PrefaultMemory is true when the memory a module defines must be written at
+// instantiation, and defaults to false.
+// See wazero.ModuleConfig WithPrefaultMemory
+func (c *Context) PrefaultMemory() bool {
+	return c.prefaultMemory
+}
+
+// WithContextValues returns ctx with the values set by wazero.ModuleConfig
+// WithContextValue, except for keys ctx already has a value for.
+func (c *Context) WithContextValues(ctx context.Context) context.Context {
+	// Iterate backwards, so that a later value of the same key wins.
+	for i := len(c.contextValues) - 2; i >= 0; i -= 2 {
+		if key := c.contextValues[i]; ctx.Value(key) == nil {
+			ctx = context.WithValue(ctx, key, c.contextValues[i+1])
+		}
+	}
+	return ctx
+}
+
 // eofReader is safer than reading from os.DevNull as it can never overrun operating system file descriptors.
 type eofReader struct{}
 
@@ -134,7 +179,7 @@ func (eofReader) Read([]byte) (int, error) {
 
 // DefaultContext returns Context with no values set except a possibly nil fs.FS
 func DefaultContext(fs fs.FS) *Context {
-	if sysCtx, err := NewContext(0, nil, nil, nil, nil, nil, nil, nil, 0, nil, 0, nil, fs); err != nil {
+	if sysCtx, err := NewContext(0, nil, nil, nil, nil, nil, nil, nil, 0, nil, 0, nil, fs, nil, nil, nil, false, nil, false); err != nil {
 		panic(fmt.Errorf("BUG: DefaultContext should never error: %w", err))
 	} else {
 		return sysCtx
@@ -160,8 +205,22 @@ func NewContext(
 	nanotimeResolution sys.ClockResolution,
 	nanosleep *sys.Nanosleep,
 	fs fs.FS,
+	listeners []net.Listener,
+	exitHandler func(exitCode uint32) error,
+	yieldHandler func(ctx context.Context) error,
+	strictUTF8 bool,
+	contextValues []interface{},
+	prefaultMemory bool,
 ) (sysCtx *Context, err error) {
-	sysCtx = &Context{args: args, environ: environ}
+	sysCtx = &Context{
+		args:           args,
+		environ:        environ,
+		exitHandler:    exitHandler,
+		yieldHandler:   yieldHandler,
+		strictUTF8:     strictUTF8,
+		contextValues:  contextValues,
+		prefaultMemory: prefaultMemory,
+	}
 
 	if sysCtx.argsSize, err = nullTerminatedByteCount(max, args); err != nil {
 		return nil, fmt.Errorf("args invalid: %w", err)
@@ -229,6 +288,15 @@ func NewContext(
 		sysCtx.fsc = NewFSContext(EmptyFS)
 	}
 
+	if len(listeners) > 0 {
+		if sysCtx.fsc == emptyFSContext { // Don't mutate the shared context.
+			sysCtx.fsc = &FSContext{fs: EmptyFS, openedFiles: map[uint32]*FileEntry{}, lastFD: 2}
+		}
+		for _, l := range listeners {
+			sysCtx.fsc.preopenListener(l)
+		}
+	}
+
 	return
 }
 
@@ -36,6 +36,12 @@ func TestDefaultSysContext(t *testing.T) {
 		nil, 0, // nanotime, nanotimeResolution
 		nil,         // nanosleep
 		testfs.FS{}, // fs
+		nil,         // listeners
+		nil,         // exitHandler
+		nil,         // yieldHandler
+		false,       // strictUTF8
+		nil,         // contextValues
+		false,       // prefaultMemory
 	)
 	require.NoError(t, err)
 
@@ -106,8 +112,14 @@ func TestNewContext_Args(t *testing.T) {
 				nil,                              // randSource
 				nil, 0,                           // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
-				nil, // nanosleep
-				nil, // fs
+				nil,   // nanosleep
+				nil,   // fs
+				nil,   // listeners
+				nil,   // exitHandler
+				nil,   // yieldHandler
+				false, // strictUTF8
+				nil,   // contextValues
+				false, // prefaultMemory
 			)
 			if tc.expectedErr == "" {
 				require.Nil(t, err)
@@ -168,8 +180,14 @@ func TestNewContext_Environ(t *testing.T) {
 				nil,                              // randSource
 				nil, 0,                           // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
-				nil, // nanosleep
-				nil, // fs
+				nil,   // nanosleep
+				nil,   // fs
+				nil,   // listeners
+				nil,   // exitHandler
+				nil,   // yieldHandler
+				false, // strictUTF8
+				nil,   // contextValues
+				false, // prefaultMemory
 			)
 			if tc.expectedErr == "" {
 				require.Nil(t, err)
@@ -216,8 +234,14 @@ func TestNewContext_Walltime(t *testing.T) {
 				nil,                    // randSource
 				tc.time, tc.resolution, // walltime, walltimeResolution
 				nil, 0, // nanotime, nanotimeResolution
-				nil, // nanosleep
-				nil, // fs
+				nil,   // nanosleep
+				nil,   // fs
+				nil,   // listeners
+				nil,   // exitHandler
+				nil,   // yieldHandler
+				false, // strictUTF8
+				nil,   // contextValues
+				false, // prefaultMemory
 			)
 			if tc.expectedErr == "" {
 				require.Nil(t, err)
@@ -264,8 +288,14 @@ func TestNewContext_Nanotime(t *testing.T) {
 				nil,    // randSource
 				nil, 0, // nanotime, nanotimeResolution
 				tc.time, tc.resolution, // nanotime, nanotimeResolution
-				nil, // nanosleep
-				nil, // fs
+				nil,   // nanosleep
+				nil,   // fs
+				nil,   // listeners
+				nil,   // exitHandler
+				nil,   // yieldHandler
+				false, // strictUTF8
+				nil,   // contextValues
+				false, // prefaultMemory
 			)
 			if tc.expectedErr == "" {
 				require.Nil(t, err)
@@ -322,8 +352,14 @@ func TestNewContext_Nanosleep(t *testing.T) {
 		nil,    // randSource
 		nil, 0, // Nanosleep, NanosleepResolution
 		nil, 0, // Nanosleep, NanosleepResolution
-		&aNs, // nanosleep
-		nil,  // fs
+		&aNs,  // nanosleep
+		nil,   // fs
+		nil,   // listeners
+		nil,   // exitHandler
+		nil,   // yieldHandler
+		false, // strictUTF8
+		nil,   // contextValues
+		false, // prefaultMemory
 	)
 	require.Nil(t, err)
 	require.Equal(t, &aNs, sysCtx.nanosleep)
@@ -20,20 +20,27 @@ package enginetest
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/moremath"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/u64"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/internal/wazeroir"
 )
 
 const (
 	i32, i64 = wasm.ValueTypeI32, wasm.ValueTypeI64
+	f32, f64 = wasm.ValueTypeF32, wasm.ValueTypeF64
 )
 
 var (
@@ -48,7 +55,7 @@ type EngineTester interface {
 	// IsCompiler returns true if this engine is a compiler.
 	IsCompiler() bool
 
-	NewEngine(enabledFeatures api.CoreFeatures) wasm.Engine
+	NewEngine(ctx context.Context, enabledFeatures api.CoreFeatures) wasm.Engine
 
 	ListenerFactory() experimental.FunctionListenerFactory
 
@@ -61,7 +68,7 @@ type EngineTester interface {
 }
 
 func RunTestEngine_NewModuleEngine(t *testing.T, et EngineTester) {
-	e := et.NewEngine(api.CoreFeaturesV1)
+	e := et.NewEngine(testCtx, api.CoreFeaturesV1)
 
 	t.Run("error before instantiation", func(t *testing.T) {
 		_, err := e.NewModuleEngine("mymod", &wasm.Module{}, nil, nil, nil, nil)
@@ -79,7 +86,7 @@ func RunTestEngine_NewModuleEngine(t *testing.T, et EngineTester) {
 }
 
 func RunTestEngine_InitializeFuncrefGlobals(t *testing.T, et EngineTester) {
-	e := et.NewEngine(api.CoreFeaturesV2)
+	e := et.NewEngine(testCtx, api.CoreFeaturesV2)
 
 	i64 := i64
 	m := &wasm.Module{
@@ -122,10 +129,10 @@ func RunTestEngine_InitializeFuncrefGlobals(t *testing.T, et EngineTester) {
 }
 
 func RunTestModuleEngine_Call(t *testing.T, et EngineTester) {
-	e := et.NewEngine(api.CoreFeaturesV2)
+	e := et.NewEngine(testCtx, api.CoreFeaturesV2)
 
-	// Define a basic function which defines two parameters and two results.
-	// This is used to test results when incorrect arity is used.
+	// Define a basic function which defines two parameters and two results,
+	// swapping them. This is used to test results when incorrect arity is used.
 	m := &wasm.Module{
 		TypeSection: []*wasm.FunctionType{
 			{
@@ -137,7 +144,7 @@ func RunTestModuleEngine_Call(t *testing.T, et EngineTester) {
 		},
 		FunctionSection: []wasm.Index{0},
 		CodeSection: []*wasm.Code{
-			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}},
 		},
 	}
 	m.BuildFunctionDefinitions()
@@ -159,16 +166,36 @@ func RunTestModuleEngine_Call(t *testing.T, et EngineTester) {
 	ce, err := me.NewCallEngine(module.CallCtx, fn)
 	require.NoError(t, err)
 
-	results, err := ce.Call(testCtx, module.CallCtx, []uint64{1, 2})
+	params := []uint64{1, 2}
+	results, err := ce.Call(testCtx, module.CallCtx, params)
 	require.NoError(t, err)
-	require.Equal(t, []uint64{1, 2}, results)
+	require.Equal(t, []uint64{2, 1}, results)
+	require.Equal(t, []uint64{1, 2}, params) // params aren't overwritten.
+
+	t.Run("in place", func(t *testing.T) {
+		ce, err := me.NewCallEngine(module.CallCtx, fn)
+		require.NoError(t, err)
+
+		stack := []uint64{1, 2}
+		results, err := ce.CallInPlace(testCtx, module.CallCtx, stack)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{2, 1}, results)
+		require.Equal(t, []uint64{2, 1}, stack)
+		require.Equal(t, &stack[0], &results[0]) // results alias params.
+
+		// The results of the previous call are the params of the next.
+		results, err = ce.CallInPlace(testCtx, module.CallCtx, results)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1, 2}, stack)
+		require.Equal(t, &stack[0], &results[0])
+	})
 
 	t.Run("errs when not enough parameters", func(t *testing.T) {
 		ce, err := me.NewCallEngine(module.CallCtx, fn)
 		require.NoError(t, err)
 
 		_, err = ce.Call(testCtx, module.CallCtx, nil)
-		require.EqualError(t, err, "expected 2 params, but passed 0")
+		require.EqualError(t, err, ".$0(i64,i64) (i64,i64): expected 2 params, but passed 0")
 	})
 
 	t.Run("errs when too many parameters", func(t *testing.T) {
@@ -176,12 +203,12 @@ func RunTestModuleEngine_Call(t *testing.T, et EngineTester) {
 		require.NoError(t, err)
 
 		_, err = ce.Call(testCtx, module.CallCtx, []uint64{1, 2, 3})
-		require.EqualError(t, err, "expected 2 params, but passed 3")
+		require.EqualError(t, err, ".$0(i64,i64) (i64,i64): expected 2 params, but passed 3")
 	})
 }
 
 func RunTestEngine_NewModuleEngine_InitTable(t *testing.T, et EngineTester) {
-	e := et.NewEngine(api.CoreFeaturesV1)
+	e := et.NewEngine(testCtx, api.CoreFeaturesV1)
 
 	t.Run("no table elements", func(t *testing.T) {
 		requireNewModuleEngine_emptyTable(t, e, et)
@@ -382,12 +409,15 @@ func requireNewModuleEngine_tableWithMixedFunctions(t *testing.T, e wasm.Engine,
 }
 
 func RunTestModuleEngine_LookupFunction(t *testing.T, et EngineTester) {
-	e := et.NewEngine(api.CoreFeaturesV1)
+	e := et.NewEngine(testCtx, api.CoreFeaturesV1)
 
 	t.Run("no table elements", func(t *testing.T) {
 		me, m := requireNewModuleEngine_emptyTable(t, e, et)
 
-		_, err := me.LookupFunction(m.Tables[0], m.TypeIDs[0], 0 /* out of range */)
+		_, err := me.LookupFunction(m.Tables[0], m.TypeIDs[0], 0 /* null */)
+		require.Equal(t, wasmruntime.ErrRuntimeUninitializedElement, err)
+
+		_, err = me.LookupFunction(m.Tables[0], m.TypeIDs[0], 2 /* out of range */)
 		require.Equal(t, wasmruntime.ErrRuntimeInvalidTableAccess, err)
 	})
 
@@ -395,42 +425,190 @@ func RunTestModuleEngine_LookupFunction(t *testing.T, et EngineTester) {
 		me, m := requireNewModuleEngine_multiTable(t, e, et)
 
 		// table[0][0] should point to func1
-		idx, err := me.LookupFunction(m.Tables[0], m.TypeIDs[0], 0)
+		f, err := me.LookupFunction(m.Tables[0], m.TypeIDs[0], 0)
 		require.NoError(t, err)
-		require.Equal(t, func1, idx)
+		require.Equal(t, func1, f.Idx)
 
 		// table[1][5] should point to func2
-		idx, err = me.LookupFunction(m.Tables[1], m.TypeIDs[0], 5)
+		f, err = me.LookupFunction(m.Tables[1], m.TypeIDs[0], 5)
 		require.NoError(t, err)
-		require.Equal(t, func2, idx)
+		require.Equal(t, func2, f.Idx)
+
+		// table[0][1] is in range, but was never initialized.
+		_, err = me.LookupFunction(m.Tables[0], m.TypeIDs[0], 1)
+		require.Equal(t, wasmruntime.ErrRuntimeUninitializedElement, err)
+
+		// table[0][2] is out of range.
+		_, err = me.LookupFunction(m.Tables[0], m.TypeIDs[0], 2)
+		require.Equal(t, wasmruntime.ErrRuntimeInvalidTableAccess, err)
 	})
 
 	t.Run("imported function", func(t *testing.T) {
 		me, m := requireNewModuleEngine_tableWithImportedFunction(t, e, et)
 
 		// table[0][0] should point to func1
-		idx, err := me.LookupFunction(m.Tables[0], m.TypeIDs[0], 0)
+		f, err := me.LookupFunction(m.Tables[0], m.TypeIDs[0], 0)
 		require.NoError(t, err)
-		require.Equal(t, func1, idx)
+		require.Equal(t, func1, f.Idx)
 	})
 
 	t.Run("mixed functions", func(t *testing.T) {
 		me, m := requireNewModuleEngine_tableWithMixedFunctions(t, e, et)
 
 		// table[0][0] should point to func1
-		idx, err := me.LookupFunction(m.Tables[0], m.TypeIDs[0], 0)
+		f, err := me.LookupFunction(m.Tables[0], m.TypeIDs[0], 0)
 		require.NoError(t, err)
-		require.Equal(t, func1, idx)
+		require.Equal(t, func1, f.Idx)
 
 		// table[0][1] should point to func2
-		idx, err = me.LookupFunction(m.Tables[0], m.TypeIDs[0], 1)
+		f, err = me.LookupFunction(m.Tables[0], m.TypeIDs[0], 1)
+		require.NoError(t, err)
+		require.Equal(t, func2, f.Idx)
+	})
+}
+
+// RunTestModuleEngine_TableOps ensures the bulk table operations table.grow,
+// table.size, table.fill and table.copy update the tables seen by
+// LookupFunction, and trap when out of bounds.
+func RunTestModuleEngine_TableOps(t *testing.T, et EngineTester) {
+	e := et.NewEngine(testCtx, api.CoreFeaturesV2)
+
+	tableMax := uint32(4)
+	tables := []*wasm.TableInstance{
+		{Min: 2, Max: &tableMax, References: make([]wasm.Reference, 2)},
+		{Min: 4, References: make([]wasm.Reference, 4)},
+	}
+
+	i32_i32 := &wasm.FunctionType{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}
+	i32i32_v := &wasm.FunctionType{Params: []wasm.ValueType{i32, i32}}
+	i32i32i32_v := &wasm.FunctionType{Params: []wasm.ValueType{i32, i32, i32}}
+	v_i32 := &wasm.FunctionType{Results: []wasm.ValueType{i32}}
+	types := []*wasm.FunctionType{v_v, i32_i32, i32i32_v, i32i32i32_v, v_i32}
+	for _, ft := range types {
+		ft.CacheNumInUint64()
+	}
+
+	const (
+		funcA, funcB                   = wasm.Index(0), wasm.Index(1)
+		growFn, fillFn, copyFn, sizeFn = 2, 3, 4, 5
+	)
+	m := &wasm.Module{
+		TypeSection:     types,
+		FunctionSection: []wasm.Index{0, 0, 1, 2, 3, 4},
+		TableSection: []*wasm.Table{
+			{Min: 2, Max: &tableMax, Type: wasm.RefTypeFuncref},
+			{Min: 4, Type: wasm.RefTypeFuncref},
+		},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+			// grow(delta) grows table[0] by delta, filled with funcA.
+			{Body: []byte{
+				wasm.OpcodeRefFunc, byte(funcA),
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscTableGrow, 0,
+				wasm.OpcodeEnd,
+			}},
+			// fill(offset, n) fills table[0] with funcB.
+			{Body: []byte{
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeRefFunc, byte(funcB),
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscTableFill, 0,
+				wasm.OpcodeEnd,
+			}},
+			// copy(dst, src, n) copies from table[0] to table[1].
+			{Body: []byte{
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeLocalGet, 2,
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscTableCopy, 1, 0,
+				wasm.OpcodeEnd,
+			}},
+			// size() returns the size of table[0].
+			{Body: []byte{
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscTableSize, 0,
+				wasm.OpcodeEnd,
+			}},
+		},
+		ID: wasm.ModuleID{4},
+	}
+	m.BuildFunctionDefinitions()
+	err := e.CompileModule(testCtx, m)
+	require.NoError(t, err)
+
+	module := &wasm.ModuleInstance{Name: t.Name(), Tables: tables, TypeIDs: []wasm.FunctionTypeID{0, 1, 2, 3, 4}}
+	module.Functions = module.BuildFunctions(m, buildListeners(et.ListenerFactory(), m))
+
+	me, err := e.NewModuleEngine(t.Name(), m, nil, module.Functions, tables, nil)
+	require.NoError(t, err)
+	linkModuleToEngine(module, me)
+
+	call := func(fn wasm.Index, params ...uint64) ([]uint64, error) {
+		ce, err := me.NewCallEngine(module.CallCtx, module.Functions[fn])
+		require.NoError(t, err)
+		return ce.Call(testCtx, module.CallCtx, params)
+	}
+	requireLookup := func(table *wasm.TableInstance, offset wasm.Index, expected wasm.Index) {
+		f, err := me.LookupFunction(table, module.TypeIDs[0], offset)
+		require.NoError(t, err)
+		require.Equal(t, expected, f.Idx)
+	}
+
+	t.Run("table.grow", func(t *testing.T) {
+		results, err := call(growFn, 1)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{2}, results) // previous size
+
+		results, err = call(sizeFn)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{3}, results)
+
+		// The new element is initialized to funcA.
+		requireLookup(tables[0], 2, funcA)
+
+		// Growing past the max fails with -1, leaving the table as-is.
+		results, err = call(growFn, 2)
 		require.NoError(t, err)
-		require.Equal(t, func2, idx)
+		require.Equal(t, []uint64{uint64(uint32(math.MaxUint32))}, results)
+
+		results, err = call(sizeFn)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{3}, results)
+	})
+
+	t.Run("table.fill", func(t *testing.T) {
+		_, err := call(fillFn, 0, 2)
+		require.NoError(t, err)
+
+		requireLookup(tables[0], 0, funcB)
+		requireLookup(tables[0], 1, funcB)
+		requireLookup(tables[0], 2, funcA) // not in range, so unchanged
+
+		// Filling past the end traps.
+		_, err = call(fillFn, 2, 2)
+		require.ErrorIs(t, err, wasmruntime.ErrRuntimeInvalidTableAccess)
+	})
+
+	t.Run("table.copy", func(t *testing.T) {
+		_, err := call(copyFn, 1, 1, 2)
+		require.NoError(t, err)
+
+		requireLookup(tables[1], 1, funcB)
+		requireLookup(tables[1], 2, funcA)
+
+		// Elements outside the copied range were never initialized.
+		_, err = me.LookupFunction(tables[1], module.TypeIDs[0], 0)
+		require.Equal(t, wasmruntime.ErrRuntimeUninitializedElement, err)
+
+		// Copying past the end of the source traps.
+		_, err = call(copyFn, 0, 2, 2)
+		require.ErrorIs(t, err, wasmruntime.ErrRuntimeInvalidTableAccess)
 	})
 }
 
 func runTestModuleEngine_Call_HostFn_Mem(t *testing.T, et EngineTester, readMem *wasm.Code) {
-	e := et.NewEngine(api.CoreFeaturesV1)
+	e := et.NewEngine(testCtx, api.CoreFeaturesV1)
 	_, importing, done := setupCallMemTests(t, e, readMem, et.ListenerFactory())
 	defer done()
 
@@ -479,7 +657,7 @@ func RunTestModuleEngine_Call_HostFn(t *testing.T, et EngineTester) {
 }
 
 func runTestModuleEngine_Call_HostFn(t *testing.T, et EngineTester, hostDivBy *wasm.Code) {
-	e := et.NewEngine(api.CoreFeaturesV1)
+	e := et.NewEngine(testCtx, api.CoreFeaturesV1)
 
 	_, imported, importing, done := setupCallTests(t, e, hostDivBy, et.ListenerFactory())
 	defer done()
@@ -532,7 +710,7 @@ func runTestModuleEngine_Call_HostFn(t *testing.T, et EngineTester, hostDivBy *w
 }
 
 func RunTestModuleEngine_Call_Errors(t *testing.T, et EngineTester) {
-	e := et.NewEngine(api.CoreFeaturesV1)
+	e := et.NewEngine(testCtx, api.CoreFeaturesV1)
 
 	_, imported, importing, done := setupCallTests(t, e, hostDivByGo, et.ListenerFactory())
 	defer done()
@@ -549,14 +727,14 @@ func RunTestModuleEngine_Call_Errors(t *testing.T, et EngineTester) {
 			input:       []uint64{},
 			module:      imported.CallCtx,
 			fn:          imported.Exports[divByWasmName].Function,
-			expectedErr: `expected 1 params, but passed 0`,
+			expectedErr: `imported.div_by.wasm(i32) i32: expected 1 params, but passed 0`,
 		},
 		{
 			name:        "wasm function too many parameters",
 			input:       []uint64{1, 2},
 			module:      imported.CallCtx,
 			fn:          imported.Exports[divByWasmName].Function,
-			expectedErr: `expected 1 params, but passed 2`,
+			expectedErr: `imported.div_by.wasm(i32) i32: expected 1 params, but passed 2`,
 		},
 		{
 			name:   "wasm function panics with wasmruntime.Error",
@@ -631,6 +809,593 @@ wasm stack trace:
 	}
 }
 
+// RunTestModuleEngine_RefTypes ensures ref.null, ref.is_null and ref.func work with both funcref and externref,
+// including a funcref global initialized to wasm.GlobalInstanceNullFuncRefValue.
+func RunTestModuleEngine_RefTypes(t *testing.T, et EngineTester) {
+	e := et.NewEngine(testCtx, api.CoreFeaturesV2)
+
+	v_i32 := &wasm.FunctionType{Results: []wasm.ValueType{i32}, ResultNumInUint64: 1}
+	externref_i32 := &wasm.FunctionType{
+		Params:            []wasm.ValueType{wasm.ValueTypeExternref},
+		Results:           []wasm.ValueType{i32},
+		ParamNumInUint64:  1,
+		ResultNumInUint64: 1,
+	}
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{v_i32, externref_i32},
+		FunctionSection: []wasm.Index{0, 0, 0, 1, 0},
+		GlobalSection: []*wasm.Global{
+			{
+				Type: &wasm.GlobalType{ValType: wasm.ValueTypeFuncref},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeRefNull, Data: []byte{wasm.RefTypeFuncref}},
+			},
+		},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // "null_funcref"
+				wasm.OpcodeRefNull, wasm.RefTypeFuncref,
+				wasm.OpcodeRefIsNull,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // "null_externref"
+				wasm.OpcodeRefNull, wasm.RefTypeExternref,
+				wasm.OpcodeRefIsNull,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // "non_null_funcref"
+				wasm.OpcodeRefFunc, 0,
+				wasm.OpcodeRefIsNull,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // "is_null_externref"
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeRefIsNull,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // "null_funcref_global"
+				wasm.OpcodeGlobalGet, 0,
+				wasm.OpcodeRefIsNull,
+				wasm.OpcodeEnd,
+			}},
+		},
+	}
+	m.BuildFunctionDefinitions()
+	err := e.CompileModule(testCtx, m)
+	require.NoError(t, err)
+
+	nullRefVal := wasm.GlobalInstanceNullFuncRefValue
+	module := &wasm.ModuleInstance{
+		Name:    t.Name(),
+		TypeIDs: []wasm.FunctionTypeID{0, 1},
+		Globals: []*wasm.GlobalInstance{
+			{Val: uint64(nullRefVal), Type: m.GlobalSection[0].Type},
+		},
+	}
+	module.Functions = module.BuildFunctions(m, buildListeners(et.ListenerFactory(), m))
+
+	me, err := e.NewModuleEngine(module.Name, m, nil, module.Functions, nil, nil)
+	require.NoError(t, err)
+	me.InitializeFuncrefGlobals(module.Globals)
+	linkModuleToEngine(module, me)
+
+	tests := []struct {
+		name     string
+		funcIdx  wasm.Index
+		params   []uint64
+		expected uint64
+	}{
+		{name: "ref.null funcref", funcIdx: 0, expected: 1},
+		{name: "ref.null externref", funcIdx: 1, expected: 1},
+		{name: "ref.func", funcIdx: 2, expected: 0},
+		{name: "null externref param", funcIdx: 3, params: []uint64{0}, expected: 1},
+		{name: "non-null externref param", funcIdx: 3, params: []uint64{api.EncodeExternref(0xdeadbeef)}, expected: 0},
+		{name: "null funcref global", funcIdx: 4, expected: 1},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			ce, err := me.NewCallEngine(module.CallCtx, module.Functions[tc.funcIdx])
+			require.NoError(t, err)
+
+			results, err := ce.Call(testCtx, module.CallCtx, tc.params)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{tc.expected}, results)
+		})
+	}
+}
+
+// RunTestModuleEngine_NonTrappingFloatToInt ensures the saturating conversions
+// added in CoreFeatureNonTrappingFloatToIntConversion clamp instead of trap.
+func RunTestModuleEngine_NonTrappingFloatToInt(t *testing.T, et EngineTester) {
+	e := et.NewEngine(testCtx, api.CoreFeaturesV2)
+
+	f32_i32 := &wasm.FunctionType{Params: []wasm.ValueType{f32}, Results: []wasm.ValueType{i32}, ParamNumInUint64: 1, ResultNumInUint64: 1}
+	f32_i64 := &wasm.FunctionType{Params: []wasm.ValueType{f32}, Results: []wasm.ValueType{i64}, ParamNumInUint64: 1, ResultNumInUint64: 1}
+	f64_i32 := &wasm.FunctionType{Params: []wasm.ValueType{f64}, Results: []wasm.ValueType{i32}, ParamNumInUint64: 1, ResultNumInUint64: 1}
+	f64_i64 := &wasm.FunctionType{Params: []wasm.ValueType{f64}, Results: []wasm.ValueType{i64}, ParamNumInUint64: 1, ResultNumInUint64: 1}
+
+	ops := []wasm.OpcodeMisc{
+		wasm.OpcodeMiscI32TruncSatF32S, wasm.OpcodeMiscI32TruncSatF32U,
+		wasm.OpcodeMiscI32TruncSatF64S, wasm.OpcodeMiscI32TruncSatF64U,
+		wasm.OpcodeMiscI64TruncSatF32S, wasm.OpcodeMiscI64TruncSatF32U,
+		wasm.OpcodeMiscI64TruncSatF64S, wasm.OpcodeMiscI64TruncSatF64U,
+	}
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{f32_i32, f64_i32, f32_i64, f64_i64},
+		FunctionSection: []wasm.Index{0, 0, 1, 1, 2, 2, 3, 3}, // index-correlated with ops
+	}
+	for _, op := range ops {
+		m.CodeSection = append(m.CodeSection, &wasm.Code{Body: []byte{
+			wasm.OpcodeLocalGet, 0,
+			wasm.OpcodeMiscPrefix, op,
+			wasm.OpcodeEnd,
+		}})
+	}
+	m.BuildFunctionDefinitions()
+	err := e.CompileModule(testCtx, m)
+	require.NoError(t, err)
+
+	module := &wasm.ModuleInstance{Name: t.Name(), TypeIDs: []wasm.FunctionTypeID{0, 1, 2, 3}}
+	module.Functions = module.BuildFunctions(m, buildListeners(et.ListenerFactory(), m))
+
+	me, err := e.NewModuleEngine(module.Name, m, nil, module.Functions, nil, nil)
+	require.NoError(t, err)
+	linkModuleToEngine(module, me)
+
+	nan32, nan64 := api.EncodeF32(float32(math.NaN())), api.EncodeF64(math.NaN())
+	posInf32, posInf64 := api.EncodeF32(float32(math.Inf(1))), api.EncodeF64(math.Inf(1))
+	negInf32, negInf64 := api.EncodeF32(float32(math.Inf(-1))), api.EncodeF64(math.Inf(-1))
+
+	tests := []struct {
+		name     string
+		op       wasm.OpcodeMisc
+		param    uint64
+		expected uint64
+	}{
+		{name: "i32.trunc_sat_f32_s NaN", op: wasm.OpcodeMiscI32TruncSatF32S, param: nan32, expected: 0},
+		{name: "i32.trunc_sat_f32_s +Inf", op: wasm.OpcodeMiscI32TruncSatF32S, param: posInf32, expected: api.EncodeI32(math.MaxInt32)},
+		{name: "i32.trunc_sat_f32_s -Inf", op: wasm.OpcodeMiscI32TruncSatF32S, param: negInf32, expected: api.EncodeI32(math.MinInt32)},
+		{name: "i32.trunc_sat_f32_s too large", op: wasm.OpcodeMiscI32TruncSatF32S, param: api.EncodeF32(3e9), expected: api.EncodeI32(math.MaxInt32)},
+		{name: "i32.trunc_sat_f32_s too small", op: wasm.OpcodeMiscI32TruncSatF32S, param: api.EncodeF32(-3e9), expected: api.EncodeI32(math.MinInt32)},
+		{name: "i32.trunc_sat_f32_s in range", op: wasm.OpcodeMiscI32TruncSatF32S, param: api.EncodeF32(-1.5), expected: api.EncodeI32(-1)},
+		{name: "i32.trunc_sat_f32_u NaN", op: wasm.OpcodeMiscI32TruncSatF32U, param: nan32, expected: 0},
+		{name: "i32.trunc_sat_f32_u +Inf", op: wasm.OpcodeMiscI32TruncSatF32U, param: posInf32, expected: math.MaxUint32},
+		{name: "i32.trunc_sat_f32_u -Inf", op: wasm.OpcodeMiscI32TruncSatF32U, param: negInf32, expected: 0},
+		{name: "i32.trunc_sat_f32_u too large", op: wasm.OpcodeMiscI32TruncSatF32U, param: api.EncodeF32(5e9), expected: math.MaxUint32},
+		{name: "i32.trunc_sat_f32_u negative", op: wasm.OpcodeMiscI32TruncSatF32U, param: api.EncodeF32(-1.5), expected: 0},
+		{name: "i32.trunc_sat_f32_u in range", op: wasm.OpcodeMiscI32TruncSatF32U, param: api.EncodeF32(3e9), expected: 3e9},
+		{name: "i32.trunc_sat_f64_s NaN", op: wasm.OpcodeMiscI32TruncSatF64S, param: nan64, expected: 0},
+		{name: "i32.trunc_sat_f64_s +Inf", op: wasm.OpcodeMiscI32TruncSatF64S, param: posInf64, expected: api.EncodeI32(math.MaxInt32)},
+		{name: "i32.trunc_sat_f64_s -Inf", op: wasm.OpcodeMiscI32TruncSatF64S, param: negInf64, expected: api.EncodeI32(math.MinInt32)},
+		{name: "i32.trunc_sat_f64_s too large", op: wasm.OpcodeMiscI32TruncSatF64S, param: api.EncodeF64(2147483648), expected: api.EncodeI32(math.MaxInt32)},
+		{name: "i32.trunc_sat_f64_s too small", op: wasm.OpcodeMiscI32TruncSatF64S, param: api.EncodeF64(-2147483649), expected: api.EncodeI32(math.MinInt32)},
+		{name: "i32.trunc_sat_f64_s in range", op: wasm.OpcodeMiscI32TruncSatF64S, param: api.EncodeF64(-2147483648.9), expected: api.EncodeI32(math.MinInt32)},
+		{name: "i32.trunc_sat_f64_u NaN", op: wasm.OpcodeMiscI32TruncSatF64U, param: nan64, expected: 0},
+		{name: "i32.trunc_sat_f64_u +Inf", op: wasm.OpcodeMiscI32TruncSatF64U, param: posInf64, expected: math.MaxUint32},
+		{name: "i32.trunc_sat_f64_u -Inf", op: wasm.OpcodeMiscI32TruncSatF64U, param: negInf64, expected: 0},
+		{name: "i32.trunc_sat_f64_u too large", op: wasm.OpcodeMiscI32TruncSatF64U, param: api.EncodeF64(4294967296), expected: math.MaxUint32},
+		{name: "i32.trunc_sat_f64_u negative", op: wasm.OpcodeMiscI32TruncSatF64U, param: api.EncodeF64(-1), expected: 0},
+		{name: "i32.trunc_sat_f64_u in range", op: wasm.OpcodeMiscI32TruncSatF64U, param: api.EncodeF64(4294967295.5), expected: math.MaxUint32},
+		{name: "i64.trunc_sat_f32_s NaN", op: wasm.OpcodeMiscI64TruncSatF32S, param: nan32, expected: 0},
+		{name: "i64.trunc_sat_f32_s +Inf", op: wasm.OpcodeMiscI64TruncSatF32S, param: posInf32, expected: math.MaxInt64},
+		{name: "i64.trunc_sat_f32_s -Inf", op: wasm.OpcodeMiscI64TruncSatF32S, param: negInf32, expected: api.EncodeI64(math.MinInt64)},
+		{name: "i64.trunc_sat_f32_s too large", op: wasm.OpcodeMiscI64TruncSatF32S, param: api.EncodeF32(1e19), expected: math.MaxInt64},
+		{name: "i64.trunc_sat_f32_s too small", op: wasm.OpcodeMiscI64TruncSatF32S, param: api.EncodeF32(-1e19), expected: api.EncodeI64(math.MinInt64)},
+		{name: "i64.trunc_sat_f32_s in range", op: wasm.OpcodeMiscI64TruncSatF32S, param: api.EncodeF32(-1.5), expected: api.EncodeI64(-1)},
+		{name: "i64.trunc_sat_f32_u NaN", op: wasm.OpcodeMiscI64TruncSatF32U, param: nan32, expected: 0},
+		{name: "i64.trunc_sat_f32_u +Inf", op: wasm.OpcodeMiscI64TruncSatF32U, param: posInf32, expected: math.MaxUint64},
+		{name: "i64.trunc_sat_f32_u -Inf", op: wasm.OpcodeMiscI64TruncSatF32U, param: negInf32, expected: 0},
+		{name: "i64.trunc_sat_f32_u too large", op: wasm.OpcodeMiscI64TruncSatF32U, param: api.EncodeF32(2e19), expected: math.MaxUint64},
+		{name: "i64.trunc_sat_f32_u negative", op: wasm.OpcodeMiscI64TruncSatF32U, param: api.EncodeF32(-1.5), expected: 0},
+		{name: "i64.trunc_sat_f32_u in range", op: wasm.OpcodeMiscI64TruncSatF32U, param: api.EncodeF32(1 << 63), expected: 1 << 63},
+		{name: "i64.trunc_sat_f64_s NaN", op: wasm.OpcodeMiscI64TruncSatF64S, param: nan64, expected: 0},
+		{name: "i64.trunc_sat_f64_s +Inf", op: wasm.OpcodeMiscI64TruncSatF64S, param: posInf64, expected: math.MaxInt64},
+		{name: "i64.trunc_sat_f64_s -Inf", op: wasm.OpcodeMiscI64TruncSatF64S, param: negInf64, expected: api.EncodeI64(math.MinInt64)},
+		{name: "i64.trunc_sat_f64_s too large", op: wasm.OpcodeMiscI64TruncSatF64S, param: api.EncodeF64(1e19), expected: math.MaxInt64},
+		{name: "i64.trunc_sat_f64_s too small", op: wasm.OpcodeMiscI64TruncSatF64S, param: api.EncodeF64(-1e19), expected: api.EncodeI64(math.MinInt64)},
+		{name: "i64.trunc_sat_f64_s in range", op: wasm.OpcodeMiscI64TruncSatF64S, param: api.EncodeF64(-1.5), expected: api.EncodeI64(-1)},
+		{name: "i64.trunc_sat_f64_u NaN", op: wasm.OpcodeMiscI64TruncSatF64U, param: nan64, expected: 0},
+		{name: "i64.trunc_sat_f64_u +Inf", op: wasm.OpcodeMiscI64TruncSatF64U, param: posInf64, expected: math.MaxUint64},
+		{name: "i64.trunc_sat_f64_u -Inf", op: wasm.OpcodeMiscI64TruncSatF64U, param: negInf64, expected: 0},
+		{name: "i64.trunc_sat_f64_u too large", op: wasm.OpcodeMiscI64TruncSatF64U, param: api.EncodeF64(2e19), expected: math.MaxUint64},
+		{name: "i64.trunc_sat_f64_u negative", op: wasm.OpcodeMiscI64TruncSatF64U, param: api.EncodeF64(-1.5), expected: 0},
+		{name: "i64.trunc_sat_f64_u in range", op: wasm.OpcodeMiscI64TruncSatF64U, param: api.EncodeF64(1 << 63), expected: 1 << 63},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			var funcIdx wasm.Index
+			for i, op := range ops {
+				if op == tc.op {
+					funcIdx = wasm.Index(i)
+				}
+			}
+			ce, err := me.NewCallEngine(module.CallCtx, module.Functions[funcIdx])
+			require.NoError(t, err)
+
+			results, err := ce.Call(testCtx, module.CallCtx, []uint64{tc.param})
+			require.NoError(t, err)
+			if tc.op <= wasm.OpcodeMiscI32TruncSatF64U {
+				// Only the lower 32 bits of an i32 result are defined.
+				results[0] = uint64(uint32(results[0]))
+			}
+			require.Equal(t, []uint64{tc.expected}, results)
+		})
+	}
+}
+
+// RunTestModuleEngine_WrappingDivision ensures signed division of the minimum
+// value by -1 traps by default, and wraps when wazeroir.WrappingDivisionKey is set.
+func RunTestModuleEngine_WrappingDivision(t *testing.T, et EngineTester) {
+	i32i32_i32 := &wasm.FunctionType{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}, ParamNumInUint64: 2, ResultNumInUint64: 1}
+	i64i64_i64 := &wasm.FunctionType{Params: []wasm.ValueType{i64, i64}, Results: []wasm.ValueType{i64}, ParamNumInUint64: 2, ResultNumInUint64: 1}
+
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{i32i32_i32, i64i64_i64},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32DivS, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI64DivS, wasm.OpcodeEnd}},
+		},
+	}
+	m.BuildFunctionDefinitions()
+
+	tests := []struct {
+		name     string
+		funcIdx  wasm.Index
+		params   []uint64
+		expected uint64
+	}{
+		{name: "i32.div_s", funcIdx: 0, params: []uint64{api.EncodeI32(-7), api.EncodeI32(2)}, expected: api.EncodeI32(-3)},
+		{name: "i32.div_s by -1", funcIdx: 0, params: []uint64{api.EncodeI32(7), api.EncodeI32(-1)}, expected: api.EncodeI32(-7)},
+		{name: "i32.div_s overflow", funcIdx: 0, params: []uint64{api.EncodeI32(math.MinInt32), api.EncodeI32(-1)}, expected: api.EncodeI32(math.MinInt32)},
+		{name: "i64.div_s", funcIdx: 1, params: []uint64{api.EncodeI64(-7), api.EncodeI64(2)}, expected: api.EncodeI64(-3)},
+		{name: "i64.div_s by -1", funcIdx: 1, params: []uint64{api.EncodeI64(7), api.EncodeI64(-1)}, expected: api.EncodeI64(-7)},
+		{name: "i64.div_s overflow", funcIdx: 1, params: []uint64{api.EncodeI64(math.MinInt64), api.EncodeI64(-1)}, expected: api.EncodeI64(math.MinInt64)},
+	}
+
+	for _, wrapping := range []bool{false, true} {
+		ctx := testCtx
+		if wrapping {
+			ctx = context.WithValue(ctx, wazeroir.WrappingDivisionKey{}, true)
+		}
+		e := et.NewEngine(ctx, api.CoreFeaturesV2)
+		err := e.CompileModule(testCtx, m)
+		require.NoError(t, err)
+
+		module := &wasm.ModuleInstance{Name: t.Name(), TypeIDs: []wasm.FunctionTypeID{0, 1}}
+		module.Functions = module.BuildFunctions(m, buildListeners(et.ListenerFactory(), m))
+
+		me, err := e.NewModuleEngine(module.Name, m, nil, module.Functions, nil, nil)
+		require.NoError(t, err)
+		linkModuleToEngine(module, me)
+
+		for _, tt := range tests {
+			tc := tt
+			t.Run(fmt.Sprintf("%s wrapping=%v", tc.name, wrapping), func(t *testing.T) {
+				ce, err := me.NewCallEngine(module.CallCtx, module.Functions[tc.funcIdx])
+				require.NoError(t, err)
+
+				results, err := ce.Call(testCtx, module.CallCtx, tc.params)
+				if !wrapping && strings.HasSuffix(tc.name, "overflow") {
+					require.ErrorIs(t, err, wasmruntime.ErrRuntimeIntegerOverflow)
+					return
+				}
+				require.NoError(t, err)
+				if tc.funcIdx == 0 {
+					// Only the lower 32 bits of an i32 result are defined.
+					results[0] = uint64(uint32(results[0]))
+				}
+				require.Equal(t, []uint64{tc.expected}, results)
+			})
+		}
+	}
+}
+
+// RunTestModuleEngine_BulkMemory ensures memory.copy handles overlapping
+// ranges in both directions, and that memory.copy and memory.fill trap
+// without writing anything when any byte of a range is out of bounds.
+func RunTestModuleEngine_BulkMemory(t *testing.T, et EngineTester) {
+	e := et.NewEngine(testCtx, api.CoreFeaturesV2)
+
+	i32i32i32_v := &wasm.FunctionType{Params: []wasm.ValueType{i32, i32, i32}, ParamNumInUint64: 3}
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{i32i32i32_v},
+		FunctionSection: []wasm.Index{0, 0},
+		MemorySection:   &wasm.Memory{Min: 1, Cap: 1, Max: 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // "copy" (dst, src, n)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 2,
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscMemoryCopy, 0, 0, // memory 0 to memory 0
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // "fill" (dst, val, n)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 2,
+				wasm.OpcodeMiscPrefix, wasm.OpcodeMiscMemoryFill, 0, // memory 0
+				wasm.OpcodeEnd,
+			}},
+		},
+	}
+	m.BuildFunctionDefinitions()
+	err := e.CompileModule(testCtx, m)
+	require.NoError(t, err)
+
+	module := &wasm.ModuleInstance{
+		Name:    t.Name(),
+		Memory:  wasm.NewMemoryInstance(m.MemorySection),
+		TypeIDs: []wasm.FunctionTypeID{0},
+	}
+	module.Functions = module.BuildFunctions(m, buildListeners(et.ListenerFactory(), m))
+
+	me, err := e.NewModuleEngine(module.Name, m, nil, module.Functions, nil, nil)
+	require.NoError(t, err)
+	linkModuleToEngine(module, me)
+
+	mem := module.Memory.Buffer
+	end := uint64(len(mem))
+	pattern := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	tests := []struct {
+		name string
+		// funcIdx is zero for memory.copy and one for memory.fill.
+		funcIdx     wasm.Index
+		params      []uint64
+		expectedErr error
+		// expectedHead and expectedTail are the first and last bytes of memory
+		// after the call.
+		expectedHead, expectedTail []byte
+	}{
+		{
+			name:         "copy forward overlap",
+			params:       []uint64{2, 0, 6},
+			expectedHead: []byte{1, 2, 1, 2, 3, 4, 5, 6},
+			expectedTail: pattern,
+		},
+		{
+			name:         "copy backward overlap",
+			params:       []uint64{0, 2, 6},
+			expectedHead: []byte{3, 4, 5, 6, 7, 8, 7, 8},
+			expectedTail: pattern,
+		},
+		{
+			name:         "copy to the end",
+			params:       []uint64{end - 8, 0, 8},
+			expectedHead: pattern,
+			expectedTail: pattern,
+		},
+		{
+			name:         "copy zero bytes at the end",
+			params:       []uint64{end, end, 0},
+			expectedHead: pattern,
+			expectedTail: pattern,
+		},
+		{
+			name:         "copy destination out of bounds",
+			params:       []uint64{end - 4, 0, 8},
+			expectedErr:  wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess,
+			expectedHead: pattern,
+			expectedTail: pattern,
+		},
+		{
+			name:         "copy source out of bounds",
+			params:       []uint64{0, end - 4, 8},
+			expectedErr:  wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess,
+			expectedHead: pattern,
+			expectedTail: pattern,
+		},
+		{
+			name:         "copy zero bytes past the end",
+			params:       []uint64{end + 1, 0, 0},
+			expectedErr:  wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess,
+			expectedHead: pattern,
+			expectedTail: pattern,
+		},
+		{
+			name:         "fill to the end",
+			funcIdx:      1,
+			params:       []uint64{end - 4, 0xff, 4},
+			expectedHead: pattern,
+			expectedTail: []byte{1, 2, 3, 4, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			name:         "fill zero bytes at the end",
+			funcIdx:      1,
+			params:       []uint64{end, 0xff, 0},
+			expectedHead: pattern,
+			expectedTail: pattern,
+		},
+		{
+			name:         "fill out of bounds",
+			funcIdx:      1,
+			params:       []uint64{end - 4, 0xff, 5},
+			expectedErr:  wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess,
+			expectedHead: pattern,
+			expectedTail: pattern,
+		},
+		{
+			name:         "fill zero bytes past the end",
+			funcIdx:      1,
+			params:       []uint64{end + 1, 0xff, 0},
+			expectedErr:  wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess,
+			expectedHead: pattern,
+			expectedTail: pattern,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			copy(mem, pattern)
+			copy(mem[end-8:], pattern)
+
+			ce, err := me.NewCallEngine(module.CallCtx, module.Functions[tc.funcIdx])
+			require.NoError(t, err)
+
+			_, err = ce.Call(testCtx, module.CallCtx, tc.params)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expectedHead, mem[:8])
+			require.Equal(t, tc.expectedTail, mem[end-8:])
+		})
+	}
+}
+
+// RunTestEngine_FloatConsistency runs a battery of f32 and f64 inputs,
+// including subnormals, signed zeros, infinities and NaN, through arithmetic
+// instructions. Results must be bit-identical to the reference semantics also
+// used by the interpreter, so that engines can't drift from each other.
+//
+// Note: The WebAssembly Core Specification doesn't define the payload of a NaN
+// result, so only NaN-ness is compared in that case.
+func RunTestEngine_FloatConsistency(t *testing.T, et EngineTester) {
+	e := et.NewEngine(testCtx, api.CoreFeaturesV2)
+
+	f32f32_f32 := &wasm.FunctionType{Params: []wasm.ValueType{f32, f32}, Results: []wasm.ValueType{f32}, ParamNumInUint64: 2, ResultNumInUint64: 1}
+	f32_f32 := &wasm.FunctionType{Params: []wasm.ValueType{f32}, Results: []wasm.ValueType{f32}, ParamNumInUint64: 1, ResultNumInUint64: 1}
+	f64f64_f64 := &wasm.FunctionType{Params: []wasm.ValueType{f64, f64}, Results: []wasm.ValueType{f64}, ParamNumInUint64: 2, ResultNumInUint64: 1}
+	f64_f64 := &wasm.FunctionType{Params: []wasm.ValueType{f64}, Results: []wasm.ValueType{f64}, ParamNumInUint64: 1, ResultNumInUint64: 1}
+
+	f32BinOps := map[wasm.Opcode]func(x, y float32) float32{
+		wasm.OpcodeF32Add: func(x, y float32) float32 { return x + y },
+		wasm.OpcodeF32Sub: func(x, y float32) float32 { return x - y },
+		wasm.OpcodeF32Mul: func(x, y float32) float32 { return x * y },
+		wasm.OpcodeF32Div: func(x, y float32) float32 { return x / y },
+		wasm.OpcodeF32Min: moremath.WasmCompatMin32,
+		wasm.OpcodeF32Max: moremath.WasmCompatMax32,
+	}
+	f32UnOps := map[wasm.Opcode]func(x float32) float32{
+		wasm.OpcodeF32Sqrt:    func(x float32) float32 { return float32(math.Sqrt(float64(x))) },
+		wasm.OpcodeF32Nearest: moremath.WasmCompatNearestF32,
+	}
+	f64BinOps := map[wasm.Opcode]func(x, y float64) float64{
+		wasm.OpcodeF64Add: func(x, y float64) float64 { return x + y },
+		wasm.OpcodeF64Sub: func(x, y float64) float64 { return x - y },
+		wasm.OpcodeF64Mul: func(x, y float64) float64 { return x * y },
+		wasm.OpcodeF64Div: func(x, y float64) float64 { return x / y },
+		wasm.OpcodeF64Min: moremath.WasmCompatMin64,
+		wasm.OpcodeF64Max: moremath.WasmCompatMax64,
+	}
+	f64UnOps := map[wasm.Opcode]func(x float64) float64{
+		wasm.OpcodeF64Sqrt:    math.Sqrt,
+		wasm.OpcodeF64Nearest: moremath.WasmCompatNearestF64,
+	}
+
+	f32Inputs := []float32{
+		0, float32(math.Copysign(0, -1)), 1, -1, 0.5, -0.5, 1.5, 2.5, -2.5, 3.4e38, 1e-3,
+		math.Float32frombits(0x0000_0001), // smallest subnormal
+		math.Float32frombits(0x007f_ffff), // largest subnormal
+		math.Float32frombits(0x8000_0001), // negative subnormal
+		math.MaxFloat32, -math.MaxFloat32,
+		float32(math.Inf(1)), float32(math.Inf(-1)), float32(math.NaN()),
+	}
+	f64Inputs := []float64{
+		0, math.Copysign(0, -1), 1, -1, 0.5, -0.5, 1.5, 2.5, -2.5, 1.7e308, 1e-3,
+		math.Float64frombits(0x0000_0000_0000_0001), // smallest subnormal
+		math.Float64frombits(0x000f_ffff_ffff_ffff), // largest subnormal
+		math.Float64frombits(0x8000_0000_0000_0001), // negative subnormal
+		math.MaxFloat64, -math.MaxFloat64,
+		math.Inf(1), math.Inf(-1), math.NaN(),
+	}
+
+	// Define a function per instruction, in a stable order.
+	var ops []wasm.Opcode
+	m := &wasm.Module{TypeSection: []*wasm.FunctionType{f32f32_f32, f32_f32, f64f64_f64, f64_f64}}
+	addFunc := func(typeIdx wasm.Index, op wasm.Opcode, paramCount int) {
+		var body []byte
+		for i := 0; i < paramCount; i++ {
+			body = append(body, wasm.OpcodeLocalGet, byte(i))
+		}
+		ops = append(ops, op)
+		m.FunctionSection = append(m.FunctionSection, typeIdx)
+		m.CodeSection = append(m.CodeSection, &wasm.Code{Body: append(body, op, wasm.OpcodeEnd)})
+	}
+	for _, op := range []wasm.Opcode{wasm.OpcodeF32Add, wasm.OpcodeF32Sub, wasm.OpcodeF32Mul, wasm.OpcodeF32Div, wasm.OpcodeF32Min, wasm.OpcodeF32Max} {
+		addFunc(0, op, 2)
+	}
+	addFunc(1, wasm.OpcodeF32Sqrt, 1)
+	addFunc(1, wasm.OpcodeF32Nearest, 1)
+	for _, op := range []wasm.Opcode{wasm.OpcodeF64Add, wasm.OpcodeF64Sub, wasm.OpcodeF64Mul, wasm.OpcodeF64Div, wasm.OpcodeF64Min, wasm.OpcodeF64Max} {
+		addFunc(2, op, 2)
+	}
+	addFunc(3, wasm.OpcodeF64Sqrt, 1)
+	addFunc(3, wasm.OpcodeF64Nearest, 1)
+
+	m.BuildFunctionDefinitions()
+	err := e.CompileModule(testCtx, m)
+	require.NoError(t, err)
+
+	module := &wasm.ModuleInstance{Name: t.Name(), TypeIDs: []wasm.FunctionTypeID{0, 1, 2, 3}}
+	module.Functions = module.BuildFunctions(m, buildListeners(et.ListenerFactory(), m))
+
+	me, err := e.NewModuleEngine(module.Name, m, nil, module.Functions, nil, nil)
+	require.NoError(t, err)
+	linkModuleToEngine(module, me)
+
+	for i, op := range ops {
+		funcIdx := wasm.Index(i)
+		name := wasm.InstructionName(op)
+		t.Run(name, func(t *testing.T) {
+			ce, err := me.NewCallEngine(module.CallCtx, module.Functions[funcIdx])
+			require.NoError(t, err)
+
+			call := func(params ...uint64) uint64 {
+				results, err := ce.Call(testCtx, module.CallCtx, params)
+				require.NoError(t, err)
+				return results[0]
+			}
+
+			switch {
+			case f32BinOps[op] != nil:
+				for _, x := range f32Inputs {
+					for _, y := range f32Inputs {
+						actual := math.Float32frombits(uint32(call(api.EncodeF32(x), api.EncodeF32(y))))
+						requireSameF32(t, f32BinOps[op](x, y), actual, "%s(%v, %v)", name, x, y)
+					}
+				}
+			case f32UnOps[op] != nil:
+				for _, x := range f32Inputs {
+					actual := math.Float32frombits(uint32(call(api.EncodeF32(x))))
+					requireSameF32(t, f32UnOps[op](x), actual, "%s(%v)", name, x)
+				}
+			case f64BinOps[op] != nil:
+				for _, x := range f64Inputs {
+					for _, y := range f64Inputs {
+						actual := math.Float64frombits(call(api.EncodeF64(x), api.EncodeF64(y)))
+						requireSameF64(t, f64BinOps[op](x, y), actual, "%s(%v, %v)", name, x, y)
+					}
+				}
+			case f64UnOps[op] != nil:
+				for _, x := range f64Inputs {
+					actual := math.Float64frombits(call(api.EncodeF64(x)))
+					requireSameF64(t, f64UnOps[op](x), actual, "%s(%v)", name, x)
+				}
+			}
+		})
+	}
+}
+
+// requireSameF32 requires the floats to have the same bits, or both be NaN.
+func requireSameF32(t *testing.T, expected, actual float32, formatWithArgs ...interface{}) {
+	if math.IsNaN(float64(expected)) {
+		require.True(t, math.IsNaN(float64(actual)), formatWithArgs...)
+		return
+	}
+	require.Equal(t, math.Float32bits(expected), math.Float32bits(actual), formatWithArgs...)
+}
+
+// requireSameF64 requires the floats to have the same bits, or both be NaN.
+func requireSameF64(t *testing.T, expected, actual float64, formatWithArgs ...interface{}) {
+	if math.IsNaN(expected) {
+		require.True(t, math.IsNaN(actual), formatWithArgs...)
+		return
+	}
+	require.Equal(t, math.Float64bits(expected), math.Float64bits(actual), formatWithArgs...)
+}
+
 // RunTestModuleEngine_Memory shows that the byte slice returned from api.Memory Read is not a copy, rather a re-slice
 // of the underlying memory. This allows both host and Wasm to see each other's writes, unless one side changes the
 // capacity of the slice.
@@ -639,7 +1404,7 @@ wasm stack trace:
 // * Host code calls append on a byte slice returned by api.Memory Read
 // * Wasm code calls wasm.OpcodeMemoryGrowName and this changes the capacity (by default, it will).
 func RunTestModuleEngine_Memory(t *testing.T, et EngineTester) {
-	e := et.NewEngine(api.CoreFeaturesV2)
+	e := et.NewEngine(testCtx, api.CoreFeaturesV2)
 
 	wasmPhrase := "Well, that'll be the day when you say goodbye."
 	wasmPhraseSize := uint32(len(wasmPhrase))
@@ -1021,3 +1786,207 @@ func buildListeners(factory experimental.FunctionListenerFactory, m *wasm.Module
 	}
 	return listeners
 }
+
+// RunTestModuleEngine_ExceptionHandling ensures a tag thrown in a try is
+// caught by its catch clauses with the values thrown, and that an exception
+// which isn't caught traps.
+func RunTestModuleEngine_ExceptionHandling(t *testing.T, et EngineTester) {
+	i32_i32 := &wasm.FunctionType{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}, ParamNumInUint64: 1, ResultNumInUint64: 1}
+	i32_v := &wasm.FunctionType{Params: []wasm.ValueType{i32}, ParamNumInUint64: 1}
+	const tryI32 = wasm.ValueTypeI32 // block type of a try with an i32 result.
+
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{i32_i32, i32_v},
+		FunctionSection: []wasm.Index{0, 0, 0, 0, 0},
+		TagSection:      []wasm.Index{1}, // tag[0] has an i32 value.
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // "caught": the thrown value plus one.
+				wasm.OpcodeTry, tryI32,
+				wasm.OpcodeI32Const, 100, // dropped when thrown.
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeThrow, 0,
+				wasm.OpcodeCatch, 0,
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // "conditional": the param if non-zero, otherwise zero.
+				wasm.OpcodeTry, tryI32,
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeIf, 0x40,
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeThrow, 0,
+				wasm.OpcodeEnd,
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeCatch, 0,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // "catch_all": 42, dropping the thrown value.
+				wasm.OpcodeTry, tryI32,
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeThrow, 0,
+				wasm.OpcodeCatchAll,
+				wasm.OpcodeI32Const, 42,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // "nested": the thrown value plus ten, caught by the outer try.
+				wasm.OpcodeTry, tryI32,
+				wasm.OpcodeTry, tryI32,
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeThrow, 0,
+				wasm.OpcodeEnd,
+				wasm.OpcodeCatch, 0,
+				wasm.OpcodeI32Const, 10,
+				wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // "uncaught"
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeThrow, 0,
+				wasm.OpcodeEnd,
+			}},
+		},
+	}
+	m.BuildFunctionDefinitions()
+
+	e := et.NewEngine(testCtx, api.CoreFeaturesV2|api.CoreFeatureExceptionHandling)
+	err := e.CompileModule(testCtx, m)
+	require.NoError(t, err)
+
+	module := &wasm.ModuleInstance{Name: t.Name(), TypeIDs: []wasm.FunctionTypeID{0, 1}}
+	module.Functions = module.BuildFunctions(m, buildListeners(et.ListenerFactory(), m))
+
+	me, err := e.NewModuleEngine(module.Name, m, nil, module.Functions, nil, nil)
+	require.NoError(t, err)
+	linkModuleToEngine(module, me)
+
+	tests := []struct {
+		name     string
+		funcIdx  wasm.Index
+		param    uint64
+		expected uint64
+	}{
+		{name: "caught", funcIdx: 0, param: 41, expected: 42},
+		{name: "conditional thrown", funcIdx: 1, param: 7, expected: 7},
+		{name: "conditional not thrown", funcIdx: 1, param: 0, expected: 0},
+		{name: "catch_all", funcIdx: 2, param: 1, expected: 42},
+		{name: "nested", funcIdx: 3, param: 32, expected: 42},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			ce, err := me.NewCallEngine(module.CallCtx, module.Functions[tc.funcIdx])
+			require.NoError(t, err)
+
+			results, err := ce.Call(testCtx, module.CallCtx, []uint64{tc.param})
+			require.NoError(t, err)
+			require.Equal(t, []uint64{tc.expected}, results)
+		})
+	}
+
+	t.Run("uncaught", func(t *testing.T) {
+		ce, err := me.NewCallEngine(module.CallCtx, module.Functions[4])
+		require.NoError(t, err)
+
+		_, err = ce.Call(testCtx, module.CallCtx, []uint64{1})
+		require.ErrorIs(t, err, wasmruntime.ErrRuntimeUncaughtException)
+	})
+}
+
+// RunTestModuleEngine_RelaxedSIMD tests relaxed vector instructions, which
+// only the interpreter implements. Results of relaxed madd are fused.
+func RunTestModuleEngine_RelaxedSIMD(t *testing.T, et EngineTester) {
+	v_v128 := &wasm.FunctionType{Results: []wasm.ValueType{wasm.ValueTypeV128}, ResultNumInUint64: 2}
+
+	fourI8 := func(b byte) uint64 { return 0x0101010101010101 * uint64(b) }
+	twoI32 := func(v uint32) uint64 { return uint64(v) | uint64(v)<<32 }
+	a := twoI32(0x3f800800) // 1 + 2^-12, so a*a is 1 + 2^-11 + 2^-24
+
+	tests := []struct {
+		name     string
+		op       wasm.OpcodeVecRelaxed
+		operands [][2]uint64
+		expected []uint64
+	}{
+		{
+			name:     "f32x4.relaxed_madd product not rounded",
+			op:       wasm.OpcodeVecF32x4RelaxedMadd,
+			operands: [][2]uint64{{a, a}, {a, a}, {twoI32(0xbf801000), twoI32(0xbf801000)}}, // -(1 + 2^-11)
+			expected: []uint64{twoI32(0x33800000), twoI32(0x33800000)},                      // 2^-24
+		},
+		{
+			// The sum is halfway between two float32 when rounded to float64
+			// first, so this fails unless the sum is rounded once.
+			name:     "f32x4.relaxed_madd sum rounded once",
+			op:       wasm.OpcodeVecF32x4RelaxedMadd,
+			operands: [][2]uint64{{a, a}, {a, a}, {twoI32(0x17800000), twoI32(0x17800000)}}, // 2^-80
+			expected: []uint64{twoI32(0x3f801001), twoI32(0x3f801001)},                      // 1 + 2^-11 + 2^-23
+		},
+		{
+			name:     "f32x4.relaxed_nmadd",
+			op:       wasm.OpcodeVecF32x4RelaxedNmadd,
+			operands: [][2]uint64{{a, a}, {a, a}, {twoI32(0x3f801000), twoI32(0x3f801000)}}, // 1 + 2^-11
+			expected: []uint64{twoI32(0xb3800000), twoI32(0xb3800000)},                      // -2^-24
+		},
+		{
+			// Each i16 lane is 2*-3 + 2*-3.
+			name:     "i16x8.relaxed_dot_i8x16_i7x16_s",
+			op:       wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16S,
+			operands: [][2]uint64{{fourI8(2), fourI8(2)}, {fourI8(0xfd), fourI8(0xfd)}},
+			expected: []uint64{0xfff4_fff4_fff4_fff4, 0xfff4_fff4_fff4_fff4},
+		},
+		{
+			// Each i32 lane is two of the above plus 100.
+			name:     "i32x4.relaxed_dot_i8x16_i7x16_add_s",
+			op:       wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddS,
+			operands: [][2]uint64{{fourI8(2), fourI8(2)}, {fourI8(0xfd), fourI8(0xfd)}, {twoI32(100), twoI32(100)}},
+			expected: []uint64{twoI32(76), twoI32(76)},
+		},
+	}
+
+	m := &wasm.Module{TypeSection: []*wasm.FunctionType{v_v128}}
+	for _, tc := range tests {
+		var body []byte
+		for _, o := range tc.operands {
+			v128Const := make([]byte, 18)
+			v128Const[0], v128Const[1] = wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Const
+			binary.LittleEndian.PutUint64(v128Const[2:], o[0])
+			binary.LittleEndian.PutUint64(v128Const[10:], o[1])
+			body = append(body, v128Const...)
+		}
+		body = append(body, wasm.OpcodeVecPrefix)
+		body = append(body, leb128.EncodeUint32(tc.op)...)
+		m.FunctionSection = append(m.FunctionSection, 0)
+		m.CodeSection = append(m.CodeSection, &wasm.Code{Body: append(body, wasm.OpcodeEnd)})
+	}
+	m.BuildFunctionDefinitions()
+
+	e := et.NewEngine(testCtx, api.CoreFeaturesV2|api.CoreFeatureRelaxedSIMD)
+	err := e.CompileModule(testCtx, m)
+	require.NoError(t, err)
+
+	module := &wasm.ModuleInstance{Name: t.Name(), TypeIDs: []wasm.FunctionTypeID{0}}
+	module.Functions = module.BuildFunctions(m, buildListeners(et.ListenerFactory(), m))
+
+	me, err := e.NewModuleEngine(module.Name, m, nil, module.Functions, nil, nil)
+	require.NoError(t, err)
+	linkModuleToEngine(module, me)
+
+	for i, tt := range tests {
+		tc := tt
+		fn := module.Functions[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ce, err := me.NewCallEngine(module.CallCtx, fn)
+			require.NoError(t, err)
+
+			results, err := ce.Call(testCtx, module.CallCtx, nil)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, results)
+		})
+	}
+}
@@ -11,7 +11,8 @@ import (
 // GetProxyModuleBinary creates the proxy module to proxy a function call against
 // all the exported functions in `proxyTarget`, and returns its encoded binary.
 // The resulting module exports the proxy functions whose names are exactly the same
-// as the proxy destination.
+// as the proxy destination. Each proxy function has the same type as its
+// destination, so functions with multiple results (multi-value) pass through.
 //
 // This is used to test host call implementations.
 func GetProxyModuleBinary(moduleName string, proxyTarget wazero.CompiledModule) []byte {
@@ -46,6 +47,8 @@ func GetProxyModuleBinary(moduleName string, proxyTarget wazero.CompiledModule)
 			body = append(body, leb128.EncodeUint32(uint32(i))...)
 		}
 
+		// Call the imported function, which leaves all its results on the
+		// stack as the results of the proxy function.
 		body = append(body, wasm.OpcodeCall)
 		body = append(body, leb128.EncodeUint32(cnt)...)
 		body = append(body, wasm.OpcodeEnd)
@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+func TestGetProxyModuleBinary(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	swap := func(a, b uint32) (uint32, uint32) {
+		return b, a
+	}
+
+	envCompiled, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(swap).WithParameterNames("a", "b").Export("swap").
+		Compile(testCtx)
+	require.NoError(t, err)
+
+	_, err = r.InstantiateModule(testCtx, envCompiled, wazero.NewModuleConfig())
+	require.NoError(t, err)
+
+	proxyCompiled, err := r.CompileModule(testCtx, GetProxyModuleBinary("env", envCompiled))
+	require.NoError(t, err)
+
+	// The proxy function has the same signature and names as the target.
+	def := proxyCompiled.ExportedFunctions()["swap"]
+	require.Equal(t, envCompiled.ExportedFunctions()["swap"].ParamTypes(), def.ParamTypes())
+	require.Equal(t, envCompiled.ExportedFunctions()["swap"].ResultTypes(), def.ResultTypes())
+	require.Equal(t, []string{"a", "b"}, def.ParamNames())
+	require.Equal(t, "proxy.swap", def.DebugName())
+
+	mod, err := r.InstantiateModule(testCtx, proxyCompiled, wazero.NewModuleConfig())
+	require.NoError(t, err)
+
+	// All results pass through the proxy.
+	results, err := mod.ExportedFunction("swap").Call(testCtx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2, 1}, results)
+}
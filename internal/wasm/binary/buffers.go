@@ -0,0 +1,49 @@
+package binary
+
+import "github.com/tetratelabs/wazero/internal/wasm"
+
+// DecodeBuffers reduces the allocations of DecodeModuleWithBuffers. Scratch
+// slices are reused across calls, and the functions of each code section are
+// allocated together.
+//
+// Note: This is not goroutine-safe, so use one per goroutine, e.g. from a
+// sync.Pool.
+type DecodeBuffers struct {
+	// localCounts and localTypes are scratch for the run-length encoded
+	// locals of a function.
+	localCounts []uint64
+	localTypes  []wasm.ValueType
+
+	// codes and bodies are the unused parts of the code section being
+	// decoded. These are retained by the module, so are reset after the
+	// section.
+	codes  []wasm.Code
+	bodies []byte
+}
+
+// NewDecodeBuffers returns empty DecodeBuffers, which grow on demand.
+func NewDecodeBuffers() *DecodeBuffers {
+	return &DecodeBuffers{}
+}
+
+// newCode returns a zero wasm.Code, allocated with the others in the code
+// section if possible.
+func (b *DecodeBuffers) newCode() *wasm.Code {
+	if b == nil || len(b.codes) == 0 {
+		return &wasm.Code{}
+	}
+	ret := &b.codes[0]
+	b.codes = b.codes[1:]
+	return ret
+}
+
+// newBody returns a function body of the given size, allocated with the others
+// in the code section if possible.
+func (b *DecodeBuffers) newBody(size int64) []byte {
+	if b == nil || int64(len(b.bodies)) < size {
+		return make([]byte, size)
+	}
+	ret := b.bodies[:size:size] // cap, so appending doesn't overwrite the next body.
+	b.bodies = b.bodies[size:]
+	return ret
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
-func decodeCode(r *bytes.Reader) (*wasm.Code, error) {
+func decodeCode(r *bytes.Reader, buffers *DecodeBuffers) (*wasm.Code, error) {
 	ss, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get the size of code: %w", err)
@@ -28,6 +28,12 @@ func decodeCode(r *bytes.Reader) (*wasm.Code, error) {
 
 	var nums []uint64
 	var types []wasm.ValueType
+	if buffers != nil {
+		nums, types = buffers.localCounts[:0], buffers.localTypes[:0]
+		defer func() {
+			buffers.localCounts, buffers.localTypes = nums, types // retain any growth.
+		}()
+	}
 	var sum uint64
 	var n uint32
 	for i := uint32(0); i < ls; i++ {
@@ -60,6 +66,9 @@ func decodeCode(r *bytes.Reader) (*wasm.Code, error) {
 	}
 
 	var localTypes []wasm.ValueType
+	if sum > 0 {
+		localTypes = make([]wasm.ValueType, 0, sum)
+	}
 	for i, num := range nums {
 		t := types[i]
 		for j := uint64(0); j < num; j++ {
@@ -67,7 +76,7 @@ func decodeCode(r *bytes.Reader) (*wasm.Code, error) {
 		}
 	}
 
-	body := make([]byte, remaining)
+	body := buffers.newBody(remaining)
 	if _, err = io.ReadFull(r, body); err != nil {
 		return nil, fmt.Errorf("read body: %w", err)
 	}
@@ -76,7 +85,9 @@ func decodeCode(r *bytes.Reader) (*wasm.Code, error) {
 		return nil, fmt.Errorf("expr not end with OpcodeEnd")
 	}
 
-	return &wasm.Code{Body: body, LocalTypes: localTypes}, nil
+	code := buffers.newCode()
+	code.Body, code.LocalTypes = body, localTypes
+	return code, nil
 }
 
 // encodeCode returns the wasm.Code encoded in WebAssembly 1.0 (20191205) Binary Format.
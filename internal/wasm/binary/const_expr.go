@@ -12,6 +12,8 @@ import (
 )
 
 func decodeConstantExpression(r *bytes.Reader, enabledFeatures api.CoreFeatures) (*wasm.ConstantExpression, error) {
+	offsetAtOpcode := r.Size() - int64(r.Len())
+
 	b, err := r.ReadByte()
 	if err != nil {
 		return nil, fmt.Errorf("read opcode: %v", err)
@@ -93,7 +95,10 @@ func decodeConstantExpression(r *bytes.Reader, enabledFeatures api.CoreFeatures)
 	}
 
 	if b != wasm.OpcodeEnd {
-		return nil, fmt.Errorf("constant expression has been not terminated")
+		if !enabledFeatures.IsEnabled(api.CoreFeatureExtendedConst) || !isExtendedConstOpcode(opcode) {
+			return nil, fmt.Errorf("constant expression has been not terminated")
+		}
+		return decodeExtendedConstantExpression(r, b, offsetAtOpcode)
 	}
 
 	data := make([]byte, remainingBeforeData-int64(r.Len())-1)
@@ -104,7 +109,65 @@ func decodeConstantExpression(r *bytes.Reader, enabledFeatures api.CoreFeatures)
 	return &wasm.ConstantExpression{Opcode: opcode, Data: data}, nil
 }
 
+// isExtendedConstOpcode returns true if the opcode is allowed in a constant
+// expression with more than one instruction, per CoreFeatureExtendedConst.
+func isExtendedConstOpcode(opcode wasm.Opcode) bool {
+	switch opcode {
+	case wasm.OpcodeI32Const, wasm.OpcodeI64Const, wasm.OpcodeGlobalGet,
+		wasm.OpcodeI32Add, wasm.OpcodeI32Sub, wasm.OpcodeI32Mul,
+		wasm.OpcodeI64Add, wasm.OpcodeI64Sub, wasm.OpcodeI64Mul:
+		return true
+	}
+	return false
+}
+
+// decodeExtendedConstantExpression continues decoding a constant expression
+// which began at offsetAtOpcode, when its first instruction is followed by the
+// opcode b instead of end. The result's Opcode is the last instruction, which
+// must be arithmetic, and its Data includes all instructions except end.
+func decodeExtendedConstantExpression(r *bytes.Reader, b byte, offsetAtOpcode int64) (*wasm.ConstantExpression, error) {
+	var err error
+	opcode := b
+	for b != wasm.OpcodeEnd {
+		if !isExtendedConstOpcode(b) {
+			return nil, fmt.Errorf("%v for const expression opt code: %#x", ErrInvalidByte, b)
+		}
+		opcode = b
+		switch b {
+		case wasm.OpcodeI32Const:
+			_, _, err = leb128.DecodeInt32(r)
+		case wasm.OpcodeI64Const:
+			_, _, err = leb128.DecodeInt64(r)
+		case wasm.OpcodeGlobalGet:
+			_, _, err = leb128.DecodeUint32(r)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read value: %v", err)
+		}
+		if b, err = r.ReadByte(); err != nil {
+			return nil, fmt.Errorf("look for end opcode: %v", err)
+		}
+	}
+
+	switch opcode {
+	case wasm.OpcodeI32Const, wasm.OpcodeI64Const, wasm.OpcodeGlobalGet:
+		return nil, fmt.Errorf("constant expression has been not terminated")
+	}
+
+	data := make([]byte, r.Size()-int64(r.Len())-offsetAtOpcode-1)
+	if _, err := r.ReadAt(data, offsetAtOpcode); err != nil {
+		return nil, fmt.Errorf("error re-buffering ConstantExpression.Data")
+	}
+
+	return &wasm.ConstantExpression{Opcode: opcode, Data: data}, nil
+}
+
 func encodeConstantExpression(expr *wasm.ConstantExpression) (ret []byte) {
+	if expr.IsExtended() {
+		ret = append(ret, expr.Data...)
+		ret = append(ret, wasm.OpcodeEnd)
+		return
+	}
 	ret = append(ret, expr.Opcode)
 	ret = append(ret, expr.Data...)
 	ret = append(ret, wasm.OpcodeEnd)
@@ -79,13 +79,29 @@ func TestDecodeConstantExpression(t *testing.T) {
 				},
 			},
 		},
+		{
+			in: []byte{
+				wasm.OpcodeGlobalGet, 0,
+				wasm.OpcodeI32Const, 0x80, 0x01, // 128 in varint encoding.
+				wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+			},
+			exp: &wasm.ConstantExpression{
+				Opcode: wasm.OpcodeI32Add,
+				Data: []byte{
+					wasm.OpcodeGlobalGet, 0,
+					wasm.OpcodeI32Const, 0x80, 0x01,
+					wasm.OpcodeI32Add,
+				},
+			},
+		},
 	}
 
 	for i, tt := range tests {
 		tc := tt
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			actual, err := decodeConstantExpression(bytes.NewReader(tc.in),
-				api.CoreFeatureBulkMemoryOperations|api.CoreFeatureSIMD)
+				api.CoreFeatureBulkMemoryOperations|api.CoreFeatureSIMD|api.CoreFeatureExtendedConst)
 			require.NoError(t, err)
 			require.Equal(t, tc.exp, actual)
 		})
@@ -177,6 +193,35 @@ func TestDecodeConstantExpression_errors(t *testing.T) {
 			expectedErr: "read vector const instruction immediates: needs 16 bytes but was 8 bytes",
 			features:    api.CoreFeatureSIMD,
 		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+			},
+			expectedErr: "constant expression has been not terminated",
+			features:    api.CoreFeaturesV2,
+		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeEnd,
+			},
+			expectedErr: "constant expression has been not terminated",
+			features:    api.CoreFeatureExtendedConst,
+		},
+		{
+			in: []byte{
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Const, 2,
+				wasm.OpcodeI32DivS,
+				wasm.OpcodeEnd,
+			},
+			expectedErr: "invalid byte for const expression opt code: 0x6d",
+			features:    api.CoreFeatureExtendedConst,
+		},
 	}
 
 	for _, tt := range tests {
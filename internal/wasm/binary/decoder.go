@@ -2,6 +2,7 @@ package binary
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 // DecodeModule implements wasm.DecodeModule for the WebAssembly 1.0 (20191205) Binary Format
@@ -19,25 +21,26 @@ func DecodeModule(
 	memoryLimitPages uint32,
 	memoryCapacityFromMax bool,
 ) (*wasm.Module, error) {
-	r := bytes.NewReader(binary)
+	return DecodeModuleWithBuffers(binary, enabledFeatures, memoryLimitPages, memoryCapacityFromMax, nil)
+}
 
-	// Magic number.
-	buf := make([]byte, 4)
-	if _, err := io.ReadFull(r, buf); err != nil || !bytes.Equal(buf, Magic) {
-		return nil, ErrInvalidMagicNumber
-	}
+// DecodeModuleWithBuffers is like DecodeModule, except it uses buffers to
+// reduce allocations, unless nil.
+func DecodeModuleWithBuffers(
+	binary []byte,
+	enabledFeatures api.CoreFeatures,
+	memoryLimitPages uint32,
+	memoryCapacityFromMax bool,
+	buffers *DecodeBuffers,
+) (*wasm.Module, error) {
+	r := bytes.NewReader(binary)
 
-	// Version.
-	if _, err := io.ReadFull(r, buf); err != nil || !bytes.Equal(buf, version) {
-		return nil, ErrInvalidVersion
+	if err := decodeHeader(r); err != nil {
+		return nil, err
 	}
 
-	memorySizer := newMemorySizer(memoryLimitPages, memoryCapacityFromMax)
-
-	m := &wasm.Module{}
+	d := newModuleDecoder(enabledFeatures, memoryLimitPages, memoryCapacityFromMax, buffers)
 	for {
-		// TODO: except custom sections, all others are required to be in order, but we aren't checking yet.
-		// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#modules%E2%91%A0%E2%93%AA
 		sectionID, err := r.ReadByte()
 		if err == io.EOF {
 			break
@@ -45,86 +48,292 @@ func DecodeModule(
 			return nil, fmt.Errorf("read section id: %w", err)
 		}
 
+		sizeOffset := int64(len(binary) - r.Len())
 		sectionSize, _, err := leb128.DecodeUint32(r)
 		if err != nil {
-			return nil, fmt.Errorf("get size of section %s: %v", wasm.SectionIDName(sectionID), err)
-		}
-
-		sectionContentStart := r.Len()
-		switch sectionID {
-		case wasm.SectionIDCustom:
-			// First, validate the section and determine if the section for this name has already been set
-			name, nameSize, decodeErr := decodeUTF8(r, "custom section name")
-			if decodeErr != nil {
-				err = decodeErr
-				break
-			} else if sectionSize < nameSize {
-				err = fmt.Errorf("malformed custom section %s", name)
-				break
-			} else if name == "name" && m.NameSection != nil {
-				err = fmt.Errorf("redundant custom section %s", name)
-				break
-			}
+			return nil, errSectionSize(sectionID, sizeOffset, err)
+		}
 
-			// Now, either decode the NameSection or skip an unsupported one
-			limit := sectionSize - nameSize
-			if name == "name" {
-				m.NameSection, err = decodeNameSection(r, uint64(limit))
-			} else {
-				// Note: Not Seek because it doesn't err when given an offset past EOF. Rather, it leads to undefined state.
-				if _, err = io.CopyN(io.Discard, r, int64(limit)); err != nil {
-					return nil, fmt.Errorf("failed to skip name[%s]: %w", name, err)
-				}
-			}
+		offset := int64(len(binary) - r.Len())
+		if int64(sectionSize) > int64(r.Len()) {
+			return nil, errSectionPastEnd(sectionID, offset)
+		}
+		section := binary[offset : offset+int64(sectionSize)]
+		if err = d.decodeSectionAt(sectionID, section, offset); err != nil {
+			return nil, err
+		}
+		_, _ = r.Seek(int64(sectionSize), io.SeekCurrent)
+	}
+	return d.module()
+}
 
-		case wasm.SectionIDType:
-			m.TypeSection, err = decodeTypeSection(enabledFeatures, r)
-		case wasm.SectionIDImport:
-			if m.ImportSection, err = decodeImportSection(r, memorySizer, memoryLimitPages, enabledFeatures); err != nil {
-				return nil, err // avoid re-wrapping the error.
-			}
-		case wasm.SectionIDFunction:
-			m.FunctionSection, err = decodeFunctionSection(r)
-		case wasm.SectionIDTable:
-			m.TableSection, err = decodeTableSection(r, enabledFeatures)
-		case wasm.SectionIDMemory:
-			m.MemorySection, err = decodeMemorySection(r, memorySizer, memoryLimitPages)
-		case wasm.SectionIDGlobal:
-			if m.GlobalSection, err = decodeGlobalSection(r, enabledFeatures); err != nil {
-				return nil, err // avoid re-wrapping the error.
+// DecodeModuleAt is like DecodeModule, except it reads the binary of the
+// given size from r, one section at a time, so the caller needn't read the
+// whole binary into a []byte first.
+//
+// Note: Each section is read whole before it is decoded, and code and data
+// are copied out of it, so memory use is still about twice the largest
+// section, plus everything decoded so far.
+//
+// The module ID is assigned from the binary. Errors decoding it are
+// sys.DecodeError, as for DecodeModule, and other errors reading r include
+// the offset in it where reading failed.
+func DecodeModuleAt(
+	r io.ReaderAt,
+	size int64,
+	enabledFeatures api.CoreFeatures,
+	memoryLimitPages uint32,
+	memoryCapacityFromMax bool,
+) (*wasm.Module, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("invalid size %d", size)
+	} else if sized, ok := r.(interface{ Size() int64 }); ok && size > sized.Size() {
+		// e.g. bytes.Reader or io.SectionReader, which know their size.
+		return nil, fmt.Errorf("invalid size %d: larger than the binary (%d)", size, sized.Size())
+	}
+
+	hash := sha256.New()
+	var offset int64
+
+	header := make([]byte, 8)
+	if size < int64(len(header)) {
+		header = header[:size]
+	}
+	if n, _ := r.ReadAt(header, 0); n < len(header) {
+		header = header[:n]
+	}
+	if err := decodeHeader(bytes.NewReader(header)); err != nil {
+		return nil, err
+	}
+	hash.Write(header)
+	offset += int64(len(header))
+
+	d := newModuleDecoder(enabledFeatures, memoryLimitPages, memoryCapacityFromMax, nil)
+	for offset < size {
+		// A section header is an ID followed by a size of at most 5 bytes.
+		sectionHeader := make([]byte, 6)
+		if remaining := size - offset; remaining < int64(len(sectionHeader)) {
+			sectionHeader = sectionHeader[:remaining]
+		}
+		if err := readFullAt(r, sectionHeader, offset); err != nil {
+			return nil, fmt.Errorf("at offset %d: read section id: %w", offset, err)
+		}
+
+		sectionID := sectionHeader[0]
+		sectionSize, sizeLen, err := leb128.DecodeUint32(bytes.NewReader(sectionHeader[1:]))
+		if err != nil {
+			return nil, errSectionSize(sectionID, offset+1, err)
+		}
+		hash.Write(sectionHeader[:1+sizeLen])
+		offset += 1 + int64(sizeLen)
+
+		if int64(sectionSize) > size-offset {
+			return nil, errSectionPastEnd(sectionID, offset)
+		}
+		section := make([]byte, sectionSize)
+		if err = readFullAt(r, section, offset); err != nil {
+			return nil, fmt.Errorf("at offset %d: read section %s: %w", offset, wasm.SectionIDName(sectionID), err)
+		}
+		hash.Write(section)
+
+		if err = d.decodeSectionAt(sectionID, section, offset); err != nil {
+			return nil, err
+		}
+		offset += int64(sectionSize)
+	}
+
+	m, err := d.module()
+	if err != nil {
+		return nil, err
+	}
+	hash.Sum(m.ID[:0])
+	return m, nil
+}
+
+// readFullAt is like io.ReadFull, except for an io.ReaderAt.
+func readFullAt(r io.ReaderAt, buf []byte, offset int64) error {
+	n, err := r.ReadAt(buf, offset)
+	if n == len(buf) {
+		return nil // io.ReaderAt can return io.EOF with a full read.
+	} else if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// decodeHeader decodes the magic number and version of a binary.
+//
+// Note: Errors have section ID zero, as the header precedes sections.
+func decodeHeader(r *bytes.Reader) error {
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(r, buf); err != nil || !bytes.Equal(buf, Magic) {
+		return sys.NewDecodeError(0, 0, ErrInvalidMagicNumber)
+	}
+	if _, err := io.ReadFull(r, buf); err != nil || !bytes.Equal(buf, version) {
+		return sys.NewDecodeError(0, int64(len(Magic)), ErrInvalidVersion)
+	}
+	return nil
+}
+
+// errSectionSize returns the error when the size of a section, which begins
+// at offset, couldn't be decoded.
+func errSectionSize(sectionID wasm.SectionID, offset int64, err error) error {
+	return sys.NewDecodeError(sectionID, offset,
+		fmt.Errorf("get size of section %s: %w", wasm.SectionIDName(sectionID), err))
+}
+
+// errSectionPastEnd returns the error when the contents of a section, which
+// begin at offset, are larger than the rest of the binary.
+func errSectionPastEnd(sectionID wasm.SectionID, offset int64) error {
+	return sys.NewDecodeError(sectionID, offset,
+		fmt.Errorf("section %s: %w", wasm.SectionIDName(sectionID), io.ErrUnexpectedEOF))
+}
+
+// moduleDecoder decodes sections into a wasm.Module.
+type moduleDecoder struct {
+	m                *wasm.Module
+	enabledFeatures  api.CoreFeatures
+	memoryLimitPages uint32
+	memorySizer      memorySizer
+	buffers          *DecodeBuffers
+}
+
+func newModuleDecoder(
+	enabledFeatures api.CoreFeatures,
+	memoryLimitPages uint32,
+	memoryCapacityFromMax bool,
+	buffers *DecodeBuffers,
+) *moduleDecoder {
+	return &moduleDecoder{
+		m:                &wasm.Module{},
+		enabledFeatures:  enabledFeatures,
+		memoryLimitPages: memoryLimitPages,
+		memorySizer:      newMemorySizer(memoryLimitPages, memoryCapacityFromMax),
+		buffers:          buffers,
+	}
+}
+
+// decodeSectionAt decodes the contents of a section, which begin at offset in
+// the binary, returning a sys.DecodeError if they are malformed.
+//
+// Note: Sections are decoded from their own reader, so that the offset of an
+// error is the same whether the binary is read at once or one section at a
+// time.
+func (d *moduleDecoder) decodeSectionAt(sectionID wasm.SectionID, section []byte, offset int64) error {
+	sr := bytes.NewReader(section)
+	if err := d.decodeSection(sr, sectionID, uint32(len(section))); err != nil {
+		return sys.NewDecodeError(sectionID, offset+int64(len(section)-sr.Len()), err)
+	}
+	return nil
+}
+
+// decodeSection decodes the section of the given ID and size, whose contents
+// begin at the current position of r.
+func (d *moduleDecoder) decodeSection(r *bytes.Reader, sectionID wasm.SectionID, sectionSize uint32) (err error) {
+	m, enabledFeatures, memoryLimitPages := d.m, d.enabledFeatures, d.memoryLimitPages
+
+	// TODO: except custom sections, all others are required to be in order, but we aren't checking yet.
+	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#modules%E2%91%A0%E2%93%AA
+	sectionContentStart := r.Len()
+	switch sectionID {
+	case wasm.SectionIDCustom:
+		// First, validate the section and determine if the section for this name has already been set
+		name, nameSize, decodeErr := decodeUTF8(r, "custom section name")
+		if decodeErr != nil {
+			err = decodeErr
+			break
+		} else if sectionSize < nameSize {
+			err = fmt.Errorf("malformed custom section %s", name)
+			break
+		} else if name == "name" && m.NameSection != nil {
+			err = fmt.Errorf("redundant custom section %s", name)
+			break
+		} else if name == "dylink.0" && m.DylinkSection != nil {
+			err = fmt.Errorf("redundant custom section %s", name)
+			break
+		}
+
+		// Now, either decode the NameSection or skip an unsupported one
+		limit := sectionSize - nameSize
+		if name == "name" {
+			m.NameSection, err = decodeNameSection(r, uint64(limit))
+		} else if name == "producers" && m.ProducersSection == nil {
+			buf := make([]byte, limit)
+			if _, err = io.ReadFull(r, buf); err != nil {
+				return fmt.Errorf("failed to read name[%s]: %w", name, err)
 			}
-		case wasm.SectionIDExport:
-			m.ExportSection, err = decodeExportSection(r)
-		case wasm.SectionIDStart:
-			if m.StartSection != nil {
-				return nil, errors.New("multiple start sections are invalid")
+			// A malformed producers section is ignored, as it doesn't affect execution.
+			m.ProducersSection, _ = decodeProducersSection(buf)
+		} else if name == "dylink.0" {
+			buf := make([]byte, limit)
+			if _, err = io.ReadFull(r, buf); err != nil {
+				return fmt.Errorf("failed to read name[%s]: %w", name, err)
 			}
-			m.StartSection, err = decodeStartSection(r)
-		case wasm.SectionIDElement:
-			m.ElementSection, err = decodeElementSection(r, enabledFeatures)
-		case wasm.SectionIDCode:
-			m.CodeSection, err = decodeCodeSection(r)
-		case wasm.SectionIDData:
-			m.DataSection, err = decodeDataSection(r, enabledFeatures)
-		case wasm.SectionIDDataCount:
-			if err := enabledFeatures.RequireEnabled(api.CoreFeatureBulkMemoryOperations); err != nil {
-				return nil, fmt.Errorf("data count section not supported as %v", err)
+			m.DylinkSection, err = decodeDylinkSection(buf)
+		} else {
+			// Note: Not Seek because it doesn't err when given an offset past EOF. Rather, it leads to undefined state.
+			if _, err = io.CopyN(io.Discard, r, int64(limit)); err != nil {
+				return fmt.Errorf("failed to skip name[%s]: %w", name, err)
 			}
-			m.DataCountSection, err = decodeDataCountSection(r)
-		default:
-			err = ErrInvalidSectionID
 		}
 
-		readBytes := sectionContentStart - r.Len()
-		if err == nil && int(sectionSize) != readBytes {
-			err = fmt.Errorf("invalid section length: expected to be %d but got %d", sectionSize, readBytes)
+	case wasm.SectionIDType:
+		m.TypeSection, err = decodeTypeSection(enabledFeatures, r)
+	case wasm.SectionIDImport:
+		if m.ImportSection, err = decodeImportSection(r, d.memorySizer, memoryLimitPages, enabledFeatures); err != nil {
+			return err // avoid re-wrapping the error.
 		}
-
-		if err != nil {
-			return nil, fmt.Errorf("section %s: %v", wasm.SectionIDName(sectionID), err)
+	case wasm.SectionIDFunction:
+		m.FunctionSection, err = decodeFunctionSection(r)
+	case wasm.SectionIDTable:
+		m.TableSection, err = decodeTableSection(r, enabledFeatures)
+	case wasm.SectionIDMemory:
+		m.MemorySection, err = decodeMemorySection(r, d.memorySizer, memoryLimitPages, enabledFeatures)
+	case wasm.SectionIDGlobal:
+		if m.GlobalSection, err = decodeGlobalSection(r, enabledFeatures); err != nil {
+			return err // avoid re-wrapping the error.
+		}
+	case wasm.SectionIDExport:
+		m.ExportSection, err = decodeExportSection(r)
+	case wasm.SectionIDStart:
+		if m.StartSection != nil {
+			return errors.New("multiple start sections are invalid")
+		}
+		m.StartSection, err = decodeStartSection(r)
+	case wasm.SectionIDElement:
+		m.ElementSection, err = decodeElementSection(r, enabledFeatures)
+	case wasm.SectionIDCode:
+		m.CodeSection, err = decodeCodeSection(r, sectionSize, d.buffers)
+	case wasm.SectionIDData:
+		m.DataSection, err = decodeDataSection(r, enabledFeatures)
+	case wasm.SectionIDDataCount:
+		if err := enabledFeatures.RequireEnabled(api.CoreFeatureBulkMemoryOperations); err != nil {
+			return fmt.Errorf("data count section not supported as %v", err)
+		}
+		m.DataCountSection, err = decodeDataCountSection(r)
+	case wasm.SectionIDTag:
+		if err := enabledFeatures.RequireEnabled(api.CoreFeatureExceptionHandling); err != nil {
+			return fmt.Errorf("tag section not supported as %v", err)
 		}
+		m.TagSection, err = decodeTagSection(r)
+	default:
+		err = ErrInvalidSectionID
 	}
 
+	readBytes := sectionContentStart - r.Len()
+	if err == nil && int(sectionSize) != readBytes {
+		err = fmt.Errorf("invalid section length: expected to be %d but got %d", sectionSize, readBytes)
+	}
+
+	if err != nil {
+		return fmt.Errorf("section %s: %w", wasm.SectionIDName(sectionID), err)
+	}
+	return nil
+}
+
+// module returns the decoded module, after checking sections are consistent.
+func (d *moduleDecoder) module() (*wasm.Module, error) {
+	m := d.m
 	functionCount, codeCount := m.SectionElementCount(wasm.SectionIDFunction), m.SectionElementCount(wasm.SectionIDCode)
 	if functionCount != codeCount {
 		return nil, fmt.Errorf("function and code section have inconsistent lengths: %d != %d", functionCount, codeCount)
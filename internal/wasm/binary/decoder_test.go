@@ -1,11 +1,15 @@
 package binary
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 // TestDecodeModule relies on unit tests for Module.Encode, specifically that the encoding is both known and correct.
@@ -111,12 +115,202 @@ func TestDecodeModule(t *testing.T) {
 		require.NoError(t, e)
 		require.Equal(t, &wasm.Module{NameSection: &wasm.NameSection{ModuleName: "simple"}}, m)
 	})
+	t.Run("producers section", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDCustom, 0x18, // 24 bytes in this section
+			0x09, 'p', 'r', 'o', 'd', 'u', 'c', 'e', 'r', 's',
+			1, // 1 field
+			0x03, 's', 'd', 'k',
+			1, // 1 value
+			0x02, 'g', 'o', 0x04, '1', '.', '1', '9')
+		m, e := DecodeModule(input, api.CoreFeaturesV1, wasm.MemoryLimitPages, false)
+		require.NoError(t, e)
+		require.Equal(t, &wasm.Module{ProducersSection: []api.Producer{{Field: "sdk", Name: "go", Version: "1.19"}}}, m)
+	})
+	t.Run("ignores malformed producers section", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDCustom, 0x0c, // 12 bytes in this section
+			0x09, 'p', 'r', 'o', 'd', 'u', 'c', 'e', 'r', 's',
+			1, 0xff) // 1 field, but its name size is truncated
+		m, e := DecodeModule(input, api.CoreFeaturesV1, wasm.MemoryLimitPages, false)
+		require.NoError(t, e)
+		require.Equal(t, &wasm.Module{}, m)
+	})
+	t.Run("dylink.0 section", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDCustom, 0x0f, // 15 bytes in this section
+			0x08, 'd', 'y', 'l', 'i', 'n', 'k', '.', '0',
+			subsectionIDDylinkMemInfo, 0x04, // 4 bytes in this subsection
+			0x10, 2, 1, 0)
+		m, e := DecodeModule(input, api.CoreFeaturesV1, wasm.MemoryLimitPages, false)
+		require.NoError(t, e)
+		require.Equal(t, &wasm.Module{DylinkSection: &api.DylinkInfo{MemorySize: 16, MemoryAlignment: 2, TableSize: 1}}, m)
+	})
+	t.Run("malformed dylink.0 section", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDCustom, 0x0b, // 11 bytes in this section
+			0x08, 'd', 'y', 'l', 'i', 'n', 'k', '.', '0',
+			subsectionIDDylinkMemInfo, 0x04) // 4 bytes in this subsection, but none follow
+		_, e := DecodeModule(input, api.CoreFeaturesV1, wasm.MemoryLimitPages, false)
+		require.EqualError(t, e, "section custom: subsection[1] size 4 exceeds the remaining 0 bytes")
+	})
 	t.Run("data count section disabled", func(t *testing.T) {
 		input := append(append(Magic, version...),
 			wasm.SectionIDDataCount, 1, 0)
 		_, e := DecodeModule(input, api.CoreFeaturesV1, wasm.MemoryLimitPages, false)
 		require.EqualError(t, e, `data count section not supported as feature "bulk-memory-operations" is disabled`)
 	})
+	t.Run("tag section", func(t *testing.T) {
+		m := &wasm.Module{
+			TypeSection: []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}}},
+			TagSection:  []wasm.Index{0, 0},
+		}
+		input := EncodeModule(m)
+		actual, e := DecodeModule(input, api.CoreFeaturesV2|api.CoreFeatureExceptionHandling, wasm.MemoryLimitPages, false)
+		require.NoError(t, e)
+		require.Equal(t, m, actual)
+	})
+	t.Run("tag section disabled", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDTag, 1, 0)
+		_, e := DecodeModule(input, api.CoreFeaturesV2, wasm.MemoryLimitPages, false)
+		require.EqualError(t, e, `tag section not supported as feature "exception-handling" is disabled`)
+	})
+	t.Run("tag section invalid attribute", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDTag, 3, 1, 1, 0)
+		_, e := DecodeModule(input, api.CoreFeaturesV2|api.CoreFeatureExceptionHandling, wasm.MemoryLimitPages, false)
+		require.EqualError(t, e, "section tag: invalid attribute of tag[0]: 0x1 != 0x0")
+	})
+}
+
+func TestDecodeModuleWithBuffers(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0, 0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}},
+			{LocalTypes: []wasm.ValueType{i32, i32, wasm.ValueTypeI64}, Body: []byte{wasm.OpcodeLocalGet, 1, wasm.OpcodeEnd}},
+			{LocalTypes: []wasm.ValueType{wasm.ValueTypeF32}, Body: []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeEnd}},
+		},
+	}
+	input := EncodeModule(m)
+
+	buffers := NewDecodeBuffers()
+	var decoded []*wasm.Module
+	for i := 0; i < 2; i++ { // reuse the buffers
+		actual, err := DecodeModuleWithBuffers(input, api.CoreFeaturesV2, wasm.MemoryLimitPages, false, buffers)
+		require.NoError(t, err)
+		require.Equal(t, m, actual)
+		decoded = append(decoded, actual)
+	}
+
+	// Modules don't share memory with each other or the buffers.
+	require.Nil(t, buffers.codes)
+	require.Nil(t, buffers.bodies)
+	decoded[0].CodeSection[1].LocalTypes[0] = wasm.ValueTypeF64
+	decoded[0].CodeSection[1].Body[1] = 0
+	require.Equal(t, m, decoded[1])
+
+	// Appending to a body doesn't overwrite the next one.
+	decoded[1].CodeSection[0].Body = append(decoded[1].CodeSection[0].Body, wasm.OpcodeNop)
+	require.Equal(t, m.CodeSection[1], decoded[1].CodeSection[1])
+}
+
+func TestDecodeModuleAt(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0},
+		MemorySection:   &wasm.Memory{Min: 1, Cap: 1, Max: wasm.MemoryLimitPages},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}}},
+		DataSection: []*wasm.DataSegment{{
+			OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:             []byte("hello"),
+		}},
+		NameSection: &wasm.NameSection{ModuleName: "at"},
+	}
+	input := EncodeModule(m)
+
+	actual, err := DecodeModuleAt(bytes.NewReader(input), int64(len(input)), api.CoreFeaturesV2, wasm.MemoryLimitPages, false)
+	require.NoError(t, err)
+
+	expected, err := DecodeModule(input, api.CoreFeaturesV2, wasm.MemoryLimitPages, false)
+	require.NoError(t, err)
+	expected.AssignModuleID(input)
+	require.Equal(t, expected, actual)
+}
+
+func TestDecodeModuleAt_Errors(t *testing.T) {
+	header := append(Magic, version...)
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr string
+	}{
+		{
+			name:        "empty",
+			input:       []byte{},
+			expectedErr: "invalid magic number",
+		},
+		{
+			name:        "wrong version",
+			input:       []byte("\x00asm\x01\x00\x00\x01"),
+			expectedErr: "invalid version header",
+		},
+		{
+			name:        "section size past end",
+			input:       append(header, wasm.SectionIDType, 4, 1, 0x60),
+			expectedErr: "section type: unexpected EOF",
+		},
+		{
+			name: "invalid section contents",
+			input: append(header,
+				wasm.SectionIDType, 4, 1, 0x60, 0, 0,
+				wasm.SectionIDFunction, 2, 1, 0x80, // unterminated LEB128
+			),
+			expectedErr: "section function: get type index: EOF",
+		},
+		{
+			name: "multiple start sections",
+			input: append(header,
+				wasm.SectionIDType, 4, 1, 0x60, 0, 0,
+				wasm.SectionIDFunction, 2, 1, 0,
+				wasm.SectionIDCode, 4, 1,
+				2, 0, wasm.OpcodeEnd,
+				wasm.SectionIDStart, 1, 0,
+				wasm.SectionIDStart, 1, 0,
+			),
+			expectedErr: "multiple start sections are invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeModuleAt(bytes.NewReader(tc.input), int64(len(tc.input)), api.CoreFeaturesV2, wasm.MemoryLimitPages, false)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+
+	t.Run("negative size", func(t *testing.T) {
+		_, err := DecodeModuleAt(bytes.NewReader(header), -1, api.CoreFeaturesV2, wasm.MemoryLimitPages, false)
+		require.EqualError(t, err, "invalid size -1")
+	})
+
+	t.Run("size larger than the binary", func(t *testing.T) {
+		_, err := DecodeModuleAt(bytes.NewReader(header), 9, api.CoreFeaturesV2, wasm.MemoryLimitPages, false)
+		require.EqualError(t, err, "invalid size 9: larger than the binary (8)")
+	})
+
+	t.Run("size larger than the binary - unknown size", func(t *testing.T) {
+		// Hide the Size method, like os.File, so the section header isn't found.
+		r := struct{ io.ReaderAt }{bytes.NewReader(header)}
+		_, err := DecodeModuleAt(r, 9, api.CoreFeaturesV2, wasm.MemoryLimitPages, false)
+		require.EqualError(t, err, "at offset 8: read section id: unexpected EOF")
+	})
 }
 
 func TestDecodeModule_Errors(t *testing.T) {
@@ -169,3 +363,98 @@ func TestDecodeModule_Errors(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeModule_DecodeError(t *testing.T) {
+	header := append(Magic, version...)
+	tests := []struct {
+		name              string
+		input             []byte
+		expectedSectionID wasm.SectionID
+		expectedOffset    int64
+		expectedErr       string
+		expectedCause     error
+	}{
+		{
+			name:           "invalid magic number",
+			input:          []byte("wasm\x01\x00\x00\x00"),
+			expectedOffset: 0,
+			expectedErr:    "invalid magic number",
+			expectedCause:  ErrInvalidMagicNumber,
+		},
+		{
+			name:           "invalid version",
+			input:          []byte("\x00asm\x01\x00\x00\x01"),
+			expectedOffset: 4,
+			expectedErr:    "invalid version header",
+			expectedCause:  ErrInvalidVersion,
+		},
+		{
+			name:              "truncated section size",
+			input:             append(header, wasm.SectionIDCode, 0x80),
+			expectedSectionID: wasm.SectionIDCode,
+			expectedOffset:    9,
+			expectedErr:       "get size of section code: EOF",
+			expectedCause:     io.EOF,
+		},
+		{
+			name:              "truncated section",
+			input:             append(header, wasm.SectionIDType, 4, 1, 0x60),
+			expectedSectionID: wasm.SectionIDType,
+			expectedOffset:    10,
+			expectedErr:       "section type: unexpected EOF",
+			expectedCause:     io.ErrUnexpectedEOF,
+		},
+		{
+			name: "malformed section",
+			input: append(header,
+				wasm.SectionIDType, 4, 1, 0x60, 0, 0,
+				wasm.SectionIDFunction, 2, 1, 0x80, // unterminated LEB128
+			),
+			expectedSectionID: wasm.SectionIDFunction,
+			expectedOffset:    18,
+			expectedErr:       "section function: get type index: EOF",
+		},
+		{
+			name: "section contents longer than its size",
+			input: append(header,
+				wasm.SectionIDType, 3, 1, 0x60, 0, // result count is past the section
+				0,
+			),
+			expectedSectionID: wasm.SectionIDType,
+			expectedOffset:    13,
+			expectedErr:       "section type: read 0-th type: could not read result count: EOF",
+		},
+		{
+			name:              "invalid section id",
+			input:             append(header, 0x0e, 0),
+			expectedSectionID: 0x0e,
+			expectedOffset:    10,
+			expectedErr:       "section unknown: invalid section id",
+			expectedCause:     ErrInvalidSectionID,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			requireDecodeError := func(t *testing.T, err error) {
+				var decodeErr *sys.DecodeError
+				require.True(t, errors.As(err, &decodeErr))
+				require.Equal(t, tc.expectedSectionID, decodeErr.SectionID())
+				require.Equal(t, tc.expectedOffset, decodeErr.Offset())
+				require.EqualError(t, err, tc.expectedErr)
+				if tc.expectedCause != nil {
+					require.True(t, errors.Is(err, tc.expectedCause))
+				}
+			}
+
+			_, err := DecodeModule(tc.input, api.CoreFeaturesV2, wasm.MemoryLimitPages, false)
+			requireDecodeError(t, err)
+
+			// Decoding a section at a time results in the same error.
+			_, err = DecodeModuleAt(bytes.NewReader(tc.input), int64(len(tc.input)), api.CoreFeaturesV2, wasm.MemoryLimitPages, false)
+			requireDecodeError(t, err)
+		})
+	}
+}
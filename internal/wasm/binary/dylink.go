@@ -0,0 +1,92 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+const (
+	// subsectionIDDylinkMemInfo is WASM_DYLINK_MEM_INFO
+	subsectionIDDylinkMemInfo = uint8(1)
+	// subsectionIDDylinkNeeded is WASM_DYLINK_NEEDED
+	subsectionIDDylinkNeeded = uint8(2)
+)
+
+// decodeDylinkSection deserializes the data associated with the "dylink.0"
+// key in SectionIDCustom. Subsections other than memory info and needed
+// libraries, such as export or import info, are skipped.
+//
+// See https://github.com/WebAssembly/tool-conventions/blob/main/DynamicLinking.md
+func decodeDylinkSection(data []byte) (*api.DylinkInfo, error) {
+	r := bytes.NewReader(data)
+	result := &api.DylinkInfo{}
+	for {
+		subsectionID, err := r.ReadByte()
+		if err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read a subsection ID: %w", err)
+		}
+
+		subsectionSize, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the size of subsection[%d]: %w", subsectionID, err)
+		} else if int(subsectionSize) > r.Len() {
+			return nil, fmt.Errorf("subsection[%d] size %d exceeds the remaining %d bytes", subsectionID, subsectionSize, r.Len())
+		}
+
+		subsectionStart := r.Len()
+		switch subsectionID {
+		case subsectionIDDylinkMemInfo:
+			err = decodeDylinkMemInfo(r, result)
+		case subsectionIDDylinkNeeded:
+			result.Needed, err = decodeDylinkNeeded(r)
+		default: // skip unknown subsections
+			_, err = r.Seek(int64(subsectionSize), io.SeekCurrent)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read subsection[%d]: %w", subsectionID, err)
+		}
+
+		if read := subsectionStart - r.Len(); read != int(subsectionSize) {
+			return nil, fmt.Errorf("subsection[%d] size %d but read %d bytes", subsectionID, subsectionSize, read)
+		}
+	}
+}
+
+func decodeDylinkMemInfo(r *bytes.Reader, result *api.DylinkInfo) (err error) {
+	if result.MemorySize, _, err = leb128.DecodeUint32(r); err != nil {
+		return fmt.Errorf("memory size: %w", err)
+	}
+	if result.MemoryAlignment, _, err = leb128.DecodeUint32(r); err != nil {
+		return fmt.Errorf("memory alignment: %w", err)
+	}
+	if result.TableSize, _, err = leb128.DecodeUint32(r); err != nil {
+		return fmt.Errorf("table size: %w", err)
+	}
+	if result.TableAlignment, _, err = leb128.DecodeUint32(r); err != nil {
+		return fmt.Errorf("table alignment: %w", err)
+	}
+	return
+}
+
+func decodeDylinkNeeded(r *bytes.Reader) ([]string, error) {
+	count, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("needed count: %w", err)
+	}
+
+	var needed []string
+	for i := uint32(0); i < count; i++ {
+		name, _, err := decodeUTF8(r, "needed[%d]", i)
+		if err != nil {
+			return nil, err
+		}
+		needed = append(needed, name)
+	}
+	return needed, nil
+}
@@ -0,0 +1,105 @@
+package binary
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestDecodeDylinkSection(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected *api.DylinkInfo
+	}{
+		{
+			name:     "empty",
+			input:    []byte{},
+			expected: &api.DylinkInfo{},
+		},
+		{
+			name: "mem info",
+			input: []byte{
+				subsectionIDDylinkMemInfo, 0x05, // 5 bytes in this subsection
+				0x80, 0x01, // memory size 128
+				3, // memory alignment 2^3
+				4, // table size
+				0, // table alignment 2^0
+			},
+			expected: &api.DylinkInfo{MemorySize: 128, MemoryAlignment: 3, TableSize: 4},
+		},
+		{
+			name: "side module",
+			input: []byte{
+				subsectionIDDylinkMemInfo, 0x04, // 4 bytes in this subsection
+				0x10, 2, 1, 0,
+				subsectionIDDylinkNeeded, 0x15, // 21 bytes in this subsection
+				2, // 2 libraries
+				0x09, 'l', 'i', 'b', 'a', '.', 'w', 'a', 's', 'm',
+				0x09, 'l', 'i', 'b', 'b', '.', 'w', 'a', 's', 'm',
+				3, 0x01, // WASM_DYLINK_EXPORT_INFO is skipped
+				0, // no exports
+			},
+			expected: &api.DylinkInfo{
+				MemorySize:      16,
+				MemoryAlignment: 2,
+				TableSize:       1,
+				Needed:          []string{"liba.wasm", "libb.wasm"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			dylink, err := decodeDylinkSection(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, dylink)
+		})
+	}
+}
+
+func TestDecodeDylinkSection_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr string
+	}{
+		{
+			name:        "EOF reading subsection size",
+			input:       []byte{subsectionIDDylinkMemInfo},
+			expectedErr: "failed to read the size of subsection[1]: EOF",
+		},
+		{
+			name:        "subsection size past end",
+			input:       []byte{3, 0x02, 0},
+			expectedErr: "subsection[3] size 2 exceeds the remaining 1 bytes",
+		},
+		{
+			name:        "EOF reading table alignment",
+			input:       []byte{subsectionIDDylinkMemInfo, 0x03, 0x10, 2, 1},
+			expectedErr: "failed to read subsection[1]: table alignment: EOF",
+		},
+		{
+			name:        "EOF reading needed",
+			input:       []byte{subsectionIDDylinkNeeded, 0x02, 1, 0x03},
+			expectedErr: "failed to read subsection[2]: failed to read needed[0]: EOF",
+		},
+		{
+			name:        "subsection size mismatch",
+			input:       []byte{subsectionIDDylinkMemInfo, 0x05, 0x10, 2, 1, 0, 0},
+			expectedErr: "subsection[1] size 5 but read 4 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := decodeDylinkSection(tc.input)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
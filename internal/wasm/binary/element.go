@@ -287,8 +287,15 @@ func decodeElementSegment(r *bytes.Reader, enabledFeatures api.CoreFeatures) (*w
 // https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#element-section%E2%91%A0
 func encodeElement(e *wasm.ElementSegment) (ret []byte) {
 	if e.Mode == wasm.ElementModeActive {
-		ret = append(ret, leb128.EncodeInt32(int32(e.TableIndex))...)
-		ret = append(ret, encodeConstantExpression(e.OffsetExpr)...)
+		if e.TableIndex == 0 {
+			ret = append(ret, elementSegmentPrefixLegacy)
+			ret = append(ret, encodeConstantExpression(e.OffsetExpr)...)
+		} else {
+			ret = append(ret, elementSegmentPrefixActiveFuncrefValueVectorWithTableIndex)
+			ret = append(ret, leb128.EncodeUint32(e.TableIndex)...)
+			ret = append(ret, encodeConstantExpression(e.OffsetExpr)...)
+			ret = append(ret, 0) // elemkind is funcref.
+		}
 		ret = append(ret, leb128.EncodeUint32(uint32(len(e.Init)))...)
 		for _, idx := range e.Init {
 			ret = append(ret, leb128.EncodeInt32(int32(*idx))...)
@@ -441,3 +441,45 @@ func TestDecodeElementSegment_errors(t *testing.T) {
 	_, err := decodeElementSegment(bytes.NewReader([]byte{1}), api.CoreFeatureMultiValue)
 	require.EqualError(t, err, `non-zero prefix for element segment is invalid as feature "bulk-memory-operations" is disabled`)
 }
+
+func TestEncodeElement(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    *wasm.ElementSegment
+		expected []byte
+	}{
+		{
+			name: "table 0",
+			input: &wasm.ElementSegment{
+				OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{1}},
+				Init:       []*wasm.Index{uint32Ptr(1), uint32Ptr(2)},
+				Mode:       wasm.ElementModeActive,
+				Type:       wasm.RefTypeFuncref,
+			},
+			expected: []byte{0, wasm.OpcodeI32Const, 1, wasm.OpcodeEnd, 2, 1, 2},
+		},
+		{
+			name: "non-zero table",
+			input: &wasm.ElementSegment{
+				OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{1}},
+				TableIndex: 10,
+				Init:       []*wasm.Index{uint32Ptr(1), uint32Ptr(2)},
+				Mode:       wasm.ElementModeActive,
+				Type:       wasm.RefTypeFuncref,
+			},
+			expected: []byte{2, 10, wasm.OpcodeI32Const, 1, wasm.OpcodeEnd, 0, 2, 1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			encoded := encodeElement(tc.input)
+			require.Equal(t, tc.expected, encoded)
+
+			decoded, err := decodeElementSegment(bytes.NewReader(encoded), api.CoreFeaturesV2)
+			require.NoError(t, err)
+			require.Equal(t, tc.input, decoded)
+		})
+	}
+}
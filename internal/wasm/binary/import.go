@@ -36,7 +36,7 @@ func decodeImport(
 	case wasm.ExternTypeTable:
 		i.DescTable, err = decodeTable(r, enabledFeatures)
 	case wasm.ExternTypeMemory:
-		i.DescMem, err = decodeMemory(r, memorySizer, memoryLimitPages)
+		i.DescMem, err = decodeMemory(r, memorySizer, memoryLimitPages, enabledFeatures)
 	case wasm.ExternTypeGlobal:
 		i.DescGlobal, err = decodeGlobalType(r)
 	default:
@@ -60,13 +60,13 @@ func encodeImport(i *wasm.Import) []byte {
 		data = append(data, leb128.EncodeUint32(i.DescFunc)...)
 	case wasm.ExternTypeTable:
 		data = append(data, wasm.RefTypeFuncref)
-		data = append(data, encodeLimitsType(i.DescTable.Min, i.DescTable.Max)...)
+		data = append(data, encodeLimitsType(i.DescTable.Min, i.DescTable.Max, false, false)...)
 	case wasm.ExternTypeMemory:
 		maxPtr := &i.DescMem.Max
 		if !i.DescMem.IsMaxEncoded {
 			maxPtr = nil
 		}
-		data = append(data, encodeLimitsType(i.DescMem.Min, maxPtr)...)
+		data = append(data, encodeLimitsType(i.DescMem.Min, maxPtr, i.DescMem.IsShared, i.DescMem.Is64)...)
 	case wasm.ExternTypeGlobal:
 		g := i.DescGlobal
 		var mutable byte
@@ -3,50 +3,97 @@ package binary
 import (
 	"bytes"
 	"fmt"
+	"math"
 
 	"github.com/tetratelabs/wazero/internal/leb128"
 )
 
 // decodeLimitsType returns the `limitsType` (min, max) decoded with the WebAssembly 1.0 (20191205) Binary Format.
+// shared is true when the flag has the shared bit (0x02) of the threads proposal, which is only valid for memories.
+// is64 is true when the flag has the 64-bit index bit (0x04) of the memory64 proposal, which is only valid for
+// memories. In this case, min and max are decoded as 64-bit, but must fit in 32-bits.
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#limits%E2%91%A6
-func decodeLimitsType(r *bytes.Reader) (min uint32, max *uint32, err error) {
+// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md#spec-changes
+// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md#binary-format
+func decodeLimitsType(r *bytes.Reader) (min uint32, max *uint32, shared, is64 bool, err error) {
 	var flag byte
 	if flag, err = r.ReadByte(); err != nil {
 		err = fmt.Errorf("read leading byte: %v", err)
 		return
 	}
 
+	decode := decodeLimit
+	if flag&0x04 != 0 {
+		is64 = true
+		decode = decodeLimit64
+		flag &^= 0x04
+	}
+
 	switch flag {
 	case 0x00:
-		min, _, err = leb128.DecodeUint32(r)
+		min, err = decode(r)
 		if err != nil {
 			err = fmt.Errorf("read min of limit: %v", err)
 		}
-	case 0x01:
-		min, _, err = leb128.DecodeUint32(r)
+	case 0x02:
+		err = fmt.Errorf("shared memory must have a max")
+	case 0x01, 0x03:
+		shared = flag == 0x03
+		min, err = decode(r)
 		if err != nil {
 			err = fmt.Errorf("read min of limit: %v", err)
 			return
 		}
 		var m uint32
-		if m, _, err = leb128.DecodeUint32(r); err != nil {
+		if m, err = decode(r); err != nil {
 			err = fmt.Errorf("read max of limit: %v", err)
 		} else {
 			max = &m
 		}
 	default:
-		err = fmt.Errorf("%v for limits: %#x != 0x00 or 0x01", ErrInvalidByte, flag)
+		if is64 {
+			flag |= 0x04
+		}
+		err = fmt.Errorf("%v for limits: %#x not in (0x00, 0x01, 0x03, 0x04, 0x05, 0x07)", ErrInvalidByte, flag)
 	}
 	return
 }
 
+func decodeLimit(r *bytes.Reader) (v uint32, err error) {
+	v, _, err = leb128.DecodeUint32(r)
+	return
+}
+
+func decodeLimit64(r *bytes.Reader) (uint32, error) {
+	v, _, err := leb128.DecodeUint64(r)
+	if err != nil {
+		return 0, err
+	} else if v > math.MaxUint32 {
+		return 0, fmt.Errorf("%d pages exceeds %d", v, uint32(math.MaxUint32))
+	}
+	return uint32(v), nil
+}
+
 // encodeLimitsType returns the `limitsType` (min, max) encoded in WebAssembly 1.0 (20191205) Binary Format.
+// shared sets the shared bit of the threads proposal, and is ignored when max is nil. is64 sets the 64-bit index bit
+// of the memory64 proposal.
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#limits%E2%91%A6
-func encodeLimitsType(min uint32, max *uint32) []byte {
-	if max == nil {
-		return append(leb128.EncodeUint32(0x00), leb128.EncodeUint32(min)...)
+func encodeLimitsType(min uint32, max *uint32, shared, is64 bool) []byte {
+	flag := uint32(0x00)
+	if max != nil {
+		flag = 0x01
+		if shared {
+			flag = 0x03
+		}
+	}
+	if is64 {
+		flag |= 0x04
+	}
+	ret := append(leb128.EncodeUint32(flag), leb128.EncodeUint32(min)...)
+	if max != nil {
+		ret = append(ret, leb128.EncodeUint32(*max)...)
 	}
-	return append(leb128.EncodeUint32(0x01), append(leb128.EncodeUint32(min), leb128.EncodeUint32(*max)...)...)
+	return ret
 }
@@ -17,6 +17,8 @@ func TestLimitsType(t *testing.T) {
 		name     string
 		min      uint32
 		max      *uint32
+		shared   bool
+		is64     bool
 		expected []byte
 	}{
 		{
@@ -44,21 +46,79 @@ func TestLimitsType(t *testing.T) {
 			max:      &largest,
 			expected: []byte{0x1, 0xff, 0xff, 0xff, 0xff, 0xf, 0xff, 0xff, 0xff, 0xff, 0xf},
 		},
+		{
+			name:     "shared min 0, max largest",
+			max:      &largest,
+			shared:   true,
+			expected: []byte{0x3, 0, 0xff, 0xff, 0xff, 0xff, 0xf},
+		},
+		{
+			name:     "64-bit min 0",
+			is64:     true,
+			expected: []byte{0x4, 0},
+		},
+		{
+			name:     "64-bit shared min 0, max largest",
+			max:      &largest,
+			shared:   true,
+			is64:     true,
+			expected: []byte{0x7, 0, 0xff, 0xff, 0xff, 0xff, 0xf},
+		},
 	}
 
 	for _, tt := range tests {
 		tc := tt
 
-		b := encodeLimitsType(tc.min, tc.max)
+		b := encodeLimitsType(tc.min, tc.max, tc.shared, tc.is64)
 		t.Run(fmt.Sprintf("encode - %s", tc.name), func(t *testing.T) {
 			require.Equal(t, tc.expected, b)
 		})
 
 		t.Run(fmt.Sprintf("decode - %s", tc.name), func(t *testing.T) {
-			min, max, err := decodeLimitsType(bytes.NewReader(b))
+			min, max, shared, is64, err := decodeLimitsType(bytes.NewReader(b))
 			require.NoError(t, err)
 			require.Equal(t, min, tc.min)
 			require.Equal(t, max, tc.max)
+			require.Equal(t, shared, tc.shared)
+			require.Equal(t, is64, tc.is64)
+		})
+	}
+}
+
+func TestDecodeLimitsType_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr string
+	}{
+		{
+			name:        "shared without max",
+			input:       []byte{0x2, 0},
+			expectedErr: "shared memory must have a max",
+		},
+		{
+			name:        "invalid flag",
+			input:       []byte{0x8, 0},
+			expectedErr: "invalid byte for limits: 0x8 not in (0x00, 0x01, 0x03, 0x04, 0x05, 0x07)",
+		},
+		{
+			name:        "invalid 64-bit flag",
+			input:       []byte{0xc, 0},
+			expectedErr: "invalid byte for limits: 0xc not in (0x00, 0x01, 0x03, 0x04, 0x05, 0x07)",
+		},
+		{
+			name:        "64-bit min over 32 bits",
+			input:       []byte{0x4, 0x80, 0x80, 0x80, 0x80, 0x10},
+			expectedErr: "read min of limit: 4294967296 pages exceeds 4294967295",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, _, _, err := decodeLimitsType(bytes.NewReader(tc.input))
+			require.EqualError(t, err, tc.expectedErr)
 		})
 	}
 }
@@ -2,7 +2,9 @@ package binary
 
 import (
 	"bytes"
+	"fmt"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
@@ -13,14 +15,24 @@ func decodeMemory(
 	r *bytes.Reader,
 	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
 	memoryLimitPages uint32,
+	enabledFeatures api.CoreFeatures,
 ) (*wasm.Memory, error) {
-	min, maxP, err := decodeLimitsType(r)
+	min, maxP, shared, is64, err := decodeLimitsType(r)
 	if err != nil {
 		return nil, err
+	} else if is64 {
+		if err = enabledFeatures.RequireEnabled(api.CoreFeatureMemory64); err != nil {
+			return nil, fmt.Errorf("64-bit memory invalid as %w", err)
+		}
+	}
+	if shared {
+		if err = enabledFeatures.RequireEnabled(api.CoreFeatureThreads); err != nil {
+			return nil, fmt.Errorf("shared memory invalid as %w", err)
+		}
 	}
 
 	min, capacity, max := memorySizer(min, maxP)
-	mem := &wasm.Memory{Min: min, Cap: capacity, Max: max, IsMaxEncoded: maxP != nil}
+	mem := &wasm.Memory{Min: min, Cap: capacity, Max: max, IsMaxEncoded: maxP != nil, IsShared: shared, Is64: is64}
 
 	return mem, mem.Validate(memoryLimitPages)
 }
@@ -33,5 +45,5 @@ func encodeMemory(i *wasm.Memory) []byte {
 	if !i.IsMaxEncoded {
 		maxPtr = nil
 	}
-	return encodeLimitsType(i.Min, maxPtr)
+	return encodeLimitsType(i.Min, maxPtr, i.IsShared, i.Is64)
 }
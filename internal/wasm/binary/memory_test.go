@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -122,6 +123,16 @@ func TestMemoryType(t *testing.T) {
 			input:    &wasm.Memory{Min: max, Cap: max, Max: max, IsMaxEncoded: true},
 			expected: []byte{0x1, 0x80, 0x80, 0x4, 0x80, 0x80, 0x4},
 		},
+		{
+			name:     "shared",
+			input:    &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true, IsShared: true},
+			expected: []byte{0x3, 1, 2},
+		},
+		{
+			name:     "64-bit",
+			input:    &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true, Is64: true},
+			expected: []byte{0x5, 1, 2},
+		},
 	}
 
 	for _, tt := range tests {
@@ -133,7 +144,8 @@ func TestMemoryType(t *testing.T) {
 		})
 
 		t.Run(fmt.Sprintf("decode %s", tc.name), func(t *testing.T) {
-			binary, err := decodeMemory(bytes.NewReader(b), newMemorySizer(max, false), max)
+			binary, err := decodeMemory(bytes.NewReader(b), newMemorySizer(max, false), max,
+				api.CoreFeaturesV2|api.CoreFeatureMemory64|api.CoreFeatureThreads)
 			require.NoError(t, err)
 			require.Equal(t, binary, tc.input)
 		})
@@ -163,13 +175,23 @@ func TestDecodeMemoryType_Errors(t *testing.T) {
 			input:       []byte{0x1, 0, 0xff, 0xff, 0xff, 0xff, 0xf},
 			expectedErr: "max 4294967295 pages (3 Ti) over limit of 65536 pages (4 Gi)",
 		},
+		{
+			name:        "64-bit disabled",
+			input:       []byte{0x4, 0},
+			expectedErr: "64-bit memory invalid as feature \"memory64\" is disabled",
+		},
+		{
+			name:        "shared disabled",
+			input:       []byte{0x3, 1, 2},
+			expectedErr: "shared memory invalid as feature \"threads\" is disabled",
+		},
 	}
 
 	for _, tt := range tests {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := decodeMemory(bytes.NewReader(tc.input), newMemorySizer(max, false), max)
+			_, err := decodeMemory(bytes.NewReader(tc.input), newMemorySizer(max, false), max, api.CoreFeaturesV2)
 			require.EqualError(t, err, tc.expectedErr)
 		})
 	}
@@ -17,6 +17,16 @@ const (
 	// subsectionIDLocalNames contain a map of function indices to a map of local indices to their names, in ascending
 	// order by function and local index
 	subsectionIDLocalNames = uint8(2)
+
+	// The below subsections are from the extended name section proposal, and
+	// each is a map of indices to names in the respective index namespace.
+	// Others in the proposal, such as global names, are skipped, as no API
+	// exposes them.
+	//
+	// See https://github.com/WebAssembly/extended-name-section/blob/main/proposals/extended-name-section/Overview.md
+
+	subsectionIDTableNames  = uint8(5)
+	subsectionIDMemoryNames = uint8(6)
 )
 
 // decodeNameSection deserializes the data associated with the "name" key in SectionIDCustom according to the
@@ -25,6 +35,7 @@ const (
 // * ModuleName decode from subsection 0
 // * FunctionNames decode from subsection 1
 // * LocalNames decode from subsection 2
+// * TableNames and MemoryNames decode from subsections 5 and 6, and are skipped if malformed
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-namesec
 func decodeNameSection(r *bytes.Reader, limit uint64) (result *wasm.NameSection, err error) {
@@ -65,6 +76,10 @@ func decodeNameSection(r *bytes.Reader, limit uint64) (result *wasm.NameSection,
 			if result.LocalNames, err = decodeLocalNames(r); err != nil {
 				return nil, err
 			}
+		case subsectionIDTableNames, subsectionIDMemoryNames:
+			if err = decodeExtendedNames(r, subsectionID, subsectionSize, result); err != nil {
+				return nil, err
+			}
 		default: // Skip other subsections.
 			// Note: Not Seek because it doesn't err when given an offset past EOF. Rather, it leads to undefined state.
 			if _, err = io.CopyN(io.Discard, r, int64(subsectionSize)); err != nil {
@@ -134,6 +149,51 @@ func decodeLocalNames(r *bytes.Reader) (wasm.IndirectNameMap, error) {
 	return result, nil
 }
 
+// decodeExtendedNames decodes a subsection of the extended name section into
+// the corresponding field of the result. A malformed subsection is skipped,
+// as names only affect debugging.
+func decodeExtendedNames(r *bytes.Reader, subsectionID uint8, subsectionSize uint32, result *wasm.NameSection) error {
+	buf := make([]byte, subsectionSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return fmt.Errorf("failed to read subsection[%d]: %w", subsectionID, err)
+	}
+
+	names, err := decodeNameMap(bytes.NewReader(buf))
+	if err != nil {
+		return nil
+	}
+
+	switch subsectionID {
+	case subsectionIDTableNames:
+		result.TableNames = names
+	case subsectionIDMemoryNames:
+		result.MemoryNames = names
+	}
+	return nil
+}
+
+func decodeNameMap(r *bytes.Reader) (wasm.NameMap, error) {
+	count, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the count: %w", err)
+	}
+
+	var result wasm.NameMap
+	for i := uint32(0); i < count; i++ {
+		index, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read an index: %w", err)
+		}
+
+		name, _, err := decodeUTF8(r, "name[%d]", index)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &wasm.NameAssoc{Index: index, Name: name})
+	}
+	return result, nil
+}
+
 func decodeFunctionIndex(r *bytes.Reader, subsectionID uint8) (uint32, error) {
 	functionIndex, _, err := leb128.DecodeUint32(r)
 	if err != nil {
@@ -166,6 +226,17 @@ func encodeNameSectionData(n *wasm.NameSection) (data []byte) {
 	if ld := encodeLocalNameData(n); len(ld) > 0 {
 		data = append(data, encodeNameSubsection(subsectionIDLocalNames, ld)...)
 	}
+	for _, sub := range []struct {
+		id    uint8
+		names wasm.NameMap
+	}{
+		{subsectionIDTableNames, n.TableNames},
+		{subsectionIDMemoryNames, n.MemoryNames},
+	} {
+		if len(sub.names) > 0 {
+			data = append(data, encodeNameSubsection(sub.id, encodeNameMap(sub.names))...)
+		}
+	}
 	return
 }
 
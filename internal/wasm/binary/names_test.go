@@ -197,6 +197,14 @@ func TestDecodeNameSection(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "table and memory names",
+			input: &wasm.NameSection{
+				ModuleName:  "simple",
+				TableNames:  wasm.NameMap{{Index: wasm.Index(1), Name: "refs"}},
+				MemoryNames: wasm.NameMap{{Index: wasm.Index(0), Name: "mem"}},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -211,6 +219,32 @@ func TestDecodeNameSection(t *testing.T) {
 	}
 }
 
+func TestDecodeNameSection_MalformedExtendedNames(t *testing.T) {
+	input := []byte{
+		subsectionIDModuleName, 0x07, 0x06, 's', 'i', 'm', 'p', 'l', 'e',
+		subsectionIDMemoryNames, 0x03, 0x01, 0x00, 0x05, // name size 5, but the subsection ends
+		subsectionIDTableNames, 0x05, 0x01, 0x00, 0x02, 't', '0',
+	}
+	ns, err := decodeNameSection(bytes.NewReader(input), uint64(len(input)))
+	require.NoError(t, err)
+	require.Equal(t, &wasm.NameSection{
+		ModuleName: "simple",
+		TableNames: wasm.NameMap{{Index: wasm.Index(0), Name: "t0"}},
+	}, ns)
+}
+
+func TestDecodeNameSection_SkipsGlobalNames(t *testing.T) {
+	input := []byte{
+		7, 0x05, 0x01, 0x00, 0x02, 's', 'p', // global names
+		subsectionIDMemoryNames, 0x06, 0x01, 0x00, 0x03, 'm', 'e', 'm',
+	}
+	ns, err := decodeNameSection(bytes.NewReader(input), uint64(len(input)))
+	require.NoError(t, err)
+	require.Equal(t, &wasm.NameSection{
+		MemoryNames: wasm.NameMap{{Index: wasm.Index(0), Name: "mem"}},
+	}, ns)
+}
+
 func TestDecodeNameSection_Errors(t *testing.T) {
 	// currently, we ignore the size of known subsections
 	ignoredSubsectionSize := byte(50)
@@ -236,8 +270,8 @@ func TestDecodeNameSection_Errors(t *testing.T) {
 		},
 		{
 			name:        "EOF after unknown subsection ID",
-			input:       []byte{4},
-			expectedErr: "failed to read the size of subsection[4]: EOF",
+			input:       []byte{3},
+			expectedErr: "failed to read the size of subsection[3]: EOF",
 		},
 		{
 			name:        "EOF after module name subsection size",
@@ -256,8 +290,13 @@ func TestDecodeNameSection_Errors(t *testing.T) {
 		},
 		{
 			name:        "EOF skipping unknown subsection size",
-			input:       []byte{4, 100},
-			expectedErr: "failed to skip subsection[4]: EOF",
+			input:       []byte{3, 100},
+			expectedErr: "failed to skip subsection[3]: EOF",
+		},
+		{
+			name:        "EOF reading memory names subsection",
+			input:       []byte{subsectionIDMemoryNames, 100},
+			expectedErr: "failed to read subsection[6]: EOF",
 		},
 		{
 			name:        "EOF after module name size",
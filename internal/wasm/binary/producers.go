@@ -0,0 +1,53 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// decodeProducersSection deserializes the data associated with the
+// "producers" key in SectionIDCustom, flattening each field's versioned
+// names into an api.Producer.
+//
+// See https://github.com/WebAssembly/tool-conventions/blob/main/ProducersSection.md
+func decodeProducersSection(data []byte) ([]api.Producer, error) {
+	r := bytes.NewReader(data)
+
+	fieldCount, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the field count: %w", err)
+	}
+
+	var result []api.Producer
+	for i := uint32(0); i < fieldCount; i++ {
+		field, _, err := decodeUTF8(r, "field[%d] name", i)
+		if err != nil {
+			return nil, err
+		}
+
+		valueCount, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the value count of field[%s]: %w", field, err)
+		}
+
+		for j := uint32(0); j < valueCount; j++ {
+			name, _, err := decodeUTF8(r, "field[%s] value[%d] name", field, j)
+			if err != nil {
+				return nil, err
+			}
+			version, _, err := decodeUTF8(r, "field[%s] value[%d] version", field, j)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, api.Producer{Field: field, Name: name, Version: version})
+		}
+	}
+
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d unexpected bytes after fields", r.Len())
+	}
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package binary
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestDecodeProducersSection(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected []api.Producer
+	}{
+		{
+			name:  "empty",
+			input: []byte{0},
+		},
+		{
+			name: "fields",
+			input: []byte{
+				2, // 2 fields
+				0x08, 'l', 'a', 'n', 'g', 'u', 'a', 'g', 'e',
+				1, // 1 value
+				0x04, 'R', 'u', 's', 't', 0x04, '1', '.', '6', '5',
+				0x0c, 'p', 'r', 'o', 'c', 'e', 's', 's', 'e', 'd', '-', 'b', 'y',
+				2,                                   // 2 values
+				0x05, 'r', 'u', 's', 't', 'c', 0x00, // empty version
+				0x08, 'w', 'a', 's', 'm', '-', 'o', 'p', 't', 0x03, '1', '1', '0',
+			},
+			expected: []api.Producer{
+				{Field: "language", Name: "Rust", Version: "1.65"},
+				{Field: "processed-by", Name: "rustc"},
+				{Field: "processed-by", Name: "wasm-opt", Version: "110"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			producers, err := decodeProducersSection(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, producers)
+		})
+	}
+}
+
+func TestDecodeProducersSection_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr string
+	}{
+		{
+			name:        "EOF reading field count",
+			input:       []byte{},
+			expectedErr: "failed to read the field count: EOF",
+		},
+		{
+			name:        "EOF reading field name",
+			input:       []byte{1, 0x03, 's', 'd'},
+			expectedErr: "failed to read field[0] name: unexpected EOF",
+		},
+		{
+			name:        "EOF reading value count",
+			input:       []byte{1, 0x03, 's', 'd', 'k'},
+			expectedErr: "failed to read the value count of field[sdk]: EOF",
+		},
+		{
+			name:        "EOF reading version",
+			input:       []byte{1, 0x03, 's', 'd', 'k', 1, 0x01, 'x'},
+			expectedErr: "failed to read field[sdk] value[0] version size: EOF",
+		},
+		{
+			name:        "trailing bytes",
+			input:       []byte{0, 0},
+			expectedErr: "1 unexpected bytes after fields",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := decodeProducersSection(tc.input)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -86,6 +86,7 @@ func decodeMemorySection(
 	r *bytes.Reader,
 	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
 	memoryLimitPages uint32,
+	enabledFeatures api.CoreFeatures,
 ) (*wasm.Memory, error) {
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
@@ -98,7 +99,7 @@ func decodeMemorySection(
 		return nil, nil
 	}
 
-	return decodeMemory(r, memorySizer, memoryLimitPages)
+	return decodeMemory(r, memorySizer, memoryLimitPages, enabledFeatures)
 }
 
 func decodeGlobalSection(r *bytes.Reader, enabledFeatures api.CoreFeatures) ([]*wasm.Global, error) {
@@ -162,15 +163,25 @@ func decodeElementSection(r *bytes.Reader, enabledFeatures api.CoreFeatures) ([]
 	return result, nil
 }
 
-func decodeCodeSection(r *bytes.Reader) ([]*wasm.Code, error) {
+func decodeCodeSection(r *bytes.Reader, sectionSize uint32, buffers *DecodeBuffers) ([]*wasm.Code, error) {
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
 	}
 
+	// Allocate all codes together, and their bodies from one slice the size of
+	// the section, which is larger than their sum. This checks the counts are
+	// possible first, so a malformed module can't cause a large allocation.
+	if buffers != nil && int(vs) <= r.Len() && int64(sectionSize) <= r.Size() {
+		buffers.codes, buffers.bodies = make([]wasm.Code, vs), make([]byte, sectionSize)
+		defer func() {
+			buffers.codes, buffers.bodies = nil, nil
+		}()
+	}
+
 	result := make([]*wasm.Code, vs)
 	for i := uint32(0); i < vs; i++ {
-		if result[i], err = decodeCode(r); err != nil {
+		if result[i], err = decodeCode(r, buffers); err != nil {
 			return nil, fmt.Errorf("read %d-th code segment: %v", i, err)
 		}
 	}
@@ -201,6 +212,29 @@ func decodeDataCountSection(r *bytes.Reader) (count *uint32, err error) {
 	return &v, nil
 }
 
+// decodeTagSection decodes the type index of each tag, after its attribute,
+// which must be zero (exception).
+func decodeTagSection(r *bytes.Reader) ([]wasm.Index, error) {
+	vs, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("get size of vector: %w", err)
+	}
+
+	result := make([]wasm.Index, vs)
+	for i := uint32(0); i < vs; i++ {
+		attribute, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("read attribute of tag[%d]: %w", i, err)
+		} else if attribute != 0 {
+			return nil, fmt.Errorf("invalid attribute of tag[%d]: %#x != 0x0", i, attribute)
+		}
+		if result[i], _, err = leb128.DecodeUint32(r); err != nil {
+			return nil, fmt.Errorf("get type index of tag[%d]: %w", i, err)
+		}
+	}
+	return result, nil
+}
+
 // encodeSection encodes the sectionID, the size of its contents in bytes, followed by the contents.
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#sections%E2%91%A0
 func encodeSection(sectionID wasm.SectionID, contents []byte) []byte {
@@ -315,6 +349,19 @@ func encodeStartSection(funcidx wasm.Index) []byte {
 	return encodeSection(wasm.SectionIDStart, leb128.EncodeUint32(funcidx))
 }
 
+// encodeTagSection encodes a wasm.SectionIDTag for the type indices of each
+// tag, which all have the exception attribute.
+//
+// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/legacy/Exceptions.md#tag-section
+func encodeTagSection(typeIndices []wasm.Index) []byte {
+	contents := leb128.EncodeUint32(uint32(len(typeIndices)))
+	for _, index := range typeIndices {
+		contents = append(contents, 0) // exception attribute
+		contents = append(contents, leb128.EncodeUint32(index)...)
+	}
+	return encodeSection(wasm.SectionIDTag, contents)
+}
+
 // encodeEelementSection encodes a wasm.SectionIDElement for the elements in WebAssembly 1.0 (20191205)
 // Binary Format.
 //
@@ -23,9 +23,13 @@ func decodeTable(r *bytes.Reader, enabledFeatures api.CoreFeatures) (*wasm.Table
 		}
 	}
 
-	min, max, err := decodeLimitsType(r)
+	min, max, shared, is64, err := decodeLimitsType(r)
 	if err != nil {
 		return nil, fmt.Errorf("read limits: %v", err)
+	} else if shared {
+		return nil, fmt.Errorf("tables cannot be shared")
+	} else if is64 {
+		return nil, fmt.Errorf("tables cannot be 64-bit")
 	}
 	if min > wasm.MaximumFunctionIndex {
 		return nil, fmt.Errorf("table min must be at most %d", wasm.MaximumFunctionIndex)
@@ -42,5 +46,5 @@ func decodeTable(r *bytes.Reader, enabledFeatures api.CoreFeatures) (*wasm.Table
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-table
 func encodeTable(i *wasm.Table) []byte {
-	return append([]byte{i.Type}, encodeLimitsType(i.Min, i.Max)...)
+	return append([]byte{i.Type}, encodeLimitsType(i.Min, i.Max, false, false)...)
 }
@@ -91,6 +91,12 @@ func TestDecodeTableType_Errors(t *testing.T) {
 			expectedErr: "table min must be at most 134217728",
 			features:    api.CoreFeatureReferenceTypes,
 		},
+		{
+			name:        "shared",
+			input:       []byte{wasm.RefTypeFuncref, 0x3, 0, 1},
+			expectedErr: "tables cannot be shared",
+			features:    api.CoreFeatureReferenceTypes,
+		},
 	}
 
 	for _, tt := range tests {
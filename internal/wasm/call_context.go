@@ -2,11 +2,16 @@ package wasm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"sync/atomic"
+	"time"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -34,6 +39,9 @@ type CallContext struct {
 	// memory is returned by Memory and overridden WithMemory
 	memory api.Memory
 	ns     *Namespace
+	// host is the module defining the host function being called, or nil. It
+	// is overridden WithHostModule and its HostState is returned by HostState.
+	host *ModuleInstance
 
 	// Sys is exposed for use in special imports such as WASI, assemblyscript
 	// and gojs.
@@ -56,6 +64,10 @@ type CallContext struct {
 
 	// CodeCloser is non-nil when the code should be closed after this module.
 	CodeCloser api.Closer
+
+	// callDepth is the count of calls in progress, when this was passed to a
+	// host function, or zero. Functions got from this are nested in them.
+	callDepth uint32
 }
 
 // FailIfClosed returns a sys.ExitError if CloseWithExitCode was called.
@@ -74,11 +86,58 @@ func (m *CallContext) Name() string {
 // WithMemory allows overriding memory without re-allocation when the result would be the same.
 func (m *CallContext) WithMemory(memory *MemoryInstance) *CallContext {
 	if memory != nil && memory != m.memory { // only re-allocate if it will change the effective memory
-		return &CallContext{module: m.module, memory: memory, Sys: m.Sys, closed: m.closed}
+		return &CallContext{module: m.module, memory: memory, host: m.host, Sys: m.Sys, closed: m.closed, callDepth: m.callDepth}
 	}
 	return m
 }
 
+// WithHostModule allows overriding the module whose state is returned by
+// HostState without re-allocation when the result would be the same.
+//
+// Engines call this with the module defining a host function, so that the
+// function sees its own state, even though Memory is that of the caller.
+func (m *CallContext) WithHostModule(host *ModuleInstance) *CallContext {
+	if host == nil || host == m.host || (m.host == nil && host == m.module) {
+		return m
+	}
+	if host.HostState == nil && m.HostState() == nil { // only re-allocate if it will change the effective state
+		return m
+	}
+	return &CallContext{module: m.module, memory: m.memory, host: host, Sys: m.Sys, closed: m.closed, callDepth: m.callDepth}
+}
+
+// callDepthCeiling is the maximum count of calls in progress at once, when
+// host functions call back into wasm. Each engine bounds the frames of a
+// single call, but not how many calls host functions start, so this bounds
+// the Go stack used by them.
+//
+// Calls are counted through the module passed to host functions, and through
+// each api.Function, which counts calls to it still in progress. So, a host
+// function calling a function it captured earlier is counted, too.
+//
+// The default value should suffice for most use cases. Those wishing to change this can via `go build -ldflags`.
+var callDepthCeiling = uint32(100)
+
+// nested returns a copy of m for a call nested in callDepth others, or an
+// error if that would exceed callDepthCeiling. Engines pass the copy to host
+// functions, so that functions got from it count this call.
+func (m *CallContext) nested(callDepth uint32) (*CallContext, error) {
+	if callDepth >= callDepthCeiling {
+		return nil, fmt.Errorf("%w: host functions nested more than %d calls", wasmruntime.ErrRuntimeStackOverflow, callDepthCeiling)
+	}
+	ret := *m
+	ret.callDepth = callDepth + 1
+	return &ret, nil
+}
+
+// HostState implements the same method as documented on api.Module
+func (m *CallContext) HostState() interface{} {
+	if m.host != nil {
+		return m.host.HostState
+	}
+	return m.module.HostState
+}
+
 // String implements the same method as documented on api.Module
 func (m *CallContext) String() string {
 	return fmt.Sprintf("Module[%s]", m.Name())
@@ -114,8 +173,15 @@ func (m *CallContext) close(ctx context.Context, exitCode uint32) (c bool, err e
 		return false, nil
 	}
 	c = true
+	m.module.releaseMappings()
+	if stubs := m.module.importStubs; stubs != nil {
+		m.ns.releaseImportStubs(ctx, stubs)
+	}
 	if sysCtx := m.Sys; sysCtx != nil { // nil if from HostModuleBuilder
 		err = sysCtx.FS(ctx).Close(ctx)
+		if w, ok := sysCtx.Stdout().(*internalsys.LineWriter); ok {
+			w.Flush() // pass any partial line to the handler.
+		}
 	}
 	return
 }
@@ -125,6 +191,22 @@ func (m *CallContext) Memory() api.Memory {
 	return m.module.Memory
 }
 
+// MemoryIndex implements the same method as documented on api.Module.
+func (m *CallContext) MemoryIndex(index uint32) api.Memory {
+	if index != 0 || m.module.Memory == nil {
+		return nil
+	}
+	return m.module.Memory
+}
+
+// MemoryStream implements the same method as documented on api.Module.
+func (m *CallContext) MemoryStream() io.ReadWriteSeeker {
+	if m.module.Memory == nil {
+		return nil
+	}
+	return &memoryStream{mem: m.module.Memory}
+}
+
 // ExportedMemory implements the same method as documented on api.Module.
 func (m *CallContext) ExportedMemory(name string) api.Memory {
 	exp, err := m.module.getExport(name, ExternTypeMemory)
@@ -134,6 +216,15 @@ func (m *CallContext) ExportedMemory(name string) api.Memory {
 	return exp.Memory
 }
 
+// ExportedTable implements the same method as documented on api.Module.
+func (m *CallContext) ExportedTable(name string) api.Table {
+	exp, err := m.module.getExport(name, ExternTypeTable)
+	if err != nil {
+		return nil
+	}
+	return exp.Table
+}
+
 // ExportedFunction implements the same method as documented on api.Module.
 func (m *CallContext) ExportedFunction(name string) api.Function {
 	exp, err := m.module.getExport(name, ExternTypeFunc)
@@ -141,7 +232,109 @@ func (m *CallContext) ExportedFunction(name string) api.Function {
 		return nil
 	}
 
-	return m.function(exp.Function)
+	return m.exportedFunction(name, exp.Function)
+}
+
+// ExportedFunctionByIndex implements the same method as documented on api.Module.
+func (m *CallContext) ExportedFunctionByIndex(funcIndex uint32) api.Function {
+	if uint32(len(m.module.Functions)) <= funcIndex {
+		return nil
+	}
+	name, ok := m.module.funcExportNames[funcIndex]
+	if !ok {
+		return nil
+	}
+	return m.exportedFunction(name, m.module.Functions[funcIndex])
+}
+
+// exportedFunction returns the function exported under name, applying any
+// timeout configured for that name.
+func (m *CallContext) exportedFunction(name string, f *FunctionInstance) api.Function {
+	fn := m.function(f)
+	if d, ok := m.module.FunctionTimeouts[name]; ok {
+		switch f := fn.(type) {
+		case *function:
+			f.timeout = d
+		case *importedFn:
+			f.timeout = d
+		}
+	}
+	return fn
+}
+
+// InstructionBudget implements the same method as documented on api.Module.
+func (m *CallContext) InstructionBudget() (remaining uint64, ok bool) {
+	if budget := m.module.InstructionBudget; budget != nil {
+		return atomic.LoadUint64(budget), true
+	}
+	return 0, false
+}
+
+// ReinitializeData is exposed for experimental.ReinitializeData.
+func (m *CallContext) ReinitializeData() error {
+	return m.module.ReinitializeData()
+}
+
+// Reset is exposed for experimental.Reset.
+func (m *CallContext) Reset() error {
+	return m.module.Reset()
+}
+
+// CompiledFunction is exposed for experimental.CompiledFunctionOf.
+func (m *CallContext) CompiledFunction(funcIndex uint32) (experimental.CompiledFunction, bool) {
+	if e, ok := m.module.Engine.(NativeModuleEngine); ok {
+		return e.CompiledFunction(funcIndex)
+	}
+	return experimental.CompiledFunction{}, false
+}
+
+// NumTables is exposed for experimental.InternalModule.
+func (m *CallContext) NumTables() uint32 {
+	return uint32(len(m.module.Tables))
+}
+
+// TableSize is exposed for experimental.InternalModule.
+func (m *CallContext) TableSize(tableIdx uint32) (uint32, bool) {
+	if tableIdx >= uint32(len(m.module.Tables)) {
+		return 0, false
+	}
+	return uint32(len(m.module.Tables[tableIdx].References)), true
+}
+
+// TypeIDs is exposed for experimental.InternalModule.
+func (m *CallContext) TypeIDs() []uint32 {
+	ret := make([]uint32, len(m.module.TypeIDs))
+	for i, id := range m.module.TypeIDs {
+		ret[i] = uint32(id)
+	}
+	return ret
+}
+
+// LookupTableFunction is exposed for experimental.InternalModule.
+func (m *CallContext) LookupTableFunction(tableIdx, offset, typeID uint32) (api.Function, error) {
+	if tableIdx >= uint32(len(m.module.Tables)) {
+		return nil, wasmruntime.ErrRuntimeInvalidTableAccess
+	}
+	f, err := m.module.Engine.LookupFunction(m.module.Tables[tableIdx], FunctionTypeID(typeID), offset)
+	if err != nil {
+		return nil, err
+	}
+	return m.function(f), nil
+}
+
+// Engine is exposed for experimental.InternalModule.
+func (m *CallContext) Engine() interface{} {
+	return m.module.Engine
+}
+
+// Initialize implements the same method as documented on api.Module.
+func (m *CallContext) Initialize(ctx context.Context) error {
+	fn := m.ExportedFunction("_initialize")
+	if fn == nil {
+		return nil
+	}
+	_, err := fn.Call(ctx)
+	return err
 }
 
 // Module is exposed for emscripten.
@@ -150,7 +343,7 @@ func (m *CallContext) Module() *ModuleInstance {
 }
 
 func (m *CallContext) Function(funcIdx Index) api.Function {
-	if uint32(len(m.module.Functions)) < funcIdx {
+	if uint32(len(m.module.Functions)) <= funcIdx {
 		return nil
 	}
 	return m.function(m.module.Functions[funcIdx])
@@ -163,17 +356,35 @@ func (m *CallContext) function(f *FunctionInstance) api.Function {
 	}
 
 	if f.Module == m.module {
-		return &function{fi: f, ce: ce}
+		return &function{fi: f, ce: ce, callDepth: m.callDepth}
 	} else {
 		return &importedFn{importingModule: m, importedFn: f, ce: ce}
 	}
 }
 
+// functionInstance returns the FunctionInstance of an api.Function returned
+// by a CallContext, or nil if it is of another type.
+func functionInstance(fn api.Function) *FunctionInstance {
+	switch f := fn.(type) {
+	case *function:
+		return f.fi
+	case *importedFn:
+		return f.importedFn
+	}
+	return nil
+}
+
 // function implements api.Function. This couples FunctionInstance with CallEngine so that
 // it can be used to make function calls originating from the FunctionInstance.
 type function struct {
 	fi *FunctionInstance
 	ce CallEngine
+	// timeout is non-zero when configured with ModuleConfig.WithFunctionTimeout.
+	timeout time.Duration
+	// callDepth is the count of calls in progress when this was got, which
+	// calls to it are nested in.
+	callDepth uint32
+	calls     nestedCalls
 }
 
 // Definition implements the same method as documented on api.FunctionDefinition.
@@ -183,7 +394,55 @@ func (f *function) Definition() api.FunctionDefinition {
 
 // Call implements the same method as documented on api.Function.
 func (f *function) Call(ctx context.Context, params ...uint64) (ret []uint64, err error) {
-	return f.ce.Call(ctx, f.fi.Module.CallCtx, params)
+	m, err := f.calls.enter(f.fi.Module.CallCtx, f.callDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer f.calls.exit()
+	return callWithTimeout(ctx, f.ce, m, params, f.timeout, false, nil)
+}
+
+// CallInPlace implements the same method as documented on api.Function.
+func (f *function) CallInPlace(ctx context.Context, params []uint64) (ret []uint64, err error) {
+	m, err := f.calls.enter(f.fi.Module.CallCtx, f.callDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer f.calls.exit()
+	return callWithTimeout(ctx, f.ce, m, params, f.timeout, true, nil)
+}
+
+// nestedCalls counts the calls in progress on an api.Function, as a host
+// function it calls may call it again, e.g. if it captured the function.
+type nestedCalls struct {
+	// inFlight is the count of calls in progress. This isn't atomic, as Call
+	// isn't goroutine-safe.
+	inFlight uint32
+	// callCtx is the CallContext calls run with when none is in progress,
+	// allocated on first use, so that most calls don't allocate.
+	callCtx *CallContext
+}
+
+// enter returns the CallContext a call runs with, nested in callDepth calls
+// and those in progress, or an error if that would exceed callDepthCeiling.
+// exit must be called when the call returns.
+func (c *nestedCalls) enter(m *CallContext, callDepth uint32) (ret *CallContext, err error) {
+	if c.inFlight == 0 {
+		if c.callCtx == nil {
+			c.callCtx, err = m.nested(callDepth)
+		}
+		ret = c.callCtx
+	} else {
+		ret, err = m.nested(callDepth + c.inFlight)
+	}
+	if err == nil {
+		c.inFlight++
+	}
+	return
+}
+
+func (c *nestedCalls) exit() {
+	c.inFlight--
 }
 
 // importedFn implements api.Function and ensures the call context of an imported function is the importing module.
@@ -191,6 +450,9 @@ type importedFn struct {
 	ce              CallEngine
 	importingModule *CallContext
 	importedFn      *FunctionInstance
+	// timeout is non-zero when configured with ModuleConfig.WithFunctionTimeout.
+	timeout time.Duration
+	calls   nestedCalls
 }
 
 // Definition implements the same method as documented on api.Function.
@@ -203,8 +465,67 @@ func (f *importedFn) Call(ctx context.Context, params ...uint64) (ret []uint64,
 	if f.importedFn.IsHostFunction {
 		return nil, fmt.Errorf("directly calling host function is not supported")
 	}
-	mod := f.importingModule
-	return f.ce.Call(ctx, mod, params)
+	mod, err := f.calls.enter(f.importingModule, f.importingModule.callDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer f.calls.exit()
+	return callWithTimeout(ctx, f.ce, mod, params, f.timeout, false, nil)
+}
+
+// CallInPlace implements the same method as documented on api.Function.
+func (f *importedFn) CallInPlace(ctx context.Context, params []uint64) (ret []uint64, err error) {
+	if f.importedFn.IsHostFunction {
+		return nil, fmt.Errorf("directly calling host function is not supported")
+	}
+	mod, err := f.calls.enter(f.importingModule, f.importingModule.callDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer f.calls.exit()
+	return callWithTimeout(ctx, f.ce, mod, params, f.timeout, true, nil)
+}
+
+// callWithTimeout calls the function, interrupting it if it runs longer than
+// the timeout. Zero means no timeout. When inPlace is true, results are
+// written into the backing array of params, if it has enough capacity. When
+// r is non-nil, the suspended call in it is continued instead.
+func callWithTimeout(ctx context.Context, ce CallEngine, m *CallContext, params []uint64, timeout time.Duration, inPlace bool, r *resumption) ([]uint64, error) {
+	ctx = m.withContextValues(ctx)
+	if stats, ok := ctx.Value(experimental.MemoryStatsKey{}).(*experimental.MemoryStats); ok {
+		defer m.recordMemoryStats(stats, m.memoryPages())
+	}
+	if timeout == 0 {
+		return call(ctx, ce, m, params, inPlace, r)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return callWithContextDone(ctx, ce, m, params, inPlace, r)
+}
+
+// withContextValues returns ctx with the values of wazero.ModuleConfig
+// WithContextValue, so that host functions called by the module see them.
+func (m *CallContext) withContextValues(ctx context.Context) context.Context {
+	if m.Sys == nil {
+		return ctx
+	}
+	return m.Sys.WithContextValues(ctx)
+}
+
+// memoryPages returns the current size of the module's memory in pages, or
+// zero if it has none.
+func (m *CallContext) memoryPages() uint32 {
+	if mem := m.module.Memory; mem != nil {
+		return memoryBytesNumToPages(uint64(mem.size()))
+	}
+	return 0
+}
+
+// recordMemoryStats records the memory size at the end of a call. As memory
+// never shrinks, this is also the peak size during the call.
+func (m *CallContext) recordMemoryStats(stats *experimental.MemoryStats, initialPages uint32) {
+	stats.InitialPages = initialPages
+	stats.PeakPages = m.memoryPages()
 }
 
 // GlobalVal is an internal hack to get the lower 64 bits of a global.
@@ -212,6 +533,62 @@ func (m *CallContext) GlobalVal(idx Index) uint64 {
 	return m.module.Globals[idx].Val
 }
 
+// StackPointer implements the same method as documented on api.Module.
+func (m *CallContext) StackPointer() (api.MutableGlobal, bool) {
+	sp, ok := m.ExportedGlobal("__stack_pointer").(api.MutableGlobal)
+	return sp, ok
+}
+
+// Resume continues a call suspended by experimental.Suspend.
+//
+// See experimental.Resume
+func (m *CallContext) Resume(ctx context.Context, cont *experimental.Continuation) ([]uint64, error) {
+	if len(cont.Frames) == 0 {
+		return nil, errors.New("continuation has no frames")
+	}
+	functions := make([]*FunctionInstance, len(cont.Frames))
+	for i, frame := range cont.Frames {
+		mod := m.module
+		if frame.ModuleName != mod.Name {
+			if mod = m.ns.module(frame.ModuleName); mod == nil {
+				return nil, fmt.Errorf("module[%s] not instantiated", frame.ModuleName)
+			}
+		}
+		if frame.FunctionIndex >= uint32(len(mod.Functions)) {
+			return nil, fmt.Errorf("module[%s] has no function[%d]", frame.ModuleName, frame.FunctionIndex)
+		}
+		functions[i] = mod.Functions[frame.FunctionIndex]
+	}
+
+	ce, err := functions[0].Module.Engine.NewCallEngine(m, functions[0])
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ce.(Resumer); !ok {
+		return nil, errors.New("engine doesn't support resuming calls")
+	}
+	r := &resumption{functions: functions, cont: cont}
+	nested, err := m.nested(m.callDepth)
+	if err != nil {
+		return nil, err
+	}
+	return callWithTimeout(ctx, ce, nested, nil, m.resumeTimeout(functions[0]), false, r)
+}
+
+// resumeTimeout returns the timeout of the function a suspended call was
+// made to, if exported with one, or zero. The shortest applies when it is
+// exported under several names with timeouts.
+func (m *CallContext) resumeTimeout(f *FunctionInstance) (timeout time.Duration) {
+	for name, d := range m.module.FunctionTimeouts {
+		if exp, ok := m.module.Exports[name]; ok && exp.Type == ExternTypeFunc && exp.Function == f {
+			if d != 0 && (timeout == 0 || d < timeout) {
+				timeout = d
+			}
+		}
+	}
+	return
+}
+
 // ExportedGlobal implements the same method as documented on api.Module.
 func (m *CallContext) ExportedGlobal(name string) api.Global {
 	exp, err := m.module.getExport(name, ExternTypeGlobal)
@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"testing"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/sys"
 	testfs "github.com/tetratelabs/wazero/internal/testing/fs"
 	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 )
 
 func TestCallContext_WithMemory(t *testing.T) {
@@ -59,6 +62,95 @@ func TestCallContext_WithMemory(t *testing.T) {
 	}
 }
 
+func TestCallContext_MemoryIndex(t *testing.T) {
+	mem := &MemoryInstance{}
+
+	t.Run("no memory", func(t *testing.T) {
+		m := &CallContext{module: &ModuleInstance{}}
+		require.Nil(t, m.MemoryIndex(0))
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		m := &CallContext{module: &ModuleInstance{Memory: mem}}
+		require.Equal(t, m.Memory(), m.MemoryIndex(0))
+		require.Nil(t, m.MemoryIndex(1))
+	})
+}
+
+func TestCallContext_MemoryStream(t *testing.T) {
+	s, ns := newStore()
+
+	noMemory, err := s.Instantiate(context.Background(), ns, &Module{}, "no memory", nil, nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, noMemory.MemoryStream())
+
+	module, err := s.Instantiate(context.Background(), ns, &Module{MemorySection: &Memory{Min: 1, Cap: 1}}, t.Name(), nil, nil, nil)
+	require.NoError(t, err)
+
+	stream := module.MemoryStream()
+	_, err = stream.Seek(10, io.SeekStart)
+	require.NoError(t, err)
+	_, err = stream.Write([]byte("wazero"))
+	require.NoError(t, err)
+
+	buf, ok := module.Memory().Read(context.Background(), 10, 6)
+	require.True(t, ok)
+	require.Equal(t, "wazero", string(buf))
+}
+
+func TestCallContext_StackPointer(t *testing.T) {
+	stackPointer := func(mutable bool) *Module {
+		return &Module{
+			GlobalSection: []*Global{{
+				Type: &GlobalType{ValType: ValueTypeI32, Mutable: mutable},
+				Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: []byte{0x80, 0x80, 0x04}}, // 65536
+			}},
+			ExportSection: []*Export{{Type: ExternTypeGlobal, Name: "__stack_pointer"}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		module     *Module
+		expectedOk bool
+	}{
+		{
+			name:   "not exported",
+			module: &Module{},
+		},
+		{
+			name:   "immutable",
+			module: stackPointer(false),
+		},
+		{
+			name:       "mutable",
+			module:     stackPointer(true),
+			expectedOk: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		s, ns := newStore()
+		t.Run(tc.name, func(t *testing.T) {
+			module, err := s.Instantiate(context.Background(), ns, tc.module, t.Name(), nil, nil, nil)
+			require.NoError(t, err)
+
+			sp, ok := module.StackPointer()
+			require.Equal(t, tc.expectedOk, ok)
+			if !ok {
+				require.Nil(t, sp)
+				return
+			}
+
+			require.Equal(t, uint64(65536), sp.Get(testCtx))
+			sp.Set(testCtx, 1024) // e.g. restoring a saved value.
+			require.Equal(t, uint64(1024), module.ExportedGlobal("__stack_pointer").Get(testCtx))
+		})
+	}
+}
+
 func TestCallContext_String(t *testing.T) {
 	s, ns := newStore()
 
@@ -82,7 +174,7 @@ func TestCallContext_String(t *testing.T) {
 
 		t.Run(tc.name, func(t *testing.T) {
 			// Ensure paths that can create the host module can see the name.
-			m, err := s.Instantiate(context.Background(), ns, &Module{}, tc.moduleName, nil, nil)
+			m, err := s.Instantiate(context.Background(), ns, &Module{}, tc.moduleName, nil, nil, nil)
 			defer m.Close(testCtx) //nolint
 
 			require.NoError(t, err)
@@ -121,7 +213,7 @@ func TestCallContext_Close(t *testing.T) {
 		t.Run(fmt.Sprintf("%s calls ns.CloseWithExitCode(module.name))", tc.name), func(t *testing.T) {
 			for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
 				moduleName := t.Name()
-				m, err := s.Instantiate(ctx, ns, &Module{}, moduleName, nil, nil)
+				m, err := s.Instantiate(ctx, ns, &Module{}, moduleName, nil, nil, nil)
 				require.NoError(t, err)
 
 				// We use side effects to see if Close called ns.CloseWithExitCode (without repeating store_test.go).
@@ -149,7 +241,7 @@ func TestCallContext_Close(t *testing.T) {
 		_, err := fsCtx.OpenFile(testCtx, "/foo")
 		require.NoError(t, err)
 
-		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil)
+		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil, nil)
 		require.NoError(t, err)
 
 		// We use side effects to determine if Close in fact called Context.Close (without repeating sys_test.go).
@@ -177,7 +269,7 @@ func TestCallContext_Close(t *testing.T) {
 		_, err := fsCtx.OpenFile(testCtx, "/foo")
 		require.NoError(t, err)
 
-		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil)
+		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil, nil)
 		require.NoError(t, err)
 
 		require.EqualError(t, m.Close(testCtx), "error closing")
@@ -217,7 +309,7 @@ func TestCallContext_CallDynamic(t *testing.T) {
 		t.Run(fmt.Sprintf("%s calls ns.CloseWithExitCode(module.name))", tc.name), func(t *testing.T) {
 			for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
 				moduleName := t.Name()
-				m, err := s.Instantiate(ctx, ns, &Module{}, moduleName, nil, nil)
+				m, err := s.Instantiate(ctx, ns, &Module{}, moduleName, nil, nil, nil)
 				require.NoError(t, err)
 
 				// We use side effects to see if Close called ns.CloseWithExitCode (without repeating store_test.go).
@@ -245,7 +337,7 @@ func TestCallContext_CallDynamic(t *testing.T) {
 		_, err := fsCtx.OpenFile(testCtx, "/foo")
 		require.NoError(t, err)
 
-		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil)
+		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil, nil)
 		require.NoError(t, err)
 
 		// We use side effects to determine if Close in fact called Context.Close (without repeating sys_test.go).
@@ -273,7 +365,7 @@ func TestCallContext_CallDynamic(t *testing.T) {
 		_, err := fsCtx.OpenFile(testCtx, "/foo")
 		require.NoError(t, err)
 
-		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil)
+		m, err := s.Instantiate(context.Background(), ns, &Module{}, t.Name(), sysCtx, nil, nil)
 		require.NoError(t, err)
 
 		require.EqualError(t, m.Close(testCtx), "error closing")
@@ -283,3 +375,39 @@ func TestCallContext_CallDynamic(t *testing.T) {
 		require.False(t, ok, "expected no opened files")
 	})
 }
+
+func TestFunction_Call_NestedCallDepth(t *testing.T) {
+	m := &ModuleInstance{}
+	m.CallCtx = NewCallContext(nil, m, nil)
+
+	// The call engine calls the same function again, like a host function
+	// which captured it would.
+	var fn api.Function
+	var calls uint32
+	ce := &nestingCallEngine{nest: func(ctx context.Context) error {
+		calls++
+		_, err := fn.Call(ctx)
+		return err
+	}}
+	fn = &function{fi: &FunctionInstance{Module: m}, ce: ce}
+
+	_, err := fn.Call(testCtx)
+	require.True(t, errors.Is(err, wasmruntime.ErrRuntimeStackOverflow), err)
+	require.Equal(t, callDepthCeiling, calls)
+
+	// Calls after the error aren't affected by it.
+	ce.nest = func(context.Context) error { return nil }
+	_, err = fn.Call(testCtx)
+	require.NoError(t, err)
+}
+
+// nestingCallEngine is a CallEngine whose calls invoke nest.
+type nestingCallEngine struct {
+	mockCallEngine
+	nest func(ctx context.Context) error
+}
+
+// Call implements the same method as documented on wasm.CallEngine.
+func (ce *nestingCallEngine) Call(ctx context.Context, _ *CallContext, _ []uint64) ([]uint64, error) {
+	return nil, ce.nest(ctx)
+}
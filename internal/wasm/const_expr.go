@@ -0,0 +1,96 @@
+package wasm
+
+import (
+	"fmt"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// IsExtended returns true if this expression has more than one instruction,
+// as allowed by api.CoreFeatureExtendedConst. When true, Opcode is the last
+// instruction and Data includes all instructions, except the terminating end.
+//
+// See https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+func (e *ConstantExpression) IsExtended() bool {
+	switch e.Opcode {
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		return true
+	}
+	return false
+}
+
+// evalExtendedConstExpression evaluates the instructions in an extended
+// constant expression, using global to resolve the type and value of any
+// global.get. The result is only meaningful when err is nil.
+func evalExtendedConstExpression(data []byte, global func(index uint32) (ValueType, uint64, error)) (ValueType, uint64, error) {
+	type value struct {
+		t ValueType
+		v uint64
+	}
+	var stack []value
+	for pc := 0; pc < len(data); {
+		op := data[pc]
+		pc++
+		switch op {
+		case OpcodeI32Const:
+			v, n, err := leb128.LoadInt32(data[pc:])
+			if err != nil {
+				return 0, 0, fmt.Errorf("read i32: %w", err)
+			}
+			pc += int(n)
+			stack = append(stack, value{ValueTypeI32, uint64(uint32(v))})
+		case OpcodeI64Const:
+			v, n, err := leb128.LoadInt64(data[pc:])
+			if err != nil {
+				return 0, 0, fmt.Errorf("read i64: %w", err)
+			}
+			pc += int(n)
+			stack = append(stack, value{ValueTypeI64, uint64(v)})
+		case OpcodeGlobalGet:
+			id, n, err := leb128.LoadUint32(data[pc:])
+			if err != nil {
+				return 0, 0, fmt.Errorf("read index of global: %w", err)
+			}
+			pc += int(n)
+			t, v, err := global(id)
+			if err != nil {
+				return 0, 0, err
+			}
+			stack = append(stack, value{t, v})
+		case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+			expected := ValueTypeI32
+			if op >= OpcodeI64Add {
+				expected = ValueTypeI64
+			}
+			if len(stack) < 2 {
+				return 0, 0, fmt.Errorf("cannot pop the operand for %s: stack underflow", InstructionName(op))
+			}
+			x1, x2 := stack[len(stack)-2], stack[len(stack)-1]
+			if x1.t != expected || x2.t != expected {
+				return 0, 0, fmt.Errorf("type mismatch on %s: expected %s operands", InstructionName(op), ValueTypeName(expected))
+			}
+			var v uint64
+			switch op {
+			case OpcodeI32Add:
+				v = uint64(uint32(x1.v) + uint32(x2.v))
+			case OpcodeI32Sub:
+				v = uint64(uint32(x1.v) - uint32(x2.v))
+			case OpcodeI32Mul:
+				v = uint64(uint32(x1.v) * uint32(x2.v))
+			case OpcodeI64Add:
+				v = x1.v + x2.v
+			case OpcodeI64Sub:
+				v = x1.v - x2.v
+			case OpcodeI64Mul:
+				v = x1.v * x2.v
+			}
+			stack = append(stack[:len(stack)-2], value{expected, v})
+		default:
+			return 0, 0, fmt.Errorf("invalid opcode for const expression: 0x%x", op)
+		}
+	}
+	if len(stack) != 1 {
+		return 0, 0, fmt.Errorf("const expression must leave exactly one value, but left %d", len(stack))
+	}
+	return stack[0].t, stack[0].v, nil
+}
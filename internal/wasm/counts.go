@@ -37,6 +37,17 @@ func (m *Module) importCount(et ExternType) (res uint32) {
 	return
 }
 
+// MaxStackDepth returns the largest Code.MaxStackDepth of functions defined
+// in this module, or zero before validation.
+func (m *Module) MaxStackDepth() (max uint32) {
+	for _, c := range m.CodeSection {
+		if c.MaxStackDepth > max {
+			max = c.MaxStackDepth
+		}
+	}
+	return
+}
+
 // SectionElementCount returns the count of elements in a given section ID
 //
 // For example...
@@ -79,6 +90,8 @@ func (m *Module) SectionElementCount(sectionID SectionID) uint32 { // element as
 		return uint32(len(m.CodeSection))
 	case SectionIDData:
 		return uint32(len(m.DataSection))
+	case SectionIDTag:
+		return uint32(len(m.TagSection))
 	default:
 		panic(fmt.Errorf("BUG: unknown section: %d", sectionID))
 	}
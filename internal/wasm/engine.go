@@ -3,6 +3,8 @@ package wasm
 import (
 	"context"
 	"errors"
+
+	"github.com/tetratelabs/wazero/experimental"
 )
 
 // Engine is a Store-scoped mechanism to compile functions declared or imported by a module.
@@ -47,8 +49,9 @@ type ModuleEngine interface {
 	// NewCallEngine returns a CallEngine for the given FunctionInstance.
 	NewCallEngine(callCtx *CallContext, f *FunctionInstance) (CallEngine, error)
 
-	// LookupFunction returns the index of the function in the function table.
-	LookupFunction(t *TableInstance, typeId FunctionTypeID, tableOffset Index) (Index, error)
+	// LookupFunction returns the function at the offset in the table. This may be defined by another module, e.g. when
+	// the function or table is imported.
+	LookupFunction(t *TableInstance, typeId FunctionTypeID, tableOffset Index) (*FunctionInstance, error)
 
 	// CreateFuncElementInstance creates an ElementInstance whose references are engine-specific function pointers
 	// corresponding to the given `indexes`.
@@ -63,6 +66,90 @@ type ModuleEngine interface {
 type CallEngine interface {
 	// Call invokes a function instance f with given parameters.
 	Call(ctx context.Context, m *CallContext, params []uint64) (results []uint64, err error)
+
+	// CallInPlace is like Call, except results are written into the backing
+	// array of params when its capacity is at least the result count.
+	CallInPlace(ctx context.Context, m *CallContext, params []uint64) (results []uint64, err error)
+
+	// Interrupt makes an in-flight Call panic with the given cause at its next
+	// safe point, such as a loop back-edge. Passing nil clears the cause.
+	//
+	// Note: Unlike Call, this is safe to invoke from another goroutine.
+	Interrupt(cause error)
+}
+
+// Resumer is implemented by a CallEngine which can resume a call suspended
+// by experimental.Suspend.
+type Resumer interface {
+	// Resume continues the call in cont, where functions are resolved from
+	// cont.Frames, from the call engine's function to the innermost.
+	Resume(ctx context.Context, m *CallContext, functions []*FunctionInstance, cont *experimental.Continuation) (results []uint64, err error)
+}
+
+// NativeModuleEngine is implemented by a ModuleEngine which compiles
+// functions to native code.
+type NativeModuleEngine interface {
+	// CompiledFunction returns the native code of the function at funcIndex,
+	// or false if there is no such function.
+	CompiledFunction(funcIndex Index) (experimental.CompiledFunction, bool)
+}
+
+// CallWithContextDone is like CallEngine.Call, except the call is interrupted
+// with ctx.Err() once ctx is done, such as when its deadline passes.
+//
+// Note: errors.Is can be used on the returned error to check the cause, e.g.
+// context.DeadlineExceeded.
+func CallWithContextDone(ctx context.Context, ce CallEngine, m *CallContext, params []uint64) ([]uint64, error) {
+	return callWithContextDone(ctx, ce, m, params, false, nil)
+}
+
+// resumption is a call suspended by experimental.Suspend, which call
+// continues instead of calling with params.
+type resumption struct {
+	functions []*FunctionInstance
+	cont      *experimental.Continuation
+}
+
+// callWithContextDone is like CallWithContextDone, except it uses
+// CallEngine.CallInPlace when inPlace is true, or continues r when non-nil.
+func callWithContextDone(ctx context.Context, ce CallEngine, m *CallContext, params []uint64, inPlace bool, r *resumption) ([]uint64, error) {
+	done := ctx.Done()
+	if done == nil { // e.g. context.Background, which is never done.
+		return call(ctx, ce, m, params, inPlace, r)
+	}
+
+	finished, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-done:
+			ce.Interrupt(ctx.Err())
+		case <-finished:
+		}
+	}()
+
+	results, err := call(ctx, ce, m, params, inPlace, r)
+	close(finished)
+	<-exited
+	ce.Interrupt(nil) // Allow reuse of the call engine.
+	return results, err
+}
+
+// call invokes CallEngine.CallInPlace when inPlace is true, otherwise
+// CallEngine.Call. When r is non-nil, ce must be a Resumer, and the call in r
+// is continued instead.
+func call(ctx context.Context, ce CallEngine, m *CallContext, params []uint64, inPlace bool, r *resumption) ([]uint64, error) {
+	// Only modules which map copy-on-write memory pay to keep it mapped.
+	if mod := m.module; mod != nil && len(mod.mappedMemories) > 0 && mod.retainCallMappings() {
+		defer mod.releaseMappings()
+	}
+	if r != nil {
+		return ce.(Resumer).Resume(ctx, m, r.functions, r.cont)
+	}
+	if inPlace {
+		return ce.CallInPlace(ctx, m, params)
+	}
+	return ce.Call(ctx, m, params)
 }
 
 // TableInitEntry is normalized element segment used for initializing tables by engines.
@@ -0,0 +1,24 @@
+package wasm
+
+// UsesExceptionHandling returns true if the module defines a tag or any
+// function in it uses an exception handling instruction. This allows engines
+// which don't implement them, such as the compiler, to reject the module.
+//
+// Note: This must be called after Validate.
+func (m *Module) UsesExceptionHandling() (ret bool) {
+	if len(m.TagSection) > 0 {
+		return true
+	}
+	for _, code := range m.CodeSection {
+		_ = scanInstructions(code.Body, func(op Opcode, _ uint32) {
+			switch op {
+			case OpcodeTry, OpcodeCatch, OpcodeCatchAll, OpcodeThrow, OpcodeRethrow:
+				ret = true
+			}
+		})
+		if ret {
+			return
+		}
+	}
+	return
+}
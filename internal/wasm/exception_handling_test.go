@@ -0,0 +1,39 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_UsesExceptionHandling(t *testing.T) {
+	tests := []struct {
+		name     string
+		module   *Module
+		expected bool
+	}{
+		{
+			// The immediate of i32.const shouldn't be mistaken for try.
+			name:     "no exception handling",
+			module:   &Module{CodeSection: []*Code{{Body: []byte{OpcodeI32Const, OpcodeTry, OpcodeDrop, OpcodeEnd}}}},
+			expected: false,
+		},
+		{
+			name:     "tag",
+			module:   &Module{TagSection: []Index{0}},
+			expected: true,
+		},
+		{
+			name:     "try catch_all",
+			module:   &Module{CodeSection: []*Code{{Body: []byte{OpcodeTry, 0x40, OpcodeCatchAll, OpcodeEnd, OpcodeEnd}}}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.module.UsesExceptionHandling())
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package wasm
+
+import (
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// ValidateNoFloats returns an error naming the first part of the module which
+// uses a floating point type or instruction, or nil if none do. This supports
+// deterministic hosts which forbid floats, such as smart-contract platforms.
+//
+// Vector instructions are rejected as well, as many of them operate on floats.
+//
+// Note: This must be called after Validate.
+func (m *Module) ValidateNoFloats() error {
+	for _, i := range m.ImportSection {
+		switch i.Type {
+		case ExternTypeFunc:
+			if ft := m.TypeSection[i.DescFunc]; hasFloat(ft) {
+				return fmt.Errorf("floats disabled: import[%q.%q] function has signature %s", i.Module, i.Name, ft)
+			}
+		case ExternTypeGlobal:
+			if isFloat(i.DescGlobal.ValType) {
+				return fmt.Errorf("floats disabled: import[%q.%q] global has type %s", i.Module, i.Name, api.ValueTypeName(i.DescGlobal.ValType))
+			}
+		}
+	}
+
+	for idx, g := range m.GlobalSection {
+		if isFloat(g.Type.ValType) {
+			return fmt.Errorf("floats disabled: %s[%d] has type %s", SectionIDName(SectionIDGlobal), idx, api.ValueTypeName(g.Type.ValType))
+		}
+	}
+
+	for idx, typeIdx := range m.FunctionSection {
+		if ft := m.TypeSection[typeIdx]; hasFloat(ft) {
+			return fmt.Errorf("floats disabled: %s has signature %s", m.funcDesc(SectionIDFunction, Index(idx)), ft)
+		}
+		code := m.CodeSection[idx]
+		for _, lt := range code.LocalTypes {
+			if isFloat(lt) {
+				return fmt.Errorf("floats disabled: %s has a local of type %s", m.funcDesc(SectionIDFunction, Index(idx)), api.ValueTypeName(lt))
+			}
+		}
+		if name, err := firstFloatInstruction(code.Body); err != nil {
+			// Fail closed, as instructions after this point weren't checked.
+			return fmt.Errorf("floats disabled: %s couldn't be scanned: %w", m.funcDesc(SectionIDFunction, Index(idx)), err)
+		} else if name != "" {
+			return fmt.Errorf("floats disabled: %s uses %s", m.funcDesc(SectionIDFunction, Index(idx)), name)
+		}
+	}
+
+	// Types not used by functions can still be used by call_indirect or blocks.
+	for idx, ft := range m.TypeSection {
+		if hasFloat(ft) {
+			return fmt.Errorf("floats disabled: %s[%d] has signature %s", SectionIDName(SectionIDType), idx, ft)
+		}
+	}
+	return nil
+}
+
+func hasFloat(ft *FunctionType) bool {
+	for _, vt := range ft.Params {
+		if isFloat(vt) {
+			return true
+		}
+	}
+	for _, vt := range ft.Results {
+		if isFloat(vt) {
+			return true
+		}
+	}
+	return false
+}
+
+func isFloat(vt ValueType) bool {
+	return vt == ValueTypeF32 || vt == ValueTypeF64
+}
+
+// firstFloatInstruction returns the name of the first instruction in the
+// validated function body which uses floats, or "" if none do. An error is
+// returned if the body couldn't be scanned to its end.
+func firstFloatInstruction(body []byte) (name string, err error) {
+	err = scanInstructions(body, func(op Opcode, imm uint32) {
+		if name != "" {
+			return
+		}
+		switch op {
+		case OpcodeMiscPrefix:
+			if OpcodeMisc(imm) <= OpcodeMiscI64TruncSatF64U {
+				name = MiscInstructionName(OpcodeMisc(imm))
+			}
+		case OpcodeVecPrefix:
+			if isRelaxedVecOpcode(imm) {
+				name = RelaxedVectorInstructionName(imm)
+			} else {
+				name = VectorInstructionName(OpcodeVec(imm))
+			}
+		default:
+			if isFloatOpcode(op) {
+				name = InstructionName(op)
+			}
+		}
+	})
+	return
+}
+
+// isFloatOpcode returns true if the single-byte opcode has a float operand or
+// result.
+func isFloatOpcode(op Opcode) bool {
+	switch op {
+	case OpcodeF32Load, OpcodeF64Load, OpcodeF32Store, OpcodeF64Store, OpcodeF32Const, OpcodeF64Const:
+		return true
+	}
+	return (op >= OpcodeF32Eq && op <= OpcodeF64Ge) ||
+		(op >= OpcodeF32Abs && op <= OpcodeF64Copysign) ||
+		(op >= OpcodeI32TruncF32S && op <= OpcodeI32TruncF64U) ||
+		(op >= OpcodeI64TruncF32S && op <= OpcodeF64ReinterpretI64)
+}
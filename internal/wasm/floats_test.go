@@ -0,0 +1,155 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_ValidateNoFloats(t *testing.T) {
+	i32_i32 := &FunctionType{Params: []ValueType{i32}, Results: []ValueType{i32}}
+	f64_v := &FunctionType{Params: []ValueType{f64}}
+	nopCode := &Code{Body: []byte{OpcodeEnd}}
+
+	tests := []struct {
+		name        string
+		m           *Module
+		expectedErr string
+	}{
+		{
+			name: "integer only",
+			m: &Module{
+				TypeSection:     []*FunctionType{i32_i32},
+				ImportSection:   []*Import{{Module: "env", Name: "g", Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: i64}}},
+				FunctionSection: []Index{0},
+				CodeSection: []*Code{{LocalTypes: []ValueType{i64}, Body: []byte{
+					OpcodeLocalGet, 0, OpcodeI32Const, 1, OpcodeI32Add,
+					OpcodeMiscPrefix, OpcodeMiscMemoryFill, 0, // skipped immediates aren't mistaken for opcodes.
+					OpcodeEnd,
+				}}},
+			},
+		},
+		{
+			name: "imported function",
+			m: &Module{
+				TypeSection:   []*FunctionType{f64_v},
+				ImportSection: []*Import{{Module: "env", Name: "f", Type: ExternTypeFunc, DescFunc: 0}},
+			},
+			expectedErr: `floats disabled: import["env"."f"] function has signature f64_v`,
+		},
+		{
+			name: "imported global",
+			m: &Module{
+				ImportSection: []*Import{{Module: "env", Name: "g", Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: f32}}},
+			},
+			expectedErr: `floats disabled: import["env"."g"] global has type f32`,
+		},
+		{
+			name: "global",
+			m: &Module{
+				GlobalSection: []*Global{{Type: &GlobalType{ValType: i32}}, {Type: &GlobalType{ValType: f64}}},
+			},
+			expectedErr: `floats disabled: global[1] has type f64`,
+		},
+		{
+			name: "function signature",
+			m: &Module{
+				TypeSection:     []*FunctionType{f64_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{nopCode},
+				ExportSection:   []*Export{{Name: "run", Type: ExternTypeFunc, Index: 0}},
+			},
+			expectedErr: `floats disabled: function[0] export["run"] has signature f64_v`,
+		},
+		{
+			name: "local",
+			m: &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{LocalTypes: []ValueType{i32, f32}, Body: []byte{OpcodeEnd}}},
+			},
+			expectedErr: `floats disabled: function[0] has a local of type f32`,
+		},
+		{
+			name: "instruction",
+			m: &Module{
+				TypeSection:     []*FunctionType{i32_i32},
+				FunctionSection: []Index{0},
+				CodeSection: []*Code{{Body: []byte{
+					OpcodeLocalGet, 0,
+					OpcodeF64ConvertI32S,
+					OpcodeI32TruncF64S,
+					OpcodeEnd,
+				}}},
+			},
+			expectedErr: `floats disabled: function[0] uses f64.convert_i32_s`,
+		},
+		{
+			name: "load",
+			m: &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection: []*Code{{Body: []byte{
+					OpcodeI32Const, 0, OpcodeF32Load, 0x2, 0x0, OpcodeDrop,
+					OpcodeEnd,
+				}}},
+			},
+			expectedErr: `floats disabled: function[0] uses f32.load`,
+		},
+		{
+			name: "saturating truncation",
+			m: &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection: []*Code{{Body: []byte{
+					OpcodeMiscPrefix, OpcodeMiscI64TruncSatF32U,
+					OpcodeEnd,
+				}}},
+			},
+			expectedErr: `floats disabled: function[0] uses i64.trunc_sat_f32_u`,
+		},
+		{
+			name: "vector",
+			m: &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection: []*Code{{Body: []byte{
+					OpcodeVecPrefix, OpcodeVecI32x4Add,
+					OpcodeEnd,
+				}}},
+			},
+			expectedErr: `floats disabled: function[0] uses i32x4.add`,
+		},
+		{
+			name: "truncated body",
+			m: &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				// The immediate of i64.const is missing.
+				CodeSection: []*Code{{Body: []byte{OpcodeI64Const}}},
+			},
+			expectedErr: `floats disabled: function[0] couldn't be scanned: readByte failed: EOF`,
+		},
+		{
+			name: "unused type",
+			m: &Module{
+				TypeSection:     []*FunctionType{v_v, f64_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{nopCode},
+			},
+			expectedErr: `floats disabled: type[1] has signature f64_v`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.m.ValidateNoFloats()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
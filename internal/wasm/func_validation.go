@@ -32,7 +32,9 @@ func (m *Module) validateFunction(enabledFeatures api.CoreFeatures, idx Index, f
 	return m.validateFunctionWithMaxStackValues(enabledFeatures, idx, functions, globals, memory, tables, maximumValuesOnStack, declaredFunctionIndexes)
 }
 
-func readMemArg(pc uint64, body []byte) (align, offset uint32, read uint64, err error) {
+// readMemArg reads the memarg immediates at pc. The offset is a u64 when the
+// memory is 64-bit (memory64), and a u32 otherwise.
+func readMemArg(pc uint64, body []byte, is64 bool) (align uint32, offset uint64, read uint64, err error) {
 	align, num, err := leb128.LoadUint32(body[pc:])
 	if err != nil {
 		err = fmt.Errorf("read memory align: %v", err)
@@ -40,7 +42,13 @@ func readMemArg(pc uint64, body []byte) (align, offset uint32, read uint64, err
 	}
 	read += num
 
-	offset, num, err = leb128.LoadUint32(body[pc+num:])
+	if is64 {
+		offset, num, err = leb128.LoadUint64(body[pc+num:])
+	} else {
+		var offset32 uint32
+		offset32, num, err = leb128.LoadUint32(body[pc+num:])
+		offset = uint64(offset32)
+	}
 	if err != nil {
 		err = fmt.Errorf("read memory offset: %v", err)
 		return
@@ -74,6 +82,12 @@ func (m *Module) validateFunctionWithMaxStackValues(
 	// Create the valueTypeStack to track the state of Wasm value stacks at anypoint of execution.
 	valueTypeStack := &valueTypeStack{}
 
+	// addressType is the type of memory addresses and sizes, which is i64 for a 64-bit memory (memory64).
+	addressType := ValueTypeI32
+	if memory != nil && memory.Is64 {
+		addressType = ValueTypeI64
+	}
+
 	// Now start walking through all the instructions in the body while tracking
 	// control blocks and value types to check the validity of all instructions.
 	for pc := uint64(0); pc < uint64(len(body)); pc++ {
@@ -95,7 +109,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				return fmt.Errorf("memory must exist for %s", InstructionName(op))
 			}
 			pc++
-			align, _, read, err := readMemArg(pc, body)
+			align, _, read, err := readMemArg(pc, body, addressType == ValueTypeI64)
 			if err != nil {
 				return err
 			}
@@ -105,7 +119,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 32/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -113,7 +127,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 32/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeF32)
@@ -124,7 +138,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeF32Store:
@@ -134,14 +148,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Load:
 				if 1<<align > 64/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -149,7 +163,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 64/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeF64)
@@ -160,7 +174,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeF64Store:
@@ -170,14 +184,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI32Load8S:
 				if 1<<align > 1 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -185,7 +199,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 1 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -193,7 +207,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 1 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -204,7 +218,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Store8:
@@ -214,14 +228,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI32Load16S, OpcodeI32Load16U:
 				if 1<<align > 16/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -229,7 +243,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 16/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -240,7 +254,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Store16:
@@ -250,14 +264,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Load32S, OpcodeI64Load32U:
 				if 1<<align > 32/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -268,7 +282,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			}
@@ -286,12 +300,12 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			}
 			switch Opcode(op) {
 			case OpcodeMemoryGrow:
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
-				valueTypeStack.push(ValueTypeI32)
+				valueTypeStack.push(addressType)
 			case OpcodeMemorySize:
-				valueTypeStack.push(ValueTypeI32)
+				valueTypeStack.push(addressType)
 			}
 			pc += num - 1
 		} else if OpcodeI32Const <= op && op <= OpcodeF64Const {
@@ -926,7 +940,15 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					if memory == nil {
 						return fmt.Errorf("memory must exist for %s", MiscInstructionName(miscOpcode))
 					}
-					params = []ValueType{ValueTypeI32, ValueTypeI32, ValueTypeI32}
+					// params are popped in order, so are the reverse of the operands.
+					switch miscOpcode {
+					case OpcodeMiscMemoryInit: // (dst, src, n) where src and n are in the data segment.
+						params = []ValueType{ValueTypeI32, ValueTypeI32, addressType}
+					case OpcodeMiscMemoryCopy: // (dst, src, n)
+						params = []ValueType{addressType, addressType, addressType}
+					case OpcodeMiscMemoryFill: // (dst, val, n)
+						params = []ValueType{addressType, ValueTypeI32, addressType}
+					}
 
 					if miscOpcode == OpcodeMiscMemoryInit {
 						if m.DataCountSection == nil {
@@ -1084,6 +1106,23 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					valueTypeStack.push(r)
 				}
 			}
+		} else if relaxedOpcode, ok := RelaxedVecOpcode(body, pc+1); op == OpcodeVecPrefix && ok {
+			pc += 2 // the relaxed opcode is two bytes.
+			name := RelaxedVectorInstructionName(relaxedOpcode)
+			if name == "" {
+				return fmt.Errorf("invalid vector opcode: 0x%x", relaxedOpcode)
+			}
+			if err := enabledFeatures.RequireEnabled(api.CoreFeatureSIMD); err != nil {
+				return fmt.Errorf("%s invalid as %v", name, err)
+			} else if err = enabledFeatures.RequireEnabled(api.CoreFeatureRelaxedSIMD); err != nil {
+				return fmt.Errorf("%s invalid as %v", name, err)
+			}
+			for i := relaxedVecOperandCount(relaxedOpcode); i > 0; i-- {
+				if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
+					return fmt.Errorf("cannot pop the operand for %s: %v", name, err)
+				}
+			}
+			valueTypeStack.push(ValueTypeV128)
 		} else if op == OpcodeVecPrefix {
 			pc++
 			// Vector instructions come with two bytes where the first byte is always OpcodeVecPrefix,
@@ -1115,7 +1154,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					return fmt.Errorf("memory must exist for %s", VectorInstructionName(vecOpcode))
 				}
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, _, read, err := readMemArg(pc, body, addressType == ValueTypeI64)
 				if err != nil {
 					return err
 				}
@@ -1144,7 +1183,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > maxAlign {
 					return fmt.Errorf("invalid memory alignment %d for %s", align, VectorInstructionName(vecOpcode))
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", VectorInstructionName(vecOpcode), err)
 				}
 				valueTypeStack.push(ValueTypeV128)
@@ -1153,7 +1192,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					return fmt.Errorf("memory must exist for %s", VectorInstructionName(vecOpcode))
 				}
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, _, read, err := readMemArg(pc, body, addressType == ValueTypeI64)
 				if err != nil {
 					return err
 				}
@@ -1164,7 +1203,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeVecV128StoreName, err)
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeVecV128StoreName, err)
 				}
 			case OpcodeVecV128Load8Lane, OpcodeVecV128Load16Lane, OpcodeVecV128Load32Lane, OpcodeVecV128Load64Lane:
@@ -1173,7 +1212,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				}
 				attr := vecLoadLanes[vecOpcode]
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, _, read, err := readMemArg(pc, body, addressType == ValueTypeI64)
 				if err != nil {
 					return err
 				}
@@ -1191,7 +1230,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
 				valueTypeStack.push(ValueTypeV128)
@@ -1201,7 +1240,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				}
 				attr := vecStoreLanes[vecOpcode]
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, _, read, err := readMemArg(pc, body, addressType == ValueTypeI64)
 				if err != nil {
 					return err
 				}
@@ -1219,7 +1258,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
 			case OpcodeVecI8x16ExtractLaneS,
@@ -1463,6 +1502,93 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			for _, p := range bl.blockType.Params {
 				valueTypeStack.push(p)
 			}
+		} else if op == OpcodeTry {
+			if err := enabledFeatures.RequireEnabled(api.CoreFeatureExceptionHandling); err != nil {
+				return fmt.Errorf("%s is invalid as %w", InstructionName(op), err)
+			}
+			bt, num, err := DecodeBlockType(types, bytes.NewReader(body[pc+1:]), enabledFeatures)
+			if err != nil {
+				return fmt.Errorf("read block: %w", err)
+			}
+			controlBlockStack = append(controlBlockStack, &controlBlock{
+				startAt:        pc,
+				blockType:      bt,
+				blockTypeBytes: num,
+				op:             op,
+			})
+			if err = valueTypeStack.popParams(op, bt.Params, false); err != nil {
+				return err
+			}
+			// Plus we have to push any block params again.
+			for _, p := range bt.Params {
+				valueTypeStack.push(p)
+			}
+			valueTypeStack.pushStackLimit(len(bt.Params))
+			pc += num
+		} else if op == OpcodeCatch || op == OpcodeCatchAll {
+			if err := enabledFeatures.RequireEnabled(api.CoreFeatureExceptionHandling); err != nil {
+				return fmt.Errorf("%s is invalid as %w", InstructionName(op), err)
+			}
+			bl := controlBlockStack[len(controlBlockStack)-1]
+			if bl.op != OpcodeTry {
+				return fmt.Errorf("%s must be in %s", InstructionName(op), OpcodeTryName)
+			} else if bl.catchAll {
+				return fmt.Errorf("%s must not follow %s", InstructionName(op), OpcodeCatchAllName)
+			}
+			// Check the type soundness of the instructions *before* entering this clause.
+			if err := valueTypeStack.popResults(OpcodeTry, bl.blockType.Results, true); err != nil {
+				return err
+			}
+			// Before entering instructions inside the clause, we pop all the values pushed by the previous one.
+			valueTypeStack.resetAtStackLimit()
+			bl.catching, bl.catchAll = true, op == OpcodeCatchAll
+			if op == OpcodeCatch {
+				pc++
+				tag, num, err := leb128.LoadUint32(body[pc:])
+				if err != nil {
+					return fmt.Errorf("read immediate: %v", err)
+				} else if tag >= uint32(len(m.TagSection)) {
+					return fmt.Errorf("invalid tag index %d for %s", tag, OpcodeCatchName)
+				}
+				pc += num - 1
+				// The values of the exception are pushed instead of the block params.
+				for _, p := range types[m.TagSection[tag]].Params {
+					valueTypeStack.push(p)
+				}
+			}
+		} else if op == OpcodeThrow {
+			if err := enabledFeatures.RequireEnabled(api.CoreFeatureExceptionHandling); err != nil {
+				return fmt.Errorf("%s is invalid as %w", InstructionName(op), err)
+			}
+			pc++
+			tag, num, err := leb128.LoadUint32(body[pc:])
+			if err != nil {
+				return fmt.Errorf("read immediate: %v", err)
+			} else if tag >= uint32(len(m.TagSection)) {
+				return fmt.Errorf("invalid tag index %d for %s", tag, OpcodeThrowName)
+			}
+			pc += num - 1
+			if err = valueTypeStack.popParams(op, types[m.TagSection[tag]].Params, false); err != nil {
+				return err
+			}
+			// throw instruction is stack-polymorphic.
+			valueTypeStack.unreachable()
+		} else if op == OpcodeRethrow {
+			if err := enabledFeatures.RequireEnabled(api.CoreFeatureExceptionHandling); err != nil {
+				return fmt.Errorf("%s is invalid as %w", InstructionName(op), err)
+			}
+			pc++
+			index, num, err := leb128.LoadUint32(body[pc:])
+			if err != nil {
+				return fmt.Errorf("read immediate: %v", err)
+			} else if int(index) >= len(controlBlockStack) {
+				return fmt.Errorf("invalid %s operation: index out of range", OpcodeRethrowName)
+			} else if target := controlBlockStack[len(controlBlockStack)-int(index)-1]; !target.catching {
+				return fmt.Errorf("invalid %s operation: label %d is not a %s or %s", OpcodeRethrowName, index, OpcodeCatchName, OpcodeCatchAllName)
+			}
+			pc += num - 1
+			// rethrow instruction is stack-polymorphic.
+			valueTypeStack.unreachable()
 		} else if op == OpcodeEnd {
 			bl := controlBlockStack[len(controlBlockStack)-1]
 			bl.endAt = pc
@@ -1569,6 +1695,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 	if valueTypeStack.maximumStackPointer > maxStackValues {
 		return fmt.Errorf("function may have %d stack values, which exceeds limit %d", valueTypeStack.maximumStackPointer, maxStackValues)
 	}
+	code.MaxStackDepth = uint32(valueTypeStack.maximumStackPointer)
 	return nil
 }
 
@@ -1850,6 +1977,9 @@ type controlBlock struct {
 	blockTypeBytes         uint64
 	// op is zero when the outermost block
 	op Opcode
+	// catching is true when the block is OpcodeTry and its OpcodeCatch or
+	// OpcodeCatchAll clauses began. catchAll is true after OpcodeCatchAll.
+	catching, catchAll bool
 }
 
 // DecodeBlockType decodes the type index from a positive 33-bit signed integer. Negative numbers indicate up to one
@@ -1893,3 +2023,21 @@ func DecodeBlockType(types []*FunctionType, r *bytes.Reader, enabledFeatures api
 	}
 	return ret, num, err
 }
+
+// relaxedVecOperandCount returns the count of v128 operands of the relaxed
+// vector instruction, which all return a single v128.
+func relaxedVecOperandCount(oc OpcodeVecRelaxed) int {
+	switch oc {
+	case OpcodeVecI32x4RelaxedTruncF32x4S, OpcodeVecI32x4RelaxedTruncF32x4U,
+		OpcodeVecI32x4RelaxedTruncF64x2SZero, OpcodeVecI32x4RelaxedTruncF64x2UZero:
+		return 1
+	case OpcodeVecF32x4RelaxedMadd, OpcodeVecF32x4RelaxedNmadd,
+		OpcodeVecF64x2RelaxedMadd, OpcodeVecF64x2RelaxedNmadd,
+		OpcodeVecI8x16RelaxedLaneselect, OpcodeVecI16x8RelaxedLaneselect,
+		OpcodeVecI32x4RelaxedLaneselect, OpcodeVecI64x2RelaxedLaneselect,
+		OpcodeVecI32x4RelaxedDotI8x16I7x16AddS:
+		return 3
+	default:
+		return 2
+	}
+}
@@ -37,6 +37,8 @@ func TestModule_ValidateFunction_validateFunctionWithMaxStackValues(t *testing.T
 	t.Run("not exceed", func(t *testing.T) {
 		err := m.validateFunctionWithMaxStackValues(api.CoreFeaturesV1, 0, []Index{0}, nil, nil, nil, max+1, nil)
 		require.NoError(t, err)
+		require.Equal(t, uint32(valuesNum), m.CodeSection[0].MaxStackDepth)
+		require.Equal(t, uint32(valuesNum), m.MaxStackDepth())
 	})
 	t.Run("exceed", func(t *testing.T) {
 		err := m.validateFunctionWithMaxStackValues(api.CoreFeaturesV1, 0, []Index{0}, nil, nil, nil, max, nil)
@@ -3154,6 +3156,30 @@ func TestModule_funcValidation_SIMD(t *testing.T) {
 	}
 }
 
+func TestModule_funcValidation_RelaxedSIMD(t *testing.T) {
+	for op, name := range relaxedVectorInstructionName {
+		var body []byte
+		for i := relaxedVecOperandCount(op); i > 0; i-- {
+			body = append(body, OpcodeVecPrefix, OpcodeVecV128Const,
+				1, 1, 1, 1, 1, 1, 1, 1,
+				1, 1, 1, 1, 1, 1, 1, 1)
+		}
+		body = append(body, OpcodeVecPrefix)
+		body = append(body, leb128.EncodeUint32(op)...)
+		body = append(body, OpcodeDrop, OpcodeEnd)
+
+		t.Run(name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: body}},
+			}
+			err := m.validateFunction(api.CoreFeatureSIMD|api.CoreFeatureRelaxedSIMD, 0, []Index{0}, nil, &Memory{}, nil, nil)
+			require.NoError(t, err)
+		})
+	}
+}
+
 func TestModule_funcValidation_SIMD_error(t *testing.T) {
 	type testCase struct {
 		name        string
@@ -3233,6 +3259,48 @@ func TestModule_funcValidation_SIMD_error(t *testing.T) {
 			},
 			expectedErr: "invalid lane index[0] 255 >= 32 for v128.shuffle",
 		},
+		{
+			name: "relaxed-simd disabled",
+			flag: api.CoreFeatureSIMD,
+			body: []byte{
+				OpcodeVecPrefix, 0x85, 0x02, // f32x4.relaxed_madd
+			},
+			expectedErr: "f32x4.relaxed_madd invalid as feature \"relaxed-simd\" is disabled",
+		},
+		{
+			name: "relaxed-simd without simd",
+			flag: api.CoreFeatureRelaxedSIMD,
+			body: []byte{
+				OpcodeVecPrefix, 0x85, 0x02, // f32x4.relaxed_madd
+			},
+			expectedErr: "f32x4.relaxed_madd invalid as feature \"simd\" is disabled",
+		},
+		{
+			name: "relaxed-simd invalid opcode",
+			flag: api.CoreFeatureSIMD | api.CoreFeatureRelaxedSIMD,
+			body: []byte{
+				OpcodeVecPrefix, 0xff, 0x02,
+			},
+			expectedErr: "invalid vector opcode: 0x17f",
+		},
+		{
+			name: "f32x4.relaxed_madd operand",
+			flag: api.CoreFeatureSIMD | api.CoreFeatureRelaxedSIMD,
+			body: []byte{
+				OpcodeVecPrefix,
+				OpcodeVecV128Const,
+				1, 1, 1, 1, 1, 1, 1, 1,
+				1, 1, 1, 1, 1, 1, 1, 1,
+				OpcodeVecPrefix,
+				OpcodeVecV128Const,
+				1, 1, 1, 1, 1, 1, 1, 1,
+				1, 1, 1, 1, 1, 1, 1, 1,
+				OpcodeVecPrefix, 0x85, 0x02, // f32x4.relaxed_madd
+				OpcodeDrop,
+				OpcodeEnd,
+			},
+			expectedErr: "cannot pop the operand for f32x4.relaxed_madd: v128 missing",
+		},
 	}
 
 	addExtractOrReplaceLaneOutOfIndexCase := func(op OpcodeVec, lane, laneCeil byte) {
@@ -3542,3 +3610,105 @@ func TestModule_funcValidation_loopWithParams(t *testing.T) {
 		})
 	}
 }
+
+func TestModule_funcValidation_ExceptionHandling(t *testing.T) {
+	// Type 0 is v_i32, the function type, and type 1 is i32_v, the type of tag 0.
+	tests := []struct {
+		name        string
+		body        []byte
+		flag        api.CoreFeatures
+		expectedErr string
+	}{
+		{
+			name: "try catch",
+			body: []byte{
+				OpcodeTry, ValueTypeI32,
+				OpcodeI32Const, 1, OpcodeThrow, 0,
+				OpcodeCatch, 0, // pushes the i32 of tag 0
+				OpcodeEnd,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "try catch catch_all",
+			body: []byte{
+				OpcodeTry, ValueTypeI32,
+				OpcodeI32Const, 1,
+				OpcodeCatch, 0,
+				OpcodeCatchAll, OpcodeI32Const, 2,
+				OpcodeEnd,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "rethrow",
+			body: []byte{
+				OpcodeTry, ValueTypeI32,
+				OpcodeI32Const, 1,
+				OpcodeCatchAll,
+				OpcodeBlock, 0x40, OpcodeRethrow, 1, OpcodeEnd,
+				OpcodeI32Const, 2,
+				OpcodeEnd,
+				OpcodeEnd,
+			},
+		},
+		{
+			name:        "disabled",
+			body:        []byte{OpcodeTry, 0x40, OpcodeEnd, OpcodeI32Const, 0, OpcodeEnd},
+			flag:        api.CoreFeaturesV2,
+			expectedErr: `try is invalid as feature "exception-handling" is disabled`,
+		},
+		{
+			name:        "catch outside try",
+			body:        []byte{OpcodeBlock, 0x40, OpcodeCatchAll, OpcodeEnd, OpcodeI32Const, 0, OpcodeEnd},
+			expectedErr: "catch_all must be in try",
+		},
+		{
+			name:        "catch after catch_all",
+			body:        []byte{OpcodeTry, 0x40, OpcodeCatchAll, OpcodeCatch, 0, OpcodeDrop, OpcodeEnd, OpcodeI32Const, 0, OpcodeEnd},
+			expectedErr: "catch must not follow catch_all",
+		},
+		{
+			name:        "catch invalid tag",
+			body:        []byte{OpcodeTry, 0x40, OpcodeCatch, 1, OpcodeEnd, OpcodeI32Const, 0, OpcodeEnd},
+			expectedErr: "invalid tag index 1 for catch",
+		},
+		{
+			name:        "try results mismatch",
+			body:        []byte{OpcodeTry, ValueTypeI32, OpcodeCatch, 0, OpcodeEnd, OpcodeEnd},
+			expectedErr: "not enough results in try block\n\thave ()\n\twant (i32)",
+		},
+		{
+			name:        "throw params mismatch",
+			body:        []byte{OpcodeI64Const, 1, OpcodeThrow, 0, OpcodeEnd},
+			expectedErr: "cannot use i64 in throw block as param[0] type i32",
+		},
+		{
+			name:        "rethrow not in catch",
+			body:        []byte{OpcodeTry, 0x40, OpcodeRethrow, 0, OpcodeEnd, OpcodeI32Const, 0, OpcodeEnd},
+			expectedErr: "invalid rethrow operation: label 0 is not a catch or catch_all",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_i32, i32_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+				TagSection:      []Index{1},
+			}
+			flag := tc.flag
+			if flag == 0 {
+				flag = api.CoreFeaturesV2 | api.CoreFeatureExceptionHandling
+			}
+			err := m.validateFunction(flag, 0, []Index{0}, nil, nil, nil, nil)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
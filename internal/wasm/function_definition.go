@@ -34,7 +34,8 @@ func (m *Module) ExportedFunctions() map[string]api.FunctionDefinition {
 // Note: This is exported for tests who don't use wazero.Runtime or
 // NewHostModule to compile the module.
 func (m *Module) BuildFunctionDefinitions() {
-	if len(m.FunctionSection) == 0 {
+	importCount := m.ImportFuncCount()
+	if importCount == 0 && len(m.FunctionSection) == 0 {
 		return
 	}
 
@@ -47,7 +48,6 @@ func (m *Module) BuildFunctionDefinitions() {
 		localNames = m.NameSection.LocalNames
 	}
 
-	importCount := m.ImportFuncCount()
 	m.FunctionDefinitionSection = make([]*FunctionDefinition, 0, importCount+uint32(len(m.FunctionSection)))
 
 	importFuncIdx := Index(0)
@@ -93,6 +93,12 @@ func (m *Module) BuildFunctionDefinitions() {
 		d.name = funcName
 		d.debugName = wasmdebug.FuncName(moduleName, funcName, funcIdx)
 		d.paramNames = paramNames(localNames, funcIdx, len(d.funcType.Params))
+		for _, nm := range localNames {
+			if nm.Index == funcIdx {
+				d.localNames = nm.NameMap
+				break
+			}
+		}
 
 		for _, e := range m.ExportSection {
 			if e.Type == ExternTypeFunc && e.Index == funcIdx {
@@ -113,6 +119,7 @@ type FunctionDefinition struct {
 	importDesc  *[2]string
 	exportNames []string
 	paramNames  []string
+	localNames  NameMap
 }
 
 // ModuleName implements the same method as documented on api.FunctionDefinition.
@@ -158,6 +165,12 @@ func (f *FunctionDefinition) ParamNames() []string {
 	return f.paramNames
 }
 
+// LocalNames returns the possibly sparse names of params and locals from the
+// custom name section, or nil if there are none.
+func (f *FunctionDefinition) LocalNames() NameMap {
+	return f.localNames
+}
+
 // ParamTypes implements api.FunctionDefinition ParamTypes.
 func (f *FunctionDefinition) ParamTypes() []ValueType {
 	return f.funcType.Params
@@ -110,6 +110,20 @@ func TestModule_BuildFunctionDefinitions(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "only imports",
+			m: &Module{
+				ImportSection: []*Import{{Module: "env", Name: "f", Type: ExternTypeFunc, DescFunc: 0}},
+				TypeSection:   []*FunctionType{v_v},
+			},
+			expected: []*FunctionDefinition{
+				{index: 0, debugName: ".$0", importDesc: &[2]string{"env", "f"}, funcType: v_v},
+			},
+			expectedImports: []api.FunctionDefinition{
+				&FunctionDefinition{index: 0, debugName: ".$0", importDesc: &[2]string{"env", "f"}, funcType: v_v},
+			},
+			expectedExports: map[string]api.FunctionDefinition{},
+		},
 		{
 			name: "with imports",
 			m: &Module{
@@ -194,6 +208,9 @@ func TestModule_BuildFunctionDefinitions(t *testing.T) {
 						{Index: Index(4), Name: "four"},
 						{Index: Index(5), Name: "five"},
 					},
+					LocalNames: IndirectNameMap{
+						{Index: Index(3), NameMap: NameMap{{Index: Index(1), Name: "x"}}},
+					},
 				},
 				FunctionSection: []Index{0, 0, 0, 0, 0},
 				CodeSection:     []*Code{nopCode, nopCode, nopCode, nopCode, nopCode},
@@ -202,7 +219,10 @@ func TestModule_BuildFunctionDefinitions(t *testing.T) {
 				{moduleName: "module", index: 0, debugName: "module.$0", importDesc: &[2]string{"i", "f"}, funcType: v_v},
 				{moduleName: "module", index: 1, debugName: "module.$1", funcType: v_v},
 				{moduleName: "module", index: 2, debugName: "module.two", funcType: v_v, name: "two"},
-				{moduleName: "module", index: 3, debugName: "module.$3", funcType: v_v},
+				{
+					moduleName: "module", index: 3, debugName: "module.$3", funcType: v_v,
+					paramNames: []string{}, localNames: NameMap{{Index: Index(1), Name: "x"}},
+				},
 				{moduleName: "module", index: 4, debugName: "module.four", funcType: v_v, name: "four"},
 				{moduleName: "module", index: 5, debugName: "module.five", funcType: v_v, name: "five"},
 			},
@@ -0,0 +1,29 @@
+package wasm
+
+import "github.com/tetratelabs/wazero/api"
+
+// Types returns the signature of each type in the TypeSection, in index order.
+func (m *Module) Types() (ret []api.FunctionSignature) {
+	for _, t := range m.TypeSection {
+		ret = append(ret, &functionSignature{funcType: t})
+	}
+	return
+}
+
+// functionSignature implements api.FunctionSignature
+//
+// Note: This wraps FunctionType instead of implementing the interface on it,
+// so that fields such as ParamNumInUint64 stay internal.
+type functionSignature struct {
+	funcType *FunctionType
+}
+
+// Params implements the same method as documented on api.FunctionSignature.
+func (s *functionSignature) Params() []api.ValueType {
+	return s.funcType.Params
+}
+
+// Results implements the same method as documented on api.FunctionSignature.
+func (s *functionSignature) Results() []api.ValueType {
+	return s.funcType.Results
+}
@@ -0,0 +1,49 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_Types(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    *Module
+		expected [][2][]api.ValueType
+	}{
+		{
+			name:  "no types",
+			input: &Module{},
+		},
+		{
+			name: "types",
+			input: &Module{
+				TypeSection: []*FunctionType{
+					v_v,
+					{Params: []ValueType{i32, i64}, Results: []ValueType{f32}, ParamNumInUint64: 2, ResultNumInUint64: 1},
+					{Results: []ValueType{ValueTypeV128}},
+				},
+			},
+			expected: [][2][]api.ValueType{
+				{nil, nil},
+				{{i32, i64}, {f32}},
+				{nil, {ValueTypeV128}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			types := tc.input.Types()
+			require.Equal(t, len(tc.expected), len(types))
+			for i, sig := range types {
+				require.Equal(t, tc.expected[i][0], sig.Params())
+				require.Equal(t, tc.expected[i][1], sig.Results())
+			}
+		})
+	}
+}
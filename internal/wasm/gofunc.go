@@ -34,10 +34,16 @@ var _ api.GoModuleFunction = (*reflectGoModuleFunction)(nil)
 type reflectGoModuleFunction struct {
 	fn              *reflect.Value
 	params, results []ValueType
+	// sf is non-nil when fn takes a struct pointer. See parseStructFields
+	sf *structFields
 }
 
 // Call implements the same method as documented on api.GoModuleFunction.
 func (f *reflectGoModuleFunction) Call(ctx context.Context, mod api.Module, stack []uint64) {
+	if f.sf != nil {
+		callGoStructFunc(ctx, mod, f.fn, f.sf, stack)
+		return
+	}
 	callGoFunc(ctx, mod, f.fn, stack)
 }
 
@@ -58,6 +64,8 @@ type reflectGoFunction struct {
 	fn              *reflect.Value
 	pk              paramsKind
 	params, results []ValueType
+	// sf is non-nil when fn takes a struct pointer. See parseStructFields
+	sf *structFields
 }
 
 // EqualTo is exposed for testing.
@@ -76,6 +84,10 @@ func (f *reflectGoFunction) Call(ctx context.Context, stack []uint64) {
 	if f.pk == paramsKindNoContext {
 		ctx = nil
 	}
+	if f.sf != nil {
+		callGoStructFunc(ctx, nil, f.fn, f.sf, stack)
+		return
+	}
 	callGoFunc(ctx, nil, f.fn, stack)
 }
 
@@ -120,45 +132,91 @@ func callGoFunc(ctx context.Context, mod api.Module, fn *reflect.Value, stack []
 		}
 
 		for j := 0; i < pLen; i++ {
-			next := tp.In(i)
-			val := reflect.New(next).Elem()
-			k := next.Kind()
-			raw := stack[j]
-			j++
-
-			switch k {
-			case reflect.Float32:
-				val.SetFloat(float64(math.Float32frombits(uint32(raw))))
-			case reflect.Float64:
-				val.SetFloat(math.Float64frombits(raw))
-			case reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-				val.SetUint(raw)
-			case reflect.Int32, reflect.Int64:
-				val.SetInt(int64(raw))
-			default:
-				panic(fmt.Errorf("BUG: param[%d] has an invalid type: %v", i, k))
+			val := reflect.New(tp.In(i)).Elem()
+			if !setReflectValue(val, stack[j]) {
+				panic(fmt.Errorf("BUG: param[%d] has an invalid type: %v", i, val.Kind()))
 			}
+			j++
 			in[i] = val
 		}
 	}
 
 	// Execute the host function and push back the call result onto the stack.
 	for i, ret := range fn.Call(in) {
-		switch ret.Kind() {
-		case reflect.Float32:
-			stack[i] = uint64(math.Float32bits(float32(ret.Float())))
-		case reflect.Float64:
-			stack[i] = math.Float64bits(ret.Float())
-		case reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-			stack[i] = ret.Uint()
-		case reflect.Int32, reflect.Int64:
-			stack[i] = uint64(ret.Int())
-		default:
+		var ok bool
+		if stack[i], ok = reflectValueToStack(ret); !ok {
 			panic(fmt.Errorf("BUG: result[%d] has an invalid type: %v", i, ret.Kind()))
 		}
 	}
 }
 
+// callGoStructFunc executes the reflective function, whose last param is a
+// pointer to a struct. Params are set into the struct before the call, and
+// results read from it after. A non-nil error result panics, which fails the
+// call from WebAssembly.
+func callGoStructFunc(ctx context.Context, mod api.Module, fn *reflect.Value, sf *structFields, stack []uint64) {
+	args := reflect.New(sf.typ)
+	fields := args.Elem()
+	for i, f := range sf.params {
+		if !setReflectValue(fields.Field(f), stack[i]) {
+			panic(fmt.Errorf("BUG: param[%d] has an invalid type: %v", i, fields.Field(f).Kind()))
+		}
+	}
+
+	in := make([]reflect.Value, 0, 3)
+	if ctx != nil {
+		in = append(in, newContextVal(ctx))
+	}
+	if mod != nil {
+		in = append(in, newModuleVal(mod))
+	}
+	if out := fn.Call(append(in, args)); len(out) == 1 && !out[0].IsNil() {
+		panic(out[0].Interface().(error))
+	}
+
+	for i, f := range sf.results {
+		var ok bool
+		if stack[i], ok = reflectValueToStack(fields.Field(f)); !ok {
+			panic(fmt.Errorf("BUG: result[%d] has an invalid type: %v", i, fields.Field(f).Kind()))
+		}
+	}
+}
+
+// setReflectValue sets val from the stack value raw, or returns false if the
+// type of val isn't supported by getTypeOf.
+func setReflectValue(val reflect.Value, raw uint64) bool {
+	switch val.Kind() {
+	case reflect.Float32:
+		val.SetFloat(float64(math.Float32frombits(uint32(raw))))
+	case reflect.Float64:
+		val.SetFloat(math.Float64frombits(raw))
+	case reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		val.SetUint(raw)
+	case reflect.Int32, reflect.Int64:
+		val.SetInt(int64(raw))
+	default:
+		return false
+	}
+	return true
+}
+
+// reflectValueToStack returns the stack value of val, or false if the type of
+// val isn't supported by getTypeOf.
+func reflectValueToStack(val reflect.Value) (uint64, bool) {
+	switch val.Kind() {
+	case reflect.Float32:
+		return uint64(math.Float32bits(float32(val.Float()))), true
+	case reflect.Float64:
+		return math.Float64bits(val.Float()), true
+	case reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return val.Uint(), true
+	case reflect.Int32, reflect.Int64:
+		return uint64(val.Int()), true
+	default:
+		return 0, false
+	}
+}
+
 func newContextVal(ctx context.Context) reflect.Value {
 	val := reflect.New(goContextType).Elem()
 	val.Set(reflect.ValueOf(ctx))
@@ -208,6 +266,11 @@ func parseGoReflectFunc(fn interface{}) (params, results []ValueType, code *Code
 	}
 
 	pCount := p.NumIn() - pOffset
+	if pCount == 1 {
+		if pI := p.In(pOffset); pI.Kind() == reflect.Ptr && pI.Elem().Kind() == reflect.Struct {
+			return parseGoReflectStructFunc(fnV, pk, pI.Elem())
+		}
+	}
 	if pCount > 0 {
 		params = make([]ValueType, pCount)
 	}
@@ -263,6 +326,81 @@ func parseGoReflectFunc(fn interface{}) (params, results []ValueType, code *Code
 	return
 }
 
+// structTagKey is the struct tag which maps fields to params or results. For
+// example, `wasm:"param"`.
+const structTagKey = "wasm"
+
+// structFields maps the fields of a struct to the params and results of a
+// host function.
+type structFields struct {
+	// typ is the struct type, not a pointer to it.
+	typ reflect.Type
+	// params and results are field indexes, in order.
+	params, results []int
+}
+
+// parseGoReflectStructFunc parses a func whose only param, besides
+// context.Context and api.Module, is a pointer to the struct st. The func
+// must have no results, or only an error.
+func parseGoReflectStructFunc(fnV reflect.Value, pk paramsKind, st reflect.Type) (params, results []ValueType, code *Code, err error) {
+	p := fnV.Type()
+	if p.NumOut() > 1 || (p.NumOut() == 1 && p.Out(0) != errorType) {
+		err = errors.New("a func with a struct param must have no result or only an error")
+		return
+	}
+
+	var sf *structFields
+	if sf, params, results, err = parseStructFields(st); err != nil {
+		return
+	}
+
+	code = &Code{IsHostFunction: true}
+	if pk == paramsKindContextModule {
+		code.GoFunc = &reflectGoModuleFunction{fn: &fnV, params: params, results: results, sf: sf}
+	} else {
+		code.GoFunc = &reflectGoFunction{pk: pk, fn: &fnV, params: params, results: results, sf: sf}
+	}
+	return
+}
+
+// parseStructFields returns the params and results of the struct st, in field
+// order. Each field must be tagged `wasm:"param"`, `wasm:"result"` or
+// `wasm:"-"` to ignore it.
+func parseStructFields(st reflect.Type) (sf *structFields, params, results []ValueType, err error) {
+	sf = &structFields{typ: st}
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		tag, ok := f.Tag.Lookup(structTagKey)
+		if !ok {
+			err = fmt.Errorf("field %s has no %s tag", f.Name, structTagKey)
+			return
+		} else if tag == "-" {
+			continue
+		} else if f.PkgPath != "" { // unexported
+			err = fmt.Errorf("field %s is unexported", f.Name)
+			return
+		}
+
+		t, ok := getTypeOf(f.Type.Kind())
+		if !ok {
+			err = fmt.Errorf("field %s is unsupported: %s", f.Name, f.Type.Kind())
+			return
+		}
+		switch tag {
+		case "param":
+			params = append(params, t)
+			sf.params = append(sf.params, i)
+		case "result":
+			results = append(results, t)
+			sf.results = append(sf.results, i)
+		default:
+			err = fmt.Errorf("field %s has an invalid %s tag: %q", f.Name, structTagKey, tag)
+			return
+		}
+	}
+	return
+}
+
 func kind(p reflect.Type) (paramsKind, error) {
 	pCount := p.NumIn()
 	if pCount > 0 && p.In(0).Kind() == reflect.Interface {
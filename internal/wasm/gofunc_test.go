@@ -2,6 +2,7 @@ package wasm
 
 import (
 	"context"
+	"errors"
 	"math"
 	"testing"
 	"unsafe"
@@ -13,6 +14,18 @@ import (
 // testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
 var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
 
+// testStructArgs interleaves params and results to show field order is used.
+type testStructArgs struct {
+	V       uint32  `wasm:"param"`
+	W       uint64  `wasm:"param"`
+	Sum     uint32  `wasm:"result"`
+	X       float32 `wasm:"param"`
+	Y       float64 `wasm:"param"`
+	Z       uintptr `wasm:"param"`
+	Product uint64  `wasm:"result"`
+	ignored string  `wasm:"-"`
+}
+
 func Test_parseGoFunc(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -52,6 +65,17 @@ func Test_parseGoFunc(t *testing.T) {
 			expectNeedsModule: true,
 			expectedType:      &FunctionType{Params: []ValueType{i32, i64, f32, f64, externref}, Results: []ValueType{i32}},
 		},
+		{
+			name:         "struct",
+			input:        func(*testStructArgs) {},
+			expectedType: &FunctionType{Params: []ValueType{i32, i64, f32, f64, externref}, Results: []ValueType{i32, i64}},
+		},
+		{
+			name:              "struct - (ctx, mod) -> error",
+			input:             func(context.Context, api.Module, *testStructArgs) error { return nil },
+			expectNeedsModule: true,
+			expectedType:      &FunctionType{Params: []ValueType{i32, i64, f32, f64, externref}, Results: []ValueType{i32, i64}},
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -112,6 +136,43 @@ func Test_parseGoFunc_Errors(t *testing.T) {
 			input:       func(context.Context, api.Module, uint64, api.Module) error { return nil },
 			expectedErr: "param[3] is a api.Module, which may be defined only once as param[0]",
 		},
+		{
+			name:        "struct with a non-error result",
+			input:       func(*testStructArgs) uint32 { return 0 },
+			expectedErr: "a func with a struct param must have no result or only an error",
+		},
+		{
+			name: "struct field without a tag",
+			input: func(*struct {
+				X uint32
+			}) {
+			},
+			expectedErr: "field X has no wasm tag",
+		},
+		{
+			name: "struct field with an invalid tag",
+			input: func(*struct {
+				X uint32 `wasm:"params"`
+			}) {
+			},
+			expectedErr: `field X has an invalid wasm tag: "params"`,
+		},
+		{
+			name: "struct field unexported",
+			input: func(*struct {
+				x uint32 `wasm:"param"`
+			}) {
+			},
+			expectedErr: "field x is unexported",
+		},
+		{
+			name: "struct field unsupported",
+			input: func(*struct {
+				X string `wasm:"result"`
+			}) {
+			},
+			expectedErr: "field X is unsupported: string",
+		},
 	}
 
 	for _, tt := range tests {
@@ -259,6 +320,30 @@ func Test_callGoFunc(t *testing.T) {
 			},
 			expectedResults: []uint64{100},
 		},
+		{
+			name: "struct - (ctx, mod)",
+			input: func(ctx context.Context, m api.Module, args *testStructArgs) error {
+				require.Equal(t, testCtx, ctx)
+				require.Equal(t, callCtx, m)
+				require.Equal(t, &testStructArgs{
+					V: math.MaxUint32,
+					W: math.MaxUint64,
+					X: math.MaxFloat32,
+					Y: math.MaxFloat64,
+					Z: tPtr,
+				}, args)
+				args.Sum, args.Product = 100, 200
+				return nil
+			},
+			inputParams: []uint64{
+				math.MaxUint32,
+				math.MaxUint64,
+				api.EncodeF32(math.MaxFloat32),
+				api.EncodeF64(math.MaxFloat64),
+				api.EncodeExternref(tPtr),
+			},
+			expectedResults: []uint64{100, 200},
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -292,3 +377,14 @@ func Test_callGoFunc(t *testing.T) {
 		})
 	}
 }
+
+func Test_callGoFunc_structError(t *testing.T) {
+	expectedErr := errors.New("invalid args")
+	_, _, code, err := parseGoReflectFunc(func(*testStructArgs) error { return expectedErr })
+	require.NoError(t, err)
+
+	err = require.CapturePanic(func() {
+		code.GoFunc.(api.GoFunction).Call(testCtx, make([]uint64, 5))
+	})
+	require.Equal(t, expectedErr, err)
+}
@@ -1,9 +1,13 @@
 package wasm
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"sort"
+	"strings"
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/wasmdebug"
@@ -98,11 +102,33 @@ func (f *HostFunc) WithWasm(body []byte) *HostFunc {
 	return &ret
 }
 
+// HostTable is a table of function references (RefTypeFuncref), used for
+// NewHostModule.
+type HostTable struct {
+	// Min and Max are equivalent to the same fields on Table.
+	Min uint32
+	Max *uint32
+
+	// Elements initialize the table from index zero. Each is nil for a null
+	// reference, the export name (string) of a function in the host module,
+	// or a function (api.Function) of an instantiated module.
+	Elements []interface{}
+}
+
+// HostTableFunction is an entry of a HostTable which refers to a function of
+// another module.
+type HostTableFunction struct {
+	TableIndex Index
+	Offset     Index
+	Function   *FunctionInstance
+}
+
 // NewHostModule is defined internally for use in WASI tests and to keep the code size in the root directory small.
 func NewHostModule(
 	moduleName string,
 	nameToGoFunc map[string]interface{},
 	funcToNames map[string][]string,
+	nameToTable map[string]*HostTable,
 	enabledFeatures api.CoreFeatures,
 ) (m *Module, err error) {
 	if moduleName != "" {
@@ -111,15 +137,18 @@ func NewHostModule(
 		m = &Module{}
 	}
 
-	if exportCount := uint32(len(nameToGoFunc)); exportCount > 0 {
+	if exportCount := uint32(len(nameToGoFunc) + len(nameToTable)); exportCount > 0 {
 		m.ExportSection = make([]*Export, 0, exportCount)
 		if err = addFuncs(m, nameToGoFunc, funcToNames, enabledFeatures); err != nil {
 			return
 		}
+		if err = addTables(m, nameToTable); err != nil {
+			return
+		}
 	}
 
 	// Assigns the ModuleID by calculating sha256 on inputs as host modules do not have `wasm` to hash.
-	m.AssignModuleID([]byte(fmt.Sprintf("%s:%v:%v", moduleName, nameToGoFunc, enabledFeatures)))
+	m.AssignModuleID([]byte(fmt.Sprintf("%s:%v:%v:%v", moduleName, nameToGoFunc, hostTablesID(nameToTable), enabledFeatures)))
 	m.BuildFunctionDefinitions()
 	return
 }
@@ -151,6 +180,9 @@ func addFuncs(
 	// Sort names for consistent iteration
 	sort.Strings(sortedExportNames)
 
+	// Collect errors parsing Go functions, so that all bad signatures are
+	// reported at once.
+	var reflectErrs funcErrors
 	funcNames := make([]string, len(nameToFunc))
 	for _, k := range sortedExportNames {
 		v := nameToGoFunc[k]
@@ -183,7 +215,8 @@ func addFuncs(
 		} else { // reflection
 			params, results, code, ftErr := parseGoReflectFunc(v)
 			if ftErr != nil {
-				return fmt.Errorf("func[%s.%s] %w", moduleName, k, ftErr)
+				reflectErrs = append(reflectErrs, fmt.Errorf("func[%s.%s] %w", moduleName, k, ftErr))
+				continue
 			}
 			hf = &HostFunc{
 				ExportNames: []string{k},
@@ -204,6 +237,9 @@ func addFuncs(
 			funcNames = append(funcNames, k)
 		}
 	}
+	if len(reflectErrs) > 0 {
+		return reflectErrs
+	}
 
 	funcCount := uint32(len(nameToFunc))
 	m.NameSection.FunctionNames = make([]*NameAssoc, 0, funcCount)
@@ -237,6 +273,95 @@ func addFuncs(
 	return nil
 }
 
+// addTables adds a table, exported under its key, for each HostTable in the
+// map. Entries that refer to host functions are initialized by an element
+// segment, and others by HostTableFunctions.
+func addTables(m *Module, nameToTable map[string]*HostTable) error {
+	sortedExportNames := make([]string, 0, len(nameToTable))
+	for k := range nameToTable {
+		sortedExportNames = append(sortedExportNames, k)
+	}
+
+	// Sort names for consistent iteration
+	sort.Strings(sortedExportNames)
+
+	// Host functions are only reachable by export name.
+	nameToFuncIdx := map[string]Index{}
+	for _, exp := range m.ExportSection {
+		if exp.Type == ExternTypeFunc {
+			nameToFuncIdx[exp.Name] = exp.Index
+		}
+	}
+
+	for _, k := range sortedExportNames {
+		t := nameToTable[k]
+		if t.Max != nil && t.Min > *t.Max {
+			return fmt.Errorf("table[%s] min %d > max %d", k, t.Min, *t.Max)
+		} else if t.Min > MaximumFunctionIndex {
+			return fmt.Errorf("table[%s] min %d over limit of %d", k, t.Min, MaximumFunctionIndex)
+		} else if uint32(len(t.Elements)) > t.Min {
+			return fmt.Errorf("table[%s] has %d elements, but min %d", k, len(t.Elements), t.Min)
+		}
+
+		tableIdx := Index(len(m.TableSection))
+		init := make([]*Index, len(t.Elements))
+		for i, e := range t.Elements {
+			switch e := e.(type) {
+			case nil:
+			case string:
+				funcIdx, ok := nameToFuncIdx[e]
+				if !ok {
+					return fmt.Errorf("table[%s] element[%d]: unknown function %q", k, i, e)
+				}
+				init[i] = &funcIdx
+			case api.Function:
+				fi := functionInstance(e)
+				if fi == nil {
+					return fmt.Errorf("table[%s] element[%d]: unsupported function %T", k, i, e)
+				}
+				m.HostTableFunctions = append(m.HostTableFunctions,
+					&HostTableFunction{TableIndex: tableIdx, Offset: Index(i), Function: fi})
+			default:
+				return fmt.Errorf("table[%s] element[%d]: invalid type %T", k, i, e)
+			}
+		}
+
+		m.TableSection = append(m.TableSection, &Table{Min: t.Min, Max: t.Max, Type: RefTypeFuncref})
+		m.ExportSection = append(m.ExportSection, &Export{Type: ExternTypeTable, Name: k, Index: tableIdx})
+		if len(init) > 0 {
+			m.ElementSection = append(m.ElementSection, &ElementSegment{
+				OffsetExpr: &ConstantExpression{Opcode: OpcodeI32Const, Data: []byte{0}},
+				TableIndex: tableIdx,
+				Init:       init,
+				Type:       RefTypeFuncref,
+				Mode:       ElementModeActive,
+			})
+		}
+	}
+	return nil
+}
+
+// hostTablesID returns a string that differs when tables differ, as the
+// default format of a pointer is its address, not what it points to.
+func hostTablesID(nameToTable map[string]*HostTable) string {
+	names := make([]string, 0, len(nameToTable))
+	for k := range nameToTable {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, k := range names {
+		t := nameToTable[k]
+		fmt.Fprintf(&b, "%s:%d:", k, t.Min)
+		if t.Max != nil {
+			fmt.Fprintf(&b, "%d", *t.Max)
+		}
+		fmt.Fprintf(&b, ":%v;", t.Elements)
+	}
+	return b.String()
+}
+
 func (m *Module) maybeAddType(params, results []ValueType, enabledFeatures api.CoreFeatures) (Index, error) {
 	if len(results) > 1 {
 		// Guard >1.0 feature multi-value
@@ -255,3 +380,89 @@ func (m *Module) maybeAddType(params, results []ValueType, enabledFeatures api.C
 	m.TypeSection = append(m.TypeSection, toAdd)
 	return result, nil
 }
+
+// EnableStrictHostResults replaces each host function whose results include
+// i32 or f32, so that it panics when it returns a value that doesn't fit the
+// result type, instead of the value being truncated.
+//
+// Note: Reflective functions can't overflow as their result types are exact,
+// so this only affects api.GoFunction and api.GoModuleFunction, which write
+// results to the stack directly.
+func (m *Module) EnableStrictHostResults() {
+	for i, code := range m.CodeSection {
+		if !code.IsHostFunction {
+			continue
+		}
+		results := m.TypeSection[m.FunctionSection[i]].Results
+		if bytes.IndexByte(results, ValueTypeI32) == -1 && bytes.IndexByte(results, ValueTypeF32) == -1 {
+			continue
+		}
+		// Replace the code, as it may be shared, e.g. by a HostFunc variable.
+		switch fn := code.GoFunc.(type) {
+		case *reflectGoFunction, *reflectGoModuleFunction:
+		case api.GoModuleFunction:
+			m.CodeSection[i] = &Code{IsHostFunction: true, GoFunc: api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+				fn.Call(ctx, mod, stack)
+				checkHostResults(results, stack)
+			})}
+		case api.GoFunction:
+			m.CodeSection[i] = &Code{IsHostFunction: true, GoFunc: api.GoFunc(func(ctx context.Context, stack []uint64) {
+				fn.Call(ctx, stack)
+				checkHostResults(results, stack)
+			})}
+		}
+	}
+}
+
+// checkHostResults panics if a result on the stack has more bits than its
+// type. An i32 may be zero or sign-extended, as either is lossless.
+func checkHostResults(results []ValueType, stack []uint64) {
+	for i, t := range results {
+		v := stack[i]
+		switch t {
+		case ValueTypeI32:
+			if hi := v >> 31; hi == 0 || hi == 1 || hi == math.MaxUint64>>31 {
+				continue
+			}
+		case ValueTypeF32:
+			if v>>32 == 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		panic(fmt.Errorf("result[%d] %#x overflows %s", i, v, ValueTypeName(t)))
+	}
+}
+
+// funcErrors are the errors parsing several Go functions, one per line.
+type funcErrors []error
+
+// Error implements error
+func (e funcErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Is allows errors.Is to match any of the errors.
+func (e funcErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As allows errors.As to match any of the errors.
+func (e funcErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,8 @@ package wasm
 
 import (
 	"context"
+	"errors"
+	"math"
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
@@ -86,7 +88,7 @@ func TestNewHostModule(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			m, e := NewHostModule(tc.moduleName, tc.nameToGoFunc, nil,
+			m, e := NewHostModule(tc.moduleName, tc.nameToGoFunc, nil, nil,
 				api.CoreFeaturesV1|api.CoreFeatureMultiValue)
 			require.NoError(t, e)
 			requireHostModuleEquals(t, tc.expected, m)
@@ -133,6 +135,15 @@ func TestNewHostModule_Errors(t *testing.T) {
 			nameToGoFunc: map[string]interface{}{"fn": t},
 			expectedErr:  "func[.fn] kind != func: ptr",
 		},
+		{
+			name: "reports all bad signatures",
+			nameToGoFunc: map[string]interface{}{
+				"ok":  func(uint32) {},
+				"fn2": func(string) {},
+				"fn1": t,
+			},
+			expectedErr: "func[.fn1] kind != func: ptr\nfunc[.fn2] param[0] is unsupported: string",
+		},
 		{
 			name:         "function has multiple results",
 			nameToGoFunc: map[string]interface{}{"fn": func() (uint32, uint32) { return 0, 0 }},
@@ -144,8 +155,77 @@ func TestNewHostModule_Errors(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			_, e := NewHostModule(tc.moduleName, tc.nameToGoFunc, nil, api.CoreFeaturesV1)
+			_, e := NewHostModule(tc.moduleName, tc.nameToGoFunc, nil, nil, api.CoreFeaturesV1)
 			require.EqualError(t, e, tc.expectedErr)
 		})
 	}
 }
+
+func TestNewHostModule_ErrorsWrapCauses(t *testing.T) {
+	_, e := NewHostModule("", map[string]interface{}{"fn1": t, "fn2": func(string) {}}, nil, nil, api.CoreFeaturesV1)
+
+	var errs funcErrors
+	require.True(t, errors.As(e, &errs))
+	require.Equal(t, 2, len(errs))
+	for i, fn := range []interface{}{t, func(string) {}} {
+		_, _, _, cause := parseGoReflectFunc(fn)
+		require.Equal(t, cause, errors.Unwrap(errs[i]))
+	}
+}
+
+func TestModule_EnableStrictHostResults(t *testing.T) {
+	i32, f32, i64 := ValueTypeI32, ValueTypeF32, ValueTypeI64
+	goFunc := &HostFunc{
+		ExportNames: []string{"go"},
+		Name:        "go",
+		ResultTypes: []ValueType{i32, f32, i64},
+		Code: &Code{IsHostFunction: true, GoFunc: api.GoFunc(func(ctx context.Context, stack []uint64) {
+			copy(stack, ctx.Value(struct{}{}).([]uint64))
+		})},
+	}
+	m, err := NewHostModule("env", map[string]interface{}{
+		"go":      goFunc,
+		"reflect": func() uint32 { return 1 },
+		"none": &HostFunc{
+			ExportNames: []string{"none"},
+			Name:        "none",
+			Code:        &Code{IsHostFunction: true, GoFunc: api.GoFunc(func(context.Context, []uint64) {})},
+		},
+	}, nil, nil, api.CoreFeaturesV2)
+	require.NoError(t, err)
+
+	before := append([]*Code{}, m.CodeSection...)
+	m.EnableStrictHostResults()
+
+	// Only the code of "go" is replaced, as it may be shared by goFunc.
+	require.True(t, before[0] != m.CodeSection[0])
+	require.True(t, goFunc.Code == before[0])
+	require.True(t, before[1] == m.CodeSection[1]) // no results
+	require.True(t, before[2] == m.CodeSection[2]) // reflective
+
+	strict := m.CodeSection[0].GoFunc.(api.GoFunction)
+	tests := []struct {
+		name        string
+		results     []uint64
+		expectedErr string
+	}{
+		{name: "in range", results: []uint64{math.MaxUint32, math.MaxUint32, math.MaxUint64}},
+		{name: "i32 sign-extended", results: []uint64{api.EncodeI32(-1), 0, 0}},
+		{name: "i32 overflow", results: []uint64{math.MaxUint32 + 1, 0, 0}, expectedErr: "result[0] 0x100000000 overflows i32"},
+		{name: "f32 overflow", results: []uint64{0, 1 << 32, 0}, expectedErr: "result[1] 0x100000000 overflows f32"},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), struct{}{}, tc.results)
+			err := require.CapturePanic(func() { strict.Call(ctx, make([]uint64, 3)) })
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
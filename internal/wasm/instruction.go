@@ -20,7 +20,17 @@ const (
 	// OpcodeElse brackets a sequence of instructions enclosed by an OpcodeIf. A branch instruction on a then label
 	// breaks out to after the OpcodeEnd on the enclosing OpcodeIf.
 	OpcodeElse Opcode = 0x05
-	// OpcodeEnd terminates a control instruction OpcodeBlock, OpcodeLoop or OpcodeIf.
+	// OpcodeTry brackets a sequence of instructions whose exceptions are handled by the OpcodeCatch and OpcodeCatchAll
+	// clauses which follow it. A branch instruction on a try label breaks out to after its OpcodeEnd.
+	OpcodeTry Opcode = 0x06
+	// OpcodeCatch brackets a sequence of instructions enclosed by an OpcodeTry, which handles exceptions of the tag
+	// in its immediate. The tag's params are pushed onto the stack.
+	OpcodeCatch Opcode = 0x07
+	// OpcodeThrow pops the params of the tag in its immediate and throws an exception of that tag.
+	OpcodeThrow Opcode = 0x08
+	// OpcodeRethrow throws the exception caught by the OpcodeCatch or OpcodeCatchAll label in its immediate.
+	OpcodeRethrow Opcode = 0x09
+	// OpcodeEnd terminates a control instruction OpcodeBlock, OpcodeLoop, OpcodeIf or OpcodeTry.
 	OpcodeEnd Opcode = 0x0b
 
 	// OpcodeBr is a stack-polymorphic opcode that performs an unconditional branch. How the stack is modified depends
@@ -43,6 +53,10 @@ const (
 	OpcodeCall         Opcode = 0x10
 	OpcodeCallIndirect Opcode = 0x11
 
+	// OpcodeCatchAll brackets a sequence of instructions enclosed by an OpcodeTry, which handles exceptions of any
+	// tag. It must be the last clause of the OpcodeTry.
+	OpcodeCatchAll Opcode = 0x19
+
 	// parametric instructions
 
 	OpcodeDrop        Opcode = 0x1a
@@ -623,6 +637,56 @@ const (
 	OpcodeVecF64x2PromoteLowF32x4Zero OpcodeVec = 0x5f
 )
 
+// OpcodeVecRelaxed represents an opcode of a relaxed vector instruction. These
+// are prefixed by OpcodeVecPrefix like OpcodeVec, but as they are at least
+// 0x100, their LEB128 encoding is two bytes.
+//
+// These opcodes are toggled with CoreFeatureRelaxedSIMD.
+type OpcodeVecRelaxed = uint32
+
+const (
+	OpcodeVecI8x16RelaxedSwizzle           OpcodeVecRelaxed = 0x100
+	OpcodeVecI32x4RelaxedTruncF32x4S       OpcodeVecRelaxed = 0x101
+	OpcodeVecI32x4RelaxedTruncF32x4U       OpcodeVecRelaxed = 0x102
+	OpcodeVecI32x4RelaxedTruncF64x2SZero   OpcodeVecRelaxed = 0x103
+	OpcodeVecI32x4RelaxedTruncF64x2UZero   OpcodeVecRelaxed = 0x104
+	OpcodeVecF32x4RelaxedMadd              OpcodeVecRelaxed = 0x105
+	OpcodeVecF32x4RelaxedNmadd             OpcodeVecRelaxed = 0x106
+	OpcodeVecF64x2RelaxedMadd              OpcodeVecRelaxed = 0x107
+	OpcodeVecF64x2RelaxedNmadd             OpcodeVecRelaxed = 0x108
+	OpcodeVecI8x16RelaxedLaneselect        OpcodeVecRelaxed = 0x109
+	OpcodeVecI16x8RelaxedLaneselect        OpcodeVecRelaxed = 0x10a
+	OpcodeVecI32x4RelaxedLaneselect        OpcodeVecRelaxed = 0x10b
+	OpcodeVecI64x2RelaxedLaneselect        OpcodeVecRelaxed = 0x10c
+	OpcodeVecF32x4RelaxedMin               OpcodeVecRelaxed = 0x10d
+	OpcodeVecF32x4RelaxedMax               OpcodeVecRelaxed = 0x10e
+	OpcodeVecF64x2RelaxedMin               OpcodeVecRelaxed = 0x10f
+	OpcodeVecF64x2RelaxedMax               OpcodeVecRelaxed = 0x110
+	OpcodeVecI16x8RelaxedQ15mulrS          OpcodeVecRelaxed = 0x111
+	OpcodeVecI16x8RelaxedDotI8x16I7x16S    OpcodeVecRelaxed = 0x112
+	OpcodeVecI32x4RelaxedDotI8x16I7x16AddS OpcodeVecRelaxed = 0x113
+)
+
+// RelaxedVecOpcode returns the relaxed vector opcode starting at body[pc],
+// which is the byte after OpcodeVecPrefix, and true. This returns false when
+// the opcode there isn't relaxed, including when it is a single byte.
+//
+// Note: Non-relaxed opcodes from 0x80 are also two bytes, but their second
+// byte is always 0x01, whereas relaxed ones are 0x02.
+func RelaxedVecOpcode(body []byte, pc uint64) (OpcodeVecRelaxed, bool) {
+	if pc+1 >= uint64(len(body)) || body[pc] < 0x80 || body[pc+1] != 0x02 {
+		return 0, false
+	}
+	return OpcodeVecRelaxed(body[pc]&0x7f) | 0x02<<7, true
+}
+
+// isRelaxedVecOpcode returns true if imm, the LEB128 decoded sub-opcode after
+// OpcodeVecPrefix, is relaxed. Like RelaxedVecOpcode, this requires the byte
+// after the first to be 0x02.
+func isRelaxedVecOpcode(imm uint32) bool {
+	return imm >= OpcodeVecI8x16RelaxedSwizzle && imm < 0x03<<7
+}
+
 const (
 	OpcodeUnreachableName       = "unreachable"
 	OpcodeNopName               = "nop"
@@ -630,6 +694,11 @@ const (
 	OpcodeLoopName              = "loop"
 	OpcodeIfName                = "if"
 	OpcodeElseName              = "else"
+	OpcodeTryName               = "try"
+	OpcodeCatchName             = "catch"
+	OpcodeThrowName             = "throw"
+	OpcodeRethrowName           = "rethrow"
+	OpcodeCatchAllName          = "catch_all"
 	OpcodeEndName               = "end"
 	OpcodeBrName                = "br"
 	OpcodeBrIfName              = "br_if"
@@ -824,6 +893,11 @@ var instructionNames = [256]string{
 	OpcodeLoop:              OpcodeLoopName,
 	OpcodeIf:                OpcodeIfName,
 	OpcodeElse:              OpcodeElseName,
+	OpcodeTry:               OpcodeTryName,
+	OpcodeCatch:             OpcodeCatchName,
+	OpcodeThrow:             OpcodeThrowName,
+	OpcodeRethrow:           OpcodeRethrowName,
+	OpcodeCatchAll:          OpcodeCatchAllName,
 	OpcodeEnd:               OpcodeEndName,
 	OpcodeBr:                OpcodeBrName,
 	OpcodeBrIf:              OpcodeBrIfName,
@@ -1548,3 +1622,55 @@ var vectorInstructionName = map[OpcodeVec]string{
 func VectorInstructionName(oc OpcodeVec) (ret string) {
 	return vectorInstructionName[oc]
 }
+
+const (
+	OpcodeVecI8x16RelaxedSwizzleName           = "i8x16.relaxed_swizzle"
+	OpcodeVecI32x4RelaxedTruncF32x4SName       = "i32x4.relaxed_trunc_f32x4_s"
+	OpcodeVecI32x4RelaxedTruncF32x4UName       = "i32x4.relaxed_trunc_f32x4_u"
+	OpcodeVecI32x4RelaxedTruncF64x2SZeroName   = "i32x4.relaxed_trunc_f64x2_s_zero"
+	OpcodeVecI32x4RelaxedTruncF64x2UZeroName   = "i32x4.relaxed_trunc_f64x2_u_zero"
+	OpcodeVecF32x4RelaxedMaddName              = "f32x4.relaxed_madd"
+	OpcodeVecF32x4RelaxedNmaddName             = "f32x4.relaxed_nmadd"
+	OpcodeVecF64x2RelaxedMaddName              = "f64x2.relaxed_madd"
+	OpcodeVecF64x2RelaxedNmaddName             = "f64x2.relaxed_nmadd"
+	OpcodeVecI8x16RelaxedLaneselectName        = "i8x16.relaxed_laneselect"
+	OpcodeVecI16x8RelaxedLaneselectName        = "i16x8.relaxed_laneselect"
+	OpcodeVecI32x4RelaxedLaneselectName        = "i32x4.relaxed_laneselect"
+	OpcodeVecI64x2RelaxedLaneselectName        = "i64x2.relaxed_laneselect"
+	OpcodeVecF32x4RelaxedMinName               = "f32x4.relaxed_min"
+	OpcodeVecF32x4RelaxedMaxName               = "f32x4.relaxed_max"
+	OpcodeVecF64x2RelaxedMinName               = "f64x2.relaxed_min"
+	OpcodeVecF64x2RelaxedMaxName               = "f64x2.relaxed_max"
+	OpcodeVecI16x8RelaxedQ15mulrSName          = "i16x8.relaxed_q15mulr_s"
+	OpcodeVecI16x8RelaxedDotI8x16I7x16SName    = "i16x8.relaxed_dot_i8x16_i7x16_s"
+	OpcodeVecI32x4RelaxedDotI8x16I7x16AddSName = "i32x4.relaxed_dot_i8x16_i7x16_add_s"
+)
+
+var relaxedVectorInstructionName = map[OpcodeVecRelaxed]string{
+	OpcodeVecI8x16RelaxedSwizzle:           OpcodeVecI8x16RelaxedSwizzleName,
+	OpcodeVecI32x4RelaxedTruncF32x4S:       OpcodeVecI32x4RelaxedTruncF32x4SName,
+	OpcodeVecI32x4RelaxedTruncF32x4U:       OpcodeVecI32x4RelaxedTruncF32x4UName,
+	OpcodeVecI32x4RelaxedTruncF64x2SZero:   OpcodeVecI32x4RelaxedTruncF64x2SZeroName,
+	OpcodeVecI32x4RelaxedTruncF64x2UZero:   OpcodeVecI32x4RelaxedTruncF64x2UZeroName,
+	OpcodeVecF32x4RelaxedMadd:              OpcodeVecF32x4RelaxedMaddName,
+	OpcodeVecF32x4RelaxedNmadd:             OpcodeVecF32x4RelaxedNmaddName,
+	OpcodeVecF64x2RelaxedMadd:              OpcodeVecF64x2RelaxedMaddName,
+	OpcodeVecF64x2RelaxedNmadd:             OpcodeVecF64x2RelaxedNmaddName,
+	OpcodeVecI8x16RelaxedLaneselect:        OpcodeVecI8x16RelaxedLaneselectName,
+	OpcodeVecI16x8RelaxedLaneselect:        OpcodeVecI16x8RelaxedLaneselectName,
+	OpcodeVecI32x4RelaxedLaneselect:        OpcodeVecI32x4RelaxedLaneselectName,
+	OpcodeVecI64x2RelaxedLaneselect:        OpcodeVecI64x2RelaxedLaneselectName,
+	OpcodeVecF32x4RelaxedMin:               OpcodeVecF32x4RelaxedMinName,
+	OpcodeVecF32x4RelaxedMax:               OpcodeVecF32x4RelaxedMaxName,
+	OpcodeVecF64x2RelaxedMin:               OpcodeVecF64x2RelaxedMinName,
+	OpcodeVecF64x2RelaxedMax:               OpcodeVecF64x2RelaxedMaxName,
+	OpcodeVecI16x8RelaxedQ15mulrS:          OpcodeVecI16x8RelaxedQ15mulrSName,
+	OpcodeVecI16x8RelaxedDotI8x16I7x16S:    OpcodeVecI16x8RelaxedDotI8x16I7x16SName,
+	OpcodeVecI32x4RelaxedDotI8x16I7x16AddS: OpcodeVecI32x4RelaxedDotI8x16I7x16AddSName,
+}
+
+// RelaxedVectorInstructionName returns the instruction name corresponding to
+// the relaxed vector Opcode.
+func RelaxedVectorInstructionName(oc OpcodeVecRelaxed) (ret string) {
+	return relaxedVectorInstructionName[oc]
+}
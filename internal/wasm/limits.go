@@ -0,0 +1,72 @@
+package wasm
+
+import "fmt"
+
+// ValidationLimits bound the size of a module, where zero fields are
+// unlimited. These are checked by Module.ValidateLimits.
+type ValidationLimits struct {
+	// MaxTypes is the maximum count of types in the type section.
+	MaxTypes uint32
+
+	// MaxTableSize is the maximum min size of each table defined in the
+	// module. Imported tables are bound by the module that defines them.
+	MaxTableSize uint32
+
+	// MaxFunctionLocals is the maximum count of locals in each function,
+	// including its parameters.
+	MaxFunctionLocals uint32
+
+	// MaxFunctionBodySize is the maximum size of each function body in
+	// bytes, excluding the declaration of its locals.
+	MaxFunctionBodySize uint32
+}
+
+// DefaultValidationLimits are the implementation limits of the WebAssembly
+// JavaScript Interface, which are shared by most runtimes.
+//
+// See https://webassembly.github.io/spec/js-api/#limits
+var DefaultValidationLimits = ValidationLimits{
+	MaxTypes:            1000000,
+	MaxTableSize:        10000000,
+	MaxFunctionLocals:   50000,
+	MaxFunctionBodySize: 7654321,
+}
+
+// ValidateLimits returns an error if the module exceeds any of the limits,
+// or nil if limits is nil.
+func (m *Module) ValidateLimits(limits *ValidationLimits) error {
+	if limits == nil {
+		return nil
+	}
+
+	if max, count := limits.MaxTypes, uint32(len(m.TypeSection)); max > 0 && count > max {
+		return fmt.Errorf("module has %d types, over limit of %d", count, max)
+	}
+
+	if max := limits.MaxTableSize; max > 0 {
+		importedTableCount := m.ImportTableCount()
+		for i, t := range m.TableSection {
+			if t.Min > max {
+				return fmt.Errorf("table[%d] min %d over limit of %d", importedTableCount+uint32(i), t.Min, max)
+			}
+		}
+	}
+
+	maxLocals, maxBodySize := limits.MaxFunctionLocals, limits.MaxFunctionBodySize
+	for i, code := range m.CodeSection {
+		idx := Index(i)
+		if maxLocals > 0 {
+			locals := uint64(len(code.LocalTypes))
+			if i < len(m.FunctionSection) && m.FunctionSection[i] < uint32(len(m.TypeSection)) {
+				locals += uint64(len(m.TypeSection[m.FunctionSection[i]].Params))
+			}
+			if locals > uint64(maxLocals) {
+				return fmt.Errorf("%s has %d locals, over limit of %d", m.funcDesc(SectionIDFunction, idx), locals, maxLocals)
+			}
+		}
+		if size := uint64(len(code.Body)); maxBodySize > 0 && size > uint64(maxBodySize) {
+			return fmt.Errorf("%s body is %d bytes, over limit of %d", m.funcDesc(SectionIDFunction, idx), size, maxBodySize)
+		}
+	}
+	return nil
+}
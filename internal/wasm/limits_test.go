@@ -0,0 +1,73 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_ValidateLimits(t *testing.T) {
+	max := uint32(5)
+	m := &Module{
+		TypeSection:     []*FunctionType{{Params: []ValueType{ValueTypeI32}}, {}},
+		ImportSection:   []*Import{{Type: ExternTypeTable, DescTable: &Table{Min: 100}}},
+		TableSection:    []*Table{{Min: 2, Max: &max}},
+		FunctionSection: []Index{0},
+		CodeSection:     []*Code{{LocalTypes: []ValueType{ValueTypeI64}, Body: []byte{OpcodeNop, OpcodeEnd}}},
+	}
+
+	tests := []struct {
+		name        string
+		limits      *ValidationLimits
+		expectedErr string
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name:   "zero is unlimited",
+			limits: &ValidationLimits{},
+		},
+		{
+			name:   "defaults",
+			limits: &DefaultValidationLimits,
+		},
+		{
+			name:   "at limits",
+			limits: &ValidationLimits{MaxTypes: 2, MaxTableSize: 2, MaxFunctionLocals: 2, MaxFunctionBodySize: 2},
+		},
+		{
+			name:        "MaxTypes",
+			limits:      &ValidationLimits{MaxTypes: 1},
+			expectedErr: "module has 2 types, over limit of 1",
+		},
+		{
+			name:        "MaxTableSize ignores imported tables",
+			limits:      &ValidationLimits{MaxTableSize: 1},
+			expectedErr: "table[1] min 2 over limit of 1",
+		},
+		{
+			name:        "MaxFunctionLocals includes params",
+			limits:      &ValidationLimits{MaxFunctionLocals: 1},
+			expectedErr: "function[0] has 2 locals, over limit of 1",
+		},
+		{
+			name:        "MaxFunctionBodySize",
+			limits:      &ValidationLimits{MaxFunctionBodySize: 1},
+			expectedErr: "function[0] body is 2 bytes, over limit of 1",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := m.ValidateLimits(tc.limits)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package wasm
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
+	"os"
 	"reflect"
 	"sync"
 	"unsafe"
@@ -35,10 +38,24 @@ var _ api.Memory = &MemoryInstance{}
 type MemoryInstance struct {
 	Buffer        []byte
 	Min, Cap, Max uint32
+	// Is64 is true when addresses are i64, per Memory.Is64.
+	Is64 bool
+	// isMaxEncoded is true when Max was defined by the module, as opposed to
+	// defaulted to the limit of the runtime.
+	isMaxEncoded bool
 	// mux is used to prevent overlapping calls to Grow.
 	mux sync.RWMutex
 	// definition is known at compile time.
 	definition api.MemoryDefinition
+	// growDeniedHook is invoked when Grow fails as it would exceed Max.
+	growDeniedHook func(requestedPages, currentPages, maxPages uint32)
+	// mapped is the copy-on-write mapping backing Buffer, until it grows
+	// beyond Cap, or nil if Buffer is on the heap. It stays mapped after
+	// growth, as views of it may still be in use, until mappedUsers is zero.
+	mapped []byte
+	// mappedUsers counts the open modules and calls in flight on them, which
+	// keep mapped alive. See releaseMapping.
+	mappedUsers int32
 }
 
 // NewMemoryInstance creates a new instance based on the parameters in the SectionIDMemory.
@@ -46,10 +63,12 @@ func NewMemoryInstance(memSec *Memory) *MemoryInstance {
 	min := MemoryPagesToBytesNum(memSec.Min)
 	capacity := MemoryPagesToBytesNum(memSec.Cap)
 	return &MemoryInstance{
-		Buffer: make([]byte, min, capacity),
-		Min:    memSec.Min,
-		Cap:    memSec.Cap,
-		Max:    memSec.Max,
+		Buffer:       make([]byte, min, capacity),
+		Min:          memSec.Min,
+		Cap:          memSec.Cap,
+		Max:          memSec.Max,
+		Is64:         memSec.Is64,
+		isMaxEncoded: memSec.IsMaxEncoded,
 	}
 }
 
@@ -63,6 +82,25 @@ func (m *MemoryInstance) Size(context.Context) uint32 {
 	return m.size()
 }
 
+// MaxSize implements the same method as documented on api.Memory.
+func (m *MemoryInstance) MaxSize(context.Context) (uint32, bool) {
+	maxSize := MemoryPagesToBytesNum(m.Max)
+	if maxSize > math.MaxUint32 {
+		maxSize = math.MaxUint32
+	}
+	return uint32(maxSize), m.isMaxEncoded
+}
+
+// MinPages implements the same method as documented on api.Memory.
+func (m *MemoryInstance) MinPages(context.Context) uint32 {
+	return m.Min
+}
+
+// MaxPages implements the same method as documented on api.Memory.
+func (m *MemoryInstance) MaxPages(context.Context) (uint32, bool) {
+	return m.Max, m.isMaxEncoded
+}
+
 // ReadByte implements the same method as documented on api.Memory.
 func (m *MemoryInstance) ReadByte(_ context.Context, offset uint32) (byte, bool) {
 	if offset >= m.size() {
@@ -98,6 +136,15 @@ func (m *MemoryInstance) ReadUint64Le(_ context.Context, offset uint32) (uint64,
 	return m.readUint64Le(offset)
 }
 
+// ReadPtr implements the same method as documented on api.Memory.
+func (m *MemoryInstance) ReadPtr(_ context.Context, offset uint32) (uint64, bool) {
+	if m.Is64 {
+		return m.readUint64Le(offset)
+	}
+	v, ok := m.readUint32Le(offset)
+	return uint64(v), ok
+}
+
 // ReadFloat64Le implements the same method as documented on api.Memory.
 func (m *MemoryInstance) ReadFloat64Le(_ context.Context, offset uint32) (float64, bool) {
 	v, ok := m.readUint64Le(offset)
@@ -115,6 +162,118 @@ func (m *MemoryInstance) Read(_ context.Context, offset, byteCount uint32) ([]by
 	return m.Buffer[offset : offset+byteCount : offset+byteCount], true
 }
 
+// ReadCopy implements the same method as documented on api.Memory.
+func (m *MemoryInstance) ReadCopy(_ context.Context, offset, byteCount uint32) ([]byte, bool) {
+	if !m.hasSize(offset, byteCount) {
+		return nil, false
+	}
+	ret := make([]byte, byteCount)
+	copy(ret, m.Buffer[offset:])
+	return ret, true
+}
+
+// Reader implements the same method as documented on api.Memory.
+func (m *MemoryInstance) Reader(ctx context.Context, offset, byteCount uint32) (*bytes.Reader, bool) {
+	buf, ok := m.Read(ctx, offset, byteCount)
+	if !ok {
+		return nil, false
+	}
+	return bytes.NewReader(buf), true
+}
+
+// writeToChunkSize is the max count of bytes WriteTo passes to io.Writer
+// Write at once.
+const writeToChunkSize = int(MemoryPageSize)
+
+// WriteTo implements the same method as documented on api.Memory.
+func (m *MemoryInstance) WriteTo(_ context.Context, offset, byteCount uint32, w io.Writer) (n int64, err error) {
+	end := uint64(offset) + uint64(byteCount) // uint64 prevents overflow on add
+	if size := uint64(len(m.Buffer)); end > size {
+		if uint64(offset) < size {
+			n, err = writeChunks(w, m.Buffer[offset:])
+		}
+		if err == nil {
+			err = fmt.Errorf("out of range writing %d bytes at offset %d of memory size %d",
+				byteCount, offset, m.size())
+		}
+		return
+	}
+	return writeChunks(w, m.Buffer[offset:end])
+}
+
+// writeChunks writes buf to w in chunks of at most writeToChunkSize.
+func writeChunks(w io.Writer, buf []byte) (n int64, err error) {
+	for len(buf) > 0 {
+		chunk := buf
+		if len(chunk) > writeToChunkSize {
+			chunk = chunk[:writeToChunkSize]
+		}
+		var written int
+		written, err = w.Write(chunk[:len(chunk):len(chunk)])
+		n += int64(written)
+		if err == nil && written < len(chunk) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return
+		}
+		buf = buf[written:]
+	}
+	return
+}
+
+// MustRead implements the same method as documented on api.Memory.
+func (m *MemoryInstance) MustRead(ctx context.Context, offset, byteCount uint32) []byte {
+	buf, ok := m.Read(ctx, offset, byteCount)
+	if !ok {
+		m.panicOutOfRange(offset, byteCount)
+	}
+	return buf
+}
+
+// MustReadByte implements the same method as documented on api.Memory.
+func (m *MemoryInstance) MustReadByte(ctx context.Context, offset uint32) byte {
+	v, ok := m.ReadByte(ctx, offset)
+	if !ok {
+		m.panicOutOfRange(offset, 1)
+	}
+	return v
+}
+
+// MustReadUint16Le implements the same method as documented on api.Memory.
+func (m *MemoryInstance) MustReadUint16Le(ctx context.Context, offset uint32) uint16 {
+	v, ok := m.ReadUint16Le(ctx, offset)
+	if !ok {
+		m.panicOutOfRange(offset, 2)
+	}
+	return v
+}
+
+// MustReadUint32Le implements the same method as documented on api.Memory.
+func (m *MemoryInstance) MustReadUint32Le(_ context.Context, offset uint32) uint32 {
+	v, ok := m.readUint32Le(offset)
+	if !ok {
+		m.panicOutOfRange(offset, 4)
+	}
+	return v
+}
+
+// MustReadUint64Le implements the same method as documented on api.Memory.
+func (m *MemoryInstance) MustReadUint64Le(_ context.Context, offset uint32) uint64 {
+	v, ok := m.readUint64Le(offset)
+	if !ok {
+		m.panicOutOfRange(offset, 8)
+	}
+	return v
+}
+
+// panicOutOfRange is used by the Must functions when a read of byteCount
+// bytes at offset is out of range.
+func (m *MemoryInstance) panicOutOfRange(offset, byteCount uint32) {
+	panic(fmt.Errorf("out of range reading %d bytes at offset %d of memory size %d",
+		byteCount, offset, m.size()))
+}
+
 // WriteByte implements the same method as documented on api.Memory.
 func (m *MemoryInstance) WriteByte(_ context.Context, offset uint32, v byte) bool {
 	if offset >= m.size() {
@@ -148,6 +307,16 @@ func (m *MemoryInstance) WriteUint64Le(_ context.Context, offset uint32, v uint6
 	return m.writeUint64Le(offset, v)
 }
 
+// WritePtr implements the same method as documented on api.Memory.
+func (m *MemoryInstance) WritePtr(_ context.Context, offset uint32, v uint64) bool {
+	if m.Is64 {
+		return m.writeUint64Le(offset, v)
+	} else if v > math.MaxUint32 {
+		return false
+	}
+	return m.writeUint32Le(offset, uint32(v))
+}
+
 // WriteFloat64Le implements the same method as documented on api.Memory.
 func (m *MemoryInstance) WriteFloat64Le(_ context.Context, offset uint32, v float64) bool {
 	return m.writeUint64Le(offset, math.Float64bits(v))
@@ -163,12 +332,21 @@ func (m *MemoryInstance) Write(_ context.Context, offset uint32, val []byte) boo
 }
 
 // WriteString implements the same method as documented on api.Memory.
-func (m *MemoryInstance) WriteString(_ context.Context, offset uint32, val string) bool {
+func (m *MemoryInstance) WriteString(ctx context.Context, offset uint32, val string) bool {
+	_, ok := m.WriteStringN(ctx, offset, val)
+	return ok
+}
+
+// WriteStringN implements the same method as documented on api.Memory.
+func (m *MemoryInstance) WriteStringN(_ context.Context, offset uint32, val string) (uint32, bool) {
 	if !m.hasSize(offset, uint32(len(val))) {
-		return false
+		if bufLen := uint32(len(m.Buffer)); offset < bufLen {
+			return bufLen - offset, false
+		}
+		return 0, false
 	}
 	copy(m.Buffer[offset:], val)
-	return true
+	return uint32(len(val)), true
 }
 
 // MemoryPagesToBytesNum converts the given pages into the number of bytes contained in these pages.
@@ -178,11 +356,24 @@ func MemoryPagesToBytesNum(pages uint32) (bytesNum uint64) {
 
 // Grow implements the same method as documented on api.Memory.
 func (m *MemoryInstance) Grow(_ context.Context, delta uint32) (result uint32, ok bool) {
+	currentPages, ok := m.grow(delta)
+	if !ok {
+		// Invoke the hook outside the lock, so that it can read memory.
+		if m.growDeniedHook != nil {
+			m.growDeniedHook(delta, currentPages, m.Max)
+		}
+		return 0, false
+	}
+	return currentPages, true
+}
+
+// grow is like Grow, except it returns the current pages even when not ok.
+func (m *MemoryInstance) grow(delta uint32) (currentPages uint32, ok bool) {
 	// We take write-lock here as the following might result in a new slice
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
-	currentPages := memoryBytesNumToPages(uint64(len(m.Buffer)))
+	currentPages = memoryBytesNumToPages(uint64(len(m.Buffer)))
 	if delta == 0 {
 		return currentPages, true
 	}
@@ -190,7 +381,7 @@ func (m *MemoryInstance) Grow(_ context.Context, delta uint32) (result uint32, o
 	// If exceeds the max of memory size, we push -1 according to the spec.
 	newPages := currentPages + delta
 	if newPages > m.Max {
-		return 0, false
+		return currentPages, false
 	} else if newPages > m.Cap { // grow the memory.
 		m.Buffer = append(m.Buffer, make([]byte, MemoryPagesToBytesNum(delta))...)
 		m.Cap = newPages
@@ -202,6 +393,24 @@ func (m *MemoryInstance) Grow(_ context.Context, delta uint32) (result uint32, o
 	}
 }
 
+// Prefault writes zero to every OS page of Buffer that reads zero, so that
+// the operating system commits them now instead of on the first guest write.
+// Pages that read non-zero were already written, so are left alone.
+//
+// This must be called before the memory is visible to other modules, as a
+// concurrent guest write to a page which read zero would be lost.
+func (m *MemoryInstance) Prefault() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	buf := m.Buffer
+	for i, n := 0, os.Getpagesize(); i < len(buf); i += n {
+		if buf[i] == 0 {
+			buf[i] = 0
+		}
+	}
+}
+
 // PageSize returns the current memory buffer size in pages.
 func (m *MemoryInstance) PageSize(context.Context) (result uint32) {
 	return memoryBytesNumToPages(uint64(len(m.Buffer)))
@@ -245,6 +454,13 @@ func (m *MemoryInstance) hasSize(offset uint32, byteCount uint32) bool {
 	return uint64(offset)+uint64(byteCount) <= uint64(len(m.Buffer)) // uint64 prevents overflow on add
 }
 
+// HasSize64 is like hasSize, except for 64-bit offsets and byte counts, such
+// as the operands of bulk memory instructions in a 64-bit memory.
+func (m *MemoryInstance) HasSize64(offset, byteCount uint64) bool {
+	bufLen := uint64(len(m.Buffer))
+	return byteCount <= bufLen && offset <= bufLen-byteCount // subtraction prevents overflow on add
+}
+
 // readUint32Le implements ReadUint32Le without using a context. This is extracted as both ints and floats are stored in
 // memory as uint32le.
 func (m *MemoryInstance) readUint32Le(offset uint32) (uint32, bool) {
@@ -282,3 +498,53 @@ func (m *MemoryInstance) writeUint64Le(offset uint32, v uint64) bool {
 	binary.LittleEndian.PutUint64(m.Buffer[offset:], v)
 	return true
 }
+
+// memoryStream implements io.ReadWriteSeeker over the current buffer of a
+// MemoryInstance.
+type memoryStream struct {
+	mem *MemoryInstance
+	// offset is the position of the next Read or Write, which may be past
+	// the end of the buffer.
+	offset int64
+}
+
+// Read implements io.Reader
+func (s *memoryStream) Read(p []byte) (int, error) {
+	buf := s.mem.Buffer
+	if s.offset >= int64(len(buf)) {
+		return 0, io.EOF
+	}
+	n := copy(p, buf[s.offset:])
+	s.offset += int64(n)
+	return n, nil
+}
+
+// Write implements io.Writer
+func (s *memoryStream) Write(p []byte) (n int, err error) {
+	if buf := s.mem.Buffer; s.offset < int64(len(buf)) {
+		n = copy(buf[s.offset:], p)
+	}
+	s.offset += int64(n)
+	if n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return
+}
+
+// Seek implements io.Seeker
+func (s *memoryStream) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.offset
+	case io.SeekEnd:
+		offset += int64(len(s.mem.Buffer))
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset: %d", offset)
+	}
+	s.offset = offset
+	return offset, nil
+}
@@ -29,8 +29,10 @@ func (m *Module) ExportedMemories() map[string]api.MemoryDefinition {
 // Note: This is exported for wazero.Runtime `CompileModule`.
 func (m *Module) BuildMemoryDefinitions() {
 	var moduleName string
+	var memoryNames NameMap
 	if m.NameSection != nil {
 		moduleName = m.NameSection.ModuleName
+		memoryNames = m.NameSection.MemoryNames
 	}
 
 	memoryCount := m.ImportMemoryCount()
@@ -66,6 +68,12 @@ func (m *Module) BuildMemoryDefinitions() {
 
 	for _, d := range m.MemoryDefinitionSection {
 		d.moduleName = moduleName
+		for _, n := range memoryNames {
+			if n.Index == d.index {
+				d.name = n.Name
+				break
+			}
+		}
 		for _, e := range m.ExportSection {
 			if e.Type == ExternTypeMemory && e.Index == d.index {
 				d.exportNames = append(d.exportNames, e.Name)
@@ -78,6 +86,7 @@ func (m *Module) BuildMemoryDefinitions() {
 type MemoryDefinition struct {
 	moduleName  string
 	index       Index
+	name        string
 	importDesc  *[2]string
 	exportNames []string
 	memory      *Memory
@@ -93,6 +102,11 @@ func (f *MemoryDefinition) Index() uint32 {
 	return f.index
 }
 
+// Name implements the same method as documented on api.MemoryDefinition.
+func (f *MemoryDefinition) Name() string {
+	return f.name
+}
+
 // Import implements the same method as documented on api.MemoryDefinition.
 func (f *MemoryDefinition) Import() (moduleName, name string, isImport bool) {
 	if importDesc := f.importDesc; importDesc != nil {
@@ -111,6 +125,16 @@ func (f *MemoryDefinition) Min() uint32 {
 	return f.memory.Min
 }
 
+// IsShared implements the same method as documented on api.MemoryDefinition.
+func (f *MemoryDefinition) IsShared() bool {
+	return f.memory.IsShared
+}
+
+// Is64 implements the same method as documented on api.MemoryDefinition.
+func (f *MemoryDefinition) Is64() bool {
+	return f.memory.Is64
+}
+
 // Max implements the same method as documented on api.MemoryDefinition.
 func (f *MemoryDefinition) Max() (max uint32, encoded bool) {
 	max = f.memory.Max
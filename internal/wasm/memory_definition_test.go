@@ -34,6 +34,20 @@ func TestModule_BuildMemoryDefinitions(t *testing.T) {
 			expected:        []*MemoryDefinition{{index: 0, memory: &Memory{Min: 0}}},
 			expectedExports: map[string]api.MemoryDefinition{},
 		},
+		{
+			name: "defines named memory",
+			m: &Module{
+				MemorySection: &Memory{Min: 1},
+				NameSection: &NameSection{
+					ModuleName:  "test",
+					MemoryNames: NameMap{{Index: 0, Name: "mem"}},
+				},
+			},
+			expected: []*MemoryDefinition{
+				{moduleName: "test", index: 0, name: "mem", memory: &Memory{Min: 1}},
+			},
+			expectedExports: map[string]api.MemoryDefinition{},
+		},
 		{
 			name: "exports defined memory{2,3}",
 			m: &Module{
@@ -119,3 +133,13 @@ func TestModule_BuildMemoryDefinitions(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryDefinition_IsShared(t *testing.T) {
+	require.False(t, (&MemoryDefinition{memory: &Memory{Min: 1}}).IsShared())
+	require.True(t, (&MemoryDefinition{memory: &Memory{Min: 1, Max: 2, IsMaxEncoded: true, IsShared: true}}).IsShared())
+}
+
+func TestMemoryDefinition_Is64(t *testing.T) {
+	require.False(t, (&MemoryDefinition{memory: &Memory{Min: 1}}).Is64())
+	require.True(t, (&MemoryDefinition{memory: &Memory{Min: 1, Is64: true}}).Is64())
+}
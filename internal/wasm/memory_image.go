@@ -0,0 +1,191 @@
+package wasm
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/platform"
+)
+
+// memoryImage lazily builds the initial contents of a module's memory, so
+// that each instance maps it copy-on-write instead of copying data segments.
+type memoryImage struct {
+	once sync.Once
+	// image is nil if it wasn't possible to build.
+	image *platform.MemoryImage
+}
+
+// EnableCopyOnWriteMemory makes instances of this module map their memory
+// copy-on-write from an image of the active data segments. This is a no-op if
+// the platform or the module's memory doesn't support it.
+//
+// Supported modules define a 32-bit memory with a non-zero capacity, and only
+// have active data segments with constant offsets in bounds of its minimum
+// size. Otherwise, instantiation copies data segments as usual.
+func (m *Module) EnableCopyOnWriteMemory() {
+	memSec := m.MemorySection
+	if !platform.CopyOnWriteSupported() || memSec == nil || memSec.Is64 || memSec.Cap == 0 {
+		return
+	}
+	if capBytes := MemoryPagesToBytesNum(memSec.Cap); uint64(int(capBytes)) != capBytes {
+		return // The image wouldn't be addressable, e.g. 4GiB on a 32-bit platform.
+	}
+	minBytes := int64(MemoryPagesToBytesNum(memSec.Min))
+	for _, d := range m.DataSection {
+		if d.IsPassive() {
+			continue
+		}
+		if d.OffsetExpression.Opcode != OpcodeI32Const {
+			return // e.g. global.get, which differs per instance.
+		}
+		offset, _, _ := leb128.LoadInt32(d.OffsetExpression.Data)
+		if offset < 0 || int64(offset)+int64(len(d.Init)) > minBytes {
+			return // Let instantiation fail as usual.
+		}
+	}
+	m.memoryImage = &memoryImage{}
+}
+
+// ReleaseMemoryImage closes any image built by EnableCopyOnWriteMemory.
+// Memory of existing instances is unaffected.
+func (m *Module) ReleaseMemoryImage() {
+	if mi := m.memoryImage; mi != nil {
+		mi.once.Do(func() {}) // Prevent building the image concurrently.
+		if mi.image != nil {
+			_ = mi.image.Close()
+		}
+	}
+}
+
+// buildMemoryFromImage is like buildMemory, except the active data segments
+// are already applied. This returns nil if copy-on-write isn't enabled or
+// failed, in which case the caller should fall back to buildMemory.
+func (m *Module) buildMemoryFromImage() *MemoryInstance {
+	mi := m.memoryImage
+	if mi == nil {
+		return nil
+	}
+	mi.once.Do(func() { mi.image = m.newMemoryImage() })
+	if mi.image == nil {
+		return nil
+	}
+
+	memSec := m.MemorySection
+	mapped, err := mi.image.Map()
+	if err != nil {
+		return nil
+	}
+	return &MemoryInstance{
+		Buffer:       mapped[:MemoryPagesToBytesNum(memSec.Min)],
+		Min:          memSec.Min,
+		Cap:          memSec.Cap,
+		Max:          memSec.Max,
+		isMaxEncoded: memSec.IsMaxEncoded,
+		mapped:       mapped,
+	}
+}
+
+// retainMappings keeps the copy-on-write memories of this module, and those
+// kept by the modules it imports, mapped until releaseMappings. Functions of
+// this module may access any of them, e.g. by calling an imported function.
+func (m *ModuleInstance) retainMappings(imported map[string]*ModuleInstance) {
+	m.retainMapping(m.Memory)
+	for _, im := range imported {
+		for _, mem := range im.mappedMemories {
+			m.retainMapping(mem)
+		}
+	}
+}
+
+func (m *ModuleInstance) retainMapping(mem *MemoryInstance) {
+	if mem == nil || mem.mapped == nil {
+		return
+	}
+	for _, retained := range m.mappedMemories {
+		if retained == mem {
+			return
+		}
+	}
+	atomic.AddInt32(&mem.mappedUsers, 1)
+	m.mappedMemories = append(m.mappedMemories, mem)
+}
+
+// releaseMappings undoes retainMappings, when the module is closed or failed
+// to instantiate, or retainCallMappings, when the call returns.
+func (m *ModuleInstance) releaseMappings() {
+	for _, mem := range m.mappedMemories {
+		mem.releaseMapping()
+	}
+}
+
+// retainCallMappings keeps the copy-on-write memories of this module mapped
+// during a call, even if the module is closed meanwhile. This returns false,
+// retaining none, if the module was already closed and any is released.
+func (m *ModuleInstance) retainCallMappings() bool {
+	for i, mem := range m.mappedMemories {
+		if !mem.tryRetainMapping() {
+			for _, retained := range m.mappedMemories[:i] {
+				retained.releaseMapping()
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// tryRetainMapping adds a user of the mapping, unless it has none left, in
+// which case it is, or is about to be, unmapped.
+func (m *MemoryInstance) tryRetainMapping() bool {
+	for {
+		users := atomic.LoadInt32(&m.mappedUsers)
+		if users == 0 {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&m.mappedUsers, users, users+1) {
+			return true
+		}
+	}
+}
+
+// releaseMapping removes a user of the mapping, unmapping it when none is
+// left. Open modules and calls in flight on them are users, as a call may
+// still access the memory, e.g. if it closed its own module.
+func (m *MemoryInstance) releaseMapping() {
+	if atomic.AddInt32(&m.mappedUsers, -1) == 0 {
+		m.unmap()
+	}
+}
+
+// unmap releases the copy-on-write mapping. If Buffer is still backed by it,
+// Buffer is cleared, so that later access, e.g. calling a function of the
+// closed module, is out of range instead of a fault.
+func (m *MemoryInstance) unmap() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if cap(m.Buffer) > 0 && &m.Buffer[:1][0] == &m.mapped[0] {
+		m.Buffer = nil
+	}
+	_ = platform.UnmapMemoryImage(m.mapped)
+	m.mapped = nil
+}
+
+// newMemoryImage returns an image of the memory capacity, holding the active
+// data segments, or nil on error.
+func (m *Module) newMemoryImage() *platform.MemoryImage {
+	image, err := platform.NewMemoryImage(int(MemoryPagesToBytesNum(m.MemorySection.Cap)))
+	if err != nil {
+		return nil
+	}
+	for _, d := range m.DataSection {
+		if d.IsPassive() {
+			continue
+		}
+		offset, _, _ := leb128.LoadInt32(d.OffsetExpression.Data)
+		if _, err = image.WriteAt(d.Init, int64(offset)); err != nil {
+			_ = image.Close()
+			return nil
+		}
+	}
+	return image
+}
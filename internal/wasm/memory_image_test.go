@@ -0,0 +1,179 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_EnableCopyOnWriteMemory(t *testing.T) {
+	if !platform.CopyOnWriteSupported() {
+		t.Skip()
+	}
+
+	i32Const := func(v int32) *ConstantExpression {
+		return &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeInt32(v)}
+	}
+
+	tests := []struct {
+		name     string
+		module   *Module
+		expected bool
+	}{
+		{name: "no memory", module: &Module{}},
+		{name: "zero capacity", module: &Module{MemorySection: &Memory{Max: 1}}},
+		{name: "memory64", module: &Module{MemorySection: &Memory{Min: 1, Cap: 1, Max: 1, Is64: true}}},
+		{
+			name:     "no data",
+			module:   &Module{MemorySection: &Memory{Min: 1, Cap: 1, Max: 1}},
+			expected: true,
+		},
+		{
+			name: "active and passive data",
+			module: &Module{
+				MemorySection: &Memory{Min: 1, Cap: 1, Max: 1},
+				DataSection: []*DataSegment{
+					{OffsetExpression: i32Const(65534), Init: []byte{1, 2}},
+					{Init: []byte{1, 2, 3}},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "global offset",
+			module: &Module{
+				MemorySection: &Memory{Min: 1, Cap: 1, Max: 1},
+				DataSection: []*DataSegment{
+					{OffsetExpression: &ConstantExpression{Opcode: OpcodeGlobalGet, Data: []byte{0}}, Init: []byte{1}},
+				},
+			},
+		},
+		{
+			name: "out of bounds",
+			module: &Module{
+				MemorySection: &Memory{Min: 1, Cap: 2, Max: 2},
+				DataSection:   []*DataSegment{{OffsetExpression: i32Const(65535), Init: []byte{1, 2}}},
+			},
+		},
+		{
+			name: "negative offset",
+			module: &Module{
+				MemorySection: &Memory{Min: 1, Cap: 1, Max: 1},
+				DataSection:   []*DataSegment{{OffsetExpression: i32Const(-1), Init: []byte{1}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			tc.module.EnableCopyOnWriteMemory()
+			require.Equal(t, tc.expected, tc.module.memoryImage != nil)
+			if !tc.expected {
+				require.Nil(t, tc.module.buildMemoryFromImage())
+			}
+		})
+	}
+}
+
+func TestModule_buildMemoryFromImage(t *testing.T) {
+	if !platform.CopyOnWriteSupported() {
+		t.Skip()
+	}
+
+	m := &Module{
+		MemorySection: &Memory{Min: 1, Cap: 2, Max: 3},
+		DataSection: []*DataSegment{
+			{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeInt32(1)}, Init: []byte{1, 2}},
+			{Init: []byte{3, 4}}, // passive
+		},
+	}
+	m.EnableCopyOnWriteMemory()
+	defer m.ReleaseMemoryImage()
+
+	mem1, mem2 := m.buildMemoryFromImage(), m.buildMemoryFromImage()
+	require.Equal(t, uint32(1), mem1.Min)
+	require.Equal(t, uint32(2), mem1.Cap)
+	require.Equal(t, uint32(3), mem1.Max)
+	require.Equal(t, int(MemoryPageSize), len(mem1.Buffer))
+	require.Equal(t, []byte{0, 1, 2, 0}, mem1.Buffer[:4])
+
+	// Writes are not visible to other instances.
+	mem1.Buffer[1] = 42
+	require.Equal(t, []byte{0, 1, 2, 0}, mem2.Buffer[:4])
+
+	// Growing within capacity exposes zeroed pages of the mapping.
+	_, ok := mem1.grow(1)
+	require.True(t, ok)
+	require.Equal(t, 2*int(MemoryPageSize), len(mem1.Buffer))
+	require.Equal(t, byte(0), mem1.Buffer[MemoryPageSize])
+
+	// Growing beyond capacity moves the contents to the heap.
+	_, ok = mem1.grow(1)
+	require.True(t, ok)
+	require.Equal(t, []byte{0, 42, 2, 0}, mem1.Buffer[:4])
+
+	// Once released, instances fall back to copying.
+	m.ReleaseMemoryImage()
+	require.Nil(t, m.buildMemoryFromImage())
+}
+
+func TestModuleInstance_releaseMappings(t *testing.T) {
+	if !platform.CopyOnWriteSupported() {
+		t.Skip()
+	}
+
+	m := &Module{MemorySection: &Memory{Min: 1, Cap: 1, Max: 1}}
+	m.EnableCopyOnWriteMemory()
+	defer m.ReleaseMemoryImage()
+
+	newModules := func() (exporter, importer *ModuleInstance, mem *MemoryInstance) {
+		mem = m.buildMemoryFromImage()
+		exporter = &ModuleInstance{Memory: mem}
+		exporter.retainMappings(nil)
+		importer = &ModuleInstance{}
+		importer.retainMappings(map[string]*ModuleInstance{"exporter": exporter})
+		require.Equal(t, int32(2), mem.mappedUsers)
+		return
+	}
+
+	t.Run("modules", func(t *testing.T) {
+		exporter, importer, mem := newModules()
+
+		// The importer keeps the memory mapped.
+		exporter.releaseMappings()
+		require.NotNil(t, mem.mapped)
+
+		importer.releaseMappings()
+		require.Nil(t, mem.mapped)
+		require.Nil(t, mem.Buffer)
+	})
+
+	t.Run("call in flight", func(t *testing.T) {
+		exporter, importer, mem := newModules()
+
+		// Unmapping is deferred until the call returns, regardless of calls
+		// on other modules.
+		require.True(t, importer.retainCallMappings())
+		other, _, otherMem := newModules()
+		require.True(t, other.retainCallMappings())
+		exporter.releaseMappings()
+		importer.releaseMappings()
+		require.NotNil(t, mem.mapped)
+		importer.releaseMappings() // the call returns.
+		require.Nil(t, mem.mapped)
+		require.NotNil(t, otherMem.mapped)
+		other.releaseMappings()
+	})
+
+	t.Run("call after close", func(t *testing.T) {
+		exporter, importer, mem := newModules()
+		exporter.releaseMappings()
+		importer.releaseMappings()
+
+		require.False(t, importer.retainCallMappings())
+		require.Equal(t, int32(0), mem.mappedUsers)
+	})
+}
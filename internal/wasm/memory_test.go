@@ -1,7 +1,9 @@
 package wasm
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"math"
 	"strings"
 	"testing"
@@ -93,6 +95,67 @@ func TestMemoryInstance_Grow_Size(t *testing.T) {
 	}
 }
 
+func TestMemoryInstance_MaxSize(t *testing.T) {
+	tests := []struct {
+		name            string
+		memSec          *Memory
+		expectedMaxSize uint32
+		expectedBounded bool
+	}{
+		{
+			name:            "bounded",
+			memSec:          &Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true},
+			expectedMaxSize: 2 * MemoryPageSize,
+			expectedBounded: true,
+		},
+		{
+			name:            "bounded zero",
+			memSec:          &Memory{IsMaxEncoded: true},
+			expectedBounded: true,
+		},
+		{
+			name:            "unbounded",
+			memSec:          &Memory{Min: 1, Cap: 1, Max: 3},
+			expectedMaxSize: 3 * MemoryPageSize,
+		},
+		{
+			name:            "unbounded at the limit",
+			memSec:          &Memory{Min: 1, Cap: 1, Max: MemoryLimitPages},
+			expectedMaxSize: math.MaxUint32,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMemoryInstance(tc.memSec)
+			maxSize, bounded := m.MaxSize(testCtx)
+			require.Equal(t, tc.expectedMaxSize, maxSize)
+			require.Equal(t, tc.expectedBounded, bounded)
+
+			require.Equal(t, tc.memSec.Min, m.MinPages(testCtx))
+			maxPages, bounded := m.MaxPages(testCtx)
+			require.Equal(t, tc.memSec.Max, maxPages)
+			require.Equal(t, tc.expectedBounded, bounded)
+		})
+	}
+}
+
+func TestMemoryInstance_Prefault(t *testing.T) {
+	m := NewMemoryInstance(&Memory{Min: 2, Cap: 2, Max: 2})
+	m.Buffer[0] = 1
+	m.Buffer[MemoryPageSize-1] = 2
+
+	m.Prefault()
+
+	// Prefaulting doesn't change the contents of memory.
+	expected := make([]byte, 2*MemoryPageSize)
+	expected[0] = 1
+	expected[MemoryPageSize-1] = 2
+	require.Equal(t, expected, m.Buffer)
+}
+
 func TestMemoryInstance_ReadByte(t *testing.T) {
 	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
 		mem := &MemoryInstance{Buffer: []byte{0, 0, 0, 0, 0, 0, 0, 16}, Min: 1}
@@ -308,6 +371,41 @@ func TestMemoryInstance_ReadUint32Le(t *testing.T) {
 	}
 }
 
+func TestMemoryInstance_Ptr(t *testing.T) {
+	tests := []struct {
+		name         string
+		is64         bool
+		expectedSize uint32
+	}{
+		{name: "32-bit", expectedSize: 4},
+		{name: "64-bit", is64: true, expectedSize: 8},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			m := &MemoryInstance{Buffer: make([]byte, 16), Is64: tc.is64}
+			require.True(t, m.WritePtr(testCtx, 0, 0x01020304))
+			v, ok := m.ReadPtr(testCtx, 0)
+			require.True(t, ok)
+			require.Equal(t, uint64(0x01020304), v)
+
+			// The last pointer in range ends at the end of memory.
+			last := uint32(len(m.Buffer)) - tc.expectedSize
+			require.True(t, m.WritePtr(testCtx, last, 1))
+			_, ok = m.ReadPtr(testCtx, last)
+			require.True(t, ok)
+			require.False(t, m.WritePtr(testCtx, last+1, 1))
+			_, ok = m.ReadPtr(testCtx, last+1)
+			require.False(t, ok)
+
+			// Only 64-bit pointers can be larger than uint32.
+			require.Equal(t, tc.is64, m.WritePtr(testCtx, 0, math.MaxUint32+1))
+		})
+	}
+}
+
 func TestMemoryInstance_ReadUint64Le(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -482,6 +580,172 @@ func TestMemoryInstance_Read(t *testing.T) {
 	}
 }
 
+func TestMemoryInstance_ReadCopy(t *testing.T) {
+	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
+		mem := &MemoryInstance{Buffer: []byte{0, 0, 0, 0, 16, 0, 0, 0}, Min: 1}
+
+		buf, ok := mem.ReadCopy(ctx, 4, 4)
+		require.True(t, ok)
+		require.Equal(t, []byte{16, 0, 0, 0}, buf)
+
+		// Test the result is a copy in both directions.
+		buf[3] = 4
+		mem.Buffer[4] = 32
+		require.Equal(t, []byte{16, 0, 0, 4}, buf)
+		require.Equal(t, []byte{0, 0, 0, 0, 32, 0, 0, 0}, mem.Buffer)
+
+		// Test zero length is an empty slice, not nil, as it is in range.
+		buf, ok = mem.ReadCopy(ctx, 8, 0)
+		require.True(t, ok)
+		require.Equal(t, []byte{}, buf)
+
+		_, ok = mem.ReadCopy(ctx, 5, 4)
+		require.False(t, ok)
+
+		_, ok = mem.ReadCopy(ctx, 9, 4)
+		require.False(t, ok)
+	}
+}
+
+func TestMemoryInstance_Reader(t *testing.T) {
+	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
+		mem := &MemoryInstance{Buffer: []byte{0, 0, 0, 0, 16, 0, 0, 0}, Min: 1}
+
+		r, ok := mem.Reader(ctx, 4, 4)
+		require.True(t, ok)
+		buf, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, []byte{16, 0, 0, 0}, buf)
+
+		// Test the reader is a view, not a copy.
+		r, _ = mem.Reader(ctx, 4, 4)
+		mem.Buffer[7] = 4
+		buf, err = io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, []byte{16, 0, 0, 4}, buf)
+
+		_, ok = mem.Reader(ctx, 5, 4)
+		require.False(t, ok)
+
+		_, ok = mem.Reader(ctx, 9, 4)
+		require.False(t, ok)
+	}
+}
+
+func TestMemoryInstance_WriteTo(t *testing.T) {
+	const size = 1 << 20 // 1MiB
+	mem := &MemoryInstance{Buffer: make([]byte, size+MemoryPageSize), Min: 17}
+	for i := range mem.Buffer {
+		mem.Buffer[i] = byte(i)
+	}
+
+	t.Run("1MiB", func(t *testing.T) {
+		w := &chunkRecorder{}
+		n, err := mem.WriteTo(testCtx, 1, size, w)
+		require.NoError(t, err)
+		require.Equal(t, int64(size), n)
+		require.Equal(t, mem.Buffer[1:size+1], w.Bytes())
+		require.Equal(t, int(MemoryPageSize), w.maxChunk)
+	})
+
+	t.Run("zero", func(t *testing.T) {
+		w := &chunkRecorder{}
+		n, err := mem.WriteTo(testCtx, uint32(len(mem.Buffer)), 0, w)
+		require.NoError(t, err)
+		require.Zero(t, n)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		w := &chunkRecorder{}
+		offset := uint32(len(mem.Buffer) - 2)
+		n, err := mem.WriteTo(testCtx, offset, 4, w)
+		require.EqualError(t, err, "out of range writing 4 bytes at offset 1114110 of memory size 1114112")
+		require.Equal(t, int64(2), n)
+		require.Equal(t, mem.Buffer[offset:], w.Bytes())
+
+		n, err = mem.WriteTo(testCtx, offset+4, 4, w)
+		require.Error(t, err)
+		require.Zero(t, n)
+	})
+
+	t.Run("writer error", func(t *testing.T) {
+		n, err := mem.WriteTo(testCtx, 0, size, &errWriter{n: 10})
+		require.Equal(t, io.ErrClosedPipe, err)
+		require.Equal(t, int64(10), n)
+	})
+}
+
+// chunkRecorder is a bytes.Buffer which records the largest Write.
+type chunkRecorder struct {
+	bytes.Buffer
+	maxChunk int
+}
+
+func (w *chunkRecorder) Write(p []byte) (int, error) {
+	if len(p) > w.maxChunk {
+		w.maxChunk = len(p)
+	}
+	return w.Buffer.Write(p)
+}
+
+// errWriter writes n bytes, then fails with io.ErrClosedPipe.
+type errWriter struct{ n int }
+
+func (w *errWriter) Write([]byte) (int, error) {
+	return w.n, io.ErrClosedPipe
+}
+
+func TestMemoryInstance_MustRead(t *testing.T) {
+	mem := &MemoryInstance{Buffer: []byte{1, 2, 3, 4, 5, 6, 7, 8}, Min: 1}
+
+	require.Equal(t, []byte{3, 4}, mem.MustRead(testCtx, 2, 2))
+	require.Equal(t, byte(8), mem.MustReadByte(testCtx, 7))
+	require.Equal(t, uint16(0x0201), mem.MustReadUint16Le(testCtx, 0))
+	require.Equal(t, uint32(0x08070605), mem.MustReadUint32Le(testCtx, 4))
+	require.Equal(t, uint64(0x0807060504030201), mem.MustReadUint64Le(testCtx, 0))
+
+	tests := []struct {
+		name        string
+		read        func()
+		expectedErr string
+	}{
+		{
+			name:        "MustRead",
+			read:        func() { mem.MustRead(testCtx, 7, 2) },
+			expectedErr: "out of range reading 2 bytes at offset 7 of memory size 8",
+		},
+		{
+			name:        "MustReadByte",
+			read:        func() { mem.MustReadByte(testCtx, 8) },
+			expectedErr: "out of range reading 1 bytes at offset 8 of memory size 8",
+		},
+		{
+			name:        "MustReadUint16Le",
+			read:        func() { mem.MustReadUint16Le(testCtx, 7) },
+			expectedErr: "out of range reading 2 bytes at offset 7 of memory size 8",
+		},
+		{
+			name:        "MustReadUint32Le",
+			read:        func() { mem.MustReadUint32Le(testCtx, 5) },
+			expectedErr: "out of range reading 4 bytes at offset 5 of memory size 8",
+		},
+		{
+			name:        "MustReadUint64Le",
+			read:        func() { mem.MustReadUint64Le(testCtx, 1) },
+			expectedErr: "out of range reading 8 bytes at offset 1 of memory size 8",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := require.CapturePanic(tc.read)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
 func TestMemoryInstance_WriteUint16Le(t *testing.T) {
 	memory := &MemoryInstance{Buffer: make([]byte, 100)}
 
@@ -782,6 +1046,32 @@ func TestMemoryInstance_WriteString(t *testing.T) {
 	}
 }
 
+func TestMemoryInstance_WriteStringN(t *testing.T) {
+	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
+		mem := &MemoryInstance{Buffer: []byte{0, 0, 0, 0, 16, 0, 0, 0}, Min: 1}
+
+		s := "bear"
+		written, ok := mem.WriteStringN(ctx, 4, s)
+		require.True(t, ok)
+		require.Equal(t, uint32(4), written)
+		require.Equal(t, []byte{0, 0, 0, 0, 'b', 'e', 'a', 'r'}, mem.Buffer)
+
+		// Returns the count that would have fit, without writing anything.
+		written, ok = mem.WriteStringN(ctx, 6, "cats")
+		require.False(t, ok)
+		require.Equal(t, uint32(2), written)
+		require.Equal(t, []byte{0, 0, 0, 0, 'b', 'e', 'a', 'r'}, mem.Buffer)
+
+		written, ok = mem.WriteStringN(ctx, 8, s)
+		require.False(t, ok)
+		require.Zero(t, written)
+
+		written, ok = mem.WriteStringN(ctx, 9, s)
+		require.False(t, ok)
+		require.Zero(t, written)
+	}
+}
+
 func BenchmarkWriteString(b *testing.B) {
 	tests := []string{
 		"",
@@ -811,3 +1101,57 @@ func BenchmarkWriteString(b *testing.B) {
 		})
 	}
 }
+
+func TestMemoryStream(t *testing.T) {
+	mem := &MemoryInstance{Buffer: make([]byte, 8), Min: 1}
+	s := &memoryStream{mem: mem}
+
+	// Write then read back from the start.
+	n, err := s.Write([]byte("wazero"))
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+
+	pos, err := s.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), pos)
+
+	buf := make([]byte, 4)
+	n, err = s.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "waze", string(buf[:n]))
+
+	// Seek relative to the current position and the end.
+	pos, err = s.Seek(-2, io.SeekCurrent)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), pos)
+
+	all, err := io.ReadAll(s)
+	require.NoError(t, err)
+	require.Equal(t, []byte{'z', 'e', 'r', 'o', 0, 0}, all)
+
+	pos, err = s.Seek(-1, io.SeekEnd)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), pos)
+
+	// Writes past the end are short.
+	n, err = s.Write([]byte("!!"))
+	require.Equal(t, io.ErrShortWrite, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, byte('!'), mem.Buffer[7])
+
+	// Reads at or past the end are EOF.
+	n, err = s.Read(buf)
+	require.Equal(t, io.EOF, err)
+	require.Zero(t, n)
+
+	// The stream sees the memory after it grows.
+	mem.Buffer = append(mem.Buffer, 'x')
+	n, err = s.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "x", string(buf[:n]))
+
+	_, err = s.Seek(-1, io.SeekStart)
+	require.EqualError(t, err, "negative offset: -1")
+	_, err = s.Seek(0, 3)
+	require.EqualError(t, err, "invalid whence: 3")
+}
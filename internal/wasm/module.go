@@ -146,6 +146,14 @@ type Module struct {
 	// Note: In the Binary Format, this is SectionIDData.
 	DataSection []*DataSegment
 
+	// TagSection contains the type index of each tag defined in this module,
+	// which is the type of the values of its exceptions.
+	//
+	// Note: In the Binary Format, this is SectionIDTag.
+	//
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/legacy/Exceptions.md#tag-section
+	TagSection []Index
+
 	// NameSection is set when the SectionIDCustom "name" was successfully decoded from the binary format.
 	//
 	// Note: This is the only SectionIDCustom defined in the WebAssembly 1.0 (20191205) Binary Format.
@@ -155,6 +163,19 @@ type Module struct {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#custom-section%E2%91%A0
 	NameSection *NameSection
 
+	// ProducersSection is set when the SectionIDCustom "producers" was
+	// successfully decoded from the binary format. It is nil if absent or
+	// malformed.
+	//
+	// See https://github.com/WebAssembly/tool-conventions/blob/main/ProducersSection.md
+	ProducersSection []api.Producer
+
+	// DylinkSection is set when the SectionIDCustom "dylink.0" was
+	// successfully decoded from the binary format.
+	//
+	// See https://github.com/WebAssembly/tool-conventions/blob/main/DynamicLinking.md
+	DylinkSection *api.DylinkInfo
+
 	// validatedActiveElementSegments are built on Validate when
 	// SectionIDElement is non-empty and all inputs are valid.
 	//
@@ -180,6 +201,19 @@ type Module struct {
 
 	// MemoryDefinitionSection is a wazero-specific section built on Validate.
 	MemoryDefinitionSection []*MemoryDefinition
+
+	// HostState is opaque state of a host module, returned by api.Module
+	// HostState to its functions. This is nil unless set by
+	// wazero.HostModuleBuilder WithState.
+	HostState interface{}
+
+	// HostTableFunctions are functions of other modules that initialize
+	// entries of tables defined by NewHostModule. These are applied after
+	// the ElementSection.
+	HostTableFunctions []*HostTableFunction
+
+	// memoryImage is set by EnableCopyOnWriteMemory.
+	memoryImage *memoryImage
 }
 
 // ModuleID represents sha256 hash value uniquely assigned to Module.
@@ -272,6 +306,22 @@ func (m *Module) Validate(enabledFeatures api.CoreFeatures) error {
 	if err = m.validateDataCountSection(); err != nil {
 		return err
 	}
+
+	if err = m.validateTags(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateTags ensures each tag has a type with no results.
+func (m *Module) validateTags() error {
+	for i, typeIndex := range m.TagSection {
+		if typeIndex >= uint32(len(m.TypeSection)) {
+			return fmt.Errorf("invalid %s[%d]: type section index %d out of range", SectionIDName(SectionIDTag), i, typeIndex)
+		} else if tp := m.TypeSection[typeIndex]; len(tp.Results) > 0 {
+			return fmt.Errorf("invalid %s[%d]: type %s has results", SectionIDName(SectionIDTag), i, tp)
+		}
+	}
 	return nil
 }
 
@@ -423,9 +473,13 @@ func (m *Module) validateMemory(memory *Memory, globals []*GlobalType, _ api.Cor
 	// Constant expression can only reference imported globals.
 	// https://github.com/WebAssembly/spec/blob/5900d839f38641989a9d8df2df4aee0513365d39/test/core/data.wast#L84-L91
 	importedGlobals := globals[:m.ImportGlobalCount()]
+	offsetType := ValueTypeI32
+	if memory != nil && memory.Is64 {
+		offsetType = ValueTypeI64
+	}
 	for _, d := range m.DataSection {
 		if !d.IsPassive() {
-			if err := validateConstExpression(importedGlobals, 0, d.OffsetExpression, ValueTypeI32); err != nil {
+			if err := validateConstExpression(importedGlobals, 0, d.OffsetExpression, offsetType); err != nil {
 				return fmt.Errorf("calculate offset: %w", err)
 			}
 		}
@@ -436,6 +490,10 @@ func (m *Module) validateMemory(memory *Memory, globals []*GlobalType, _ api.Cor
 func (m *Module) validateImports(enabledFeatures api.CoreFeatures) error {
 	for _, i := range m.ImportSection {
 		switch i.Type {
+		case ExternTypeFunc:
+			if i.DescFunc >= uint32(len(m.TypeSection)) {
+				return fmt.Errorf("invalid import[%q.%q] function: type index %d out of range", i.Module, i.Name, i.DescFunc)
+			}
 		case ExternTypeGlobal:
 			if !i.DescGlobal.Mutable {
 				continue
@@ -539,6 +597,16 @@ func validateConstExpression(globals []*GlobalType, numFuncs uint32, expr *Const
 			return fmt.Errorf("%s needs 16 bytes but was %d bytes", OpcodeVecV128ConstName, len(expr.Data))
 		}
 		actualType = ValueTypeV128
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		actualType, _, err = evalExtendedConstExpression(expr.Data, func(id uint32) (ValueType, uint64, error) {
+			if uint32(len(globals)) <= id {
+				return 0, 0, fmt.Errorf("global index out of range")
+			}
+			return globals[id].ValType, 0, nil
+		})
+		if err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("invalid opcode for const expression: 0x%x", expr.Opcode)
 	}
@@ -750,6 +818,15 @@ type Memory struct {
 	Min, Cap, Max uint32
 	// IsMaxEncoded true if the Max is encoded in the original source (binary or text).
 	IsMaxEncoded bool
+	// IsShared is true if the memory has the shared flag of the threads proposal. Shared memories always have a max.
+	//
+	// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+	IsShared bool
+	// Is64 is true if the memory is indexed with i64 values per the memory64 proposal. Pages are still limited to
+	// MemoryLimitPages, so addresses beyond 4GiB are always out of bounds.
+	//
+	// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+	Is64 bool
 }
 
 // Validate ensures values assigned to Min, Cap and Max are within valid thresholds.
@@ -830,6 +907,10 @@ type Code struct {
 	// Note: This has no serialization format, so is not encodable.
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#host-functions%E2%91%A2
 	GoFunc interface{}
+
+	// MaxStackDepth is the maximum count of values on the operand stack of
+	// Body, set on validation. This excludes params and locals.
+	MaxStackDepth uint32
 }
 
 type DataSegment struct {
@@ -879,6 +960,16 @@ type NameSection struct {
 	// Note: LocalNames are only used for debugging. At runtime, locals are called based on raw numeric index.
 	// Note: This can be nil for any reason including configuration.
 	LocalNames IndirectNameMap
+
+	// TableNames and MemoryNames associate an index in the respective index
+	// namespace with its symbolic identifier, per the extended name section
+	// proposal. For example, (memory $mem 1) results in MemoryNames[0] = "mem".
+	// These are exposed by api.TableDefinition and api.MemoryDefinition Name.
+	//
+	// Note: These are only used for debugging, and can be nil for any reason
+	// including configuration.
+	// See https://github.com/WebAssembly/extended-name-section/blob/main/proposals/extended-name-section/Overview.md
+	TableNames, MemoryNames NameMap
 }
 
 // NameMap associates an index with any associated names.
@@ -968,6 +1059,11 @@ const (
 	// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/binary/modules.html#data-count-section
 	// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/appendix/changes.html#bulk-memory-and-table-instructions
 	SectionIDDataCount
+
+	// SectionIDTag may exist when CoreFeatureExceptionHandling is enabled.
+	//
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/legacy/Exceptions.md#tag-section
+	SectionIDTag
 )
 
 // SectionIDName returns the canonical name of a module section.
@@ -1000,6 +1096,8 @@ func SectionIDName(sectionID SectionID) string {
 		return "data"
 	case SectionIDDataCount:
 		return "data_count"
+	case SectionIDTag:
+		return "tag"
 	}
 	return "unknown"
 }
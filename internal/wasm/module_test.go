@@ -321,6 +321,89 @@ func TestValidateConstExpression(t *testing.T) {
 			}
 		})
 	})
+	t.Run("extended const", func(t *testing.T) {
+		globals := []*GlobalType{{ValType: ValueTypeI32}, {ValType: ValueTypeI64}}
+		tests := []struct {
+			name        string
+			expr        *ConstantExpression
+			expected    ValueType
+			expectedErr string
+		}{
+			{
+				name: "i32.add",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Add,
+					Data:   []byte{OpcodeGlobalGet, 0, OpcodeI32Const, 2, OpcodeI32Add},
+				},
+				expected: ValueTypeI32,
+			},
+			{
+				name: "i64.mul",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI64Mul,
+					Data:   []byte{OpcodeGlobalGet, 1, OpcodeI64Const, 2, OpcodeI64Mul},
+				},
+				expected: ValueTypeI64,
+			},
+			{
+				name: "result type mismatch",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI64Sub,
+					Data:   []byte{OpcodeI64Const, 1, OpcodeI64Const, 2, OpcodeI64Sub},
+				},
+				expected:    ValueTypeI32,
+				expectedErr: "const expression type mismatch expected i32 but got i64",
+			},
+			{
+				name: "operand type mismatch",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Sub,
+					Data:   []byte{OpcodeGlobalGet, 1, OpcodeI32Const, 2, OpcodeI32Sub},
+				},
+				expected:    ValueTypeI32,
+				expectedErr: "type mismatch on i32.sub: expected i32 operands",
+			},
+			{
+				name: "stack underflow",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Mul,
+					Data:   []byte{OpcodeI32Const, 2, OpcodeI32Mul},
+				},
+				expected:    ValueTypeI32,
+				expectedErr: "cannot pop the operand for i32.mul: stack underflow",
+			},
+			{
+				name: "too many values",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Add,
+					Data:   []byte{OpcodeI32Const, 1, OpcodeI32Const, 2, OpcodeI32Const, 3, OpcodeI32Add},
+				},
+				expected:    ValueTypeI32,
+				expectedErr: "const expression must leave exactly one value, but left 2",
+			},
+			{
+				name: "global index out of range",
+				expr: &ConstantExpression{
+					Opcode: OpcodeI32Add,
+					Data:   []byte{OpcodeGlobalGet, 2, OpcodeI32Const, 2, OpcodeI32Add},
+				},
+				expected:    ValueTypeI32,
+				expectedErr: "global index out of range",
+			},
+		}
+
+		for _, tt := range tests {
+			tc := tt
+			t.Run(tc.name, func(t *testing.T) {
+				err := validateConstExpression(globals, 0, tc.expr, tc.expected)
+				if tc.expectedErr == "" {
+					require.NoError(t, err)
+				} else {
+					require.EqualError(t, err, tc.expectedErr)
+				}
+			})
+		}
+	})
 }
 
 func TestModule_Validate_Errors(t *testing.T) {
@@ -388,6 +471,16 @@ func TestModule_validateStartSection(t *testing.T) {
 		err := m.validateStartSection()
 		require.NoError(t, err)
 	})
+	t.Run("imported invalid func", func(t *testing.T) {
+		index := Index(0)
+		m := Module{
+			StartSection:  &index,
+			TypeSection:   []*FunctionType{{Results: []ValueType{ValueTypeI32}}},
+			ImportSection: []*Import{{Type: ExternTypeFunc, DescFunc: 0}},
+		}
+		err := m.validateStartSection()
+		require.EqualError(t, err, "invalid start function: func[0] must have an empty (nullary) signature: v_i32")
+	})
 }
 
 func TestModule_validateGlobals(t *testing.T) {
@@ -577,6 +670,12 @@ func TestModule_validateImports(t *testing.T) {
 			enabledFeatures: api.CoreFeaturesV1,
 			i:               &Import{Module: "m", Name: "n", Type: ExternTypeFunc, DescFunc: 0},
 		},
+		{
+			name:            "func unknown type",
+			enabledFeatures: api.CoreFeaturesV1,
+			i:               &Import{Module: "m", Name: "n", Type: ExternTypeFunc, DescFunc: 1},
+			expectedErr:     `invalid import["m"."n"] function: type index 1 out of range`,
+		},
 		{
 			name:            "global var disabled",
 			enabledFeatures: api.CoreFeaturesV1.SetEnabled(api.CoreFeatureMutableGlobal, false),
@@ -613,7 +712,7 @@ func TestModule_validateImports(t *testing.T) {
 	for _, tt := range tests {
 		tc := tt
 		t.Run(tc.name, func(t *testing.T) {
-			m := Module{}
+			m := Module{TypeSection: []*FunctionType{v_v}}
 			if tc.i != nil {
 				m.ImportSection = []*Import{tc.i}
 			}
@@ -849,6 +948,37 @@ func TestModule_validateDataCountSection(t *testing.T) {
 	})
 }
 
+func TestModule_validateTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        []Index
+		expectedErr string
+	}{
+		{name: "ok", tags: []Index{0, 0}},
+		{name: "type out of range", tags: []Index{2}, expectedErr: "invalid tag[0]: type section index 2 out of range"},
+		{name: "type has results", tags: []Index{0, 1}, expectedErr: "invalid tag[1]: type v_i32 has results"},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection: []*FunctionType{
+					{Params: []ValueType{ValueTypeI32}},
+					{Results: []ValueType{ValueTypeI32}},
+				},
+				TagSection: tc.tags,
+			}
+			err := m.validateTags()
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestModule_declaredFunctionIndexes(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -21,6 +21,11 @@ type Namespace struct {
 
 	// mux is used to guard the fields from concurrent access.
 	mux sync.RWMutex
+
+	// importStubs are the shared stubs of unresolved imports, keyed by
+	// module ID. See Store.importStubs.
+	importStubs    map[string]*importStub // guarded by importStubsMux
+	importStubsMux sync.Mutex
 }
 
 // newNamespace returns an empty namespace.
@@ -78,6 +83,29 @@ func (ns *Namespace) requireModules(moduleNames map[string]struct{}) (map[string
 	return ret, nil
 }
 
+// existingModules is like requireModules, except it omits modules that
+// aren't instantiated instead of erring.
+func (ns *Namespace) existingModules(moduleNames map[string]struct{}) map[string]*ModuleInstance {
+	ret := make(map[string]*ModuleInstance, len(moduleNames))
+
+	ns.mux.RLock()
+	defer ns.mux.RUnlock()
+
+	for n := range moduleNames {
+		if m, ok := ns.modules[n]; ok {
+			ret[n] = m
+		}
+	}
+	return ret
+}
+
+// moduleCount returns the count of module names reserved by requireModuleName, and not yet deleted.
+func (ns *Namespace) moduleCount() int {
+	ns.mux.RLock()
+	defer ns.mux.RUnlock()
+	return len(ns.moduleNamesSet)
+}
+
 // requireModuleName is a pre-flight check to reserve a module.
 // This must be reverted on error with deleteModule if initialization fails.
 func (ns *Namespace) requireModuleName(moduleName string) error {
@@ -125,3 +153,17 @@ func (ns *Namespace) Module(moduleName string) api.Module {
 		return nil
 	}
 }
+
+// Modules implements wazero.Namespace Modules
+func (ns *Namespace) Modules() []api.Module {
+	ns.mux.RLock()
+	defer ns.mux.RUnlock()
+	ret := make([]api.Module, 0, len(ns.moduleNamesList))
+	for _, n := range ns.moduleNamesList {
+		// Skip names reserved by modules not yet done instantiating.
+		if m, ok := ns.modules[n]; ok {
+			ret = append(ret, m.CallCtx)
+		}
+	}
+	return ret
+}
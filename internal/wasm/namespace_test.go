@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/sys"
 	testfs "github.com/tetratelabs/wazero/internal/testing/fs"
 	"github.com/tetratelabs/wazero/internal/testing/require"
@@ -207,6 +208,29 @@ func TestNamespace_Module(t *testing.T) {
 	})
 }
 
+func TestNamespace_Modules(t *testing.T) {
+	ns, m1, m2 := newTestNamespace()
+
+	t.Run("instantiation order", func(t *testing.T) {
+		require.Equal(t, []api.Module{m1.CallCtx, m2.CallCtx}, ns.Modules())
+	})
+
+	t.Run("skips reserved names", func(t *testing.T) {
+		require.NoError(t, ns.requireModuleName("m3"))
+		defer ns.deleteModule("m3")
+
+		require.Equal(t, []api.Module{m1.CallCtx, m2.CallCtx}, ns.Modules())
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		modules := ns.Modules()
+		ns.deleteModule(m1.Name)
+
+		require.Equal(t, []api.Module{m1.CallCtx, m2.CallCtx}, modules)
+		require.Equal(t, []api.Module{m2.CallCtx}, ns.Modules())
+	})
+}
+
 // newTestNamespace sets up a new Namespace without adding test coverage its functions.
 func newTestNamespace() (*Namespace, *ModuleInstance, *ModuleInstance) {
 	ns := &Namespace{}
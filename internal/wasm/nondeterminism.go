@@ -0,0 +1,79 @@
+package wasm
+
+import "fmt"
+
+// ValidateDeterministic returns an error naming the first function and
+// instruction in the module whose result may differ by platform, or nil if
+// none do. This supports consensus hosts, where every node must compute the
+// same result.
+//
+// The following instructions are rejected:
+//   - Relaxed vector instructions, whose results are implementation-defined.
+//   - Float instructions which may produce a NaN, as the bits of that NaN
+//     aren't canonicalized, e.g. f32.add or f64x2.sqrt. Instructions which
+//     only move or compare bits, such as f32.abs or f64.eq, are allowed.
+//
+// Note: This must be called after Validate.
+func (m *Module) ValidateDeterministic() error {
+	for idx, code := range m.CodeSection {
+		if name, err := firstNondeterministicInstruction(code.Body); err != nil {
+			// Fail closed, as instructions after this point weren't checked.
+			return fmt.Errorf("nondeterminism rejected: %s couldn't be scanned: %w", m.funcDesc(SectionIDFunction, Index(idx)), err)
+		} else if name != "" {
+			return fmt.Errorf("nondeterminism rejected: %s uses %s", m.funcDesc(SectionIDFunction, Index(idx)), name)
+		}
+	}
+	return nil
+}
+
+// firstNondeterministicInstruction returns the name of the first instruction
+// in the validated function body whose result may differ by platform, or ""
+// if none do. An error is returned if the body couldn't be scanned to its end.
+func firstNondeterministicInstruction(body []byte) (name string, err error) {
+	err = scanInstructions(body, func(op Opcode, imm uint32) {
+		if name != "" {
+			return
+		}
+		switch op {
+		case OpcodeVecPrefix:
+			if isRelaxedVecOpcode(imm) {
+				name = RelaxedVectorInstructionName(imm)
+			} else if isNaNProducingVecOpcode(OpcodeVec(imm)) {
+				name = VectorInstructionName(OpcodeVec(imm))
+			}
+		case OpcodeMiscPrefix: // Saturating truncation is deterministic.
+		default:
+			if isNaNProducingOpcode(op) {
+				name = InstructionName(op)
+			}
+		}
+	})
+	return
+}
+
+// isNaNProducingOpcode returns true if the single-byte float opcode can
+// produce a NaN from non-NaN operands or propagate a NaN operand.
+func isNaNProducingOpcode(op Opcode) bool {
+	switch op {
+	case OpcodeF32DemoteF64, OpcodeF64PromoteF32:
+		return true
+	}
+	// Excludes abs, neg and copysign, which only change the sign bit.
+	return (op >= OpcodeF32Ceil && op <= OpcodeF32Max) ||
+		(op >= OpcodeF64Ceil && op <= OpcodeF64Max)
+}
+
+// isNaNProducingVecOpcode is like isNaNProducingOpcode, for vector opcodes.
+func isNaNProducingVecOpcode(op OpcodeVec) bool {
+	switch op {
+	case OpcodeVecF32x4Ceil, OpcodeVecF32x4Floor, OpcodeVecF32x4Trunc, OpcodeVecF32x4Nearest,
+		OpcodeVecF32x4Sqrt, OpcodeVecF32x4Add, OpcodeVecF32x4Sub, OpcodeVecF32x4Mul,
+		OpcodeVecF32x4Div, OpcodeVecF32x4Min, OpcodeVecF32x4Max,
+		OpcodeVecF64x2Ceil, OpcodeVecF64x2Floor, OpcodeVecF64x2Trunc, OpcodeVecF64x2Nearest,
+		OpcodeVecF64x2Sqrt, OpcodeVecF64x2Add, OpcodeVecF64x2Sub, OpcodeVecF64x2Mul,
+		OpcodeVecF64x2Div, OpcodeVecF64x2Min, OpcodeVecF64x2Max,
+		OpcodeVecF32x4DemoteF64x2Zero, OpcodeVecF64x2PromoteLowF32x4Zero:
+		return true
+	}
+	return false
+}
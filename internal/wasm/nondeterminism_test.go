@@ -0,0 +1,94 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_ValidateDeterministic(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name: "deterministic floats",
+			body: []byte{
+				OpcodeF32Const, 0, 0, 0, 0, OpcodeF32Neg, OpcodeF32Abs,
+				OpcodeF32Const, 0, 0, 0, 0, OpcodeF32Copysign,
+				OpcodeF32Const, 0, 0, 0, 0, OpcodeF32Eq, OpcodeDrop,
+				OpcodeMiscPrefix, OpcodeMiscMemoryFill, 0, // skipped immediates aren't mistaken for opcodes.
+				OpcodeVecPrefix, OpcodeVecF32x4Abs, 0x01, // LEB128 continuation
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "scalar arithmetic",
+			body: []byte{
+				OpcodeF64Const, 0, 0, 0, 0, 0, 0, 0, 0,
+				OpcodeF64Const, 0, 0, 0, 0, 0, 0, 0, 0,
+				OpcodeF64Div, OpcodeDrop,
+				OpcodeEnd,
+			},
+			expectedErr: `nondeterminism rejected: function[0] export["run"] uses f64.div`,
+		},
+		{
+			name: "scalar rounding",
+			body: []byte{
+				OpcodeF32Const, 0, 0, 0, 0, OpcodeF32Nearest, OpcodeDrop,
+				OpcodeEnd,
+			},
+			expectedErr: `nondeterminism rejected: function[0] export["run"] uses f32.nearest`,
+		},
+		{
+			name: "scalar conversion",
+			body: []byte{
+				OpcodeF64Const, 0, 0, 0, 0, 0, 0, 0, 0, OpcodeF32DemoteF64, OpcodeDrop,
+				OpcodeEnd,
+			},
+			expectedErr: `nondeterminism rejected: function[0] export["run"] uses f32.demote_f64`,
+		},
+		{
+			name: "vector arithmetic",
+			body: []byte{
+				OpcodeVecPrefix, OpcodeVecF64x2Sqrt, 0x01, // LEB128 continuation
+				OpcodeEnd,
+			},
+			expectedErr: `nondeterminism rejected: function[0] export["run"] uses f64x2.sqrt`,
+		},
+		{
+			name: "relaxed vector",
+			body: []byte{
+				OpcodeVecPrefix, 0x85, 0x02, // f32x4.relaxed_madd
+				OpcodeEnd,
+			},
+			expectedErr: `nondeterminism rejected: function[0] export["run"] uses f32x4.relaxed_madd`,
+		},
+		{
+			name: "truncated body",
+			body: []byte{
+				OpcodeF64Const, 0, 0, 0, // the immediate is 8 bytes.
+			},
+			expectedErr: `nondeterminism rejected: function[0] export["run"] couldn't be scanned: unexpected EOF`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+				ExportSection:   []*Export{{Name: "run", Type: ExternTypeFunc, Index: 0}},
+			}
+			err := m.ValidateDeterministic()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
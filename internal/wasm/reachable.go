@@ -0,0 +1,307 @@
+package wasm
+
+import (
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// ReachableFunctions returns the sorted indices in the function index
+// namespace, including imports, reachable from the functions exported under
+// the given names. Names that aren't function exports are ignored.
+//
+// The start function is always a root. Since call_indirect can't be resolved
+// statically, all functions referenced by element segments, ref.func or
+// global initializers are considered reachable when any call_indirect is.
+//
+// If a function body can't be scanned to its end, every function is
+// considered reachable, so that none which is used is dropped.
+func (m *Module) ReachableFunctions(roots []string) []Index {
+	importCount := m.ImportFuncCount()
+
+	var queue []Index
+	reachable := map[Index]struct{}{}
+	enqueue := func(idx Index) {
+		if _, ok := reachable[idx]; !ok {
+			reachable[idx] = struct{}{}
+			queue = append(queue, idx)
+		}
+	}
+
+	for _, name := range roots {
+		for _, e := range m.ExportSection {
+			if e.Type == ExternTypeFunc && e.Name == name {
+				enqueue(e.Index)
+			}
+		}
+	}
+	if m.StartSection != nil {
+		enqueue(*m.StartSection)
+	}
+
+	var indirect bool
+	for len(queue) > 0 {
+		idx := queue[0]
+		queue = queue[1:]
+		if idx < importCount {
+			continue // imported functions have no code in this module.
+		}
+		codeIdx := idx - importCount
+		if codeIdx >= Index(len(m.CodeSection)) {
+			continue
+		}
+		callees, callsIndirect, err := scanCalls(m.CodeSection[codeIdx].Body)
+		if err != nil {
+			return m.allFunctions()
+		}
+		for _, callee := range callees {
+			enqueue(callee)
+		}
+		if callsIndirect && !indirect {
+			indirect = true
+			referenced, err := m.tableReferencedFunctions()
+			if err != nil {
+				return m.allFunctions()
+			}
+			for _, f := range referenced {
+				enqueue(f)
+			}
+		}
+	}
+
+	ret := make([]Index, 0, len(reachable))
+	for idx := range reachable {
+		ret = append(ret, idx)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// CalledImports returns the sorted indices of imported functions reachable
+// from any exported function or the start function. Others are never called,
+// unless a host function calls them.
+func (m *Module) CalledImports() []Index {
+	var roots []string
+	for _, e := range m.ExportSection {
+		if e.Type == ExternTypeFunc {
+			roots = append(roots, e.Name)
+		}
+	}
+
+	importCount := m.ImportFuncCount()
+	ret := []Index{}
+	for _, idx := range m.ReachableFunctions(roots) {
+		if idx >= importCount {
+			break // sorted, so the rest are defined in this module.
+		}
+		ret = append(ret, idx)
+	}
+	return ret
+}
+
+// tableReferencedFunctions returns the functions which could be placed into a
+// table, and so called by call_indirect.
+func (m *Module) tableReferencedFunctions() (ret []Index, err error) {
+	for _, e := range m.ElementSection {
+		for _, idx := range e.Init {
+			if idx != nil {
+				ret = append(ret, *idx)
+			}
+		}
+	}
+	for _, g := range m.GlobalSection {
+		if g.Init.Opcode == OpcodeRefFunc {
+			if idx, _, err := leb128.LoadUint32(g.Init.Data); err == nil {
+				ret = append(ret, idx)
+			}
+		}
+	}
+	for _, c := range m.CodeSection {
+		refs, err := scanRefFuncs(c.Body)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, refs...)
+	}
+	return
+}
+
+// allFunctions returns the indices of all functions in the function index
+// namespace, including imports.
+func (m *Module) allFunctions() []Index {
+	ret := make([]Index, m.ImportFuncCount()+Index(len(m.FunctionSection)))
+	for i := range ret {
+		ret[i] = Index(i)
+	}
+	return ret
+}
+
+// scanCalls returns the targets of call instructions in the function body,
+// and whether it includes any call_indirect.
+func scanCalls(body []byte) (callees []Index, callsIndirect bool, err error) {
+	err = scanInstructions(body, func(op Opcode, idx Index) {
+		switch op {
+		case OpcodeCall:
+			callees = append(callees, idx)
+		case OpcodeCallIndirect:
+			callsIndirect = true
+		}
+	})
+	return
+}
+
+// scanRefFuncs returns the targets of ref.func instructions in the function
+// body.
+func scanRefFuncs(body []byte) (refs []Index, err error) {
+	err = scanInstructions(body, func(op Opcode, idx Index) {
+		if op == OpcodeRefFunc {
+			refs = append(refs, idx)
+		}
+	})
+	return
+}
+
+// scanInstructions calls visit for each instruction in the validated function
+// body. imm is the function index of OpcodeCall and OpcodeRefFunc, the
+// sub-opcode of OpcodeMiscPrefix and OpcodeVecPrefix, or zero.
+func scanInstructions(body []byte, visit func(op Opcode, imm uint32)) error {
+	r := bytes.NewReader(body)
+	for {
+		op, err := r.ReadByte()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var imm uint32
+		switch op {
+		case OpcodeCall, OpcodeRefFunc:
+			imm, _, err = leb128.DecodeUint32(r)
+		case OpcodeMiscPrefix:
+			if imm, _, err = leb128.DecodeUint32(r); err == nil {
+				err = skipMiscImmediates(OpcodeMisc(imm), r)
+			}
+		case OpcodeVecPrefix:
+			// Relaxed vector instructions have no immediates.
+			if imm, _, err = leb128.DecodeUint32(r); err == nil && !isRelaxedVecOpcode(imm) {
+				err = skipVecImmediates(OpcodeVec(imm), r)
+			}
+		default:
+			err = skipImmediates(op, r)
+		}
+		if err != nil {
+			return err
+		}
+		visit(op, imm)
+	}
+}
+
+// skipImmediates advances the reader past the immediates of the opcode.
+func skipImmediates(op Opcode, r *bytes.Reader) error {
+	switch op {
+	case OpcodeBlock, OpcodeLoop, OpcodeIf, OpcodeTry:
+		_, _, err := leb128.DecodeInt33AsInt64(r)
+		return err
+	case OpcodeBr, OpcodeBrIf, OpcodeLocalGet, OpcodeLocalSet, OpcodeLocalTee,
+		OpcodeGlobalGet, OpcodeGlobalSet, OpcodeTableGet, OpcodeTableSet,
+		OpcodeCatch, OpcodeThrow, OpcodeRethrow:
+		return skipUint32s(r, 1)
+	case OpcodeBrTable:
+		count, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return err
+		}
+		return skipUint32s(r, int(count)+1) // targets and the default.
+	case OpcodeCallIndirect:
+		return skipUint32s(r, 2) // type and table index.
+	case OpcodeTypedSelect:
+		count, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return err
+		}
+		return skipBytes(r, int64(count))
+	case OpcodeMemorySize, OpcodeMemoryGrow, OpcodeRefNull:
+		return skipBytes(r, 1)
+	case OpcodeI32Const:
+		_, _, err := leb128.DecodeInt32(r)
+		return err
+	case OpcodeI64Const:
+		_, _, err := leb128.DecodeInt64(r)
+		return err
+	case OpcodeF32Const:
+		return skipBytes(r, 4)
+	case OpcodeF64Const:
+		return skipBytes(r, 8)
+	}
+	if op >= OpcodeI32Load && op <= OpcodeI64Store32 {
+		return skipMemArg(r)
+	}
+	return nil
+}
+
+func skipMiscImmediates(op OpcodeMisc, r *bytes.Reader) (err error) {
+	switch op {
+	case OpcodeMiscMemoryInit:
+		if err = skipUint32s(r, 1); err != nil {
+			return err
+		}
+		return skipBytes(r, 1)
+	case OpcodeMiscDataDrop, OpcodeMiscElemDrop, OpcodeMiscTableGrow, OpcodeMiscTableSize, OpcodeMiscTableFill:
+		return skipUint32s(r, 1)
+	case OpcodeMiscMemoryCopy:
+		return skipBytes(r, 2)
+	case OpcodeMiscMemoryFill:
+		return skipBytes(r, 1)
+	case OpcodeMiscTableInit, OpcodeMiscTableCopy:
+		return skipUint32s(r, 2)
+	}
+	return nil
+}
+
+func skipVecImmediates(vecOp OpcodeVec, r *bytes.Reader) (err error) {
+	switch {
+	case vecOp <= OpcodeVecV128Store, vecOp == OpcodeVecV128Load32zero, vecOp == OpcodeVecV128Load64zero:
+		return skipMemArg(r)
+	case vecOp >= OpcodeVecV128Load8Lane && vecOp <= OpcodeVecV128Store64Lane:
+		if err = skipMemArg(r); err != nil {
+			return err
+		}
+		return skipBytes(r, 1) // lane
+	case vecOp == OpcodeVecV128Const, vecOp == OpcodeVecV128i8x16Shuffle:
+		return skipBytes(r, 16)
+	case vecOp >= OpcodeVecI8x16ExtractLaneS && vecOp <= OpcodeVecF64x2ReplaceLane:
+		return skipBytes(r, 1) // lane
+	}
+	return nil
+}
+
+func skipUint32s(r *bytes.Reader, n int) error {
+	for i := 0; i < n; i++ {
+		if _, _, err := leb128.DecodeUint32(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipMemArg advances the reader past the memarg immediates: the alignment
+// and the offset, which is a u64 in a 64-bit memory (memory64). The body is
+// already validated, so the offset is read as a u64 regardless.
+func skipMemArg(r *bytes.Reader) error {
+	if _, _, err := leb128.DecodeUint32(r); err != nil {
+		return err
+	}
+	_, _, err := leb128.DecodeUint64(r)
+	return err
+}
+
+func skipBytes(r *bytes.Reader, n int64) error {
+	if int64(r.Len()) < n {
+		return io.ErrUnexpectedEOF
+	}
+	_, err := r.Seek(n, io.SeekCurrent)
+	return err
+}
@@ -0,0 +1,183 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_ReachableFunctions(t *testing.T) {
+	one := Index(1)
+	three := Index(3)
+	nop := &Code{Body: []byte{OpcodeNop, OpcodeEnd}}
+
+	tests := []struct {
+		name     string
+		m        *Module
+		roots    []string
+		expected []Index
+	}{
+		{
+			name:     "no roots",
+			m:        &Module{FunctionSection: []Index{0}, CodeSection: []*Code{nop}},
+			expected: []Index{},
+		},
+		{
+			name: "unknown root",
+			m: &Module{
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{nop},
+				ExportSection:   []*Export{{Type: ExternTypeGlobal, Name: "g"}},
+			},
+			roots:    []string{"g", "missing"},
+			expected: []Index{},
+		},
+		{
+			name: "transitive calls",
+			m: &Module{
+				ImportSection:   []*Import{{Type: ExternTypeFunc}},
+				FunctionSection: []Index{0, 0, 0, 0},
+				CodeSection: []*Code{
+					{Body: []byte{ // Skips immediates which look like call.
+						OpcodeI32Const, OpcodeCall, OpcodeDrop,
+						OpcodeCall, 2, OpcodeEnd,
+					}},
+					{Body: []byte{OpcodeCall, 0, OpcodeCall, 1, OpcodeEnd}}, // recursive
+					nop, // start
+					nop, // unreachable
+				},
+				ExportSection: []*Export{{Type: ExternTypeFunc, Name: "main", Index: 1}},
+				StartSection:  &three,
+			},
+			roots:    []string{"main"},
+			expected: []Index{0, 1, 2, 3},
+		},
+		{
+			name: "call_indirect includes table-referenced functions",
+			m: &Module{
+				FunctionSection: []Index{0, 0, 0, 0},
+				CodeSection: []*Code{
+					{Body: []byte{OpcodeI32Const, 0, OpcodeCallIndirect, 0, 0, OpcodeEnd}},
+					nop,
+					{Body: []byte{OpcodeRefFunc, 3, OpcodeDrop, OpcodeEnd}}, // unreachable, but references 3
+					nop,
+				},
+				ElementSection: []*ElementSegment{{Init: []*Index{nil, &one}}},
+				ExportSection:  []*Export{{Type: ExternTypeFunc, Name: "main", Index: 0}},
+			},
+			roots:    []string{"main"},
+			expected: []Index{0, 1, 3},
+		},
+		{
+			name: "table-referenced functions aren't reachable without call_indirect",
+			m: &Module{
+				FunctionSection: []Index{0, 0},
+				CodeSection:     []*Code{nop, nop},
+				ElementSection:  []*ElementSegment{{Init: []*Index{&one}}},
+				ExportSection:   []*Export{{Type: ExternTypeFunc, Name: "main", Index: 0}},
+			},
+			roots:    []string{"main"},
+			expected: []Index{0},
+		},
+		{
+			name: "all functions when a body can't be scanned",
+			m: &Module{
+				ImportSection:   []*Import{{Type: ExternTypeFunc}},
+				FunctionSection: []Index{0, 0},
+				CodeSection: []*Code{
+					{Body: []byte{OpcodeCall}}, // the immediate is missing.
+					nop,
+				},
+				ExportSection: []*Export{{Type: ExternTypeFunc, Name: "main", Index: 1}},
+			},
+			roots:    []string{"main"},
+			expected: []Index{0, 1, 2},
+		},
+		{
+			name: "all functions when a ref.func can't be scanned",
+			m: &Module{
+				FunctionSection: []Index{0, 0},
+				CodeSection: []*Code{
+					{Body: []byte{OpcodeI32Const, 0, OpcodeCallIndirect, 0, 0, OpcodeEnd}},
+					{Body: []byte{OpcodeRefFunc}}, // the immediate is missing.
+				},
+				ExportSection: []*Export{{Type: ExternTypeFunc, Name: "main", Index: 0}},
+			},
+			roots:    []string{"main"},
+			expected: []Index{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.m.ReachableFunctions(tc.roots))
+		})
+	}
+}
+
+func TestModule_CalledImports(t *testing.T) {
+	zero, three := Index(0), Index(3)
+	nop := &Code{Body: []byte{OpcodeNop, OpcodeEnd}}
+	imports := []*Import{{Type: ExternTypeFunc}, {Type: ExternTypeFunc}, {Type: ExternTypeFunc}}
+
+	tests := []struct {
+		name     string
+		m        *Module
+		expected []Index
+	}{
+		{
+			name:     "no imports",
+			m:        &Module{FunctionSection: []Index{0}, CodeSection: []*Code{nop}},
+			expected: []Index{},
+		},
+		{
+			name: "calls from exports",
+			m: &Module{
+				ImportSection:   imports,
+				FunctionSection: []Index{0, 0, 0},
+				CodeSection: []*Code{
+					{Body: []byte{OpcodeCall, 4, OpcodeEnd}},
+					{Body: []byte{OpcodeCall, 2, OpcodeEnd}},
+					{Body: []byte{OpcodeCall, 1, OpcodeEnd}}, // not exported
+				},
+				ExportSection: []*Export{
+					{Type: ExternTypeFunc, Name: "a", Index: 3},
+					{Type: ExternTypeGlobal, Name: "g", Index: 5},
+				},
+			},
+			expected: []Index{2},
+		},
+		{
+			name: "start and exported import",
+			m: &Module{
+				ImportSection:   imports,
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: []byte{OpcodeCall, 0, OpcodeEnd}}},
+				ExportSection:   []*Export{{Type: ExternTypeFunc, Name: "b", Index: 2}},
+				StartSection:    &three,
+			},
+			expected: []Index{0, 2},
+		},
+		{
+			name: "call_indirect includes table-referenced imports",
+			m: &Module{
+				ImportSection:   imports,
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: []byte{OpcodeI32Const, 0, OpcodeCallIndirect, 0, 0, OpcodeEnd}}},
+				ElementSection:  []*ElementSegment{{Init: []*Index{&zero}}},
+				ExportSection:   []*Export{{Type: ExternTypeFunc, Name: "main", Index: 3}},
+			},
+			expected: []Index{0},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.m.CalledImports())
+		})
+	}
+}
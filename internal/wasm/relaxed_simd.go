@@ -0,0 +1,20 @@
+package wasm
+
+// UsesRelaxedSIMD returns true if any function in the module uses a relaxed
+// vector instruction. This allows engines which don't implement them, such as
+// the compiler, to reject the module.
+//
+// Note: This must be called after Validate.
+func (m *Module) UsesRelaxedSIMD() (ret bool) {
+	for _, code := range m.CodeSection {
+		_ = scanInstructions(code.Body, func(op Opcode, imm uint32) {
+			if op == OpcodeVecPrefix && isRelaxedVecOpcode(imm) {
+				ret = true
+			}
+		})
+		if ret {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_UsesRelaxedSIMD(t *testing.T) {
+	v128Const := []byte{OpcodeVecPrefix, OpcodeVecV128Const, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+
+	tests := []struct {
+		name     string
+		body     []byte
+		expected bool
+	}{
+		{
+			name:     "no vector instructions",
+			body:     []byte{OpcodeI32Const, 0x7f, OpcodeDrop, OpcodeEnd},
+			expected: false,
+		},
+		{
+			// The last byte of the constant shouldn't be mistaken for a relaxed opcode.
+			name:     "v128.const",
+			body:     append(append(append([]byte{}, v128Const...), OpcodeDrop), OpcodeEnd),
+			expected: false,
+		},
+		{
+			name:     "non-relaxed two byte opcode",
+			body:     append(append(append([]byte{}, v128Const...), OpcodeVecPrefix, 0xe0, 0x01, OpcodeDrop), OpcodeEnd), // f32x4.abs
+			expected: false,
+		},
+		{
+			name:     "relaxed opcode",
+			body:     append(append(append([]byte{}, v128Const...), OpcodeVecPrefix, 0x81, 0x02, OpcodeDrop), OpcodeEnd), // i32x4.relaxed_trunc_f32x4_s
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{CodeSection: []*Code{{Body: tc.body}}}
+			require.Equal(t, tc.expected, m.UsesRelaxedSIMD())
+		})
+	}
+}
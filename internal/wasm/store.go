@@ -5,13 +5,16 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/tetratelabs/wazero/api"
 	experimentalapi "github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/ieee754"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -42,6 +45,19 @@ type (
 		// Note: this is fixed to 2^27 but have this a field for testability.
 		functionMaxTypes uint32
 
+		// MaxInstances is the maximum count of modules which aren't closed, across all namespaces, or zero if
+		// unlimited. This must be set before instantiating any module.
+		MaxInstances int
+
+		// MemoryGrowDeniedHook is invoked with the module that defines a memory when growing it fails, as it would
+		// exceed its max pages. This must be set before instantiating any module.
+		MemoryGrowDeniedHook func(mod api.Module, requestedPages, currentPages, maxPages uint32)
+
+		// UnresolvedImportStubs satisfies each function import that can't be
+		// resolved with a stub that traps when called. This must be set before
+		// instantiating any module.
+		UnresolvedImportStubs bool
+
 		// namespaces are all Namespace instances for this store including the default one.
 		namespaces []*Namespace // guarded by mux
 
@@ -84,6 +100,39 @@ type (
 		// ElementInstances holds the element instance, and each holds the references to either functions
 		// or external objects (unimplemented).
 		ElementInstances []ElementInstance
+
+		// HostState is copied from Module.HostState on instantiation.
+		HostState interface{}
+
+		// funcExportNames are the first names in Module.ExportSection order
+		// each function is exported under, keyed by function index.
+		funcExportNames map[Index]string
+
+		// dataSegments are retained from Module.DataSection for ReinitializeData.
+		dataSegments []*DataSegment
+
+		// initialGlobals are the values of the globals this module defines
+		// after instantiation, and memorySection is retained from Module, for
+		// Reset.
+		initialGlobals [][2]uint64
+		memorySection  *Memory
+
+		// InstructionBudget is the remaining count of instructions functions
+		// in this module may execute, or nil if unlimited. This is shared by
+		// all calls, so must be read and updated atomically.
+		InstructionBudget *uint64
+
+		// FunctionTimeouts bound calls to exported functions, keyed by
+		// export name. This is nil when no function has a timeout.
+		FunctionTimeouts map[string]time.Duration
+
+		// mappedMemories are the copy-on-write memories this module keeps
+		// mapped until it is closed: its own and those of modules it imports.
+		mappedMemories []*MemoryInstance
+
+		// importStubs are the stubs of unresolved imports this module uses,
+		// released when it is closed.
+		importStubs []*importStub
 	}
 
 	// DataInstance holds bytes corresponding to the data segment in a module.
@@ -199,14 +248,29 @@ func (m *ModuleInstance) buildElementInstances(elements []*ElementSegment) {
 	}
 }
 
+// applyHostTableFunctions sets table entries to functions of other modules.
+// References are engine-specific, so each is created by the engine of the
+// module that defines the function.
+func (m *ModuleInstance) applyHostTableFunctions(fns []*HostTableFunction) {
+	for _, fn := range fns {
+		idx := fn.Function.Idx
+		ref := fn.Function.Module.Engine.CreateFuncElementInstance([]*Index{&idx}).References[0]
+		m.Tables[fn.TableIndex].References[fn.Offset] = ref
+	}
+}
+
 func (m *ModuleInstance) BuildExports(exports []*Export) {
 	m.Exports = make(map[string]*ExportInstance, len(exports))
+	m.funcExportNames = map[Index]string{}
 	for _, exp := range exports {
 		index := exp.Index
 		var ei *ExportInstance
 		switch exp.Type {
 		case ExternTypeFunc:
 			ei = &ExportInstance{Type: exp.Type, Function: m.Functions[index]}
+			if _, ok := m.funcExportNames[index]; !ok {
+				m.funcExportNames[index] = exp.Name
+			}
 		case ExternTypeGlobal:
 			ei = &ExportInstance{Type: exp.Type, Global: m.Globals[index]}
 		case ExternTypeMemory:
@@ -225,9 +289,8 @@ func (m *ModuleInstance) BuildExports(exports []*Export) {
 func (m *ModuleInstance) validateData(data []*DataSegment) (err error) {
 	for i, d := range data {
 		if !d.IsPassive() {
-			offset := int(executeConstExpression(m.Globals, d.OffsetExpression).(int32))
-			ceil := offset + len(d.Init)
-			if offset < 0 || ceil > len(m.Memory.Buffer) {
+			offset := executeDataOffset(m.Globals, d.OffsetExpression)
+			if !m.Memory.HasSize64(offset, uint64(len(d.Init))) {
 				return fmt.Errorf("%s[%d]: out of bounds memory access", SectionIDName(SectionIDData), i)
 			}
 		}
@@ -238,21 +301,89 @@ func (m *ModuleInstance) validateData(data []*DataSegment) (err error) {
 // applyData uses the given data segments and mutate the memory according to the initial contents on it
 // and populate the `DataInstances`. This is called after all the validation phase passes and out of
 // bounds memory access error here is not a validation error, but rather a runtime error.
-func (m *ModuleInstance) applyData(data []*DataSegment) error {
+//
+// When preloaded, active data segments are already in memory, so are only bounds checked.
+func (m *ModuleInstance) applyData(data []*DataSegment, preloaded bool) error {
+	m.dataSegments = data
 	m.DataInstances = make([][]byte, len(data))
 	for i, d := range data {
 		m.DataInstances[i] = d.Init
 		if !d.IsPassive() {
-			offset := executeConstExpression(m.Globals, d.OffsetExpression).(int32)
-			if offset < 0 || int(offset)+len(d.Init) > len(m.Memory.Buffer) {
+			offset := executeDataOffset(m.Globals, d.OffsetExpression)
+			if !m.Memory.HasSize64(offset, uint64(len(d.Init))) {
 				return fmt.Errorf("%s[%d]: out of bounds memory access", SectionIDName(SectionIDData), i)
 			}
+			if !preloaded {
+				copy(m.Memory.Buffer[offset:], d.Init)
+			}
+		}
+	}
+	return nil
+}
+
+// ReinitializeData copies the active data segments into the current memory
+// again, as done on instantiation. Passive data segments are left untouched.
+//
+// This errs without mutating memory if any segment is out of bounds, as
+// memory is never grown here.
+func (m *ModuleInstance) ReinitializeData() error {
+	if err := m.validateData(m.dataSegments); err != nil {
+		return err
+	}
+	for _, d := range m.dataSegments {
+		if !d.IsPassive() {
+			offset := executeDataOffset(m.Globals, d.OffsetExpression)
 			copy(m.Memory.Buffer[offset:], d.Init)
 		}
 	}
 	return nil
 }
 
+// Reset restores the mutable globals and memory defined by this module to
+// their state after instantiation, without calling the start function again.
+// Memory is shrunk to its initial size and zeroed, before active data
+// segments are copied into it. Dropped data segments are restored.
+//
+// Mutable globals are restored to the values they had after instantiation,
+// even if initialized from an imported global which changed since. Imported
+// globals and memory, tables and element segments are left as-is.
+func (m *ModuleInstance) Reset() error {
+	defined := m.Globals[len(m.Globals)-len(m.initialGlobals):]
+	for i, g := range defined {
+		if g.Type.Mutable {
+			g.Val, g.ValHi = m.initialGlobals[i][0], m.initialGlobals[i][1]
+		}
+	}
+
+	for i, d := range m.dataSegments {
+		m.DataInstances[i] = d.Init
+	}
+
+	mem := m.Memory
+	if mem == nil || m.memorySection == nil {
+		return nil // no memory, or it is imported.
+	}
+	mem.mux.Lock()
+	// Zero all pages before shrinking, as growing up to Cap reslices the
+	// same backing array instead of allocating zeroed pages.
+	for i := range mem.Buffer {
+		mem.Buffer[i] = 0
+	}
+	mem.Buffer = mem.Buffer[:MemoryPagesToBytesNum(m.memorySection.Min)]
+	mem.mux.Unlock()
+	return m.ReinitializeData()
+}
+
+// snapshotGlobals records the values of the last n globals, which are those
+// this module defines, for Reset. Initializers may read imported globals, so
+// this must be called before any are mutated.
+func (m *ModuleInstance) snapshotGlobals(n int) {
+	m.initialGlobals = make([][2]uint64, n)
+	for i, g := range m.Globals[len(m.Globals)-n:] {
+		m.initialGlobals[i] = [2]uint64{g.Val, g.ValHi}
+	}
+}
+
 // GetExport returns an export of the given name and type or errs if not exported or the wrong type.
 func (m *ModuleInstance) getExport(name string, et ExternType) (*ExportInstance, error) {
 	exp, ok := m.Exports[name]
@@ -291,6 +422,7 @@ func (s *Store) NewNamespace(context.Context) *Namespace {
 // * ctx: the default context used for function calls.
 // * name: the name of the module.
 // * sys: the system context, which will be closed (SysContext.Close) on CallContext.Close.
+// * limits: bounds on calls to the module, or nil if unbounded.
 //
 // Note: Module.Validate must be called prior to instantiation.
 func (s *Store) Instantiate(
@@ -300,6 +432,7 @@ func (s *Store) Instantiate(
 	name string,
 	sys *internalsys.Context,
 	listeners []experimentalapi.FunctionListener,
+	limits *InstanceLimits,
 ) (*CallContext, error) {
 	// Collect any imported modules to avoid locking the namespace too long.
 	importedModuleNames := map[string]struct{}{}
@@ -307,19 +440,23 @@ func (s *Store) Instantiate(
 		importedModuleNames[i.Module] = struct{}{}
 	}
 
-	// Read-Lock the namespace and ensure imports needed are present.
-	importedModules, err := ns.requireModules(importedModuleNames)
-	if err != nil {
+	// Read-Lock the namespace and ensure imports needed are present, unless
+	// they would be stubbed.
+	var importedModules map[string]*ModuleInstance
+	var err error
+	if s.UnresolvedImportStubs {
+		importedModules = ns.existingModules(importedModuleNames)
+	} else if importedModules, err = ns.requireModules(importedModuleNames); err != nil {
 		return nil, err
 	}
 
 	// Write-Lock the namespace and claim the name of the current module.
-	if err = ns.requireModuleName(name); err != nil {
+	if err = s.requireModuleName(ns, name); err != nil {
 		return nil, err
 	}
 
 	// Instantiate the module and add it to the namespace so that other modules can import it.
-	if callCtx, err := s.instantiate(ctx, ns, module, name, sys, listeners, importedModules); err != nil {
+	if callCtx, err := s.instantiate(ctx, ns, module, name, sys, listeners, limits, importedModules); err != nil {
 		ns.deleteModule(name)
 		return nil, err
 	} else {
@@ -330,6 +467,36 @@ func (s *Store) Instantiate(
 	}
 }
 
+// InstanceLimits bound calls to functions of a module instance. These are in
+// place before its start function runs.
+type InstanceLimits struct {
+	// InstructionBudget is the same as ModuleInstance.InstructionBudget.
+	InstructionBudget *uint64
+
+	// FunctionTimeouts is the same as ModuleInstance.FunctionTimeouts.
+	FunctionTimeouts map[string]time.Duration
+}
+
+// requireModuleName is like Namespace.requireModuleName, except it also errs if MaxInstances would be exceeded.
+func (s *Store) requireModuleName(ns *Namespace, moduleName string) error {
+	if s.MaxInstances == 0 {
+		return ns.requireModuleName(moduleName)
+	}
+
+	// Lock the store, so that concurrent instantiation cannot exceed the limit.
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	count := 0
+	for _, n := range s.namespaces {
+		count += n.moduleCount()
+	}
+	if count >= s.MaxInstances {
+		return fmt.Errorf("module[%s] exceeds max instances: %d", moduleName, s.MaxInstances)
+	}
+	return ns.requireModuleName(moduleName)
+}
+
 func (s *Store) instantiate(
 	ctx context.Context,
 	ns *Namespace,
@@ -337,6 +504,7 @@ func (s *Store) instantiate(
 	name string,
 	sysCtx *internalsys.Context,
 	listeners []experimentalapi.FunctionListener,
+	limits *InstanceLimits,
 	modules map[string]*ModuleInstance,
 ) (*CallContext, error) {
 	typeIDs, err := s.getFunctionTypeIDs(module.TypeSection)
@@ -344,7 +512,20 @@ func (s *Store) instantiate(
 		return nil, err
 	}
 
-	importedFunctions, importedGlobals, importedTables, importedMemory, err := resolveImports(module, modules)
+	var stubs map[*Import]*FunctionInstance
+	var usedStubs []*importStub
+	if s.UnresolvedImportStubs {
+		if stubs, usedStubs, err = s.importStubs(ctx, ns, module, modules); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if usedStubs != nil { // Failed before the module could be closed.
+				ns.releaseImportStubs(ctx, usedStubs)
+			}
+		}()
+	}
+
+	importedFunctions, importedGlobals, importedTables, importedMemory, err := resolveImports(module, modules, stubs)
 	if err != nil {
 		return nil, err
 	}
@@ -355,14 +536,35 @@ func (s *Store) instantiate(
 	if err != nil {
 		return nil, err
 	}
-	globals, memory := module.buildGlobals(importedGlobals), module.buildMemory()
+	globals := module.buildGlobals(importedGlobals)
+
+	// When mapped from an image, the memory already holds active data segments.
+	memory, dataPreloaded := module.buildMemoryFromImage(), true
+	if memory == nil {
+		memory, dataPreloaded = module.buildMemory(), false
+	}
 
-	m := &ModuleInstance{Name: name, TypeIDs: typeIDs}
+	m := &ModuleInstance{
+		Name:          name,
+		TypeIDs:       typeIDs,
+		HostState:     module.HostState,
+		memorySection: module.MemorySection,
+	}
+	if limits != nil { // Before the start function runs or others can import this.
+		m.InstructionBudget, m.FunctionTimeouts = limits.InstructionBudget, limits.FunctionTimeouts
+	}
 	functions := m.BuildFunctions(module, listeners)
 
 	// Now we have all instances from imports and local ones, so ready to create a new ModuleInstance.
 	m.addSections(module, importedFunctions, functions, importedGlobals, globals, tables, importedMemory, memory, module.TypeSection)
 
+	m.retainMappings(modules)
+	defer func() {
+		if m.CallCtx == nil { // Failed before the module could be closed.
+			m.releaseMappings()
+		}
+	}()
+
 	// As of reference types proposal, data segment validation must happen after instantiation,
 	// and the side effect must persist even if there's out of bounds error after instantiation.
 	// https://github.com/WebAssembly/spec/blob/d39195773112a22b245ffbe864bab6d1182ccb06/test/core/linking.wast#L395-L405
@@ -384,39 +586,208 @@ func (s *Store) instantiate(
 	// After engine creation, we can create the funcref element instances and initialize funcref type globals.
 	m.buildElementInstances(module.ElementSection)
 	m.Engine.InitializeFuncrefGlobals(globals)
+	m.snapshotGlobals(len(globals))
+	m.applyHostTableFunctions(module.HostTableFunctions)
 
 	// Now all the validation passes, we are safe to mutate memory instances (possibly imported ones).
-	if err = m.applyData(module.DataSection); err != nil {
+	if err = m.applyData(module.DataSection, dataPreloaded); err != nil {
 		return nil, err
 	}
 
+	// Only prefault memory this module defines, as it isn't visible to other
+	// goroutines, unlike imported memory, until the module is published.
+	if memory != nil && sysCtx != nil && sysCtx.PrefaultMemory() {
+		memory.Prefault()
+	}
+
 	// Compile the default context for calls to this module.
 	callCtx := NewCallContext(ns, m, sysCtx)
 	m.CallCtx = callCtx
+	m.importStubs, usedStubs = usedStubs, nil // Now released on close.
+
+	if hook := s.MemoryGrowDeniedHook; memory != nil && hook != nil {
+		memory.growDeniedHook = func(requestedPages, currentPages, maxPages uint32) {
+			hook(callCtx, requestedPages, currentPages, maxPages)
+		}
+	}
 
 	// Execute the start function.
 	if module.StartSection != nil {
 		funcIdx := *module.StartSection
+		// The start function may be imported, e.g. a host function, in which
+		// case it is called with the importing module's context.
 		f := m.Functions[funcIdx]
+		startDesc := module.funcDesc(SectionIDFunction, funcIdx)
+		if funcIdx < module.ImportFuncCount() {
+			startDesc = fmt.Sprintf("function[%d] import[%s]", funcIdx, f.Definition.DebugName())
+		}
 
 		ce, err := f.Module.Engine.NewCallEngine(callCtx, f)
 		if err != nil {
-			return nil, fmt.Errorf("create call engine for start function[%s]: %v",
-				module.funcDesc(SectionIDFunction, funcIdx), err)
+			return nil, fmt.Errorf("create call engine for start %s: %v", startDesc, err)
 		}
 
-		_, err = ce.Call(ctx, callCtx, nil)
+		// Honor the context deadline, as a start function can otherwise hang instantiation.
+		_, err = CallWithContextDone(callCtx.withContextValues(ctx), ce, callCtx, nil)
+		if err != nil {
+			// Release system resources, as the caller never sees this module.
+			_, _ = callCtx.close(ctx, 0)
+		}
 		if exitErr, ok := err.(*sys.ExitError); ok { // Don't wrap an exit error!
 			return nil, exitErr
 		} else if err != nil {
-			return nil, fmt.Errorf("start %s failed: %w", module.funcDesc(SectionIDFunction, funcIdx), err)
+			return nil, fmt.Errorf("start %s failed: %w", startDesc, err)
 		}
 	}
 
 	return m.CallCtx, nil
 }
 
-func resolveImports(module *Module, modules map[string]*ModuleInstance) (
+// importStubs returns a stub for each function import of the module that
+// can't be resolved from modules. A stub traps with the name of the import
+// when called.
+//
+// Stubs are grouped into a host module per import module name, so that they
+// have the same names in stack traces. These aren't added to the namespace,
+// rather shared by modules with the same unresolved imports, until the last
+// of them, per the importStub result, is released.
+func (s *Store) importStubs(ctx context.Context, ns *Namespace, module *Module, modules map[string]*ModuleInstance) (map[*Import]*FunctionInstance, []*importStub, error) {
+	var moduleNames []string
+	unresolved := map[string][]*Import{}
+	for _, i := range module.ImportSection {
+		if i.Type != ExternTypeFunc {
+			continue
+		}
+		if m, ok := modules[i.Module]; ok {
+			if _, err := m.getExport(i.Name, ExternTypeFunc); err == nil {
+				continue
+			}
+		}
+		if _, ok := unresolved[i.Module]; !ok {
+			moduleNames = append(moduleNames, i.Module)
+		}
+		unresolved[i.Module] = append(unresolved[i.Module], i)
+	}
+
+	if len(moduleNames) == 0 {
+		return nil, nil, nil
+	}
+
+	stubs := make(map[*Import]*FunctionInstance, len(module.ImportSection))
+	used := make([]*importStub, 0, len(moduleNames))
+	for _, moduleName := range moduleNames {
+		imports := unresolved[moduleName]
+		stub, err := s.importStub(ctx, ns, module, moduleName, imports)
+		if err != nil {
+			ns.releaseImportStubs(ctx, used)
+			return nil, nil, err
+		}
+		used = append(used, stub)
+		for _, i := range imports {
+			// Duplicate imports of different types can't share a stub.
+			if exp, ok := stub.instance.Exports[i.Name]; ok && exp.Function.Type.EqualsSignature(
+				module.TypeSection[i.DescFunc].Params, module.TypeSection[i.DescFunc].Results) {
+				stubs[i] = exp.Function
+			}
+		}
+	}
+	return stubs, used, nil
+}
+
+// importStub is a host module of stubs for unresolved imports of the same
+// module name and signatures. See Store.importStubs.
+type importStub struct {
+	// id is the module ID, which is also the key in Namespace.importStubs.
+	id       string
+	module   *Module
+	instance *ModuleInstance
+	engine   Engine
+	// users is the count of modules using this stub. guarded by Namespace.importStubsMux
+	users int
+}
+
+// importStub returns the stub module of the imports, instantiating it unless
+// another module in the namespace already uses the same one.
+func (s *Store) importStub(ctx context.Context, ns *Namespace, module *Module, moduleName string, imports []*Import) (*importStub, error) {
+	// The ID is derived from the imports, so that the stub module is only
+	// compiled and instantiated once however many modules need it.
+	var id strings.Builder
+	id.WriteString("unresolved import stubs:" + moduleName + ":")
+	nameToGoFunc := make(map[string]interface{}, len(imports))
+	for _, i := range imports {
+		if int(i.DescFunc) >= len(module.TypeSection) {
+			continue // resolveImports errs on this.
+		}
+		ft := module.TypeSection[i.DescFunc]
+		nameToGoFunc[i.Name] = &HostFunc{
+			ExportNames: []string{i.Name},
+			Name:        i.Name,
+			ParamTypes:  ft.Params,
+			ResultTypes: ft.Results,
+			Code:        &Code{IsHostFunction: true, GoFunc: unresolvedImportStub(moduleName, i.Name)},
+		}
+		fmt.Fprintf(&id, "%s:%s;", i.Name, ft)
+	}
+
+	ns.importStubsMux.Lock()
+	defer ns.importStubsMux.Unlock()
+
+	if stub, ok := ns.importStubs[id.String()]; ok {
+		stub.users++
+		return stub, nil
+	}
+
+	stubModule, err := NewHostModule(moduleName, nameToGoFunc, nil, nil, s.EnabledFeatures)
+	if err != nil {
+		return nil, err
+	}
+	stubModule.AssignModuleID([]byte(id.String()))
+	if err = stubModule.Validate(s.EnabledFeatures); err != nil {
+		return nil, err
+	} else if err = s.Engine.CompileModule(ctx, stubModule); err != nil {
+		return nil, err
+	}
+
+	callCtx, err := s.instantiate(ctx, ns, stubModule, moduleName, nil, nil, nil, nil)
+	if err != nil {
+		s.Engine.DeleteCompiledModule(stubModule)
+		return nil, err
+	}
+	stub := &importStub{id: id.String(), module: stubModule, instance: callCtx.module, engine: s.Engine, users: 1}
+	if ns.importStubs == nil {
+		ns.importStubs = map[string]*importStub{}
+	}
+	ns.importStubs[stub.id] = stub
+	return stub, nil
+}
+
+// releaseImportStubs is called when a module using the stubs is closed or
+// failed to instantiate. The last user closes a stub and deletes its code.
+func (ns *Namespace) releaseImportStubs(ctx context.Context, stubs []*importStub) {
+	ns.importStubsMux.Lock()
+	defer ns.importStubsMux.Unlock()
+	for _, stub := range stubs {
+		if stub.users--; stub.users > 0 {
+			continue
+		}
+		delete(ns.importStubs, stub.id)
+		_, _ = stub.instance.CallCtx.close(ctx, 0)
+		stub.engine.DeleteCompiledModule(stub.module)
+	}
+}
+
+// unresolvedImportStub returns a function which traps with the name of the
+// import it stubs.
+func unresolvedImportStub(moduleName, name string) api.GoFunc {
+	err := wasmruntime.New(fmt.Sprintf("unimplemented import: %s.%s", moduleName, name))
+	return func(context.Context, []uint64) {
+		panic(err)
+	}
+}
+
+// resolveImports returns the instances imported by the module, where stubs
+// replace function imports that can't be resolved, unless nil.
+func resolveImports(module *Module, modules map[string]*ModuleInstance, stubs map[*Import]*FunctionInstance) (
 	importedFunctions []*FunctionInstance,
 	importedGlobals []*GlobalInstance,
 	importedTables []*TableInstance,
@@ -424,6 +795,11 @@ func resolveImports(module *Module, modules map[string]*ModuleInstance) (
 	err error,
 ) {
 	for idx, i := range module.ImportSection {
+		if stub, ok := stubs[i]; ok {
+			importedFunctions = append(importedFunctions, stub)
+			continue
+		}
+
 		m, ok := modules[i.Module]
 		if !ok {
 			err = fmt.Errorf("module[%s] not instantiated", i.Module)
@@ -461,10 +837,12 @@ func resolveImports(module *Module, modules map[string]*ModuleInstance) (
 			if expected.Type != importedTable.Type {
 				err = errorInvalidImport(i, idx, fmt.Errorf("table type mismatch: %s != %s",
 					RefTypeName(expected.Type), RefTypeName(importedTable.Type)))
+				return
 			}
 
-			if expected.Min > importedTable.Min {
-				err = errorMinSizeMismatch(i, idx, expected.Min, importedTable.Min)
+			// Compare against the current size, as the table may have grown.
+			if size := uint32(len(importedTable.References)); expected.Min > size {
+				err = errorMinSizeMismatch(i, idx, expected.Min, size)
 				return
 			}
 
@@ -492,6 +870,11 @@ func resolveImports(module *Module, modules map[string]*ModuleInstance) (
 				err = errorMaxSizeMismatch(i, idx, expected.Max, importedMemory.Max)
 				return
 			}
+
+			if expected.Is64 != importedMemory.Is64 {
+				err = errorInvalidImport(i, idx, fmt.Errorf("64-bit mismatch: %t != %t", expected.Is64, importedMemory.Is64))
+				return
+			}
 		case ExternTypeGlobal:
 			expected := i.DescGlobal
 			importedGlobal := imported.Global
@@ -574,10 +957,32 @@ func executeConstExpression(importedGlobals []*GlobalInstance, expr *ConstantExp
 		v, _, _ = leb128.LoadUint32(expr.Data)
 	case OpcodeVecV128Const:
 		v = [2]uint64{binary.LittleEndian.Uint64(expr.Data[0:8]), binary.LittleEndian.Uint64(expr.Data[8:16])}
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		// The expression was already validated, so errors are impossible.
+		t, val, _ := evalExtendedConstExpression(expr.Data, func(id uint32) (ValueType, uint64, error) {
+			g := importedGlobals[id]
+			return g.Type.ValType, g.Val, nil
+		})
+		if t == ValueTypeI32 {
+			v = int32(val)
+		} else {
+			v = int64(val)
+		}
 	}
 	return
 }
 
+// executeDataOffset returns the offset of an active data segment, which is an
+// unsigned i64 for a 64-bit memory (memory64), or an unsigned i32 otherwise.
+func executeDataOffset(globals []*GlobalInstance, expr *ConstantExpression) uint64 {
+	switch v := executeConstExpression(globals, expr).(type) {
+	case int64:
+		return uint64(v)
+	default:
+		return uint64(uint32(v.(int32)))
+	}
+}
+
 // GlobalInstanceNullFuncRefValue is the temporary value for ValueTypeFuncref globals which are initialized via ref.null.
 const GlobalInstanceNullFuncRefValue int64 = -1
 
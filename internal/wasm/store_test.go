@@ -7,6 +7,7 @@ import (
 	"math"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
@@ -76,7 +77,7 @@ func TestModuleInstance_Memory(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			s, ns := newStore()
 
-			instance, err := s.Instantiate(testCtx, ns, tc.input, "test", nil, nil)
+			instance, err := s.Instantiate(testCtx, ns, tc.input, "test", nil, nil, nil)
 			require.NoError(t, err)
 
 			mem := instance.ExportedMemory("memory")
@@ -91,11 +92,11 @@ func TestModuleInstance_Memory(t *testing.T) {
 
 func TestStore_Instantiate(t *testing.T) {
 	s, ns := newStore()
-	m, err := NewHostModule("", map[string]interface{}{"fn": func() {}}, nil, api.CoreFeaturesV1)
+	m, err := NewHostModule("", map[string]interface{}{"fn": func() {}}, nil, nil, api.CoreFeaturesV1)
 	require.NoError(t, err)
 
 	sysCtx := sys.DefaultContext(nil)
-	mod, err := s.Instantiate(testCtx, ns, m, "", sysCtx, nil)
+	mod, err := s.Instantiate(testCtx, ns, m, "", sysCtx, nil, nil)
 	require.NoError(t, err)
 	defer mod.Close(testCtx)
 
@@ -107,6 +108,21 @@ func TestStore_Instantiate(t *testing.T) {
 	})
 }
 
+func TestStore_Instantiate_Limits(t *testing.T) {
+	s, ns := newStore()
+	m, err := NewHostModule("", map[string]interface{}{"fn": func() {}}, nil, nil, api.CoreFeaturesV1)
+	require.NoError(t, err)
+
+	budget := uint64(5)
+	timeouts := map[string]time.Duration{"fn": time.Second}
+	mod, err := s.Instantiate(testCtx, ns, m, "", nil, nil, &InstanceLimits{InstructionBudget: &budget, FunctionTimeouts: timeouts})
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	require.Equal(t, &budget, mod.module.InstructionBudget)
+	require.Equal(t, timeouts, mod.module.FunctionTimeouts)
+}
+
 func TestStore_CloseWithExitCode(t *testing.T) {
 	const importedModuleName = "imported"
 	const importingModuleName = "test"
@@ -136,7 +152,7 @@ func TestStore_CloseWithExitCode(t *testing.T) {
 				CodeSection:               []*Code{{Body: []byte{OpcodeEnd}}},
 				ExportSection:             []*Export{{Type: ExternTypeFunc, Index: 0, Name: "fn"}},
 				FunctionDefinitionSection: []*FunctionDefinition{{funcType: v_v}},
-			}, importedModuleName, nil, nil)
+			}, importedModuleName, nil, nil, nil)
 			require.NoError(t, err)
 
 			m2, err := s.Instantiate(testCtx, ns, &Module{
@@ -145,7 +161,7 @@ func TestStore_CloseWithExitCode(t *testing.T) {
 				MemorySection: &Memory{Min: 1, Cap: 1},
 				GlobalSection: []*Global{{Type: &GlobalType{}, Init: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1}}},
 				TableSection:  []*Table{{Min: 10}},
-			}, importingModuleName, nil, nil)
+			}, importingModuleName, nil, nil, nil)
 			require.NoError(t, err)
 
 			if tc.testClosed {
@@ -169,11 +185,11 @@ func TestStore_CloseWithExitCode(t *testing.T) {
 func TestStore_hammer(t *testing.T) {
 	const importedModuleName = "imported"
 
-	m, err := NewHostModule(importedModuleName, map[string]interface{}{"fn": func() {}}, nil, api.CoreFeaturesV1)
+	m, err := NewHostModule(importedModuleName, map[string]interface{}{"fn": func() {}}, nil, nil, api.CoreFeaturesV1)
 	require.NoError(t, err)
 
 	s, ns := newStore()
-	imported, err := s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+	imported, err := s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 	require.NoError(t, err)
 
 	_, ok := ns.modules[imported.Name()]
@@ -204,7 +220,7 @@ func TestStore_hammer(t *testing.T) {
 		N = 100
 	}
 	hammer.NewHammer(t, P, N).Run(func(name string) {
-		mod, instantiateErr := s.Instantiate(testCtx, ns, importingModule, name, sys.DefaultContext(nil), nil)
+		mod, instantiateErr := s.Instantiate(testCtx, ns, importingModule, name, sys.DefaultContext(nil), nil, nil)
 		require.NoError(t, instantiateErr)
 		require.NoError(t, mod.Close(testCtx))
 	}, nil)
@@ -223,22 +239,22 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 	const importedModuleName = "imported"
 	const importingModuleName = "test"
 
-	m, err := NewHostModule(importedModuleName, map[string]interface{}{"fn": func() {}}, nil, api.CoreFeaturesV1)
+	m, err := NewHostModule(importedModuleName, map[string]interface{}{"fn": func() {}}, nil, nil, api.CoreFeaturesV1)
 	require.NoError(t, err)
 
 	t.Run("Fails if module name already in use", func(t *testing.T) {
 		s, ns := newStore()
-		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 		require.NoError(t, err)
 
 		// Trying to register it again should fail
-		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 		require.EqualError(t, err, "module[imported] has already been instantiated")
 	})
 
 	t.Run("fail resolve import", func(t *testing.T) {
 		s, ns := newStore()
-		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 		require.NoError(t, err)
 
 		hm := ns.modules[importedModuleName]
@@ -252,14 +268,14 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 				// But the second one tries to import uninitialized-module ->
 				{Type: ExternTypeFunc, Module: "non-exist", Name: "fn", DescFunc: 0},
 			},
-		}, importingModuleName, nil, nil)
+		}, importingModuleName, nil, nil, nil)
 		require.EqualError(t, err, "module[non-exist] not instantiated")
 	})
 
 	t.Run("compilation failed", func(t *testing.T) {
 		s, ns := newStore()
 
-		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 		require.NoError(t, err)
 
 		hm := ns.modules[importedModuleName]
@@ -281,7 +297,7 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 		}
 		importingModule.BuildFunctionDefinitions()
 
-		_, err = s.Instantiate(testCtx, ns, importingModule, importingModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, importingModule, importingModuleName, nil, nil, nil)
 		require.EqualError(t, err, "compilation failed: some compilation error")
 	})
 
@@ -290,7 +306,7 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 		engine := s.Engine.(*mockEngine)
 		engine.callFailIndex = 1
 
-		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
 		require.NoError(t, err)
 
 		hm := ns.modules[importedModuleName]
@@ -308,19 +324,41 @@ func TestStore_Instantiate_Errors(t *testing.T) {
 		}
 		importingModule.BuildFunctionDefinitions()
 
-		_, err = s.Instantiate(testCtx, ns, importingModule, importingModuleName, nil, nil)
+		_, err = s.Instantiate(testCtx, ns, importingModule, importingModuleName, nil, nil, nil)
 		require.EqualError(t, err, "start function[1] failed: call failed")
 	})
+
+	t.Run("imported start func failed", func(t *testing.T) {
+		s, ns := newStore()
+		engine := s.Engine.(*mockEngine)
+		engine.callFailIndex = 0
+
+		_, err = s.Instantiate(testCtx, ns, m, importedModuleName, nil, nil, nil)
+		require.NoError(t, err)
+
+		startFuncIndex := uint32(0)
+		importingModule := &Module{
+			TypeSection:  []*FunctionType{v_v},
+			StartSection: &startFuncIndex,
+			ImportSection: []*Import{
+				{Type: ExternTypeFunc, Module: importedModuleName, Name: "fn", DescFunc: 0},
+			},
+		}
+		importingModule.BuildFunctionDefinitions()
+
+		_, err = s.Instantiate(testCtx, ns, importingModule, importingModuleName, nil, nil, nil)
+		require.EqualError(t, err, "start function[0] import[imported.fn] failed: call failed")
+	})
 }
 
 func TestCallContext_ExportedFunction(t *testing.T) {
-	host, err := NewHostModule("host", map[string]interface{}{"host_fn": func() {}}, nil, api.CoreFeaturesV1)
+	host, err := NewHostModule("host", map[string]interface{}{"host_fn": func() {}}, nil, nil, api.CoreFeaturesV1)
 	require.NoError(t, err)
 
 	s, ns := newStore()
 
 	// Add the host module
-	imported, err := s.Instantiate(testCtx, ns, host, host.NameSection.ModuleName, nil, nil)
+	imported, err := s.Instantiate(testCtx, ns, host, host.NameSection.ModuleName, nil, nil, nil)
 	require.NoError(t, err)
 	defer imported.Close(testCtx)
 
@@ -330,7 +368,7 @@ func TestCallContext_ExportedFunction(t *testing.T) {
 			ImportSection: []*Import{{Type: ExternTypeFunc, Module: "host", Name: "host_fn", DescFunc: 0}},
 			MemorySection: &Memory{Min: 1, Cap: 1},
 			ExportSection: []*Export{{Type: ExternTypeFunc, Name: "host.fn", Index: 0}},
-		}, "test", nil, nil)
+		}, "test", nil, nil, nil)
 		require.NoError(t, err)
 		defer importing.Close(testCtx)
 
@@ -342,6 +380,36 @@ func TestCallContext_ExportedFunction(t *testing.T) {
 	})
 }
 
+func TestCallContext_ExportedFunctionByIndex(t *testing.T) {
+	s, ns := newStore()
+
+	m := &Module{
+		TypeSection:     []*FunctionType{v_v},
+		FunctionSection: []Index{0},
+		CodeSection:     []*Code{{Body: []byte{OpcodeEnd}}},
+		// The function is exported under several names, and the first is not
+		// the least in map or lexical order.
+		ExportSection: []*Export{
+			{Type: ExternTypeFunc, Name: "c", Index: 0},
+			{Type: ExternTypeFunc, Name: "a", Index: 0},
+			{Type: ExternTypeFunc, Name: "b", Index: 0},
+		},
+	}
+	m.BuildFunctionDefinitions()
+	mod, err := s.Instantiate(testCtx, ns, m, "test", nil, nil, nil)
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	mod.module.FunctionTimeouts = map[string]time.Duration{"c": time.Second}
+
+	// Repeat as a map iteration order would differ between calls.
+	for i := 0; i < 10; i++ {
+		fn := mod.ExportedFunctionByIndex(0)
+		require.Equal(t, time.Second, fn.(*function).timeout)
+	}
+	require.Nil(t, mod.ExportedFunctionByIndex(1))
+}
+
 type mockEngine struct {
 	shouldCompileFail bool
 	callFailIndex     int
@@ -365,8 +433,8 @@ func newStore() (*Store, *Namespace) {
 func (e *mockEngine) CompileModule(context.Context, *Module) error { return nil }
 
 // LookupFunction implements the same method as documented on wasm.Engine.
-func (e *mockModuleEngine) LookupFunction(*TableInstance, FunctionTypeID, Index) (Index, error) {
-	return 0, nil
+func (e *mockModuleEngine) LookupFunction(*TableInstance, FunctionTypeID, Index) (*FunctionInstance, error) {
+	return nil, nil
 }
 
 // CompiledModuleCount implements the same method as documented on wasm.Engine.
@@ -413,6 +481,14 @@ func (ce *mockCallEngine) Call(ctx context.Context, callCtx *CallContext, _ []ui
 	return
 }
 
+// CallInPlace implements the same method as documented on wasm.CallEngine.
+func (ce *mockCallEngine) CallInPlace(ctx context.Context, callCtx *CallContext, params []uint64) (results []uint64, err error) {
+	return ce.Call(ctx, callCtx, params)
+}
+
+// Interrupt implements the same method as documented on wasm.CallEngine.
+func (ce *mockCallEngine) Interrupt(error) {}
+
 func TestStore_getFunctionTypeID(t *testing.T) {
 	t.Run("too many functions", func(t *testing.T) {
 		s, _ := newStore()
@@ -609,14 +685,14 @@ func Test_resolveImports(t *testing.T) {
 
 	t.Run("module not instantiated", func(t *testing.T) {
 		modules := map[string]*ModuleInstance{}
-		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: "unknown", Name: "unknown"}}}, modules)
+		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: "unknown", Name: "unknown"}}}, modules, nil)
 		require.EqualError(t, err, "module[unknown] not instantiated")
 	})
 	t.Run("export instance not found", func(t *testing.T) {
 		modules := map[string]*ModuleInstance{
 			moduleName: {Exports: map[string]*ExportInstance{}, Name: moduleName},
 		}
-		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: "unknown"}}}, modules)
+		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: "unknown"}}}, modules, nil)
 		require.EqualError(t, err, "\"unknown\" is not exported in module \"test\"")
 	})
 	t.Run("func", func(t *testing.T) {
@@ -643,7 +719,7 @@ func Test_resolveImports(t *testing.T) {
 					{Module: moduleName, Name: "", Type: ExternTypeFunc, DescFunc: 1},
 				},
 			}
-			functions, _, _, _, err := resolveImports(m, modules)
+			functions, _, _, _, err := resolveImports(m, modules, nil)
 			require.NoError(t, err)
 			require.True(t, functionsContain(functions, f), "expected to find %v in %v", f, functions)
 			require.True(t, functionsContain(functions, g), "expected to find %v in %v", g, functions)
@@ -652,7 +728,7 @@ func Test_resolveImports(t *testing.T) {
 			modules := map[string]*ModuleInstance{
 				moduleName: {Exports: map[string]*ExportInstance{name: {}}, Name: moduleName},
 			}
-			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 100}}}, modules)
+			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 100}}}, modules, nil)
 			require.EqualError(t, err, "import[0] func[test.target]: function type out of range")
 		})
 		t.Run("signature mismatch", func(t *testing.T) {
@@ -665,7 +741,7 @@ func Test_resolveImports(t *testing.T) {
 				TypeSection:   []*FunctionType{{Results: []ValueType{ValueTypeF32}}},
 				ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 0}},
 			}
-			_, _, _, _, err := resolveImports(m, modules)
+			_, _, _, _, err := resolveImports(m, modules, nil)
 			require.EqualError(t, err, "import[0] func[test.target]: signature mismatch: v_f32 != v_v")
 		})
 	})
@@ -675,7 +751,7 @@ func Test_resolveImports(t *testing.T) {
 			modules := map[string]*ModuleInstance{
 				moduleName: {Exports: map[string]*ExportInstance{name: {Type: ExternTypeGlobal, Global: g}}, Name: moduleName},
 			}
-			_, globals, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: g.Type}}}, modules)
+			_, globals, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: g.Type}}}, modules, nil)
 			require.NoError(t, err)
 			require.True(t, globalsContain(globals, g), "expected to find %v in %v", g, globals)
 		})
@@ -686,7 +762,7 @@ func Test_resolveImports(t *testing.T) {
 					Global: &GlobalInstance{Type: &GlobalType{Mutable: false}},
 				}}, Name: moduleName},
 			}
-			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: &GlobalType{Mutable: true}}}}, modules)
+			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: &GlobalType{Mutable: true}}}}, modules, nil)
 			require.EqualError(t, err, "import[0] global[test.target]: mutability mismatch: true != false")
 		})
 		t.Run("type mismatch", func(t *testing.T) {
@@ -696,7 +772,7 @@ func Test_resolveImports(t *testing.T) {
 					Global: &GlobalInstance{Type: &GlobalType{ValType: ValueTypeI32}},
 				}}, Name: moduleName},
 			}
-			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeF64}}}}, modules)
+			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeF64}}}}, modules, nil)
 			require.EqualError(t, err, "import[0] global[test.target]: value type mismatch: f64 != i32")
 		})
 	})
@@ -710,7 +786,7 @@ func Test_resolveImports(t *testing.T) {
 					Memory: memoryInst,
 				}}, Name: moduleName},
 			}
-			_, _, _, memory, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: &Memory{Max: max}}}}, modules)
+			_, _, _, memory, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: &Memory{Max: max}}}}, modules, nil)
 			require.NoError(t, err)
 			require.Equal(t, memory, memoryInst)
 		})
@@ -722,7 +798,7 @@ func Test_resolveImports(t *testing.T) {
 					Memory: &MemoryInstance{Min: importMemoryType.Min - 1, Cap: 2},
 				}}, Name: moduleName},
 			}
-			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}}, modules)
+			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}}, modules, nil)
 			require.EqualError(t, err, "import[0] memory[test.target]: minimum size mismatch: 2 > 1")
 		})
 		t.Run("maximum size mismatch", func(t *testing.T) {
@@ -734,7 +810,7 @@ func Test_resolveImports(t *testing.T) {
 					Memory: &MemoryInstance{Max: MemoryLimitPages},
 				}}, Name: moduleName},
 			}
-			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}}, modules)
+			_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}}, modules, nil)
 			require.EqualError(t, err, "import[0] memory[test.target]: maximum size mismatch: 10 < 65536")
 		})
 	})
@@ -790,18 +866,112 @@ func TestModuleInstance_applyData(t *testing.T) {
 		err := m.applyData([]*DataSegment{
 			{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const0}, Init: []byte{0xa, 0xf}},
 			{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeUint32(8)}, Init: []byte{0x1, 0x5}},
-		})
+		}, false)
 		require.NoError(t, err)
 		require.Equal(t, []byte{0xa, 0xf, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0x5}, m.Memory.Buffer)
 		require.Equal(t, [][]byte{{0xa, 0xf}, {0x1, 0x5}}, m.DataInstances)
 	})
+	t.Run("extended const offset", func(t *testing.T) {
+		m := &ModuleInstance{
+			Memory:  &MemoryInstance{Buffer: make([]byte, 10)},
+			Globals: []*GlobalInstance{{Type: &GlobalType{ValType: ValueTypeI32}, Val: 3}},
+		}
+		err := m.applyData([]*DataSegment{
+			{
+				// global.get 0 + i32.const 4
+				OffsetExpression: &ConstantExpression{
+					Opcode: OpcodeI32Add,
+					Data:   []byte{OpcodeGlobalGet, 0, OpcodeI32Const, 4, OpcodeI32Add},
+				},
+				Init: []byte{0xa, 0xf},
+			},
+		}, false)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xa, 0xf, 0x0}, m.Memory.Buffer)
+	})
+	t.Run("preloaded", func(t *testing.T) {
+		m := &ModuleInstance{Memory: &MemoryInstance{Buffer: make([]byte, 10)}}
+		err := m.applyData([]*DataSegment{
+			{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const0}, Init: []byte{0xa, 0xf}},
+		}, true)
+		require.NoError(t, err)
+		require.Equal(t, make([]byte, 10), m.Memory.Buffer) // not copied
+		require.Equal(t, [][]byte{{0xa, 0xf}}, m.DataInstances)
+	})
 	t.Run("error", func(t *testing.T) {
 		m := &ModuleInstance{Memory: &MemoryInstance{Buffer: make([]byte, 5)}}
 		err := m.applyData([]*DataSegment{
 			{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeUint32(8)}, Init: []byte{}},
-		})
+		}, false)
 		require.EqualError(t, err, "data[0]: out of bounds memory access")
 	})
+	t.Run("error on overflow", func(t *testing.T) {
+		// Adding the length to these offsets overflows int64 and uint64.
+		for _, offset := range []int64{math.MaxInt64, -1} {
+			m := &ModuleInstance{Memory: &MemoryInstance{Buffer: make([]byte, 5), Is64: true}}
+			err := m.applyData([]*DataSegment{
+				{OffsetExpression: &ConstantExpression{Opcode: OpcodeI64Const, Data: leb128.EncodeInt64(offset)}, Init: []byte{0xa}},
+			}, false)
+			require.EqualError(t, err, "data[0]: out of bounds memory access")
+		}
+	})
+}
+
+func TestModuleInstance_Reset(t *testing.T) {
+	i32 := &GlobalType{ValType: ValueTypeI32, Mutable: true}
+	imported := &GlobalInstance{Type: i32, Val: 9}
+	m := &ModuleInstance{
+		Engine: &mockModuleEngine{},
+		// The first defined global was initialized from the imported one.
+		Globals:       []*GlobalInstance{imported, {Type: i32, Val: 9}, {Type: &GlobalType{ValType: ValueTypeI32}, Val: 2}},
+		Memory:        &MemoryInstance{Buffer: make([]byte, 2*MemoryPageSize), Min: 1, Cap: 2, Max: 2},
+		memorySection: &Memory{Min: 1, Max: 2},
+	}
+	err := m.applyData([]*DataSegment{
+		{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1}, Init: []byte{0xa, 0xf}},
+		{Init: []byte{0xb}}, // passive
+	}, false)
+	require.NoError(t, err)
+	m.snapshotGlobals(2)
+
+	m.Memory.Buffer[0] = 1
+	// Write to the grown page, which Reset must zero.
+	copy(m.Memory.Buffer[MemoryPageSize:], "secret")
+	m.DataInstances[1] = nil // data.drop
+	m.Globals[1].Val = 5
+	imported.Val = 7
+
+	require.NoError(t, m.Reset())
+	require.Equal(t, uint64(7), m.Globals[0].Val) // imported globals are left as-is.
+	require.Equal(t, uint64(9), m.Globals[1].Val) // the imported value at instantiation.
+	require.Equal(t, uint64(2), m.Globals[2].Val) // immutable, so unchanged.
+	require.Equal(t, int(MemoryPageSize), len(m.Memory.Buffer))
+	require.Equal(t, []byte{0x0, 0xa, 0xf, 0x0}, m.Memory.Buffer[:4])
+	require.Equal(t, []DataInstance{{0xa, 0xf}, {0xb}}, m.DataInstances)
+
+	// Growing within Cap must not expose data written before the reset.
+	_, ok := m.Memory.Grow(testCtx, 1)
+	require.True(t, ok)
+	require.Equal(t, make([]byte, 6), m.Memory.Buffer[MemoryPageSize:MemoryPageSize+6])
+}
+
+func TestModuleInstance_ReinitializeData(t *testing.T) {
+	m := &ModuleInstance{Memory: &MemoryInstance{Buffer: make([]byte, 10)}}
+	err := m.applyData([]*DataSegment{
+		{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const0}, Init: []byte{0xa, 0xf}},
+		{Init: []byte{0x1, 0x5}}, // passive
+	}, false)
+	require.NoError(t, err)
+
+	copy(m.Memory.Buffer, []byte{1, 2, 3})
+	require.NoError(t, m.ReinitializeData())
+	require.Equal(t, []byte{0xa, 0xf, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}, m.Memory.Buffer)
+
+	t.Run("error", func(t *testing.T) {
+		m.Memory.Buffer = make([]byte, 1)
+		require.EqualError(t, m.ReinitializeData(), "data[0]: out of bounds memory access")
+		require.Equal(t, []byte{0}, m.Memory.Buffer)
+	})
 }
 
 func globalsContain(globals []*GlobalInstance, want *GlobalInstance) bool {
@@ -84,6 +84,9 @@ func (e *ElementSegment) IsActive() bool {
 	return e.Mode == ElementModeActive
 }
 
+// compile-time check to ensure TableInstance implements api.Table
+var _ api.Table = &TableInstance{}
+
 // TableInstance represents a table of (RefTypeFuncref) elements in a module.
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#table-instances%E2%91%A0
@@ -123,7 +126,7 @@ type Reference = uintptr
 //
 // Note: The global imported at globalIdx may have an offset value that is out-of-bounds for the corresponding table.
 type validatedActiveElementSegment struct {
-	// opcode is OpcodeGlobalGet or OpcodeI32Const
+	// opcode is OpcodeGlobalGet, OpcodeI32Const, or the last instruction of an extended constant expression.
 	opcode Opcode
 
 	// arg is the only argument to opcode, which when applied results in the offset to add to init indices.
@@ -131,6 +134,10 @@ type validatedActiveElementSegment struct {
 	//  * OpcodeI32Const: a constant ValueTypeI32 offset.
 	arg uint32
 
+	// expr is the offset expression when it is extended, per ConstantExpression.IsExtended, so is evaluated on
+	// instantiation instead of using arg.
+	expr *ConstantExpression
+
 	// init are a range of table elements whose values are positions in the function index namespace. This range
 	// replaces any values in TableInstance.Table at an offset arg which is a constant if opcode == OpcodeI32Const or
 	// derived from a globalIdx if opcode == OpcodeGlobalGet
@@ -228,6 +235,17 @@ func (m *Module) validateTable(enabledFeatures api.CoreFeatures, tables []*Table
 				}
 
 				ret = append(ret, &validatedActiveElementSegment{opcode: oc, arg: offset, init: elem.Init, tableIndex: elem.TableIndex})
+			} else if elem.OffsetExpr.IsExtended() {
+				// This may read imported globals, so is evaluated on instantiation, similar to global.get.
+				if err := validateConstExpression(m.importedGlobalTypes(), 0, elem.OffsetExpr, ValueTypeI32); err != nil {
+					return nil, fmt.Errorf("%s[%d] has an invalid const expression: %w", SectionIDName(SectionIDElement), idx, err)
+				}
+
+				if initCount == 0 {
+					continue // Per https://github.com/WebAssembly/spec/issues/1427 init can be no-op, but validate anyway!
+				}
+
+				ret = append(ret, &validatedActiveElementSegment{opcode: oc, expr: elem.OffsetExpr, init: elem.Init, tableIndex: elem.TableIndex})
 			} else {
 				return nil, fmt.Errorf("%s[%d] has an invalid const expression: %s", SectionIDName(SectionIDElement), idx, InstructionName(oc))
 			}
@@ -267,6 +285,8 @@ func (m *Module) buildTables(importedTables []*TableInstance, importedGlobals []
 		if elem.opcode == OpcodeGlobalGet {
 			global := importedGlobals[elem.arg]
 			offset = uint32(global.Val)
+		} else if elem.expr != nil {
+			offset = uint32(executeConstExpression(importedGlobals, elem.expr).(int32))
 		} else {
 			offset = elem.arg // constant
 		}
@@ -309,6 +329,17 @@ func checkSegmentBounds(min uint32, requireMin uint64, idx Index) error { // uin
 	return nil
 }
 
+// importedGlobalTypes returns the types of the globals this module imports,
+// in the global index namespace.
+func (m *Module) importedGlobalTypes() (ret []*GlobalType) {
+	for _, im := range m.ImportSection {
+		if im.Type == ExternTypeGlobal {
+			ret = append(ret, im.DescGlobal)
+		}
+	}
+	return
+}
+
 func (m *Module) verifyImportGlobalI32(sectionID SectionID, sectionIdx Index, idx uint32) error {
 	ig := uint32(math.MaxUint32) // +1 == 0
 	for i, im := range m.ImportSection {
@@ -325,6 +356,27 @@ func (m *Module) verifyImportGlobalI32(sectionID SectionID, sectionIdx Index, id
 	return fmt.Errorf("%s[%d] (global.get %d): out of range of imported globals", SectionIDName(sectionID), sectionIdx, idx)
 }
 
+// Size implements the same method as documented on api.Table.
+func (t *TableInstance) Size(context.Context) uint32 {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	return uint32(len(t.References))
+}
+
+// Entries implements the same method as documented on api.Table.
+func (t *TableInstance) Entries(context.Context) ([]api.Reference, bool) {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+	if len(t.References) == 0 {
+		return nil, false
+	}
+	ret := make([]api.Reference, len(t.References))
+	for i, ref := range t.References {
+		ret[i] = api.Reference(ref)
+	}
+	return ret, true
+}
+
 // Grow appends the `initialRef` by `delta` times into the References slice.
 // Returns -1 if the operation is not valid, otherwise the old length of the table.
 //
@@ -0,0 +1,98 @@
+package wasm
+
+import "github.com/tetratelabs/wazero/api"
+
+// TableDefinitions implements the same method as documented on
+// wazero.CompiledModule.
+func (m *Module) TableDefinitions() (ret []api.TableDefinition) {
+	var moduleName string
+	var tableNames NameMap
+	if m.NameSection != nil {
+		moduleName = m.NameSection.ModuleName
+		tableNames = m.NameSection.TableNames
+	}
+
+	var defs []*TableDefinition
+	for _, i := range m.ImportSection {
+		if i.Type == ExternTypeTable {
+			defs = append(defs, &TableDefinition{importDesc: &[2]string{i.Module, i.Name}, table: i.DescTable})
+		}
+	}
+	for _, t := range m.TableSection {
+		defs = append(defs, &TableDefinition{table: t})
+	}
+
+	for idx, d := range defs {
+		d.moduleName = moduleName
+		d.index = Index(idx)
+		for _, n := range tableNames {
+			if n.Index == d.index {
+				d.name = n.Name
+				break
+			}
+		}
+		for _, e := range m.ExportSection {
+			if e.Type == ExternTypeTable && e.Index == d.index {
+				d.exportNames = append(d.exportNames, e.Name)
+			}
+		}
+		ret = append(ret, d)
+	}
+	return
+}
+
+// TableDefinition implements api.TableDefinition
+type TableDefinition struct {
+	moduleName  string
+	index       Index
+	name        string
+	importDesc  *[2]string
+	exportNames []string
+	table       *Table
+}
+
+// ModuleName implements the same method as documented on api.TableDefinition.
+func (f *TableDefinition) ModuleName() string {
+	return f.moduleName
+}
+
+// Index implements the same method as documented on api.TableDefinition.
+func (f *TableDefinition) Index() uint32 {
+	return f.index
+}
+
+// Name implements the same method as documented on api.TableDefinition.
+func (f *TableDefinition) Name() string {
+	return f.name
+}
+
+// Import implements the same method as documented on api.TableDefinition.
+func (f *TableDefinition) Import() (moduleName, name string, isImport bool) {
+	if importDesc := f.importDesc; importDesc != nil {
+		moduleName, name, isImport = importDesc[0], importDesc[1], true
+	}
+	return
+}
+
+// ExportNames implements the same method as documented on api.TableDefinition.
+func (f *TableDefinition) ExportNames() []string {
+	return f.exportNames
+}
+
+// ElemType implements the same method as documented on api.TableDefinition.
+func (f *TableDefinition) ElemType() api.RefType {
+	return f.table.Type
+}
+
+// Min implements the same method as documented on api.TableDefinition.
+func (f *TableDefinition) Min() uint32 {
+	return f.table.Min
+}
+
+// Max implements the same method as documented on api.TableDefinition.
+func (f *TableDefinition) Max() (max uint32, encoded bool) {
+	if f.table.Max != nil {
+		max, encoded = *f.table.Max, true
+	}
+	return
+}
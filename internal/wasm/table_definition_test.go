@@ -0,0 +1,73 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_TableDefinitions(t *testing.T) {
+	max := uint32(3)
+	tests := []struct {
+		name     string
+		m        *Module
+		expected []api.TableDefinition
+	}{
+		{
+			name: "no tables",
+			m:    &Module{},
+		},
+		{
+			name: "imports then defines",
+			m: &Module{
+				ImportSection: []*Import{
+					{Type: ExternTypeFunc, Module: "env", Name: "f"},
+					{Type: ExternTypeTable, Module: "env", Name: "t", DescTable: &Table{Min: 1, Type: RefTypeFuncref}},
+				},
+				TableSection: []*Table{{Min: 2, Max: &max, Type: RefTypeExternref}},
+				ExportSection: []*Export{
+					{Type: ExternTypeTable, Name: "imported", Index: 0},
+					{Type: ExternTypeFunc, Name: "f", Index: 1},
+					{Type: ExternTypeTable, Name: "refs", Index: 1},
+				},
+				NameSection: &NameSection{ModuleName: "test", TableNames: NameMap{{Index: 1, Name: "refs"}}},
+			},
+			expected: []api.TableDefinition{
+				&TableDefinition{
+					moduleName:  "test",
+					index:       0,
+					importDesc:  &[2]string{"env", "t"},
+					exportNames: []string{"imported"},
+					table:       &Table{Min: 1, Type: RefTypeFuncref},
+				},
+				&TableDefinition{
+					moduleName:  "test",
+					index:       1,
+					name:        "refs",
+					exportNames: []string{"refs"},
+					table:       &Table{Min: 2, Max: &max, Type: RefTypeExternref},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.m.TableDefinitions())
+		})
+	}
+}
+
+func TestTableDefinition_Max(t *testing.T) {
+	max := uint32(3)
+
+	_, ok := (&TableDefinition{table: &Table{}}).Max()
+	require.False(t, ok)
+
+	actual, ok := (&TableDefinition{table: &Table{Max: &max}}).Max()
+	require.True(t, ok)
+	require.Equal(t, max, actual)
+}
@@ -26,7 +26,7 @@ func Test_resolveImports_table(t *testing.T) {
 				Table: tableInst,
 			}}, Name: moduleName},
 		}
-		_, _, tables, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: &Table{Max: &max}}}}, modules)
+		_, _, tables, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: &Table{Max: &max}}}}, modules, nil)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(tables))
 		require.Equal(t, tables[0], tableInst)
@@ -36,12 +36,34 @@ func Test_resolveImports_table(t *testing.T) {
 		modules := map[string]*ModuleInstance{
 			moduleName: {Exports: map[string]*ExportInstance{name: {
 				Type:  ExternTypeTable,
-				Table: &TableInstance{Min: importTableType.Min - 1},
+				Table: &TableInstance{Min: importTableType.Min - 1, References: make([]Reference, importTableType.Min-1)},
 			}}, Name: moduleName},
 		}
-		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules)
+		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules, nil)
 		require.EqualError(t, err, "import[0] table[test.target]: minimum size mismatch: 2 > 1")
 	})
+	t.Run("minimum size of grown table", func(t *testing.T) {
+		importTableType := &Table{Min: 2}
+		modules := map[string]*ModuleInstance{
+			moduleName: {Exports: map[string]*ExportInstance{name: {
+				Type:  ExternTypeTable,
+				Table: &TableInstance{Min: 1, References: make([]Reference, 2)}, // grown by one.
+			}}, Name: moduleName},
+		}
+		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules, nil)
+		require.NoError(t, err)
+	})
+	t.Run("type mismatch", func(t *testing.T) {
+		importTableType := &Table{Type: RefTypeExternref}
+		modules := map[string]*ModuleInstance{
+			moduleName: {Exports: map[string]*ExportInstance{name: {
+				Type:  ExternTypeTable,
+				Table: &TableInstance{Type: RefTypeFuncref},
+			}}, Name: moduleName},
+		}
+		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules, nil)
+		require.EqualError(t, err, "import[0] table[test.target]: table type mismatch: externref != funcref")
+	})
 	t.Run("maximum size mismatch", func(t *testing.T) {
 		max := uint32(10)
 		importTableType := &Table{Max: &max}
@@ -51,9 +73,21 @@ func Test_resolveImports_table(t *testing.T) {
 				Table: &TableInstance{Min: importTableType.Min - 1},
 			}}, Name: moduleName},
 		}
-		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules)
+		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules, nil)
 		require.EqualError(t, err, "import[0] table[test.target]: maximum size mismatch: 10, but actual has no max")
 	})
+	t.Run("maximum size larger than expected", func(t *testing.T) {
+		max, actualMax := uint32(5), uint32(10)
+		importTableType := &Table{Max: &max}
+		modules := map[string]*ModuleInstance{
+			moduleName: {Exports: map[string]*ExportInstance{name: {
+				Type:  ExternTypeTable,
+				Table: &TableInstance{Max: &actualMax},
+			}}, Name: moduleName},
+		}
+		_, _, _, _, err := resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: importTableType}}}, modules, nil)
+		require.EqualError(t, err, "import[0] table[test.target]: maximum size mismatch: 5 < 10")
+	})
 }
 
 var codeEnd = &Code{Body: []byte{OpcodeEnd}}
@@ -286,6 +320,36 @@ func TestModule_validateTable(t *testing.T) {
 				{opcode: OpcodeGlobalGet, arg: 1, init: []*Index{uint32Ptr(0), uint32Ptr(2)}},
 			},
 		},
+		{
+			name: "extended const derived element offset",
+			input: &Module{
+				TypeSection: []*FunctionType{{}},
+				ImportSection: []*Import{
+					{Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeI32}},
+				},
+				TableSection:    []*Table{{Min: 1, Type: RefTypeFuncref}},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{codeEnd},
+				ElementSection: []*ElementSegment{
+					{
+						OffsetExpr: &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{
+							OpcodeGlobalGet, 0, OpcodeI32Const, 2, OpcodeI32Add,
+						}},
+						Init: []*Index{uint32Ptr(0)},
+						Type: RefTypeFuncref,
+					},
+				},
+			},
+			expected: []*validatedActiveElementSegment{
+				{
+					opcode: OpcodeI32Add,
+					expr: &ConstantExpression{Opcode: OpcodeI32Add, Data: []byte{
+						OpcodeGlobalGet, 0, OpcodeI32Const, 2, OpcodeI32Add,
+					}},
+					init: []*Index{uint32Ptr(0)},
+				},
+			},
+		},
 		{
 			name: "mixed elementSegments - const before imported global",
 			input: &Module{
@@ -591,6 +655,28 @@ func TestModule_validateTable_Errors(t *testing.T) {
 			},
 			expectedErr: "element[0] (global.get 0): import[0].global.ValType != i32",
 		},
+		{
+			name: "extended const derived element offset - wrong ValType",
+			input: &Module{
+				TypeSection: []*FunctionType{{}},
+				ImportSection: []*Import{
+					{Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeI64}},
+				},
+				TableSection:    []*Table{{Min: 1, Type: RefTypeFuncref}},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{codeEnd},
+				ElementSection: []*ElementSegment{
+					{
+						OffsetExpr: &ConstantExpression{Opcode: OpcodeI64Add, Data: []byte{
+							OpcodeGlobalGet, 0, OpcodeI64Const, 2, OpcodeI64Add,
+						}},
+						Init: []*Index{uint32Ptr(0)},
+						Type: RefTypeFuncref,
+					},
+				},
+			},
+			expectedErr: "element[0] has an invalid const expression: const expression type mismatch expected i32 but got i64",
+		},
 		{
 			name: "imported global derived element offset - decode error",
 			input: &Module{
@@ -882,6 +968,34 @@ func TestModule_buildTables(t *testing.T) {
 				{TableIndex: 0, Offset: 1, FunctionIndexes: []*Index{uint32Ptr(0), uint32Ptr(2)}},
 			},
 		},
+		{
+			name: "extended const derived element offset",
+			module: &Module{
+				TypeSection: []*FunctionType{{}},
+				ImportSection: []*Import{
+					{Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: ValueTypeI32}},
+				},
+				TableSection:    []*Table{{Min: 3}},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{codeEnd},
+				validatedActiveElementSegments: []*validatedActiveElementSegment{
+					{
+						opcode: OpcodeI32Mul,
+						expr: &ConstantExpression{Opcode: OpcodeI32Mul, Data: []byte{
+							OpcodeGlobalGet, 0, OpcodeI32Const, 2, OpcodeI32Mul,
+						}},
+						init: []*Index{uint32Ptr(0)},
+					},
+				},
+			},
+			importedGlobals: []*GlobalInstance{
+				{Type: &GlobalType{ValType: ValueTypeI32}, Val: 1},
+			},
+			expectedTables: []*TableInstance{{References: make([]Reference, 3), Min: 3}},
+			expectedInit: []TableInitEntry{
+				{TableIndex: 0, Offset: 2, FunctionIndexes: []*Index{uint32Ptr(0)}},
+			},
+		},
 		{
 			name: "mixed elementSegments - const before imported global",
 			module: &Module{
@@ -1071,3 +1185,26 @@ func TestTableInstance_Grow(t *testing.T) {
 		})
 	}
 }
+
+func TestTableInstance_Entries(t *testing.T) {
+	table := &TableInstance{References: []Reference{0, 0x10, 0, 0x20}}
+	require.Equal(t, uint32(4), table.Size(testCtx))
+
+	entries, ok := table.Entries(testCtx)
+	require.True(t, ok)
+	require.Equal(t, []api.Reference{0, 0x10, 0, 0x20}, entries)
+
+	// The entries are a copy.
+	entries[0] = 0x30
+	require.Equal(t, Reference(0), table.References[0])
+	table.References[1] = 0
+	require.Equal(t, api.Reference(0x10), entries[1])
+
+	t.Run("empty", func(t *testing.T) {
+		empty := &TableInstance{}
+		require.Zero(t, empty.Size(testCtx))
+		entries, ok := empty.Entries(testCtx)
+		require.False(t, ok)
+		require.Nil(t, entries)
+	})
+}
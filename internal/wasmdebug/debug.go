@@ -40,12 +40,12 @@ func FuncName(moduleName, funcName string, funcIdx uint32) string {
 	return ret.String()
 }
 
-// signature returns a formatted signature similar to how it is defined in Go.
+// Signature returns a formatted signature similar to how it is defined in Go.
 //
 // * paramTypes should be from wasm.FunctionType
 // * resultTypes should be from wasm.FunctionType
 // TODO: add paramNames
-func signature(funcName string, paramTypes []api.ValueType, resultTypes []api.ValueType) string {
+func Signature(funcName string, paramTypes []api.ValueType, resultTypes []api.ValueType) string {
 	var ret strings.Builder
 	ret.WriteString(funcName)
 
@@ -99,6 +99,14 @@ type ErrorBuilder interface {
 	// Note: paramTypes and resultTypes are present because signature misunderstanding, mismatch or overflow are common.
 	AddFrame(funcName string, paramTypes, resultTypes []api.ValueType)
 
+	// AddLocals adds the locals of the last frame added, formatted as
+	// "name=value" on the line after it.
+	//
+	// * names are the possibly empty names of each local, beginning with params
+	// * types are the param types followed by the types of the other locals
+	// * values are the current values of the locals, two for each vector
+	AddLocals(names []string, types []api.ValueType, values []uint64)
+
 	// FromRecovered returns an error with the wasm stack trace appended to it.
 	FromRecovered(recovered interface{}) error
 }
@@ -146,5 +154,59 @@ func (s *stackTrace) FromRecovered(recovered interface{}) error {
 func (s *stackTrace) AddFrame(funcName string, paramTypes, resultTypes []api.ValueType) {
 	// Format as best as we can, considering we don't yet have source and line numbers,
 	// TODO: include DWARF symbols. See #58
-	s.frames = append(s.frames, signature(funcName, paramTypes, resultTypes))
+	s.frames = append(s.frames, Signature(funcName, paramTypes, resultTypes))
+}
+
+// AddLocals implements ErrorBuilder.AddLocals
+func (s *stackTrace) AddLocals(names []string, types []api.ValueType, values []uint64) {
+	if len(s.frames) == 0 || len(types) == 0 {
+		return
+	}
+	s.frames[len(s.frames)-1] += "\n\t\t" + Locals(names, types, values)
+}
+
+// valueTypeV128 is not in the api package, and this can't import wasm.
+const valueTypeV128 api.ValueType = 0x7b
+
+// Locals returns the locals formatted as "name=value", separated by commas.
+// Locals without a name are named by their index, e.g. "$1".
+//
+// See ErrorBuilder.AddLocals
+func Locals(names []string, types []api.ValueType, values []uint64) string {
+	var ret strings.Builder
+	for i, vt := range types {
+		if len(values) == 0 || (vt == valueTypeV128 && len(values) < 2) {
+			break // not yet on the stack
+		}
+		if i > 0 {
+			ret.WriteString(", ")
+		}
+		if i < len(names) && names[i] != "" {
+			ret.WriteString(names[i])
+		} else {
+			ret.WriteByte('$')
+			ret.WriteString(strconv.Itoa(i))
+		}
+		ret.WriteByte('=')
+
+		v := values[0]
+		values = values[1:]
+		switch vt {
+		case api.ValueTypeI32:
+			ret.WriteString(strconv.FormatInt(int64(int32(v)), 10))
+		case api.ValueTypeI64:
+			ret.WriteString(strconv.FormatInt(int64(v), 10))
+		case api.ValueTypeF32:
+			ret.WriteString(strconv.FormatFloat(float64(api.DecodeF32(v)), 'g', -1, 32))
+		case api.ValueTypeF64:
+			ret.WriteString(strconv.FormatFloat(api.DecodeF64(v), 'g', -1, 64))
+		case valueTypeV128:
+			hi := values[0]
+			values = values[1:]
+			ret.WriteString(fmt.Sprintf("0x%016x%016x", hi, v))
+		default: // references
+			ret.WriteString(fmt.Sprintf("0x%x", v))
+		}
+	}
+	return ret.String()
 }
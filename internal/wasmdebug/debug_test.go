@@ -58,12 +58,58 @@ func TestAddSignature(t *testing.T) {
 	for _, tt := range tests {
 		tc := tt
 		t.Run(tc.name, func(t *testing.T) {
-			withSignature := signature("x.y", tc.paramTypes, tc.resultTypes)
+			withSignature := Signature("x.y", tc.paramTypes, tc.resultTypes)
 			require.Equal(t, tc.expected, withSignature)
 		})
 	}
 }
 
+func TestLocals(t *testing.T) {
+	i32, i64, f32, f64 := api.ValueTypeI32, api.ValueTypeI64, api.ValueTypeF32, api.ValueTypeF64
+	tests := []struct {
+		name     string
+		names    []string
+		types    []api.ValueType
+		values   []uint64
+		expected string
+	}{
+		{name: "none"},
+		{
+			name:     "named",
+			names:    []string{"a", "b"},
+			types:    []api.ValueType{i32, i64},
+			values:   []uint64{api.EncodeI32(-1), api.EncodeI64(-2)},
+			expected: "a=-1, b=-2",
+		},
+		{
+			name:     "unnamed",
+			names:    []string{"a", ""},
+			types:    []api.ValueType{f32, f64},
+			values:   []uint64{api.EncodeF32(1.5), api.EncodeF64(-0.25)},
+			expected: "a=1.5, $1=-0.25",
+		},
+		{
+			name:     "vector and reference",
+			types:    []api.ValueType{valueTypeV128, api.ValueTypeExternref},
+			values:   []uint64{2, 1, 0xff},
+			expected: "$0=0x00000000000000010000000000000002, $1=0xff",
+		},
+		{
+			name:     "not yet on the stack",
+			types:    []api.ValueType{i32, i32},
+			values:   []uint64{1},
+			expected: "$0=1",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, Locals(tc.names, tc.types, tc.values))
+		})
+	}
+}
+
 func TestErrorBuilder(t *testing.T) {
 	argErr := errors.New("invalid argument")
 	rteErr := testRuntimeErr("index out of bounds")
@@ -126,6 +172,22 @@ wasm stack trace:
 	x.y()`,
 			expectUnwrap: wasmruntime.ErrRuntimeStackOverflow,
 		},
+		{
+			name: "locals",
+			build: func(builder ErrorBuilder) error {
+				builder.AddFrame("x.y", []api.ValueType{i32}, nil)
+				builder.AddLocals([]string{"a"}, []api.ValueType{i32}, []uint64{1})
+				builder.AddFrame("x.z", nil, nil)
+				builder.AddLocals(nil, nil, nil)
+				return builder.FromRecovered(argErr)
+			},
+			expectedErr: `invalid argument (recovered by wazero)
+wasm stack trace:
+	x.y(i32)
+		a=1
+	x.z()`,
+			expectUnwrap: argErr,
+		},
 	}
 
 	for _, tt := range tests {
@@ -22,11 +22,19 @@ var (
 	// ErrRuntimeOutOfBoundsMemoryAccess indicates that the program tried to access the
 	// region beyond the linear memory.
 	ErrRuntimeOutOfBoundsMemoryAccess = New("out of bounds memory access")
-	// ErrRuntimeInvalidTableAccess means either offset to the table was out of bounds of table, or
-	// the target element in the table was uninitialized during call_indirect instruction.
+	// ErrRuntimeInvalidTableAccess means the offset to the table was out of bounds of table.
 	ErrRuntimeInvalidTableAccess = New("invalid table access")
+	// ErrRuntimeUninitializedElement means the target element in the table was null during
+	// call_indirect instruction, though its offset was in bounds.
+	ErrRuntimeUninitializedElement = New("uninitialized element")
 	// ErrRuntimeIndirectCallTypeMismatch indicates that the type check failed during call_indirect.
 	ErrRuntimeIndirectCallTypeMismatch = New("indirect call type mismatch")
+	// ErrRuntimeInstructionBudgetExhausted indicates that the module executed
+	// as many instructions as allowed by its instruction budget.
+	ErrRuntimeInstructionBudgetExhausted = New("instruction budget exhausted")
+	// ErrRuntimeUncaughtException means "throw" instruction was executed by the
+	// program, and no "try" in the same function caught the exception.
+	ErrRuntimeUncaughtException = New("uncaught exception")
 )
 
 // Error is returned by a wasm.Engine during the execution of Wasm functions, and they indicate that the Wasm runtime
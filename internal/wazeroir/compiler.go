@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -23,6 +24,7 @@ const (
 	controlFrameKindLoop
 	controlFrameKindIfWithElse
 	controlFrameKindIfWithoutElse
+	controlFrameKindTry
 )
 
 type (
@@ -33,8 +35,24 @@ type (
 		originalStackLenWithoutParam int
 		blockType                    *wasm.FunctionType
 		kind                         controlFrameKind
+		// catching is true when the frame is controlFrameKindTry and its
+		// catch clauses began, so exceptions are no longer thrown to it.
+		catching bool
+		// thrown are the exceptions thrown to this frame by tag, in the order
+		// first thrown, when it is controlFrameKindTry.
+		thrown []*thrownTag
 	}
 	controlFrames struct{ frames []*controlFrame }
+
+	// thrownTag is an exception thrown in the body of a try.
+	thrownTag struct {
+		tag wasm.Index
+		// label is where the exception is thrown to with the values of the
+		// tag on top of the stack of the try.
+		label *Label
+		// caught is true when a catch clause began at label.
+		caught bool
+	}
 )
 
 func (c *controlFrame) ensureContinuation() {
@@ -57,7 +75,8 @@ func (c *controlFrame) asBranchTarget() *BranchTarget {
 		// Note nil target is translated as return.
 		return &BranchTarget{Label: nil}
 	case controlFrameKindIfWithElse,
-		controlFrameKindIfWithoutElse:
+		controlFrameKindIfWithoutElse,
+		controlFrameKindTry:
 		return &BranchTarget{Label: &Label{FrameID: c.frameID, Kind: LabelKindContinuation}}
 	}
 	panic(fmt.Sprintf("unreachable: a bug in wazeroir implementation: %v", c.kind))
@@ -182,6 +201,10 @@ type compiler struct {
 	funcs []uint32
 	// globals holds the global types for all declard globas in the module where the targe function exists.
 	globals []*wasm.GlobalType
+	// memory64 is true when the memory in the module where the target function exists is 64-bit (memory64).
+	memory64 bool
+	// tags holds the type indexes for all tags in the module where the target function exists.
+	tags []wasm.Index
 }
 
 //lint:ignore U1000 for debugging only.
@@ -213,6 +236,12 @@ type CompilationResult struct {
 	// Operations holds wazeroir operations compiled from Wasm instructions in a Wasm function.
 	Operations []Operation
 
+	// SourceOffsets holds the offset in the function body of the Wasm
+	// instruction each of Operations was lowered from, or NoSourceOffset for
+	// operations which initialize locals before the first instruction. This
+	// is nil unless compiled with SourceOffsetsKey.
+	SourceOffsets []uint64
+
 	// LabelCallers maps Label.String() to the number of callers to that label.
 	// Here "callers" means that the call-sites which jumps to the label with br, br_if or br_table
 	// instructions.
@@ -247,9 +276,30 @@ type CompilationResult struct {
 	HasDataInstances bool
 	// HasDataInstances is true if the module has element instances which might be used by table.init or elem.drop instructions.
 	HasElementInstances bool
+	// WrappingDivision is true when signed integer division of the minimum value by -1 should wrap to the minimum
+	// value instead of trapping. This is set by the engine, not during compilation.
+	//
+	// See WrappingDivisionKey
+	WrappingDivision bool
 }
 
-func CompileFunctions(_ context.Context, enabledFeatures api.CoreFeatures, callFrameStackSizeInUint64 int, module *wasm.Module) ([]*CompilationResult, error) {
+// NoSourceOffset is the value of CompilationResult.SourceOffsets for
+// operations not lowered from a Wasm instruction.
+const NoSourceOffset = math.MaxUint64
+
+// SourceOffsetsKey is a context.Context key which, when set to true, makes
+// CompileFunctions record CompilationResult.SourceOffsets.
+type SourceOffsetsKey struct{}
+
+// WrappingDivisionKey is a context.Context key which, when set to true,
+// makes signed integer division of the minimum value by -1 wrap instead of
+// trapping with wasmruntime.ErrRuntimeIntegerOverflow.
+//
+// Note: This deviates from the WebAssembly specification.
+// See wazero.RuntimeConfig WithWrappingDivision
+type WrappingDivisionKey struct{}
+
+func CompileFunctions(ctx context.Context, enabledFeatures api.CoreFeatures, callFrameStackSizeInUint64 int, module *wasm.Module) ([]*CompilationResult, error) {
 	functions, globals, mem, tables, err := module.AllDeclarations()
 	if err != nil {
 		return nil, err
@@ -263,6 +313,8 @@ func CompileFunctions(_ context.Context, enabledFeatures api.CoreFeatures, callF
 		tableTypes[i] = tables[i].Type
 	}
 
+	sourceOffsets, _ := ctx.Value(SourceOffsetsKey{}).(bool)
+
 	var ret []*CompilationResult
 	for funcIndex := range module.FunctionSection {
 		typeID := module.FunctionSection[funcIndex]
@@ -286,7 +338,7 @@ func CompileFunctions(_ context.Context, enabledFeatures api.CoreFeatures, callF
 			}
 			continue
 		}
-		r, err := compile(enabledFeatures, callFrameStackSizeInUint64, sig, code.Body, code.LocalTypes, module.TypeSection, functions, globals)
+		r, err := compile(enabledFeatures, callFrameStackSizeInUint64, sig, code.Body, code.LocalTypes, module.TypeSection, functions, globals, module.TagSection, hasMemory && mem.Is64, sourceOffsets)
 		if err != nil {
 			def := module.FunctionDefinitionSection[uint32(funcIndex)+module.ImportFuncCount()]
 			return nil, fmt.Errorf("failed to lower func[%s] to wazeroir: %w", def.DebugName(), err)
@@ -316,6 +368,9 @@ func compile(enabledFeatures api.CoreFeatures,
 	localTypes []wasm.ValueType,
 	types []*wasm.FunctionType,
 	functions []uint32, globals []*wasm.GlobalType,
+	tags []wasm.Index,
+	memory64 bool,
+	sourceOffsets bool,
 ) (*CompilationResult, error) {
 	c := compiler{
 		enabledFeatures:            enabledFeatures,
@@ -328,6 +383,8 @@ func compile(enabledFeatures api.CoreFeatures,
 		globals:                    globals,
 		funcs:                      functions,
 		types:                      types,
+		memory64:                   memory64,
+		tags:                       tags,
 	}
 
 	c.initializeStack()
@@ -339,6 +396,11 @@ func compile(enabledFeatures api.CoreFeatures,
 	for _, t := range localTypes {
 		c.emitDefaultValue(t)
 	}
+	if sourceOffsets {
+		for range c.result.Operations {
+			c.result.SourceOffsets = append(c.result.SourceOffsets, NoSourceOffset)
+		}
+	}
 
 	// Insert the function control frame.
 	c.controlFrames.push(&controlFrame{
@@ -349,9 +411,13 @@ func compile(enabledFeatures api.CoreFeatures,
 
 	// Now, enter the function body.
 	for !c.controlFrames.empty() && c.pc < uint64(len(c.body)) {
+		pc := c.pc
 		if err := c.handleInstruction(); err != nil {
 			return nil, fmt.Errorf("handling instruction: %w", err)
 		}
+		for sourceOffsets && len(c.result.SourceOffsets) < len(c.result.Operations) {
+			c.result.SourceOffsets = append(c.result.SourceOffsets, pc)
+		}
 	}
 	return &c.result, nil
 }
@@ -456,6 +522,85 @@ operatorSwitch:
 			&OperationLabel{Label: loopLabel},
 		)
 
+	case wasm.OpcodeTry:
+		bt, num, err := wasm.DecodeBlockType(c.types, bytes.NewReader(c.body[c.pc+1:]), c.enabledFeatures)
+		if err != nil {
+			return fmt.Errorf("reading block type for try instruction: %w", err)
+		}
+		c.pc += num
+
+		if c.unreachableState.on {
+			// If it is currently in unreachable,
+			// just remove the entire block.
+			c.unreachableState.depth++
+			break operatorSwitch
+		}
+
+		// Create a new frame -- entering try. Labels for exceptions thrown
+		// to this frame are created when they are first thrown.
+		frame := &controlFrame{
+			frameID:                      c.nextID(),
+			originalStackLenWithoutParam: len(c.stack) - len(bt.Params),
+			kind:                         controlFrameKindTry,
+			blockType:                    bt,
+		}
+		c.controlFrames.push(frame)
+
+	case wasm.OpcodeCatch, wasm.OpcodeCatchAll:
+		var tag uint32
+		if op == wasm.OpcodeCatch {
+			v, n, err := leb128.LoadUint32(c.body[c.pc+1:])
+			if err != nil {
+				return fmt.Errorf("read the tag for catch: %w", err)
+			}
+			c.pc += n
+			tag = v
+		}
+
+		if c.unreachableState.on && c.unreachableState.depth > 0 {
+			// If it is currently in unreachable, and the nested try,
+			// just remove the entire catch clause.
+			break operatorSwitch
+		}
+
+		frame := c.controlFrames.top()
+		c.endTryClause(frame)
+		if op == wasm.OpcodeCatch {
+			c.beginCatch(frame, tag)
+		} else {
+			c.beginCatchAll(frame)
+		}
+
+	case wasm.OpcodeThrow:
+		tag, n, err := leb128.LoadUint32(c.body[c.pc+1:])
+		if err != nil {
+			return fmt.Errorf("read the tag for throw: %w", err)
+		}
+		c.pc += n
+
+		if c.unreachableState.on {
+			// If it is currently in unreachable, throw is no-op.
+			break operatorSwitch
+		}
+
+		// The values of the tag are on top of the stack, so throw them.
+		c.emitThrow(tag)
+		// Throw is stack-polymorphic, similar to br.
+		c.markUnreachable()
+
+	case wasm.OpcodeRethrow:
+		_, n, err := leb128.LoadUint32(c.body[c.pc+1:])
+		if err != nil {
+			return fmt.Errorf("read the label for rethrow: %w", err)
+		}
+		c.pc += n
+
+		if c.unreachableState.on {
+			// If it is currently in unreachable, rethrow is no-op.
+			break operatorSwitch
+		}
+		return errors.New("rethrow is not supported yet")
+
 	case wasm.OpcodeIf:
 		bt, num, err := wasm.DecodeBlockType(c.types, bytes.NewReader(c.body[c.pc+1:]), c.enabledFeatures)
 		if err != nil {
@@ -583,6 +728,9 @@ operatorSwitch:
 					&OperationLabel{Label: continuationLabel},
 				)
 			} else {
+				if frame.kind == controlFrameKindTry {
+					c.emitUncaught(frame)
+				}
 				c.emit(
 					&OperationLabel{Label: continuationLabel},
 				)
@@ -639,6 +787,17 @@ operatorSwitch:
 				&OperationBr{Target: continuationLabel.asBranchTarget()},
 				&OperationLabel{Label: continuationLabel},
 			)
+		case controlFrameKindTry:
+			continuationLabel := &Label{Kind: LabelKindContinuation, FrameID: frame.frameID}
+			c.result.LabelCallers[continuationLabel.String()]++
+			c.emit(
+				dropOp,
+				&OperationBr{Target: continuationLabel.asBranchTarget()},
+			)
+			c.emitUncaught(frame)
+			c.emit(
+				&OperationLabel{Label: continuationLabel},
+			)
 		case controlFrameKindLoop, controlFrameKindBlockWithoutContinuationLabel:
 			c.emit(
 				dropOp,
@@ -1824,6 +1983,13 @@ operatorSwitch:
 		}
 	case wasm.OpcodeVecPrefix:
 		c.pc++
+		if relaxedOp, ok := wasm.RelaxedVecOpcode(c.body, c.pc); ok {
+			c.pc++ // the relaxed opcode is two bytes.
+			if err := c.compileRelaxedVec(relaxedOp); err != nil {
+				return err
+			}
+			break
+		}
 		switch vecOp := c.body[c.pc]; vecOp {
 		case wasm.OpcodeVecV128Const:
 			c.pc++
@@ -2920,6 +3086,86 @@ operatorSwitch:
 	return nil
 }
 
+// compileRelaxedVec emits the operations of the relaxed vector instruction.
+// Where the instruction has a non-relaxed counterpart, this emits its
+// operation, as that is one of the results the relaxed-simd proposal allows.
+func (c *compiler) compileRelaxedVec(op wasm.OpcodeVecRelaxed) error {
+	switch op {
+	case wasm.OpcodeVecI8x16RelaxedSwizzle:
+		c.emit(
+			&OperationV128Swizzle{},
+		)
+	case wasm.OpcodeVecI32x4RelaxedTruncF32x4S:
+		c.emit(
+			&OperationV128ITruncSatFromF{OriginShape: ShapeF32x4, Signed: true},
+		)
+	case wasm.OpcodeVecI32x4RelaxedTruncF32x4U:
+		c.emit(
+			&OperationV128ITruncSatFromF{OriginShape: ShapeF32x4, Signed: false},
+		)
+	case wasm.OpcodeVecI32x4RelaxedTruncF64x2SZero:
+		c.emit(
+			&OperationV128ITruncSatFromF{OriginShape: ShapeF64x2, Signed: true},
+		)
+	case wasm.OpcodeVecI32x4RelaxedTruncF64x2UZero:
+		c.emit(
+			&OperationV128ITruncSatFromF{OriginShape: ShapeF64x2, Signed: false},
+		)
+	case wasm.OpcodeVecF32x4RelaxedMadd:
+		c.emit(
+			&OperationV128RelaxedMadd{Shape: ShapeF32x4},
+		)
+	case wasm.OpcodeVecF32x4RelaxedNmadd:
+		c.emit(
+			&OperationV128RelaxedMadd{Shape: ShapeF32x4, Negate: true},
+		)
+	case wasm.OpcodeVecF64x2RelaxedMadd:
+		c.emit(
+			&OperationV128RelaxedMadd{Shape: ShapeF64x2},
+		)
+	case wasm.OpcodeVecF64x2RelaxedNmadd:
+		c.emit(
+			&OperationV128RelaxedMadd{Shape: ShapeF64x2, Negate: true},
+		)
+	case wasm.OpcodeVecI8x16RelaxedLaneselect, wasm.OpcodeVecI16x8RelaxedLaneselect,
+		wasm.OpcodeVecI32x4RelaxedLaneselect, wasm.OpcodeVecI64x2RelaxedLaneselect:
+		c.emit(
+			&OperationV128Bitselect{},
+		)
+	case wasm.OpcodeVecF32x4RelaxedMin:
+		c.emit(
+			&OperationV128Min{Shape: ShapeF32x4},
+		)
+	case wasm.OpcodeVecF32x4RelaxedMax:
+		c.emit(
+			&OperationV128Max{Shape: ShapeF32x4},
+		)
+	case wasm.OpcodeVecF64x2RelaxedMin:
+		c.emit(
+			&OperationV128Min{Shape: ShapeF64x2},
+		)
+	case wasm.OpcodeVecF64x2RelaxedMax:
+		c.emit(
+			&OperationV128Max{Shape: ShapeF64x2},
+		)
+	case wasm.OpcodeVecI16x8RelaxedQ15mulrS:
+		c.emit(
+			&OperationV128Q15mulrSatS{},
+		)
+	case wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16S:
+		c.emit(
+			&OperationV128RelaxedDot{},
+		)
+	case wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddS:
+		c.emit(
+			&OperationV128RelaxedDotAdd{},
+		)
+	default:
+		return fmt.Errorf("unsupported vector instruction in wazeroir: %s", wasm.RelaxedVectorInstructionName(op))
+	}
+	return nil
+}
+
 func (c *compiler) nextID() (id uint32) {
 	id = c.currentID + 1
 	c.currentID++
@@ -2964,6 +3210,9 @@ func (c *compiler) applyToStack(opcode wasm.Opcode) (*uint32, error) {
 	if err != nil {
 		return nil, err
 	}
+	if c.memory64 {
+		s = c.memory64Signature(opcode, s)
+	}
 
 	// Manipulate the stack according to the signature.
 	// Note that the following algorithm assumes that
@@ -3080,6 +3329,144 @@ func (c *compiler) localType(index wasm.Index) (t wasm.ValueType) {
 	return
 }
 
+// endTryClause ends the body or the catch clause of the try frame, before
+// beginning another catch clause.
+func (c *compiler) endTryClause(frame *controlFrame) {
+	if c.unreachableState.on {
+		c.resetUnreachable()
+	} else {
+		continuationLabel := &Label{FrameID: frame.frameID, Kind: LabelKindContinuation}
+		c.result.LabelCallers[continuationLabel.String()]++
+		c.emit(
+			&OperationDrop{Depth: c.getFrameDropRange(frame, true)},
+			&OperationBr{Target: continuationLabel.asBranchTarget()},
+		)
+	}
+	// Exceptions thrown in catch clauses aren't caught by this frame.
+	frame.catching = true
+	c.stack = c.stack[:frame.originalStackLenWithoutParam]
+}
+
+// beginCatch begins the catch clause of the try frame for the given tag. If
+// no exception of the tag was thrown to the frame, the clause is unreachable.
+func (c *compiler) beginCatch(frame *controlFrame, tag wasm.Index) {
+	for _, t := range c.types[c.tags[tag]].Params {
+		c.stackPush(wasmValueTypeToUnsignedType(t)...)
+	}
+
+	for _, thrown := range frame.thrown {
+		if thrown.tag == tag && !thrown.caught {
+			thrown.caught = true
+			c.emit(
+				&OperationLabel{Label: thrown.label},
+			)
+			return
+		}
+	}
+	c.markUnreachable()
+}
+
+// beginCatchAll begins the catch_all clause of the try frame, which drops the
+// values of all exceptions thrown to the frame and not yet caught. If there
+// are none, the clause is unreachable.
+func (c *compiler) beginCatchAll(frame *controlFrame) {
+	catchAllLabel := &Label{FrameID: c.nextID(), Kind: LabelKindHeader}
+	for _, thrown := range frame.thrown {
+		if thrown.caught {
+			continue
+		}
+		thrown.caught = true
+
+		var drop *InclusiveRange
+		if n := c.types[c.tags[thrown.tag]].ParamNumInUint64; n > 0 {
+			drop = &InclusiveRange{Start: 0, End: n - 1}
+		}
+		c.result.LabelCallers[catchAllLabel.String()]++
+		c.emit(
+			&OperationLabel{Label: thrown.label},
+			&OperationDrop{Depth: drop},
+			&OperationBr{Target: catchAllLabel.asBranchTarget()},
+		)
+	}
+
+	if c.result.LabelCallers[catchAllLabel.String()] == 0 {
+		c.markUnreachable()
+		return
+	}
+	c.emit(
+		&OperationLabel{Label: catchAllLabel},
+	)
+}
+
+// emitThrow emits the operations to throw an exception of the given tag,
+// whose values are on top of the stack. This branches to the innermost try
+// frame whose body is being compiled, or traps if there's none.
+//
+// Note: Exceptions aren't propagated to callers, yet.
+func (c *compiler) emitThrow(tag wasm.Index) {
+	for i := range c.controlFrames.frames {
+		frame := c.controlFrames.get(i)
+		if frame.kind != controlFrameKindTry || frame.catching {
+			continue
+		}
+
+		var thrown *thrownTag
+		for _, t := range frame.thrown {
+			if t.tag == tag {
+				thrown = t
+				break
+			}
+		}
+		if thrown == nil {
+			thrown = &thrownTag{tag: tag, label: &Label{FrameID: c.nextID(), Kind: LabelKindHeader}}
+			frame.thrown = append(frame.thrown, thrown)
+		}
+
+		// Drop the values between the tag's and the ones before the try.
+		var drop *InclusiveRange
+		start := c.types[c.tags[tag]].ParamNumInUint64
+		end := c.stackLenInUint64(len(c.stack)) - 1 - c.stackLenInUint64(frame.originalStackLenWithoutParam)
+		if start <= end {
+			drop = &InclusiveRange{Start: start, End: end}
+		}
+		c.result.LabelCallers[thrown.label.String()]++
+		c.emit(
+			&OperationDrop{Depth: drop},
+			&OperationBr{Target: thrown.label.asBranchTarget()},
+		)
+		return
+	}
+	c.emit(
+		&OperationThrow{TagIndex: tag},
+	)
+}
+
+// emitUncaught emits the operations to throw exceptions thrown to the try
+// frame, which its catch clauses didn't catch, to the frames enclosing it.
+// This must be called after the frame is popped, when the stack holds its
+// results.
+func (c *compiler) emitUncaught(frame *controlFrame) {
+	base := frame.originalStackLenWithoutParam
+	for _, thrown := range frame.thrown {
+		if thrown.caught {
+			continue
+		}
+		c.stack = c.stack[:base]
+		for _, t := range c.types[c.tags[thrown.tag]].Params {
+			c.stackPush(wasmValueTypeToUnsignedType(t)...)
+		}
+		c.emit(
+			&OperationLabel{Label: thrown.label},
+		)
+		c.emitThrow(thrown.tag)
+	}
+
+	c.stack = c.stack[:base]
+	for _, t := range frame.blockType.Results {
+		c.stackPush(wasmValueTypeToUnsignedType(t)...)
+	}
+}
+
 // getFrameDropRange returns the range (starting from top of the stack) that spans across the (uint64) stack. The range is
 // supposed to be dropped from the stack when the given frame exists or branch into it.
 //
@@ -3127,7 +3514,14 @@ func (c *compiler) readMemoryArg(tag string) (*MemoryArg, error) {
 		return nil, fmt.Errorf("reading alignment for %s: %w", tag, err)
 	}
 	c.pc += num
-	offset, num, err := leb128.LoadUint32(c.body[c.pc+1:])
+	var offset uint64
+	if c.memory64 {
+		offset, num, err = leb128.LoadUint64(c.body[c.pc+1:])
+	} else {
+		var offset32 uint32
+		offset32, num, err = leb128.LoadUint32(c.body[c.pc+1:])
+		offset = uint64(offset32)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("reading offset for %s: %w", tag, err)
 	}
@@ -790,6 +790,37 @@ func TestCompile_CallIndirectNonZeroTableIndex(t *testing.T) {
 	require.Equal(t, expected, res[0])
 }
 
+func TestCompile_SourceOffsets(t *testing.T) {
+	module := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{v_v},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{
+			LocalTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			Body: []byte{
+				wasm.OpcodeI32Const, 1, // offset 0
+				wasm.OpcodeLocalSet, 0, // offset 2
+				wasm.OpcodeEnd, // offset 4
+			},
+		}},
+	}
+
+	res, err := CompileFunctions(context.WithValue(ctx, SourceOffsetsKey{}, true), api.CoreFeaturesV2, 0, module)
+	require.NoError(t, err)
+	require.Equal(t, []Operation{
+		&OperationConstI32{Value: 0}, // local initialization
+		&OperationConstI32{Value: 1},
+		&OperationSet{Depth: 1},
+		&OperationDrop{Depth: &InclusiveRange{Start: 0, End: 0}},
+		&OperationBr{Target: &BranchTarget{}}, // return!
+	}, res[0].Operations)
+	require.Equal(t, []uint64{NoSourceOffset, 0, 2, 4, 4}, res[0].SourceOffsets)
+
+	// Offsets aren't recorded by default.
+	res, err = CompileFunctions(ctx, api.CoreFeaturesV2, 0, module)
+	require.NoError(t, err)
+	require.Nil(t, res[0].SourceOffsets)
+}
+
 func TestCompile_Refs(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -2816,6 +2847,88 @@ func TestCompile_Vec(t *testing.T) {
 	}
 }
 
+func TestCompile_RelaxedVec(t *testing.T) {
+	relaxed := func(op wasm.OpcodeVecRelaxed, operands int) (ret []byte) {
+		for i := 0; i < operands; i++ {
+			ret = append(ret, wasm.OpcodeVecPrefix,
+				wasm.OpcodeVecV128Const,
+				1, 1, 1, 1, 1, 1, 1, 1,
+				1, 1, 1, 1, 1, 1, 1, 1)
+		}
+		ret = append(ret, wasm.OpcodeVecPrefix)
+		ret = append(ret, leb128.EncodeUint32(op)...)
+		return append(ret, wasm.OpcodeDrop, wasm.OpcodeEnd)
+	}
+
+	tests := []struct {
+		name     string
+		body     []byte
+		expected Operation
+	}{
+		{
+			name:     wasm.OpcodeVecI8x16RelaxedSwizzleName,
+			body:     relaxed(wasm.OpcodeVecI8x16RelaxedSwizzle, 2),
+			expected: &OperationV128Swizzle{},
+		},
+		{
+			name:     wasm.OpcodeVecI32x4RelaxedTruncF64x2UZeroName,
+			body:     relaxed(wasm.OpcodeVecI32x4RelaxedTruncF64x2UZero, 1),
+			expected: &OperationV128ITruncSatFromF{OriginShape: ShapeF64x2, Signed: false},
+		},
+		{
+			name:     wasm.OpcodeVecF32x4RelaxedMaddName,
+			body:     relaxed(wasm.OpcodeVecF32x4RelaxedMadd, 3),
+			expected: &OperationV128RelaxedMadd{Shape: ShapeF32x4},
+		},
+		{
+			name:     wasm.OpcodeVecF64x2RelaxedNmaddName,
+			body:     relaxed(wasm.OpcodeVecF64x2RelaxedNmadd, 3),
+			expected: &OperationV128RelaxedMadd{Shape: ShapeF64x2, Negate: true},
+		},
+		{
+			name:     wasm.OpcodeVecI64x2RelaxedLaneselectName,
+			body:     relaxed(wasm.OpcodeVecI64x2RelaxedLaneselect, 3),
+			expected: &OperationV128Bitselect{},
+		},
+		{
+			name:     wasm.OpcodeVecF32x4RelaxedMinName,
+			body:     relaxed(wasm.OpcodeVecF32x4RelaxedMin, 2),
+			expected: &OperationV128Min{Shape: ShapeF32x4},
+		},
+		{
+			name:     wasm.OpcodeVecI16x8RelaxedQ15mulrSName,
+			body:     relaxed(wasm.OpcodeVecI16x8RelaxedQ15mulrS, 2),
+			expected: &OperationV128Q15mulrSatS{},
+		},
+		{
+			name:     wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16SName,
+			body:     relaxed(wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16S, 2),
+			expected: &OperationV128RelaxedDot{},
+		},
+		{
+			name:     wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddSName,
+			body:     relaxed(wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddS, 3),
+			expected: &OperationV128RelaxedDotAdd{},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			module := &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: tc.body}},
+			}
+			res, err := CompileFunctions(ctx, api.CoreFeaturesV2|api.CoreFeatureRelaxedSIMD, 0, module)
+			require.NoError(t, err)
+
+			// The operations look like: [... target, drop, br(to return)].
+			require.Equal(t, tc.expected, res[0].Operations[len(res[0].Operations)-3])
+		})
+	}
+}
+
 // TestCompile_unreachable_Br_BrIf_BrTable ensures that unreachable br/br_if/br_table instructions are correctly ignored.
 func TestCompile_unreachable_Br_BrIf_BrTable(t *testing.T) {
 	tests := []struct {
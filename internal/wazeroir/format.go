@@ -26,6 +26,8 @@ func formatOperation(w io.StringWriter, b Operation) {
 	switch o := b.(type) {
 	case *OperationUnreachable:
 		str = "unreachable"
+	case *OperationThrow:
+		str = fmt.Sprintf("throw %d", o.TagIndex)
 	case *OperationLabel:
 		isLabel = true
 		str = fmt.Sprintf("%s:", o.Label.asBranchTarget())
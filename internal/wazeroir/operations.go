@@ -416,6 +416,14 @@ func (o OperationKind) String() (ret string) {
 		ret = "V128Narrow"
 	case OperationKindV128ITruncSatFromF:
 		ret = "V128ITruncSatFromF"
+	case OperationKindV128RelaxedMadd:
+		ret = "V128RelaxedMadd"
+	case OperationKindV128RelaxedDot:
+		ret = "V128RelaxedDot"
+	case OperationKindV128RelaxedDotAdd:
+		ret = "V128RelaxedDotAdd"
+	case OperationKindThrow:
+		ret = "Throw"
 	default:
 		panic(fmt.Errorf("unknown operation %d", o))
 	}
@@ -702,6 +710,14 @@ const (
 	OperationKindV128Narrow
 	// OperationKindV128ITruncSatFromF is the kind for OperationV128ITruncSatFromF.
 	OperationKindV128ITruncSatFromF
+	// OperationKindV128RelaxedMadd is the kind for OperationV128RelaxedMadd.
+	OperationKindV128RelaxedMadd
+	// OperationKindV128RelaxedDot is the kind for OperationV128RelaxedDot.
+	OperationKindV128RelaxedDot
+	// OperationKindV128RelaxedDotAdd is the kind for OperationV128RelaxedDotAdd.
+	OperationKindV128RelaxedDotAdd
+	// OperationKindThrow is the kind for OperationThrow.
+	OperationKindThrow
 
 	// operationKindEnd is always placed at the bottom of this iota definition to be used in the test.
 	operationKindEnd
@@ -1011,7 +1027,9 @@ type MemoryArg struct {
 
 	// Offset is the address offset added to the instruction's dynamic address operand, yielding a 33-bit effective
 	// address that is the zero-based index at which the memory is accessed. Default to zero.
-	Offset uint32
+	//
+	// This only exceeds 32-bits in a 64-bit memory (memory64).
+	Offset uint64
 }
 
 // OperationLoad implements Operation.
@@ -2832,3 +2850,55 @@ type OperationV128ITruncSatFromF struct {
 func (OperationV128ITruncSatFromF) Kind() OperationKind {
 	return OperationKindV128ITruncSatFromF
 }
+
+// OperationV128RelaxedMadd implements Operation.
+//
+// This corresponds to
+//
+//	wasm.OpcodeVecF32x4RelaxedMaddName wasm.OpcodeVecF32x4RelaxedNmaddName
+//	wasm.OpcodeVecF64x2RelaxedMaddName wasm.OpcodeVecF64x2RelaxedNmaddName.
+type OperationV128RelaxedMadd struct {
+	// Shape is either ShapeF32x4 or ShapeF64x2.
+	Shape Shape
+	// Negate is true for nmadd, which negates the product before adding.
+	Negate bool
+}
+
+// Kind implements Operation.Kind.
+func (OperationV128RelaxedMadd) Kind() OperationKind {
+	return OperationKindV128RelaxedMadd
+}
+
+// OperationV128RelaxedDot implements Operation.
+//
+// This corresponds to wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16SName
+type OperationV128RelaxedDot struct{}
+
+// Kind implements Operation.Kind.
+func (OperationV128RelaxedDot) Kind() OperationKind {
+	return OperationKindV128RelaxedDot
+}
+
+// OperationV128RelaxedDotAdd implements Operation.
+//
+// This corresponds to wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddSName
+type OperationV128RelaxedDotAdd struct{}
+
+// Kind implements Operation.Kind.
+func (OperationV128RelaxedDotAdd) Kind() OperationKind {
+	return OperationKindV128RelaxedDotAdd
+}
+
+// OperationThrow implements Operation.
+//
+// This corresponds to wasm.OpcodeThrow when there's no try in the current
+// function to catch the exception, so it is uncaught.
+type OperationThrow struct {
+	// TagIndex is the index of the tag of the exception.
+	TagIndex uint32
+}
+
+// Kind implements Operation.Kind.
+func (*OperationThrow) Kind() OperationKind {
+	return OperationKindThrow
+}
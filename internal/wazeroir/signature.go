@@ -232,6 +232,9 @@ func (c *compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 	switch op {
 	case wasm.OpcodeUnreachable, wasm.OpcodeNop, wasm.OpcodeBlock, wasm.OpcodeLoop:
 		return signature_None_None, nil
+	case wasm.OpcodeTry, wasm.OpcodeCatch, wasm.OpcodeCatchAll, wasm.OpcodeThrow, wasm.OpcodeRethrow:
+		// Catch and throw handle the values of the tag on the stack themselves.
+		return signature_None_None, nil
 	case wasm.OpcodeIf:
 		return signature_I32_None, nil
 	case wasm.OpcodeElse, wasm.OpcodeEnd, wasm.OpcodeBr:
@@ -471,6 +474,26 @@ func (c *compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 			return nil, fmt.Errorf("unsupported misc instruction in wazeroir: 0x%x", op)
 		}
 	case wasm.OpcodeVecPrefix:
+		if relaxedOp, ok := wasm.RelaxedVecOpcode(c.body, c.pc+1); ok {
+			switch relaxedOp {
+			case wasm.OpcodeVecI32x4RelaxedTruncF32x4S, wasm.OpcodeVecI32x4RelaxedTruncF32x4U,
+				wasm.OpcodeVecI32x4RelaxedTruncF64x2SZero, wasm.OpcodeVecI32x4RelaxedTruncF64x2UZero:
+				return signature_V128_V128, nil
+			case wasm.OpcodeVecF32x4RelaxedMadd, wasm.OpcodeVecF32x4RelaxedNmadd,
+				wasm.OpcodeVecF64x2RelaxedMadd, wasm.OpcodeVecF64x2RelaxedNmadd,
+				wasm.OpcodeVecI8x16RelaxedLaneselect, wasm.OpcodeVecI16x8RelaxedLaneselect,
+				wasm.OpcodeVecI32x4RelaxedLaneselect, wasm.OpcodeVecI64x2RelaxedLaneselect,
+				wasm.OpcodeVecI32x4RelaxedDotI8x16I7x16AddS:
+				return signature_V128V128V128_V32, nil
+			case wasm.OpcodeVecI8x16RelaxedSwizzle,
+				wasm.OpcodeVecF32x4RelaxedMin, wasm.OpcodeVecF32x4RelaxedMax,
+				wasm.OpcodeVecF64x2RelaxedMin, wasm.OpcodeVecF64x2RelaxedMax,
+				wasm.OpcodeVecI16x8RelaxedQ15mulrS, wasm.OpcodeVecI16x8RelaxedDotI8x16I7x16S:
+				return signature_V128V128_V128, nil
+			default:
+				return nil, fmt.Errorf("unsupported vector instruction in wazeroir: 0x%x", relaxedOp)
+			}
+		}
 		switch vecOp := c.body[c.pc+1]; vecOp {
 		case wasm.OpcodeVecV128Const:
 			return signature_None_V128, nil
@@ -613,3 +636,55 @@ func wasmValueTypeToUnsignedType(vt wasm.ValueType) []UnsignedType {
 	}
 	panic("unreachable")
 }
+
+// memory64Signature returns the signature of a memory instruction in a 64-bit
+// memory (memory64), where addresses, sizes and page counts are i64 instead of
+// i32. Signatures of other instructions are returned unchanged.
+func (c *compiler) memory64Signature(op wasm.Opcode, s *signature) *signature {
+	switch op {
+	case wasm.OpcodeI32Load, wasm.OpcodeI64Load, wasm.OpcodeF32Load, wasm.OpcodeF64Load,
+		wasm.OpcodeI32Load8S, wasm.OpcodeI32Load8U, wasm.OpcodeI32Load16S, wasm.OpcodeI32Load16U,
+		wasm.OpcodeI64Load8S, wasm.OpcodeI64Load8U, wasm.OpcodeI64Load16S, wasm.OpcodeI64Load16U,
+		wasm.OpcodeI64Load32S, wasm.OpcodeI64Load32U,
+		wasm.OpcodeI32Store, wasm.OpcodeI64Store, wasm.OpcodeF32Store, wasm.OpcodeF64Store,
+		wasm.OpcodeI32Store8, wasm.OpcodeI32Store16, wasm.OpcodeI64Store8, wasm.OpcodeI64Store16,
+		wasm.OpcodeI64Store32:
+		return withI64Address(s)
+	case wasm.OpcodeMemorySize:
+		return signature_None_I64
+	case wasm.OpcodeMemoryGrow:
+		return signature_I64_I64
+	case wasm.OpcodeMiscPrefix:
+		switch c.body[c.pc+1] {
+		case wasm.OpcodeMiscMemoryInit:
+			return &signature{in: []UnsignedType{UnsignedTypeI64, UnsignedTypeI32, UnsignedTypeI32}}
+		case wasm.OpcodeMiscMemoryCopy:
+			return &signature{in: []UnsignedType{UnsignedTypeI64, UnsignedTypeI64, UnsignedTypeI64}}
+		case wasm.OpcodeMiscMemoryFill:
+			return &signature{in: []UnsignedType{UnsignedTypeI64, UnsignedTypeI32, UnsignedTypeI64}}
+		}
+	case wasm.OpcodeVecPrefix:
+		switch c.body[c.pc+1] {
+		case wasm.OpcodeVecV128Load, wasm.OpcodeVecV128Load8x8s, wasm.OpcodeVecV128Load8x8u,
+			wasm.OpcodeVecV128Load16x4s, wasm.OpcodeVecV128Load16x4u, wasm.OpcodeVecV128Load32x2s,
+			wasm.OpcodeVecV128Load32x2u, wasm.OpcodeVecV128Load8Splat, wasm.OpcodeVecV128Load16Splat,
+			wasm.OpcodeVecV128Load32Splat, wasm.OpcodeVecV128Load64Splat, wasm.OpcodeVecV128Load32zero,
+			wasm.OpcodeVecV128Load64zero,
+			wasm.OpcodeVecV128Load8Lane, wasm.OpcodeVecV128Load16Lane,
+			wasm.OpcodeVecV128Load32Lane, wasm.OpcodeVecV128Load64Lane,
+			wasm.OpcodeVecV128Store, wasm.OpcodeVecV128Store8Lane, wasm.OpcodeVecV128Store16Lane,
+			wasm.OpcodeVecV128Store32Lane, wasm.OpcodeVecV128Store64Lane:
+			return withI64Address(s)
+		}
+	}
+	return s
+}
+
+// withI64Address returns a copy of the signature where the first input, the
+// memory address, is i64.
+func withI64Address(s *signature) *signature {
+	in := make([]UnsignedType, len(s.in))
+	copy(in, s.in)
+	in[0] = UnsignedTypeI64
+	return &signature{in: in, out: s.out}
+}
@@ -2,6 +2,7 @@ package wazero
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/tetratelabs/wazero/api"
@@ -15,6 +16,19 @@ type Namespace interface {
 	// Module returns exports from an instantiated module in this namespace or nil if there aren't any.
 	Module(moduleName string) api.Module
 
+	// Modules returns the modules instantiated in this namespace and not yet
+	// closed, in instantiation order.
+	//
+	// This example closes modules before the ones they may import:
+	//	modules := n.Modules()
+	//	for i := len(modules) - 1; i >= 0; i-- {
+	//		_ = modules[i].Close(ctx)
+	//	}
+	//
+	// Note: The result is a snapshot, so it is safe to close modules while
+	// iterating it.
+	Modules() []api.Module
+
 	// InstantiateModule instantiates the module namespace or errs if the configuration was invalid.
 	//
 	// Here's an example:
@@ -24,6 +38,9 @@ type Namespace interface {
 	//   - The module name is already in use.
 	//   - The module has a table element initializer that resolves to an index outside the Table minimum size.
 	//   - The module has a start function, and it failed to execute.
+	//
+	// Note: The start function is interrupted when ctx is done, e.g. on its deadline. Use errors.Is on the error to
+	// check for context.DeadlineExceeded.
 	InstantiateModule(ctx context.Context, compiled CompiledModule, config ModuleConfig) (api.Module, error)
 
 	// CloseWithExitCode closes all modules initialized in this Namespace with the provided exit code.
@@ -50,8 +67,9 @@ type Namespace interface {
 
 // namespace allows decoupling of public interfaces from internal representation.
 type namespace struct {
-	store *wasm.Store
-	ns    *wasm.Namespace
+	store         *wasm.Store
+	ns            *wasm.Namespace
+	isInterpreter bool
 }
 
 // Module implements Namespace.Module.
@@ -59,6 +77,11 @@ func (ns *namespace) Module(moduleName string) api.Module {
 	return ns.ns.Module(moduleName)
 }
 
+// Modules implements Namespace.Modules.
+func (ns *namespace) Modules() []api.Module {
+	return ns.ns.Modules()
+}
+
 // InstantiateModule implements Namespace.InstantiateModule
 func (ns *namespace) InstantiateModule(
 	ctx context.Context,
@@ -68,6 +91,11 @@ func (ns *namespace) InstantiateModule(
 	code := compiled.(*compiledModule)
 	config := mConfig.(*moduleConfig)
 
+	if config.instructionBudget > 0 && !ns.isInterpreter {
+		err = errors.New("module config includes an instruction budget, which is only supported in the interpreter")
+		return
+	}
+
 	var sysCtx *internalsys.Context
 	if sysCtx, err = config.toSysContext(); err != nil {
 		return
@@ -78,8 +106,17 @@ func (ns *namespace) InstantiateModule(
 		name = code.module.NameSection.ModuleName
 	}
 
+	var limits *wasm.InstanceLimits
+	if config.instructionBudget > 0 || len(config.functionTimeouts) > 0 {
+		limits = &wasm.InstanceLimits{FunctionTimeouts: config.functionTimeouts}
+		if config.instructionBudget > 0 {
+			budget := config.instructionBudget
+			limits.InstructionBudget = &budget
+		}
+	}
+
 	// Instantiate the module in the appropriate namespace.
-	mod, err = ns.store.Instantiate(ctx, ns.ns, code.module, name, sysCtx, code.listeners)
+	mod, err = ns.store.Instantiate(ctx, ns.ns, code.module, name, sysCtx, code.listeners, limits)
 	if err != nil {
 		// If there was an error, don't leak the compiled module.
 		if code.closeWithModule {
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"testing"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
@@ -59,3 +60,33 @@ func TestRuntime_Namespace(t *testing.T) {
 	require.Nil(t, r.Module("env"))
 	require.Nil(t, ns1.Module("env"))
 }
+
+func TestRuntime_Modules(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	compiled, err := r.NewHostModuleBuilder("env").Compile(testCtx)
+	require.NoError(t, err)
+
+	require.Equal(t, []api.Module{}, r.Modules())
+
+	var expected []api.Module
+	for _, name := range []string{"c", "a", "b"} {
+		m, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName(name))
+		require.NoError(t, err)
+		expected = append(expected, m)
+	}
+	require.Equal(t, expected, r.Modules())
+
+	// Modules in other namespaces aren't included.
+	_, err = r.NewNamespace(testCtx).InstantiateModule(testCtx, compiled, NewModuleConfig())
+	require.NoError(t, err)
+	require.Equal(t, expected, r.Modules())
+
+	// Closing while iterating doesn't affect the snapshot.
+	modules := r.Modules()
+	for i := len(modules) - 1; i >= 0; i-- {
+		require.NoError(t, modules[i].Close(testCtx))
+		require.Equal(t, expected[:i], r.Modules())
+	}
+}
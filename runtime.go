@@ -4,12 +4,20 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	goruntime "runtime"
+	"strings"
+	"sync"
 
 	"github.com/tetratelabs/wazero/api"
 	experimentalapi "github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/engine/compiler"
+	"github.com/tetratelabs/wazero/internal/engine/interpreter"
 	"github.com/tetratelabs/wazero/internal/version"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
+	"github.com/tetratelabs/wazero/internal/wazeroir"
 )
 
 // Runtime allows embedding of WebAssembly modules.
@@ -21,6 +29,16 @@ import (
 //	defer r.Close(ctx) // This closes everything this Runtime created.
 //
 //	module, _ := r.InstantiateModuleFromBinary(ctx, wasm)
+//
+// # Concurrency
+//
+// A Runtime is safe to use from multiple goroutines: modules can be compiled
+// and instantiated concurrently, as can host modules be built. Each
+// api.Function is not, so a goroutine that calls an exported function
+// concurrently with others should look up its own via Module
+// ExportedFunction. Module names are unique per Namespace, so concurrent
+// instantiations of the same CompiledModule need distinct names, e.g. via
+// ModuleConfig.WithName.
 type Runtime interface {
 	// NewHostModuleBuilder lets you create modules out of functions defined in Go.
 	//
@@ -50,6 +68,32 @@ type Runtime interface {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#name-section%E2%91%A0
 	CompileModule(ctx context.Context, binary []byte) (CompiledModule, error)
 
+	// CompileModules is like CompileModule, except it compiles the binaries
+	// concurrently, using up to runtime.GOMAXPROCS(0) goroutines. This reduces
+	// the time to warm up many modules at startup on multi-core machines.
+	//
+	// The result has the same length and order as binaries. When any binary
+	// fails to compile, its entry is nil and the error is a
+	// *CompileModulesError holding the cause of each failure. Other modules
+	// are still compiled and returned.
+	//
+	// Once ctx is done, binaries not yet compiled fail with ctx.Err().
+	CompileModules(ctx context.Context, binaries [][]byte) ([]CompiledModule, error)
+
+	// CompileModuleAt is like CompileModule, except it reads the binary of
+	// the given size from an io.ReaderAt, such as an os.File, one section at
+	// a time. This avoids reading a large binary into a []byte first.
+	//
+	// Decoding errors are sys.DecodeError, which include the offset in the
+	// binary where they occurred. An error is returned if size is negative,
+	// or larger than the binary when that is known, e.g. for an
+	// io.SectionReader.
+	//
+	// Note: This doesn't reduce peak memory compared to CompileModule with
+	// the binary already in a []byte. Code and data are decoded eagerly and
+	// retained by the CompiledModule, and each section is read whole first.
+	CompileModuleAt(ctx context.Context, binary io.ReaderAt, size int64) (CompiledModule, error)
+
 	// InstantiateModuleFromBinary instantiates a module from the WebAssembly binary (%.wasm) or errs if invalid.
 	//
 	// Here's an example:
@@ -130,14 +174,48 @@ func NewRuntimeWithConfig(ctx context.Context, rConfig RuntimeConfig) Runtime {
 		ctx = context.WithValue(ctx, version.WazeroVersionKey{}, wazeroVersion)
 	}
 	config := rConfig.(*runtimeConfig)
+	if config.interpreterStackSize > 0 {
+		ctx = context.WithValue(ctx, interpreter.InitialStackSizeKey{}, config.interpreterStackSize)
+	}
+	if config.deterministicFloats {
+		ctx = context.WithValue(ctx, interpreter.DeterministicFloatsKey{}, true)
+	}
+	if config.wrappingDivision {
+		ctx = context.WithValue(ctx, wazeroir.WrappingDivisionKey{}, true)
+	}
+	if config.verboseTraces {
+		ctx = context.WithValue(ctx, interpreter.VerboseTracesKey{}, true)
+	}
+	if config.maxCompiledCodeBytes > 0 {
+		ctx = context.WithValue(ctx, compiler.MaxCodeBytesKey{}, config.maxCompiledCodeBytes)
+	}
 	store, ns := wasm.NewStore(config.enabledFeatures, config.newEngine(ctx, config.enabledFeatures))
+	store.MaxInstances = config.maxInstances
+	store.MemoryGrowDeniedHook = config.memoryGrowDeniedHook
+	store.UnresolvedImportStubs = config.unresolvedImportStub
+	var decodeBuffers *sync.Pool
+	if config.decodeBufferPool {
+		decodeBuffers = &sync.Pool{New: func() interface{} { return binaryformat.NewDecodeBuffers() }}
+	}
+	var unimplementedFeatures api.CoreFeatures
+	if config.strictFeatures {
+		unimplementedFeatures = config.enabledFeatures &^ config.implementedFeatures
+	}
 	return &runtime{
 		store:                 store,
-		ns:                    &namespace{store: store, ns: ns},
+		ns:                    &namespace{store: store, ns: ns, isInterpreter: config.isInterpreter},
 		enabledFeatures:       config.enabledFeatures,
 		memoryLimitPages:      config.memoryLimitPages,
 		memoryCapacityFromMax: config.memoryCapacityFromMax,
+		stripNames:            config.stripNames,
+		floatsDisabled:        config.floatsDisabled,
+		rejectNondeterminism:  config.rejectNondeterminism,
+		copyOnWriteMemory:     config.copyOnWriteMemory,
+		strictHostResults:     config.strictHostResults,
+		decodeBuffers:         decodeBuffers,
 		isInterpreter:         config.isInterpreter,
+		unimplementedFeatures: unimplementedFeatures,
+		validationLimits:      config.validationLimits,
 	}
 }
 
@@ -148,13 +226,31 @@ type runtime struct {
 	enabledFeatures       api.CoreFeatures
 	memoryLimitPages      uint32
 	memoryCapacityFromMax bool
+	stripNames            bool
+	floatsDisabled        bool
+	rejectNondeterminism  bool
+	copyOnWriteMemory     bool
+	strictHostResults     bool
 	isInterpreter         bool
-	compiledModules       []*compiledModule
+	// unimplementedFeatures are enabled, but not implemented by the engine.
+	// This is only set when RuntimeConfig.WithStrictFeatures.
+	unimplementedFeatures api.CoreFeatures
+	// validationLimits are nil unless RuntimeConfig.WithValidationLimits.
+	validationLimits *wasm.ValidationLimits
+
+	// decodeBuffers pools *binaryformat.DecodeBuffers when non-nil. A pool
+	// gives each concurrent CompileModule its own.
+	decodeBuffers *sync.Pool
+
+	// compiledModulesMux guards compiledModules, as modules can be compiled
+	// concurrently.
+	compiledModulesMux sync.Mutex
+	compiledModules    []*compiledModule
 }
 
 // NewNamespace implements Runtime.NewNamespace.
 func (r *runtime) NewNamespace(ctx context.Context) Namespace {
-	return &namespace{store: r.store, ns: r.store.NewNamespace(ctx)}
+	return &namespace{store: r.store, ns: r.store.NewNamespace(ctx), isInterpreter: r.isInterpreter}
 }
 
 // Module implements Namespace.Module embedded by Runtime.
@@ -162,6 +258,11 @@ func (r *runtime) Module(moduleName string) api.Module {
 	return r.ns.Module(moduleName)
 }
 
+// Modules implements Namespace.Modules embedded by Runtime.
+func (r *runtime) Modules() []api.Module {
+	return r.ns.Modules()
+}
+
 // CompileModule implements Runtime.CompileModule
 func (r *runtime) CompileModule(ctx context.Context, binary []byte) (CompiledModule, error) {
 	if binary == nil {
@@ -172,21 +273,79 @@ func (r *runtime) CompileModule(ctx context.Context, binary []byte) (CompiledMod
 		return nil, errors.New("invalid binary")
 	}
 
-	internal, err := binaryformat.DecodeModule(binary, r.enabledFeatures, r.memoryLimitPages, r.memoryCapacityFromMax)
+	var buffers *binaryformat.DecodeBuffers
+	if r.decodeBuffers != nil {
+		buffers = r.decodeBuffers.Get().(*binaryformat.DecodeBuffers)
+		defer r.decodeBuffers.Put(buffers)
+	}
+
+	internal, err := binaryformat.DecodeModuleWithBuffers(binary, r.enabledFeatures, r.memoryLimitPages, r.memoryCapacityFromMax, buffers)
 	if err != nil {
 		return nil, err
+	}
+	internal.AssignModuleID(binary)
+	return r.compileDecodedModule(ctx, internal)
+}
+
+// CompileModuleAt implements Runtime.CompileModuleAt
+func (r *runtime) CompileModuleAt(ctx context.Context, binary io.ReaderAt, size int64) (CompiledModule, error) {
+	if binary == nil {
+		return nil, errors.New("binary == nil")
+	}
+
+	internal, err := binaryformat.DecodeModuleAt(binary, size, r.enabledFeatures, r.memoryLimitPages, r.memoryCapacityFromMax)
+	if err != nil {
+		return nil, err
+	}
+	return r.compileDecodedModule(ctx, internal)
+}
+
+// compileDecodedModule validates and compiles a decoded module whose ID is
+// already assigned.
+func (r *runtime) compileDecodedModule(ctx context.Context, internal *wasm.Module) (CompiledModule, error) {
+	var err error
+	if r.unimplementedFeatures != 0 {
+		engine := "compiler"
+		if r.isInterpreter {
+			engine = "interpreter"
+		}
+		return nil, fmt.Errorf("features not implemented by the %s: %s", engine, r.unimplementedFeatures)
+	} else if err = internal.ValidateLimits(r.validationLimits); err != nil {
+		return nil, err
 	} else if err = internal.Validate(r.enabledFeatures); err != nil {
 		// TODO: decoders should validate before returning, as that allows
 		// them to err with the correct position in the wasm binary.
 		return nil, err
+	} else if !r.isInterpreter && usesMemory64(internal) {
+		return nil, errors.New("module has a 64-bit memory, which is only supported in the interpreter")
+	} else if !r.isInterpreter && r.enabledFeatures.IsEnabled(api.CoreFeatureRelaxedSIMD) && internal.UsesRelaxedSIMD() {
+		return nil, errors.New("module uses relaxed vector instructions, which are only supported in the interpreter")
+	} else if !r.isInterpreter && r.enabledFeatures.IsEnabled(api.CoreFeatureExceptionHandling) && internal.UsesExceptionHandling() {
+		return nil, errors.New("module uses exception handling instructions, which are only supported in the interpreter")
+	} else if r.floatsDisabled {
+		if err = internal.ValidateNoFloats(); err != nil {
+			return nil, err
+		}
+	}
+	if r.rejectNondeterminism {
+		if err = internal.ValidateDeterministic(); err != nil {
+			return nil, err
+		}
 	}
 
-	internal.AssignModuleID(binary)
+	// Strip names before building definitions, so they don't retain them.
+	if r.stripNames && internal.NameSection != nil {
+		internal.NameSection = &wasm.NameSection{ModuleName: internal.NameSection.ModuleName}
+	}
 
 	// Now that the module is validated, cache the function and memory definitions.
 	internal.BuildFunctionDefinitions()
 	internal.BuildMemoryDefinitions()
 
+	if r.copyOnWriteMemory {
+		internal.EnableCopyOnWriteMemory()
+	}
+
 	c := &compiledModule{module: internal, compiledEngine: r.store.Engine}
 
 	if c.listeners, err = buildListeners(ctx, r, internal); err != nil {
@@ -197,10 +356,102 @@ func (r *runtime) CompileModule(ctx context.Context, binary []byte) (CompiledMod
 		return nil, err
 	}
 
+	r.compiledModulesMux.Lock()
 	r.compiledModules = append(r.compiledModules, c)
+	r.compiledModulesMux.Unlock()
 	return c, nil
 }
 
+// CompileModules implements Runtime.CompileModules
+func (r *runtime) CompileModules(ctx context.Context, binaries [][]byte) ([]CompiledModule, error) {
+	ret := make([]CompiledModule, len(binaries))
+	errs := make([]error, len(binaries))
+
+	workers := goruntime.GOMAXPROCS(0)
+	if workers > len(binaries) {
+		workers = len(binaries)
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if errs[i] = ctx.Err(); errs[i] == nil {
+					ret[i], errs[i] = r.CompileModule(ctx, binaries[i])
+				}
+			}
+		}()
+	}
+	for i := range binaries {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return ret, &CompileModulesError{Errors: errs}
+		}
+	}
+	return ret, nil
+}
+
+// CompileModulesError is returned by Runtime.CompileModules when any binary
+// fails to compile.
+//
+// errors.Is and errors.As match if any of the Errors do.
+type CompileModulesError struct {
+	// Errors is index-correlated with the binaries, holding nil for each
+	// which compiled.
+	Errors []error
+}
+
+// Error implements the error interface, with a line per failed binary.
+func (e *CompileModulesError) Error() string {
+	var msgs []string
+	for i, err := range e.Errors {
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("binaries[%d]: %v", i, err))
+		}
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the non-nil Errors, for errors.Is and errors.As on Go 1.20+.
+func (e *CompileModulesError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Is implements errors.Is for Go versions before 1.20, which don't use the
+// result of Unwrap.
+func (e *CompileModulesError) Is(target error) bool {
+	for _, err := range e.Unwrap() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As is like Is, for errors.As.
+func (e *CompileModulesError) As(target interface{}) bool {
+	for _, err := range e.Unwrap() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 func buildListeners(ctx context.Context, r *runtime, internal *wasm.Module) ([]experimentalapi.FunctionListener, error) {
 	// Test to see if internal code are using an experimental feature.
 	fnlf := ctx.Value(experimentalapi.FunctionListenerFactoryKey{})
@@ -219,6 +470,19 @@ func buildListeners(ctx context.Context, r *runtime, internal *wasm.Module) ([]e
 	return listeners, nil
 }
 
+// usesMemory64 returns true if the module defines or imports a 64-bit memory.
+func usesMemory64(m *wasm.Module) bool {
+	if m.MemorySection != nil && m.MemorySection.Is64 {
+		return true
+	}
+	for _, i := range m.ImportSection {
+		if i.Type == wasm.ExternTypeMemory && i.DescMem.Is64 {
+			return true
+		}
+	}
+	return false
+}
+
 // InstantiateModuleFromBinary implements Runtime.InstantiateModuleFromBinary
 func (r *runtime) InstantiateModuleFromBinary(ctx context.Context, binary []byte) (api.Module, error) {
 	if compiled, err := r.CompileModule(ctx, binary); err != nil {
@@ -246,6 +510,8 @@ func (r *runtime) Close(ctx context.Context) error {
 // CloseWithExitCode implements Runtime.CloseWithExitCode
 func (r *runtime) CloseWithExitCode(ctx context.Context, exitCode uint32) error {
 	err := r.store.CloseWithExitCode(ctx, exitCode)
+	r.compiledModulesMux.Lock()
+	defer r.compiledModulesMux.Unlock()
 	for _, c := range r.compiledModules {
 		if e := c.Close(ctx); e != nil && err == nil {
 			err = e
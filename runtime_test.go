@@ -1,18 +1,31 @@
 package wazero
 
 import (
+	"bytes"
 	"context"
 	_ "embed"
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/platform"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/version"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -187,6 +200,187 @@ func TestRuntime_CompileModule_Errors(t *testing.T) {
 	}
 }
 
+func TestRuntime_CompileModule_DecodeError(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	bin := binaryformat.EncodeModule(&wasm.Module{MemorySection: &wasm.Memory{Min: 2, Cap: 2, Max: 70000, IsMaxEncoded: true}})
+	_, err := r.CompileModule(testCtx, bin)
+
+	var decodeErr *sys.DecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	require.Equal(t, wasm.SectionIDMemory, decodeErr.SectionID())
+	require.Equal(t, int64(len(bin)), decodeErr.Offset())
+}
+
+func TestRuntime_CompileModules(t *testing.T) {
+	var binaries [][]byte
+	for i := 0; i < 10; i++ {
+		binaries = append(binaries, binaryformat.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{}},
+			FunctionSection: []wasm.Index{0},
+			CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+			NameSection:     &wasm.NameSection{ModuleName: strconv.Itoa(i)},
+		}))
+	}
+
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	t.Run("ok", func(t *testing.T) {
+		compiled, err := r.CompileModules(testCtx, binaries)
+		require.NoError(t, err)
+		require.Equal(t, len(binaries), len(compiled))
+		for i, c := range compiled {
+			require.Equal(t, strconv.Itoa(i), c.Name())
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		compiled, err := r.CompileModules(testCtx, [][]byte{binaries[0], nil, binaries[2], {1, 2}})
+		require.EqualError(t, err, "binaries[1]: binary == nil\nbinaries[3]: invalid binary")
+		require.Equal(t, 4, len(compiled))
+		require.Equal(t, "0", compiled[0].Name())
+		require.Nil(t, compiled[1])
+		require.Equal(t, "2", compiled[2].Name())
+		require.Nil(t, compiled[3])
+
+		var cmErr *CompileModulesError
+		require.True(t, errors.As(err, &cmErr))
+		require.Equal(t, 4, len(cmErr.Errors))
+		require.NoError(t, cmErr.Errors[0])
+		require.EqualError(t, cmErr.Errors[1], "binary == nil")
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(testCtx)
+		cancel()
+
+		compiled, err := r.CompileModules(ctx, binaries[:2])
+		require.True(t, errors.Is(err, context.Canceled), err.Error())
+		require.Equal(t, []CompiledModule{nil, nil}, compiled)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		compiled, err := r.CompileModules(testCtx, nil)
+		require.NoError(t, err)
+		require.Zero(t, len(compiled))
+	})
+}
+
+func TestRuntime_CompileModuleAt(t *testing.T) {
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeI32Const, 42, wasm.OpcodeEnd}}},
+		ExportSection:   []*wasm.Export{{Name: "answer", Type: wasm.ExternTypeFunc, Index: 0}},
+		NameSection:     &wasm.NameSection{ModuleName: "at"},
+	})
+	path := filepath.Join(t.TempDir(), "at.wasm")
+	require.NoError(t, os.WriteFile(path, bin, 0o600))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModuleAt(testCtx, f, int64(len(bin)))
+	require.NoError(t, err)
+	require.Equal(t, "at", compiled.Name())
+
+	// The module ID is the same as when compiled from a []byte.
+	fromBytes, err := r.CompileModule(testCtx, bin)
+	require.NoError(t, err)
+	require.Equal(t, fromBytes.(*compiledModule).module.ID, compiled.(*compiledModule).module.ID)
+
+	mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+	require.NoError(t, err)
+	results, err := mod.ExportedFunction("answer").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := r.CompileModuleAt(testCtx, nil, 0)
+		require.EqualError(t, err, "binary == nil")
+
+		_, err = r.CompileModuleAt(testCtx, f, -1)
+		require.EqualError(t, err, "invalid size -1")
+
+		// The size claims more than the binary has, and its size is known.
+		_, err = r.CompileModuleAt(testCtx, bytes.NewReader(bin[:len(bin)-2]), int64(len(bin)))
+		require.EqualError(t, err, fmt.Sprintf("invalid size %d: larger than the binary (%d)", len(bin), len(bin)-2))
+
+		// The size claims more than the binary has, and its size is unknown.
+		_, err = r.CompileModuleAt(testCtx, struct{ io.ReaderAt }{bytes.NewReader(bin[:len(bin)-2])}, int64(len(bin)))
+		require.EqualError(t, err, "at offset 41: read section custom: unexpected EOF")
+	})
+}
+
+func TestRuntime_ExportedFunctionByIndex(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	i32 := wasm.ValueTypeI32
+	mod, err := r.InstantiateModuleFromBinary(testCtx, binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeI32Const, 42, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeI32Const, 0, wasm.OpcodeEnd}},
+		},
+		// Only the first function is exported, and its name is empty.
+		ExportSection: []*wasm.Export{{Name: "", Type: wasm.ExternTypeFunc, Index: 0}},
+	}))
+	require.NoError(t, err)
+
+	results, err := mod.ExportedFunctionByIndex(0).Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+
+	require.Nil(t, mod.ExportedFunctionByIndex(1)) // not exported
+	require.Nil(t, mod.ExportedFunctionByIndex(2)) // out of range
+}
+
+func TestRuntime_CompileModule_DecodeBufferPool(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	var binaries [][]byte
+	var localTypes []wasm.ValueType
+	for i := 0; i < 20; i++ {
+		localTypes = append(localTypes, i32)
+		binaries = append(binaries, binaryformat.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{i32}}},
+			FunctionSection: []wasm.Index{0, 0},
+			CodeSection: []*wasm.Code{
+				{LocalTypes: localTypes[:i], Body: append(append([]byte{wasm.OpcodeI32Const}, leb128.EncodeInt32(int32(i))...), wasm.OpcodeEnd)},
+				{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+			},
+			ExportSection: []*wasm.Export{{Name: "f", Type: api.ExternTypeFunc, Index: 1}},
+			NameSection:   &wasm.NameSection{ModuleName: strconv.Itoa(i)},
+		}))
+	}
+
+	r := NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithDecodeBufferPool())
+	defer r.Close(testCtx)
+
+	// Compile concurrently twice, to reuse buffers.
+	for n := 0; n < 2; n++ {
+		compiled, err := r.CompileModules(testCtx, binaries)
+		require.NoError(t, err)
+
+		for i, c := range compiled {
+			mod, err := r.InstantiateModule(testCtx, c, NewModuleConfig().WithName(""))
+			require.NoError(t, err)
+
+			results, err := mod.ExportedFunction("f").Call(testCtx)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{uint64(i)}, results)
+			require.NoError(t, mod.Close(testCtx))
+		}
+	}
+}
+
 // TestModule_Memory only covers a couple cases to avoid duplication of internal/wasm/runtime_test.go
 func TestModule_Memory(t *testing.T) {
 	tests := []struct {
@@ -231,6 +425,34 @@ func TestModule_Memory(t *testing.T) {
 	}
 }
 
+func TestModule_Memory_ImportedLimits(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	max := uint32(5)
+	_, err := r.InstantiateModuleFromBinary(testCtx, binaryformat.EncodeModule(&wasm.Module{
+		MemorySection: &wasm.Memory{Min: 2, Cap: 2, Max: max, IsMaxEncoded: true},
+		ExportSection: []*wasm.Export{{Name: "memory", Type: api.ExternTypeMemory}},
+		NameSection:   &wasm.NameSection{ModuleName: "provider"},
+	}))
+	require.NoError(t, err)
+
+	// The importing module declares looser limits than the provider.
+	importing, err := r.InstantiateModuleFromBinary(testCtx, binaryformat.EncodeModule(&wasm.Module{
+		ImportSection: []*wasm.Import{{
+			Module: "provider", Name: "memory", Type: api.ExternTypeMemory,
+			DescMem: &wasm.Memory{Min: 1, Max: wasm.MemoryLimitPages},
+		}},
+	}))
+	require.NoError(t, err)
+
+	mem := importing.Memory()
+	require.Equal(t, uint32(2), mem.MinPages(testCtx))
+	maxPages, ok := mem.MaxPages(testCtx)
+	require.True(t, ok)
+	require.Equal(t, max, maxPages)
+}
+
 // TestModule_Global only covers a couple cases to avoid duplication of internal/wasm/global_test.go
 func TestModule_Global(t *testing.T) {
 	globalVal := int64(100) // intentionally a value that differs in signed vs unsigned encoding
@@ -321,6 +543,76 @@ func TestModule_Global(t *testing.T) {
 	}
 }
 
+func TestModule_Initialize(t *testing.T) {
+	initializedGlobal := []*wasm.Global{
+		{
+			Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32, Mutable: true},
+			Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: leb128.EncodeInt32(0)},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		module      *wasm.Module
+		expectedErr string
+	}{
+		{
+			name:   "plain module",
+			module: &wasm.Module{},
+		},
+		{
+			name: "reactor",
+			module: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection: []*wasm.Code{{Body: []byte{
+					wasm.OpcodeI32Const, 1, wasm.OpcodeGlobalSet, 0, wasm.OpcodeEnd,
+				}}},
+				GlobalSection: initializedGlobal,
+				ExportSection: []*wasm.Export{
+					{Type: wasm.ExternTypeFunc, Name: "_initialize", Index: 0},
+					{Type: wasm.ExternTypeGlobal, Name: "initialized", Index: 0},
+				},
+			},
+		},
+		{
+			name: "reactor traps",
+			module: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeUnreachable, wasm.OpcodeEnd}}},
+				ExportSection:   []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "_initialize", Index: 0}},
+			},
+			expectedErr: `wasm error: unreachable
+wasm stack trace:
+	.$0()`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntime(testCtx)
+			defer r.Close(testCtx)
+
+			module, err := r.InstantiateModuleFromBinary(testCtx, binaryformat.EncodeModule(tc.module))
+			require.NoError(t, err)
+
+			err = module.Initialize(testCtx)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			if initialized := module.ExportedGlobal("initialized"); initialized != nil {
+				require.Equal(t, uint64(1), initialized.Get(testCtx))
+			}
+		})
+	}
+}
+
 func TestRuntime_InstantiateModule_UsesContext(t *testing.T) {
 	r := NewRuntime(testCtx)
 	defer r.Close(testCtx)
@@ -487,6 +779,1062 @@ func TestRuntime_InstantiateModule_ExitError(t *testing.T) {
 	require.Equal(t, err, sys.NewExitError("call-exit", 2))
 }
 
+type tenantKey struct{}
+
+func TestRuntime_InstantiateModule_WithContextValue(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	var tenants []interface{}
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context) {
+		tenants = append(tenants, ctx.Value(tenantKey{}))
+	}).Export("record").
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	one := uint32(1)
+	code, err := r.CompileModule(testCtx, binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		ImportSection:   []*wasm.Import{{Type: wasm.ExternTypeFunc, Module: "env", Name: "record", DescFunc: 0}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}}},
+		ExportSection:   []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "run", Index: 1}},
+		StartSection:    &one,
+	}))
+	require.NoError(t, err)
+
+	config := NewModuleConfig().WithContextValue(tenantKey{}, "ignored")
+	acme, err := r.InstantiateModule(testCtx, code, config.WithName("acme").WithContextValue(tenantKey{}, "acme"))
+	require.NoError(t, err)
+	other, err := r.InstantiateModule(testCtx, code, config.WithName("other").WithContextValue(tenantKey{}, "other"))
+	require.NoError(t, err)
+
+	// The start function of each instance saw its value.
+	require.Equal(t, []interface{}{"acme", "other"}, tenants)
+
+	tenants = nil
+	_, err = acme.ExportedFunction("run").Call(testCtx)
+	require.NoError(t, err)
+	_, err = other.ExportedFunction("run").Call(testCtx)
+	require.NoError(t, err)
+
+	// A value in the context of the call takes precedence.
+	_, err = acme.ExportedFunction("run").Call(context.WithValue(testCtx, tenantKey{}, "caller"))
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"acme", "other", "caller"}, tenants)
+}
+
+func TestRuntime_InstantiateModule_MaxInstances(t *testing.T) {
+	r := NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithMaxInstances(2))
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, binaryNamedZero)
+	require.NoError(t, err)
+
+	m1, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("1"))
+	require.NoError(t, err)
+
+	// Modules in other namespaces count towards the limit.
+	ns := r.NewNamespace(testCtx)
+	_, err = ns.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("2"))
+	require.NoError(t, err)
+
+	_, err = r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("3"))
+	require.EqualError(t, err, "module[3] exceeds max instances: 2")
+
+	// Closing a module frees its slot.
+	require.NoError(t, m1.Close(testCtx))
+	_, err = r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("3"))
+	require.NoError(t, err)
+
+	// Closing a namespace frees all of its slots.
+	require.NoError(t, ns.Close(testCtx))
+	_, err = r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("4"))
+	require.NoError(t, err)
+
+	t.Run("concurrent", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithMaxInstances(5))
+		defer r.Close(testCtx)
+
+		compiled, err := r.CompileModule(testCtx, binaryNamedZero)
+		require.NoError(t, err)
+
+		const goroutines = 20
+		var wg sync.WaitGroup
+		var succeeded int32
+		wg.Add(goroutines)
+		for i := 0; i < goroutines; i++ {
+			go func(name string) {
+				defer wg.Done()
+				if _, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName(name)); err == nil {
+					atomic.AddInt32(&succeeded, 1)
+				}
+			}(strconv.Itoa(i))
+		}
+		wg.Wait()
+		require.Equal(t, int32(5), succeeded)
+	})
+}
+
+func TestRuntime_InstantiateModule_InstructionBudget(t *testing.T) {
+	binary := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeNop, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{
+			{Type: api.ExternTypeFunc, Name: "nop", Index: 0},
+			{Type: api.ExternTypeFunc, Name: "call_nop", Index: 1},
+		},
+	})
+
+	t.Run("interpreter", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter())
+		defer r.Close(testCtx)
+
+		code, err := r.CompileModule(testCtx, binary)
+		require.NoError(t, err)
+
+		unlimited, err := r.InstantiateModule(testCtx, code, NewModuleConfig().WithName("unlimited"))
+		require.NoError(t, err)
+		_, ok := unlimited.InstructionBudget()
+		require.False(t, ok)
+
+		limited, err := r.InstantiateModule(testCtx, code, NewModuleConfig().WithName("limited").WithInstructionBudget(5))
+		require.NoError(t, err)
+		remaining, ok := limited.InstructionBudget()
+		require.True(t, ok)
+		require.Equal(t, uint64(5), remaining)
+
+		// nop compiles to one instruction: br .return
+		nop := limited.ExportedFunction("nop")
+		_, err = nop.Call(testCtx)
+		require.NoError(t, err)
+		remaining, _ = limited.InstructionBudget()
+		require.Equal(t, uint64(4), remaining)
+
+		// call_nop compiles to two instructions, call 0 and br .return, and
+		// is charged for the instruction of nop, too.
+		callNop := limited.ExportedFunction("call_nop")
+		_, err = callNop.Call(testCtx)
+		require.NoError(t, err)
+		remaining, _ = limited.InstructionBudget()
+		require.Equal(t, uint64(1), remaining)
+
+		// The budget is exhausted by call 0, so nop traps.
+		_, err = callNop.Call(testCtx)
+		require.True(t, errors.Is(err, wasmruntime.ErrRuntimeInstructionBudgetExhausted), err.Error())
+		remaining, _ = limited.InstructionBudget()
+		require.Zero(t, remaining)
+
+		// Subsequent calls also fail, but other instances are unaffected.
+		_, err = nop.Call(testCtx)
+		require.True(t, errors.Is(err, wasmruntime.ErrRuntimeInstructionBudgetExhausted), err.Error())
+		_, err = unlimited.ExportedFunction("nop").Call(testCtx)
+		require.NoError(t, err)
+	})
+
+	t.Run("interpreter start function", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter())
+		defer r.Close(testCtx)
+
+		// The budget is in place before the start function runs, so it can't
+		// loop forever.
+		start := uint32(0)
+		code, err := r.CompileModule(testCtx, binaryformat.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{}},
+			FunctionSection: []wasm.Index{0},
+			CodeSection: []*wasm.Code{{Body: []byte{
+				wasm.OpcodeLoop, 0x40, wasm.OpcodeBr, 0, wasm.OpcodeEnd, wasm.OpcodeEnd,
+			}}},
+			StartSection: &start,
+		}))
+		require.NoError(t, err)
+		_, err = r.InstantiateModule(testCtx, code, NewModuleConfig().WithInstructionBudget(100))
+		require.True(t, errors.Is(err, wasmruntime.ErrRuntimeInstructionBudgetExhausted), err)
+	})
+
+	if platform.CompilerSupported() {
+		t.Run("compiler", func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler())
+			defer r.Close(testCtx)
+
+			code, err := r.CompileModule(testCtx, binary)
+			require.NoError(t, err)
+
+			_, err = r.InstantiateModule(testCtx, code, NewModuleConfig().WithInstructionBudget(5))
+			require.EqualError(t, err, "module config includes an instruction budget, which is only supported in the interpreter")
+		})
+	}
+}
+
+func TestRuntime_MemoryGrowDeniedHook(t *testing.T) {
+	binary := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeMemoryGrow, 0, wasm.OpcodeEnd,
+		}}},
+		MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true},
+		ExportSection: []*wasm.Export{{Type: api.ExternTypeFunc, Name: "grow", Index: 0}},
+	})
+
+	var denials [][3]uint32
+	var denied api.Module
+	r := NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithMemoryGrowDeniedHook(
+		func(mod api.Module, requestedPages, currentPages, maxPages uint32) {
+			denied = mod
+			denials = append(denials, [3]uint32{requestedPages, currentPages, maxPages})
+		}))
+	defer r.Close(testCtx)
+
+	mod, err := r.InstantiateModuleFromBinary(testCtx, binary)
+	require.NoError(t, err)
+	grow := mod.ExportedFunction("grow")
+
+	// A successful grow doesn't invoke the hook.
+	results, err := grow.Call(testCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), uint32(results[0]))
+	require.Zero(t, len(denials))
+
+	// The instruction still returns -1 when denied.
+	results, err = grow.Call(testCtx, 3)
+	require.NoError(t, err)
+	require.Equal(t, int32(-1), int32(results[0]))
+	require.Equal(t, [][3]uint32{{3, 2, 2}}, denials)
+	require.Equal(t, mod, denied)
+
+	// api.Memory Grow also invokes the hook.
+	_, ok := mod.Memory().Grow(testCtx, 1)
+	require.False(t, ok)
+	require.Equal(t, [][3]uint32{{3, 2, 2}, {1, 2, 2}}, denials)
+}
+
+func TestRuntime_Memory64(t *testing.T) {
+	i64 := wasm.ValueTypeI64
+	binary := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i64, i64}},
+			{Params: []wasm.ValueType{i64}, Results: []wasm.ValueType{i64}},
+		},
+		FunctionSection: []wasm.Index{0, 1, 1, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // store(addr, v)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI64Store, 0x3, 0x0, wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // load(addr)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Load, 0x3, 0x0, wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // grow(delta)
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeMemoryGrow, 0, wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // load_far(addr), with a memarg offset of 1<<32
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Load, 0x3, 0x80, 0x80, 0x80, 0x80, 0x10, wasm.OpcodeEnd,
+			}},
+		},
+		MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true, Is64: true},
+		DataSection: []*wasm.DataSegment{{
+			OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI64Const, Data: []byte{8}},
+			Init:             []byte{1},
+		}},
+		ExportSection: []*wasm.Export{
+			{Type: api.ExternTypeFunc, Name: "store", Index: 0},
+			{Type: api.ExternTypeFunc, Name: "load", Index: 1},
+			{Type: api.ExternTypeFunc, Name: "grow", Index: 2},
+			{Type: api.ExternTypeFunc, Name: "load_far", Index: 3},
+			{Type: api.ExternTypeMemory, Name: "memory", Index: 0},
+		},
+	})
+	features := api.CoreFeaturesV2 | api.CoreFeatureMemory64
+
+	t.Run("disabled", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter())
+		defer r.Close(testCtx)
+
+		_, err := r.CompileModule(testCtx, binary)
+		require.EqualError(t, err, "section memory: 64-bit memory invalid as feature \"memory64\" is disabled")
+	})
+
+	t.Run("interpreter", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter().WithCoreFeatures(features))
+		defer r.Close(testCtx)
+
+		compiled, err := r.CompileModule(testCtx, binary)
+		require.NoError(t, err)
+		require.True(t, compiled.ExportedMemories()["memory"].Is64())
+
+		mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+		require.NoError(t, err)
+
+		// The data segment was applied at an i64 offset.
+		results, err := mod.ExportedFunction("load").Call(testCtx, 8)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), results[0])
+
+		_, err = mod.ExportedFunction("store").Call(testCtx, 16, math.MaxUint64)
+		require.NoError(t, err)
+		results, err = mod.ExportedFunction("load").Call(testCtx, 16)
+		require.NoError(t, err)
+		require.Equal(t, uint64(math.MaxUint64), results[0])
+
+		// Addresses over 32-bits are out of bounds, as memory is at most 4GiB.
+		_, err = mod.ExportedFunction("load").Call(testCtx, 1<<32)
+		require.True(t, errors.Is(err, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess), err.Error())
+		// So are memarg offsets, which are u64.
+		_, err = mod.ExportedFunction("load_far").Call(testCtx, 0)
+		require.True(t, errors.Is(err, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess), err.Error())
+
+		// memory.grow returns i64, including -1 when denied.
+		results, err = mod.ExportedFunction("grow").Call(testCtx, 1)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), results[0])
+		results, err = mod.ExportedFunction("grow").Call(testCtx, 1<<32)
+		require.NoError(t, err)
+		require.Equal(t, uint64(math.MaxUint64), results[0])
+	})
+
+	if platform.CompilerSupported() {
+		t.Run("compiler", func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler().WithCoreFeatures(features))
+			defer r.Close(testCtx)
+
+			_, err := r.CompileModule(testCtx, binary)
+			require.EqualError(t, err, "module has a 64-bit memory, which is only supported in the interpreter")
+		})
+	}
+}
+
+// TestRuntime_RelaxedSIMD tests the configuration of relaxed vector
+// instructions. Their results are tested in enginetest.
+func TestRuntime_RelaxedSIMD(t *testing.T) {
+	v128Const := func(lo, hi uint64) []byte {
+		ret := make([]byte, 18)
+		ret[0], ret[1] = wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Const
+		binary.LittleEndian.PutUint64(ret[2:], lo)
+		binary.LittleEndian.PutUint64(ret[10:], hi)
+		return ret
+	}
+
+	// a*a is 1 + 2^-11 + 2^-24, which rounds to 1 + 2^-11 as a float32.
+	a := uint64(0x3f800800) // 1 + 2^-12
+	c := uint64(0xbf801000) // -(1 + 2^-11)
+	twoI32 := func(v uint32) uint64 { return uint64(v) | uint64(v)<<32 }
+
+	var body []byte
+	body = append(body, v128Const(twoI32(uint32(a)), 0)...)
+	body = append(body, v128Const(twoI32(uint32(a)), 0)...)
+	body = append(body, v128Const(twoI32(uint32(c)), 0)...)
+	body = append(body, wasm.OpcodeVecPrefix)
+	body = append(body, leb128.EncodeUint32(wasm.OpcodeVecF32x4RelaxedMadd)...)
+	body = append(body, wasm.OpcodeEnd)
+
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeV128}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: body}},
+		ExportSection:   []*wasm.Export{{Type: api.ExternTypeFunc, Name: "madd", Index: 0}},
+	})
+	features := api.CoreFeaturesV2 | api.CoreFeatureRelaxedSIMD
+
+	t.Run("disabled", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter())
+		defer r.Close(testCtx)
+
+		_, err := r.CompileModule(testCtx, bin)
+		require.EqualError(t, err, "invalid function[0] export[\"madd\"]: f32x4.relaxed_madd invalid as feature \"relaxed-simd\" is disabled")
+	})
+
+	tests := []struct {
+		name         string
+		config       RuntimeConfig
+		expectedMadd uint64
+	}{
+		{
+			name:         "interpreter",
+			config:       NewRuntimeConfigInterpreter().WithCoreFeatures(features),
+			expectedMadd: twoI32(0x33800000), // 2^-24
+		},
+		{
+			name:         "interpreter deterministic floats",
+			config:       NewRuntimeConfigInterpreter().WithCoreFeatures(features).WithDeterministicFloats(),
+			expectedMadd: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, tc.config)
+			defer r.Close(testCtx)
+
+			mod, err := r.InstantiateModuleFromBinary(testCtx, bin)
+			require.NoError(t, err)
+
+			results, err := mod.ExportedFunction("madd").Call(testCtx)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{tc.expectedMadd, 0}, results)
+		})
+	}
+
+	if platform.CompilerSupported() {
+		t.Run("compiler", func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler().WithCoreFeatures(features))
+			defer r.Close(testCtx)
+
+			_, err := r.CompileModule(testCtx, bin)
+			require.EqualError(t, err, "module uses relaxed vector instructions, which are only supported in the interpreter")
+		})
+	}
+}
+
+func TestRuntime_ExceptionHandling(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	// run(x) throws x in a try, whose catch returns x + 1.
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32}},
+		},
+		FunctionSection: []wasm.Index{0},
+		TagSection:      []wasm.Index{1},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeTry, i32,
+			wasm.OpcodeLocalGet, 0,
+			wasm.OpcodeThrow, 0,
+			wasm.OpcodeCatch, 0,
+			wasm.OpcodeI32Const, 1,
+			wasm.OpcodeI32Add,
+			wasm.OpcodeEnd,
+			wasm.OpcodeEnd,
+		}}},
+		ExportSection: []*wasm.Export{{Type: api.ExternTypeFunc, Name: "run", Index: 0}},
+	})
+	features := api.CoreFeaturesV2 | api.CoreFeatureExceptionHandling
+
+	t.Run("disabled", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter())
+		defer r.Close(testCtx)
+
+		_, err := r.CompileModule(testCtx, bin)
+		require.EqualError(t, err, "tag section not supported as feature \"exception-handling\" is disabled")
+	})
+
+	t.Run("interpreter", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter().WithCoreFeatures(features))
+		defer r.Close(testCtx)
+
+		mod, err := r.InstantiateModuleFromBinary(testCtx, bin)
+		require.NoError(t, err)
+
+		results, err := mod.ExportedFunction("run").Call(testCtx, 41)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{42}, results)
+	})
+
+	if platform.CompilerSupported() {
+		t.Run("compiler", func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler().WithCoreFeatures(features))
+			defer r.Close(testCtx)
+
+			_, err := r.CompileModule(testCtx, bin)
+			require.EqualError(t, err, "module uses exception handling instructions, which are only supported in the interpreter")
+		})
+	}
+}
+
+func TestRuntime_CompileModule_FloatsDisabled(t *testing.T) {
+	r := NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithFloatsDisabled())
+	defer r.Close(testCtx)
+
+	t.Run("integer only", func(t *testing.T) {
+		_, err := r.CompileModule(testCtx, binaryformat.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+			FunctionSection: []wasm.Index{0},
+			CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}}},
+		}))
+		require.NoError(t, err)
+	})
+
+	t.Run("float instruction", func(t *testing.T) {
+		_, err := r.CompileModule(testCtx, binaryformat.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+			FunctionSection: []wasm.Index{0},
+			CodeSection: []*wasm.Code{{Body: []byte{
+				wasm.OpcodeF32Const, 0, 0, 0, 0,
+				wasm.OpcodeI32ReinterpretF32,
+				wasm.OpcodeEnd,
+			}}},
+			ExportSection: []*wasm.Export{{Name: "bits", Type: wasm.ExternTypeFunc, Index: 0}},
+		}))
+		require.EqualError(t, err, `floats disabled: function[0] export["bits"] uses f32.const`)
+	})
+}
+
+func TestRuntime_CompileModule_RejectNondeterminism(t *testing.T) {
+	r := NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithRejectNondeterminism())
+	defer r.Close(testCtx)
+
+	// module returns the bits of a float computed by body.
+	module := func(body ...byte) []byte {
+		return binaryformat.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+			FunctionSection: []wasm.Index{0},
+			CodeSection: []*wasm.Code{{Body: append(append([]byte{
+				wasm.OpcodeF32Const, 0, 0, 0xc0, 0x7f, // NaN
+			}, body...), wasm.OpcodeI32ReinterpretF32, wasm.OpcodeEnd)}},
+			ExportSection: []*wasm.Export{{Name: "bits", Type: wasm.ExternTypeFunc, Index: 0}},
+		})
+	}
+
+	t.Run("deterministic", func(t *testing.T) {
+		_, err := r.CompileModule(testCtx, module(wasm.OpcodeF32Neg))
+		require.NoError(t, err)
+	})
+
+	t.Run("nondeterministic", func(t *testing.T) {
+		_, err := r.CompileModule(testCtx, module(wasm.OpcodeF32Const, 0, 0, 0, 0, wasm.OpcodeF32Add))
+		require.EqualError(t, err, `nondeterminism rejected: function[0] export["bits"] uses f32.add`)
+	})
+}
+
+func TestRuntime_CopyOnWriteMemory(t *testing.T) {
+	binary := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}}, {}},
+		ImportSection:   []*wasm.Import{{Type: wasm.ExternTypeFunc, Module: "env", Name: "close", DescFunc: 1}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Store8, 0, 0,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{ // Like store, except the module is closed first.
+				wasm.OpcodeCall, 0,
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Store8, 0, 0,
+				wasm.OpcodeEnd,
+			}},
+		},
+		MemorySection: &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true},
+		DataSection: []*wasm.DataSegment{{
+			OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: leb128.EncodeInt32(4)},
+			Init:             []byte("wazero"),
+		}},
+		ExportSection: []*wasm.Export{
+			{Type: api.ExternTypeFunc, Name: "store", Index: 1},
+			{Type: api.ExternTypeFunc, Name: "close_and_store", Index: 2},
+			{Type: api.ExternTypeMemory, Name: "memory", Index: 0},
+		},
+	})
+	importerBin := binaryformat.EncodeModule(&wasm.Module{
+		ImportSection: []*wasm.Import{{Type: wasm.ExternTypeMemory, Module: "2", Name: "memory", DescMem: &wasm.Memory{Min: 1}}},
+		ExportSection: []*wasm.Export{{Type: api.ExternTypeMemory, Name: "memory", Index: 0}},
+	})
+
+	r := NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithCopyOnWriteMemory())
+	defer r.Close(testCtx)
+
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module) {
+		require.NoError(t, m.Close(ctx))
+	}).Export("close").
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, binary)
+	require.NoError(t, err)
+
+	mod1, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("1"))
+	require.NoError(t, err)
+	mod2, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("2"))
+	require.NoError(t, err)
+	mod3, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("3"))
+	require.NoError(t, err)
+
+	mem1, mem2, mem3 := mod1.ExportedMemory("memory"), mod2.ExportedMemory("memory"), mod3.ExportedMemory("memory")
+	buf, _ := mem1.Read(testCtx, 0, 12)
+	require.Equal(t, "\x00\x00\x00\x00wazero\x00\x00", string(buf))
+
+	// Guest writes are private to the instance.
+	_, err = mod1.ExportedFunction("store").Call(testCtx, 5)
+	require.NoError(t, err)
+	buf, _ = mem1.Read(testCtx, 4, 6)
+	require.Equal(t, "w\x01zero", string(buf))
+	buf, _ = mem2.Read(testCtx, 4, 6)
+	require.Equal(t, "wazero", string(buf))
+
+	// Growth beyond capacity keeps contents.
+	_, ok := mem1.Grow(testCtx, 1)
+	require.True(t, ok)
+	buf, _ = mem1.Read(testCtx, 4, 6)
+	require.Equal(t, "w\x01zero", string(buf))
+	_, err = mod1.ExportedFunction("store").Call(testCtx, 65536+1)
+	require.NoError(t, err)
+	b, _ := mem1.ReadByte(testCtx, 65536+1)
+	require.Equal(t, byte(1), b)
+
+	// Closing the compiled module doesn't affect existing instances.
+	require.NoError(t, compiled.Close(testCtx))
+	buf, _ = mem2.Read(testCtx, 4, 6)
+	require.Equal(t, "wazero", string(buf))
+
+	// A call which closes its module can still access memory until it
+	// returns. This memory grew onto the heap, so it stays readable.
+	_, err = mod1.ExportedFunction("close_and_store").Call(testCtx, 6)
+	require.Equal(t, sys.NewExitError("1", 0), err)
+	b, _ = mem1.ReadByte(testCtx, 6)
+	require.Equal(t, byte(1), b)
+
+	// Otherwise, the memory is unmapped once the call returns.
+	_, err = mod3.ExportedFunction("close_and_store").Call(testCtx, 6)
+	require.Equal(t, sys.NewExitError("3", 0), err)
+	_, ok = mem3.Read(testCtx, 4, 6)
+	require.False(t, ok)
+	_, err = mod3.ExportedFunction("store").Call(testCtx, 6)
+	require.Error(t, err)
+
+	// The memory stays mapped while a module importing it is open.
+	importer, err := r.InstantiateModuleFromBinary(testCtx, importerBin)
+	require.NoError(t, err)
+	require.NoError(t, mod2.Close(testCtx))
+	buf, _ = importer.ExportedMemory("memory").Read(testCtx, 4, 6)
+	require.Equal(t, "wazero", string(buf))
+	require.NoError(t, importer.Close(testCtx))
+	_, ok = mem2.Read(testCtx, 4, 6)
+	require.False(t, ok)
+}
+
+func TestRuntime_CompileModule_StripNames(t *testing.T) {
+	binary := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeUnreachable, wasm.OpcodeEnd}}},
+		ExportSection:   []*wasm.Export{{Type: api.ExternTypeFunc, Name: "run", Index: 0}},
+		NameSection: &wasm.NameSection{
+			ModuleName:    "test",
+			FunctionNames: wasm.NameMap{{Index: 0, Name: "crash"}},
+		},
+	})
+
+	tests := []struct {
+		name                            string
+		config                          RuntimeConfig
+		expectedName, expectedDebugName string
+	}{
+		{
+			name:              "default",
+			config:            NewRuntimeConfig(),
+			expectedName:      "crash",
+			expectedDebugName: "test.crash",
+		},
+		{
+			name:              "WithStripNames",
+			config:            NewRuntimeConfig().WithStripNames(),
+			expectedDebugName: "test.$0",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, tc.config)
+			defer r.Close(testCtx)
+
+			compiled, err := r.CompileModule(testCtx, binary)
+			require.NoError(t, err)
+			require.Equal(t, "test", compiled.Name())
+
+			def := compiled.ExportedFunctions()["run"]
+			require.Equal(t, tc.expectedName, def.Name())
+			require.Equal(t, tc.expectedDebugName, def.DebugName())
+
+			// The module name is still the default for the instance.
+			mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+			require.NoError(t, err)
+			require.Equal(t, "test", mod.Name())
+
+			// Stack traces use the same debug names.
+			_, err = mod.ExportedFunction("run").Call(testCtx)
+			require.Contains(t, err.Error(), tc.expectedDebugName+"()")
+		})
+	}
+}
+
+func TestRuntime_VerboseTraces(t *testing.T) {
+	i32, i64, f64 := api.ValueTypeI32, api.ValueTypeI64, api.ValueTypeF64
+	binary := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []api.ValueType{i32, i32}, Results: []api.ValueType{i32}},
+			{Params: []api.ValueType{i64}, Results: []api.ValueType{i32}},
+		},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{LocalTypes: []api.ValueType{i32}, Body: []byte{
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32DivU, wasm.OpcodeEnd,
+			}},
+			{LocalTypes: []api.ValueType{f64}, Body: []byte{
+				wasm.OpcodeF64Const, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f, // 1.5
+				wasm.OpcodeLocalSet, 1,
+				wasm.OpcodeI32Const, 1, wasm.OpcodeI32Const, 0, wasm.OpcodeCall, 0,
+				wasm.OpcodeEnd,
+			}},
+		},
+		ExportSection: []*wasm.Export{{Type: api.ExternTypeFunc, Name: "main", Index: 1}},
+		NameSection: &wasm.NameSection{
+			FunctionNames: wasm.NameMap{{Index: 0, Name: "div"}, {Index: 1, Name: "main"}},
+			LocalNames: wasm.IndirectNameMap{
+				{Index: 0, NameMap: wasm.NameMap{{Index: 0, Name: "x"}, {Index: 1, Name: "y"}, {Index: 2, Name: "result"}}},
+				{Index: 1, NameMap: wasm.NameMap{{Index: 0, Name: "a"}}},
+			},
+		},
+	})
+
+	tests := []struct {
+		name        string
+		config      RuntimeConfig
+		expectedErr string
+	}{
+		{
+			name:   "interpreter",
+			config: NewRuntimeConfigInterpreter(),
+			expectedErr: `wasm error: integer divide by zero
+wasm stack trace:
+	.div(i32,i32) i32
+	.main(i64) i32`,
+		},
+		{
+			name:   "interpreter WithVerboseTraces",
+			config: NewRuntimeConfigInterpreter().WithVerboseTraces(),
+			expectedErr: `wasm error: integer divide by zero
+wasm stack trace:
+	.div(i32,i32) i32
+		x=1, y=0, result=0
+	.main(i64) i32
+		a=-2, $1=1.5`,
+		},
+	}
+	if platform.CompilerSupported() {
+		tests = append(tests, struct {
+			name        string
+			config      RuntimeConfig
+			expectedErr string
+		}{
+			name:   "compiler WithVerboseTraces",
+			config: NewRuntimeConfigCompiler().WithVerboseTraces(),
+			expectedErr: `wasm error: integer divide by zero
+wasm stack trace:
+	.div(i32,i32) i32
+	.main(i64) i32`,
+		})
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, tc.config)
+			defer r.Close(testCtx)
+
+			mod, err := r.InstantiateModuleFromBinary(testCtx, binary)
+			require.NoError(t, err)
+
+			_, err = mod.ExportedFunction("main").Call(testCtx, api.EncodeI64(-2))
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestRuntime_StrictHostResults(t *testing.T) {
+	i32 := api.ValueTypeI32
+	binary := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []api.ValueType{i32}}},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "five", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeCall, 0, wasm.OpcodeI32Const, 0, wasm.OpcodeI32Add, wasm.OpcodeEnd,
+		}}},
+		ExportSection: []*wasm.Export{{Type: api.ExternTypeFunc, Name: "main", Index: 1}},
+	})
+
+	run := func(config RuntimeConfig) ([]uint64, error) {
+		r := NewRuntimeWithConfig(testCtx, config)
+		defer r.Close(testCtx)
+
+		_, err := r.NewHostModuleBuilder("env").NewFunctionBuilder().
+			WithGoFunction(api.GoFunc(func(ctx context.Context, stack []uint64) {
+				stack[0] = 1<<32 | 5 // doesn't fit in i32
+			}), []api.ValueType{}, []api.ValueType{i32}).
+			Export("five").Instantiate(testCtx, r)
+		require.NoError(t, err)
+
+		mod, err := r.InstantiateModuleFromBinary(testCtx, binary)
+		require.NoError(t, err)
+		return mod.ExportedFunction("main").Call(testCtx)
+	}
+
+	t.Run("truncates by default", func(t *testing.T) {
+		results, err := run(NewRuntimeConfig())
+		require.NoError(t, err)
+		require.Equal(t, []uint64{5}, results)
+	})
+
+	t.Run("WithStrictHostResults", func(t *testing.T) {
+		_, err := run(NewRuntimeConfig().WithStrictHostResults())
+		require.EqualError(t, err, `result[0] 0x100000005 overflows i32 (recovered by wazero)
+wasm stack trace:
+	env.five() i32
+	.$1() i32`)
+	})
+}
+
+func TestRuntime_MaxCompiledCodeBytes(t *testing.T) {
+	// Define a function which adds one to its param 1000 times.
+	body := []byte{wasm.OpcodeLocalGet, 0}
+	for i := 0; i < 1000; i++ {
+		body = append(body, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add)
+	}
+	body = append(body, wasm.OpcodeEnd)
+	i32 := api.ValueTypeI32
+	binary := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []api.ValueType{i32}, Results: []api.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: body}},
+	})
+
+	t.Run("interpreter ignores", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter().WithMaxCompiledCodeBytes(1024))
+		defer r.Close(testCtx)
+
+		_, err := r.CompileModule(testCtx, binary)
+		require.NoError(t, err)
+	})
+
+	if !platform.CompilerSupported() {
+		return
+	}
+
+	t.Run("compiler within limit", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler().WithMaxCompiledCodeBytes(1<<20))
+		defer r.Close(testCtx)
+
+		_, err := r.CompileModule(testCtx, binary)
+		require.NoError(t, err)
+	})
+
+	t.Run("compiler exceeds limit", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler().WithMaxCompiledCodeBytes(1024))
+		defer r.Close(testCtx)
+
+		_, err := r.CompileModule(testCtx, binary)
+		require.EqualError(t, err, "compiled code exceeds the max of 1024 bytes")
+	})
+
+	t.Run("compiler exceeds limit when cached without it", func(t *testing.T) {
+		ctx, err := experimental.WithCompilationCacheDirName(testCtx, t.TempDir())
+		require.NoError(t, err)
+
+		unlimited := NewRuntimeWithConfig(ctx, NewRuntimeConfigCompiler())
+		_, err = unlimited.CompileModule(ctx, binary)
+		require.NoError(t, err)
+		require.NoError(t, unlimited.Close(ctx))
+
+		r := NewRuntimeWithConfig(ctx, NewRuntimeConfigCompiler().WithMaxCompiledCodeBytes(1024))
+		defer r.Close(ctx)
+
+		_, err = r.CompileModule(ctx, binary)
+		require.EqualError(t, err, "compiled code exceeds the max of 1024 bytes")
+	})
+}
+
+func TestRuntime_StrictFeatures(t *testing.T) {
+	features := api.CoreFeaturesV2 | api.CoreFeatureMemory64 | api.CoreFeatureRelaxedSIMD
+	binary := binaryformat.EncodeModule(&wasm.Module{})
+
+	t.Run("interpreter implements all", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter().
+			WithCoreFeatures(features).WithStrictFeatures())
+		defer r.Close(testCtx)
+
+		_, err := r.CompileModule(testCtx, binary)
+		require.NoError(t, err)
+	})
+
+	if !platform.CompilerSupported() {
+		return
+	}
+
+	t.Run("compiler not strict", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler().WithCoreFeatures(features))
+		defer r.Close(testCtx)
+
+		_, err := r.CompileModule(testCtx, binary)
+		require.NoError(t, err)
+	})
+
+	t.Run("compiler strict", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler().
+			WithCoreFeatures(features).WithStrictFeatures())
+		defer r.Close(testCtx)
+
+		_, err := r.CompileModule(testCtx, binary)
+		require.EqualError(t, err, "features not implemented by the compiler: memory64|relaxed-simd")
+	})
+}
+
+func TestRuntime_UnresolvedImportStub(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	bin := binaryformat.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		ImportSection: []*wasm.Import{
+			{Type: wasm.ExternTypeFunc, Module: "env", Name: "present", DescFunc: 0},
+			{Type: wasm.ExternTypeFunc, Module: "env", Name: "missing", DescFunc: 0},
+			{Type: wasm.ExternTypeFunc, Module: "other", Name: "abort", DescFunc: 0},
+		},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 1, wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{
+			{Type: wasm.ExternTypeFunc, Name: "call_present", Index: 3},
+			{Type: wasm.ExternTypeFunc, Name: "call_missing", Index: 4},
+		},
+		NameSection: &wasm.NameSection{ModuleName: "main"},
+	})
+
+	t.Run("default fails", func(t *testing.T) {
+		r := NewRuntime(testCtx)
+		defer r.Close(testCtx)
+
+		_, err := r.NewHostModuleBuilder("env").
+			NewFunctionBuilder().WithFunc(func(x uint32) uint32 { return x + 1 }).Export("present").
+			Instantiate(testCtx, r)
+		require.NoError(t, err)
+
+		_, err = r.InstantiateModuleFromBinary(testCtx, bin)
+		require.EqualError(t, err, "module[other] not instantiated")
+	})
+
+	r := NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithUnresolvedImportStub())
+	defer r.Close(testCtx)
+
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func(x uint32) uint32 { return x + 1 }).Export("present").
+		Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, bin)
+	require.NoError(t, err)
+	engine := r.(*runtime).store.Engine
+	compiledCount := engine.CompiledModuleCount()
+
+	mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+	require.NoError(t, err)
+
+	// The stubs aren't visible in the namespace.
+	require.Nil(t, r.Module("other"))
+
+	results, err := mod.ExportedFunction("call_present").Call(testCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, results)
+
+	_, err = mod.ExportedFunction("call_missing").Call(testCtx, 1)
+	require.EqualError(t, err, `wasm error: unimplemented import: env.missing
+wasm stack trace:
+	env.missing(i32) i32
+	main.$4(i32) i32`)
+
+	// A stub module per import module name is shared by the instances.
+	require.Equal(t, compiledCount+2, engine.CompiledModuleCount())
+	mod2, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig().WithName("main2"))
+	require.NoError(t, err)
+	require.Equal(t, compiledCount+2, engine.CompiledModuleCount())
+
+	// Stubs are released with the last module using them.
+	require.NoError(t, mod.Close(testCtx))
+	require.Equal(t, compiledCount+2, engine.CompiledModuleCount())
+	require.NoError(t, mod2.Close(testCtx))
+	require.Equal(t, compiledCount, engine.CompiledModuleCount())
+}
+
+func TestRuntime_ValidationLimits(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	tests := []struct {
+		name        string
+		limits      ValidationLimits
+		module      *wasm.Module
+		expectedErr string
+	}{
+		{
+			name:   "MaxTypes",
+			limits: ValidationLimits{MaxTypes: 1},
+			module: &wasm.Module{
+				TypeSection: []*wasm.FunctionType{{}, {Params: []wasm.ValueType{i32}}},
+			},
+			expectedErr: "module has 2 types, over limit of 1",
+		},
+		{
+			name:   "MaxTableSize",
+			limits: ValidationLimits{MaxTableSize: 10},
+			module: &wasm.Module{
+				TableSection: []*wasm.Table{{Min: 11, Type: wasm.RefTypeFuncref}},
+			},
+			expectedErr: "table[0] min 11 over limit of 10",
+		},
+		{
+			name:   "MaxFunctionLocals",
+			limits: ValidationLimits{MaxFunctionLocals: 2},
+			module: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32, i32}}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{LocalTypes: []wasm.ValueType{i32}, Body: []byte{wasm.OpcodeEnd}}},
+				ExportSection:   []*wasm.Export{{Type: wasm.ExternTypeFunc, Index: 0, Name: "f"}},
+			},
+			expectedErr: `function[0] export["f"] has 3 locals, over limit of 2`,
+		},
+		{
+			name:   "MaxFunctionBodySize",
+			limits: ValidationLimits{MaxFunctionBodySize: 2},
+			module: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeNop, wasm.OpcodeNop, wasm.OpcodeEnd}}},
+			},
+			expectedErr: "function[0] body is 3 bytes, over limit of 2",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			binary := binaryformat.EncodeModule(tc.module)
+
+			// Limits are only enforced when configured.
+			r := NewRuntime(testCtx)
+			defer r.Close(testCtx)
+			_, err := r.CompileModule(testCtx, binary)
+			require.NoError(t, err)
+
+			r = NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithValidationLimits(tc.limits))
+			defer r.Close(testCtx)
+			_, err = r.CompileModule(testCtx, binary)
+			require.EqualError(t, err, tc.expectedErr)
+
+			// The default limits are much larger.
+			r = NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithValidationLimits(ValidationLimits{}))
+			defer r.Close(testCtx)
+			_, err = r.CompileModule(testCtx, binary)
+			require.NoError(t, err)
+		})
+	}
+}
+
 func TestRuntime_CloseWithExitCode(t *testing.T) {
 	bin := binaryformat.EncodeModule(&wasm.Module{
 		TypeSection:     []*wasm.FunctionType{{}},
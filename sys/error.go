@@ -54,3 +54,52 @@ func (e *ExitError) Is(err error) bool {
 	}
 	return false
 }
+
+// DecodeError is returned when compiling a malformed WebAssembly binary. It
+// includes where decoding failed, e.g. to highlight it in a hex view.
+//
+// Here's an example of how to get the offset:
+//
+//	_, err := r.CompileModule(ctx, wasm)
+//	var decodeErr *sys.DecodeError
+//	if errors.As(err, &decodeErr) {
+//		offset := decodeErr.Offset()
+//	--snip--
+type DecodeError struct {
+	sectionID byte
+	offset    int64
+	err       error
+}
+
+// NewDecodeError returns a DecodeError for err, which occurred at offset in
+// the binary while decoding the section with the given ID.
+//
+// Note: This is exported for wazero's decoders and isn't needed otherwise.
+func NewDecodeError(sectionID byte, offset int64, err error) *DecodeError {
+	return &DecodeError{sectionID: sectionID, offset: offset, err: err}
+}
+
+// SectionID is the ID of the section being decoded, e.g. 10 for the code
+// section. When the section ID itself was invalid, this is that ID. When the
+// magic number or version preceding sections was invalid, this is zero.
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#sections%E2%91%A0
+func (e *DecodeError) SectionID() byte {
+	return e.sectionID
+}
+
+// Offset is the position in the binary, in bytes, where decoding failed.
+func (e *DecodeError) Offset() int64 {
+	return e.offset
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the cause, e.g. to use errors.Is with an error the decoder
+// exports.
+func (e *DecodeError) Unwrap() error {
+	return e.err
+}